@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+	"k8s-installer/validate"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxDescriptionLength 变更说明的最大字符数
+const MaxDescriptionLength = 500
+
+// CheckDescription 去除变更说明首尾的空白，超过最大长度时返回422
+func CheckDescription(c *gin.Context, description *string) bool {
+	*description = strings.TrimSpace(*description)
+	v := &validate.Validator{}
+	v.MaxLength("description", *description, MaxDescriptionLength)
+	if err := v.Err(); err != nil {
+		ValidationFailed(c, err)
+		return false
+	}
+	return true
+}
+
+// WithDescription 在审计日志的命令后附加变更说明，说明为空时原样返回
+func WithDescription(command, description string) string {
+	if description == "" {
+		return command
+	}
+	return fmt.Sprintf("%s，说明: %s", command, description)
+}
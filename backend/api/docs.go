@@ -0,0 +1,33 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// docsUI 接口文档页面的静态资源，编译进程序，离线环境中也可以使用
+//
+//go:embed docsui
+var docsUI embed.FS
+
+// RegisterDocs 注册OpenAPI文档（/openapi.json）和接口文档页面（/docs）
+func RegisterDocs(r gin.IRouter, spec *Spec) {
+	files, err := fs.Sub(docsUI, "docsui")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.StripPrefix("/docs", http.FileServer(http.FS(files)))
+
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec.Document())
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, c.Request.URL.Path+"/")
+	})
+	r.GET("/docs/*filepath", func(c *gin.Context) {
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	})
+}
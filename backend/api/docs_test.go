@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterDocs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	spec := NewSpec("K8s Installer API", "1.0.0", "")
+	RegisterDocs(r, spec)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get("/docs"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/docs/" {
+		t.Fatalf("/docs: status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	if w := get("/openapi.json"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"openapi":"3.0.3"`) {
+		t.Fatalf("/openapi.json: status %d, body %s", w.Code, w.Body)
+	}
+
+	// 页面和静态资源都从程序内嵌的文件提供，不引用外部地址
+	for _, path := range []string{"/docs/", "/docs/docs.js", "/docs/docs.css"} {
+		w := get(path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", path, w.Code)
+		}
+		if body := w.Body.String(); strings.Contains(body, "http://") || strings.Contains(body, "https://") {
+			t.Errorf("%s references an external URL", path)
+		}
+	}
+	if w := get("/docs/missing.js"); w.Code != http.StatusNotFound {
+		t.Fatalf("/docs/missing.js: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,211 @@
+// 接口文档页面：读取../openapi.json，按标签列出接口、参数和数据结构，并可以直接发送请求
+(function () {
+  "use strict";
+
+  var methods = ["get", "post", "put", "patch", "delete"];
+  var spec;
+
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      if (key === "text") {
+        node.textContent = attrs[key];
+      } else {
+        node.setAttribute(key, attrs[key]);
+      }
+    });
+    (children || []).forEach(function (child) {
+      if (child) {
+        node.appendChild(child);
+      }
+    });
+    return node;
+  }
+
+  // resolve 展开$ref引用，depth限制自引用类型的展开层数
+  function resolve(schema, depth) {
+    if (!schema) {
+      return {};
+    }
+    if (schema.$ref) {
+      var name = schema.$ref.replace("#/components/schemas/", "");
+      if (depth > 4) {
+        return { $ref: name };
+      }
+      return resolve(spec.components.schemas[name], depth + 1);
+    }
+    if (schema.type === "array") {
+      return [resolve(schema.items, depth + 1)];
+    }
+    if (schema.type === "object" && schema.properties) {
+      var out = {};
+      Object.keys(schema.properties).forEach(function (key) {
+        out[key] = resolve(schema.properties[key], depth + 1);
+      });
+      return out;
+    }
+    if (schema.type === "object" && schema.additionalProperties) {
+      return { "<key>": resolve(schema.additionalProperties, depth + 1) };
+    }
+    return schema.format ? schema.type + " (" + schema.format + ")" : schema.type || "any";
+  }
+
+  // example 生成请求体示例
+  function example(schema, depth) {
+    if (!schema || depth > 4) {
+      return null;
+    }
+    if (schema.$ref) {
+      return example(spec.components.schemas[schema.$ref.replace("#/components/schemas/", "")], depth + 1);
+    }
+    switch (schema.type) {
+      case "object":
+        var out = {};
+        Object.keys(schema.properties || {}).forEach(function (key) {
+          out[key] = example(schema.properties[key], depth + 1);
+        });
+        return out;
+      case "array":
+        return [];
+      case "integer":
+      case "number":
+        return 0;
+      case "boolean":
+        return false;
+      case "string":
+        return "";
+    }
+    return null;
+  }
+
+  function pretty(value) {
+    return JSON.stringify(value, null, 2);
+  }
+
+  function operationView(path, method, op) {
+    var inputs = {};
+    var body = el("div", { class: "body" });
+    if (op.description) {
+      body.appendChild(el("p", { text: op.description }));
+    }
+
+    if (op.parameters && op.parameters.length) {
+      body.appendChild(el("h4", { text: "参数" }));
+      op.parameters.forEach(function (p) {
+        var input = el("input", { placeholder: p.description || "" });
+        inputs[p.in + ":" + p.name] = input;
+        body.appendChild(el("div", { class: "param" }, [
+          el("span", { text: p.name + " (" + p.in + (p.required ? ", 必填" : "") + ")" }),
+          input
+        ]));
+      });
+    }
+
+    var requestBody;
+    var content = op.requestBody && op.requestBody.content["application/json"];
+    if (content) {
+      body.appendChild(el("h4", { text: "请求体" }));
+      body.appendChild(el("pre", { text: pretty(resolve(content.schema, 0)) }));
+      requestBody = el("textarea");
+      requestBody.value = pretty(example(content.schema, 0));
+      body.appendChild(requestBody);
+    }
+
+    var ok = op.responses && op.responses["200"] && op.responses["200"].content;
+    if (ok && ok["application/json"] && ok["application/json"].schema) {
+      body.appendChild(el("h4", { text: "响应" }));
+      body.appendChild(el("pre", { text: pretty(resolve(ok["application/json"].schema, 0)) }));
+    }
+
+    var result = el("pre");
+    var button = el("button", { text: "发送请求" });
+    button.addEventListener("click", function () {
+      send(path, method, inputs, requestBody, result);
+    });
+    body.appendChild(button);
+    body.appendChild(result);
+
+    return el("details", { class: "op" }, [
+      el("summary", {}, [
+        el("span", { class: "method " + method, text: method.toUpperCase() }),
+        el("span", { class: "path", text: path }),
+        el("span", { class: "summary", text: op.summary || "" })
+      ]),
+      body
+    ]);
+  }
+
+  function send(path, method, inputs, requestBody, result) {
+    var query = [];
+    Object.keys(inputs).forEach(function (key) {
+      var value = inputs[key].value;
+      var name = key.slice(key.indexOf(":") + 1);
+      if (key.indexOf("path:") === 0) {
+        path = path.replace("{" + name + "}", encodeURIComponent(value));
+      } else if (value !== "") {
+        query.push(encodeURIComponent(name) + "=" + encodeURIComponent(value));
+      }
+    });
+    var url = ".." + path + (query.length ? "?" + query.join("&") : "");
+    var headers = { Accept: "application/json" };
+    var project = document.getElementById("project").value;
+    if (project) {
+      headers["X-Project-ID"] = project;
+    }
+    var init = { method: method.toUpperCase(), headers: headers };
+    if (requestBody) {
+      headers["Content-Type"] = "application/json";
+      init.body = requestBody.value;
+    }
+
+    result.textContent = "请求中…";
+    fetch(url, init).then(function (resp) {
+      return resp.text().then(function (text) {
+        try {
+          text = pretty(JSON.parse(text));
+        } catch (e) {
+          // 非JSON响应原样输出
+        }
+        result.textContent = resp.status + " " + resp.statusText + "\n\n" + text;
+      });
+    }).catch(function (err) {
+      result.textContent = String(err);
+    });
+  }
+
+  function render() {
+    document.title = spec.info.title;
+    document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+    document.getElementById("description").textContent = spec.info.description || "";
+
+    var groups = {};
+    Object.keys(spec.paths).sort().forEach(function (path) {
+      methods.forEach(function (method) {
+        var op = spec.paths[path][method];
+        if (!op) {
+          return;
+        }
+        var tag = (op.tags && op.tags[0]) || "other";
+        (groups[tag] = groups[tag] || []).push(operationView(path, method, op));
+      });
+    });
+
+    var main = document.getElementById("operations");
+    main.textContent = "";
+    Object.keys(groups).sort().forEach(function (tag) {
+      main.appendChild(el("h2", { text: tag }));
+      groups[tag].forEach(function (view) {
+        main.appendChild(view);
+      });
+    });
+  }
+
+  fetch("../openapi.json").then(function (resp) {
+    return resp.json();
+  }).then(function (doc) {
+    spec = doc;
+    render();
+  }).catch(function (err) {
+    document.getElementById("operations").textContent = "加载接口文档失败: " + err;
+  });
+})();
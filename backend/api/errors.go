@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"k8s-installer/errcode"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Lang 根据请求的Accept-Language选择错误信息语言
+func Lang(c *gin.Context) string {
+	return errcode.Language(c.GetHeader("Accept-Language"))
+}
+
+// ErrorResponse 生成错误响应：error为原始错误文本，code为错误码，message为按Accept-Language选择的中文或英文说明。
+// 无法从错误中识别错误码时按HTTP状态码选择
+func ErrorResponse(c *gin.Context, status int, err error) gin.H {
+	code := errcode.Of(err)
+	if code == "" {
+		code = statusCode(status)
+	}
+	return gin.H{
+		"error":   err.Error(),
+		"code":    code,
+		"message": errcode.Message(code, Lang(c)),
+	}
+}
+
+// Error 返回带错误码的错误响应，读取请求体超出BodyLimit的限制时返回413
+func Error(c *gin.Context, status int, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	c.JSON(status, ErrorResponse(c, status, err))
+}
+
+// statusCode HTTP状态码对应的默认错误码
+func statusCode(status int) errcode.Code {
+	switch status {
+	case http.StatusBadRequest:
+		return errcode.BadRequest
+	case http.StatusNotFound:
+		return errcode.NotFound
+	case http.StatusConflict:
+		return errcode.Conflict
+	case http.StatusTooManyRequests:
+		return errcode.RateLimited
+	case http.StatusRequestEntityTooLarge:
+		return errcode.TooLarge
+	case http.StatusUnprocessableEntity:
+		return errcode.Validation
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return errcode.Timeout
+	}
+	return errcode.Internal
+}
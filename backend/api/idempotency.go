@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"k8s-installer/idempotency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Idempotency 幂等键中间件：POST请求携带Idempotency-Key时，有效期内同一项目同一接口相同幂等键的重复请求返回首次请求的响应，
+// 不再执行处理函数。服务端错误（5xx）的响应不保存，可以使用相同的幂等键重试
+func Idempotency(store *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotency.Header)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			Error(c, http.StatusBadRequest, fmt.Errorf("%s must be at most %d characters", idempotency.Header, idempotency.MaxKeyLength))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			Error(c, http.StatusBadRequest, fmt.Errorf("failed to read request body: %v", err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		// 幂等键按项目和接口隔离，不同项目或接口使用相同的幂等键时不会重放其他请求的响应
+		scope := idempotency.Scope{ProjectID: ProjectID(c), Method: c.Request.Method, Path: c.Request.URL.Path}
+		hash := idempotency.RequestHash(c.Request.Method, c.Request.URL.Path, body)
+
+		saved, err := store.Begin(scope, key, hash)
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			Error(c, http.StatusConflict, err)
+			c.Abort()
+			return
+		case errors.Is(err, idempotency.ErrKeyReused):
+			Error(c, http.StatusUnprocessableEntity, err)
+			c.Abort()
+			return
+		case err != nil:
+			Error(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		case saved != nil:
+			c.Header(idempotency.ReplayedHeader, "true")
+			c.Data(saved.Status, saved.ContentType, saved.Body)
+			c.Abort()
+			return
+		}
+
+		// 处理函数panic或返回服务端错误时不保存响应
+		completed := false
+		defer func() {
+			if !completed {
+				store.Release(scope, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		if err := store.Complete(idempotency.Response{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: hash,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}); err != nil {
+			fmt.Printf("保存幂等键响应失败: %v\n", err)
+		}
+	}
+}
+
+// responseRecorder 写入响应的同时记录响应体
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,222 @@
+package kubeadm
+
+import (
+	"errors"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adoptClusterRequest 导入已有集群请求
+type adoptClusterRequest struct {
+	// Master 可以执行kubectl的master节点的SSH连接信息
+	Master node.SSHConfig `json:"master"`
+	// MemberCredentials 其他成员节点的SSH凭据（忽略IP），为空时使用master节点的凭据
+	MemberCredentials *node.SSHConfig `json:"memberCredentials,omitempty"`
+}
+
+// validate 检查master节点的连接信息和成员节点凭据
+func (r adoptClusterRequest) validate() error {
+	v := &validate.Validator{}
+	if v.Required("master.ip", r.Master.IP) {
+		v.IP("master.ip", r.Master.IP)
+	}
+	v.Port("master.port", r.Master.Port)
+	v.Required("master.username", r.Master.Username)
+	if !r.Master.HasCredentials() {
+		v.Add("master", "password, privateKey or a secret reference is required")
+	}
+	v.Merge("master", r.Master.ValidateSecretRefs())
+	if r.MemberCredentials != nil {
+		v.Port("memberCredentials.port", r.MemberCredentials.Port)
+		v.Required("memberCredentials.username", r.MemberCredentials.Username)
+		if !r.MemberCredentials.HasCredentials() {
+			v.Add("memberCredentials", "password, privateKey or a secret reference is required")
+		}
+		v.Merge("memberCredentials", r.MemberCredentials.ValidateSecretRefs())
+	}
+	return v.Err()
+}
+
+// adoptClusterResponse 导入结果，集群ID即master节点ID
+type adoptClusterResponse struct {
+	ClusterID    string                   `json:"clusterId"`
+	DeploymentID string                   `json:"deploymentId"`
+	Cluster      kubeadm.ClusterDiscovery `json:"cluster"`
+	Nodes        []node.View              `json:"nodes"`
+	Created      int                      `json:"created"`
+	Updated      int                      `json:"updated"`
+}
+
+// adoptCluster 导入不是由本工具创建的已有集群：读取集群版本、节点和CNI插件，
+// 将成员节点导入节点列表并标记为adopted，之后可以对其执行升级、备份、插件等集群级操作
+func (h *Handler) adoptCluster(c *gin.Context) {
+	var req adoptClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+	if req.Master.Port == 0 {
+		req.Master.Port = 22
+	}
+	credentials := req.Master
+	if req.MemberCredentials != nil {
+		credentials = *req.MemberCredentials
+		if credentials.Port == 0 {
+			credentials.Port = 22
+		}
+	}
+
+	discovery, err := kubeadm.DiscoverClusterRemote(kubeadm.SSHConfig{
+		Host:          req.Master.IP,
+		Port:          req.Master.Port,
+		Username:      req.Master.Username,
+		Password:      req.Master.Password,
+		PrivateKey:    req.Master.PrivateKey,
+		PasswordRef:   req.Master.PasswordRef,
+		PrivateKeyRef: req.Master.PrivateKeyRef,
+	})
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+
+	// 通过请求中的地址连接的master节点，地址不是节点的InternalIP时（如公网地址）使用第一个控制平面节点
+	masterIndex := -1
+	for i, n := range discovery.Nodes {
+		if n.IP == req.Master.IP {
+			masterIndex = i
+			break
+		}
+		if masterIndex < 0 && n.NodeType == node.NodeTypeMaster {
+			masterIndex = i
+		}
+	}
+	if masterIndex < 0 || discovery.Nodes[masterIndex].NodeType != node.NodeTypeMaster {
+		api.Error(c, http.StatusUnprocessableEntity, fmt.Errorf("%s is not a control plane node of the cluster", req.Master.IP))
+		return
+	}
+
+	existing, err := h.projectNodes(c).GetNodes()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := adoptClusterResponse{Cluster: *discovery, Nodes: []node.View{}}
+	var nodeIDs []string
+	for i, discovered := range discovery.Nodes {
+		sshConfig := credentials
+		sshConfig.IP = discovered.IP
+		if i == masterIndex {
+			sshConfig = req.Master
+		}
+		status := node.NodeStatusOffline
+		if discovered.Ready {
+			status = node.NodeStatusOnline
+		}
+		n := node.Node{
+			Name:             discovered.Name,
+			IP:               sshConfig.IP,
+			Port:             sshConfig.Port,
+			Username:         sshConfig.Username,
+			Password:         sshConfig.Password,
+			PrivateKey:       sshConfig.PrivateKey,
+			PasswordRef:      sshConfig.PasswordRef,
+			PrivateKeyRef:    sshConfig.PrivateKeyRef,
+			NodeType:         discovered.NodeType,
+			Status:           status,
+			ContainerRuntime: discovered.ContainerRuntime,
+			OS:               discovered.OS,
+			ProjectID:        api.ProjectID(c),
+		}
+
+		// 已在节点列表中的节点（相同IP和端口）只更新角色和状态，保留原有凭据
+		var saved *node.Node
+		if match := findNodeByAddress(existing, n.IP, n.Port); match != nil {
+			match.NodeType = n.NodeType
+			match.Status = n.Status
+			if match.OS == "" || match.OS == "unknown" {
+				match.OS = n.OS
+			}
+			saved, err = h.nodeManager.UpdateNode(match.ID, *match)
+			response.Updated++
+		} else {
+			n.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+			saved, err = h.nodeManager.CreateNode(n)
+			response.Created++
+		}
+		if err != nil {
+			code := http.StatusInternalServerError
+			var dupErr *node.DuplicateNodeError
+			if errors.As(err, &dupErr) {
+				code = http.StatusConflict
+			}
+			api.Error(c, code, fmt.Errorf("failed to import node %s: %v", discovered.Name, err))
+			return
+		}
+		if err := h.nodeManager.SetNodeManaged(saved.ID, node.NodeManagedAdopted); err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+		saved.Managed = node.NodeManagedAdopted
+		if i == masterIndex {
+			response.ClusterID = saved.ID
+		}
+		nodeIDs = append(nodeIDs, saved.ID)
+		response.Nodes = append(response.Nodes, saved.View())
+	}
+
+	master := discovery.Nodes[masterIndex]
+	deployment, err := h.deploymentStore.RecordAdoptedCluster(kubeadm.Deployment{
+		KubeVersion:   strings.TrimPrefix(discovery.Version, "v"),
+		Arch:          master.Arch,
+		Distro:        master.OS,
+		InstallerType: discovery.InstallerType,
+		NodeIDs:       nodeIDs,
+	})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	response.DeploymentID = deployment.ID
+
+	h.nodeManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    response.ClusterID,
+		NodeName:  master.Name,
+		Operation: "AdoptCluster",
+		Command:   fmt.Sprintf("导入已有集群 %s", req.Master.IP),
+		Output: fmt.Sprintf("版本: %s，安装方式: %s，CNI: %s，节点: %d（新增%d，更新%d）",
+			discovery.Version, discovery.InstallerType, discovery.CNI, len(nodeIDs), response.Created, response.Updated),
+		Status:    "success",
+		Type:      log.TypeStep,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		JobID:     deployment.ID,
+	})
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// findNodeByAddress 按IP和SSH端口查找节点
+func findNodeByAddress(nodes []node.Node, ip string, port int) *node.Node {
+	for i := range nodes {
+		if nodes[i].IP == ip && nodes[i].Port == port {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cgroupCheckRequest 节点cgroup检查请求
+type cgroupCheckRequest struct {
+	NodeIDs     []string `json:"nodeIds"`
+	KubeVersion string   `json:"kubeVersion"`
+}
+
+// cgroupCheckResponse 节点cgroup检查结果，cgroupDriver为集群应使用的cgroup驱动
+type cgroupCheckResponse struct {
+	Passed       bool                   `json:"passed"`
+	CgroupDriver string                 `json:"cgroupDriver,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	Nodes        []kubeadm.CgroupReport `json:"nodes"`
+}
+
+// Validate 检查节点和版本号
+func (r cgroupCheckRequest) Validate() error {
+	v := &validate.Validator{}
+	if len(r.NodeIDs) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	if v.Required("kubeVersion", r.KubeVersion) {
+		v.Version("kubeVersion", r.KubeVersion)
+	}
+	return v.Err()
+}
+
+// checkCgroup 检测节点的cgroup版本和cgroup驱动，所选Kubernetes版本不支持或节点之间驱动不一致时检查不通过
+func (h *Handler) checkCgroup(c *gin.Context) {
+	var req cgroupCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	var nodes []node.Node
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node %s: %v", id, err),
+			})
+			return
+		}
+		nodes = append(nodes, *n)
+	}
+
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), nodes, kubeadm.DetectCgroupCmd, node.ExecOptions{Source: c.ClientIP()}, nil)
+	resp := cgroupCheckResponse{Passed: true, Nodes: make([]kubeadm.CgroupReport, 0, len(results))}
+	infos := make(map[string]kubeadm.CgroupInfo)
+	for i, res := range results {
+		report := kubeadm.BuildCgroupReport(nodes[i], res, req.KubeVersion)
+		if report.Passed {
+			infos[report.NodeID] = report.Info
+		}
+		resp.Passed = resp.Passed && report.Passed
+		resp.Nodes = append(resp.Nodes, report)
+	}
+	driver, err := kubeadm.ClusterCgroupDriver(infos)
+	if err != nil {
+		resp.Passed = false
+		resp.Error = err.Error()
+	}
+	resp.CgroupDriver = driver
+	c.JSON(http.StatusOK, resp)
+}
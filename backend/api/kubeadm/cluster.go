@@ -0,0 +1,1022 @@
+package kubeadm
+
+import (
+	"fmt"
+	"io"
+	"k8s-installer/api"
+	"k8s-installer/event"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// initClusterRequest 初始化master节点请求
+type initClusterRequest struct {
+	Config    kubeadm.KubeadmConfig `json:"config" binding:"required"`
+	SkipSteps []string              `json:"skipSteps" binding:"omitempty"`
+}
+
+// initCluster 初始化master节点
+func (h *Handler) initCluster(c *gin.Context) {
+	var req initClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if rejectUnknownSkipSteps(c, req.SkipSteps) {
+		return
+	}
+	if err := req.Config.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	// 获取所有节点，然后选择第一个主节点
+	allNodes, err := h.projectNodes(c).GetNodes()
+	if err != nil {
+		errorLog := fmt.Sprintf("调试信息: 获取所有节点失败: %v", err)
+		fmt.Println(errorLog)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get nodes: %v", err),
+		})
+		return
+	}
+
+	// 过滤出主节点
+	var masterNode *node.Node
+	for _, n := range allNodes {
+		if n.NodeType == "master" || n.NodeType == "Master" {
+			masterNode = &n
+			break
+		}
+	}
+
+	if masterNode == nil {
+		errorLog := "调试信息: 没有找到主节点，请先添加主节点并设置为主节点类型"
+		fmt.Println(errorLog)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "no master node found",
+		})
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "InitCluster", lock.NodeKey(masterNode.ID), lock.ClusterKey(masterNode.ID))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	// 记录找到的主节点信息
+	debugLog := fmt.Sprintf("调试信息: 成功找到主节点: ID=%s, Name=%s, IP=%s", masterNode.ID, masterNode.Name, masterNode.IP)
+	fmt.Println(debugLog)
+
+	// 添加详细的节点信息调试
+	nodeInfoLog := fmt.Sprintf("调试信息: 成功获取节点信息:\nID: %s\nName: %s\nIP: '%s' (长度: %d)\nPort: %d\nUsername: '%s'\nPassword: %s\nPrivateKey: %s\nNodeType: %s\nStatus: %s\nOS: %s",
+		masterNode.ID, masterNode.Name, masterNode.IP, len(masterNode.IP),
+		masterNode.Port, masterNode.Username, maskPassword(masterNode.Password),
+		maskPrivateKey(masterNode.PrivateKey), masterNode.NodeType, masterNode.Status, masterNode.OS)
+	fmt.Println(nodeInfoLog)
+	// 记录节点信息日志
+	h.nodeManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    masterNode.ID,
+		NodeName:  masterNode.Name,
+		Operation: "Debug",
+		Command:   "节点信息",
+		Output:    nodeInfoLog,
+		Status:    "success",
+		Level:     log.LevelDebug,
+		Type:      log.TypeSystem,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+
+	// 验证节点信息是否完整
+	if masterNode.IP == "" {
+		errorLog := "错误: 节点IP地址为空"
+		fmt.Println(errorLog)
+		// 记录错误日志
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    masterNode.ID,
+			NodeName:  masterNode.Name,
+			Operation: "Debug",
+			Command:   "验证节点信息",
+			Output:    errorLog,
+			Status:    "failed",
+			Level:     log.LevelError,
+			Type:      log.TypeSystem,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "master node IP address is empty",
+		})
+		return
+	}
+
+	if masterNode.Port == 0 {
+		warningLog := "警告: 节点端口为0，设置为默认值22"
+		fmt.Println(warningLog)
+		// 记录警告日志
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    masterNode.ID,
+			NodeName:  masterNode.Name,
+			Operation: "Debug",
+			Command:   "验证节点信息",
+			Output:    warningLog,
+			Status:    "warning",
+			Level:     log.LevelWarn,
+			Type:      log.TypeSystem,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		masterNode.Port = 22
+	}
+
+	if masterNode.Username == "" {
+		errorLog := "错误: 节点用户名为空"
+		fmt.Println(errorLog)
+		// 记录错误日志
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    masterNode.ID,
+			NodeName:  masterNode.Name,
+			Operation: "Debug",
+			Command:   "验证节点信息",
+			Output:    errorLog,
+			Status:    "failed",
+			Level:     log.LevelError,
+			Type:      log.TypeSystem,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "master node username is empty",
+		})
+		return
+	}
+
+	if !masterNode.HasCredentials() {
+		errorLog := "错误: 节点既没有密码也没有私钥"
+		fmt.Println(errorLog)
+		// 记录错误日志
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    masterNode.ID,
+			NodeName:  masterNode.Name,
+			Operation: "Debug",
+			Command:   "验证节点信息",
+			Output:    errorLog,
+			Status:    "failed",
+			Level:     log.LevelError,
+			Type:      log.TypeSystem,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "master node has neither password nor private key",
+		})
+		return
+	}
+
+	// 创建SSH配置，首先使用IP地址连接（确保在任何hosts文件更新之前都能连接）
+	sshConfig := kubeadm.SSHConfig{
+		Host:          masterNode.IP,
+		Port:          masterNode.Port,
+		Username:      masterNode.Username,
+		Password:      masterNode.Password,
+		PrivateKey:    masterNode.PrivateKey,
+		PasswordRef:   masterNode.PasswordRef,
+		PrivateKeyRef: masterNode.PrivateKeyRef,
+	}
+
+	// 添加SSH配置调试信息
+	sshConfigLog := fmt.Sprintf("调试信息: 最终的SSH配置:\nHost: %s\nPort: %d\nUsername: %s\nPassword: %s\nPrivateKey: %s",
+		sshConfig.Host, sshConfig.Port, sshConfig.Username,
+		maskPassword(sshConfig.Password), maskPrivateKey(sshConfig.PrivateKey))
+	fmt.Println(sshConfigLog)
+	// 记录SSH配置日志
+	h.nodeManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    masterNode.ID,
+		NodeName:  masterNode.Name,
+		Operation: "Debug",
+		Command:   "SSH配置",
+		Output:    sshConfigLog,
+		Status:    "success",
+		Level:     log.LevelDebug,
+		Type:      log.TypeSystem,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+
+	// 记录初始化开始日志
+	initLog := log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    masterNode.ID,
+		NodeName:  masterNode.Name,
+		Operation: "InitMaster",
+		Command:   "初始化Master节点",
+		Output:    "开始初始化Master节点...",
+		Status:    "running",
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	h.nodeManager.CreateLog(initLog)
+
+	fmt.Printf("开始初始化master节点: %s\n", masterNode.Name)
+	fmt.Printf("跳过的步骤: %s\n", strings.Join(req.SkipSteps, ", "))
+
+	result, err := kubeadm.InitMaster(sshConfig, req.Config, req.SkipSteps)
+	if err != nil {
+		// 记录初始化失败日志
+		initLog.Output = fmt.Sprintf("初始化失败: %v\n输出: %s", err, result)
+		initLog.Status = "failed"
+		initLog.UpdatedAt = time.Now()
+		h.nodeManager.CreateLog(initLog)
+
+		fmt.Printf("初始化master节点失败: %s\n错误: %v\n输出: %s\n", masterNode.Name, err, log.Redact(result))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 记录初始化成功日志
+	initLog.Output = fmt.Sprintf("初始化成功\n输出: %s", result)
+	initLog.Status = "success"
+	initLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(initLog)
+
+	fmt.Printf("初始化master节点成功: %s\n", masterNode.Name)
+
+	// 从输出中提取join命令并存储到数据库中
+	var joinCommand string
+	lines := strings.Split(result, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "kubeadm join") {
+			// 开始构建join命令，处理多行情况
+			var fullCommand []string
+			j := i
+			for j < len(lines) {
+				currentLine := strings.TrimSpace(lines[j])
+				// 检查是否以反斜杠结尾（表示命令换行）
+				if strings.HasSuffix(currentLine, "\\") {
+					// 移除反斜杠并添加到命令中
+					fullCommand = append(fullCommand, strings.TrimSuffix(currentLine, "\\"))
+					j++
+				} else {
+					// 这是命令的最后一行，添加到命令中并停止
+					fullCommand = append(fullCommand, currentLine)
+					break
+				}
+			}
+			// 合并所有行到一个完整的命令中
+			joinCommand = strings.TrimSpace(strings.Join(fullCommand, " "))
+			break
+		}
+	}
+
+	// 如果提取到join命令，将其存储到数据库中
+	if joinCommand != "" {
+		fmt.Println("已从初始化输出中提取join命令")
+		// 更新master节点的JoinCommand字段
+		masterNode.JoinCommand = joinCommand
+		_, err := h.nodeManager.UpdateNode(masterNode.ID, *masterNode)
+		if err != nil {
+			fmt.Printf("存储join命令到数据库失败: %v\n", err)
+		} else {
+			fmt.Println("join命令存储到数据库成功")
+		}
+	} else {
+		fmt.Println("未从输出中提取到join命令")
+		// 尝试直接获取join命令
+		sshConfig := kubeadm.SSHConfig{
+			Host:          masterNode.IP,
+			Port:          masterNode.Port,
+			Username:      masterNode.Username,
+			Password:      masterNode.Password,
+			PrivateKey:    masterNode.PrivateKey,
+			PasswordRef:   masterNode.PasswordRef,
+			PrivateKeyRef: masterNode.PrivateKeyRef,
+		}
+		joinCommand, err := kubeadm.GetJoinCommand(sshConfig)
+		if err == nil && joinCommand != "" {
+			fmt.Println("已直接获取join命令")
+			// 更新master节点的JoinCommand字段
+			masterNode.JoinCommand = joinCommand
+			_, err := h.nodeManager.UpdateNode(masterNode.ID, *masterNode)
+			if err != nil {
+				fmt.Printf("存储join命令到数据库失败: %v\n", err)
+			} else {
+				fmt.Println("join命令存储到数据库成功")
+			}
+		} else {
+			fmt.Printf("直接获取join命令失败: %v\n", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result":      result,
+		"joinCommand": joinCommand,
+	})
+}
+
+// pullImagesRequest 在master节点上拉取Kubernetes镜像请求
+type pullImagesRequest struct {
+	MasterNodeID string `json:"masterNodeId" binding:"required"`
+	Version      string `json:"version" binding:"required"`
+}
+
+// pullImages 拉取Kubernetes镜像到本地
+func (h *Handler) pullImages(c *gin.Context) {
+	var req pullImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 获取master节点信息
+	masterNode, err := h.projectNodes(c).GetNode(req.MasterNodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get master node: %v", err),
+		})
+		return
+	}
+
+	// 创建SSH配置，首先使用IP地址连接（确保在任何hosts文件更新之前都能连接）
+	sshConfig := kubeadm.SSHConfig{
+		Host:          masterNode.IP,
+		Port:          masterNode.Port,
+		Username:      masterNode.Username,
+		Password:      masterNode.Password,
+		PrivateKey:    masterNode.PrivateKey,
+		PasswordRef:   masterNode.PasswordRef,
+		PrivateKeyRef: masterNode.PrivateKeyRef,
+	}
+
+	// 记录镜像拉取开始日志
+	pullLog := log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    masterNode.ID,
+		NodeName:  masterNode.Name,
+		Operation: "PullKubernetesImages",
+		Command:   fmt.Sprintf("拉取Kubernetes镜像，版本: %s", req.Version),
+		Output:    "开始拉取Kubernetes镜像...",
+		Status:    "running",
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	h.nodeManager.CreateLog(pullLog)
+
+	fmt.Printf("开始拉取Kubernetes镜像，版本: %s\n", req.Version)
+
+	result, err := kubeadm.PullKubernetesImages(sshConfig, req.Version)
+	if err != nil {
+		// 记录镜像拉取失败日志
+		pullLog.Output = fmt.Sprintf("拉取失败: %v\n输出: %s", err, result)
+		pullLog.Status = "failed"
+		pullLog.UpdatedAt = time.Now()
+		h.nodeManager.CreateLog(pullLog)
+
+		fmt.Printf("拉取Kubernetes镜像失败\n版本: %s\n错误: %v\n输出: %s\n", req.Version, err, log.Redact(result))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 记录镜像拉取成功日志
+	pullLog.Output = fmt.Sprintf("拉取成功\n输出: %s", result)
+	pullLog.Status = "success"
+	pullLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(pullLog)
+
+	fmt.Printf("拉取Kubernetes镜像成功\n版本: %s\n输出: %s\n", req.Version, log.Redact(result))
+
+	c.JSON(http.StatusOK, gin.H{
+		"result": result,
+	})
+}
+
+// getJoinCommand 获取worker节点加入集群的命令
+func (h *Handler) getJoinCommand(c *gin.Context) {
+	// 获取所有节点，然后选择第一个主节点
+	allNodes, err := h.projectNodes(c).GetNodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get nodes: %v", err),
+		})
+		return
+	}
+
+	// 过滤出主节点
+	var masterNode *node.Node
+	for _, n := range allNodes {
+		if n.NodeType == "master" || n.NodeType == "Master" {
+			masterNode = &n
+			break
+		}
+	}
+
+	if masterNode == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "no master node found",
+		})
+		return
+	}
+
+	// 创建SSH配置，首先使用IP地址连接（确保在任何hosts文件更新之前都能连接）
+	sshConfig := kubeadm.SSHConfig{
+		Host:          masterNode.IP,
+		Port:          masterNode.Port,
+		Username:      masterNode.Username,
+		Password:      masterNode.Password,
+		PrivateKey:    masterNode.PrivateKey,
+		PasswordRef:   masterNode.PasswordRef,
+		PrivateKeyRef: masterNode.PrivateKeyRef,
+	}
+
+	// 存储的join命令中的令牌默认24小时过期，过期或不存在时重新生成
+	cmd, regenerated, err := kubeadm.EnsureJoinCommand(sshConfig, masterNode.JoinCommand)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 将重新生成的join命令存储到master节点的JoinCommand字段中
+	if regenerated {
+		masterNode.JoinCommand = cmd
+		_, err = h.nodeManager.UpdateNode(masterNode.ID, *masterNode)
+		if err != nil {
+			// 存储失败不影响返回结果，只记录错误
+			fmt.Printf("存储join命令到数据库失败: %v\n", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"command":     cmd,
+		"regenerated": regenerated,
+	})
+}
+
+// clusterMaster 获取集群的master节点及其SSH配置，集群ID即master节点ID
+func (h *Handler) clusterMaster(c *gin.Context, clusterID string) (*node.Node, kubeadm.SSHConfig, error) {
+	masterNode, err := h.projectNodes(c).GetNode(clusterID)
+	if err != nil {
+		return nil, kubeadm.SSHConfig{}, err
+	}
+	if masterNode.NodeType != node.NodeTypeMaster {
+		return nil, kubeadm.SSHConfig{}, fmt.Errorf("node %s is not a master node", clusterID)
+	}
+	return masterNode, kubeadm.SSHConfig{
+		Host:          masterNode.IP,
+		Port:          masterNode.Port,
+		Username:      masterNode.Username,
+		Password:      masterNode.Password,
+		PrivateKey:    masterNode.PrivateKey,
+		PasswordRef:   masterNode.PasswordRef,
+		PrivateKeyRef: masterNode.PrivateKeyRef,
+	}, nil
+}
+
+// verifyCluster 集群验证：节点就绪、核心Pod就绪、DNS解析和跨节点Pod网络
+func (h *Handler) verifyCluster(c *gin.Context) {
+	var opts kubeadm.VerifyOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	for _, check := range opts.SkipChecks {
+		if !kubeadm.IsValidCheck(check) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid check: %s", check),
+			})
+			return
+		}
+	}
+
+	masterNode, _, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	executor := kubeadm.NewClusterExecutor(h.deploymentStore, *masterNode)
+	defer executor.Close()
+	report, err := executor.Verify(c.Request.Context(), opts, func(msg string) {
+		fmt.Printf("[%s] %s\n", masterNode.Name, msg)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// listTokens 列出bootstrap令牌
+func (h *Handler) listTokens(c *gin.Context) {
+	_, sshConfig, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := kubeadm.ListJoinTokens(sshConfig)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+	})
+}
+
+// createTokenRequest 创建bootstrap令牌和join命令请求
+type createTokenRequest struct {
+	TTL string `json:"ttl"`
+}
+
+// createToken 创建bootstrap令牌和join命令
+func (h *Handler) createToken(c *gin.Context) {
+	var req createTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	masterNode, sshConfig, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	cmd, err := kubeadm.CreateJoinCommand(sshConfig, req.TTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	masterNode.JoinCommand = cmd
+	if _, err := h.nodeManager.UpdateNode(masterNode.ID, *masterNode); err != nil {
+		fmt.Printf("存储join命令到数据库失败: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"command": cmd,
+		"token":   kubeadm.ParseJoinToken(cmd),
+	})
+}
+
+// deleteToken 吊销bootstrap令牌
+func (h *Handler) deleteToken(c *gin.Context) {
+	token := c.Param("token")
+	if err := kubeadm.ValidateToken(token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	masterNode, sshConfig, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := kubeadm.RevokeJoinToken(sshConfig, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 如果吊销的是已存储的join命令使用的令牌，清空存储的join命令
+	storedToken := kubeadm.ParseJoinToken(masterNode.JoinCommand)
+	if storedToken != "" && (storedToken == token || strings.HasPrefix(storedToken, token+".")) {
+		masterNode.JoinCommand = ""
+		if _, err := h.nodeManager.UpdateNode(masterNode.ID, *masterNode); err != nil {
+			fmt.Printf("清除join命令失败: %v\n", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token revoked successfully",
+	})
+}
+
+// resetClusterRequest 重置master节点请求
+type resetClusterRequest struct {
+	MasterNodeID string `json:"masterNodeId" binding:"required"`
+	// 变更说明，记录在审计日志中
+	Description string `json:"description"`
+}
+
+// resetCluster 重置master节点
+func (h *Handler) resetCluster(c *gin.Context) {
+	var req resetClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !api.CheckDescription(c, &req.Description) {
+		return
+	}
+
+	// 获取master节点信息
+	masterNode, err := h.projectNodes(c).GetNode(req.MasterNodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get master node: %v", err),
+		})
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "ResetCluster", lock.NodeKey(masterNode.ID), lock.ClusterKey(masterNode.ID))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	// 创建SSH配置
+	sshConfig := kubeadm.SSHConfig{
+		Host:          masterNode.IP,
+		Port:          masterNode.Port,
+		Username:      masterNode.Username,
+		Password:      masterNode.Password,
+		PrivateKey:    masterNode.PrivateKey,
+		PasswordRef:   masterNode.PasswordRef,
+		PrivateKeyRef: masterNode.PrivateKeyRef,
+	}
+
+	// 记录集群重置开始日志
+	resetLog := log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    masterNode.ID,
+		NodeName:  masterNode.Name,
+		Operation: "ResetCluster",
+		Command:   api.WithDescription("重置Kubernetes集群", req.Description),
+		Output:    "开始重置Kubernetes集群...",
+		Status:    "running",
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	h.nodeManager.CreateLog(resetLog)
+
+	fmt.Printf("开始重置Kubernetes集群\n")
+
+	result, err := kubeadm.ResetCluster(sshConfig)
+	if err != nil {
+		// 记录集群重置失败日志
+		resetLog.Output = fmt.Sprintf("重置失败: %v\n输出: %s", err, result)
+		resetLog.Status = "failed"
+		resetLog.UpdatedAt = time.Now()
+		h.nodeManager.CreateLog(resetLog)
+
+		fmt.Printf("重置Kubernetes集群失败\n错误: %v\n输出: %s\n", err, log.Redact(result))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 记录集群重置成功日志
+	resetLog.Output = fmt.Sprintf("重置成功\n输出: %s", result)
+	resetLog.Status = "success"
+	resetLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(resetLog)
+
+	fmt.Printf("重置Kubernetes集群成功\n输出: %s\n", log.Redact(result))
+	// 重置后集群证书失效，下次访问时重新读取kubeconfig
+	h.deploymentStore.DeleteKubeconfig(masterNode.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"result": result,
+	})
+}
+
+// installHelmChart 通过Helm部署Chart，master节点未安装Helm时先安装Helm
+func (h *Handler) installHelmChart(c *gin.Context) {
+	var req kubeadm.HelmChartOptions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	masterNode, sshConfig, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "HelmInstall", lock.ClusterKey(masterNode.ID))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	output, err := kubeadm.InstallHelmChart(sshConfig, req, func(msg string) {
+		fmt.Printf("[%s] %s\n", masterNode.Name, msg)
+	})
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	h.nodeManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    masterNode.ID,
+		NodeName:  masterNode.Name,
+		Operation: "HelmInstall",
+		Command:   fmt.Sprintf("helm upgrade --install %s %s %s", req.ReleaseName, req.Chart, req.Version),
+		Output:    output,
+		Status:    status,
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  err.Error(),
+			"output": output,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"output":  output,
+	})
+}
+
+// teardownClusterRequest 拆除集群的所有成员节点请求
+type teardownClusterRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+	// 变更说明，记录在审计日志中
+	Description string `json:"description"`
+}
+
+// teardownCluster 拆除集群：按先Worker后控制平面的顺序重置所有成员节点，清理hosts解析和存储的join命令。
+// 未指定nodeIds时使用包含该master节点的最近一次部署记录中的节点
+func (h *Handler) teardownCluster(c *gin.Context) {
+	var req teardownClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !api.CheckDescription(c, &req.Description) {
+		return
+	}
+
+	masterNode, _, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	memberIDs := req.NodeIDs
+	deploymentID := ""
+	if len(memberIDs) == 0 {
+		deployments, err := h.deploymentStore.ListDeployments(50)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		for _, d := range deployments {
+			if containsString(d.NodeIDs, masterNode.ID) {
+				memberIDs = d.NodeIDs
+				deploymentID = d.ID
+				break
+			}
+		}
+	}
+	if !containsString(memberIDs, masterNode.ID) {
+		memberIDs = append(memberIDs, masterNode.ID)
+	}
+
+	members := make([]node.Node, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node not found: %s", id),
+			})
+			return
+		}
+		members = append(members, *n)
+	}
+
+	lockKeys := []string{lock.ClusterKey(masterNode.ID)}
+	for _, n := range members {
+		lockKeys = append(lockKeys, lock.NodeKey(n.ID))
+	}
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "TeardownCluster", lockKeys...)
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	fmt.Printf("开始拆除集群 %s，共 %d 个节点\n", masterNode.Name, len(members))
+	results := kubeadm.TeardownCluster(members, func(msg string) {
+		fmt.Println(msg)
+	})
+
+	success := true
+	for i, result := range results {
+		status := "success"
+		if !result.Success {
+			status = "failed"
+			success = false
+		}
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d-%d", time.Now().UnixNano(), i),
+			NodeID:    result.NodeID,
+			NodeName:  result.NodeName,
+			Operation: "TeardownCluster",
+			Command:   api.WithDescription(fmt.Sprintf("拆除集群 %s", masterNode.Name), req.Description),
+			Output:    strings.TrimSpace(result.Output + "\n" + result.Error),
+			Status:    status,
+			Type:      log.TypeScriptOutput,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+
+		// 节点已拆除时清空存储的join命令并恢复为在线状态，失败的节点标记为错误
+		n, err := h.projectNodes(c).GetNode(result.NodeID)
+		if err != nil {
+			continue
+		}
+		if result.Success {
+			n.Status = node.NodeStatusOnline
+			n.JoinCommand = ""
+		} else {
+			n.Status = node.NodeStatusError
+		}
+		if _, err := h.nodeManager.UpdateNode(n.ID, *n); err != nil {
+			fmt.Printf("更新节点 %s 状态失败: %v\n", n.Name, err)
+		}
+	}
+
+	if success && deploymentID != "" {
+		h.deploymentStore.UpdateDeploymentStatus(deploymentID, kubeadm.DeploymentStatusTornDown, "")
+	}
+	if success {
+		h.deploymentStore.DeleteKubeconfig(masterNode.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": success,
+		"results": results,
+	})
+}
+
+// joinWorkerRequest 将worker节点加入集群请求
+type joinWorkerRequest struct {
+	WorkerNodeID         string `json:"workerNodeId" binding:"required"`
+	Token                string `json:"token" binding:"required"`
+	CACertHash           string `json:"caCertHash" binding:"required"`
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint" binding:"required"`
+}
+
+// joinWorker 将worker节点加入集群
+func (h *Handler) joinWorker(c *gin.Context) {
+	var req joinWorkerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 获取工作节点信息
+	workerNode, err := h.projectNodes(c).GetNode(req.WorkerNodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get worker node: %v", err),
+		})
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "JoinWorker", lock.NodeKey(workerNode.ID))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	// 创建SSH配置，首先使用IP地址连接（确保在任何hosts文件更新之前都能连接）
+	sshConfig := kubeadm.SSHConfig{
+		Host:          workerNode.IP,
+		Port:          workerNode.Port,
+		Username:      workerNode.Username,
+		Password:      workerNode.Password,
+		PrivateKey:    workerNode.PrivateKey,
+		PasswordRef:   workerNode.PasswordRef,
+		PrivateKeyRef: workerNode.PrivateKeyRef,
+	}
+
+	// 记录工作节点加入开始日志
+	joinLog := log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    workerNode.ID,
+		NodeName:  workerNode.Name,
+		Operation: "JoinWorker",
+		Command:   fmt.Sprintf("将工作节点加入集群，控制平面端点: %s", req.ControlPlaneEndpoint),
+		Output:    "开始将工作节点加入集群...",
+		Status:    "running",
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	h.nodeManager.CreateLog(joinLog)
+
+	fmt.Printf("开始将工作节点加入集群: %s\n", workerNode.Name)
+
+	result, err := kubeadm.JoinWorker(sshConfig, req.Token, req.CACertHash, req.ControlPlaneEndpoint)
+	if err != nil {
+		// 记录工作节点加入失败日志
+		joinLog.Output = fmt.Sprintf("加入失败: %v\n输出: %s", err, result)
+		joinLog.Status = "failed"
+		joinLog.UpdatedAt = time.Now()
+		h.nodeManager.CreateLog(joinLog)
+
+		fmt.Printf("工作节点加入集群失败: %s\n错误: %v\n输出: %s\n", workerNode.Name, err, log.Redact(result))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 记录工作节点加入成功日志
+	joinLog.Output = fmt.Sprintf("加入成功\n输出: %s", result)
+	joinLog.Status = "success"
+	joinLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(joinLog)
+
+	fmt.Printf("工作节点加入集群成功: %s\n输出: %s\n", workerNode.Name, log.Redact(result))
+
+	h.eventBus.Publish(event.Event{
+		Type:     event.TypeJoinCompleted,
+		Message:  fmt.Sprintf("工作节点 %s 加入集群成功", workerNode.Name),
+		NodeID:   workerNode.ID,
+		NodeName: workerNode.Name,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"result": result,
+	})
+}
@@ -0,0 +1,243 @@
+package kubeadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/event"
+	"k8s-installer/job"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addClusterNodesRequest 向已有集群添加worker节点请求
+type addClusterNodesRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+	// 跳过的步骤，如节点已安装容器运行时时跳过container_runtime_installation
+	SkipSteps []string `json:"skipSteps"`
+	// 单条SSH命令的超时时间，单位为秒
+	CommandTimeoutSeconds int `json:"commandTimeoutSeconds"`
+	// 确认执行包含危险命令的自定义脚本
+	Force bool `json:"force"`
+	// 忽略兼容性矩阵的检查结果
+	IgnoreCompatibility bool `json:"ignoreCompatibility"`
+	// Kubernetes软件仓库镜像，为空时使用pkgs.k8s.io
+	KubeRepoMirror string `json:"kubeRepoMirror"`
+	// 变更说明，记录在部署记录、任务列表和审计日志中
+	Description string `json:"description"`
+}
+
+// addClusterNodes 向已有集群添加worker节点：使用集群部署时的版本和发行版，只执行节点准备和加入集群步骤，
+// join命令由Master节点上新创建的令牌生成，成功后将节点加入集群成员
+func (h *Handler) addClusterNodes(c *gin.Context) {
+	var req addClusterNodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if rejectUnknownSkipSteps(c, req.SkipSteps) {
+		return
+	}
+
+	masterNode, sshConfig, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	cluster, err := h.deploymentStore.FindClusterDeployment(masterNode.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, kubeadm.ErrDeploymentNotFound) {
+			status = http.StatusNotFound
+			err = fmt.Errorf("no deployment found for cluster %s", masterNode.ID)
+		}
+		api.Error(c, status, err)
+		return
+	}
+
+	v := &validate.Validator{}
+	if cluster.InstallerType == kubeadm.InstallerTypeK3s {
+		v.Add("clusterId", "adding nodes is only supported for kubeadm clusters")
+	}
+	if len(req.NodeIDs) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	v.MirrorURL("kubeRepoMirror", req.KubeRepoMirror)
+	req.Description = strings.TrimSpace(req.Description)
+	v.MaxLength("description", req.Description, api.MaxDescriptionLength)
+	var nodes []node.Node
+	var nodeNames []string
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		switch {
+		case err != nil:
+			v.Add("nodeIds."+id, "node not found")
+		case n.NodeType == node.NodeTypeMaster:
+			v.Add("nodeIds."+id, "only worker nodes can be added")
+		case containsString(cluster.NodeIDs, id):
+			v.Add("nodeIds."+id, "node is already a member of this cluster")
+		case !n.HasCredentials():
+			v.Add("nodeIds."+id, "node has no SSH credentials")
+		default:
+			nodes = append(nodes, *n)
+			nodeNames = append(nodeNames, n.Name)
+		}
+	}
+	if err := v.Err(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	groupDefaults, err := h.groupManager.DefaultsFor(nodes)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	deployOptions := kubeadm.DeployOptions{
+		CommandTimeout:          time.Duration(req.CommandTimeoutSeconds) * time.Second,
+		NodeGroupDefaults:       groupDefaults,
+		AllowDestructiveScripts: req.Force,
+		IgnoreCompatibility:     req.IgnoreCompatibility,
+		KubeRepoMirror:          req.KubeRepoMirror,
+		PackagePinning:          cluster.PackagePinning,
+	}
+	if !req.IgnoreCompatibility {
+		results := kubeadm.CheckNodesCompatibility(nodes, cluster.KubeVersion, groupDefaults)
+		if err := firstIncompatible(results); err != nil {
+			resp := api.ErrorResponse(c, http.StatusUnprocessableEntity, err)
+			resp["nodes"] = results
+			c.JSON(http.StatusUnprocessableEntity, resp)
+			return
+		}
+	}
+	// 使用集群所属项目的脚本
+	scripts, err := h.scriptManager.ForProject(api.ProjectID(c))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !req.Force {
+		if findings := kubeadm.DestructiveScripts(scripts, nodes, cluster.KubeVersion, cluster.Distro, deployOptions); len(findings) > 0 {
+			v := &validate.Validator{}
+			for _, f := range findings {
+				v.Add("scripts."+f.Script, "line %d: destructive command %q (%s), set force to run it", f.Line, f.Command, f.Rule)
+			}
+			api.ValidationFailed(c, v.Err())
+			return
+		}
+	}
+
+	// 新节点和集群加锁，与部署和拆除集群互斥
+	jobID := fmt.Sprintf("%d", time.Now().UnixNano())
+	lockKeys := []string{lock.ClusterKey(masterNode.ID)}
+	for _, n := range nodes {
+		lockKeys = append(lockKeys, lock.NodeKey(n.ID))
+	}
+	ticket, err := h.jobQueue.Enqueue(c.Request.Context(), job.Job{ID: jobID, Operation: "AddClusterNodes", Keys: lockKeys, Description: req.Description}, func(position int) {
+		fmt.Printf("添加节点任务 %s 排队中，位置: %d\n", jobID, position)
+	})
+	if err != nil {
+		api.Error(c, http.StatusServiceUnavailable, fmt.Errorf("job %s left the queue: %v", jobID, err))
+		return
+	}
+	defer ticket.Done()
+	lease, ok := api.AcquireLocks(c, h.lockManager, jobID, "AddClusterNodes", lockKeys...)
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	// 每次添加节点都创建新令牌，不复用可能已过期的join命令
+	joinCommand, err := kubeadm.CreateJoinCommand(sshConfig, "")
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, fmt.Errorf("failed to create join token on %s: %v", masterNode.Name, err))
+		return
+	}
+	masterNode.JoinCommand = joinCommand
+	if _, err := h.nodeManager.UpdateNode(masterNode.ID, *masterNode); err != nil {
+		fmt.Printf("存储join命令到数据库失败: %v\n", err)
+	}
+
+	deployment, err := h.deploymentStore.CreateDeployment(kubeadm.Deployment{
+		ID:             jobID,
+		KubeVersion:    cluster.KubeVersion,
+		Arch:           cluster.Arch,
+		Distro:         cluster.Distro,
+		InstallerType:  cluster.InstallerType,
+		NodeIDs:        req.NodeIDs,
+		PackagePinning: cluster.PackagePinning,
+		Description:    req.Description,
+	})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	deployOptions.JoinParams = kubeadm.JoinParams{JoinCommand: joinCommand}
+	deployOptions.StepTracker = &logFlushingTracker{StepTracker: h.deploymentStore.Tracker(deployment.ID), flush: h.nodeManager.FlushLogs}
+	deployOptions.OnArtifact = func(artifact kubeadm.Artifact) {
+		if err := h.deploymentStore.SaveArtifact(deployment.ID, artifact); err != nil {
+			fmt.Printf("保存部署产物失败: %v\n", err)
+		}
+	}
+	deployOptions.OnOSDetected = func(nodeID string, info node.OSInfo) {
+		if err := h.nodeManager.SetNodeOS(nodeID, info); err != nil {
+			fmt.Printf("缓存节点操作系统信息失败: %v\n", err)
+		}
+	}
+	command := api.WithDescription(fmt.Sprintf("向集群 %s 添加节点: %s", masterNode.Name, strings.Join(nodeNames, ", ")), req.Description)
+	logCallback := func(logMsg, nodeID, nodeName string) {
+		if nodeID == "cluster" {
+			nodeName = "Kubernetes Cluster"
+		}
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    nodeID,
+			NodeName:  nodeName,
+			Operation: "AddClusterNodes",
+			Command:   command,
+			Output:    logMsg,
+			Status:    "running",
+			Type:      log.TypeScriptOutput,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			JobID:     deployment.ID,
+		})
+	}
+
+	result, err := kubeadm.DeployK8sCluster(context.Background(), nodes, cluster.KubeVersion, cluster.Arch, cluster.Distro, scripts, req.SkipSteps, deployOptions, logCallback)
+	if err != nil {
+		h.deploymentStore.FailDeployment(deployment.ID, err, result)
+		api.Error(c, http.StatusInternalServerError, fmt.Errorf("failed to add nodes to cluster %s: %v", masterNode.Name, err))
+		return
+	}
+	h.deploymentStore.UpdateDeploymentStatus(deployment.ID, kubeadm.DeploymentStatusSuccess, "")
+	if err := h.deploymentStore.AddNodes(cluster.ID, req.NodeIDs); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	for _, n := range nodes {
+		h.eventBus.Publish(event.Event{
+			Type:     event.TypeJoinCompleted,
+			Message:  fmt.Sprintf("工作节点 %s 加入集群成功", n.Name),
+			NodeID:   n.ID,
+			NodeName: n.Name,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"clusterId":    masterNode.ID,
+		"deploymentId": deployment.ID,
+		"nodes":        nodeNames,
+		"result":       result,
+	})
+}
@@ -0,0 +1,128 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compatCheckRequest 节点兼容性检查请求
+type compatCheckRequest struct {
+	NodeIDs     []string `json:"nodeIds"`
+	KubeVersion string   `json:"kubeVersion"`
+}
+
+// compatCheckResponse 节点兼容性检查结果
+type compatCheckResponse struct {
+	Passed bool                   `json:"passed"`
+	Nodes  []kubeadm.CompatResult `json:"nodes"`
+}
+
+// Validate 检查节点和版本号
+func (r compatCheckRequest) Validate() error {
+	v := &validate.Validator{}
+	if len(r.NodeIDs) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	if v.Required("kubeVersion", r.KubeVersion) {
+		v.Version("kubeVersion", r.KubeVersion)
+	}
+	return v.Err()
+}
+
+// getCompatibilityMatrix 返回Kubernetes版本与发行版、containerd版本的兼容性矩阵
+func (h *Handler) getCompatibilityMatrix(c *gin.Context) {
+	c.JSON(http.StatusOK, kubeadm.CompatibilityMatrix)
+}
+
+// checkCompatibility 按兼容性矩阵检查节点，没有缓存操作系统信息的节点先检测并缓存
+func (h *Handler) checkCompatibility(c *gin.Context) {
+	var req compatCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	var nodes, undetected []node.Node
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node %s: %v", id, err),
+			})
+			return
+		}
+		nodes = append(nodes, *n)
+		if n.OSInfo == nil {
+			undetected = append(undetected, *n)
+		}
+	}
+
+	// 检测失败的节点保持未检测状态，结果中给出警告
+	if len(undetected) > 0 {
+		detected := make(map[string]node.OSInfo)
+		results := h.nodeManager.ExecOnNodes(c.Request.Context(), undetected, node.DetectOSCmd, node.ExecOptions{Source: c.ClientIP()}, nil)
+		for i, res := range results {
+			if !res.Success {
+				continue
+			}
+			info := node.ParseOSInfo(res.Output)
+			detected[undetected[i].ID] = info
+			if err := h.nodeManager.SetNodeOS(undetected[i].ID, info); err != nil {
+				fmt.Printf("缓存节点操作系统信息失败: %v\n", err)
+			}
+		}
+		for i := range nodes {
+			if info, ok := detected[nodes[i].ID]; ok {
+				nodes[i].OSInfo = &info
+			}
+		}
+	}
+
+	groupDefaults, err := h.groupManager.DefaultsFor(nodes)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	resp := compatCheckResponse{Passed: true, Nodes: kubeadm.CheckNodesCompatibility(nodes, req.KubeVersion, groupDefaults)}
+	for _, result := range resp.Nodes {
+		resp.Passed = resp.Passed && result.Compatible
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// nodesCompatibility 部署前按兼容性矩阵检查节点缓存的操作系统信息，不存在的节点由部署流程报错
+func (h *Handler) nodesCompatibility(c *gin.Context, nodeIDs []string, kubeVersion string) ([]kubeadm.CompatResult, error) {
+	var nodes []node.Node
+	for _, id := range nodeIDs {
+		if n, err := h.projectNodes(c).GetNode(id); err == nil {
+			nodes = append(nodes, *n)
+		}
+	}
+	groupDefaults, err := h.groupManager.DefaultsFor(nodes)
+	if err != nil {
+		return nil, err
+	}
+	return kubeadm.CheckNodesCompatibility(nodes, kubeVersion, groupDefaults), nil
+}
+
+// firstIncompatible 返回第一个不兼容节点的错误，全部兼容时返回nil
+func firstIncompatible(results []kubeadm.CompatResult) error {
+	for _, result := range results {
+		if err := result.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
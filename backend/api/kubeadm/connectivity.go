@@ -0,0 +1,93 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 连通性检查单个端口探测超时的上限，单位为秒
+const maxConnectivityTimeoutSeconds = 30
+
+// connectivityRequest 部署前节点间端口连通性检查请求
+type connectivityRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+	// Checks 检查的端口，为空时检查apiserver、kubelet和VXLAN端口
+	Checks []kubeadm.PortCheck `json:"checks,omitempty"`
+	// TimeoutSeconds 单个端口探测的超时时间，默认3秒
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// Validate 检查节点、端口和超时时间
+func (r connectivityRequest) Validate() error {
+	v := &validate.Validator{}
+	if len(r.NodeIDs) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	v.Merge("", kubeadm.ValidatePortChecks(r.Checks))
+	if r.TimeoutSeconds < 0 || r.TimeoutSeconds > maxConnectivityTimeoutSeconds {
+		v.Add("timeoutSeconds", "must be between 0 and %d", maxConnectivityTimeoutSeconds)
+	}
+	return v.Err()
+}
+
+// checkConnectivity 在每个节点上启动临时监听并探测到其他节点的端口，返回节点间的连通性矩阵
+func (h *Handler) checkConnectivity(c *gin.Context) {
+	var req connectivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+	checks := req.Checks
+	if len(checks) == 0 {
+		checks = kubeadm.DefaultPortChecks
+	}
+	timeout := kubeadm.DefaultConnectivityTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+
+	var nodes []node.Node
+	lockKeys := make([]string, 0, len(req.NodeIDs))
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node %s: %v", id, err),
+			})
+			return
+		}
+		nodes = append(nodes, *n)
+		lockKeys = append(lockKeys, lock.NodeKey(id))
+	}
+
+	// 临时监听会占用部署使用的端口，检查期间锁定节点
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "CheckConnectivity", lockKeys...)
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	ctx := c.Request.Context()
+	opts := node.ExecOptions{Source: c.ClientIP()}
+	// 监听时间覆盖所有探测，探测结束后主动结束监听
+	listenDuration := time.Minute + time.Duration(len(nodes)*len(checks))*timeout/8
+	listeners := h.nodeManager.ExecOnNodes(ctx, nodes, kubeadm.PortListenerCmd(checks, listenDuration), opts, nil)
+	probes := h.nodeManager.ExecOnNodes(ctx, nodes, kubeadm.ConnectivityProbeCmd(nodes, checks, timeout), opts, nil)
+	h.nodeManager.ExecOnNodes(ctx, nodes, kubeadm.StopPortListenerCmd, opts, nil)
+
+	c.JSON(http.StatusOK, kubeadm.BuildConnectivityMatrix(nodes, checks, listeners, probes))
+}
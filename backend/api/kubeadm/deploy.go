@@ -0,0 +1,808 @@
+package kubeadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/diagnose"
+	"k8s-installer/errcode"
+	"k8s-installer/event"
+	"k8s-installer/job"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/log"
+	"k8s-installer/metrics"
+	"k8s-installer/node"
+	"k8s-installer/script"
+	"k8s-installer/validate"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deployClusterRequest 部署Kubernetes集群请求
+type deployClusterRequest struct {
+	KubeVersion          string   `json:"kubeVersion" binding:"required"`
+	Arch                 string   `json:"arch" binding:"required"`
+	Distro               string   `json:"distro" binding:"required"`
+	NodeIds              []string `json:"nodeIds"`
+	GroupIds             []string `json:"groupIds"` // 按节点组选择节点，组内节点追加到nodeIds
+	SkipSteps            []string `json:"skipSteps" binding:"omitempty"`
+	JoinToken            string   `json:"joinToken" binding:"omitempty"`
+	CACertHash           string   `json:"caCertHash" binding:"omitempty"`
+	ControlPlaneEndpoint string   `json:"controlPlaneEndpoint" binding:"omitempty"`
+	Resume               bool     `json:"resume"`
+	DeploymentID         string   `json:"deploymentId"`
+	// 按节点ID跳过的步骤，与skipSteps叠加，如已安装containerd的节点跳过container_runtime_installation
+	NodeSkipSteps map[string][]string `json:"nodeSkipSteps"`
+	// 超时配置，单位为秒
+	CommandTimeoutSeconds int            `json:"commandTimeoutSeconds"`
+	StepTimeouts          map[string]int `json:"stepTimeouts"`
+	// 每个步骤的重试策略，未配置的步骤使用默认策略
+	RetryPolicies map[string]kubeadm.RetryPolicy `json:"retryPolicies"`
+	// 集群级配置：kube-proxy模式（iptables/ipvs）和kubelet额外参数
+	KubeProxyMode    string            `json:"kubeProxyMode"`
+	KubeletExtraArgs map[string]string `json:"kubeletExtraArgs"`
+	// kubelet资源预留、驱逐阈值和最大Pod数，nodeKubelet按节点ID覆盖
+	Kubelet     kubeadm.KubeletSettings            `json:"kubelet"`
+	NodeKubelet map[string]kubeadm.KubeletSettings `json:"nodeKubelet"`
+	// 保留节点swap并配置kubelet的NodeSwap，要求Kubernetes 1.28+和cgroup v2
+	Swap kubeadm.SwapOptions `json:"swap"`
+	// 高级kubeadm配置，如apiServer extraArgs、etcd等
+	KubeadmConfig kubeadm.KubeadmConfig `json:"kubeadmConfig"`
+	// apiserver证书额外的主题备用名称，如VIP和域名，合并到kubeadmConfig的apiServer.certSANs
+	CertSANs []string `json:"certSANs"`
+	// 用户提供的集群CA证书和私钥，为空时由kubeadm生成
+	CA kubeadm.ClusterCA `json:"ca"`
+	// 使用kube-vip提供控制平面VIP，VIP作为controlPlaneEndpoint
+	KubeVIP kubeadm.KubeVIPOptions `json:"kubeVip"`
+	// kubeadm init之前在所有节点上预拉取镜像
+	Prepull kubeadm.PrepullOptions `json:"prepull"`
+	// 部署完成后的集群验证选项
+	Verify kubeadm.VerifyOptions `json:"verify"`
+	// 就绪门控：等待所有节点Ready和核心Pod就绪，超时后部署失败；disabled为true时只输出警告
+	ReadinessGate kubeadm.ReadinessGateOptions `json:"readinessGate"`
+	// 部署后冒烟测试选项
+	SmokeTest kubeadm.SmokeTestOptions `json:"smokeTest"`
+	// 时区和NTP服务器配置，nodeTimeSync按节点ID覆盖集群配置
+	TimeSync     kubeadm.TimeSyncOptions            `json:"timeSync"`
+	NodeTimeSync map[string]kubeadm.TimeSyncOptions `json:"nodeTimeSync"`
+	// 安装方式：kubeadm（默认）或k3s，k3s安装选项
+	InstallerType string             `json:"installerType"`
+	K3s           kubeadm.K3sOptions `json:"k3s"`
+	// 单节点集群：唯一的节点作为Master并移除控制平面污点；addons为安装的可选插件
+	SingleNode   bool                 `json:"singleNode"`
+	Addons       []string             `json:"addons"`
+	AddonOptions kubeadm.AddonOptions `json:"addonOptions"`
+	// 部署完成后安装Argo CD或Flux并注册Git仓库
+	GitOps kubeadm.GitOpsOptions `json:"gitops"`
+	// 排队优先级，数值大的部署先执行，默认0
+	Priority int `json:"priority"`
+	// 确认执行包含危险命令（如rm -rf /、mkfs、dd of=/dev/sda）的自定义脚本
+	Force bool `json:"force"`
+	// 忽略兼容性矩阵的检查结果，不兼容的发行版或containerd版本只输出警告
+	IgnoreCompatibility bool `json:"ignoreCompatibility"`
+	// Kubernetes软件仓库镜像，目录结构与https://pkgs.k8s.io/core:/stable:/相同，为空时使用pkgs.k8s.io
+	KubeRepoMirror string `json:"kubeRepoMirror"`
+	// 安装后固定kubelet、kubeadm和kubectl的版本，为空时默认固定；作为集群设置保存，可通过PUT /clusters/:id/package-pinning修改
+	PackagePinning *bool `json:"packagePinning"`
+	// 变更说明，记录在部署记录、任务列表和审计日志中，说明这次变更的原因
+	Description string `json:"description"`
+}
+
+// packagePinning 是否固定Kubernetes组件版本，未指定时默认固定
+func (r deployClusterRequest) packagePinning() bool {
+	return r.PackagePinning == nil || *r.PackagePinning
+}
+
+// deployCluster 部署Kubernetes集群
+func (h *Handler) deployCluster(c *gin.Context) {
+	var req deployClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if rejectUnknownSkipSteps(c, req.SkipSteps) {
+		return
+	}
+
+	// 按节点组选择节点，与nodeIds合并去重
+	if len(req.GroupIds) > 0 {
+		groupNodeIDs, err := h.groupManager.NodeIDs(api.ProjectID(c), req.GroupIds)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, node.ErrGroupNotFound) {
+				status = http.StatusNotFound
+			}
+			api.Error(c, status, err)
+			return
+		}
+		for _, id := range groupNodeIDs {
+			if !containsString(req.NodeIds, id) {
+				req.NodeIds = append(req.NodeIds, id)
+			}
+		}
+	}
+
+	for _, san := range req.CertSANs {
+		if !containsString(req.KubeadmConfig.ClusterConfiguration.APIServer.CertSANs, san) {
+			req.KubeadmConfig.ClusterConfiguration.APIServer.CertSANs = append(req.KubeadmConfig.ClusterConfiguration.APIServer.CertSANs, san)
+		}
+	}
+
+	// 校验版本号、地址和网段等字段，无效字段在422响应中列出
+	v := &validate.Validator{}
+	v.Version("kubeVersion", req.KubeVersion)
+	v.HostPort("controlPlaneEndpoint", req.ControlPlaneEndpoint)
+	v.MirrorURL("kubeRepoMirror", req.KubeRepoMirror)
+	req.Description = strings.TrimSpace(req.Description)
+	v.MaxLength("description", req.Description, api.MaxDescriptionLength)
+	v.Merge("kubeadmConfig", req.KubeadmConfig.Validate())
+	v.Merge("ca", req.CA.Validate())
+	v.Merge("kubeVip", req.KubeVIP.Validate())
+	if req.KubeVIP.Enabled && req.InstallerType == kubeadm.InstallerTypeK3s {
+		v.Add("kubeVip.enabled", "kube-vip is only supported with the kubeadm installer")
+	}
+	v.Merge("kubelet", req.Kubelet.Validate())
+	v.Merge("prepull", req.Prepull.Validate())
+	v.Merge("swap", req.Swap.Validate(req.KubeVersion))
+	if req.Swap.Enabled && req.InstallerType == kubeadm.InstallerTypeK3s {
+		v.Add("swap.enabled", "swap is only supported with the kubeadm installer")
+	}
+	for nodeID, settings := range req.NodeKubelet {
+		v.Merge("nodeKubelet."+nodeID, settings.Validate())
+	}
+	if len(req.NodeIds) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	v.Merge("nodeSkipSteps", kubeadm.ValidateNodeSkipSteps(req.NodeSkipSteps))
+	for nodeID := range req.NodeSkipSteps {
+		if !containsString(req.NodeIds, nodeID) {
+			v.Add("nodeSkipSteps."+nodeID, "node is not part of this deployment")
+		}
+	}
+	if err := v.Err(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+	// 请求中的凭据在日志中脱敏
+	log.RegisterSecret(req.JoinToken)
+	log.RegisterSecret(req.K3s.Token)
+	log.RegisterSecret(req.GitOps.Password)
+
+	stepTimeouts := make(map[string]time.Duration)
+	for step, seconds := range req.StepTimeouts {
+		if !kubeadm.IsValidStep(step) || seconds < 0 {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid step timeout: %s=%d", step, seconds))
+			return
+		}
+		stepTimeouts[step] = time.Duration(seconds) * time.Second
+	}
+	if err := kubeadm.ValidateKubeProxyMode(req.KubeadmConfig.KubeProxy.Mode); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := kubeadm.ValidateKubeProxyMode(req.KubeProxyMode); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	for _, check := range req.Verify.SkipChecks {
+		if !kubeadm.IsValidCheck(check) {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid verify check: %s", check))
+			return
+		}
+	}
+	if err := req.ReadinessGate.Validate(); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := kubeadm.ValidateInstallerType(req.InstallerType); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.InstallerType == "" {
+		req.InstallerType = kubeadm.InstallerTypeKubeadm
+	}
+	if req.SingleNode && len(req.NodeIds) != 1 {
+		api.Error(c, http.StatusBadRequest, errors.New("single node cluster requires exactly one node"))
+		return
+	}
+	if err := kubeadm.ValidateAddons(req.Addons, req.AddonOptions); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.GitOps.Validate(); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.K3s.Validate(); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.TimeSync.Validate(); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	for nodeID, timeSync := range req.NodeTimeSync {
+		if err := timeSync.Validate(); err != nil {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid time sync for node %s: %v", nodeID, err))
+			return
+		}
+	}
+	for step, policy := range req.RetryPolicies {
+		if !kubeadm.IsValidStep(step) {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid retry policy step: %s", step))
+			return
+		}
+		if err := policy.Validate(); err != nil {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid retry policy for %s: %v", step, err))
+			return
+		}
+	}
+
+	// 自定义脚本包含危险命令时需要force确认，在422响应中列出每条命令
+	if req.InstallerType == kubeadm.InstallerTypeKubeadm && !req.Force {
+		findings, err := h.destructiveScripts(c, req)
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+		if len(findings) > 0 {
+			v := &validate.Validator{}
+			for _, f := range findings {
+				v.Add("scripts."+f.Script, "line %d: destructive command %q (%s), set force to run it", f.Line, f.Command, f.Rule)
+			}
+			api.ValidationFailed(c, v.Err())
+			return
+		}
+	}
+
+	// 按兼容性矩阵检查已检测操作系统的节点，不兼容时在部署开始前返回可选方案
+	if req.InstallerType == kubeadm.InstallerTypeKubeadm && !req.IgnoreCompatibility {
+		results, err := h.nodesCompatibility(c, req.NodeIds, req.KubeVersion)
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+		if err := firstIncompatible(results); err != nil {
+			resp := api.ErrorResponse(c, http.StatusUnprocessableEntity, err)
+			resp["nodes"] = results
+			c.JSON(http.StatusUnprocessableEntity, resp)
+			return
+		}
+	}
+
+	// 部署涉及的所有节点以及作为master的节点对应的集群加锁，继续部署时任务ID为原部署ID
+	jobID := fmt.Sprintf("%d", time.Now().UnixNano())
+	if req.Resume && req.DeploymentID != "" {
+		jobID = req.DeploymentID
+	}
+	lockKeys := make([]string, 0, len(req.NodeIds))
+	for _, id := range req.NodeIds {
+		lockKeys = append(lockKeys, lock.NodeKey(id))
+		if n, err := h.projectNodes(c).GetNode(id); err == nil && (n.NodeType == node.NodeTypeMaster || req.SingleNode) {
+			lockKeys = append(lockKeys, lock.ClusterKey(id))
+		}
+	}
+
+	// 超出最大并发数或同一节点、集群已有部署在运行时排队等待，请求断开时退出队列
+	ticket, err := h.jobQueue.Enqueue(c.Request.Context(), job.Job{ID: jobID, Operation: "DeployK8sCluster", Keys: lockKeys, Priority: req.Priority, Description: req.Description}, func(position int) {
+		fmt.Printf("部署任务 %s 排队中，位置: %d\n", jobID, position)
+	})
+	if err != nil {
+		api.Error(c, http.StatusServiceUnavailable, fmt.Errorf("deployment %s left the queue: %v", jobID, err))
+		return
+	}
+	defer ticket.Done()
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, jobID, "DeployK8sCluster", lockKeys...)
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	// 断点续部署：复用之前的部署记录，跳过已成功的步骤
+	var deployment *kubeadm.Deployment
+	resumed := false
+	if req.Resume {
+		var err error
+		if req.DeploymentID != "" {
+			deployment, err = h.deploymentStore.GetDeployment(req.DeploymentID)
+			// 其他项目的部署记录按不存在处理
+			if err == nil && h.deploymentProject(deployment) != api.ProjectID(c) {
+				deployment, err = nil, kubeadm.ErrDeploymentNotFound
+			}
+		} else {
+			deployment, err = h.deploymentStore.FindResumableDeployment(req.NodeIds, req.KubeVersion)
+		}
+		if err != nil && (err != kubeadm.ErrDeploymentNotFound || req.DeploymentID != "") {
+			status := http.StatusInternalServerError
+			if err == kubeadm.ErrDeploymentNotFound {
+				status = http.StatusNotFound
+			}
+			api.Error(c, status, err)
+			return
+		}
+		if deployment != nil && deployment.InstallerType != req.InstallerType {
+			api.Error(c, http.StatusConflict, fmt.Errorf("deployment %s uses installer %s, cannot resume with %s", deployment.ID, deployment.InstallerType, req.InstallerType))
+			return
+		}
+		if deployment != nil {
+			resumed = true
+			lease.SetJobID(deployment.ID)
+			ticket.SetID(deployment.ID)
+			h.deploymentStore.UpdateDeploymentStatus(deployment.ID, kubeadm.DeploymentStatusRunning, "")
+			fmt.Printf("从部署 %s 继续执行，跳过已成功的步骤\n", deployment.ID)
+		} else {
+			fmt.Println("未找到可继续的部署记录，开始新的部署")
+		}
+	}
+	if deployment == nil {
+		var err error
+		deployment, err = h.deploymentStore.CreateDeployment(kubeadm.Deployment{
+			ID:             jobID,
+			KubeVersion:    req.KubeVersion,
+			Arch:           req.Arch,
+			Distro:         req.Distro,
+			InstallerType:  req.InstallerType,
+			NodeIDs:        req.NodeIds,
+			PackagePinning: req.packagePinning(),
+			Description:    req.Description,
+		})
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	// 记录部署开始日志
+	deployCommand := api.WithDescription(fmt.Sprintf("部署Kubernetes集群，版本: %s，架构: %s，发行版: %s", req.KubeVersion, req.Arch, req.Distro), req.Description)
+	deployLog := log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    "cluster",
+		NodeName:  "Kubernetes Cluster",
+		Operation: "DeployK8sCluster",
+		Command:   deployCommand,
+		Output:    "开始部署Kubernetes集群...",
+		Status:    "running",
+		Type:      log.TypeStep,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		JobID:     deployment.ID,
+	}
+	h.nodeManager.CreateLog(deployLog)
+
+	fmt.Printf("开始部署Kubernetes集群\n节点ID列表: %s\n版本: %s\n架构: %s\n发行版: %s\n", strings.Join(req.NodeIds, ", "), req.KubeVersion, req.Arch, req.Distro)
+
+	// 获取所有指定的节点
+	var nodes []node.Node
+	var nodeNames []string
+	for _, id := range req.NodeIds {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			// 记录部署失败日志
+			deployLog.Output = fmt.Sprintf("部署失败: 获取节点 %s 失败\n错误: %v\n", id, err)
+			deployLog.Status = "failed"
+			deployLog.UpdatedAt = time.Now()
+			h.nodeManager.CreateLog(deployLog)
+			h.deploymentStore.FailDeployment(deployment.ID, err, "")
+
+			fmt.Printf("部署失败: 获取节点 %s 失败\n错误: %v\n", id, err)
+			api.Error(c, http.StatusInternalServerError, fmt.Errorf("获取节点 %s 失败: %v", id, err))
+			return
+		}
+		// 单节点集群的节点作为Master节点部署，后续集群操作以该节点ID作为集群ID
+		if req.SingleNode && n.NodeType != node.NodeTypeMaster {
+			n.NodeType = node.NodeTypeMaster
+			if _, err := h.nodeManager.UpdateNode(n.ID, *n); err != nil {
+				fmt.Printf("更新节点 %s 类型为master失败: %v\n", n.Name, err)
+			}
+		}
+		nodes = append(nodes, *n)
+		nodeNames = append(nodeNames, n.Name)
+	}
+
+	// 节点所属节点组的默认配置，部署时应用到对应节点
+	groupDefaults, err := h.groupManager.DefaultsFor(nodes)
+	if err != nil {
+		h.deploymentStore.FailDeployment(deployment.ID, err, "")
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// 更新部署日志，添加节点信息
+	deployLog.Output = fmt.Sprintf("节点列表: %s\n开始部署...", strings.Join(nodeNames, ", "))
+	deployLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(deployLog)
+
+	fmt.Printf("节点列表: %s\n", strings.Join(nodeNames, ", "))
+
+	deployEventData := map[string]interface{}{
+		"kubeVersion": req.KubeVersion,
+		"arch":        req.Arch,
+		"distro":      req.Distro,
+		"nodes":       nodeNames,
+	}
+	h.eventBus.Publish(event.Event{
+		Type:    event.TypeDeploymentStarted,
+		Message: fmt.Sprintf("开始部署Kubernetes集群 %s，节点: %s", req.KubeVersion, strings.Join(nodeNames, ", ")),
+		Data:    deployEventData,
+	})
+
+	// 创建一个上下文，支持取消部署
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// join参数按请求显式传递，避免并发部署之间通过进程环境变量互相覆盖
+	joinParams := kubeadm.JoinParams{
+		Token:                req.JoinToken,
+		CACertHash:           req.CACertHash,
+		ControlPlaneEndpoint: req.ControlPlaneEndpoint,
+	}
+
+	// 本次部署不包含master节点且未提供join参数时，复用已存储的master节点join命令
+	hasMaster := false
+	for _, n := range nodes {
+		if n.NodeType == node.NodeTypeMaster {
+			hasMaster = true
+			break
+		}
+	}
+	if !hasMaster && joinParams.Command() == "" {
+		allNodes, err := h.projectNodes(c).GetNodes()
+		if err == nil {
+			for _, n := range allNodes {
+				if n.NodeType != node.NodeTypeMaster || n.JoinCommand == "" {
+					continue
+				}
+				sshConfig := kubeadm.SSHConfig{
+					Host:          n.IP,
+					Port:          n.Port,
+					Username:      n.Username,
+					Password:      n.Password,
+					PrivateKey:    n.PrivateKey,
+					PasswordRef:   n.PasswordRef,
+					PrivateKeyRef: n.PrivateKeyRef,
+				}
+				cmd, regenerated, err := kubeadm.EnsureJoinCommand(sshConfig, n.JoinCommand)
+				if err != nil {
+					fmt.Printf("刷新master节点 %s 的join命令失败: %v\n", n.Name, err)
+					continue
+				}
+				if regenerated {
+					n.JoinCommand = cmd
+					if _, err := h.nodeManager.UpdateNode(n.ID, n); err != nil {
+						fmt.Printf("存储join命令到数据库失败: %v\n", err)
+					}
+				}
+				joinParams.JoinCommand = cmd
+				fmt.Printf("复用master节点 %s 的join命令\n", n.Name)
+				break
+			}
+		}
+	}
+
+	// 调用DeployK8sCluster函数进行部署，传递scriptManager和skipSteps
+	// 实时日志回调函数，支持按节点记录日志
+	logCallback := func(logMsg, nodeID, nodeName string) {
+		// 确定日志的节点ID和节点名
+		logNodeID := nodeID
+		logNodeName := nodeName
+
+		// 如果是集群级别的日志，使用原始日志回调中的固定值
+		if logNodeID == "cluster" {
+			logNodeName = "Kubernetes Cluster"
+		}
+
+		// 创建日志条目
+		logEntry := log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    logNodeID,
+			NodeName:  logNodeName,
+			Operation: "DeployK8sCluster",
+			Command:   deployCommand,
+			Output:    logMsg,
+			Status:    "running",
+			Type:      log.TypeScriptOutput,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			JobID:     deployment.ID,
+		}
+		if progress, ok := h.deploymentStore.Progress(deployment.ID); ok {
+			logEntry.Progress = progress
+		}
+		h.nodeManager.CreateLog(logEntry)
+	}
+
+	var verification *kubeadm.VerificationReport
+	var smokeTest *kubeadm.SmokeTestResult
+	deployOptions := kubeadm.DeployOptions{
+		JoinParams:       joinParams,
+		StepTracker:      &logFlushingTracker{StepTracker: h.deploymentStore.Tracker(deployment.ID), flush: h.nodeManager.FlushLogs},
+		NodeSkipSteps:    req.NodeSkipSteps,
+		CommandTimeout:   time.Duration(req.CommandTimeoutSeconds) * time.Second,
+		StepTimeouts:     stepTimeouts,
+		RetryPolicies:    req.RetryPolicies,
+		KubeadmConfig:    req.KubeadmConfig,
+		CA:               req.CA,
+		KubeVIP:          req.KubeVIP,
+		KubeProxyMode:    req.KubeProxyMode,
+		KubeletExtraArgs: req.KubeletExtraArgs,
+		Swap:             req.Swap,
+		Kubelet:          req.Kubelet,
+		NodeKubelet:      req.NodeKubelet,
+		TimeSync:         req.TimeSync,
+		NodeTimeSync:     req.NodeTimeSync,
+		Prepull:          req.Prepull,
+		Verify:           req.Verify,
+		ReadinessGate:    req.ReadinessGate,
+		OnVerified: func(report kubeadm.VerificationReport) {
+			verification = &report
+		},
+		SmokeTest: req.SmokeTest,
+		OnSmokeTested: func(result kubeadm.SmokeTestResult) {
+			smokeTest = &result
+		},
+		OnArtifact: func(artifact kubeadm.Artifact) {
+			if err := h.deploymentStore.SaveArtifact(deployment.ID, artifact); err != nil {
+				fmt.Printf("保存部署产物失败: %v\n", err)
+			}
+		},
+		OnOSDetected: func(nodeID string, info node.OSInfo) {
+			if err := h.nodeManager.SetNodeOS(nodeID, info); err != nil {
+				fmt.Printf("缓存节点操作系统信息失败: %v\n", err)
+			}
+		},
+		K3s:          req.K3s,
+		SingleNode:   req.SingleNode,
+		Addons:       req.Addons,
+		AddonOptions: req.AddonOptions,
+		GitOps:       req.GitOps,
+
+		AllowDestructiveScripts: req.Force,
+		IgnoreCompatibility:     req.IgnoreCompatibility,
+		KubeRepoMirror:          req.KubeRepoMirror,
+		PackagePinning:          req.packagePinning(),
+
+		NodeGroupDefaults: groupDefaults,
+	}
+	// 按历史步骤耗时统计进度，通过GET /jobs、部署记录和SSE日志返回
+	if err := h.deploymentStore.StartProgress(deployment.ID, kubeadm.PlanSteps(nodes, req.InstallerType, req.SkipSteps, deployOptions)); err != nil {
+		fmt.Printf("统计部署进度失败: %v\n", err)
+	}
+	defer h.deploymentStore.FinishProgress(deployment.ID)
+
+	var result string
+	if req.InstallerType == kubeadm.InstallerTypeK3s {
+		result, err = kubeadm.DeployK3sCluster(ctx, nodes, req.KubeVersion, req.SkipSteps, deployOptions, logCallback)
+	} else {
+		// 使用请求所属项目的脚本
+		var scripts *script.ScriptManager
+		if scripts, err = h.scriptManager.ForProject(api.ProjectID(c)); err == nil {
+			result, err = kubeadm.DeployK8sCluster(ctx, nodes, req.KubeVersion, req.Arch, req.Distro, scripts, req.SkipSteps, deployOptions, logCallback)
+		}
+	}
+	// 保存master节点的join参数，之后加入集群的节点由这些参数生成join命令
+	for _, n := range nodes {
+		if n.NodeType != node.NodeTypeMaster || n.JoinInfo == nil {
+			continue
+		}
+		stored, getErr := h.projectNodes(c).GetNode(n.ID)
+		if getErr != nil {
+			continue
+		}
+		stored.JoinCommand, stored.JoinInfo = n.JoinCommand, n.JoinInfo
+		if _, updateErr := h.nodeManager.UpdateNode(n.ID, *stored); updateErr != nil {
+			fmt.Printf("存储join参数到数据库失败: %v\n", updateErr)
+		}
+	}
+	if err != nil {
+		h.deploymentStore.FailDeployment(deployment.ID, err, result)
+		metrics.DeploymentsTotal.Inc("failed")
+		h.eventBus.Publish(event.Event{
+			Type:    event.TypeDeploymentFailed,
+			Message: fmt.Sprintf("Kubernetes集群 %s 部署失败: %v", req.KubeVersion, err),
+			Data:    deployEventData,
+		})
+
+		// 记录部署失败日志
+		deployLog.Output = fmt.Sprintf("部署失败: %v\n详细错误: %s\n", err, result)
+		deployLog.Status = "failed"
+		deployLog.UpdatedAt = time.Now()
+		h.nodeManager.CreateLog(deployLog)
+
+		fmt.Printf("部署失败: %v\n详细错误: %s\n", err, log.Redact(result))
+
+		// 返回详细的错误信息
+		code := kubeadm.DeploymentErrorCode(err)
+		lang := api.Lang(c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":        fmt.Sprintf("部署Kubernetes集群失败: %v\n详细信息: %s", err, result),
+			"code":         code,
+			"message":      errcode.Message(code, lang),
+			"diagnoses":    diagnose.Diagnose(err.Error()+"\n"+result, lang),
+			"deploymentId": deployment.ID,
+		})
+		return
+	}
+
+	h.deploymentStore.UpdateDeploymentStatus(deployment.ID, kubeadm.DeploymentStatusSuccess, "")
+	metrics.DeploymentsTotal.Inc("success")
+	h.eventBus.Publish(event.Event{
+		Type:    event.TypeDeploymentSucceeded,
+		Message: fmt.Sprintf("Kubernetes集群 %s 部署成功，节点: %s", req.KubeVersion, strings.Join(nodeNames, ", ")),
+		Data:    deployEventData,
+	})
+	// worker节点随部署一起加入集群
+	if !containsString(req.SkipSteps, kubeadm.StepWorkerJoin) {
+		for _, n := range nodes {
+			if n.NodeType == node.NodeTypeWorker {
+				h.eventBus.Publish(event.Event{
+					Type:     event.TypeJoinCompleted,
+					Message:  fmt.Sprintf("工作节点 %s 加入集群成功", n.Name),
+					NodeID:   n.ID,
+					NodeName: n.Name,
+				})
+			}
+		}
+	}
+
+	// 记录部署成功日志
+	deployLog.Output = fmt.Sprintf("部署成功!\n结果: %s\n", result)
+	deployLog.Status = "success"
+	deployLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(deployLog)
+
+	fmt.Printf("部署成功!\n结果: %s\n", log.Redact(result))
+
+	// 返回部署成功结果
+	c.JSON(http.StatusOK, gin.H{
+		"result":        result,
+		"message":       "Kubernetes集群部署成功",
+		"nodes":         nodeNames,
+		"version":       req.KubeVersion,
+		"installerType": req.InstallerType,
+		"deploymentId":  deployment.ID,
+		"resumed":       resumed,
+		"verification":  verification,
+		"smokeTest":     smokeTest,
+	})
+}
+
+// logFlushingTracker 步骤结束时先等待日志写入数据库再记录步骤结果，
+// 保证步骤记录为完成时其输出已经可以查询
+type logFlushingTracker struct {
+	kubeadm.StepTracker
+	flush func() error
+}
+
+// StartStep 转发给支持kubeadm.StepStarter的StepTracker
+func (t *logFlushingTracker) StartStep(nodeID, step string) {
+	if starter, ok := t.StepTracker.(kubeadm.StepStarter); ok {
+		starter.StartStep(nodeID, step)
+	}
+}
+
+// MarkStep 写入步骤的日志后记录步骤结果
+func (t *logFlushingTracker) MarkStep(nodeID, step string, err error) {
+	if flushErr := t.flush(); flushErr != nil {
+		fmt.Printf("写入步骤日志失败: %v\n", flushErr)
+	}
+	t.StepTracker.MarkStep(nodeID, step, err)
+}
+
+// jobStatus 部署任务的排队状态，运行中的任务附带进度
+type jobStatus struct {
+	job.Status
+	Progress *kubeadm.Progress `json:"progress,omitempty"`
+}
+
+// jobsResponse 部署任务队列状态
+type jobsResponse struct {
+	Jobs          []jobStatus `json:"jobs"`
+	MaxConcurrent int         `json:"maxConcurrent"`
+}
+
+// listJobs 获取运行中和排队的部署任务
+func (h *Handler) listJobs(c *gin.Context) {
+	jobs := []jobStatus{}
+	for _, status := range h.jobQueue.List() {
+		if !h.jobInProject(c, status.Keys) {
+			continue
+		}
+		item := jobStatus{Status: status}
+		if progress, ok := h.deploymentStore.Progress(status.ID); ok {
+			item.Progress = &progress
+		}
+		jobs = append(jobs, item)
+	}
+	c.JSON(http.StatusOK, jobsResponse{
+		Jobs:          jobs,
+		MaxConcurrent: h.jobQueue.MaxConcurrent(),
+	})
+}
+
+// listJobArtifacts 获取部署任务保存的产物
+func (h *Handler) listJobArtifacts(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.deploymentStore.GetDeployment(id); err != nil {
+		status := http.StatusInternalServerError
+		if err == kubeadm.ErrDeploymentNotFound {
+			status = http.StatusNotFound
+		}
+		api.Error(c, status, err)
+		return
+	}
+	artifacts, err := h.deploymentStore.ListArtifacts(id)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"artifacts": artifacts})
+}
+
+// listDeployments 获取部署记录列表
+func (h *Handler) listDeployments(c *gin.Context) {
+	all, err := h.deploymentStore.ListDeployments(50)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	lang := api.Lang(c)
+	projectID := api.ProjectID(c)
+	deployments := make([]kubeadm.Deployment, 0, len(all))
+	for _, d := range all {
+		if h.deploymentProject(&d) != projectID {
+			continue
+		}
+		d.Localize(lang)
+		if progress, ok := h.deploymentStore.Progress(d.ID); ok {
+			d.Progress = &progress
+		}
+		deployments = append(deployments, d)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"deployments": deployments,
+	})
+}
+
+// getDeployment 获取部署记录及步骤
+func (h *Handler) getDeployment(c *gin.Context) {
+	deployment, err := h.deploymentStore.GetDeployment(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == kubeadm.ErrDeploymentNotFound {
+			status = http.StatusNotFound
+		}
+		api.Error(c, status, err)
+		return
+	}
+	deployment.Localize(api.Lang(c))
+	if progress, ok := h.deploymentStore.Progress(deployment.ID); ok {
+		deployment.Progress = &progress
+	}
+	c.JSON(http.StatusOK, deployment)
+}
+
+// destructiveScripts 检查部署节点将使用的自定义脚本中的危险命令
+func (h *Handler) destructiveScripts(c *gin.Context, req deployClusterRequest) ([]script.DestructiveFinding, error) {
+	var nodes []node.Node
+	for _, id := range req.NodeIds {
+		if n, err := h.projectNodes(c).GetNode(id); err == nil {
+			nodes = append(nodes, *n)
+		}
+	}
+	groupDefaults, err := h.groupManager.DefaultsFor(nodes)
+	if err != nil {
+		return nil, err
+	}
+	scripts, err := h.scriptManager.ForProject(api.ProjectID(c))
+	if err != nil {
+		return nil, err
+	}
+	opts := kubeadm.DeployOptions{SingleNode: req.SingleNode, NodeGroupDefaults: groupDefaults}
+	return kubeadm.DestructiveScripts(scripts, nodes, req.KubeVersion, req.Distro, opts), nil
+}
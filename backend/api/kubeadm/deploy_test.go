@@ -0,0 +1,219 @@
+package kubeadm
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"k8s-installer/api"
+	"k8s-installer/job"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"k8s-installer/script"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testProject 测试中使用的非默认项目
+const testProject = "team-a"
+
+type testServer struct {
+	router          *gin.Engine
+	nodeManager     *node.SqliteNodeManager
+	deploymentStore *kubeadm.DeploymentStore
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	deploymentStore, err := kubeadm.NewDeploymentStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupManager, err := node.NewGroupManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectManager, err := node.NewProjectManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projectManager.CreateProject(node.Project{ID: testProject}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(api.ProjectScope(projectManager))
+	NewHandler(nodeManager, script.NewScriptManager(), deploymentStore, nil, nil, nil, lock.NewManager(), groupManager, job.NewQueue(1), nil).Register(api.NewRouter(r, nil))
+	return &testServer{router: r, nodeManager: nodeManager, deploymentStore: deploymentStore}
+}
+
+// do 以project项目的身份发送请求，project为空时使用默认项目
+func (s *testServer) do(method, path, project string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if raw, ok := body.(string); ok {
+		data = []byte(raw)
+	} else if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if project != "" {
+		req.Header.Set(api.ProjectHeader, project)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *testServer) createNode(t *testing.T, name, ip, project string) *node.Node {
+	t.Helper()
+	n, err := s.nodeManager.CreateNode(node.Node{Name: name, IP: ip, Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeMaster, ProjectID: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// validationFields 422响应中出错的字段
+func validationFields(t *testing.T, w *httptest.ResponseRecorder) map[string]bool {
+	t.Helper()
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422, body %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Fields []struct {
+			Field string `json:"field"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]bool)
+	for _, f := range resp.Fields {
+		fields[f.Field] = true
+	}
+	return fields
+}
+
+func deployRequest(nodeIDs ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"kubeVersion": "1.30.2",
+		"arch":        "amd64",
+		"distro":      "ubuntu",
+		"nodeIds":     nodeIDs,
+	}
+}
+
+func TestDeployMalformedRequest(t *testing.T) {
+	s := newTestServer(t)
+	if w := s.do(http.MethodPost, "/k8s/deploy", "", "not json"); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want 400", w.Code)
+	}
+	// kubeVersion、arch和distro为必填字段
+	if w := s.do(http.MethodPost, "/k8s/deploy", "", map[string]interface{}{"nodeIds": []string{"node-1"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing required fields: status %d, want 400", w.Code)
+	}
+}
+
+func TestDeployUnknownSkipSteps(t *testing.T) {
+	s := newTestServer(t)
+	req := deployRequest("node-1")
+	req["skipSteps"] = []string{kubeadm.StepSystemPreparation, "make_coffee"}
+
+	w := s.do(http.MethodPost, "/k8s/deploy", "", req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400, body %s", w.Code, w.Body)
+	}
+	var resp struct {
+		UnknownSteps []string `json:"unknownSteps"`
+		AllowedSteps []string `json:"allowedSteps"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.UnknownSteps) != 1 || resp.UnknownSteps[0] != "make_coffee" {
+		t.Fatalf("unknownSteps = %v", resp.UnknownSteps)
+	}
+	allowed := false
+	for _, step := range resp.AllowedSteps {
+		allowed = allowed || step == kubeadm.StepSystemPreparation
+	}
+	if !allowed {
+		t.Fatalf("allowedSteps %v does not include %s", resp.AllowedSteps, kubeadm.StepSystemPreparation)
+	}
+}
+
+func TestDeployValidation(t *testing.T) {
+	s := newTestServer(t)
+
+	req := deployRequest()
+	req["kubeVersion"] = "latest"
+	fields := validationFields(t, s.do(http.MethodPost, "/k8s/deploy", "", req))
+	for _, want := range []string{"kubeVersion", "nodeIds"} {
+		if !fields[want] {
+			t.Errorf("no validation error for %s: %v", want, fields)
+		}
+	}
+
+	// nodeSkipSteps只能指定本次部署的节点，且步骤必须存在
+	req = deployRequest("node-1")
+	req["nodeSkipSteps"] = map[string][]string{"node-2": {kubeadm.StepSystemPreparation}}
+	if fields := validationFields(t, s.do(http.MethodPost, "/k8s/deploy", "", req)); !fields["nodeSkipSteps.node-2"] {
+		t.Errorf("no validation error for nodeSkipSteps.node-2: %v", fields)
+	}
+	req["nodeSkipSteps"] = map[string][]string{"node-1": {"make_coffee"}}
+	if fields := validationFields(t, s.do(http.MethodPost, "/k8s/deploy", "", req)); len(fields) == 0 {
+		t.Error("unknown node skip step was accepted")
+	}
+}
+
+func TestDeployUnknownGroup(t *testing.T) {
+	s := newTestServer(t)
+	req := deployRequest()
+	req["groupIds"] = []string{"missing"}
+	if w := s.do(http.MethodPost, "/k8s/deploy", "", req); w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404, body %s", w.Code, w.Body)
+	}
+}
+
+func TestDeploymentProjectScoping(t *testing.T) {
+	s := newTestServer(t)
+	other := s.createNode(t, "master-a", "10.0.0.10", testProject)
+	own := s.createNode(t, "master-b", "10.0.0.11", "")
+	otherDeployment, err := s.deploymentStore.CreateDeployment(kubeadm.Deployment{ID: "deploy-a", KubeVersion: "1.30.2", NodeIDs: []string{other.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.deploymentStore.CreateDeployment(kubeadm.Deployment{ID: "deploy-b", KubeVersion: "1.30.2", NodeIDs: []string{own.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := s.do(http.MethodGet, "/deployments/"+otherDeployment.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("get from another project: status %d, want 404", w.Code)
+	}
+	if w := s.do(http.MethodGet, "/deployments/"+otherDeployment.ID, testProject, nil); w.Code != http.StatusOK {
+		t.Fatalf("get: status %d, body %s", w.Code, w.Body)
+	}
+
+	w := s.do(http.MethodGet, "/deployments", "", nil)
+	var resp struct {
+		Deployments []kubeadm.Deployment `json:"deployments"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Deployments) != 1 || resp.Deployments[0].ID != "deploy-b" {
+		t.Fatalf("list returned %+v, want only deploy-b", resp.Deployments)
+	}
+}
@@ -0,0 +1,46 @@
+package kubeadm
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/diagnose"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// diagnoseRequest 故障诊断请求
+type diagnoseRequest struct {
+	Output string `json:"output"`
+}
+
+// diagnoseResponse 故障诊断结果
+type diagnoseResponse struct {
+	Diagnoses []diagnose.Diagnosis `json:"diagnoses"`
+}
+
+// Validate 检查输出不为空
+func (r diagnoseRequest) Validate() error {
+	v := &validate.Validator{}
+	v.Required("output", r.Output)
+	return v.Err()
+}
+
+// diagnoseOutput 根据步骤输出或错误文本识别常见故障并返回处理建议
+func (h *Handler) diagnoseOutput(c *gin.Context) {
+	var req diagnoseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	diagnoses := diagnose.Diagnose(req.Output, api.Lang(c))
+	if diagnoses == nil {
+		diagnoses = []diagnose.Diagnosis{}
+	}
+	c.JSON(http.StatusOK, diagnoseResponse{Diagnoses: diagnoses})
+}
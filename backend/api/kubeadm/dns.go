@@ -0,0 +1,70 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dnsCheckRequest 节点DNS检查请求
+type dnsCheckRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+	kubeadm.DNSCheckOptions
+}
+
+// dnsCheckResponse 节点DNS检查结果
+type dnsCheckResponse struct {
+	Passed bool                    `json:"passed"`
+	Nodes  []kubeadm.NodeDNSReport `json:"nodes"`
+}
+
+// Validate 检查节点和域名
+func (r dnsCheckRequest) Validate() error {
+	v := &validate.Validator{}
+	if len(r.NodeIDs) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	v.Merge("", r.DNSCheckOptions.Validate())
+	return v.Err()
+}
+
+// checkDNS 检查节点上镜像仓库和控制平面地址的解析、systemd-resolved stub解析问题，部署后可选检查集群DNS
+func (h *Handler) checkDNS(c *gin.Context) {
+	var req dnsCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	var nodes []node.Node
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node %s: %v", id, err),
+			})
+			return
+		}
+		nodes = append(nodes, *n)
+	}
+
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), nodes, kubeadm.NodeDNSCheckCmd(req.DNSCheckOptions), node.ExecOptions{Source: c.ClientIP()}, nil)
+	resp := dnsCheckResponse{Passed: true, Nodes: make([]kubeadm.NodeDNSReport, 0, len(results))}
+	for i, res := range results {
+		report := kubeadm.ParseNodeDNSCheck(nodes[i], res)
+		resp.Passed = resp.Passed && report.Passed
+		resp.Nodes = append(resp.Nodes, report)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,47 @@
+package kubeadm
+
+import (
+	"errors"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getClusterDrift 比较集群成员节点与kubectl get nodes的结果，cached=true时返回定期检查保存的最近一次结果。
+// 无法从master节点读取节点列表时返回502，响应中的error为失败原因
+func (h *Handler) getClusterDrift(c *gin.Context) {
+	masterNode, _, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+
+	if c.Query("cached") == "true" {
+		report, ok := h.driftReconciler.LastReport(masterNode.ID)
+		if !ok {
+			api.Error(c, http.StatusNotFound, fmt.Errorf("cluster %s has not been checked yet", masterNode.ID))
+			return
+		}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	report, err := h.driftReconciler.Reconcile(masterNode.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, kubeadm.ErrDeploymentNotFound) {
+			status = http.StatusNotFound
+			err = fmt.Errorf("no deployment found for cluster %s", masterNode.ID)
+		}
+		api.Error(c, status, err)
+		return
+	}
+	if report.Error != "" {
+		c.JSON(http.StatusBadGateway, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
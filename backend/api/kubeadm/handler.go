@@ -0,0 +1,95 @@
+package kubeadm
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/event"
+	"k8s-installer/job"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+// Handler kubeadm、集群和部署接口
+type Handler struct {
+	nodeManager          *node.SqliteNodeManager
+	scriptManager        *script.ScriptManager
+	deploymentStore      *kubeadm.DeploymentStore
+	eventBus             *event.Bus
+	versionManager       *kubeadm.VersionManager
+	packageSourceManager *kubeadm.PackageSourceManager
+	lockManager          *lock.Manager
+	groupManager         *node.GroupManager
+	jobQueue             *job.Queue
+	driftReconciler      *kubeadm.DriftReconciler
+}
+
+// NewHandler 创建kubeadm、集群和部署接口处理器
+func NewHandler(nodeManager *node.SqliteNodeManager, scriptManager *script.ScriptManager, deploymentStore *kubeadm.DeploymentStore, eventBus *event.Bus, versionManager *kubeadm.VersionManager, packageSourceManager *kubeadm.PackageSourceManager, lockManager *lock.Manager, groupManager *node.GroupManager, jobQueue *job.Queue, driftReconciler *kubeadm.DriftReconciler) *Handler {
+	return &Handler{
+		nodeManager:          nodeManager,
+		scriptManager:        scriptManager,
+		deploymentStore:      deploymentStore,
+		eventBus:             eventBus,
+		versionManager:       versionManager,
+		packageSourceManager: packageSourceManager,
+		lockManager:          lockManager,
+		groupManager:         groupManager,
+		jobQueue:             jobQueue,
+		driftReconciler:      driftReconciler,
+	}
+}
+
+// Register 注册kubeadm、集群和部署路由
+func (h *Handler) Register(r *api.Router) {
+	kubeadmRoutes := r.Group("/kubeadm")
+	// 集群ID即master节点ID，部署记录按部署节点归属项目，其他项目的集群和部署记录按不存在处理
+	clusterRoutes := r.Group("/clusters", api.RequireProject("cluster", api.NodeProject(h.nodeManager)))
+	deploymentRoutes := r.Group("/deployments", api.RequireProject("deployment", h.deploymentProjectByID))
+
+	kubeadmRoutes.GET("/version", api.Operation{Tag: "kubeadm", Summary: "查询master节点上的kubeadm版本", Query: []api.Param{{Name: "masterNodeId", Description: "master节点ID", Required: true}}}, h.getVersion)
+	kubeadmRoutes.GET("/preflight", api.Operation{Tag: "kubeadm", Summary: "系统预检"}, h.preflight)
+	kubeadmRoutes.POST("/preflight/connectivity", api.Operation{Tag: "kubeadm", Summary: "部署前检查节点间端口连通性", Description: "在每个节点上临时监听apiserver、kubelet和VXLAN端口，并从其他节点探测，返回节点间open/blocked的连通性矩阵", Request: connectivityRequest{}, Response: kubeadm.ConnectivityMatrix{}}, h.checkConnectivity)
+	kubeadmRoutes.POST("/preflight/dns", api.Operation{Tag: "kubeadm", Summary: "检查节点DNS配置", Description: "检查节点对镜像仓库和控制平面地址的解析，检测systemd-resolved stub解析导致的CoreDNS转发循环；clusterDns为true时在部署后从节点通过集群DNS解析kubernetes.default", Request: dnsCheckRequest{}, Response: dnsCheckResponse{}}, h.checkDNS)
+	kubeadmRoutes.POST("/preflight/cgroup", api.Operation{Tag: "kubeadm", Summary: "检查节点cgroup版本和驱动", Description: "检测节点的cgroup版本、init系统以及containerd和kubelet使用的cgroup驱动，所选Kubernetes版本不支持cgroup v1或节点之间需要的驱动不一致时检查不通过", Request: cgroupCheckRequest{}, Response: cgroupCheckResponse{}}, h.checkCgroup)
+	kubeadmRoutes.POST("/preflight/runtime", api.Operation{Tag: "kubeadm", Summary: "检查节点容器运行时", Description: "通过crictl info/version（未安装crictl时使用ctr version）检查containerd的CRI socket、运行时版本和RuntimeReady、NetworkReady状态，任一节点运行时未就绪时检查不通过", Request: runtimeCheckRequest{}, Response: runtimeCheckResponse{}}, h.checkRuntime)
+	kubeadmRoutes.POST("/preflight/compatibility", api.Operation{Tag: "kubeadm", Summary: "检查节点与Kubernetes版本的兼容性", Description: "按兼容性矩阵检查节点的发行版版本和节点组指定的containerd版本是否支持所选Kubernetes版本，不兼容时返回可选的发行版、Kubernetes或containerd版本；没有缓存操作系统信息的节点先检测", Request: compatCheckRequest{}, Response: compatCheckResponse{}}, h.checkCompatibility)
+	kubeadmRoutes.GET("/compatibility", api.Operation{Tag: "kubeadm", Summary: "获取兼容性矩阵", Description: "Kubernetes版本与发行版版本、containerd版本的兼容范围，部署时不兼容的节点返回422（ERR_INCOMPATIBLE），ignoreCompatibility为true时只输出警告", Response: kubeadm.Compatibility{}}, h.getCompatibilityMatrix)
+	kubeadmRoutes.POST("/diagnose", api.Operation{Tag: "kubeadm", Summary: "诊断步骤输出中的常见故障", Description: "根据步骤输出或错误文本识别kubelet不可达、cgroup驱动不一致、镜像拉取失败、令牌过期等常见故障，返回按Accept-Language本地化的处理建议", Request: diagnoseRequest{}, Response: diagnoseResponse{}}, h.diagnoseOutput)
+	kubeadmRoutes.GET("/packages", api.Operation{Tag: "kubeadm", Summary: "获取可用的Kubernetes版本"}, h.listPackages)
+	kubeadmRoutes.GET("/versions", api.Operation{Tag: "kubeadm", Summary: "获取带次版本和EOL信息的版本列表", Query: []api.Param{{Name: "minor", Description: "按次版本过滤，如1.30"}, {Name: "includeEol", Description: "是否包含已停止维护的版本，默认true"}}}, h.listVersionInfos)
+	kubeadmRoutes.GET("/versions/refresh", api.Operation{Tag: "kubeadm", Summary: "立即同步版本列表"}, h.refreshVersions)
+	kubeadmRoutes.GET("/sources", api.Operation{Tag: "kubeadm", Summary: "获取包源列表", Response: []kubeadm.PackageSource{}}, h.listSources)
+	kubeadmRoutes.PUT("/sources/:id", api.Operation{Tag: "kubeadm", Summary: "更新包源", Request: kubeadm.PackageSource{}, Response: kubeadm.PackageSource{}}, h.updateSource)
+	kubeadmRoutes.POST("/sources", api.Operation{Tag: "kubeadm", Summary: "添加包源", Request: kubeadm.PackageSource{}, Response: kubeadm.PackageSource{}}, h.createSource)
+	kubeadmRoutes.DELETE("/sources/:id", api.Operation{Tag: "kubeadm", Summary: "删除包源"}, h.deleteSource)
+	kubeadmRoutes.GET("/sources/:id/test", api.Operation{Tag: "kubeadm", Summary: "测试包源可达性"}, h.testSource)
+	kubeadmRoutes.GET("/packages/local", api.Operation{Tag: "kubeadm", Summary: "获取已下载的包列表"}, h.listLocalPackages)
+	kubeadmRoutes.DELETE("/packages/local", api.Operation{Tag: "kubeadm", Summary: "删除本地包", Request: deleteLocalPackageRequest{}}, h.deleteLocalPackage)
+	kubeadmRoutes.POST("/packages/download", api.Operation{Tag: "kubeadm", Summary: "下载Kubernetes组件包", Request: downloadPackageRequest{}}, h.downloadPackage)
+	kubeadmRoutes.POST("/packages/deploy", api.Operation{Tag: "kubeadm", Summary: "将本地包分发到节点", Request: deployPackageRequest{}}, h.deployPackage)
+	kubeadmRoutes.POST("/init", api.Operation{Tag: "kubeadm", Summary: "初始化master节点", Request: initClusterRequest{}}, h.initCluster)
+	kubeadmRoutes.POST("/images/pull", api.Operation{Tag: "kubeadm", Summary: "在master节点上拉取Kubernetes镜像", Request: pullImagesRequest{}}, h.pullImages)
+	kubeadmRoutes.GET("/join-command", api.Operation{Tag: "kubeadm", Summary: "获取worker节点加入集群的命令"}, h.getJoinCommand)
+	clusterRoutes.POST("/adopt", api.Operation{Tag: "clusters", Summary: "导入已有集群", Description: "通过master节点的kubectl读取已有集群的版本、节点和CNI插件，将成员节点导入节点列表并标记为adopted，集群ID为master节点ID", Request: adoptClusterRequest{}, Response: adoptClusterResponse{}}, h.adoptCluster)
+	clusterRoutes.POST("/:id/verify", api.Operation{Tag: "clusters", Summary: "验证集群状态", Description: "使用保存的集群kubeconfig直接访问API Server检查节点、核心Pod、DNS和跨节点网络，没有kubeconfig时先通过SSH从master节点读取并保存；API Server不可达时改为在master节点上执行kubectl，响应的access为实际使用的方式", Request: kubeadm.VerifyOptions{}, Response: kubeadm.VerificationReport{}}, h.verifyCluster)
+	clusterRoutes.GET("/:id/tokens", api.Operation{Tag: "clusters", Summary: "列出bootstrap令牌"}, h.listTokens)
+	clusterRoutes.POST("/:id/tokens", api.Operation{Tag: "clusters", Summary: "创建bootstrap令牌和join命令", Request: createTokenRequest{}}, h.createToken)
+	clusterRoutes.DELETE("/:id/tokens/:token", api.Operation{Tag: "clusters", Summary: "吊销bootstrap令牌"}, h.deleteToken)
+	kubeadmRoutes.POST("/reset", api.Operation{Tag: "kubeadm", Summary: "重置master节点", Request: resetClusterRequest{}}, h.resetCluster)
+	clusterRoutes.POST("/:id/helm/install", api.Operation{Tag: "clusters", Summary: "通过Helm部署Chart", Request: kubeadm.HelmChartOptions{}}, h.installHelmChart)
+	clusterRoutes.POST("/:id/nodes", api.Operation{Tag: "clusters", Summary: "向已有集群添加worker节点", Description: "使用集群部署时的版本和发行版，只对新节点执行节点准备和加入集群步骤；join命令由新创建的令牌生成，成功后节点加入集群成员", Request: addClusterNodesRequest{}}, h.addClusterNodes)
+	clusterRoutes.PUT("/:id/package-pinning", api.Operation{Tag: "clusters", Summary: "固定或解除固定集群的Kubernetes组件版本", Description: "在集群所有成员节点上执行apt-mark hold/unhold、dnf/yum versionlock或zypper addlock/removelock，并保存为集群的packagePinning设置，之后添加的节点按该设置固定版本；升级Kubernetes组件之前以enabled=false解除固定。任一节点执行失败时返回502，设置不变", Request: packagePinningRequest{}, Response: packagePinningResponse{}}, h.setPackagePinning)
+	clusterRoutes.GET("/:id/drift", api.Operation{Tag: "clusters", Summary: "检查数据库记录与集群实际状态的差异", Description: "通过API Server（不可达时通过master节点的kubectl get nodes）比较集群成员的名称、版本和就绪状态，列出集群中未登记的节点、登记但不在集群中的节点、版本不一致和未就绪的节点；cached=true时返回定期检查的最近一次结果", Query: []api.Param{{Name: "cached", Description: "为true时返回最近一次检查结果，不连接master节点"}}, Response: kubeadm.DriftReport{}}, h.getClusterDrift)
+	clusterRoutes.GET("/:id/health", api.Operation{Tag: "clusters", Summary: "获取集群健康状态", Description: "读取API Server的/readyz检查、节点状态条件、未就绪的Pod和最近的Warning事件；API Server不可达时通过master节点上的kubectl get --raw读取，access为实际使用的方式；无法访问集群时返回502", Response: kubeadm.ClusterHealth{}}, h.getClusterHealth)
+	clusterRoutes.GET("/:id/topology", api.Operation{Tag: "clusters", Summary: "获取集群拓扑", Description: "按节点列出运行的Pod及其阶段、就绪状态和重启次数，unscheduled为尚未调度的Pod", Response: kubeadm.ClusterTopology{}}, h.getClusterTopology)
+	clusterRoutes.POST("/:id/teardown", api.Operation{Tag: "clusters", Summary: "拆除集群的所有成员节点", Request: teardownClusterRequest{}}, h.teardownCluster)
+	kubeadmRoutes.POST("/join", api.Operation{Tag: "kubeadm", Summary: "将worker节点加入集群", Request: joinWorkerRequest{}}, h.joinWorker)
+	r.POST("/k8s/deploy", api.Operation{Tag: "deployments", Summary: "部署Kubernetes集群", Description: "skipSteps中包含未知步骤时返回400，响应的unknownSteps列出未知步骤，allowedSteps列出可用的步骤", Request: deployClusterRequest{}}, h.deployCluster)
+	r.GET("/templates", api.Operation{Tag: "deployments", Summary: "获取集群规模模板", Description: "预定义的单节点开发环境、1+2小型集群和1+N生产集群模板，部署只支持单个控制平面节点，模板均不提供控制平面高可用；包含控制平面和worker节点数以及推荐的网段、kubelet资源预留和插件"}, h.listTemplates)
+	r.GET("/templates/:id", api.Operation{Tag: "deployments", Summary: "按模板为节点分配角色并生成部署请求", Description: "指定nodeIds时按模板为节点分配控制平面和worker角色，节点数不符合模板时返回422；request为部署请求的起点，补充kubeVersion、arch和distro后提交到POST /k8s/deploy。节点类型与角色不一致时在warnings中列出", Query: []api.Param{{Name: "nodeIds", Description: "逗号分隔的节点ID，已是master类型的节点优先作为控制平面"}}, Response: templateExpansion{}}, h.getTemplate)
+	r.GET("/jobs", api.Operation{Tag: "deployments", Summary: "获取运行中和排队的部署任务", Description: "排队的任务按执行顺序排列，position为排队位置；优先级高的任务先执行，作用于同一节点或集群的任务串行执行", Response: jobsResponse{}}, h.listJobs)
+	r.GET("/jobs/:id/artifacts", api.Operation{Tag: "deployments", Summary: "获取部署任务的产物", Description: "返回部署过程中保存的kubeadm init输出、join命令、证书密钥和kubeconfig位置，任务ID即部署ID"}, api.RequireProject("deployment", h.deploymentProjectByID), h.listJobArtifacts)
+	deploymentRoutes.GET("", api.Operation{Tag: "deployments", Summary: "获取最近的部署记录"}, h.listDeployments)
+	deploymentRoutes.GET("/:id", api.Operation{Tag: "deployments", Summary: "获取部署记录及步骤", Response: kubeadm.Deployment{}}, h.getDeployment)
+}
@@ -0,0 +1,46 @@
+package kubeadm
+
+import (
+	"net/http"
+
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getClusterHealth 读取集群健康报告，无法访问API Server也无法通过master节点读取时返回502
+func (h *Handler) getClusterHealth(c *gin.Context) {
+	masterNode, _, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+
+	executor := kubeadm.NewClusterExecutor(h.deploymentStore, *masterNode)
+	defer executor.Close()
+	health, err := executor.Health(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
+
+// getClusterTopology 读取每个节点上运行的Pod，无法访问集群时返回502
+func (h *Handler) getClusterTopology(c *gin.Context) {
+	masterNode, _, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+
+	executor := kubeadm.NewClusterExecutor(h.deploymentStore, *masterNode)
+	defer executor.Close()
+	topology, err := executor.Topology(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, topology)
+}
@@ -0,0 +1,93 @@
+package kubeadm
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maskPassword 掩码密码，只显示前2个字符和后2个字符
+func maskPassword(password string) string {
+	if password == "" {
+		return "<空>"
+	}
+	if len(password) <= 4 {
+		return strings.Repeat("*", len(password))
+	}
+	return password[:2] + strings.Repeat("*", len(password)-4) + password[len(password)-2:]
+}
+
+// maskPrivateKey 掩码私钥，只显示前20个字符和后20个字符
+func maskPrivateKey(privateKey string) string {
+	if privateKey == "" {
+		return "<空>"
+	}
+	if len(privateKey) <= 40 {
+		return strings.Repeat("*", len(privateKey))
+	}
+	return privateKey[:20] + "...(省略)..." + privateKey[len(privateKey)-20:]
+}
+
+// containsString 检查字符串切片中是否包含指定值
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectUnknownSkipSteps skipSteps中存在未知步骤时返回400，响应中列出未知步骤和可用的步骤
+func rejectUnknownSkipSteps(c *gin.Context, steps []string) bool {
+	err := kubeadm.ValidateSkipSteps(steps)
+	var unknownErr *kubeadm.UnknownStepsError
+	if !errors.As(err, &unknownErr) {
+		return false
+	}
+	resp := api.ErrorResponse(c, http.StatusBadRequest, err)
+	resp["unknownSteps"] = unknownErr.Unknown
+	resp["allowedSteps"] = unknownErr.Allowed
+	c.JSON(http.StatusBadRequest, resp)
+	return true
+}
+
+// projectNodes 请求所属项目中的节点，其他项目的节点按不存在处理
+func (h *Handler) projectNodes(c *gin.Context) node.ProjectNodes {
+	return h.nodeManager.InProject(api.ProjectID(c))
+}
+
+// deploymentProject 部署记录所属的项目
+func (h *Handler) deploymentProject(d *kubeadm.Deployment) string {
+	return api.DeploymentProject(h.nodeManager, d)
+}
+
+// deploymentProjectByID 按部署ID查询所属的项目，用于api.RequireProject
+func (h *Handler) deploymentProjectByID(id string) (string, error) {
+	d, err := h.deploymentStore.GetDeployment(id)
+	if err != nil {
+		return "", err
+	}
+	return h.deploymentProject(d), nil
+}
+
+// jobInProject 任务锁定的节点或集群是否属于请求所属的项目
+func (h *Handler) jobInProject(c *gin.Context, keys []string) bool {
+	nodes := h.projectNodes(c)
+	for _, key := range keys {
+		id, ok := strings.CutPrefix(key, "node:")
+		if !ok {
+			id, ok = strings.CutPrefix(key, "cluster:")
+		}
+		if ok && nodes.Contains(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package kubeadm
+
+import (
+	"errors"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// packagePinningRequest 集群的软件包版本固定设置
+type packagePinningRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// packagePinningResponse 固定或解除固定的执行结果
+type packagePinningResponse struct {
+	ClusterID string            `json:"clusterId"`
+	Enabled   bool              `json:"enabled"`
+	Nodes     []node.ExecResult `json:"nodes"`
+}
+
+// setPackagePinning 在集群所有成员节点上固定或解除固定kubelet、kubeadm和kubectl的版本并保存为集群设置。
+// 升级Kubernetes组件之前以enabled=false解除固定，升级完成后以enabled=true重新固定；任一节点执行失败时返回502，设置不变
+func (h *Handler) setPackagePinning(c *gin.Context) {
+	var req packagePinningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+
+	masterNode, _, err := h.clusterMaster(c, c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	cluster, err := h.deploymentStore.FindClusterDeployment(masterNode.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, kubeadm.ErrDeploymentNotFound) {
+			status = http.StatusNotFound
+			err = fmt.Errorf("no deployment found for cluster %s", masterNode.ID)
+		}
+		api.Error(c, status, err)
+		return
+	}
+	if cluster.InstallerType == kubeadm.InstallerTypeK3s {
+		api.Error(c, http.StatusBadRequest, fmt.Errorf("package pinning is only supported for kubeadm clusters"))
+		return
+	}
+
+	members := make([]node.Node, 0, len(cluster.NodeIDs))
+	lockKeys := []string{lock.ClusterKey(masterNode.ID)}
+	for _, id := range cluster.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			api.Error(c, http.StatusNotFound, fmt.Errorf("node not found: %s", id))
+			return
+		}
+		members = append(members, *n)
+		lockKeys = append(lockKeys, lock.NodeKey(n.ID))
+	}
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "PackagePinning", lockKeys...)
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	cmd := node.PackageUnpinCmd
+	if req.Enabled {
+		cmd = node.PackagePinCmd(cluster.KubeVersion)
+	}
+	resp := packagePinningResponse{ClusterID: masterNode.ID, Enabled: req.Enabled}
+	resp.Nodes = h.nodeManager.ExecOnNodes(c.Request.Context(), members, cmd, node.ExecOptions{Source: c.ClientIP()}, nil)
+	for _, res := range resp.Nodes {
+		if !res.Success {
+			c.JSON(http.StatusBadGateway, resp)
+			return
+		}
+	}
+
+	if err := h.deploymentStore.SetPackagePinning(cluster.ID, req.Enabled); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,310 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/kubeadm"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getVersion 查询master节点上的kubeadm版本
+func (h *Handler) getVersion(c *gin.Context) {
+	masterNodeID := c.Query("masterNodeId")
+	if masterNodeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "masterNodeId is required",
+		})
+		return
+	}
+
+	// 获取master节点信息
+	masterNode, err := h.projectNodes(c).GetNode(masterNodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get master node: %v", err),
+		})
+		return
+	}
+
+	// 创建SSH配置，首先使用IP地址连接（确保在任何hosts文件更新之前都能连接）
+	sshConfig := kubeadm.SSHConfig{
+		Host:          masterNode.IP,
+		Port:          masterNode.Port,
+		Username:      masterNode.Username,
+		Password:      masterNode.Password,
+		PrivateKey:    masterNode.PrivateKey,
+		PasswordRef:   masterNode.PasswordRef,
+		PrivateKeyRef: masterNode.PrivateKeyRef,
+	}
+
+	version, err := kubeadm.CheckKubeadmVersion(sshConfig)
+	if err != nil {
+		// 记录详细错误日志
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"version": version,
+	})
+}
+
+// preflight 系统预检
+func (h *Handler) preflight(c *gin.Context) {
+	results := kubeadm.PreflightChecks()
+	c.JSON(http.StatusOK, gin.H{
+		"checks": results,
+	})
+}
+
+// listPackages 获取可用的Kubernetes版本
+func (h *Handler) listPackages(c *gin.Context) {
+	// 从版本管理器获取可用的Kubernetes版本列表
+	versions := h.versionManager.GetAvailableVersions()
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+	})
+}
+
+// listVersionInfos 获取带次版本和EOL信息的版本列表，支持按次版本过滤
+func (h *Handler) listVersionInfos(c *gin.Context) {
+	minor := c.Query("minor")
+	includeEOL := c.DefaultQuery("includeEol", "true") == "true"
+	c.JSON(http.StatusOK, gin.H{
+		"versions": h.versionManager.GetVersionInfos(minor, includeEOL),
+		"sync":     h.versionManager.GetSyncStatus(),
+	})
+}
+
+// refreshVersions 强制立即同步版本列表
+func (h *Handler) refreshVersions(c *gin.Context) {
+	status := h.versionManager.SyncVersions()
+	c.JSON(http.StatusOK, gin.H{
+		"sync":     status,
+		"versions": h.versionManager.GetVersionInfos("", true),
+	})
+}
+
+// listSources 获取包源列表
+func (h *Handler) listSources(c *gin.Context) {
+	sources, err := h.packageSourceManager.ListSources()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sources": sources,
+	})
+}
+
+// updateSource 更新包源
+func (h *Handler) updateSource(c *gin.Context) {
+	var source kubeadm.PackageSource
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	updated, err := h.packageSourceManager.UpdateSource(c.Param("id"), source)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == kubeadm.ErrPackageSourceNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "updated",
+		"source": updated,
+	})
+}
+
+// createSource 添加新包源
+func (h *Handler) createSource(c *gin.Context) {
+	var source kubeadm.PackageSource
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	created, err := h.packageSourceManager.CreateSource(source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "added",
+		"source": created,
+	})
+}
+
+// deleteSource 删除包源
+func (h *Handler) deleteSource(c *gin.Context) {
+	if err := h.packageSourceManager.DeleteSource(c.Param("id")); err != nil {
+		status := http.StatusInternalServerError
+		if err == kubeadm.ErrPackageSourceNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "deleted",
+	})
+}
+
+// testSource 测试包源可达性
+func (h *Handler) testSource(c *gin.Context) {
+	result, err := h.packageSourceManager.TestSource(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == kubeadm.ErrPackageSourceNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// listLocalPackages 获取已下载的包列表
+func (h *Handler) listLocalPackages(c *gin.Context) {
+	packages, err := kubeadm.ListLocalPackages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"packages": packages,
+	})
+}
+
+// deleteLocalPackageRequest 删除本地包请求
+type deleteLocalPackageRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Version string `json:"version" binding:"required"`
+	Arch    string `json:"arch" binding:"required"`
+	Distro  string `json:"distro" binding:"required"`
+}
+
+// deleteLocalPackage 删除本地包
+func (h *Handler) deleteLocalPackage(c *gin.Context) {
+	var req deleteLocalPackageRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := kubeadm.DeletePackage(req.Name, req.Version, req.Arch, req.Distro); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "deleted",
+	})
+}
+
+// downloadPackageRequest 下载Kubernetes组件包请求
+type downloadPackageRequest struct {
+	Version   string `json:"version" binding:"required"`
+	Arch      string `json:"arch" binding:"required"`
+	Distro    string `json:"distro" binding:"required"`
+	SourceURL string `json:"sourceURL"`
+}
+
+// downloadPackage 下载Kubernetes组件包
+func (h *Handler) downloadPackage(c *gin.Context) {
+	var req downloadPackageRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 下载指定版本的Kubeadm包
+	log := func(format string, args ...interface{}) {
+		fmt.Printf(format+"\n", args...)
+	}
+	packagePath, err := kubeadm.DownloadKubeadmPackage(req.Version, req.Arch, req.Distro, req.SourceURL, log)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"packagePath": packagePath,
+		"version":     req.Version,
+	})
+}
+
+// deployPackageRequest 将本地包分发到节点请求
+type deployPackageRequest struct {
+	PackagePath string `json:"packagePath" binding:"required"`
+	NodeIP      string `json:"nodeIP" binding:"required"`
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password"`
+	Port        int    `json:"port"`
+	PrivateKey  string `json:"privateKey"`
+}
+
+// deployPackage 将本地包分发到节点
+func (h *Handler) deployPackage(c *gin.Context) {
+	var req deployPackageRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 部署Kubeadm包到远程节点
+	log := func(format string, args ...interface{}) {
+		fmt.Printf(format+"\n", args...)
+	}
+	err := kubeadm.DeployKubeadmPackage(req.PackagePath, req.NodeIP, req.Username, req.Password, req.Port, req.PrivateKey, log)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "deployed",
+		"nodeIP": req.NodeIP,
+	})
+}
@@ -0,0 +1,68 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runtimeCheckRequest 节点容器运行时检查请求
+type runtimeCheckRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+}
+
+// runtimeCheckResponse 节点容器运行时检查结果
+type runtimeCheckResponse struct {
+	Passed bool                `json:"passed"`
+	Nodes  []kubeadm.CRIReport `json:"nodes"`
+}
+
+// Validate 检查节点列表
+func (r runtimeCheckRequest) Validate() error {
+	v := &validate.Validator{}
+	if len(r.NodeIDs) == 0 {
+		v.Add("nodeIds", "at least one node is required")
+	}
+	return v.Err()
+}
+
+// checkRuntime 通过CRI API检查节点上已安装的containerd，用于跳过容器运行时安装或加入已有集群之前确认运行时可用
+func (h *Handler) checkRuntime(c *gin.Context) {
+	var req runtimeCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	var nodes []node.Node
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node %s: %v", id, err),
+			})
+			return
+		}
+		nodes = append(nodes, *n)
+	}
+
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), nodes, kubeadm.CRIHealthCmd, node.ExecOptions{Source: c.ClientIP()}, nil)
+	resp := runtimeCheckResponse{Passed: true, Nodes: make([]kubeadm.CRIReport, 0, len(results))}
+	for i, res := range results {
+		report := kubeadm.BuildCRIReport(nodes[i], res)
+		resp.Passed = resp.Passed && report.Healthy
+		resp.Nodes = append(resp.Nodes, report)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,107 @@
+package kubeadm
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// templateKubeadmConfig 部署请求中模板设置的kubeadm网络配置
+type templateKubeadmConfig struct {
+	ClusterConfiguration struct {
+		Networking kubeadm.Networking `json:"networking"`
+	} `json:"clusterConfiguration"`
+}
+
+// templateDeployRequest 模板生成的部署请求，字段与POST /k8s/deploy的请求一致，
+// 补充kubeVersion、arch、distro后即可部署
+type templateDeployRequest struct {
+	NodeIds       []string                      `json:"nodeIds"`
+	SingleNode    bool                          `json:"singleNode,omitempty"`
+	KubeProxyMode string                        `json:"kubeProxyMode,omitempty"`
+	Kubelet       kubeadm.KubeletSettings       `json:"kubelet"`
+	KubeadmConfig templateKubeadmConfig         `json:"kubeadmConfig"`
+	ReadinessGate *kubeadm.ReadinessGateOptions `json:"readinessGate,omitempty"`
+	Addons        []string                      `json:"addons,omitempty"`
+}
+
+// templateExpansion 模板按节点展开的结果
+type templateExpansion struct {
+	Template kubeadm.ClusterTemplate    `json:"template"`
+	Roles    []kubeadm.TemplateNodeRole `json:"roles,omitempty"`
+	Request  templateDeployRequest      `json:"request"`
+	Warnings []string                   `json:"warnings,omitempty"`
+}
+
+// listTemplates 获取集群规模模板
+func (h *Handler) listTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"templates": kubeadm.ClusterTemplates})
+}
+
+// getTemplate 获取模板，指定nodeIds时为节点分配角色并生成部署请求
+func (h *Handler) getTemplate(c *gin.Context) {
+	template, ok := kubeadm.FindClusterTemplate(c.Param("id"))
+	if !ok {
+		api.Error(c, http.StatusNotFound, fmt.Errorf("cluster template %s not found", c.Param("id")))
+		return
+	}
+
+	settings := template.Settings
+	result := templateExpansion{Template: template}
+	result.Request = templateDeployRequest{
+		NodeIds:       []string{},
+		SingleNode:    template.SingleNode,
+		KubeProxyMode: settings.KubeProxyMode,
+		Kubelet:       settings.Kubelet,
+		Addons:        settings.Addons,
+	}
+	result.Request.KubeadmConfig.ClusterConfiguration.Networking = kubeadm.Networking{PodSubnet: settings.PodSubnet, ServiceSubnet: settings.ServiceSubnet}
+	if settings.ReadinessTimeoutMinutes > 0 {
+		result.Request.ReadinessGate = &kubeadm.ReadinessGateOptions{TimeoutMinutes: settings.ReadinessTimeoutMinutes}
+	}
+
+	var nodeIDs []string
+	for _, id := range strings.Split(c.Query("nodeIds"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+	if len(nodeIDs) == 0 {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	v := &validate.Validator{}
+	var nodes []node.Node
+	for _, id := range nodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			v.Add("nodeIds."+id, "node not found")
+			continue
+		}
+		nodes = append(nodes, *n)
+	}
+	if err := v.Err(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+	roles, err := template.AssignRoles(nodes)
+	if err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+	result.Roles = roles
+	for _, role := range roles {
+		result.Request.NodeIds = append(result.Request.NodeIds, role.NodeID)
+		if mismatch := role.RoleMismatch(); mismatch != "" {
+			result.Warnings = append(result.Warnings, mismatch)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s-installer/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIdleTimeout 客户端超过该时间没有请求时删除其令牌桶
+const clientIdleTimeout = 10 * time.Minute
+
+// bucket 单个客户端的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter 按客户端IP的令牌桶限流器
+type rateLimiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// allow 消耗客户端的一个令牌，没有令牌时返回需要等待的时间
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// 定期删除长时间没有请求的客户端，避免内存持续增长
+	if now.Sub(l.swept) > clientIdleTimeout {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) > clientIdleTimeout {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// RateLimit 按客户端IP限制请求频率的中间件，超出限制时返回429和Retry-After，RequestsPerMinute为0时不限制
+func RateLimit(cfg config.LimitsConfig) gin.HandlerFunc {
+	if cfg.RequestsPerMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := &rateLimiter{
+		rate:    float64(cfg.RequestsPerMinute) / 60,
+		burst:   float64(cfg.Burst),
+		buckets: make(map[string]*bucket),
+		swept:   time.Now(),
+	}
+	return func(c *gin.Context) {
+		ok, wait := limiter.allow(c.ClientIP(), time.Now())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			Error(c, http.StatusTooManyRequests, fmt.Errorf("rate limit of %d requests per minute exceeded", cfg.RequestsPerMinute))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// BodyLimit 限制请求体大小的中间件，Content-Length超出限制时直接返回413，未声明长度的请求体读取超出限制时报错，
+// MaxBodyBytes为0时不限制
+func BodyLimit(cfg config.LimitsConfig) gin.HandlerFunc {
+	if cfg.MaxBodyBytes <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > cfg.MaxBodyBytes {
+			Error(c, http.StatusRequestEntityTooLarge, fmt.Errorf("request body of %d bytes exceeds the limit of %d bytes", c.Request.ContentLength, cfg.MaxBodyBytes))
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxBodyBytes)
+		}
+		c.Next()
+	}
+}
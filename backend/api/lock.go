@@ -0,0 +1,28 @@
+package api
+
+import (
+	"k8s-installer/errcode"
+	"k8s-installer/lock"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcquireLocks 获取节点和集群锁，已被其他任务锁定时返回409和持有锁的任务ID
+func AcquireLocks(c *gin.Context, manager *lock.Manager, jobID, operation string, keys ...string) (*lock.Lease, bool) {
+	lease, err := manager.Acquire(jobID, operation, keys...)
+	if err != nil {
+		lockedErr := err.(*lock.LockedError)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     err.Error(),
+			"code":      errcode.Locked,
+			"message":   errcode.Message(errcode.Locked, Lang(c)),
+			"lockedKey": lockedErr.Key,
+			"jobId":     lockedErr.Holder.JobID,
+			"operation": lockedErr.Holder.Operation,
+			"lockedAt":  lockedErr.Holder.AcquiredAt,
+		})
+		return nil, false
+	}
+	return lease, true
+}
@@ -0,0 +1,33 @@
+package logs
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+)
+
+// Handler 操作日志接口
+type Handler struct {
+	nodeManager     *node.SqliteNodeManager
+	deploymentStore *kubeadm.DeploymentStore
+}
+
+// NewHandler 创建操作日志接口处理器，部署记录用于判断集群级别的日志所属的项目
+func NewHandler(nodeManager *node.SqliteNodeManager, deploymentStore *kubeadm.DeploymentStore) *Handler {
+	return &Handler{
+		nodeManager:     nodeManager,
+		deploymentStore: deploymentStore,
+	}
+}
+
+// Register 注册操作日志路由
+func (h *Handler) Register(r *api.Router) {
+	// 只返回请求所属项目的日志，其他项目的节点日志按节点不存在处理
+	logRoutes := r.Group("/logs", api.RequireProject("node", api.NodeProject(h.nodeManager)))
+
+	logRoutes.GET("", api.Operation{Tag: "logs", Summary: "获取项目的所有日志"}, h.listLogs)
+	logRoutes.GET("/node/:id", api.Operation{Tag: "logs", Summary: "获取指定节点的日志"}, h.listNodeLogs)
+	logRoutes.GET("/export", api.Operation{Tag: "logs", Summary: "导出日志", Query: []api.Param{{Name: "format", Description: "ndjson（默认）或csv"}, {Name: "nodeId", Description: "按节点过滤"}, {Name: "operation", Description: "按操作类型过滤"}, {Name: "jobId", Description: "按任务ID过滤"}, {Name: "type", Description: "按日志类型过滤：script-output、step或system"}}, Produces: "application/x-ndjson"}, h.exportLogs)
+	logRoutes.DELETE("", api.Operation{Tag: "logs", Summary: "清除项目的所有日志"}, h.clearLogs)
+	logRoutes.GET("/stream", api.Operation{Tag: "logs", Summary: "实时日志流（SSE）", Description: "过滤条件在服务端按订阅生效，只推送匹配的日志", Query: []api.Param{{Name: "nodeId", Description: "按节点过滤"}, {Name: "operation", Description: "按操作类型过滤"}, {Name: "jobId", Description: "按任务ID过滤，如部署ID"}, {Name: "minLevel", Description: "最低日志级别：debug、info、warn或error"}, {Name: "type", Description: "按日志类型过滤：script-output、step或system"}}, Produces: "text/event-stream"}, h.streamLogs)
+}
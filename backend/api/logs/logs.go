@@ -0,0 +1,223 @@
+package logs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/log"
+	"k8s-installer/validate"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listLogs 获取请求所属项目的所有日志
+func (h *Handler) listLogs(c *gin.Context) {
+	all, err := h.nodeManager.GetLogs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	allow := h.projectLogFilter(c)
+	logs := make([]log.LogEntry, 0, len(all))
+	for _, entry := range all {
+		if allow(entry) {
+			logs = append(logs, entry)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"logs": logs,
+	})
+}
+
+// listNodeLogs 获取指定节点的日志
+func (h *Handler) listNodeLogs(c *gin.Context) {
+	id := c.Param("id")
+	logs, err := h.nodeManager.GetLogsByNode(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"logs": logs,
+	})
+}
+
+// exportLogs 导出日志为文件，支持按节点、操作、任务ID和日志类型过滤，format为ndjson（默认）或csv
+func (h *Handler) exportLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", "ndjson")
+	if format != "ndjson" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be ndjson or csv",
+		})
+		return
+	}
+	filter := log.LogFilter{
+		NodeID:    c.Query("nodeId"),
+		Operation: c.Query("operation"),
+		JobID:     c.Query("jobId"),
+		Type:      c.Query("type"),
+		Allow:     h.projectLogFilter(c),
+	}
+	if err := log.ValidateType(filter.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "type " + err.Error(),
+		})
+		return
+	}
+
+	fileName := fmt.Sprintf("k8s-installer-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	var err error
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(c.Writer)
+		writer.Write([]string{"id", "nodeId", "nodeName", "operation", "command", "status", "level", "type", "output", "createdAt", "updatedAt"})
+		err = h.nodeManager.ExportLogs(filter, func(entry log.LogEntry) error {
+			return writer.Write([]string{
+				entry.ID, entry.NodeID, entry.NodeName, entry.Operation, entry.Command, entry.Status, entry.Level, entry.Type, entry.Output,
+				entry.CreatedAt.Format(time.RFC3339), entry.UpdatedAt.Format(time.RFC3339),
+			})
+		})
+		writer.Flush()
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(c.Writer)
+		err = h.nodeManager.ExportLogs(filter, func(entry log.LogEntry) error {
+			return encoder.Encode(entry)
+		})
+	}
+	if err != nil {
+		// 响应已开始写入，只能记录错误
+		fmt.Printf("导出日志失败: %v\n", err)
+	}
+}
+
+// clearLogs 清除请求所属项目的所有日志，其他项目的日志保留
+func (h *Handler) clearLogs(c *gin.Context) {
+	var ids []string
+	err := h.nodeManager.ExportLogs(log.LogFilter{Allow: h.projectLogFilter(c)}, func(entry log.LogEntry) error {
+		ids = append(ids, entry.ID)
+		return nil
+	})
+	if err == nil {
+		err = h.nodeManager.DeleteLogs(ids)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "logs cleared successfully",
+	})
+}
+
+// streamLogs 实时日志流（SSE），按节点、操作、任务ID、最低级别和日志类型过滤
+func (h *Handler) streamLogs(c *gin.Context) {
+	filter := log.LogFilter{
+		NodeID:    c.Query("nodeId"),
+		Operation: c.Query("operation"),
+		JobID:     c.Query("jobId"),
+		MinLevel:  c.Query("minLevel"),
+		Type:      c.Query("type"),
+		Allow:     h.projectLogFilter(c),
+	}
+	v := &validate.Validator{}
+	if err := log.ValidateLevel(filter.MinLevel); err != nil {
+		v.Add("minLevel", "%v", err)
+	}
+	if err := log.ValidateType(filter.Type); err != nil {
+		v.Add("type", "%v", err)
+	}
+	if err := v.Err(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	// 设置响应头，支持SSE；跨域响应头由api.CORS按配置设置
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	// 获取日志管理器
+	logManager := h.nodeManager.GetLogManager()
+
+	// 创建日志通道
+	var logChan <-chan log.LogEntry
+	var subscription log.LogSubscription
+
+	// 检查日志管理器是否支持订阅功能
+	if lm, ok := logManager.(interface {
+		SubscribeLogs(filter log.LogFilter) log.LogSubscription
+		UnsubscribeLogs(sub log.LogSubscription)
+	}); ok {
+		// 订阅日志事件
+		subscription = lm.SubscribeLogs(filter)
+		logChan = subscription.Ch
+
+		// 客户端断开连接时取消订阅
+		defer func() {
+			lm.UnsubscribeLogs(subscription)
+		}()
+	} else {
+		// 如果不支持订阅功能，创建一个新的通道并定期发送心跳
+		ch := make(chan log.LogEntry, 100)
+		logChan = ch
+
+		// 定期发送心跳
+		go func() {
+			for {
+				select {
+				case <-time.After(30 * time.Second):
+					select {
+					case ch <- log.LogEntry{
+						ID:        fmt.Sprintf("heartbeat-%d", time.Now().UnixNano()),
+						Operation: "Heartbeat",
+						NodeName:  "系统",
+						Level:     log.LevelDebug,
+						Type:      log.TypeSystem,
+						CreatedAt: time.Now(),
+					}:
+						// 心跳发送成功
+					default:
+						// 通道已满，跳过此心跳
+					}
+				case <-c.Request.Context().Done():
+					close(ch)
+					return
+				}
+			}
+		}()
+	}
+
+	// 客户端断开连接时关闭通道
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			// 客户端断开连接
+			return
+		case logEntry := <-logChan:
+			// 直接发送LogEntry，不包装
+			logJSON, err := json.Marshal(logEntry)
+			if err != nil {
+				continue
+			}
+			// 使用标准SSE格式
+			fmt.Fprintf(c.Writer, "data: %s\n\n", logJSON)
+			c.Writer.(http.Flusher).Flush()
+		case <-time.After(60 * time.Second):
+			// 60秒内没有日志，发送一个心跳事件，保持连接活跃
+			fmt.Fprintf(c.Writer, "data: {\"type\": \"heartbeat\"}\n\n")
+			c.Writer.(http.Flusher).Flush()
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-installer/api"
+	"k8s-installer/config"
+	"k8s-installer/kubeadm"
+	"k8s-installer/log"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testProject 测试中使用的非默认项目
+const testProject = "team-a"
+
+func newTestServer(t *testing.T) (*gin.Engine, *node.SqliteNodeManager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	deploymentStore, err := kubeadm.NewDeploymentStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectManager, err := node.NewProjectManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projectManager.CreateProject(node.Project{ID: testProject}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(api.CORS(config.CORSConfig{}))
+	r.Use(api.ProjectScope(projectManager))
+	NewHandler(nodeManager, deploymentStore).Register(api.NewRouter(r, nil))
+	return r, nodeManager
+}
+
+// TestStreamLogsCORS 日志流不自行设置跨域响应头，未允许的来源不能读取实时日志
+func TestStreamLogsCORS(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/logs/stream", nil).WithContext(ctx)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("Access-Control-Allow-Origin = %q for a disallowed origin", got)
+	}
+}
+
+// do 以project项目的身份发送GET或DELETE请求，project为空时使用默认项目
+func do(r http.Handler, method, path, project string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if project != "" {
+		req.Header.Set(api.ProjectHeader, project)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// seedLogs 创建默认项目和testProject中各一个节点，以及它们的日志和一条系统日志，返回两个节点
+func seedLogs(t *testing.T, nodeManager *node.SqliteNodeManager) (own, other *node.Node) {
+	t.Helper()
+	var err error
+	own, err = nodeManager.CreateNode(node.Node{Name: "node-1", IP: "10.0.0.10", Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeWorker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err = nodeManager.CreateNode(node.Node{Name: "node-2", IP: "10.0.0.11", Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeWorker, ProjectID: testProject})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	entries := []log.LogEntry{
+		{NodeID: own.ID, NodeName: own.Name, Operation: "Deploy", Command: "install", Output: "ok", Status: "success", Type: log.TypeScriptOutput},
+		{NodeID: own.ID, NodeName: own.Name, Operation: "TestConnection", Command: "echo", Output: "ok", Status: "success", Type: log.TypeSystem},
+		{NodeID: other.ID, NodeName: other.Name, Operation: "Deploy", Command: "install", Output: "team-a output", Status: "success", Type: log.TypeScriptOutput},
+		{NodeID: "system", NodeName: "系统", Operation: "Startup", Command: "start", Output: "started", Status: "success"},
+	}
+	for i, entry := range entries {
+		entry.ID = fmt.Sprintf("log-%d", i)
+		entry.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		if err := nodeManager.CreateLog(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return own, other
+}
+
+func listedNodeIDs(t *testing.T, w *httptest.ResponseRecorder) map[string]int {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Logs []log.LogEntry `json:"logs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]int)
+	for _, entry := range resp.Logs {
+		ids[entry.NodeID]++
+	}
+	return ids
+}
+
+func TestListLogsProjectFilter(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+	own, other := seedLogs(t, nodeManager)
+
+	ids := listedNodeIDs(t, do(r, http.MethodGet, "/logs", ""))
+	if ids[own.ID] != 2 || ids["system"] != 1 || ids[other.ID] != 0 {
+		t.Fatalf("default project logs by node: %v", ids)
+	}
+	ids = listedNodeIDs(t, do(r, http.MethodGet, "/logs", testProject))
+	if len(ids) != 1 || ids[other.ID] != 1 {
+		t.Fatalf("%s logs by node: %v", testProject, ids)
+	}
+
+	if w := do(r, http.MethodGet, "/logs/node/"+other.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("node logs from another project: status %d, want 404", w.Code)
+	}
+}
+
+func TestExportLogs(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+	own, other := seedLogs(t, nodeManager)
+
+	w := do(r, http.MethodGet, "/logs/export?type=script-output", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;") {
+		t.Errorf("Content-Disposition = %q", w.Header().Get("Content-Disposition"))
+	}
+	var exported []log.LogEntry
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var entry log.LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid ndjson line %q: %v", scanner.Text(), err)
+		}
+		exported = append(exported, entry)
+	}
+	// 按类型过滤，其他项目节点的日志不导出
+	if len(exported) != 1 || exported[0].NodeID != own.ID || exported[0].Operation != "Deploy" {
+		t.Fatalf("exported %+v", exported)
+	}
+
+	w = do(r, http.MethodGet, "/logs/export?format=csv&nodeId="+other.ID, testProject)
+	if w.Code != http.StatusOK {
+		t.Fatalf("csv: status %d, body %s", w.Code, w.Body)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][0] != "id" || records[1][1] != other.ID {
+		t.Fatalf("csv records: %v", records)
+	}
+}
+
+func TestExportLogsValidation(t *testing.T) {
+	r, _ := newTestServer(t)
+	for _, query := range []string{"format=xml", "type=unknown"} {
+		if w := do(r, http.MethodGet, "/logs/export?"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, w.Code)
+		}
+	}
+	if w := do(r, http.MethodGet, "/logs/stream?minLevel=verbose", ""); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("stream with invalid minLevel: status %d, want 422", w.Code)
+	}
+}
+
+func TestClearLogsKeepsOtherProjects(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+	_, other := seedLogs(t, nodeManager)
+
+	if w := do(r, http.MethodDelete, "/logs", ""); w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if ids := listedNodeIDs(t, do(r, http.MethodGet, "/logs", "")); len(ids) != 0 {
+		t.Fatalf("default project logs left: %v", ids)
+	}
+	if ids := listedNodeIDs(t, do(r, http.MethodGet, "/logs", testProject)); ids[other.ID] != 1 {
+		t.Fatalf("%s logs were cleared: %v", testProject, ids)
+	}
+}
+
+// TestListLogsByDeployment 不属于节点的集群日志按部署所属的项目过滤，部署记录不存在时属于默认项目
+func TestListLogsByDeployment(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+	_, other := seedLogs(t, nodeManager)
+	deploymentStore, err := kubeadm.NewDeploymentStore(nodeManager.GetDB().(*sql.DB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deploymentStore.CreateDeployment(kubeadm.Deployment{ID: "deploy-a", KubeVersion: "1.30.2", NodeIDs: []string{other.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i, jobID := range []string{"deploy-a", "deploy-a", "deploy-missing"} {
+		entry := log.LogEntry{ID: fmt.Sprintf("cluster-%d", i), NodeID: "cluster", NodeName: "集群", JobID: jobID, Operation: "Deploy", Status: "success", CreatedAt: now}
+		if err := nodeManager.CreateLog(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := listedNodeIDs(t, do(r, http.MethodGet, "/logs", testProject))
+	if ids["cluster"] != 2 || ids[other.ID] != 1 {
+		t.Fatalf("%s logs by node: %v", testProject, ids)
+	}
+	ids = listedNodeIDs(t, do(r, http.MethodGet, "/logs", ""))
+	if ids["cluster"] != 1 {
+		t.Fatalf("default project logs by node: %v", ids)
+	}
+}
@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"sync"
+
+	"k8s-installer/api"
+	"k8s-installer/log"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectLogs 判断日志是否属于请求所属的项目：节点的日志按节点所属项目，集群级别的部署日志按部署所属项目，
+// 不属于任何节点和部署的系统日志属于默认项目。实时日志流在广播协程中调用，因此创建时预先加载所有节点所属的项目，
+// 之后新出现的节点和任务只查询一次并缓存，查询数据库时不持有锁
+type projectLogs struct {
+	h         *Handler
+	projectID string
+	mutex     sync.Mutex
+	// nodes 节点ID到所属项目，不存在的节点为空字符串
+	nodes map[string]string
+	jobs  map[string]bool
+}
+
+// projectLogFilter 返回只保留请求所属项目日志的过滤函数，用于log.LogFilter.Allow
+func (h *Handler) projectLogFilter(c *gin.Context) func(log.LogEntry) bool {
+	p := &projectLogs{
+		h:         h,
+		projectID: api.ProjectID(c),
+		nodes:     make(map[string]string),
+		jobs:      make(map[string]bool),
+	}
+	if nodes, err := h.nodeManager.GetNodes(); err == nil {
+		for _, n := range nodes {
+			p.nodes[n.ID] = n.ProjectID
+		}
+	}
+	return p.allow
+}
+
+func (p *projectLogs) allow(entry log.LogEntry) bool {
+	if projectID := p.nodeProject(entry.NodeID); projectID != "" {
+		return projectID == p.projectID
+	}
+	if entry.JobID == "" {
+		return p.projectID == node.DefaultProjectID
+	}
+	return p.jobAllowed(entry.JobID)
+}
+
+// nodeProject 节点所属的项目，节点不存在时返回空字符串
+func (p *projectLogs) nodeProject(id string) string {
+	p.mutex.Lock()
+	projectID, ok := p.nodes[id]
+	p.mutex.Unlock()
+	if ok {
+		return projectID
+	}
+
+	if n, err := p.h.nodeManager.GetNode(id); err == nil {
+		projectID = n.ProjectID
+	}
+	p.mutex.Lock()
+	p.nodes[id] = projectID
+	p.mutex.Unlock()
+	return projectID
+}
+
+// jobAllowed 任务的日志是否属于请求所属的项目，部署记录不存在时属于默认项目
+func (p *projectLogs) jobAllowed(jobID string) bool {
+	p.mutex.Lock()
+	allowed, ok := p.jobs[jobID]
+	p.mutex.Unlock()
+	if ok {
+		return allowed
+	}
+
+	allowed = p.projectID == node.DefaultProjectID
+	if d, err := p.h.deploymentStore.GetDeployment(jobID); err == nil {
+		allowed = api.DeploymentProject(p.h.nodeManager, d) == p.projectID
+	}
+	p.mutex.Lock()
+	p.jobs[jobID] = allowed
+	p.mutex.Unlock()
+	return allowed
+}
@@ -0,0 +1,231 @@
+package nodes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/log"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 节点事件类型
+const (
+	NodeEventCreated        = "created"
+	NodeEventConnectionTest = "connection_test"
+	NodeEventStatusChange   = "status_change"
+	NodeEventDeployment     = "deployment"
+	NodeEventStep           = "deployment_step"
+	NodeEventJoin           = "join"
+	NodeEventReset          = "reset"
+	NodeEventExec           = "exec"
+	NodeEventOperation      = "operation"
+)
+
+// nodeEventKinds 所有节点事件类型
+var nodeEventKinds = []string{
+	NodeEventCreated, NodeEventConnectionTest, NodeEventStatusChange, NodeEventDeployment,
+	NodeEventStep, NodeEventJoin, NodeEventReset, NodeEventExec, NodeEventOperation,
+}
+
+// logEventKinds 日志操作对应的事件类型，其他操作为operation
+var logEventKinds = map[string]string{
+	"TestConnection":   NodeEventConnectionTest,
+	"NodeStatusChange": NodeEventStatusChange,
+	"JoinWorker":       NodeEventJoin,
+	"AddClusterNodes":  NodeEventJoin,
+	"ResetNode":        NodeEventReset,
+	"ResetCluster":     NodeEventReset,
+	"TeardownCluster":  NodeEventReset,
+	"Exec":             NodeEventExec,
+	"Terminal":         NodeEventExec,
+}
+
+// 节点事件时间线的返回条数
+const (
+	defaultNodeEventLimit = 200
+	maxNodeEventLimit     = 1000
+	// nodeEventSummaryLimit 事件摘要的最大长度，完整输出通过logId查询日志
+	nodeEventSummaryLimit = 200
+)
+
+// NodeEvent 节点时间线中的一条事件
+type NodeEvent struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Status    string    `json:"status,omitempty"`
+	Operation string    `json:"operation,omitempty"`
+	Summary   string    `json:"summary"`
+	// JobID 关联的部署ID
+	JobID string `json:"jobId,omitempty"`
+	// LogID 来自日志的事件对应的日志ID
+	LogID string `json:"logId,omitempty"`
+}
+
+// nodeEventsResponse 节点事件时间线
+type nodeEventsResponse struct {
+	NodeID string      `json:"nodeId"`
+	Total  int         `json:"total"`
+	Events []NodeEvent `json:"events"`
+}
+
+// summarize 取输出的第一行作为摘要
+func summarize(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	if runes := []rune(text); len(runes) > nodeEventSummaryLimit {
+		text = string(runes[:nodeEventSummaryLimit]) + "..."
+	}
+	return text
+}
+
+// nodeEvents 汇总节点记录、日志和部署记录中与节点有关的事件，按时间排序。
+// 属于部署任务的日志由部署和步骤事件代表，调试日志不计入
+func (h *Handler) nodeEvents(n *node.Node) ([]NodeEvent, error) {
+	events := []NodeEvent{{
+		Time:    n.CreatedAt,
+		Kind:    NodeEventCreated,
+		Summary: fmt.Sprintf("节点 %s (%s:%d) 已添加，类型 %s", n.Name, n.IP, n.Port, n.NodeType),
+	}}
+
+	deployments, err := h.deploymentStore.ListNodeDeployments(n.ID)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make(map[string]bool, len(deployments))
+	for _, d := range deployments {
+		jobs[d.ID] = true
+		events = append(events, NodeEvent{
+			Time:    d.CreatedAt,
+			Kind:    NodeEventDeployment,
+			Status:  kubeadm.DeploymentStatusRunning,
+			Summary: api.WithDescription(fmt.Sprintf("部署 Kubernetes %s 开始，共 %d 个节点", d.KubeVersion, len(d.NodeIDs)), d.Description),
+			JobID:   d.ID,
+		})
+		if d.Status != kubeadm.DeploymentStatusRunning {
+			summary := fmt.Sprintf("部署 Kubernetes %s 结束: %s", d.KubeVersion, d.Status)
+			if d.Error != "" {
+				summary += ", " + summarize(d.Error)
+			}
+			events = append(events, NodeEvent{Time: d.UpdatedAt, Kind: NodeEventDeployment, Status: d.Status, Summary: summary, JobID: d.ID})
+		}
+
+		steps, err := h.deploymentStore.GetSteps(d.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range steps {
+			if step.NodeID != n.ID {
+				continue
+			}
+			summary := fmt.Sprintf("步骤 %s: %s", step.Step, step.Status)
+			if step.Error != "" {
+				summary += ", " + summarize(step.Error)
+			}
+			events = append(events, NodeEvent{Time: step.UpdatedAt, Kind: NodeEventStep, Status: step.Status, Operation: step.Step, Summary: summary, JobID: d.ID})
+		}
+	}
+
+	logs, err := h.nodeManager.GetLogsByNode(n.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range logs {
+		// 旧版本的调试信息以Debug操作记录，没有级别
+		if jobs[entry.JobID] || log.EntryLevel(entry) == log.LevelDebug || entry.Operation == "Debug" {
+			continue
+		}
+		kind, ok := logEventKinds[entry.Operation]
+		if !ok {
+			kind = NodeEventOperation
+		}
+		summary := summarize(entry.Output)
+		if entry.Command != "" && kind != NodeEventStatusChange {
+			summary = summarize(entry.Command) + ": " + summary
+		}
+		events = append(events, NodeEvent{
+			Time:      entry.CreatedAt,
+			Kind:      kind,
+			Status:    entry.Status,
+			Operation: entry.Operation,
+			Summary:   summary,
+			JobID:     entry.JobID,
+			LogID:     entry.ID,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+// getNodeEvents 节点事件时间线，按时间正序返回最近的limit条，since和kind用于过滤
+func (h *Handler) getNodeEvents(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+
+	limit := defaultNodeEventLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxNodeEventLimit {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxNodeEventLimit))
+			return
+		}
+	}
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("since must be an RFC3339 time: %v", err))
+			return
+		}
+	}
+	kinds := make(map[string]bool)
+	for _, kind := range strings.Split(c.Query("kind"), ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		if !containsKind(kind) {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("unknown event kind %q, must be one of %s", kind, strings.Join(nodeEventKinds, ", ")))
+			return
+		}
+		kinds[kind] = true
+	}
+
+	all, err := h.nodeEvents(n)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	events := []NodeEvent{}
+	for _, e := range all {
+		if e.Time.Before(since) || (len(kinds) > 0 && !kinds[e.Kind]) {
+			continue
+		}
+		events = append(events, e)
+	}
+	total := len(events)
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	c.JSON(http.StatusOK, nodeEventsResponse{NodeID: n.ID, Total: total, Events: events})
+}
+
+// containsKind 是否为支持的节点事件类型
+func containsKind(kind string) bool {
+	for _, k := range nodeEventKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"k8s-installer/api"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// execRequest 命令执行请求
+type execRequest struct {
+	NodeIDs        []string `json:"nodeIds"`
+	Command        string   `json:"command" binding:"required"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+	Concurrency    int      `json:"concurrency"`
+	// Stream 为true时以SSE实时返回每个节点的输出
+	Stream bool `json:"stream"`
+}
+
+// runExec 在节点上执行命令，支持SSE流式输出
+func (h *Handler) runExec(c *gin.Context, nodes []node.Node, req execRequest) {
+	opts := node.ExecOptions{
+		Timeout:     time.Duration(req.TimeoutSeconds) * time.Second,
+		Concurrency: req.Concurrency,
+		Source:      c.ClientIP(),
+	}
+
+	if !req.Stream {
+		results := h.nodeManager.ExecOnNodes(c.Request.Context(), nodes, req.Command, opts, nil)
+		c.JSON(http.StatusOK, gin.H{
+			"results": results,
+		})
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	var writeMutex sync.Mutex
+	writeEvent := func(eventType string, data interface{}) {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return
+		}
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", eventType, payload)
+		c.Writer.(http.Flusher).Flush()
+	}
+
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), nodes, req.Command, opts, func(n node.Node, line string) {
+		writeEvent("output", gin.H{
+			"nodeId":   n.ID,
+			"nodeName": n.Name,
+			"line":     line,
+		})
+	})
+	for _, result := range results {
+		writeEvent("result", result)
+	}
+	writeEvent("done", gin.H{
+		"results": results,
+	})
+}
+
+// execOnNode 在单个节点上执行命令
+func (h *Handler) execOnNode(c *gin.Context) {
+	var req execRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	h.runExec(c, []node.Node{*n}, req)
+}
+
+// execOnNodes 在多个节点上执行命令，nodeIds为空时在所有节点上执行
+func (h *Handler) execOnNodes(c *gin.Context) {
+	var req execRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var nodes []node.Node
+	if len(req.NodeIDs) == 0 {
+		allNodes, err := h.projectNodes(c).GetNodes()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		nodes = allNodes
+	} else {
+		for _, id := range req.NodeIDs {
+			n, err := h.projectNodes(c).GetNode(id)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error": fmt.Sprintf("node %s: %v", id, err),
+				})
+				return
+			}
+			nodes = append(nodes, *n)
+		}
+	}
+	if len(nodes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "no nodes to execute on",
+		})
+		return
+	}
+	h.runExec(c, nodes, req)
+}
+
+// terminal 节点Web终端，通过WebSocket代理交互式SSH shell
+// 客户端发送 {"type":"input","data":"..."} 输入数据，{"type":"resize","cols":80,"rows":24} 调整窗口大小
+// 服务端以二进制帧返回终端输出
+func (h *Handler) terminal(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	cols, rows := 80, 24
+	fmt.Sscanf(c.Query("cols"), "%d", &cols)
+	fmt.Sscanf(c.Query("rows"), "%d", &rows)
+
+	// 接口没有认证，浏览器不限制跨站WebSocket连接，必须检查Origin，否则任何网页都能打开节点的root终端
+	handshake := func(_ *websocket.Config, r *http.Request) error {
+		if !api.AllowOrigin(h.cors, r) {
+			return fmt.Errorf("origin %s is not allowed", r.Header.Get("Origin"))
+		}
+		return nil
+	}
+
+	websocket.Server{Handshake: handshake, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		ws.PayloadType = websocket.BinaryFrame
+
+		client, err := node.Connect(*n)
+		if err != nil {
+			ws.Write([]byte(fmt.Sprintf("连接节点失败: %v\r\n", err)))
+			return
+		}
+		defer client.Close()
+
+		shell, err := client.StartShell("xterm-256color", cols, rows)
+		if err != nil {
+			ws.Write([]byte(fmt.Sprintf("启动终端失败: %v\r\n", err)))
+			return
+		}
+		defer shell.Close()
+
+		startedAt := time.Now()
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", startedAt.UnixNano()),
+			NodeID:    n.ID,
+			NodeName:  n.Name,
+			Operation: "Terminal",
+			Command:   "web terminal",
+			Output:    fmt.Sprintf("打开Web终端，来源: %s", c.ClientIP()),
+			Status:    "success",
+			Type:      log.TypeSystem,
+			CreatedAt: startedAt,
+			UpdatedAt: startedAt,
+		})
+
+		// 终端输出转发到浏览器
+		go func() {
+			io.Copy(ws, shell.Stdout)
+			ws.Close()
+		}()
+
+		// 浏览器输入和窗口调整转发到终端
+		go func() {
+			defer shell.Close()
+			for {
+				var msg struct {
+					Type string `json:"type"`
+					Data string `json:"data"`
+					Cols int    `json:"cols"`
+					Rows int    `json:"rows"`
+				}
+				if err := websocket.JSON.Receive(ws, &msg); err != nil {
+					return
+				}
+				switch msg.Type {
+				case "input":
+					if _, err := shell.Stdin.Write([]byte(msg.Data)); err != nil {
+						return
+					}
+				case "resize":
+					if msg.Cols > 0 && msg.Rows > 0 {
+						shell.Resize(msg.Cols, msg.Rows)
+					}
+				}
+			}
+		}()
+
+		shell.Wait()
+		fmt.Printf("节点 %s Web终端已关闭，持续时间: %v\n", n.Name, time.Since(startedAt))
+	}}.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,50 @@
+package nodes
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s-installer/config"
+	"k8s-installer/node"
+
+	"golang.org/x/net/websocket"
+)
+
+// dialTerminal 以origin为来源连接节点的Web终端
+func dialTerminal(t *testing.T, serverURL, nodeID, origin string) (*websocket.Conn, error) {
+	t.Helper()
+	wsConfig, err := websocket.NewConfig("ws"+strings.TrimPrefix(serverURL, "http")+"/nodes/"+nodeID+"/terminal", origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return websocket.DialConfig(wsConfig)
+}
+
+func TestTerminalOrigin(t *testing.T) {
+	r, _ := newTestServerWithCORS(t, config.CORSConfig{AllowedOrigins: []string{"https://console.example.com"}})
+	server := httptest.NewServer(r)
+	defer server.Close()
+	// 端口1上没有SSH服务，连接建立后终端立即返回连接失败
+	n := createNode(t, r, "", node.Node{Name: "node-1", IP: "127.0.0.1", Port: 1, Username: "root", Password: "secret", NodeType: node.NodeTypeWorker})
+
+	if ws, err := dialTerminal(t, server.URL, n.ID, "https://evil.example.com"); err == nil {
+		ws.Close()
+		t.Fatal("cross-origin terminal upgrade was accepted")
+	}
+
+	for _, origin := range []string{server.URL, "https://console.example.com"} {
+		ws, err := dialTerminal(t, server.URL, n.ID, origin)
+		if err != nil {
+			t.Fatalf("origin %s: %v", origin, err)
+		}
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatalf("origin %s: %v", origin, err)
+		}
+		ws.Close()
+		if !strings.Contains(string(msg), "连接节点失败") {
+			t.Fatalf("origin %s: unexpected terminal output %q", origin, msg)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package nodes
+
+import (
+	"errors"
+	"k8s-installer/api"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// groupMembersRequest 设置节点组成员请求
+type groupMembersRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+}
+
+// listGroups 获取请求所属项目的节点组列表
+func (h *Handler) listGroups(c *gin.Context) {
+	all, err := h.groupManager.ListGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	projectID := api.ProjectID(c)
+	groups := make([]node.Group, 0, len(all))
+	for _, group := range all {
+		if group.ProjectID == projectID {
+			groups = append(groups, group)
+		}
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// getGroup 获取单个节点组
+func (h *Handler) getGroup(c *gin.Context) {
+	group, err := h.groupManager.GetGroup(c.Param("id"))
+	if err != nil {
+		groupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// createGroup 创建节点组
+func (h *Handler) createGroup(c *gin.Context) {
+	var group node.Group
+	if err := c.ShouldBindJSON(&group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 节点组属于请求所属的项目，组成员只能是该项目的节点
+	group.ProjectID = api.ProjectID(c)
+	created, err := h.groupManager.CreateGroup(group)
+	if err != nil {
+		groupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// updateGroup 更新节点组，请求中包含nodeIds时同时替换组成员
+func (h *Handler) updateGroup(c *gin.Context) {
+	var group node.Group
+	if err := c.ShouldBindJSON(&group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	updated, err := h.groupManager.UpdateGroup(c.Param("id"), group)
+	if err != nil {
+		groupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteGroup 删除节点组，组内节点保留
+func (h *Handler) deleteGroup(c *gin.Context) {
+	if err := h.groupManager.DeleteGroup(c.Param("id")); err != nil {
+		groupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "node group deleted successfully",
+	})
+}
+
+// setGroupMembers 替换节点组成员，节点原来所属的节点组被替换
+func (h *Handler) setGroupMembers(c *gin.Context) {
+	var req groupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	group, err := h.groupManager.SetMembers(c.Param("id"), req.NodeIDs)
+	if err != nil {
+		groupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// groupError 返回节点组操作的错误响应
+func groupError(c *gin.Context, err error) {
+	var unknownErr *node.UnknownNodesError
+	switch {
+	case errors.Is(err, node.ErrGroupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, node.ErrGroupExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &unknownErr):
+		api.ValidationFailed(c, validate.Errors{{Field: "nodeIds", Message: err.Error()}})
+	default:
+		if _, ok := validate.AsErrors(err); ok {
+			api.ValidationFailed(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
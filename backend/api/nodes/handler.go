@@ -0,0 +1,502 @@
+package nodes
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/config"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 节点管理接口
+type Handler struct {
+	nodeManager     *node.SqliteNodeManager
+	heartbeatPoller *node.HeartbeatPoller
+	deploymentStore *kubeadm.DeploymentStore
+	lockManager     *lock.Manager
+	hostsManager    *node.HostsManager
+	groupManager    *node.GroupManager
+	installerKeys   *node.InstallerKeyManager
+	// cors 跨域配置，Web终端只接受同源或允许的来源发起的WebSocket连接
+	cors config.CORSConfig
+}
+
+// NewHandler 创建节点管理接口处理器
+func NewHandler(nodeManager *node.SqliteNodeManager, heartbeatPoller *node.HeartbeatPoller, deploymentStore *kubeadm.DeploymentStore, lockManager *lock.Manager, hostsManager *node.HostsManager, groupManager *node.GroupManager, installerKeys *node.InstallerKeyManager, cors config.CORSConfig) *Handler {
+	return &Handler{
+		nodeManager:     nodeManager,
+		heartbeatPoller: heartbeatPoller,
+		deploymentStore: deploymentStore,
+		lockManager:     lockManager,
+		hostsManager:    hostsManager,
+		groupManager:    groupManager,
+		installerKeys:   installerKeys,
+		cors:            cors,
+	}
+}
+
+// projectNodes 请求所属项目中的节点，其他项目的节点按不存在处理
+func (h *Handler) projectNodes(c *gin.Context) node.ProjectNodes {
+	return h.nodeManager.InProject(api.ProjectID(c))
+}
+
+// groupProject 节点组所属的项目，用于api.RequireProject
+func (h *Handler) groupProject(id string) (string, error) {
+	group, err := h.groupManager.GetGroup(id)
+	if err != nil {
+		return "", err
+	}
+	return group.ProjectID, nil
+}
+
+// Register 注册节点管理路由
+func (h *Handler) Register(r *api.Router) {
+	// 路径中的节点和节点组属于其他项目时按不存在处理
+	nodeRoutes := r.Group("/nodes", api.RequireProject("node", api.NodeProject(h.nodeManager)))
+
+	nodeRoutes.GET("", api.Operation{Tag: "nodes", Summary: "获取节点列表", Description: "总数在X-Total-Count响应头中", Query: []api.Param{{Name: "nodeType", Description: "按节点类型过滤：master或worker"}, {Name: "status", Description: "按状态过滤"}, {Name: "cluster", Description: "按集群（master节点ID）过滤"}, {Name: "limit", Description: "返回的最大节点数，默认返回全部"}, {Name: "offset", Description: "跳过的节点数"}, {Name: "fields", Description: "逗号分隔的返回字段，如id,name,ip,status"}}, Response: []node.View{}}, h.listNodes)
+	nodeRoutes.GET("/:id", api.Operation{Tag: "nodes", Summary: "获取单个节点", Response: node.View{}}, h.getNode)
+	nodeRoutes.POST("", api.Operation{Tag: "nodes", Summary: "创建节点", Query: []api.Param{{Name: "allowDuplicate", Description: "为true时允许与已有节点的IP+端口或名称重复"}}, Request: node.Node{}, Response: node.View{}}, h.createNode)
+	nodeRoutes.PUT("/:id", api.Operation{Tag: "nodes", Summary: "更新节点", Query: []api.Param{{Name: "allowDuplicate", Description: "为true时允许与已有节点的IP+端口或名称重复"}}, Request: node.Node{}, Response: node.View{}}, h.updateNode)
+	nodeRoutes.DELETE("/:id", api.Operation{Tag: "nodes", Summary: "删除节点"}, h.deleteNode)
+	nodeRoutes.POST("/:id/test-connection", api.Operation{Tag: "nodes", Summary: "测试节点SSH连接"}, h.testConnection)
+	nodeRoutes.POST("/:id/reset", api.Operation{Tag: "nodes", Summary: "重置单个worker节点", Request: kubeadm.NodeResetOptions{}}, h.resetNode)
+	nodeRoutes.GET("/heartbeat/config", api.Operation{Tag: "nodes", Summary: "获取节点心跳配置", Response: node.HeartbeatConfig{}}, h.getHeartbeatConfig)
+	nodeRoutes.PUT("/heartbeat/config", api.Operation{Tag: "nodes", Summary: "更新节点心跳配置", Request: node.HeartbeatConfig{}, Response: node.HeartbeatConfig{}}, h.updateHeartbeatConfig)
+	nodeRoutes.POST("/heartbeat/poll", api.Operation{Tag: "nodes", Summary: "立即对所有节点执行一次心跳探测"}, h.pollHeartbeats)
+	nodeRoutes.GET("/:id/heartbeats", api.Operation{Tag: "nodes", Summary: "获取节点可达性历史", Query: []api.Param{{Name: "limit", Description: "返回的记录数，默认100"}}}, h.listHeartbeats)
+	nodeRoutes.GET("/:id/events", api.Operation{Tag: "nodes", Summary: "获取节点事件时间线", Description: "汇总节点的添加、连接测试、状态变化、部署及其步骤、加入集群、重置和命令执行审计，按时间正序返回最近的limit条；部署任务的脚本输出由部署和步骤事件代表，完整输出通过logId查询日志", Query: []api.Param{{Name: "limit", Description: "返回的最大事件数，默认200，最大1000"}, {Name: "since", Description: "只返回该时间（RFC3339）之后的事件"}, {Name: "kind", Description: "逗号分隔的事件类型：created、connection_test、status_change、deployment、deployment_step、join、reset、exec、operation"}}, Response: nodeEventsResponse{}}, h.getNodeEvents)
+	nodeRoutes.GET("/:id/usage", api.Operation{Tag: "nodes", Summary: "获取节点资源使用快照", Description: "CPU负载、内存以及/、/var/lib/containerd和/var/lib/etcd所在文件系统的磁盘和inode使用率，超过阈值时在warnings中提示，避免部署中途磁盘写满", Query: []api.Param{{Name: "diskWarnPercent", Description: "磁盘使用率告警阈值，默认85"}, {Name: "inodeWarnPercent", Description: "inode使用率告警阈值，默认85"}, {Name: "memoryWarnPercent", Description: "内存使用率告警阈值，默认90"}}, Response: node.NodeUsage{}}, h.getNodeUsage)
+	nodeRoutes.POST("/:id/support-bundle", api.Operation{Tag: "nodes", Summary: "收集节点故障排查支持包"}, h.collectSupportBundle)
+	r.GET("/support-bundles/:name", api.Operation{Tag: "nodes", Summary: "下载支持包", Produces: "application/gzip"}, h.downloadSupportBundle)
+	r.DELETE("/support-bundles/:name", api.Operation{Tag: "nodes", Summary: "删除支持包"}, h.deleteSupportBundle)
+	nodeRoutes.POST("/:id/exec", api.Operation{Tag: "nodes", Summary: "在单个节点上执行命令", Request: execRequest{}}, h.execOnNode)
+	nodeRoutes.POST("/exec", api.Operation{Tag: "nodes", Summary: "在多个节点上执行命令", Request: execRequest{}}, h.execOnNodes)
+	nodeRoutes.GET("/:id/terminal", api.Operation{Tag: "nodes", Summary: "节点Web终端（WebSocket）", Query: []api.Param{{Name: "cols", Description: "终端列数"}, {Name: "rows", Description: "终端行数"}}}, h.terminal)
+	nodeRoutes.PUT("/:id/kubelet", api.Operation{Tag: "nodes", Summary: "更新节点的kubelet配置", Description: "资源预留、驱逐阈值和最大Pod数写入kubelet的systemd配置并重启kubelet，配置为空时删除", Request: kubeadm.KubeletSettings{}}, h.applyKubeletSettings)
+	nodeRoutes.PUT("/:id/runtime/registries", api.Operation{Tag: "nodes", Summary: "配置节点的containerd镜像仓库", Description: "写入hosts.toml（镜像加速、HTTP和自签名证书仓库）并重启containerd，verifyImage不为空时拉取镜像验证", Request: registriesRequest{}}, h.configureRegistries)
+	nodeRoutes.GET("/:id/runtime/status", api.Operation{Tag: "nodes", Summary: "获取节点容器运行时状态", Description: "通过crictl info/version（未安装crictl时使用ctr version）读取containerd版本和CRI状态条件，healthy表示RuntimeReady；安装CNI插件之前networkReady为false属于正常情况", Response: kubeadm.CRIReport{}}, h.getRuntimeStatus)
+	nodeRoutes.PUT("/runtime/registries", api.Operation{Tag: "nodes", Summary: "批量配置containerd镜像仓库", Request: registriesRequest{}}, h.batchConfigureRegistries)
+	nodeRoutes.POST("/:id/kubernetes/install", api.Operation{Tag: "nodes", Summary: "在节点上安装Kubernetes组件", Request: installKubernetesRequest{}}, h.installKubernetes)
+	nodeRoutes.POST("/:id/ssh/configure", api.Operation{Tag: "nodes", Summary: "配置节点SSH设置"}, h.configureSSH)
+	nodeRoutes.POST("/:id/ssh/bootstrap", api.Operation{Tag: "nodes", Summary: "切换节点到安装器密钥认证", Description: "使用节点现有的凭据（通常是密码）连接一次，把安装器公钥加入authorized_keys，验证密钥登录成功后删除保存的密码，节点凭据改为installer:key；验证失败时不修改节点", Response: node.KeyBootstrapResult{}}, h.bootstrapKeyAuth)
+	nodeRoutes.GET("/:id/ssh/authorized-keys", api.Operation{Tag: "nodes", Summary: "查看节点authorized_keys中安装器管理的公钥", Description: "免密互通和安装器公钥写入authorized_keys中的标记块，块外的公钥不受影响", Response: node.AuthorizedKeysBlock{}}, h.getAuthorizedKeys)
+	nodeRoutes.DELETE("/:id/ssh/authorized-keys", api.Operation{Tag: "nodes", Summary: "删除节点authorized_keys中安装器管理的标记块", Description: "块外的公钥保持不变；节点使用installer:key连接时保留安装器公钥", Response: node.AuthorizedKeysBlock{}}, h.removeAuthorizedKeys)
+	nodeRoutes.GET("/ssh/installer-key", api.Operation{Tag: "nodes", Summary: "获取安装器SSH公钥", Response: node.InstallerKey{}}, h.getInstallerKey)
+	nodeRoutes.POST("/ssh/installer-key", api.Operation{Tag: "nodes", Summary: "生成或导入安装器SSH密钥", Description: "privateKey为空时生成ed25519密钥对，私钥加密保存在数据库中，不会返回；已有密钥时返回409，请使用轮换", Request: installerKeyRequest{}, Response: node.InstallerKey{}}, h.createInstallerKey)
+	nodeRoutes.POST("/ssh/installer-key/rotate", api.Operation{Tag: "nodes", Summary: "轮换安装器SSH密钥", Description: "先把新公钥加入所有使用installer:key的节点（包括其他项目的节点）并验证登录，全部成功后切换到新密钥并删除旧公钥；任一节点失败时保持当前密钥，rotated为false", Request: installerKeyRequest{}, Response: node.KeyRotationResult{}}, h.rotateInstallerKey)
+	nodeRoutes.POST("/ssh/passwdless", api.Operation{Tag: "nodes", Summary: "配置所有节点之间的SSH免密互通", Description: "并发收集各节点公钥并写入每个节点authorized_keys中的标记块（同时加入安装器公钥，块外的公钥保持不变），更新/etc/hosts后测试节点之间的免密登录，返回登录矩阵；passed为false时查看links和errors", Request: node.PasswdlessOptions{}, Response: node.PasswdlessResult{}}, h.configurePasswordless)
+	nodeRoutes.POST("/hosts/sync", api.Operation{Tag: "nodes", Summary: "同步节点/etc/hosts解析", Request: hostsSyncRequest{}}, h.syncHosts)
+	nodeRoutes.GET("/clock-skew", api.Operation{Tag: "nodes", Summary: "检查节点间的时钟偏差", Query: []api.Param{{Name: "nodeIds", Description: "逗号分隔的节点ID，为空时检查所有节点"}, {Name: "maxSkewMs", Description: "允许的最大偏差（毫秒）"}}, Response: node.ClockSkewReport{}}, h.checkClockSkew)
+
+	groupRoutes := r.Group("/node-groups", api.RequireProject("node group", h.groupProject))
+	groupRoutes.GET("", api.Operation{Tag: "node-groups", Summary: "获取节点组列表", Response: []node.Group{}}, h.listGroups)
+	groupRoutes.GET("/:id", api.Operation{Tag: "node-groups", Summary: "获取单个节点组", Response: node.Group{}}, h.getGroup)
+	groupRoutes.POST("", api.Operation{Tag: "node-groups", Summary: "创建节点组", Description: "节点组的默认配置（代理、标签、containerd版本、脚本替换）在部署时应用到组内节点", Request: node.Group{}, Response: node.Group{}}, h.createGroup)
+	groupRoutes.PUT("/:id", api.Operation{Tag: "node-groups", Summary: "更新节点组", Description: "请求中包含nodeIds时同时替换组成员", Request: node.Group{}, Response: node.Group{}}, h.updateGroup)
+	groupRoutes.DELETE("/:id", api.Operation{Tag: "node-groups", Summary: "删除节点组"}, h.deleteGroup)
+	groupRoutes.PUT("/:id/nodes", api.Operation{Tag: "node-groups", Summary: "设置节点组成员", Description: "一个节点最多属于一个节点组，加入新的节点组时离开原节点组", Request: groupMembersRequest{}, Response: node.Group{}}, h.setGroupMembers)
+
+	// 容器运行时相关API端点 - 暂时注释，因为节点管理器没有实现这些方法
+	/*
+		// 安装容器运行时
+		r.POST("/nodes/:id/runtime/install", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var req struct {
+				RuntimeType string `json:"runtimeType"`
+				Version     string `json:"version"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.InstallContainerRuntime(id, req.RuntimeType, req.Version); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime installed successfully",
+			})
+		})
+
+		// 配置容器运行时
+		r.POST("/nodes/:id/runtime/configure", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var config node.ContainerRuntimeConfig
+			if err := c.ShouldBindJSON(&config); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.ConfigureContainerRuntime(id, config); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime configured successfully",
+			})
+		})
+
+		// 启动容器运行时
+		r.POST("/nodes/:id/runtime/start", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var req struct {
+				RuntimeType string `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.StartContainerRuntime(id, req.RuntimeType); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime started successfully",
+			})
+		})
+
+		// 停止容器运行时
+		r.POST("/nodes/:id/runtime/stop", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var req struct {
+				RuntimeType string `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.StopContainerRuntime(id, req.RuntimeType); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime stopped successfully",
+			})
+		})
+
+		// 移除容器运行时
+		r.POST("/nodes/:id/runtime/remove", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var req struct {
+				RuntimeType string `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.RemoveContainerRuntime(id, req.RuntimeType); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime removed successfully",
+			})
+		})
+
+		// 启用容器运行时开机自启
+		r.POST("/nodes/:id/runtime/enable", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var req struct {
+				RuntimeType string `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.EnableContainerRuntime(id, req.RuntimeType); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime enabled successfully",
+			})
+		})
+
+		// 禁用容器运行时开机自启
+		r.POST("/nodes/:id/runtime/disable", func(c *gin.Context) {
+			id := c.Param("id")
+
+			var req struct {
+				RuntimeType string `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			if err := nodeManager.DisableContainerRuntime(id, req.RuntimeType); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "container runtime disabled successfully",
+			})
+		})
+
+		// 检查容器运行时状态
+		r.GET("/nodes/:id/runtime/status", func(c *gin.Context) {
+			id := c.Param("id")
+
+			runtimeType := c.Query("runtimeType")
+			if runtimeType == "" {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "runtimeType is required",
+				})
+				return
+			}
+
+			status, err := nodeManager.CheckContainerRuntimeStatus(id, runtimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": status,
+			})
+		})
+
+		// 批量安装容器运行时
+		r.POST("/nodes/runtime/batch-install", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+				Version     string   `json:"version"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchInstallContainerRuntime(req.NodeIds, req.RuntimeType, req.Version)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量配置容器运行时
+		r.POST("/nodes/runtime/batch-configure", func(c *gin.Context) {
+			var req struct {
+				NodeIds []string                    `json:"nodeIds"`
+				Config  node.ContainerRuntimeConfig `json:"config"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchConfigureContainerRuntime(req.NodeIds, req.Config)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量启动容器运行时
+		r.POST("/nodes/runtime/batch-start", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchStartContainerRuntime(req.NodeIds, req.RuntimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量停止容器运行时
+		r.POST("/nodes/runtime/batch-stop", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchStopContainerRuntime(req.NodeIds, req.RuntimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量移除容器运行时
+		r.POST("/nodes/runtime/batch-remove", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchRemoveContainerRuntime(req.NodeIds, req.RuntimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量启用容器运行时开机自启
+		r.POST("/nodes/runtime/batch-enable", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchEnableContainerRuntime(req.NodeIds, req.RuntimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量禁用容器运行时开机自启
+		r.POST("/nodes/runtime/batch-disable", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			result, err := nodeManager.BatchDisableContainerRuntime(req.NodeIds, req.RuntimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"result": result,
+			})
+		})
+
+		// 批量检查容器运行时状态
+		r.POST("/nodes/runtime/batch-status", func(c *gin.Context) {
+			var req struct {
+				NodeIds     []string `json:"nodeIds"`
+				RuntimeType string   `json:"runtimeType"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			statusMap, err := nodeManager.BatchCheckContainerRuntimeStatus(req.NodeIds, req.RuntimeType)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			c.JSON(http.StatusOK, statusMap)
+		})
+	*/
+}
@@ -0,0 +1,70 @@
+package nodes
+
+import (
+	"fmt"
+	"k8s-installer/node"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getHeartbeatConfig 获取节点心跳配置
+func (h *Handler) getHeartbeatConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.heartbeatPoller.GetConfig())
+}
+
+// updateHeartbeatConfig 更新节点心跳配置
+func (h *Handler) updateHeartbeatConfig(c *gin.Context) {
+	var config node.HeartbeatConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := h.heartbeatPoller.UpdateConfig(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, h.heartbeatPoller.GetConfig())
+}
+
+// pollHeartbeats 立即对所有节点执行一次心跳探测
+func (h *Handler) pollHeartbeats(c *gin.Context) {
+	h.heartbeatPoller.PollOnce()
+	nodes, err := h.projectNodes(c).GetNodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"nodes": node.Views(nodes),
+	})
+}
+
+// listHeartbeats 获取节点可达性历史
+func (h *Handler) listHeartbeats(c *gin.Context) {
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		if _, err := fmt.Sscanf(l, "%d", &limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid limit",
+			})
+			return
+		}
+	}
+	records, err := h.heartbeatPoller.GetHistory(c.Param("id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"heartbeats": records,
+	})
+}
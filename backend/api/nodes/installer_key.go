@@ -0,0 +1,78 @@
+package nodes
+
+import (
+	"errors"
+	"net/http"
+
+	"k8s-installer/api"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// installerKeyRequest 生成或导入安装器密钥的请求，privateKey为空时生成ed25519密钥对
+type installerKeyRequest struct {
+	PrivateKey string `json:"privateKey"`
+}
+
+// bindInstallerKeyRequest 请求体可以为空
+func bindInstallerKeyRequest(c *gin.Context) (installerKeyRequest, bool) {
+	var req installerKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// getInstallerKey 获取安装器公钥
+func (h *Handler) getInstallerKey(c *gin.Context) {
+	key, err := h.installerKeys.Get()
+	if errors.Is(err, node.ErrInstallerKeyNotFound) {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// createInstallerKey 生成或导入安装器密钥
+func (h *Handler) createInstallerKey(c *gin.Context) {
+	req, ok := bindInstallerKeyRequest(c)
+	if !ok {
+		return
+	}
+	key, err := h.installerKeys.Create(req.PrivateKey)
+	if errors.Is(err, node.ErrInstallerKeyExists) {
+		api.Error(c, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// rotateInstallerKey 轮换安装器密钥，rotated为false时表示有节点失败，当前密钥未变化
+func (h *Handler) rotateInstallerKey(c *gin.Context) {
+	req, ok := bindInstallerKeyRequest(c)
+	if !ok {
+		return
+	}
+	result, err := h.nodeManager.RotateInstallerKey(h.installerKeys, req.PrivateKey)
+	if errors.Is(err, node.ErrInstallerKeyNotFound) {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
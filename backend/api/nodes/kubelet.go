@@ -0,0 +1,61 @@
+package nodes
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// applyKubeletSettings 将kubelet配置写入已有节点的systemd配置并重启kubelet，配置为空时删除安装器写入的配置
+func (h *Handler) applyKubeletSettings(c *gin.Context) {
+	var settings kubeadm.KubeletSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := settings.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "ApplyKubeletSettings", lock.NodeKey(n.ID))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	cmd := kubeadm.RemoveKubeletDropInCmd
+	if !settings.Empty() {
+		cmd = kubeadm.KubeletDropInCmd(settings)
+	}
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), []node.Node{*n}, cmd, node.ExecOptions{Source: c.ClientIP()}, nil)
+	result := results[0]
+	if !result.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  result.Error,
+			"output": result.Output,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"flags":   settings.Flags(),
+		"output":  result.Output,
+	})
+}
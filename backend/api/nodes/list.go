@@ -0,0 +1,160 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxListLimit limit的最大值，未指定limit时返回所有节点
+const MaxListLimit = 1000
+
+// listQuery 节点列表的过滤、分页和字段选择参数
+type listQuery struct {
+	NodeType string
+	Status   string
+	// Cluster 集群ID（master节点ID），只返回最近一次部署中属于该集群的节点
+	Cluster string
+	Limit   int
+	Offset  int
+	// Fields 只返回指定的JSON字段，为空时返回全部字段
+	Fields []string
+}
+
+// parseListQuery 解析节点列表查询参数
+func parseListQuery(c *gin.Context) (listQuery, error) {
+	q := listQuery{
+		NodeType: c.Query("nodeType"),
+		Status:   c.Query("status"),
+		Cluster:  c.Query("cluster"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 || limit > MaxListLimit {
+			return q, fmt.Errorf("invalid limit: %s, expected 0-%d", v, MaxListLimit)
+		}
+		q.Limit = limit
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return q, fmt.Errorf("invalid offset: %s", v)
+		}
+		q.Offset = offset
+	}
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			q.Fields = append(q.Fields, field)
+		}
+	}
+	return q, nil
+}
+
+// listNodes 获取节点列表，支持按类型、状态和集群过滤，limit/offset分页（总数在X-Total-Count响应头中）和fields字段选择。
+// 返回的节点信息不包含密码、私钥和join命令
+func (h *Handler) listNodes(c *gin.Context) {
+	q, err := parseListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	nodes, err := h.projectNodes(c).GetNodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var members map[string]bool
+	if q.Cluster != "" {
+		deployment, err := h.deploymentStore.FindClusterDeployment(q.Cluster)
+		if err != nil && err != kubeadm.ErrDeploymentNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		members = map[string]bool{q.Cluster: true}
+		if deployment != nil {
+			for _, id := range deployment.NodeIDs {
+				members[id] = true
+			}
+		}
+	}
+
+	// 显式创建切片，确保Gin将其序列化为数组而不是null
+	filtered := []node.Node{}
+	for _, n := range nodes {
+		if q.NodeType != "" && n.NodeType != q.NodeType {
+			continue
+		}
+		if q.Status != "" && n.Status != q.Status {
+			continue
+		}
+		if members != nil && !members[n.ID] {
+			continue
+		}
+		filtered = append(filtered, n)
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(len(filtered)))
+	page := node.Views(paginate(filtered, q.Limit, q.Offset))
+	if len(q.Fields) == 0 {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	selected, err := selectFields(page, q.Fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, selected)
+}
+
+// paginate 返回offset开始的最多limit个节点，limit为0时返回offset之后的所有节点
+func paginate(nodes []node.Node, limit, offset int) []node.Node {
+	if offset >= len(nodes) {
+		return []node.Node{}
+	}
+	nodes = nodes[offset:]
+	if limit > 0 && limit < len(nodes) {
+		nodes = nodes[:limit]
+	}
+	return nodes
+}
+
+// selectFields 只保留节点的指定JSON字段，未知字段忽略
+func selectFields(nodes []node.View, fields []string) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]interface{}
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		item := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if v, ok := all[field]; ok {
+				item[field] = v
+			}
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
@@ -0,0 +1,300 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getNode 获取单个节点
+func (h *Handler) getNode(c *gin.Context) {
+	id := c.Param("id")
+	node, err := h.projectNodes(c).GetNode(id)
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	c.JSON(http.StatusOK, node.View())
+}
+
+// createNode 创建节点
+func (h *Handler) createNode(c *gin.Context) {
+	var node node.Node
+	if err := c.ShouldBindJSON(&node); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := node.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	// 节点属于请求所属的项目
+	node.ProjectID = api.ProjectID(c)
+	createdNode, err := h.nodeManager.CreateNodeWithOptions(node, writeOptions(c))
+	if err != nil {
+		if duplicateConflict(c, err) {
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, createdNode.View())
+}
+
+// updateNode 更新节点
+func (h *Handler) updateNode(c *gin.Context) {
+	id := c.Param("id")
+	var node node.Node
+	if err := c.ShouldBindJSON(&node); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := node.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	// 接口不返回凭据和join命令，请求中未提供时保留原值
+	if !node.HasCredentials() || node.JoinCommand == "" {
+		if existing, err := h.projectNodes(c).GetNode(id); err == nil {
+			if !node.HasCredentials() {
+				node.Password = existing.Password
+				node.PrivateKey = existing.PrivateKey
+				node.PasswordRef = existing.PasswordRef
+				node.PrivateKeyRef = existing.PrivateKeyRef
+			}
+			if node.JoinCommand == "" {
+				node.JoinCommand = existing.JoinCommand
+			}
+		}
+	}
+
+	updatedNode, err := h.nodeManager.UpdateNodeWithOptions(id, node, writeOptions(c))
+	if err != nil {
+		if duplicateConflict(c, err) {
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedNode.View())
+}
+
+// writeOptions 从查询参数解析节点写入选项，allowDuplicate=true时允许与已有节点重复
+func writeOptions(c *gin.Context) node.WriteOptions {
+	return node.WriteOptions{AllowDuplicate: c.Query("allowDuplicate") == "true"}
+}
+
+// duplicateConflict 节点重复时返回409和已有节点信息
+func duplicateConflict(c *gin.Context, err error) bool {
+	var dupErr *node.DuplicateNodeError
+	if !errors.As(err, &dupErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":            dupErr.Error(),
+		"field":            dupErr.Field,
+		"existingNodeId":   dupErr.ExistingID,
+		"existingNodeName": dupErr.ExistingName,
+		"hint":             "set allowDuplicate=true to create the node anyway",
+	})
+	return true
+}
+
+// deleteNode 删除节点
+func (h *Handler) deleteNode(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.nodeManager.DeleteNode(id); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// testConnection 测试节点连接
+func (h *Handler) testConnection(c *gin.Context) {
+	id := c.Param("id")
+	connected, err := h.nodeManager.TestConnection(id)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"connected": connected,
+	})
+}
+
+// resetNode 重置单个worker节点，可选择保留containerd和Kubernetes软件包，master节点请使用集群拆除接口
+func (h *Handler) resetNode(c *gin.Context) {
+	var opts kubeadm.NodeResetOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if !api.CheckDescription(c, &opts.Description) {
+		return
+	}
+
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	if n.NodeType == node.NodeTypeMaster {
+		api.Error(c, http.StatusBadRequest, fmt.Errorf("node %s is a master node, use /clusters/%s/teardown instead", n.ID, n.ID))
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "ResetNode", lock.NodeKey(n.ID))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	output, err := kubeadm.ResetNode(*n, opts, func(line string) {
+		fmt.Printf("[%s] [重置流程] %s\n", n.Name, line)
+	})
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	h.nodeManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Operation: "ResetNode",
+		Command:   api.WithDescription(fmt.Sprintf("reset node (keepContainerd=%t, keepPackages=%t)", opts.KeepContainerd, opts.KeepPackages), opts.Description),
+		Output:    output,
+		Status:    status,
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		n.Status = node.NodeStatusError
+	} else {
+		n.Status = node.NodeStatusOnline
+		n.JoinCommand = ""
+	}
+	if _, updateErr := h.nodeManager.UpdateNode(n.ID, *n); updateErr != nil {
+		fmt.Printf("更新节点 %s 状态失败: %v\n", n.Name, updateErr)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  err.Error(),
+			"output": output,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"output":  output,
+	})
+}
+
+// installKubernetesRequest 在节点上安装Kubernetes组件请求
+type installKubernetesRequest struct {
+	KubeadmVersion string `json:"kubeadmVersion" binding:"required"`
+}
+
+// installKubernetes 安装Kubernetes组件
+func (h *Handler) installKubernetes(c *gin.Context) {
+	id := c.Param("id")
+
+	var req installKubernetesRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "请求参数错误: " + err.Error(),
+			"details":   err.Error(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	// 获取节点信息
+	node, err := h.projectNodes(c).GetNode(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":          "获取节点信息失败",
+			"details":        fmt.Sprintf("failed to get node: %v", err),
+			"timestamp":      time.Now().Format(time.RFC3339),
+			"nodeId":         id,
+			"kubeadmVersion": req.KubeadmVersion,
+		})
+		return
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "InstallKubernetes", lock.NodeKey(id))
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	// 记录安装开始日志
+	installLog := log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    node.ID,
+		NodeName:  node.Name,
+		Operation: "InstallKubernetesComponents",
+		Command:   fmt.Sprintf("安装Kubernetes组件，版本: %s", req.KubeadmVersion),
+		Output:    "开始安装Kubernetes组件...",
+		Status:    "running",
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	h.nodeManager.CreateLog(installLog)
+
+	// 记录安装请求
+	fmt.Printf("开始为节点 %s 安装Kubernetes组件，版本: %s\n", id, req.KubeadmVersion)
+
+	if err := h.nodeManager.InstallKubernetesComponents(id, req.KubeadmVersion); err != nil {
+		// 记录详细错误日志
+		fmt.Printf("节点 %s 安装Kubernetes组件失败: %v\n", id, err)
+
+		// 记录安装失败日志
+		installLog.Output = fmt.Sprintf("安装失败: %v", err)
+		installLog.Status = "failed"
+		installLog.UpdatedAt = time.Now()
+		h.nodeManager.CreateLog(installLog)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":          "安装Kubernetes组件失败",
+			"details":        err.Error(),
+			"timestamp":      time.Now().Format(time.RFC3339),
+			"nodeId":         id,
+			"kubeadmVersion": req.KubeadmVersion,
+		})
+		return
+	}
+
+	// 记录安装成功日志
+	installLog.Output = "安装成功"
+	installLog.Status = "success"
+	installLog.UpdatedAt = time.Now()
+	h.nodeManager.CreateLog(installLog)
+
+	// 记录成功日志
+	fmt.Printf("节点 %s 成功安装Kubernetes组件，版本: %s\n", id, req.KubeadmVersion)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "success",
+		"message":        "Kubernetes组件安装成功",
+		"result":         "Kubernetes组件安装成功", // 添加result字段，兼容前端期望
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"nodeId":         id,
+		"kubeadmVersion": req.KubeadmVersion,
+	})
+}
@@ -0,0 +1,207 @@
+package nodes
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"k8s-installer/api"
+	"k8s-installer/config"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testProject 测试中使用的非默认项目
+const testProject = "team-a"
+
+func newTestServer(t *testing.T) (*gin.Engine, *node.SqliteNodeManager) {
+	t.Helper()
+	return newTestServerWithCORS(t, config.CORSConfig{})
+}
+
+func newTestServerWithCORS(t *testing.T, cors config.CORSConfig) (*gin.Engine, *node.SqliteNodeManager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	deploymentStore, err := kubeadm.NewDeploymentStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupManager, err := node.NewGroupManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectManager, err := node.NewProjectManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projectManager.CreateProject(node.Project{ID: testProject}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(api.ProjectScope(projectManager))
+	NewHandler(nodeManager, nil, deploymentStore, lock.NewManager(), node.NewHostsManager(nodeManager), groupManager, nil, cors).Register(api.NewRouter(r, nil))
+	return r, nodeManager
+}
+
+// do 以project项目的身份发送请求，project为空时使用默认项目
+func do(r http.Handler, method, path, project string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if project != "" {
+		req.Header.Set(api.ProjectHeader, project)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func testNode(name, ip string) node.Node {
+	return node.Node{Name: name, IP: ip, Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeWorker}
+}
+
+func createNode(t *testing.T, r http.Handler, project string, n node.Node) node.View {
+	t.Helper()
+	w := do(r, http.MethodPost, "/nodes", project, n)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create node: status %d, body %s", w.Code, w.Body)
+	}
+	var view node.View
+	if err := json.Unmarshal(w.Body.Bytes(), &view); err != nil {
+		t.Fatal(err)
+	}
+	return view
+}
+
+func TestCreateNode(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+
+	view := createNode(t, r, testProject, testNode("node-1", "10.0.0.10"))
+	stored, err := nodeManager.GetNode(view.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ProjectID != testProject {
+		t.Fatalf("node created in project %q, want %q", stored.ProjectID, testProject)
+	}
+	if bytes.Contains(do(r, http.MethodGet, "/nodes/"+view.ID, testProject, nil).Body.Bytes(), []byte("secret")) {
+		t.Error("node response contains the password")
+	}
+}
+
+func TestCreateNodeValidation(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	w := do(r, http.MethodPost, "/nodes", "", node.Node{Name: "Bad_Name", IP: "10.0.0", Port: 70000})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422", w.Code)
+	}
+	var resp struct {
+		Code   string `json:"code"`
+		Fields []struct {
+			Field string `json:"field"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]bool)
+	for _, f := range resp.Fields {
+		fields[f.Field] = true
+	}
+	for _, want := range []string{"name", "ip", "port", "username"} {
+		if !fields[want] {
+			t.Errorf("no validation error for %s in %s", want, w.Body)
+		}
+	}
+
+	if w := do(r, http.MethodPost, "/nodes", "", "not an object"); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want 400", w.Code)
+	}
+}
+
+func TestCreateNodeDuplicate(t *testing.T) {
+	r, _ := newTestServer(t)
+	existing := createNode(t, r, "", testNode("node-1", "10.0.0.10"))
+
+	w := do(r, http.MethodPost, "/nodes", "", testNode("node-2", "10.0.0.10"))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status %d, want 409", w.Code)
+	}
+	var resp struct {
+		Field          string `json:"field"`
+		ExistingNodeID string `json:"existingNodeId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Field != "ip" || resp.ExistingNodeID != existing.ID {
+		t.Fatalf("conflict = %+v, want ip of node %s", resp, existing.ID)
+	}
+
+	if w := do(r, http.MethodPost, "/nodes?allowDuplicate=true", "", testNode("node-2", "10.0.0.10")); w.Code != http.StatusCreated {
+		t.Fatalf("allowDuplicate: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestNodeProjectScoping(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+	other := createNode(t, r, testProject, testNode("node-1", "10.0.0.10"))
+	own := createNode(t, r, "", testNode("node-2", "10.0.0.11"))
+
+	// 其他项目的节点按不存在处理
+	if w := do(r, http.MethodGet, "/nodes/"+other.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("get: status %d, want 404", w.Code)
+	}
+	if w := do(r, http.MethodPut, "/nodes/"+other.ID, "", testNode("renamed", "10.0.0.10")); w.Code != http.StatusNotFound {
+		t.Fatalf("update: status %d, want 404", w.Code)
+	}
+	if w := do(r, http.MethodDelete, "/nodes/"+other.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("delete: status %d, want 404", w.Code)
+	}
+	if _, err := nodeManager.GetNode(other.ID); err != nil {
+		t.Fatalf("node of another project was deleted: %v", err)
+	}
+
+	w := do(r, http.MethodGet, "/nodes", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status %d", w.Code)
+	}
+	var nodes []node.View
+	if err := json.Unmarshal(w.Body.Bytes(), &nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != own.ID {
+		t.Fatalf("list returned %+v, want only node %s", nodes, own.ID)
+	}
+}
+
+func TestUnknownProject(t *testing.T) {
+	r, _ := newTestServer(t)
+	if w := do(r, http.MethodGet, "/nodes", "missing", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", w.Code)
+	}
+}
+
+func TestGetNodeNotFound(t *testing.T) {
+	r, _ := newTestServer(t)
+	if w := do(r, http.MethodGet, "/nodes/missing", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,118 @@
+package nodes
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registriesRequest 配置containerd镜像仓库请求
+type registriesRequest struct {
+	// NodeIDs 批量配置的节点，单节点接口忽略该字段
+	NodeIDs    []string                 `json:"nodeIds,omitempty"`
+	Registries []kubeadm.RegistryConfig `json:"registries"`
+	// VerifyImage 配置完成后拉取的镜像，用于验证仓库配置
+	VerifyImage string `json:"verifyImage,omitempty"`
+	// Concurrency 批量配置时同时执行的节点数
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// Validate 检查仓库配置和验证镜像
+func (r registriesRequest) Validate() error {
+	v := &validate.Validator{}
+	if len(r.Registries) == 0 {
+		v.Add("registries", "at least one registry is required")
+	}
+	for i, registry := range r.Registries {
+		v.Merge(fmt.Sprintf("registries[%d]", i), registry.Validate())
+	}
+	if r.VerifyImage != "" {
+		if err := kubeadm.ValidateImageRef(r.VerifyImage); err != nil {
+			v.Add("verifyImage", "%v", err)
+		}
+	}
+	return v.Err()
+}
+
+// configureRegistries 为单个节点配置containerd镜像仓库
+func (h *Handler) configureRegistries(c *gin.Context) {
+	var req registriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.NodeIDs = []string{c.Param("id")}
+	h.applyRegistries(c, req)
+}
+
+// batchConfigureRegistries 为多个节点配置containerd镜像仓库
+func (h *Handler) batchConfigureRegistries(c *gin.Context) {
+	var req registriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(req.NodeIDs) == 0 {
+		api.ValidationFailed(c, validate.Errors{{Field: "nodeIds", Message: "at least one node is required"}})
+		return
+	}
+	h.applyRegistries(c, req)
+}
+
+// applyRegistries 在节点上写入hosts.toml并重启containerd，返回每个节点的执行结果
+func (h *Handler) applyRegistries(c *gin.Context, req registriesRequest) {
+	if err := req.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+
+	var nodes []node.Node
+	lockKeys := make([]string, 0, len(req.NodeIDs))
+	for _, id := range req.NodeIDs {
+		n, err := h.projectNodes(c).GetNode(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("node %s: %v", id, err),
+			})
+			return
+		}
+		nodes = append(nodes, *n)
+		lockKeys = append(lockKeys, lock.NodeKey(id))
+	}
+
+	lease, ok := api.AcquireLocks(c, h.lockManager, fmt.Sprintf("%d", time.Now().UnixNano()), "ConfigureRegistries", lockKeys...)
+	if !ok {
+		return
+	}
+	defer lease.Release()
+
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), nodes, kubeadm.RegistriesCmd(req.Registries, req.VerifyImage), node.ExecOptions{
+		Concurrency: req.Concurrency,
+		Source:      c.ClientIP(),
+	}, nil)
+	success := true
+	for _, result := range results {
+		if !result.Success {
+			success = false
+		}
+	}
+	status := http.StatusOK
+	if !success {
+		status = http.StatusInternalServerError
+	}
+	c.JSON(status, gin.H{
+		"success": success,
+		"results": results,
+	})
+}
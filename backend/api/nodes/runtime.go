@@ -0,0 +1,27 @@
+package nodes
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getRuntimeStatus 通过CRI API检查节点的容器运行时状态，无法连接节点时返回502，响应中的error为失败原因
+func (h *Handler) getRuntimeStatus(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+
+	results := h.nodeManager.ExecOnNodes(c.Request.Context(), []node.Node{*n}, kubeadm.CRIHealthCmd, node.ExecOptions{Source: c.ClientIP()}, nil)
+	report := kubeadm.BuildCRIReport(*n, results[0])
+	if !results[0].Success {
+		c.JSON(http.StatusBadGateway, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
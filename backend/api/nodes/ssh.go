@@ -0,0 +1,181 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configureSSH 配置节点SSH设置
+func (h *Handler) configureSSH(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.nodeManager.ConfigureSSHSettings(id); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "SSH settings configured successfully",
+	})
+}
+
+// bootstrapKeyAuth 节点改为使用安装器密钥认证
+func (h *Handler) bootstrapKeyAuth(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	result, err := h.nodeManager.BootstrapKeyAuth(n.ID, h.installerKeys)
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getAuthorizedKeys 查看节点authorized_keys中安装器管理的公钥
+func (h *Handler) getAuthorizedKeys(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	block, err := h.nodeManager.ManagedAuthorizedKeys(*n)
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, block)
+}
+
+// removeAuthorizedKeys 删除节点authorized_keys中安装器管理的标记块，返回删除后的标记块
+func (h *Handler) removeAuthorizedKeys(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+	block, err := h.nodeManager.RemoveManagedAuthorizedKeys(*n)
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, block)
+}
+
+// configurePasswordless 配置请求所属项目中所有节点之间的SSH免密互通，返回节点之间的登录矩阵
+func (h *Handler) configurePasswordless(c *gin.Context) {
+	var opts node.PasswdlessOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+	result, err := h.nodeManager.ConfigureProjectSSHPasswdless(api.ProjectID(c), opts)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// hostsSyncRequest 同步节点/etc/hosts解析请求
+type hostsSyncRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+	Remove  bool     `json:"remove"`
+}
+
+// syncHosts 同步项目内所有节点的主机名解析到/etc/hosts的托管标记块，remove为true时移除标记块
+func (h *Handler) syncHosts(c *gin.Context) {
+	var req hostsSyncRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// 只同步请求所属项目中的节点，其他项目的主机名不写入/etc/hosts
+	results, err := h.hostsManager.SyncHostsAmong(h.projectNodes(c), req.NodeIDs, req.Remove)
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+
+	success := true
+	for _, result := range results {
+		status := "success"
+		if !result.Success {
+			status = "failed"
+			success = false
+		}
+		output := result.Output
+		if result.Error != "" {
+			output += "\n错误: " + result.Error
+		}
+		h.nodeManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    result.NodeID,
+			NodeName:  result.NodeName,
+			Operation: "HostsSync",
+			Command:   fmt.Sprintf("sync /etc/hosts (remove=%v)", req.Remove),
+			Output:    output,
+			Status:    status,
+			Type:      log.TypeScriptOutput,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": success,
+		"results": results,
+	})
+}
+
+// checkClockSkew 部署前检查节点间的时钟偏差，nodeIds为逗号分隔的节点ID，为空时检查所有节点
+func (h *Handler) checkClockSkew(c *gin.Context) {
+	var nodes []node.Node
+	if ids := c.Query("nodeIds"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			n, err := h.projectNodes(c).GetNode(strings.TrimSpace(id))
+			if err != nil {
+				api.Error(c, http.StatusNotFound, fmt.Errorf("node %s: %v", id, err))
+				return
+			}
+			nodes = append(nodes, *n)
+		}
+	} else {
+		allNodes, err := h.projectNodes(c).GetNodes()
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+		nodes = allNodes
+	}
+	if len(nodes) == 0 {
+		api.Error(c, http.StatusBadRequest, errors.New("no nodes to check"))
+		return
+	}
+
+	maxSkew := node.DefaultMaxClockSkew
+	if v := c.Query("maxSkewMs"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid maxSkewMs: %s", v))
+			return
+		}
+		maxSkew = time.Duration(ms) * time.Millisecond
+	}
+
+	c.JSON(http.StatusOK, node.CheckClockSkew(nodes, maxSkew))
+}
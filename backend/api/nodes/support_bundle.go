@@ -0,0 +1,97 @@
+package nodes
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/log"
+	"k8s-installer/node"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// collectSupportBundle 收集节点故障排查支持包
+func (h *Handler) collectSupportBundle(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	bundle, err := node.CollectSupportBundle(*n)
+	status := "success"
+	output := ""
+	if err != nil {
+		status = "failed"
+		output = err.Error()
+	} else {
+		output = fmt.Sprintf("支持包已生成: %s (%d bytes)", bundle.Name, bundle.Size)
+	}
+	h.nodeManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Operation: "SupportBundle",
+		Command:   "collect support bundle",
+		Output:    output,
+		Status:    status,
+		Type:      log.TypeScriptOutput,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 浏览器直接下载时无法设置请求头，非默认项目的下载地址带上project参数
+	downloadURL := "/support-bundles/" + bundle.Name
+	if bundle.ProjectID != node.DefaultProjectID {
+		downloadURL += "?project=" + url.QueryEscape(bundle.ProjectID)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"bundle":      bundle,
+		"downloadUrl": downloadURL,
+	})
+}
+
+// projectSupportBundle 读取当前项目的支持包，其他项目的支持包按不存在处理
+func (h *Handler) projectSupportBundle(c *gin.Context) (*node.SupportBundle, string, bool) {
+	bundle, path, err := node.GetSupportBundle(c.Param("name"))
+	if err == nil && bundle.ProjectID != api.ProjectID(c) {
+		err = fmt.Errorf("%w: %s", node.ErrSupportBundleNotFound, c.Param("name"))
+	}
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return nil, "", false
+	}
+	return bundle, path, true
+}
+
+// downloadSupportBundle 下载支持包
+func (h *Handler) downloadSupportBundle(c *gin.Context) {
+	bundle, path, ok := h.projectSupportBundle(c)
+	if !ok {
+		return
+	}
+	c.FileAttachment(path, bundle.Name)
+}
+
+// deleteSupportBundle 删除支持包
+func (h *Handler) deleteSupportBundle(c *gin.Context) {
+	bundle, _, ok := h.projectSupportBundle(c)
+	if !ok {
+		return
+	}
+	if err := node.DeleteSupportBundle(bundle.Name); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
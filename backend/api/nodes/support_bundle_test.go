@@ -0,0 +1,79 @@
+package nodes
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s-installer/node"
+)
+
+// writeBundle 在SupportBundleDir中创建属于project的支持包，project为空时不写元数据，模拟旧版本的支持包
+func writeBundle(t *testing.T, name, project string) {
+	t.Helper()
+	if err := os.MkdirAll(node.SupportBundleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(node.SupportBundleDir, name), []byte("bundle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if project == "" {
+		return
+	}
+	if err := node.SaveSupportBundle(&node.SupportBundle{Name: name, ProjectID: project, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSupportBundleProjectScoping(t *testing.T) {
+	t.Chdir(t.TempDir())
+	r, _ := newTestServer(t)
+	writeBundle(t, "support-node-1.tar.gz", testProject)
+
+	if w := do(r, http.MethodGet, "/support-bundles/support-node-1.tar.gz", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("download from another project: status %d, want 404", w.Code)
+	}
+	if w := do(r, http.MethodDelete, "/support-bundles/support-node-1.tar.gz", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("delete from another project: status %d, want 404", w.Code)
+	}
+
+	w := do(r, http.MethodGet, "/support-bundles/support-node-1.tar.gz", testProject, nil)
+	if w.Code != http.StatusOK || w.Body.String() != "bundle" {
+		t.Fatalf("download: status %d, body %q", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodDelete, "/support-bundles/support-node-1.tar.gz", testProject, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d, want 204", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/support-bundles/support-node-1.tar.gz", testProject, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("download after delete: status %d, want 404", w.Code)
+	}
+	if entries, _ := os.ReadDir(node.SupportBundleDir); len(entries) != 0 {
+		t.Fatalf("%d files left after delete", len(entries))
+	}
+}
+
+// TestLegacySupportBundle 没有元数据的旧支持包属于默认项目
+func TestLegacySupportBundle(t *testing.T) {
+	t.Chdir(t.TempDir())
+	r, _ := newTestServer(t)
+	writeBundle(t, "support-legacy.tar.gz", "")
+
+	if w := do(r, http.MethodGet, "/support-bundles/support-legacy.tar.gz", testProject, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("download from another project: status %d, want 404", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/support-bundles/support-legacy.tar.gz", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("download: status %d", w.Code)
+	}
+}
+
+func TestSupportBundleInvalidName(t *testing.T) {
+	t.Chdir(t.TempDir())
+	r, _ := newTestServer(t)
+	for _, name := range []string{"..%2Fk8s_installer.db", ".hidden.tar.gz", "bundle.zip"} {
+		if w := do(r, http.MethodGet, "/support-bundles/"+name, "", nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", name, w.Code)
+		}
+	}
+}
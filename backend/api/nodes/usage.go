@@ -0,0 +1,43 @@
+package nodes
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/node"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getNodeUsage 通过SSH读取节点的CPU负载、内存、磁盘和inode使用情况，超过阈值时给出告警
+func (h *Handler) getNodeUsage(c *gin.Context) {
+	n, err := h.projectNodes(c).GetNode(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusNotFound, err)
+		return
+	}
+
+	thresholds := node.DefaultUsageThresholds
+	for name, value := range map[string]*float64{
+		"diskWarnPercent":   &thresholds.DiskWarnPercent,
+		"inodeWarnPercent":  &thresholds.InodeWarnPercent,
+		"memoryWarnPercent": &thresholds.MemoryWarnPercent,
+	} {
+		if v := c.Query(name); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > 100 {
+				api.Error(c, http.StatusBadRequest, fmt.Errorf("invalid %s: %s", name, v))
+				return
+			}
+			*value = parsed
+		}
+	}
+
+	usage, err := node.CollectNodeUsage(*n, thresholds)
+	if err != nil {
+		api.Error(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
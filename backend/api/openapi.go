@@ -0,0 +1,353 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Param 查询参数说明
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Operation 接口说明，注册路由时一并登记，用于生成OpenAPI文档
+type Operation struct {
+	// Tag 接口分组
+	Tag string
+	// Summary 接口简介
+	Summary string
+	// Description 详细说明
+	Description string
+	// Query 查询参数
+	Query []Param
+	// Request 请求体类型的零值，为nil表示没有JSON请求体
+	Request interface{}
+	// Response 成功响应类型的零值，为nil时文档中只说明返回JSON对象
+	Response interface{}
+	// Produces 非JSON响应的Content-Type，如文件下载和SSE
+	Produces string
+}
+
+// Spec OpenAPI 3文档，由注册的路由生成
+type Spec struct {
+	mutex       sync.RWMutex
+	title       string
+	version     string
+	description string
+	routes      []route
+}
+
+type route struct {
+	method string
+	path   string
+	op     Operation
+}
+
+// NewSpec 创建OpenAPI文档
+func NewSpec(title, version, description string) *Spec {
+	return &Spec{title: title, version: version, description: description}
+}
+
+// Add 登记一个接口
+func (s *Spec) Add(method, path string, op Operation) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.routes = append(s.routes, route{method: strings.ToLower(method), path: path, op: op})
+}
+
+// Router 在注册gin路由的同时登记接口说明
+type Router struct {
+	router   gin.IRouter
+	spec     *Spec
+	basePath string
+}
+
+// NewRouter 创建路由注册器
+func NewRouter(r gin.IRouter, spec *Spec) *Router {
+	return &Router{router: r, spec: spec}
+}
+
+// Spec 返回路由登记到的OpenAPI文档，不登记文档的路由注册器返回nil
+func (r *Router) Spec() *Spec {
+	return r.spec
+}
+
+// Group 创建路由分组，分组内的路由路径加上prefix，handlers作为分组的中间件
+func (r *Router) Group(prefix string, handlers ...gin.HandlerFunc) *Router {
+	return &Router{
+		router:   r.router.Group(prefix, handlers...),
+		spec:     r.spec,
+		basePath: r.basePath + prefix,
+	}
+}
+
+// Handle 注册路由并登记接口说明
+func (r *Router) Handle(method, path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.router.Handle(method, path, handlers...)
+	if r.spec != nil {
+		r.spec.Add(method, r.basePath+path, op)
+	}
+}
+
+// GET 注册GET路由
+func (r *Router) GET(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, path, op, handlers...)
+}
+
+// POST 注册POST路由
+func (r *Router) POST(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, path, op, handlers...)
+}
+
+// PUT 注册PUT路由
+func (r *Router) PUT(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, path, op, handlers...)
+}
+
+// DELETE 注册DELETE路由
+func (r *Router) DELETE(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, path, op, handlers...)
+}
+
+var pathParamPattern = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Document 生成OpenAPI 3文档
+func (s *Spec) Document() map[string]interface{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	g := &schemaGenerator{components: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+	paths := make(map[string]map[string]interface{})
+	tagSet := make(map[string]bool)
+
+	for _, rt := range s.routes {
+		var params []interface{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(rt.path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, q := range rt.op.Query {
+			params = append(params, map[string]interface{}{
+				"name":        q.Name,
+				"in":          "query",
+				"required":    q.Required,
+				"description": q.Description,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+
+		operation := map[string]interface{}{
+			"summary":   rt.op.Summary,
+			"responses": g.responses(rt.op),
+		}
+		if rt.op.Tag != "" {
+			operation["tags"] = []string{rt.op.Tag}
+			tagSet[rt.op.Tag] = true
+		}
+		if rt.op.Description != "" {
+			operation["description"] = rt.op.Description
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if rt.op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": g.schema(reflect.TypeOf(rt.op.Request)),
+					},
+				},
+			}
+		}
+
+		openAPIPath := pathParamPattern.ReplaceAllString(rt.path, "{$1}")
+		if paths[openAPIPath] == nil {
+			paths[openAPIPath] = make(map[string]interface{})
+		}
+		paths[openAPIPath][rt.method] = operation
+	}
+
+	tags := make([]string, 0, len(tagSet))
+	for tag := range tagSet {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	tagList := make([]interface{}, 0, len(tags))
+	for _, tag := range tags {
+		tagList = append(tagList, map[string]interface{}{"name": tag})
+	}
+
+	g.components["Error"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"error": map[string]interface{}{"type": "string"},
+		},
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       s.title,
+			"version":     s.version,
+			"description": s.description,
+		},
+		"tags":       tagList,
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+// schemaGenerator 通过反射将Go类型转换为JSON Schema，命名结构体放入components
+type schemaGenerator struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+// responses 生成接口的响应说明
+func (g *schemaGenerator) responses(op Operation) map[string]interface{} {
+	var content map[string]interface{}
+	switch {
+	case op.Produces != "":
+		content = map[string]interface{}{op.Produces: map[string]interface{}{}}
+	case op.Response != nil:
+		content = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Response))},
+		}
+	default:
+		content = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "object"}},
+		}
+	}
+	return map[string]interface{}{
+		"200": map[string]interface{}{"description": "OK", "content": content},
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+				},
+			},
+		},
+	}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schema 生成类型的JSON Schema
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.componentName(t)
+		if _, ok := g.components[name]; !ok {
+			// 先占位，避免自引用类型无限递归
+			g.components[name] = map[string]interface{}{}
+			g.components[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// componentName 命名结构体在components中的名称，不同包中的同名类型加上包名区分
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for other, otherName := range g.names {
+		if otherName == name && other != t {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+			break
+		}
+	}
+	g.names[t] = name
+	return name
+}
+
+// structSchema 生成结构体的JSON Schema，字段名使用json标签，binding:"required"的字段标记为必填
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	g.collectFields(t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// collectFields 收集结构体字段，匿名嵌入的结构体字段展开到外层
+func (g *schemaGenerator) collectFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.collectFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectHeader 指定请求所属项目的请求头，未指定时使用默认项目
+const ProjectHeader = "X-Project-ID"
+
+// ProjectQuery EventSource和WebSocket无法设置请求头，通过该查询参数指定项目
+const ProjectQuery = "project"
+
+// projectKey 请求上下文中保存项目ID的键
+const projectKey = "projectId"
+
+// ProjectScope 解析请求所属的项目，项目不存在时返回404；之后的处理器通过ProjectID读取
+// 项目由调用方的请求头或查询参数决定，没有与认证身份绑定，只用于过滤可见的资源，不是访问控制边界
+func ProjectScope(projects *node.ProjectManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(ProjectHeader)
+		if id == "" {
+			id = c.Query(ProjectQuery)
+		}
+		if id == "" {
+			id = node.DefaultProjectID
+		}
+		if id != node.DefaultProjectID {
+			if _, err := projects.GetProject(id); err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, node.ErrProjectNotFound) {
+					status = http.StatusNotFound
+				}
+				Error(c, status, err)
+				c.Abort()
+				return
+			}
+		}
+		c.Set(projectKey, id)
+		c.Next()
+	}
+}
+
+// ProjectID 请求所属的项目，未经过ProjectScope时为默认项目
+func ProjectID(c *gin.Context) string {
+	if id := c.GetString(projectKey); id != "" {
+		return id
+	}
+	return node.DefaultProjectID
+}
+
+// RequireProject 路径参数id对应的资源属于其他项目时返回404，与资源不存在相同；
+// projectOf返回资源所属的项目，资源不存在时返回错误，交给处理器按原有方式处理
+func RequireProject(resource string, projectOf func(id string) (string, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.Next()
+			return
+		}
+		projectID, err := projectOf(id)
+		if err == nil && projectID != ProjectID(c) {
+			Error(c, http.StatusNotFound, errors.New(resource+" not found"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// NodeProject 节点所属的项目，用于RequireProject
+func NodeProject(nodeManager *node.SqliteNodeManager) func(id string) (string, error) {
+	return func(id string) (string, error) {
+		n, err := nodeManager.GetNode(id)
+		if err != nil {
+			return "", err
+		}
+		return n.ProjectID, nil
+	}
+}
+
+// DeploymentProject 部署记录所属的项目，即部署节点所属的项目；节点都已删除时属于默认项目
+func DeploymentProject(nodeManager *node.SqliteNodeManager, d *kubeadm.Deployment) string {
+	for _, id := range d.NodeIDs {
+		if n, err := nodeManager.GetNode(id); err == nil {
+			return n.ProjectID
+		}
+	}
+	return node.DefaultProjectID
+}
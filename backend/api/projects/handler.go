@@ -0,0 +1,31 @@
+package projects
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+// Handler 项目管理接口
+type Handler struct {
+	projectManager *node.ProjectManager
+	scriptManager  *script.ScriptManager
+}
+
+// NewHandler 创建项目管理接口处理器，删除项目时同时删除项目的脚本
+func NewHandler(projectManager *node.ProjectManager, scriptManager *script.ScriptManager) *Handler {
+	return &Handler{
+		projectManager: projectManager,
+		scriptManager:  scriptManager,
+	}
+}
+
+// Register 注册项目管理路由
+func (h *Handler) Register(r *api.Router) {
+	projectRoutes := r.Group("/projects")
+
+	projectRoutes.GET("", api.Operation{Tag: "projects", Summary: "获取项目列表", Description: "请求头X-Project-ID指定请求所属的项目，节点、节点组、集群、部署记录、脚本和日志按项目隔离；未指定时为默认项目default", Response: []node.Project{}}, h.listProjects)
+	projectRoutes.GET("/:id", api.Operation{Tag: "projects", Summary: "获取单个项目", Response: node.Project{}}, h.getProject)
+	projectRoutes.POST("", api.Operation{Tag: "projects", Summary: "创建项目", Description: "项目ID为DNS子域名格式，创建后不能修改", Request: node.Project{}, Response: node.Project{}}, h.createProject)
+	projectRoutes.DELETE("/:id", api.Operation{Tag: "projects", Summary: "删除项目", Description: "默认项目不能删除；项目中还有节点或节点组时返回409，项目的脚本随项目删除"}, h.deleteProject)
+}
@@ -0,0 +1,77 @@
+package projects
+
+import (
+	"errors"
+	"k8s-installer/api"
+	"k8s-installer/node"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listProjects 获取项目列表
+func (h *Handler) listProjects(c *gin.Context) {
+	projects, err := h.projectManager.ListProjects()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, projects)
+}
+
+// getProject 获取单个项目
+func (h *Handler) getProject(c *gin.Context) {
+	project, err := h.projectManager.GetProject(c.Param("id"))
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, project)
+}
+
+// createProject 创建项目
+func (h *Handler) createProject(c *gin.Context) {
+	var project node.Project
+	if err := c.ShouldBindJSON(&project); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	created, err := h.projectManager.CreateProject(project)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// deleteProject 删除没有节点和节点组的项目
+func (h *Handler) deleteProject(c *gin.Context) {
+	if err := h.projectManager.DeleteProject(c.Param("id")); err != nil {
+		projectError(c, err)
+		return
+	}
+	if err := h.scriptManager.DeleteProject(c.Param("id")); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "project deleted successfully",
+	})
+}
+
+// projectError 返回项目操作的错误响应
+func projectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, node.ErrProjectNotFound):
+		api.Error(c, http.StatusNotFound, err)
+	case errors.Is(err, node.ErrProjectExists), errors.Is(err, node.ErrProjectNotEmpty), errors.Is(err, node.ErrDefaultProject):
+		api.Error(c, http.StatusConflict, err)
+	default:
+		if _, ok := validate.AsErrors(err); ok {
+			api.ValidationFailed(c, err)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err)
+	}
+}
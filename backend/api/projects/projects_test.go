@@ -0,0 +1,123 @@
+package projects
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"k8s-installer/api"
+	"k8s-installer/node"
+	"k8s-installer/script"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestServer(t *testing.T) (*gin.Engine, *node.SqliteNodeManager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	if _, err := node.NewGroupManager(db); err != nil {
+		t.Fatal(err)
+	}
+	projectManager, err := node.NewProjectManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(api.ProjectScope(projectManager))
+	NewHandler(projectManager, script.NewScriptManager()).Register(api.NewRouter(r, nil))
+	return r, nodeManager
+}
+
+func do(r http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateProject(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	if w := do(r, http.MethodPost, "/projects", node.Project{ID: "team-a", Description: "Team A"}); w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodGet, "/projects/team-a", nil); w.Code != http.StatusOK {
+		t.Fatalf("get: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodPost, "/projects", node.Project{ID: "team-a"}); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate: status %d, want 409", w.Code)
+	}
+}
+
+func TestCreateProjectValidation(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	w := do(r, http.MethodPost, "/projects", node.Project{ID: "Not A Subdomain"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422", w.Code)
+	}
+	var resp struct {
+		Fields []struct {
+			Field string `json:"field"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Fields) == 0 || resp.Fields[0].Field != "id" {
+		t.Fatalf("fields = %+v, want an error on id", resp.Fields)
+	}
+
+	if w := do(r, http.MethodPost, "/projects", "not an object"); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want 400", w.Code)
+	}
+}
+
+func TestGetProjectNotFound(t *testing.T) {
+	r, _ := newTestServer(t)
+	if w := do(r, http.MethodGet, "/projects/missing", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", w.Code)
+	}
+}
+
+func TestDeleteProject(t *testing.T) {
+	r, nodeManager := newTestServer(t)
+
+	if w := do(r, http.MethodDelete, "/projects/"+node.DefaultProjectID, nil); w.Code != http.StatusConflict {
+		t.Fatalf("delete default: status %d, want 409", w.Code)
+	}
+
+	do(r, http.MethodPost, "/projects", node.Project{ID: "team-a"})
+	if _, err := nodeManager.CreateNode(node.Node{Name: "node-1", IP: "10.0.0.10", Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeWorker, ProjectID: "team-a"}); err != nil {
+		t.Fatal(err)
+	}
+	if w := do(r, http.MethodDelete, "/projects/team-a", nil); w.Code != http.StatusConflict {
+		t.Fatalf("delete non-empty: status %d, want 409", w.Code)
+	}
+
+	do(r, http.MethodPost, "/projects", node.Project{ID: "team-b"})
+	if w := do(r, http.MethodDelete, "/projects/team-b", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete empty: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodGet, "/projects/team-b", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("get deleted: status %d, want 404", w.Code)
+	}
+}
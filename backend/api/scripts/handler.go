@@ -0,0 +1,56 @@
+package scripts
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/script"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 脚本管理接口
+type Handler struct {
+	scriptManager *script.ScriptManager
+}
+
+// NewHandler 创建脚本管理接口处理器
+func NewHandler(scriptManager *script.ScriptManager) *Handler {
+	return &Handler{
+		scriptManager: scriptManager,
+	}
+}
+
+// scriptsKey 请求上下文中保存项目脚本管理器的键
+const scriptsKey = "scriptManager"
+
+// loadProjectScripts 加载请求所属项目的脚本管理器，每个项目的脚本相互独立
+func (h *Handler) loadProjectScripts(c *gin.Context) {
+	manager, err := h.scriptManager.ForProject(api.ProjectID(c))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		c.Abort()
+		return
+	}
+	c.Set(scriptsKey, manager)
+	c.Next()
+}
+
+// projectScripts 请求所属项目的脚本管理器，由loadProjectScripts加载
+func projectScripts(c *gin.Context) *script.ScriptManager {
+	return c.MustGet(scriptsKey).(*script.ScriptManager)
+}
+
+// Register 注册脚本管理路由
+func (h *Handler) Register(r *api.Router) {
+	scriptRoutes := r.Group("/scripts", h.loadProjectScripts)
+	processScriptRoutes := r.Group("/deployment-process/scripts", h.loadProjectScripts)
+
+	scriptRoutes.GET("", api.Operation{Tag: "scripts", Summary: "获取系统脚本", Description: "返回脚本内容和元数据，没有保存元数据的脚本按名称推断绑定的步骤和发行版"}, h.listScripts)
+	scriptRoutes.POST("", api.Operation{Tag: "scripts", Summary: "保存自定义系统脚本", Description: "保存前检查脚本，存在bash语法错误时返回422且不保存，缺少必要命令、未替换的模板变量和危险命令作为warnings返回", Request: map[string]string{}}, h.saveScripts)
+	scriptRoutes.POST("/lint", api.Operation{Tag: "scripts", Summary: "检查脚本但不保存", Description: "使用bash -n检查语法，按脚本对应的部署步骤检查必要命令，并检查未替换的模板变量和危险命令", Request: map[string]string{}, Response: lintResponse{}}, h.lintScripts)
+	scriptRoutes.PUT("/:name/metadata", api.Operation{Tag: "scripts", Summary: "更新脚本元数据", Description: "设置脚本的描述、绑定的部署步骤、适用的发行版和作者，部署时按步骤和节点发行版选择脚本", Request: script.Metadata{}}, h.updateScriptMetadata)
+	processScriptRoutes.GET("", api.Operation{Tag: "scripts", Summary: "获取部署流程脚本"}, h.listDeploymentScripts)
+	processScriptRoutes.POST("", api.Operation{Tag: "scripts", Summary: "保存部署流程脚本", Description: "保存前检查脚本，存在bash语法错误时返回422且不保存", Request: map[string]string{}}, h.saveDeploymentScripts)
+	processScriptRoutes.POST("/reset", api.Operation{Tag: "scripts", Summary: "重置部署流程脚本到默认脚本"}, h.resetDeploymentScripts)
+	processScriptRoutes.GET("/:name/default", api.Operation{Tag: "scripts", Summary: "获取单个脚本的默认值"}, h.getDefaultScript)
+}
@@ -0,0 +1,56 @@
+package scripts
+
+import (
+	"errors"
+	"k8s-installer/api"
+	"k8s-installer/script"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errScriptLint 脚本存在语法错误，拒绝保存
+var errScriptLint = errors.New("script validation failed")
+
+// lintResponse 脚本检查结果
+type lintResponse struct {
+	Valid  bool               `json:"valid"`
+	Issues []script.LintIssue `json:"issues"`
+}
+
+// lintScripts 检查脚本但不保存：bash语法、步骤必要命令和未替换的模板变量
+func (h *Handler) lintScripts(c *gin.Context) {
+	var scripts map[string]string
+	if err := c.ShouldBindJSON(&scripts); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+
+	issues := script.LintAll(scripts)
+	c.JSON(http.StatusOK, lintResponse{Valid: !script.HasErrors(issues), Issues: issues})
+}
+
+// lintAndSave 检查并保存脚本，存在语法错误时返回422且不保存，其他问题作为warnings返回
+func (h *Handler) lintAndSave(c *gin.Context, scripts map[string]string) {
+	issues := script.LintAll(scripts)
+	if script.HasErrors(issues) {
+		resp := api.ErrorResponse(c, http.StatusUnprocessableEntity, errScriptLint)
+		resp["issues"] = issues
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	// 使用脚本管理器更新并保存脚本
+	projectScripts(c).UpdateScripts(scripts)
+	if err := projectScripts(c).SaveScripts(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "scripts saved successfully",
+		"warnings": issues,
+	})
+}
@@ -0,0 +1,126 @@
+package scripts
+
+import (
+	"fmt"
+	"k8s-installer/api"
+	"k8s-installer/script"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listScripts 获取系统脚本
+func (h *Handler) listScripts(c *gin.Context) {
+	// 使用脚本管理器获取脚本
+	c.JSON(http.StatusOK, gin.H{
+		"scripts":  projectScripts(c).GetScripts(),
+		"metadata": projectScripts(c).GetMetadata(),
+	})
+}
+
+// saveScripts 保存自定义系统脚本
+func (h *Handler) saveScripts(c *gin.Context) {
+	var scripts map[string]string
+	if err := c.ShouldBindJSON(&scripts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.lintAndSave(c, scripts)
+}
+
+// listDeploymentScripts 获取部署流程脚本
+func (h *Handler) listDeploymentScripts(c *gin.Context) {
+	// 获取所有部署流程脚本
+	c.JSON(http.StatusOK, gin.H{
+		"scripts":  projectScripts(c).GetScripts(),
+		"metadata": projectScripts(c).GetMetadata(),
+	})
+}
+
+// saveDeploymentScripts 保存部署流程脚本
+func (h *Handler) saveDeploymentScripts(c *gin.Context) {
+	var scripts map[string]string
+	if err := c.ShouldBindJSON(&scripts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.lintAndSave(c, scripts)
+}
+
+// resetDeploymentScripts 重置部署流程脚本到默认脚本
+func (h *Handler) resetDeploymentScripts(c *gin.Context) {
+	// 获取默认脚本
+	defaultScripts := projectScripts(c).GetDefaultScripts()
+
+	// 更新脚本管理器
+	projectScripts(c).UpdateScripts(defaultScripts)
+
+	// 保存到文件
+	if err := projectScripts(c).SaveScripts(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "scripts reset to default",
+		"message":      "所有脚本已重置为默认脚本",
+		"scriptsCount": len(defaultScripts),
+	})
+}
+
+// getDefaultScript 获取单个脚本的默认值
+func (h *Handler) getDefaultScript(c *gin.Context) {
+	scriptName := c.Param("name")
+	// 获取所有默认脚本
+	defaultScripts := projectScripts(c).GetDefaultScripts()
+	// 查找指定脚本
+	if scriptContent, exists := defaultScripts[scriptName]; exists {
+		c.JSON(http.StatusOK, gin.H{
+			"status":        "success",
+			"message":       "获取默认脚本成功",
+			"scriptName":    scriptName,
+			"scriptContent": scriptContent,
+		})
+	} else {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":      "script not found",
+			"message":    "未找到指定的默认脚本",
+			"scriptName": scriptName,
+		})
+	}
+}
+
+// updateScriptMetadata 更新脚本的描述、绑定步骤、适用发行版和作者
+func (h *Handler) updateScriptMetadata(c *gin.Context) {
+	name := script.CanonicalName(c.Param("name"))
+	var md script.Metadata
+	if err := c.ShouldBindJSON(&md); err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := md.Validate(); err != nil {
+		api.ValidationFailed(c, err)
+		return
+	}
+	if _, ok := projectScripts(c).GetScript(name); !ok {
+		api.Error(c, http.StatusNotFound, fmt.Errorf("script %s not found", name))
+		return
+	}
+
+	if err := projectScripts(c).SetMetadata(name, md); err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"name":     name,
+		"metadata": projectScripts(c).MetadataOf(name),
+	})
+}
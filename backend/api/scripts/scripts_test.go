@@ -0,0 +1,207 @@
+package scripts
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"k8s-installer/api"
+	"k8s-installer/node"
+	"k8s-installer/script"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testProject = "team-a"
+
+// newTestServer 创建使用临时数据库的脚本接口
+func newTestServer(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	projectManager, err := node.NewProjectManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projectManager.CreateProject(node.Project{ID: testProject}); err != nil {
+		t.Fatal(err)
+	}
+	scriptManager := script.NewScriptManager()
+	if err := scriptManager.SetDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(api.ProjectScope(projectManager))
+	NewHandler(scriptManager).Register(api.NewRouter(r, nil))
+	return r
+}
+
+// do 以project项目的身份发送请求，body不为nil时编码为JSON
+func do(r http.Handler, method, path, project string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if project != "" {
+		req.Header.Set(api.ProjectHeader, project)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// listScripts 获取项目的脚本和元数据
+func listScripts(t *testing.T, r http.Handler, project string) (map[string]string, map[string]script.Metadata) {
+	t.Helper()
+	w := do(r, http.MethodGet, "/scripts", project, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Scripts  map[string]string          `json:"scripts"`
+		Metadata map[string]script.Metadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Scripts, resp.Metadata
+}
+
+func requireBash(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found, syntax check is skipped")
+	}
+}
+
+func TestSaveAndResetScripts(t *testing.T) {
+	r := newTestServer(t)
+
+	custom := "#!/bin/bash\nset -e\nkubeadm init --pod-network-cidr=10.244.0.0/16\n"
+	w := do(r, http.MethodPost, "/scripts", testProject, map[string]string{script.StepK8sInit: custom})
+	if w.Code != http.StatusOK {
+		t.Fatalf("save: status %d, body %s", w.Code, w.Body)
+	}
+
+	scripts, _ := listScripts(t, r, testProject)
+	if scripts[script.StepK8sInit] != custom {
+		t.Fatalf("saved script not returned: %q", scripts[script.StepK8sInit])
+	}
+	// 每个项目的脚本相互独立
+	scripts, _ = listScripts(t, r, "")
+	if scripts[script.StepK8sInit] == custom {
+		t.Fatal("script saved in another project is visible in the default project")
+	}
+
+	w = do(r, http.MethodGet, "/deployment-process/scripts/"+script.StepK8sInit+"/default", testProject, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("default script: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodGet, "/deployment-process/scripts/unknown/default", testProject, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown default script: status %d, want 404", w.Code)
+	}
+
+	if w := do(r, http.MethodPost, "/deployment-process/scripts/reset", testProject, nil); w.Code != http.StatusOK {
+		t.Fatalf("reset: status %d, body %s", w.Code, w.Body)
+	}
+	scripts, _ = listScripts(t, r, testProject)
+	if scripts[script.StepK8sInit] == custom {
+		t.Fatal("reset kept the custom script")
+	}
+}
+
+func TestSaveScriptsRejectsSyntaxErrors(t *testing.T) {
+	requireBash(t)
+	r := newTestServer(t)
+
+	broken := "#!/bin/bash\nif true; then\nkubeadm init\n"
+	w := do(r, http.MethodPost, "/scripts", "", map[string]string{script.StepK8sInit: broken})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422, body %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Issues []script.LintIssue `json:"issues"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !script.HasErrors(resp.Issues) {
+		t.Fatalf("issues without errors: %+v", resp.Issues)
+	}
+
+	scripts, _ := listScripts(t, r, "")
+	if scripts[script.StepK8sInit] == broken {
+		t.Fatal("script with syntax error was saved")
+	}
+}
+
+func TestLintScripts(t *testing.T) {
+	requireBash(t)
+	r := newTestServer(t)
+
+	w := do(r, http.MethodPost, "/scripts/lint", "", map[string]string{
+		script.StepK8sInit: "#!/bin/bash\necho {{ .Version }}\n",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var resp lintResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	// 缺少kubeadm init和未替换的模板变量只是警告
+	rules := make(map[string]bool)
+	for _, issue := range resp.Issues {
+		rules[issue.Rule] = true
+	}
+	if !resp.Valid || !rules["essential-command"] || !rules["template-variable"] {
+		t.Fatalf("lint response: %+v", resp)
+	}
+
+	w = do(r, http.MethodPost, "/scripts/lint", "", map[string]string{script.StepK8sInit: "if then fi (\n"})
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Valid {
+		t.Fatalf("script with syntax error is valid: %+v", resp)
+	}
+
+	if w := do(r, http.MethodPost, "/scripts/lint", "", "not a map"); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want 400", w.Code)
+	}
+}
+
+func TestUpdateScriptMetadata(t *testing.T) {
+	r := newTestServer(t)
+
+	md := script.Metadata{Description: "初始化控制平面", Step: script.StepK8sInit, Distros: []string{"ubuntu"}, Author: "ops"}
+	w := do(r, http.MethodPut, "/scripts/"+script.StepK8sInit+"/metadata", testProject, md)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	_, metadata := listScripts(t, r, testProject)
+	if got := metadata[script.StepK8sInit]; got.Description != md.Description || got.Author != md.Author {
+		t.Fatalf("metadata = %+v", got)
+	}
+
+	w = do(r, http.MethodPut, "/scripts/"+script.StepK8sInit+"/metadata", testProject, script.Metadata{Step: "unknown", Distros: []string{"Ubuntu 22.04"}})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid metadata: status %d, want 422", w.Code)
+	}
+	if w := do(r, http.MethodPut, "/scripts/missing/metadata", testProject, md); w.Code != http.StatusNotFound {
+		t.Fatalf("missing script: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package system
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"k8s-installer/backup"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// backupExportRequest 导出加密的安装器状态备份请求
+type backupExportRequest struct {
+	Passphrase string `json:"passphrase" binding:"required"`
+}
+
+// exportBackup 导出安装器状态：项目、节点（含凭据）、所有项目的脚本、部署记录、包源、webhook，使用passphrase加密
+func (h *Handler) exportBackup(c *gin.Context) {
+	var req backupExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	data, archive, err := backup.Export(h.nodeManager.GetDB().(*sql.DB), req.Passphrase)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == backup.ErrPassphraseTooShort {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	fmt.Printf("导出备份: %v\n", archive.Summary())
+	filename := fmt.Sprintf("k8s-installer-backup-%s.bak", archive.CreatedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// importBackup 导入备份：multipart表单字段file为备份文件，passphrase为导出时使用的密码。
+// 备份中包含的表会被整体替换
+func (h *Handler) importBackup(c *gin.Context) {
+	passphrase := c.PostForm("passphrase")
+	file, err := c.FormFile("file")
+	if err != nil || passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "file and passphrase are required",
+		})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	archive, err := backup.Import(h.nodeManager.GetDB().(*sql.DB), data, passphrase)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, backup.ErrInvalidArchive) || err == backup.ErrInvalidPassphrase {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 版本1的备份只包含默认项目的脚本，不在scripts表中
+	if _, ok := archive.Tables["scripts"]; !ok && len(archive.Scripts) > 0 {
+		h.scriptManager.UpdateScripts(archive.Scripts)
+		if err := h.scriptManager.SaveScripts(); err != nil {
+			fmt.Printf("保存恢复的脚本失败: %v\n", err)
+		}
+	}
+	// 恢复内存中的脚本和hosts缓存
+	if err := h.scriptManager.Reload(); err != nil {
+		fmt.Printf("重新加载恢复的脚本失败: %v\n", err)
+	}
+	h.hostsManager.RefreshCache()
+
+	summary := archive.Summary()
+	fmt.Printf("导入备份 (创建于 %s): %v\n", archive.CreatedAt.Format(time.RFC3339), summary)
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"createdAt": archive.CreatedAt,
+		"restored":  summary,
+	})
+}
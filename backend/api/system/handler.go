@@ -0,0 +1,53 @@
+package system
+
+import (
+	"k8s-installer/api"
+	"k8s-installer/event"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/metrics"
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+// Handler 系统、webhook通知和备份接口
+type Handler struct {
+	nodeManager    *node.SqliteNodeManager
+	scriptManager  *script.ScriptManager
+	webhookManager *event.WebhookManager
+	lockManager    *lock.Manager
+	hostsManager   *node.HostsManager
+	versionManager *kubeadm.VersionManager
+	// dataDir 数据目录，就绪检查确认该目录可写
+	dataDir string
+}
+
+// NewHandler 创建系统、webhook通知和备份接口处理器
+func NewHandler(nodeManager *node.SqliteNodeManager, scriptManager *script.ScriptManager, webhookManager *event.WebhookManager, lockManager *lock.Manager, hostsManager *node.HostsManager, versionManager *kubeadm.VersionManager, dataDir string) *Handler {
+	return &Handler{
+		nodeManager:    nodeManager,
+		scriptManager:  scriptManager,
+		webhookManager: webhookManager,
+		lockManager:    lockManager,
+		hostsManager:   hostsManager,
+		versionManager: versionManager,
+		dataDir:        dataDir,
+	}
+}
+
+// Register 注册系统、webhook通知和备份路由
+func (h *Handler) Register(r *api.Router) {
+	webhookRoutes := r.Group("/webhooks")
+	backupRoutes := r.Group("/backup")
+
+	r.GET("/health", api.Operation{Tag: "system", Summary: "健康检查", Description: "包含数据库查询延迟和连接池状态，数据库不可用时返回503", Response: healthResponse{}}, h.health)
+	r.GET("/metrics", api.Operation{Tag: "system", Summary: "Prometheus监控指标", Produces: "text/plain"}, metrics.Handler())
+	r.GET("/locks", api.Operation{Tag: "system", Summary: "当前持有的节点和集群锁"}, h.listLocks)
+	webhookRoutes.GET("", api.Operation{Tag: "webhooks", Summary: "获取webhook列表"}, h.listWebhooks)
+	webhookRoutes.POST("", api.Operation{Tag: "webhooks", Summary: "添加webhook", Request: event.Webhook{}, Response: event.Webhook{}}, h.createWebhook)
+	webhookRoutes.PUT("/:id", api.Operation{Tag: "webhooks", Summary: "更新webhook", Request: event.Webhook{}, Response: event.Webhook{}}, h.updateWebhook)
+	webhookRoutes.DELETE("/:id", api.Operation{Tag: "webhooks", Summary: "删除webhook"}, h.deleteWebhook)
+	webhookRoutes.POST("/:id/test", api.Operation{Tag: "webhooks", Summary: "发送测试通知"}, h.testWebhook)
+	backupRoutes.POST("/export", api.Operation{Tag: "backup", Summary: "导出加密的安装器状态备份", Request: backupExportRequest{}, Produces: "application/octet-stream"}, h.exportBackup)
+	backupRoutes.POST("/import", api.Operation{Tag: "backup", Summary: "导入备份（multipart表单：file、passphrase）"}, h.importBackup)
+}
@@ -0,0 +1,127 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s-installer/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 探针检查项的状态
+const (
+	checkOK    = "ok"
+	checkWarn  = "warning"
+	checkError = "error"
+)
+
+// probeCheck 就绪检查中单个依赖的检查结果，warning不影响就绪状态
+type probeCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Message   string  `json:"message,omitempty"`
+}
+
+// readinessResponse 就绪检查结果
+type readinessResponse struct {
+	Status string       `json:"status"`
+	Checks []probeCheck `json:"checks"`
+}
+
+// RegisterProbes 在根路径注册存活和就绪探针，供Kubernetes、systemd等进程管理工具使用，不带版本前缀
+func (h *Handler) RegisterProbes(r *api.Router) {
+	r.GET("/healthz", api.Operation{Tag: "system", Summary: "存活探针", Description: "进程能够处理请求时返回200，不检查依赖"}, h.healthz)
+	r.GET("/readyz", api.Operation{Tag: "system", Summary: "就绪探针", Description: "检查数据库连接、数据目录读写、脚本加载和版本列表同步，任一检查失败时返回503；版本同步失败但可以使用缓存时只给出警告", Response: readinessResponse{}}, h.readyz)
+}
+
+// healthz 存活探针
+func (h *Handler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz 就绪探针，依次检查各个依赖
+func (h *Handler) readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	response := readinessResponse{Status: "ready"}
+	for _, check := range []struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}{
+		{"database", h.checkDatabase},
+		{"dataDir", h.checkDataDir},
+		{"scripts", h.checkScripts},
+		{"versions", h.checkVersions},
+	} {
+		start := time.Now()
+		warning, err := check.run(ctx)
+		result := probeCheck{Name: check.name, Status: checkOK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+		switch {
+		case err != nil:
+			result.Status = checkError
+			result.Message = err.Error()
+			response.Status = "not_ready"
+		case warning != "":
+			result.Status = checkWarn
+			result.Message = warning
+		}
+		response.Checks = append(response.Checks, result)
+	}
+
+	status := http.StatusOK
+	if response.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+// checkDatabase 数据库能否查询
+func (h *Handler) checkDatabase(ctx context.Context) (string, error) {
+	health := h.nodeManager.CheckDB(ctx)
+	if health.Status != "ok" {
+		return "", fmt.Errorf("database unavailable: %s", health.Error)
+	}
+	return "", nil
+}
+
+// checkDataDir 数据目录能否写入，数据库、WAL和备份文件都保存在该目录
+func (h *Handler) checkDataDir(ctx context.Context) (string, error) {
+	dir := h.dataDir
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return "", fmt.Errorf("data directory %s is not writable: %v", filepath.Clean(dir), err)
+	}
+	name := f.Name()
+	f.Close()
+	return "", os.Remove(name)
+}
+
+// checkScripts 部署脚本是否已加载并能从数据库读取
+func (h *Handler) checkScripts(ctx context.Context) (string, error) {
+	return "", h.scriptManager.Check(ctx)
+}
+
+// checkVersions Kubernetes版本列表是否已同步，上游不可用时使用缓存或内置列表，只给出警告
+func (h *Handler) checkVersions(ctx context.Context) (string, error) {
+	status := h.versionManager.GetSyncStatus()
+	if status.LastSyncTime.IsZero() {
+		return "", fmt.Errorf("kubernetes versions have not been synced yet")
+	}
+	if status.Count == 0 {
+		return "", fmt.Errorf("no kubernetes versions available")
+	}
+	if status.Error != "" {
+		return fmt.Sprintf("%s, using %s versions", status.Error, status.Source), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,37 @@
+package system
+
+import (
+	"context"
+	"k8s-installer/node"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthResponse 健康检查结果
+type healthResponse struct {
+	Status   string        `json:"status"`
+	Database node.DBHealth `json:"database"`
+}
+
+// health 健康检查，包含数据库查询延迟，数据库不可用时返回503
+func (h *Handler) health(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	response := healthResponse{Status: "ok", Database: h.nodeManager.CheckDB(ctx)}
+	if response.Database.Status != "ok" {
+		response.Status = "error"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// listLocks 获取当前持有的节点和集群锁
+func (h *Handler) listLocks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"locks": h.lockManager.List(),
+	})
+}
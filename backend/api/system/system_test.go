@@ -0,0 +1,226 @@
+package system
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s-installer/api"
+	"k8s-installer/event"
+	"k8s-installer/kubeadm"
+	"k8s-installer/lock"
+	"k8s-installer/node"
+	"k8s-installer/script"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testPassphrase = "backup-passphrase"
+
+type testServer struct {
+	router        *gin.Engine
+	nodeManager   *node.SqliteNodeManager
+	scriptManager *script.ScriptManager
+}
+
+// newTestServer 创建使用临时数据库和数据目录的系统接口，版本列表没有同步
+func newTestServer(t *testing.T, dataDir string) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	scriptManager := script.NewScriptManager()
+	if err := scriptManager.SetDB(db); err != nil {
+		t.Fatal(err)
+	}
+	webhookManager, err := event.NewWebhookManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	h := NewHandler(nodeManager, scriptManager, webhookManager, lock.NewManager(), node.NewHostsManager(nodeManager), kubeadm.NewVersionManager(time.Hour), dataDir)
+	router := api.NewRouter(r, nil)
+	h.Register(router)
+	h.RegisterProbes(router)
+	return &testServer{router: r, nodeManager: nodeManager, scriptManager: scriptManager}
+}
+
+func (s *testServer) do(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *testServer) get(path string) *httptest.ResponseRecorder {
+	return s.do(httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+// readyChecks 发送就绪检查请求，返回HTTP状态和各检查项的结果
+func (s *testServer) readyChecks(t *testing.T) (int, map[string]probeCheck) {
+	t.Helper()
+	w := s.get("/readyz")
+	var resp readinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	checks := make(map[string]probeCheck)
+	for _, check := range resp.Checks {
+		checks[check.Name] = check
+	}
+	return w.Code, checks
+}
+
+func TestHealthz(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+	if w := s.get("/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+
+	// 版本列表还没有同步，其他依赖可用
+	code, checks := s.readyChecks(t)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503", code)
+	}
+	for _, name := range []string{"database", "dataDir", "scripts"} {
+		if checks[name].Status != checkOK {
+			t.Errorf("%s check: %+v", name, checks[name])
+		}
+	}
+	if checks["versions"].Status != checkError {
+		t.Errorf("versions check: %+v", checks["versions"])
+	}
+}
+
+func TestReadyzDataDirNotWritable(t *testing.T) {
+	s := newTestServer(t, filepath.Join(t.TempDir(), "missing"))
+
+	code, checks := s.readyChecks(t)
+	if code != http.StatusServiceUnavailable || checks["dataDir"].Status != checkError {
+		t.Fatalf("status %d, dataDir check %+v", code, checks["dataDir"])
+	}
+}
+
+func TestReadyzDatabaseUnavailable(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+	s.nodeManager.GetDB().(*sql.DB).Close()
+
+	_, checks := s.readyChecks(t)
+	if checks["database"].Status != checkError {
+		t.Fatalf("database check: %+v", checks["database"])
+	}
+}
+
+// exportBackup 导出备份，返回响应
+func (s *testServer) exportBackup(passphrase string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(backupExportRequest{Passphrase: passphrase})
+	req := httptest.NewRequest(http.MethodPost, "/backup/export", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return s.do(req)
+}
+
+// importBackup 以multipart表单上传备份
+func (s *testServer) importBackup(t *testing.T, data []byte, passphrase string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if data != nil {
+		part, err := form.CreateFormFile("file", "backup.bak")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	form.WriteField("passphrase", passphrase)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/backup/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return s.do(req)
+}
+
+func TestBackupExportImport(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+
+	n, err := s.nodeManager.CreateNode(node.Node{Name: "node-1", IP: "10.0.0.10", Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeMaster})
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := "#!/bin/bash\nkubeadm init\n"
+	s.scriptManager.UpdateScript(script.StepK8sInit, custom)
+	if err := s.scriptManager.SaveScripts(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := s.exportBackup(testPassphrase)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: status %d, body %s", w.Code, w.Body)
+	}
+	data := w.Body.Bytes()
+
+	// 导出之后的修改在导入时被备份替换
+	if err := s.nodeManager.DeleteNode(n.ID); err != nil {
+		t.Fatal(err)
+	}
+	s.scriptManager.UpdateScript(script.StepK8sInit, "#!/bin/bash\necho changed\n")
+	if err := s.scriptManager.SaveScripts(); err != nil {
+		t.Fatal(err)
+	}
+
+	w = s.importBackup(t, data, testPassphrase)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: status %d, body %s", w.Code, w.Body)
+	}
+	if _, err := s.nodeManager.GetNode(n.ID); err != nil {
+		t.Fatalf("node was not restored: %v", err)
+	}
+	// 内存中的脚本随导入重新加载
+	if got, _ := s.scriptManager.GetScript(script.StepK8sInit); got != custom {
+		t.Fatalf("script after import = %q, want %q", got, custom)
+	}
+}
+
+func TestBackupValidation(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+
+	if w := s.exportBackup("short"); w.Code != http.StatusBadRequest {
+		t.Errorf("export with short passphrase: status %d, want 400", w.Code)
+	}
+	if w := s.exportBackup(""); w.Code != http.StatusBadRequest {
+		t.Errorf("export without passphrase: status %d, want 400", w.Code)
+	}
+
+	w := s.exportBackup(testPassphrase)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: status %d, body %s", w.Code, w.Body)
+	}
+	data := w.Body.Bytes()
+
+	for name, tc := range map[string]struct {
+		data       []byte
+		passphrase string
+	}{
+		"missing file":       {nil, testPassphrase},
+		"missing passphrase": {data, ""},
+		"wrong passphrase":   {data, "wrong-passphrase"},
+		"not a backup":       {[]byte("not a backup"), testPassphrase},
+	} {
+		if w := s.importBackup(t, tc.data, tc.passphrase); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400, body %s", name, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package system
+
+import (
+	"fmt"
+	"k8s-installer/event"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listWebhooks 获取webhook通知配置列表
+func (h *Handler) listWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookManager.ListWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":   webhooks,
+		"eventTypes": event.AllTypes,
+	})
+}
+
+// createWebhook 添加webhook
+func (h *Handler) createWebhook(c *gin.Context) {
+	var webhook event.Webhook
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	created, err := h.webhookManager.CreateWebhook(webhook)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// updateWebhook 更新webhook
+func (h *Handler) updateWebhook(c *gin.Context) {
+	var webhook event.Webhook
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	updated, err := h.webhookManager.UpdateWebhook(c.Param("id"), webhook)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == event.ErrWebhookNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteWebhook 删除webhook
+func (h *Handler) deleteWebhook(c *gin.Context) {
+	if err := h.webhookManager.DeleteWebhook(c.Param("id")); err != nil {
+		status := http.StatusInternalServerError
+		if err == event.ErrWebhookNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// testWebhook 发送测试通知
+func (h *Handler) testWebhook(c *gin.Context) {
+	webhook, err := h.webhookManager.GetWebhook(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == event.ErrWebhookNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	testEvent := event.Event{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		Type:      "test",
+		Message:   "这是一条测试通知",
+		CreatedAt: time.Now(),
+	}
+	if err := h.webhookManager.Send(*webhook, testEvent); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Test notification sent",
+	})
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"k8s-installer/errcode"
+	"k8s-installer/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidationFailed 返回请求校验失败响应：字段校验错误返回422和无效字段列表，其他错误返回400
+func ValidationFailed(c *gin.Context, err error) {
+	if errs, ok := validate.AsErrors(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "validation failed",
+			"code":    errcode.Validation,
+			"message": errcode.Message(errcode.Validation, Lang(c)),
+			"fields":  errs,
+		})
+		return
+	}
+	Error(c, http.StatusBadRequest, err)
+}
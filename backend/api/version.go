@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// V1Prefix 当前版本接口的路径前缀
+const V1Prefix = "/api/v1"
+
+// Module 按模块注册路由的接口处理器
+type Module interface {
+	Register(r *Router)
+}
+
+// Alias 创建不登记到文档的路由注册器，用于保留旧路径，handlers作为这些路由的中间件
+func (r *Router) Alias(handlers ...gin.HandlerFunc) *Router {
+	return &Router{router: r.router.Group("", handlers...), basePath: r.basePath}
+}
+
+// Deprecated 标记旧的无前缀路径已废弃，响应中加上Deprecation头和指向prefix下新路径的Link头
+func Deprecated(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		successor := prefix + c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			successor += "?" + c.Request.URL.RawQuery
+		}
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		c.Next()
+	}
+}
+
+// RegisterVersioned 在prefix下注册模块路由，同时保留已废弃的无前缀路径，兼容旧版前端
+func RegisterVersioned(r *Router, prefix string, modules ...Module) {
+	versioned := r.Group(prefix)
+	legacy := r.Alias(Deprecated(strings.TrimSuffix(prefix, "/")))
+	for _, m := range modules {
+		m.Register(versioned)
+		m.Register(legacy)
+	}
+}
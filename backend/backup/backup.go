@@ -0,0 +1,387 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ArchiveVersion 备份文件格式版本。版本2起脚本保存在scripts和project_scripts表中
+const ArchiveVersion = 2
+
+// MinPassphraseLength 备份密码的最小长度
+const MinPassphraseLength = 8
+
+// archiveMagic 备份文件头，用于识别文件格式
+var archiveMagic = []byte("K8SIBAK1")
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// Tables 备份的数据库表，按恢复顺序排列，项目在节点和节点组之前恢复
+var Tables = []string{
+	"projects",
+	"installer_keys",
+	"nodes",
+	"node_groups",
+	"scripts",
+	"project_scripts",
+	"package_sources",
+	"deployments",
+	"deployment_steps",
+	"job_artifacts",
+	"cluster_kubeconfigs",
+	"webhooks",
+	"kubernetes_versions",
+}
+
+// ExcludedTables 不备份的数据库表：日志和心跳记录数据量大且可以重新生成，幂等记录只在短时间内有效。
+// 新增的表必须加入Tables或ExcludedTables
+var ExcludedTables = []string{
+	"logs",
+	"node_heartbeats",
+	"idempotency_keys",
+}
+
+// projectTables 通过project_id列归属项目的表，恢复后补齐这些表引用的项目
+var projectTables = []string{
+	"nodes",
+	"node_groups",
+	"project_scripts",
+}
+
+// 错误定义
+var (
+	ErrInvalidArchive     = errors.New("invalid backup archive")
+	ErrInvalidPassphrase  = errors.New("invalid passphrase or corrupted backup archive")
+	ErrPassphraseTooShort = fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+)
+
+// Archive 备份内容，节点凭据等敏感信息只在加密后的备份文件中保存
+type Archive struct {
+	Version   int                                 `json:"version"`
+	CreatedAt time.Time                           `json:"createdAt"`
+	Tables    map[string][]map[string]interface{} `json:"tables"`
+	// Scripts 版本1备份中默认项目的脚本，版本2起脚本随scripts和project_scripts表备份
+	Scripts map[string]string `json:"scripts,omitempty"`
+}
+
+// Summary 每张表的记录数，版本1备份还包括脚本数
+func (a *Archive) Summary() map[string]int {
+	summary := make(map[string]int, len(a.Tables)+1)
+	for table, rows := range a.Tables {
+		summary[table] = len(rows)
+	}
+	if len(a.Scripts) > 0 {
+		summary["scripts"] = len(a.Scripts)
+	}
+	return summary
+}
+
+// Export 导出数据库表，包括所有项目的脚本，压缩后使用passphrase加密
+func Export(db *sql.DB, passphrase string) ([]byte, *Archive, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, nil, ErrPassphraseTooShort
+	}
+
+	archive := &Archive{
+		Version:   ArchiveVersion,
+		CreatedAt: time.Now(),
+		Tables:    make(map[string][]map[string]interface{}),
+	}
+	for _, table := range Tables {
+		rows, err := dumpTable(db, table)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rows != nil {
+			archive.Tables[table] = rows
+		}
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode backup: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to compress backup: %v", err)
+	}
+
+	data, err := encrypt(buf.Bytes(), passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, archive, nil
+}
+
+// Import 解密备份文件并在一个事务中替换备份中包含的表，返回备份内容供调用方恢复脚本等内存状态
+func Import(db *sql.DB, data []byte, passphrase string) (*Archive, error) {
+	plain, err := decrypt(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	defer gz.Close()
+
+	var archive Archive
+	decoder := json.NewDecoder(gz)
+	decoder.UseNumber()
+	if err := decoder.Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if archive.Version > ArchiveVersion {
+		return nil, fmt.Errorf("unsupported backup version: %d", archive.Version)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, table := range Tables {
+		rows, ok := archive.Tables[table]
+		if !ok {
+			continue
+		}
+		if err := restoreTable(tx, table, rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := restoreProjects(tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit restore: %v", err)
+	}
+	return &archive, nil
+}
+
+// restoreProjects 为恢复后的节点、节点组和项目脚本补齐引用的项目，
+// 没有projects表的旧备份恢复后节点不会属于不存在的项目
+func restoreProjects(tx *sql.Tx) error {
+	projectColumns, err := tableColumns(tx, "projects")
+	if err != nil {
+		return err
+	}
+	if len(projectColumns) == 0 {
+		return nil
+	}
+	for _, table := range projectTables {
+		columns, err := tableColumns(tx, table)
+		if err != nil {
+			return err
+		}
+		if _, ok := columns["project_id"]; !ok {
+			continue
+		}
+		query := fmt.Sprintf("INSERT OR IGNORE INTO projects (id, description, created_at) SELECT DISTINCT project_id, '', ? FROM %s", table)
+		if _, err := tx.Exec(query, time.Now()); err != nil {
+			return fmt.Errorf("failed to restore projects of %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// dumpTable 读取表中的所有记录，表不存在时返回nil
+func dumpTable(db *sql.DB, table string) ([]map[string]interface{}, error) {
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check table %s: %v", table, err)
+	}
+	if exists == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.Query("SELECT * FROM " + table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan table %s: %v", table, err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// restoreTable 清空表后写入备份中的记录，只写入当前表结构中存在的列，兼容新旧版本的表结构
+func restoreTable(tx *sql.Tx, table string, rows []map[string]interface{}) error {
+	columnTypes, err := tableColumns(tx, table)
+	if err != nil {
+		return err
+	}
+	if len(columnTypes) == 0 {
+		// 当前版本没有该表，跳过
+		return nil
+	}
+	if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+		return fmt.Errorf("failed to clear table %s: %v", table, err)
+	}
+
+	for _, row := range rows {
+		var columns, placeholders []string
+		var args []interface{}
+		for column, value := range row {
+			columnType, ok := columnTypes[column]
+			if !ok {
+				continue
+			}
+			columns = append(columns, column)
+			placeholders = append(placeholders, "?")
+			args = append(args, restoreValue(value, columnType))
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to restore table %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// tableColumns 获取表的列名和声明类型，表不存在时返回空map
+func tableColumns(tx *sql.Tx, table string) (map[string]string, error) {
+	rows, err := tx.Query("SELECT name, type FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, columnType string
+		if err := rows.Scan(&name, &columnType); err != nil {
+			return nil, err
+		}
+		columns[name] = strings.ToUpper(columnType)
+	}
+	return columns, rows.Err()
+}
+
+// restoreValue 将JSON解码后的值转换回数据库类型，时间列还原为time.Time，保证读取时格式一致
+func restoreValue(value interface{}, columnType string) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case string:
+		if columnType == "DATETIME" || columnType == "TIMESTAMP" {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+		return v
+	}
+	return value
+}
+
+// deriveKey 使用scrypt从密码派生AES-256密钥
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+}
+
+// encrypt 使用AES-256-GCM加密，输出格式：文件头 | salt | nonce | 密文
+func encrypt(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(archiveMagic)+saltSize+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, archiveMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	// 文件头作为附加数据参与认证
+	return gcm.Seal(out, nonce, plain, archiveMagic), nil
+}
+
+// decrypt 解密encrypt生成的数据
+func decrypt(data []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(data, archiveMagic) || len(data) < len(archiveMagic)+saltSize {
+		return nil, ErrInvalidArchive
+	}
+	data = data[len(archiveMagic):]
+	salt, data := data[:saltSize], data[saltSize:]
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrInvalidArchive
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, archiveMagic)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return plain, nil
+}
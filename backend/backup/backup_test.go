@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+const testPassphrase = "backup-passphrase"
+
+// createTablePattern 匹配迁移中创建的表
+var createTablePattern = regexp.MustCompile(`CREATE TABLE IF NOT EXISTS (\w+)`)
+
+// TestTablesCoverMigrations 每个迁移创建的表都必须加入Tables或ExcludedTables，避免新表在备份中丢失
+func TestTablesCoverMigrations(t *testing.T) {
+	known := make(map[string]bool)
+	for _, table := range append(append([]string{}, Tables...), ExcludedTables...) {
+		known[table] = true
+	}
+
+	found := 0
+	err := filepath.WalkDir("..", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, match := range createTablePattern.FindAllSubmatch(data, -1) {
+			found++
+			if table := string(match[1]); !known[table] {
+				t.Errorf("table %s created in %s is neither backed up nor excluded", table, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == 0 {
+		t.Fatal("no migrations found")
+	}
+}
+
+func newTestDB(t *testing.T) (*sql.DB, *node.SqliteNodeManager, *node.ProjectManager, *script.ScriptManager) {
+	t.Helper()
+	nodeManager, err := node.NewSqliteNodeManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := nodeManager.GetDB().(*sql.DB)
+	projectManager, err := node.NewProjectManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptManager := script.NewScriptManager()
+	if err := scriptManager.SetDB(db); err != nil {
+		t.Fatal(err)
+	}
+	return db, nodeManager, projectManager, scriptManager
+}
+
+func TestExportImportProjects(t *testing.T) {
+	db, nodeManager, projectManager, scriptManager := newTestDB(t)
+	if _, err := projectManager.CreateProject(node.Project{ID: "team-a"}); err != nil {
+		t.Fatal(err)
+	}
+	created, err := nodeManager.CreateNode(node.Node{Name: "node-1", IP: "10.0.0.10", Port: 22, Username: "root", Password: "secret", NodeType: node.NodeTypeWorker, ProjectID: "team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectScripts, err := scriptManager.ForProject("team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectScripts.UpdateScript("custom_check", "echo team-a")
+	if err := projectScripts.SaveScripts(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _, err := Export(db, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stmt := range []string{"DELETE FROM nodes", "DELETE FROM project_scripts", "DELETE FROM projects WHERE id = 'team-a'"} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Import(db, data, testPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if err := scriptManager.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := projectManager.GetProject("team-a"); err != nil {
+		t.Fatalf("project not restored: %v", err)
+	}
+	restored, err := nodeManager.GetNode(created.ID)
+	if err != nil {
+		t.Fatalf("node not restored: %v", err)
+	}
+	if restored.ProjectID != "team-a" {
+		t.Fatalf("node restored into project %q", restored.ProjectID)
+	}
+	projectScripts, err = scriptManager.ForProject("team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, ok := projectScripts.GetScript("custom_check"); !ok || content != "echo team-a" {
+		t.Fatalf("project script not restored: %q, %v", content, ok)
+	}
+}
+
+// TestImportAddsMissingProjects 没有projects表的旧备份恢复后，节点引用的项目必须存在
+func TestImportAddsMissingProjects(t *testing.T) {
+	db, _, projectManager, _ := newTestDB(t)
+	archive := &Archive{
+		Version:   1,
+		CreatedAt: time.Now(),
+		Tables: map[string][]map[string]interface{}{
+			"nodes": {{
+				"id": "node-1", "name": "node-1", "ip": "10.0.0.10", "port": 22, "username": "root",
+				"node_type": "worker", "status": "offline", "project_id": "legacy",
+				"created_at": time.Now().Format(time.RFC3339Nano), "updated_at": time.Now().Format(time.RFC3339Nano),
+			}},
+		},
+	}
+	if _, err := Import(db, encodeArchive(t, archive), testPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projectManager.GetProject("legacy"); err != nil {
+		t.Fatalf("missing project not created: %v", err)
+	}
+	var projectID string
+	if err := db.QueryRow("SELECT project_id FROM nodes WHERE id = 'node-1'").Scan(&projectID); err != nil || projectID != "legacy" {
+		t.Fatalf("node not restored: %q, %v", projectID, err)
+	}
+}
+
+func TestImportWrongPassphrase(t *testing.T) {
+	db, _, _, _ := newTestDB(t)
+	data, _, err := Export(db, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(db, data, "another-passphrase"); err != ErrInvalidPassphrase {
+		t.Fatalf("err = %v, want ErrInvalidPassphrase", err)
+	}
+	if _, _, err := Export(db, "short"); err != ErrPassphraseTooShort {
+		t.Fatalf("err = %v, want ErrPassphraseTooShort", err)
+	}
+}
+
+// encodeArchive 按Export的格式压缩并加密备份内容
+func encodeArchive(t *testing.T, archive *Archive) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := encrypt(buf.Bytes(), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
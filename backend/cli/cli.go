@@ -0,0 +1,149 @@
+// Package cli 命令行模式：不启动Web界面，通过后端接口或直接访问本地数据库管理节点、部署和重置集群、查看实时日志，
+// 便于在CI等无界面环境中使用脚本操作。
+//
+// 子命令使用标准库flag解析参数，没有按最初的需求引入cobra：安装器需要在离线环境中构建，
+// 新增依赖都要随源码提供，而这里只有少量固定的子命令，用命令表按最长路径匹配即可。
+// 参数写法为Go风格的-name value（--name同样可用），不支持短参数合并；需要补全、嵌套帮助等功能时再改用cobra
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// EnvServer 后端接口地址的环境变量，未指定-server时使用
+const EnvServer = "K8S_INSTALLER_SERVER"
+
+// EnvProject 命令操作的项目，未设置时为默认项目
+const EnvProject = "K8S_INSTALLER_PROJECT"
+
+// DefaultServer 默认的后端接口地址
+const DefaultServer = "http://localhost:8080"
+
+// 退出码
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// errUsage 参数错误，已输出用法说明
+var errUsage = errors.New("invalid usage")
+
+// command 子命令，name为空格分隔的命令路径，如"nodes add"
+type command struct {
+	name    string
+	summary string
+	run     func(env *env, args []string) error
+}
+
+var commands = []command{
+	{"nodes list", "列出节点", runNodesList},
+	{"nodes add", "添加节点", runNodesAdd},
+	{"deploy", "部署Kubernetes集群，等待部署完成", runDeploy},
+	{"reset", "重置worker节点或拆除集群", runReset},
+	{"logs tail", "实时输出部署和操作日志", runLogsTail},
+	{"service install", "安装并启动后端的systemd服务", runServiceInstall},
+	{"service uninstall", "停止并删除后端的systemd服务", runServiceUninstall},
+}
+
+// env 命令的输出
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run 执行子命令并返回退出码
+func Run(args []string) int {
+	e := &env{stdout: os.Stdout, stderr: os.Stderr}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		e.usage(e.stdout)
+		return exitOK
+	}
+
+	// 按最长的命令路径匹配子命令
+	var matched *command
+	for i := range commands {
+		path := strings.Fields(commands[i].name)
+		if len(args) >= len(path) && strings.Join(args[:len(path)], " ") == commands[i].name {
+			if matched == nil || len(path) > len(strings.Fields(matched.name)) {
+				matched = &commands[i]
+			}
+		}
+	}
+	if matched == nil {
+		fmt.Fprintf(e.stderr, "未知命令: %s\n\n", strings.Join(args, " "))
+		e.usage(e.stderr)
+		return exitUsage
+	}
+
+	err := matched.run(e, args[len(strings.Fields(matched.name)):])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	default:
+		fmt.Fprintf(e.stderr, "错误: %v\n", err)
+		return exitError
+	}
+}
+
+// usage 输出所有子命令的说明
+func (e *env) usage(w io.Writer) {
+	fmt.Fprintln(w, "用法: k8s-installer [serve]            启动后端服务和Web界面")
+	fmt.Fprintln(w, "      k8s-installer <命令> [参数]       命令行模式")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-17s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "命令默认访问%s的后端接口，可通过-server或%s指定；nodes命令使用-local时直接读写本地数据库。\n", DefaultServer, EnvServer)
+	fmt.Fprintf(w, "命令操作默认项目的节点和集群，可通过%s指定其他项目。\n", EnvProject)
+	fmt.Fprintln(w, "使用 k8s-installer <命令> -h 查看命令参数。")
+}
+
+// newFlagSet 创建子命令的参数解析器，解析失败时输出用法说明
+func (e *env) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("k8s-installer "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// serverFlag 添加-server参数，默认使用环境变量中的地址
+func serverFlag(fs *flag.FlagSet) *string {
+	server := os.Getenv(EnvServer)
+	if server == "" {
+		server = DefaultServer
+	}
+	return fs.String("server", server, "后端接口地址，也可以通过"+EnvServer+"指定")
+}
+
+// parse 解析参数，不允许多余的位置参数
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "多余的参数: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
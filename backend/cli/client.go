@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"k8s-installer/api"
+	"k8s-installer/diagnose"
+)
+
+// defaultTimeout 普通接口请求的超时时间，部署和日志流不设超时
+const defaultTimeout = 60 * time.Second
+
+// client 后端接口客户端，路径相对于/api/v1
+type client struct {
+	baseURL string
+	http    *http.Client
+	// project 请求所属的项目，为空时后端使用默认项目
+	project string
+}
+
+// newClient 创建接口客户端，timeout为0时不设超时
+func newClient(server string, timeout time.Duration) *client {
+	return &client{
+		baseURL: strings.TrimSuffix(server, "/") + api.V1Prefix,
+		http:    &http.Client{Timeout: timeout},
+		project: os.Getenv(EnvProject),
+	}
+}
+
+// newRequest 创建请求，设置请求所属的项目
+func (c *client) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.project != "" {
+		req.Header.Set(api.ProjectHeader, c.project)
+	}
+	return req, nil
+}
+
+// apiError 接口返回的错误
+type apiError struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Err     string `json:"error"`
+	// Diagnoses DeploymentID 部署失败时返回的故障诊断和部署记录ID
+	Diagnoses    []diagnose.Diagnosis `json:"diagnoses"`
+	DeploymentID string               `json:"deploymentId"`
+}
+
+func (e *apiError) Error() string {
+	msg := e.Err
+	if msg == "" {
+		msg = e.Message
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("%s (HTTP %d, %s)", msg, e.Status, e.Code)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", msg, e.Status)
+}
+
+// do 发送JSON请求，in为json.RawMessage时原样发送，out不为nil时解析响应；非2xx响应返回*apiError
+func (c *client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		if raw, ok := in.(json.RawMessage); ok {
+			body = bytes.NewReader(raw)
+		} else {
+			data, err := json.Marshal(in)
+			if err != nil {
+				return err
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept-Language", "zh-CN")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach backend: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apiError{Status: resp.StatusCode}
+		if json.Unmarshal(data, apiErr) != nil || (apiErr.Err == "" && apiErr.Message == "") {
+			apiErr.Err = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+	if out != nil && len(data) > 0 {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
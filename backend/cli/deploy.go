@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// deployRequest 通过参数生成的部署请求，与POST /k8s/deploy的请求字段一致，完整配置使用-f指定JSON文件
+type deployRequest struct {
+	KubeVersion   string   `json:"kubeVersion"`
+	Arch          string   `json:"arch"`
+	Distro        string   `json:"distro"`
+	InstallerType string   `json:"installerType,omitempty"`
+	NodeIds       []string `json:"nodeIds,omitempty"`
+	GroupIds      []string `json:"groupIds,omitempty"`
+	SkipSteps     []string `json:"skipSteps,omitempty"`
+	Resume        bool     `json:"resume,omitempty"`
+	DeploymentID  string   `json:"deploymentId,omitempty"`
+}
+
+// deployResponse 部署成功的响应
+type deployResponse struct {
+	Result       string   `json:"result"`
+	Message      string   `json:"message"`
+	Nodes        []string `json:"nodes"`
+	Version      string   `json:"version"`
+	DeploymentID string   `json:"deploymentId"`
+	Resumed      bool     `json:"resumed"`
+}
+
+// runDeploy 部署集群，请求在后端执行，命令等待部署结束；部署日志可以同时用logs tail查看
+func runDeploy(e *env, args []string) error {
+	fs := e.newFlagSet("deploy")
+	server := serverFlag(fs)
+	file := fs.String("f", "", "部署请求的JSON文件，与界面和接口的请求格式相同，指定时忽略其他部署参数")
+	var req deployRequest
+	fs.StringVar(&req.KubeVersion, "version", "", "Kubernetes版本，如1.30.2")
+	fs.StringVar(&req.Arch, "arch", "amd64", "CPU架构")
+	fs.StringVar(&req.Distro, "distro", "", "节点发行版，如ubuntu、centos")
+	fs.StringVar(&req.InstallerType, "installer", "", "安装方式：kubeadm（默认）或k3s")
+	nodes := fs.String("nodes", "", "逗号分隔的节点ID")
+	groups := fs.String("groups", "", "逗号分隔的节点组ID，组内节点加入部署")
+	skip := fs.String("skip", "", "逗号分隔的跳过步骤")
+	fs.BoolVar(&req.Resume, "resume", false, "从失败的部署记录继续，跳过已完成的步骤")
+	fs.StringVar(&req.DeploymentID, "deployment-id", "", "继续部署时使用的部署记录ID")
+	output := fs.String("o", "text", "输出格式：text或json")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var body interface{}
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("%s is not valid JSON", *file)
+		}
+		body = json.RawMessage(data)
+	} else {
+		req.NodeIds, req.GroupIds, req.SkipSteps = splitList(*nodes), splitList(*groups), splitList(*skip)
+		if req.KubeVersion == "" || req.Distro == "" || (len(req.NodeIds) == 0 && len(req.GroupIds) == 0) {
+			fmt.Fprintln(fs.Output(), "需要指定-version、-distro以及-nodes或-groups，或使用-f指定部署请求文件")
+			fs.Usage()
+			return errUsage
+		}
+		body = req
+	}
+
+	fmt.Fprintln(e.stderr, "正在部署，等待后端完成……")
+	var resp deployResponse
+	err := newClient(*server, 0).do(http.MethodPost, "/k8s/deploy", body, &resp)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.DeploymentID != "" {
+		fmt.Fprintf(e.stderr, "部署记录ID: %s\n", apiErr.DeploymentID)
+		for _, d := range apiErr.Diagnoses {
+			fmt.Fprintf(e.stderr, "可能的原因: %s\n  处理建议: %s\n", d.Title, d.Remediation)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp)
+	}
+	fmt.Fprintln(e.stdout, resp.Result)
+	fmt.Fprintf(e.stdout, "%s，版本: %s，部署记录ID: %s\n", resp.Message, resp.Version, resp.DeploymentID)
+	return nil
+}
+
+// runReset 重置worker节点，或按master节点拆除整个集群
+func runReset(e *env, args []string) error {
+	fs := e.newFlagSet("reset")
+	server := serverFlag(fs)
+	nodeID := fs.String("node", "", "重置单个worker节点")
+	clusterID := fs.String("cluster", "", "拆除集群，值为master节点ID，按先worker后控制平面的顺序重置所有成员节点")
+	keepContainerd := fs.Bool("keep-containerd", false, "重置worker节点时保留containerd及镜像缓存")
+	keepPackages := fs.Bool("keep-packages", false, "重置worker节点时保留kubeadm、kubelet、kubectl软件包")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if (*nodeID == "") == (*clusterID == "") {
+		fmt.Fprintln(fs.Output(), "需要指定-node或-cluster中的一个")
+		fs.Usage()
+		return errUsage
+	}
+
+	c := newClient(*server, 0)
+	var resp map[string]interface{}
+	var err error
+	if *nodeID != "" {
+		err = c.do(http.MethodPost, "/nodes/"+*nodeID+"/reset", map[string]bool{"keepContainerd": *keepContainerd, "keepPackages": *keepPackages}, &resp)
+	} else {
+		err = c.do(http.MethodPost, "/clusters/"+*clusterID+"/teardown", struct{}{}, &resp)
+	}
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(resp)
+}
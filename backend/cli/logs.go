@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+
+	"k8s-installer/log"
+)
+
+// runLogsTail 订阅后端的实时日志流并逐条输出，Ctrl+C退出
+func runLogsTail(e *env, args []string) error {
+	fs := e.newFlagSet("logs tail")
+	server := serverFlag(fs)
+	query := url.Values{}
+	filters := map[string]*string{
+		"nodeId":    fs.String("node", "", "按节点ID过滤"),
+		"jobId":     fs.String("job", "", "按任务ID过滤，如部署记录ID"),
+		"operation": fs.String("operation", "", "按操作类型过滤"),
+		"minLevel":  fs.String("level", "", "最低日志级别：debug、info、warn或error"),
+		"type":      fs.String("type", "", "按日志类型过滤：script-output、step或system"),
+	}
+	asJSON := fs.Bool("json", false, "每行输出一条JSON格式的日志")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	for name, value := range filters {
+		if *value != "" {
+			query.Set(name, *value)
+		}
+	}
+
+	c := newClient(*server, 0)
+	req, err := c.newRequest(http.MethodGet, "/logs/stream?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(req.Context(), os.Interrupt)
+	defer stop()
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to reach backend: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &apiError{Status: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var entry log.LogEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.Type == "heartbeat" || entry.Operation == "Heartbeat" {
+			continue
+		}
+		if *asJSON {
+			fmt.Fprintln(e.stdout, data)
+			continue
+		}
+		fmt.Fprintf(e.stdout, "%s [%s] %s %s: %s\n", entry.CreatedAt.Format("15:04:05"), entry.Level, entry.NodeName, entry.Operation, strings.TrimRight(entry.Output, "\n"))
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("log stream closed by backend")
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"k8s-installer/config"
+	"k8s-installer/node"
+)
+
+// nodeStore 节点的读写方式：通过后端接口，或直接访问本地数据库
+type nodeStore interface {
+	ListNodes() ([]node.View, error)
+	CreateNode(n node.Node) (node.View, error)
+	Close() error
+}
+
+// apiNodeStore 通过后端接口读写节点
+type apiNodeStore struct {
+	client *client
+}
+
+func (s apiNodeStore) ListNodes() ([]node.View, error) {
+	var nodes []node.View
+	err := s.client.do(http.MethodGet, "/nodes", nil, &nodes)
+	return nodes, err
+}
+
+func (s apiNodeStore) CreateNode(n node.Node) (node.View, error) {
+	var created node.View
+	err := s.client.do(http.MethodPost, "/nodes", n, &created)
+	return created, err
+}
+
+func (s apiNodeStore) Close() error { return nil }
+
+// localNodeStore 直接读写配置的数据目录中的数据库，与后端服务使用相同的节点管理器，只读写指定项目的节点
+type localNodeStore struct {
+	manager *node.SqliteNodeManager
+	project string
+}
+
+func (s localNodeStore) ListNodes() ([]node.View, error) {
+	nodes, err := s.manager.InProject(s.project).GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	views := make([]node.View, 0, len(nodes))
+	for _, n := range nodes {
+		views = append(views, n.View())
+	}
+	return views, nil
+}
+
+func (s localNodeStore) CreateNode(n node.Node) (node.View, error) {
+	n.ProjectID = s.project
+	created, err := s.manager.CreateNode(n)
+	if err != nil {
+		return node.View{}, err
+	}
+	return created.View(), nil
+}
+
+func (s localNodeStore) Close() error { return s.manager.CloseLogs() }
+
+// openNodeStore 按-local参数选择节点的读写方式
+func openNodeStore(server string, local bool) (nodeStore, error) {
+	if !local {
+		return apiNodeStore{client: newClient(server, defaultTimeout)}, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	manager, err := node.NewSqliteNodeManager(cfg.DatabasePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %v", cfg.DatabasePath(), err)
+	}
+	project := os.Getenv(EnvProject)
+	if project == "" {
+		project = node.DefaultProjectID
+	}
+	projects, err := node.NewProjectManager(manager.GetDB().(*sql.DB))
+	if err == nil {
+		_, err = projects.GetProject(project)
+	}
+	if err != nil {
+		manager.CloseLogs()
+		return nil, err
+	}
+	return localNodeStore{manager: manager, project: project}, nil
+}
+
+// runNodesList 列出节点
+func runNodesList(e *env, args []string) error {
+	fs := e.newFlagSet("nodes list")
+	server := serverFlag(fs)
+	local := fs.Bool("local", false, "直接读取本地数据库，不经过后端接口")
+	output := fs.String("o", "table", "输出格式：table或json")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	store, err := openNodeStore(*server, *local)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	nodes, err := store.ListNodes()
+	if err != nil {
+		return err
+	}
+
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nodes)
+	case "table":
+		w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tIP\tTYPE\tSTATUS\tOS")
+		for _, n := range nodes {
+			fmt.Fprintf(w, "%s\t%s\t%s:%d\t%s\t%s\t%s\n", n.ID, n.Name, n.IP, n.Port, n.NodeType, n.Status, n.OS)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", *output)
+	}
+}
+
+// runNodesAdd 添加节点
+func runNodesAdd(e *env, args []string) error {
+	fs := e.newFlagSet("nodes add")
+	server := serverFlag(fs)
+	local := fs.Bool("local", false, "直接写入本地数据库，不经过后端接口")
+	var n node.Node
+	fs.StringVar(&n.Name, "name", "", "节点名称（必填）")
+	fs.StringVar(&n.IP, "ip", "", "节点IP地址（必填）")
+	fs.IntVar(&n.Port, "port", 22, "SSH端口")
+	fs.StringVar(&n.Username, "user", "root", "SSH用户名")
+	fs.StringVar(&n.NodeType, "type", node.NodeTypeWorker, "节点类型：master或worker")
+	fs.StringVar(&n.PasswordRef, "password-ref", "", "SSH密码引用，如env:K8S_INSTALLER_SECRET_NODE1或file:/path")
+	fs.StringVar(&n.PrivateKeyRef, "key-ref", "", "SSH私钥引用，如file:/root/.ssh/id_rsa")
+	password := fs.String("password", "", "SSH密码，会出现在进程列表中，建议使用-password-ref")
+	keyFile := fs.String("key-file", "", "读取SSH私钥文件，私钥内容保存到数据库")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	n.Password = *password
+	if *keyFile != "" {
+		key, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read private key: %v", err)
+		}
+		n.PrivateKey = string(key)
+	}
+	if err := n.Validate(); err != nil {
+		return err
+	}
+
+	store, err := openNodeStore(*server, *local)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	created, err := store.CreateNode(n)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "已添加节点 %s (%s)，ID: %s\n", created.Name, created.IP, created.ID)
+	return nil
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"k8s-installer/config"
+)
+
+// 默认的systemd服务配置
+const (
+	defaultServiceName    = "k8s-installer"
+	defaultServiceDataDir = "/var/lib/k8s-installer"
+	systemdUnitDir        = "/etc/systemd/system"
+)
+
+// serviceUnit 生成systemd单元文件的参数
+type serviceUnit struct {
+	Binary  string
+	User    string
+	DataDir string
+	Config  string
+}
+
+// serviceUnitTemplate 后端服务的systemd单元，工作目录为数据目录，程序可以安装在任意位置
+var serviceUnitTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description=Kubernetes cluster installer backend
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+User={{.User}}
+WorkingDirectory={{.DataDir}}
+Environment={{.DataDirEnv}}={{.DataDir}}
+{{- if .Config}}
+Environment={{.ConfigEnv}}={{.Config}}
+{{- end}}
+ExecStart={{.Binary}} serve
+Restart=on-failure
+RestartSec=5
+TimeoutStopSec=30
+LimitNOFILE=65536
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// render 生成单元文件内容
+func (u serviceUnit) render() ([]byte, error) {
+	var buf bytes.Buffer
+	err := serviceUnitTemplate.Execute(&buf, struct {
+		serviceUnit
+		DataDirEnv string
+		ConfigEnv  string
+	}{u, config.EnvDataDir, config.FileEnv})
+	return buf.Bytes(), err
+}
+
+// unitPath 服务名称对应的单元文件路径
+func unitPath(name string) string {
+	return filepath.Join(systemdUnitDir, name+".service")
+}
+
+// systemctl 执行systemctl命令，输出写到命令的标准错误
+func (e *env) systemctl(args ...string) error {
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = e.stderr
+	cmd.Stderr = e.stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("systemctl %v failed: %v", args, err)
+	}
+	return nil
+}
+
+// runServiceInstall 生成后端服务的systemd单元并启用，数据目录不存在时创建并交给运行用户
+func runServiceInstall(e *env, args []string) error {
+	fs := e.newFlagSet("service install")
+	name := fs.String("name", defaultServiceName, "systemd服务名称")
+	var unit serviceUnit
+	fs.StringVar(&unit.Binary, "binary", "", "后端程序路径，默认为当前程序")
+	fs.StringVar(&unit.User, "user", "root", "运行服务的用户，通过SSH部署节点不需要root权限")
+	fs.StringVar(&unit.DataDir, "data-dir", defaultServiceDataDir, "数据目录，保存数据库，同时作为服务的工作目录")
+	fs.StringVar(&unit.Config, "config", "", "配置文件路径，默认读取数据目录中的config.json")
+	dryRun := fs.Bool("dry-run", false, "只输出单元文件内容，不安装")
+	noStart := fs.Bool("no-start", false, "只安装并启用服务，不立即启动")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if unit.Binary == "" {
+		binary, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the installer binary, use -binary: %v", err)
+		}
+		unit.Binary = binary
+	}
+	for _, path := range []*string{&unit.Binary, &unit.DataDir, &unit.Config} {
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return err
+		}
+		*path = abs
+	}
+	content, err := unit.render()
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		_, err := e.stdout.Write(content)
+		return err
+	}
+
+	account, err := user.Lookup(unit.User)
+	if err != nil {
+		return fmt.Errorf("unknown user %s: %v", unit.User, err)
+	}
+	if err := os.MkdirAll(unit.DataDir, 0750); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+	uid, _ := strconv.Atoi(account.Uid)
+	gid, _ := strconv.Atoi(account.Gid)
+	if err := os.Chown(unit.DataDir, uid, gid); err != nil {
+		return fmt.Errorf("failed to change owner of data directory: %v", err)
+	}
+
+	path := unitPath(*name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write unit file: %v", err)
+	}
+	fmt.Fprintf(e.stdout, "已写入 %s\n", path)
+
+	enable := []string{"enable", *name}
+	if !*noStart {
+		enable = []string{"enable", "--now", *name}
+	}
+	if err := e.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := e.systemctl(enable...); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "已启用服务 %s，数据目录: %s\n", *name, unit.DataDir)
+	return nil
+}
+
+// runServiceUninstall 停止并禁用服务，删除单元文件，保留数据目录
+func runServiceUninstall(e *env, args []string) error {
+	fs := e.newFlagSet("service uninstall")
+	name := fs.String("name", defaultServiceName, "systemd服务名称")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	path := unitPath(*name)
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("service %s is not installed: %s not found", *name, path)
+		}
+		return err
+	}
+	if err := e.systemctl("disable", "--now", *name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit file: %v", err)
+	}
+	if err := e.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "已卸载服务 %s，数据目录未删除\n", *name)
+	return nil
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s-installer/job"
+	"k8s-installer/vault"
+)
+
+// 配置文件路径，可通过环境变量指定
+const (
+	FileEnv     = "K8S_INSTALLER_CONFIG"
+	DefaultFile = "config.json"
+)
+
+// 覆盖配置文件的环境变量，列表使用逗号分隔
+const (
+	EnvCORSAllowedOrigins   = "K8S_INSTALLER_CORS_ALLOWED_ORIGINS"
+	EnvCORSAllowedHeaders   = "K8S_INSTALLER_CORS_ALLOWED_HEADERS"
+	EnvCORSAllowCredentials = "K8S_INSTALLER_CORS_ALLOW_CREDENTIALS"
+	EnvMaxConcurrentJobs    = "K8S_INSTALLER_MAX_CONCURRENT_JOBS"
+	EnvVaultAddress         = "K8S_INSTALLER_VAULT_ADDR"
+	EnvVaultToken           = "K8S_INSTALLER_VAULT_TOKEN"
+	EnvVaultSecretID        = "K8S_INSTALLER_VAULT_SECRET_ID"
+	EnvDataDir              = "K8S_INSTALLER_DATA_DIR"
+	EnvIdempotencyWindow    = "K8S_INSTALLER_IDEMPOTENCY_WINDOW_HOURS"
+	EnvRequestsPerMinute    = "K8S_INSTALLER_REQUESTS_PER_MINUTE"
+	EnvMaxBodyBytes         = "K8S_INSTALLER_MAX_BODY_BYTES"
+)
+
+// DatabaseFile 数据目录中SQLite数据库的文件名
+const DatabaseFile = "k8s_installer.db"
+
+// SecretKeyFile 数据目录中加密敏感数据的密钥文件名，未通过环境变量提供密钥时使用
+const SecretKeyFile = "secret.key"
+
+// DefaultCORSAllowedHeaders 默认允许的跨域请求头
+var DefaultCORSAllowedHeaders = []string{"Content-Type", "Authorization", "Idempotency-Key", "X-Project-ID"}
+
+// DefaultIdempotencyWindowHours 默认保存幂等键响应的小时数
+const DefaultIdempotencyWindowHours = 24
+
+// 请求限制的默认值
+const (
+	DefaultRequestsPerMinute = 600
+	DefaultRequestBurst      = 100
+	// DefaultMaxBodyBytes 默认的请求体大小上限，足够容纳脚本和私钥
+	DefaultMaxBodyBytes = 10 << 20
+)
+
+// Config 后端配置
+type Config struct {
+	// DataDir 数据目录，保存SQLite数据库，为空时使用当前工作目录
+	DataDir string     `json:"dataDir"`
+	CORS    CORSConfig `json:"cors"`
+	Jobs    JobsConfig `json:"jobs"`
+	// Idempotency 带Idempotency-Key的POST请求的响应保存配置
+	Idempotency IdempotencyConfig `json:"idempotency"`
+	Limits      LimitsConfig      `json:"limits"`
+	// Vault 节点凭据引用vault:path#field使用的Vault配置，令牌和SecretID建议通过环境变量提供
+	Vault vault.Config `json:"vault"`
+}
+
+// CORSConfig 跨域访问配置，AllowedOrigins为空时只允许同源访问
+type CORSConfig struct {
+	// AllowedOrigins 允许跨域访问的来源，如 http://localhost:5173，"*"表示允许所有来源
+	AllowedOrigins []string `json:"allowedOrigins"`
+	// AllowedHeaders 允许的请求头
+	AllowedHeaders []string `json:"allowedHeaders"`
+	// AllowCredentials 是否允许跨域请求携带Cookie和认证信息
+	AllowCredentials bool `json:"allowCredentials"`
+}
+
+// JobsConfig 部署任务队列配置
+type JobsConfig struct {
+	// MaxConcurrent 同时运行的最大部署任务数，超出的任务排队等待
+	MaxConcurrent int `json:"maxConcurrent"`
+}
+
+// IdempotencyConfig 幂等键配置
+type IdempotencyConfig struct {
+	// WindowHours 保存首次请求响应的小时数，期间相同幂等键的请求返回该响应
+	WindowHours int `json:"windowHours"`
+}
+
+// LimitsConfig 请求频率和请求体大小限制，保护后端不被意外的大量请求压垮
+type LimitsConfig struct {
+	// RequestsPerMinute 每个客户端IP每分钟允许的请求数，为0时不限制
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	// Burst 每个客户端IP允许的突发请求数
+	Burst int `json:"burst"`
+	// MaxBodyBytes 请求体的最大字节数，为0时不限制
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+}
+
+// Default 默认配置
+func Default() *Config {
+	return &Config{
+		CORS: CORSConfig{
+			AllowedHeaders: append([]string(nil), DefaultCORSAllowedHeaders...),
+		},
+		Jobs:        JobsConfig{MaxConcurrent: job.DefaultMaxConcurrent},
+		Idempotency: IdempotencyConfig{WindowHours: DefaultIdempotencyWindowHours},
+		Limits: LimitsConfig{
+			RequestsPerMinute: DefaultRequestsPerMinute,
+			Burst:             DefaultRequestBurst,
+			MaxBodyBytes:      DefaultMaxBodyBytes,
+		},
+	}
+}
+
+// Load 加载配置：先读取配置文件（不存在时使用默认配置），再应用环境变量覆盖
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path := os.Getenv(FileEnv)
+	explicit := path != ""
+	if !explicit {
+		path = DefaultFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+
+	if v, ok := os.LookupEnv(EnvCORSAllowedOrigins); ok {
+		cfg.CORS.AllowedOrigins = splitList(v)
+	}
+	if v, ok := os.LookupEnv(EnvCORSAllowedHeaders); ok {
+		cfg.CORS.AllowedHeaders = splitList(v)
+	}
+	if v, ok := os.LookupEnv(EnvCORSAllowCredentials); ok {
+		allow, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvCORSAllowCredentials, err)
+		}
+		cfg.CORS.AllowCredentials = allow
+	}
+	if v, ok := os.LookupEnv(EnvMaxConcurrentJobs); ok {
+		maxConcurrent, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvMaxConcurrentJobs, err)
+		}
+		cfg.Jobs.MaxConcurrent = maxConcurrent
+	}
+	if v, ok := os.LookupEnv(EnvIdempotencyWindow); ok {
+		hours, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvIdempotencyWindow, err)
+		}
+		cfg.Idempotency.WindowHours = hours
+	}
+	if v, ok := os.LookupEnv(EnvRequestsPerMinute); ok {
+		perMinute, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvRequestsPerMinute, err)
+		}
+		cfg.Limits.RequestsPerMinute = perMinute
+	}
+	if v, ok := os.LookupEnv(EnvMaxBodyBytes); ok {
+		maxBody, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvMaxBodyBytes, err)
+		}
+		cfg.Limits.MaxBodyBytes = maxBody
+	}
+	if v, ok := os.LookupEnv(EnvDataDir); ok {
+		cfg.DataDir = v
+	}
+	if v, ok := os.LookupEnv(EnvVaultAddress); ok {
+		cfg.Vault.Address = v
+	}
+	if v, ok := os.LookupEnv(EnvVaultToken); ok {
+		cfg.Vault.Token = v
+	}
+	if v, ok := os.LookupEnv(EnvVaultSecretID); ok {
+		cfg.Vault.SecretID = v
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// DatabasePath SQLite数据库文件的路径
+func (c *Config) DatabasePath() string {
+	return filepath.Join(c.DataDir, DatabaseFile)
+}
+
+// SecretKeyPath 加密敏感数据的密钥文件的路径
+func (c *Config) SecretKeyPath() string {
+	return filepath.Join(c.DataDir, SecretKeyFile)
+}
+
+// Validate 检查配置
+func (c *Config) Validate() error {
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				return errors.New("cors: allowCredentials cannot be used with wildcard origin")
+			}
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("cors: invalid origin %q, expected scheme://host[:port]", origin)
+		}
+	}
+	if c.Jobs.MaxConcurrent < 1 {
+		return fmt.Errorf("jobs: maxConcurrent must be at least 1, got %d", c.Jobs.MaxConcurrent)
+	}
+	if c.Idempotency.WindowHours < 1 {
+		return fmt.Errorf("idempotency: windowHours must be at least 1, got %d", c.Idempotency.WindowHours)
+	}
+	if c.Limits.RequestsPerMinute < 0 {
+		return fmt.Errorf("limits: requestsPerMinute cannot be negative, got %d", c.Limits.RequestsPerMinute)
+	}
+	if c.Limits.RequestsPerMinute > 0 && c.Limits.Burst < 1 {
+		return fmt.Errorf("limits: burst must be at least 1 when rate limiting is enabled, got %d", c.Limits.Burst)
+	}
+	if c.Limits.MaxBodyBytes < 0 {
+		return fmt.Errorf("limits: maxBodyBytes cannot be negative, got %d", c.Limits.MaxBodyBytes)
+	}
+	return c.Vault.Validate()
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
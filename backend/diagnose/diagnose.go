@@ -0,0 +1,171 @@
+// Package diagnose 根据步骤输出和错误文本识别常见的部署故障，给出中英文的处理建议
+package diagnose
+
+import (
+	"regexp"
+	"strings"
+
+	"k8s-installer/errcode"
+)
+
+// Diagnosis 识别出的故障及处理建议
+type Diagnosis struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Remediation string `json:"remediation"`
+}
+
+// rule 故障识别规则，text的第一项为中文、第二项为英文
+type rule struct {
+	id          string
+	pattern     *regexp.Regexp
+	title       [2]string
+	remediation [2]string
+}
+
+// rules 故障识别规则，按顺序输出匹配结果
+var rules = []rule{
+	{
+		id:          "kubelet-unreachable",
+		pattern:     regexp.MustCompile(`(?i):10250\b.*connection refused|connection refused.*:10250\b`),
+		title:       [2]string{"无法连接kubelet（10250端口）", "kubelet is unreachable on port 10250"},
+		remediation: [2]string{"在节点上执行systemctl status kubelet和journalctl -u kubelet检查kubelet是否运行，并确认防火墙放行10250端口", "run systemctl status kubelet and journalctl -u kubelet on the node to check that kubelet is running, and allow port 10250 in the firewall"},
+	},
+	{
+		id:          "apiserver-unreachable",
+		pattern:     regexp.MustCompile(`(?i):6443\b.*connection refused|connection to the server .* was refused`),
+		title:       [2]string{"无法连接kube-apiserver", "kube-apiserver is unreachable"},
+		remediation: [2]string{"在master节点上执行crictl ps -a检查kube-apiserver容器是否反复重启，查看其日志，并确认6443端口未被防火墙拦截、控制平面地址正确", "run crictl ps -a on the master to check whether the kube-apiserver container keeps restarting, inspect its logs, and make sure port 6443 is open and the control plane endpoint is correct"},
+	},
+	{
+		id:          "cgroup-driver-mismatch",
+		pattern:     regexp.MustCompile(`(?i)cgroup driver.*(mismatch|is different|does not match)|misconfiguration: kubelet cgroup driver`),
+		title:       [2]string{"kubelet与容器运行时的cgroup驱动不一致", "kubelet and container runtime cgroup drivers do not match"},
+		remediation: [2]string{"调用POST /api/v1/kubeadm/preflight/cgroup检查节点，将containerd的SystemdCgroup与kubelet的cgroupDriver设置为一致（systemd节点均使用systemd），重启containerd和kubelet", "check the node with POST /api/v1/kubeadm/preflight/cgroup, set containerd SystemdCgroup and kubelet cgroupDriver to the same driver (systemd on systemd nodes), then restart containerd and kubelet"},
+	},
+	{
+		id:          "image-pull",
+		pattern:     regexp.MustCompile(`(?i)imagepullbackoff|errimagepull|failed to pull image|pull access denied|manifest unknown`),
+		title:       [2]string{"镜像拉取失败", "image pull failed"},
+		remediation: [2]string{"检查imageRepository是否可访问且包含所需版本的镜像，确认节点的DNS、代理和containerd镜像加速配置，必要时先执行镜像预拉取", "make sure the imageRepository is reachable and hosts images for this version, check DNS, proxy and containerd mirror settings on the node, and pre-pull images if needed"},
+	},
+	{
+		id:          "token-expired",
+		pattern:     regexp.MustCompile(`(?i)could not find a jws signature|token id "?[a-z0-9]+"? is invalid|token.*(has expired|expired)|unable to fetch the kubeadm-config configmap.*unauthorized`),
+		title:       [2]string{"bootstrap令牌无效或已过期", "bootstrap token is invalid or expired"},
+		remediation: [2]string{"调用POST /api/v1/clusters/:id/tokens创建新的令牌和join命令后重新加入节点", "create a new token and join command with POST /api/v1/clusters/:id/tokens and join the node again"},
+	},
+	{
+		id:          "discovery-ca-hash",
+		pattern:     regexp.MustCompile(`(?i)cluster ca found in cluster-info configmap is invalid|public key .* not pinned`),
+		title:       [2]string{"CA证书哈希不匹配", "CA certificate hash does not match"},
+		remediation: [2]string{"join命令中的--discovery-token-ca-cert-hash与当前集群不一致，请重新获取join命令", "--discovery-token-ca-cert-hash in the join command does not match the cluster, fetch the join command again"},
+	},
+	{
+		id:          "port-in-use",
+		pattern:     regexp.MustCompile(`(?i)\[ERROR Port-\d+\]|address already in use`),
+		title:       [2]string{"端口已被占用", "port is already in use"},
+		remediation: [2]string{"节点上残留了之前的Kubernetes组件，执行kubeadm reset -f清理后重试，或停止占用端口的进程", "leftover Kubernetes components are running on the node, clean up with kubeadm reset -f and retry, or stop the process using the port"},
+	},
+	{
+		id:          "existing-manifests",
+		pattern:     regexp.MustCompile(`(?i)\[ERROR (FileAvailable|DirAvailable)-`),
+		title:       [2]string{"节点上存在之前部署的文件", "files from a previous deployment exist on the node"},
+		remediation: [2]string{"执行kubeadm reset -f并删除/etc/kubernetes/manifests和/var/lib/etcd后重试", "run kubeadm reset -f and remove /etc/kubernetes/manifests and /var/lib/etcd, then retry"},
+	},
+	{
+		id:          "swap-enabled",
+		pattern:     regexp.MustCompile(`(?i)\[ERROR Swap\]|running with swap on is not supported`),
+		title:       [2]string{"节点启用了swap", "swap is enabled on the node"},
+		remediation: [2]string{"执行swapoff -a并注释/etc/fstab中的swap行，或在部署参数中启用swap（Kubernetes 1.28+且节点使用cgroup v2）", "run swapoff -a and comment out swap in /etc/fstab, or enable swap in the deployment options (Kubernetes 1.28+ on cgroup v2)"},
+	},
+	{
+		id:          "ip-forward",
+		pattern:     regexp.MustCompile(`(?i)\[ERROR FileContent--proc-sys-net-(ipv4-ip_forward|bridge-bridge-nf-call-iptables)\]`),
+		title:       [2]string{"IP转发或bridge-nf-call-iptables未开启", "IP forwarding or bridge-nf-call-iptables is disabled"},
+		remediation: [2]string{"执行modprobe br_netfilter，并通过sysctl设置net.ipv4.ip_forward=1和net.bridge.bridge-nf-call-iptables=1", "run modprobe br_netfilter and set net.ipv4.ip_forward=1 and net.bridge.bridge-nf-call-iptables=1 with sysctl"},
+	},
+	{
+		id:          "runtime-not-running",
+		pattern:     regexp.MustCompile(`(?i)container runtime is not running|containerd\.sock: connect: (no such file|connection refused)|failed to connect to containerd`),
+		title:       [2]string{"容器运行时未运行", "container runtime is not running"},
+		remediation: [2]string{"执行systemctl restart containerd并用journalctl -u containerd检查启动错误，确认/etc/containerd/config.toml有效", "run systemctl restart containerd, check journalctl -u containerd for startup errors and verify /etc/containerd/config.toml"},
+	},
+	{
+		id:          "kubelet-not-healthy",
+		pattern:     regexp.MustCompile(`(?i)the kubelet is not running|kubelet is unhealthy|wait-control-plane.*timed out|timed out waiting for the condition`),
+		title:       [2]string{"kubelet未正常运行或控制平面启动超时", "kubelet is unhealthy or the control plane did not start in time"},
+		remediation: [2]string{"在节点上执行journalctl -xeu kubelet查看kubelet错误，常见原因为cgroup驱动不一致、镜像拉取失败或swap未关闭", "run journalctl -xeu kubelet on the node, common causes are a cgroup driver mismatch, image pull failures or swap being enabled"},
+	},
+	{
+		id:          "certificate-time",
+		pattern:     regexp.MustCompile(`(?i)x509: certificate has expired or is not yet valid`),
+		title:       [2]string{"证书不在有效期内", "certificate is not within its validity period"},
+		remediation: [2]string{"检查节点时间是否同步（chronyc tracking或timedatectl），时间正确时用kubeadm certs renew续期证书", "check that the node clock is synchronized (chronyc tracking or timedatectl), renew certificates with kubeadm certs renew if the clock is correct"},
+	},
+	{
+		id:          "dns-resolution",
+		pattern:     regexp.MustCompile(`(?i)could not resolve host|temporary failure (in name resolution|resolving)|no such host`),
+		title:       [2]string{"域名解析失败", "DNS resolution failed"},
+		remediation: [2]string{"调用POST /api/v1/kubeadm/preflight/dns检查节点DNS配置，确认/etc/resolv.conf中的DNS服务器可用或配置代理", "check node DNS with POST /api/v1/kubeadm/preflight/dns and make sure the nameservers in /etc/resolv.conf work, or configure a proxy"},
+	},
+	{
+		id:          "disk-full",
+		pattern:     regexp.MustCompile(`(?i)no space left on device|disk ?pressure`),
+		title:       [2]string{"磁盘空间不足", "disk space is exhausted"},
+		remediation: [2]string{"清理/var/lib/containerd、/var/log等目录或扩容磁盘，可执行crictl rmi --prune删除未使用的镜像", "free space in /var/lib/containerd and /var/log or grow the disk, crictl rmi --prune removes unused images"},
+	},
+	{
+		id:          "insufficient-resources",
+		pattern:     regexp.MustCompile(`(?i)\[ERROR (NumCPU|Mem)\]`),
+		title:       [2]string{"节点CPU或内存不足", "node does not have enough CPU or memory"},
+		remediation: [2]string{"kubeadm要求control-plane节点至少2核CPU和1700MB内存，请扩容节点", "kubeadm requires at least 2 CPUs and 1700MB of memory on control plane nodes, resize the node"},
+	},
+}
+
+// Match 返回文本匹配的故障规则ID，按规则顺序排列
+func Match(text string) []string {
+	var ids []string
+	for _, r := range rules {
+		if r.pattern.MatchString(text) {
+			ids = append(ids, r.id)
+		}
+	}
+	return ids
+}
+
+// Lookup 返回规则ID在指定语言下的故障说明和处理建议，忽略未知的ID
+func Lookup(ids []string, lang string) []Diagnosis {
+	i := 0
+	if lang == errcode.LangEN {
+		i = 1
+	}
+	var diagnoses []Diagnosis
+	for _, id := range ids {
+		for _, r := range rules {
+			if r.id == id {
+				diagnoses = append(diagnoses, Diagnosis{ID: r.id, Title: r.title[i], Remediation: r.remediation[i]})
+				break
+			}
+		}
+	}
+	return diagnoses
+}
+
+// Diagnose 识别文本中的故障，返回指定语言的处理建议
+func Diagnose(text, lang string) []Diagnosis {
+	return Lookup(Match(text), lang)
+}
+
+// Join 将规则ID保存为逗号分隔的字符串
+func Join(ids []string) string {
+	return strings.Join(ids, ",")
+}
+
+// Split 解析Join保存的规则ID
+func Split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,124 @@
+//go:build e2e
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+)
+
+// 节点镜像中root用户的密码，见Dockerfile
+const nodePassword = "k8s-installer-e2e"
+
+// cluster 作为假节点的一组容器
+type cluster struct {
+	engine  string
+	network string
+	names   []string
+	nodes   []node.Node
+}
+
+// engineCmd 执行容器引擎命令，返回去掉首尾空白的输出
+func engineCmd(ctx context.Context, engine string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, engine, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %v\n%s", engine, strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// buildImage 使用e2e/Dockerfile构建节点镜像
+func buildImage(ctx context.Context, engine, image, dir string) error {
+	_, err := engineCmd(ctx, engine, "build", "-t", image, dir)
+	return err
+}
+
+// startCluster 创建容器网络并启动count个节点容器，第一个容器作为master
+func startCluster(ctx context.Context, engine, image, prefix string, count int) (*cluster, error) {
+	c := &cluster{engine: engine, network: prefix}
+	if _, err := engineCmd(ctx, engine, "network", "create", c.network); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		nodeType := "worker"
+		if i == 0 {
+			nodeType = "master"
+		}
+		// kubelet和containerd需要特权容器、宿主机的cgroup和内核模块
+		if _, err := engineCmd(ctx, engine, "run", "-d", "--name", name, "--hostname", name,
+			"--network", c.network, "--privileged", "--cgroupns=host",
+			"-v", "/sys/fs/cgroup:/sys/fs/cgroup:rw", "-v", "/lib/modules:/lib/modules:ro",
+			"--tmpfs", "/run", "--tmpfs", "/tmp", image); err != nil {
+			return c, err
+		}
+		c.names = append(c.names, name)
+
+		ip, err := engineCmd(ctx, engine, "inspect", "-f", "{{range .NetworkSettings.Networks}}{{.IPAddress}}{{end}}", name)
+		if err != nil {
+			return c, err
+		}
+		c.nodes = append(c.nodes, node.Node{
+			ID:               name,
+			Name:             name,
+			IP:               ip,
+			Port:             22,
+			Username:         "root",
+			Password:         nodePassword,
+			NodeType:         nodeType,
+			Status:           "online",
+			ContainerRuntime: "containerd",
+			OS:               "ubuntu",
+		})
+	}
+	return c, nil
+}
+
+// waitForSSH 等待所有节点的sshd可以登录
+func (c *cluster) waitForSSH(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, n := range c.nodes {
+		for {
+			client, err := node.Connect(n)
+			if err == nil {
+				client.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("node %s (%s) is not reachable over SSH: %v", n.Name, n.IP, err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+	return nil
+}
+
+// logs 返回容器的systemd日志，部署失败时用于排查
+func (c *cluster) logs(ctx context.Context, name string) string {
+	output, err := engineCmd(ctx, c.engine, "exec", name, "journalctl", "--no-pager", "-n", "200", "-u", "kubelet", "-u", "containerd")
+	if err != nil {
+		return err.Error()
+	}
+	return output
+}
+
+// remove 删除节点容器和网络，使用独立的上下文保证取消后仍然清理
+func (c *cluster) remove() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	for _, name := range c.names {
+		engineCmd(ctx, c.engine, "rm", "-f", "-v", name)
+	}
+	engineCmd(ctx, c.engine, "network", "rm", c.network)
+}
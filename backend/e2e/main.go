@@ -0,0 +1,261 @@
+//go:build e2e
+
+// e2e 端到端测试：启动运行systemd和sshd的容器作为假节点，对其执行完整的kubeadm部署流程，
+// 检查脚本生成和步骤顺序是否正确。需要docker或podman，使用e2e构建标签运行：
+//
+//	go run -tags e2e ./e2e -nodes 2 -ci
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/kubeadm"
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+// stepEvent 部署过程中记录的步骤结果
+type stepEvent struct {
+	NodeID string `json:"nodeId"`
+	Step   string `json:"step"`
+	Error  string `json:"error,omitempty"`
+}
+
+// stepRecorder 按执行顺序记录步骤结果的StepTracker，不跳过任何步骤
+type stepRecorder struct {
+	mutex  sync.Mutex
+	events []stepEvent
+}
+
+// IsStepCompleted 端到端测试总是完整执行所有步骤
+func (r *stepRecorder) IsStepCompleted(nodeID, step string) bool {
+	return false
+}
+
+// MarkStep 记录步骤结果
+func (r *stepRecorder) MarkStep(nodeID, step string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	event := stepEvent{NodeID: nodeID, Step: step}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	r.events = append(r.events, event)
+}
+
+// report 端到端测试结果，-report指定文件时以JSON格式写入
+type report struct {
+	Passed   bool               `json:"passed"`
+	Duration string             `json:"duration"`
+	Nodes    []string           `json:"nodes"`
+	Steps    []stepEvent        `json:"steps"`
+	Problems []string           `json:"problems,omitempty"`
+	Lint     []script.LintIssue `json:"lint,omitempty"`
+}
+
+func main() {
+	engine := flag.String("engine", "docker", "容器引擎：docker或podman")
+	image := flag.String("image", "k8s-installer-e2e-node:latest", "节点镜像")
+	build := flag.Bool("build", true, "使用e2e/Dockerfile构建节点镜像")
+	nodeCount := flag.Int("nodes", 2, "节点数量，第一个节点作为master")
+	kubeVersion := flag.String("kube-version", "1.28.2", "Kubernetes版本")
+	skip := flag.String("skip", "", "跳过的步骤，逗号分隔")
+	ci := flag.Bool("ci", false, "CI模式：忽略容器中无法满足的kubeadm预检，失败时输出容器中kubelet和containerd的日志")
+	keep := flag.Bool("keep", false, "结束后保留节点容器，便于排查")
+	timeout := flag.Duration("timeout", 45*time.Minute, "整个测试的超时时间")
+	reportPath := flag.String("report", "", "以JSON格式写入测试结果的文件")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	r := run(ctx, options{
+		engine:      *engine,
+		image:       *image,
+		build:       *build,
+		nodeCount:   *nodeCount,
+		kubeVersion: *kubeVersion,
+		skipSteps:   splitList(*skip),
+		ci:          *ci,
+		keep:        *keep,
+	})
+
+	if *reportPath != "" {
+		data, _ := json.MarshalIndent(r, "", "  ")
+		if err := os.WriteFile(*reportPath, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "写入测试结果失败: %v\n", err)
+		}
+	}
+	for _, problem := range r.Problems {
+		fmt.Fprintf(os.Stderr, "FAIL: %s\n", problem)
+	}
+	if !r.Passed {
+		os.Exit(1)
+	}
+	fmt.Printf("PASS: %d个节点部署成功，耗时%s\n", len(r.Nodes), r.Duration)
+}
+
+// options 端到端测试参数
+type options struct {
+	engine      string
+	image       string
+	build       bool
+	nodeCount   int
+	kubeVersion string
+	skipSteps   []string
+	ci          bool
+	keep        bool
+}
+
+// run 启动节点容器并执行部署，返回测试结果
+func run(ctx context.Context, o options) (r report) {
+	start := time.Now()
+	defer func() {
+		r.Passed = len(r.Problems) == 0
+		r.Duration = time.Since(start).Round(time.Second).String()
+	}()
+	fail := func(format string, args ...interface{}) report {
+		r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+		return r
+	}
+
+	for _, step := range o.skipSteps {
+		if !kubeadm.IsValidStep(step) {
+			return fail("unknown step %q", step)
+		}
+	}
+
+	// 默认脚本存在语法错误时不需要启动容器
+	scriptManager := script.NewScriptManager()
+	r.Lint = script.LintAll(scriptManager.GetScripts())
+	if script.HasErrors(r.Lint) {
+		return fail("default scripts have syntax errors, see lint in the report")
+	}
+
+	if o.build {
+		fmt.Println("=== 构建节点镜像 ===")
+		if err := buildImage(ctx, o.engine, o.image, "e2e"); err != nil {
+			return fail("failed to build node image: %v", err)
+		}
+	}
+
+	fmt.Printf("=== 启动%d个节点容器 ===\n", o.nodeCount)
+	c, err := startCluster(ctx, o.engine, o.image, fmt.Sprintf("k8s-installer-e2e-%d", time.Now().Unix()), o.nodeCount)
+	if c != nil && !o.keep {
+		defer c.remove()
+	}
+	if err != nil {
+		return fail("failed to start node containers: %v", err)
+	}
+	for _, n := range c.nodes {
+		r.Nodes = append(r.Nodes, fmt.Sprintf("%s %s (%s)", n.NodeType, n.Name, n.IP))
+	}
+	if err := c.waitForSSH(ctx, 2*time.Minute); err != nil {
+		return fail("%v", err)
+	}
+
+	fmt.Println("=== 执行部署 ===")
+	recorder := &stepRecorder{}
+	opts := kubeadm.DeployOptions{StepTracker: recorder}
+	if o.ci {
+		// 容器共享宿主机内核，kubeadm的内核配置、swap和资源检查无法满足
+		opts.IgnorePreflightErrors = []string{"all"}
+	}
+	_, deployErr := kubeadm.DeployK8sCluster(ctx, c.nodes, o.kubeVersion, "amd64", "ubuntu", scriptManager, o.skipSteps, opts, func(msg, nodeID, nodeName string) {})
+	r.Steps = recorder.events
+	if deployErr != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("deployment failed: %v", deployErr))
+		if o.ci {
+			for _, name := range c.names {
+				fmt.Fprintf(os.Stderr, "=== %s kubelet/containerd日志 ===\n%s\n", name, c.logs(context.Background(), name))
+			}
+		}
+	}
+	r.Problems = append(r.Problems, checkSteps(c.nodes, o.skipSteps, r.Steps)...)
+	return r
+}
+
+// checkSteps 检查每个节点的步骤都按AllSteps的顺序执行且全部成功，未跳过的节点级步骤都已执行，
+// 并且所有Worker节点在Master初始化之后才加入集群
+func checkSteps(nodes []node.Node, skipSteps []string, events []stepEvent) []string {
+	var problems []string
+	order := make(map[string]int)
+	for i, step := range kubeadm.AllSteps {
+		order[step] = i
+	}
+
+	masterInitialized := false
+	last := make(map[string]int)
+	seen := make(map[string]map[string]bool)
+	for _, e := range events {
+		if e.Error != "" {
+			problems = append(problems, fmt.Sprintf("step %s failed on %s: %s", e.Step, e.NodeID, e.Error))
+		}
+		if i, ok := order[e.Step]; ok {
+			if prev, ok := last[e.NodeID]; ok && i < prev {
+				problems = append(problems, fmt.Sprintf("step %s ran on %s after %s", e.Step, e.NodeID, kubeadm.AllSteps[prev]))
+			}
+			last[e.NodeID] = i
+		}
+		if seen[e.NodeID] == nil {
+			seen[e.NodeID] = make(map[string]bool)
+		}
+		seen[e.NodeID][e.Step] = true
+
+		switch e.Step {
+		case kubeadm.StepMasterInitialization:
+			masterInitialized = e.Error == ""
+		case kubeadm.StepWorkerJoin:
+			if !masterInitialized {
+				problems = append(problems, fmt.Sprintf("%s joined before the master was initialized", e.NodeID))
+			}
+		}
+	}
+
+	skipped := make(map[string]bool)
+	for _, step := range skipSteps {
+		skipped[step] = true
+	}
+	for _, n := range nodes {
+		expected := []string{
+			kubeadm.StepSystemPreparation,
+			kubeadm.StepIpForwardConfiguration,
+			kubeadm.StepContainerRuntimeInstallation,
+			kubeadm.StepKubernetesRepositoryConfiguration,
+			kubeadm.StepKubernetesComponentsInstallation,
+		}
+		if n.NodeType == "master" {
+			expected = append(expected, kubeadm.StepMasterInitialization)
+		} else {
+			expected = append(expected, kubeadm.StepWorkerJoin)
+		}
+		for _, step := range expected {
+			if !skipped[step] && !seen[n.ID][step] {
+				problems = append(problems, fmt.Sprintf("step %s did not run on %s", step, n.ID))
+			}
+		}
+	}
+	return problems
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
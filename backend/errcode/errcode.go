@@ -0,0 +1,160 @@
+// Package errcode 定义API、部署记录和部署步骤使用的机器可读错误码，以及每个错误码的中英文说明
+package errcode
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Code 机器可读的错误码
+type Code string
+
+// 错误码
+const (
+	SSHAuth         Code = "ERR_SSH_AUTH"
+	SSHConnect      Code = "ERR_SSH_CONNECT"
+	RepoUnreachable Code = "ERR_REPO_UNREACHABLE"
+	ImagePull       Code = "ERR_IMAGE_PULL"
+	KubeadmInit     Code = "ERR_KUBEADM_INIT"
+	KubeadmJoin     Code = "ERR_KUBEADM_JOIN"
+	ClusterNotReady Code = "ERR_CLUSTER_NOT_READY"
+	Incompatible    Code = "ERR_INCOMPATIBLE"
+	StepFailed      Code = "ERR_STEP_FAILED"
+	Timeout         Code = "ERR_TIMEOUT"
+	Canceled        Code = "ERR_CANCELED"
+	Validation      Code = "ERR_VALIDATION"
+	BadRequest      Code = "ERR_BAD_REQUEST"
+	NotFound        Code = "ERR_NOT_FOUND"
+	Locked          Code = "ERR_LOCKED"
+	Conflict        Code = "ERR_CONFLICT"
+	RateLimited     Code = "ERR_RATE_LIMITED"
+	TooLarge        Code = "ERR_TOO_LARGE"
+	Internal        Code = "ERR_INTERNAL"
+)
+
+// 错误信息语言
+const (
+	LangZH = "zh"
+	LangEN = "en"
+)
+
+// messages 错误码的中文和英文说明
+var messages = map[Code][2]string{
+	SSHAuth:         {"SSH认证失败，请检查用户名、密码或私钥", "SSH authentication failed, check the username, password or private key"},
+	SSHConnect:      {"无法通过SSH连接节点，请检查地址、端口和网络", "cannot connect to the node over SSH, check the address, port and network"},
+	RepoUnreachable: {"无法访问软件源，请检查节点的DNS、代理和镜像源配置", "package repository is unreachable, check DNS, proxy and mirror settings on the node"},
+	ImagePull:       {"镜像拉取失败，请检查镜像仓库地址和节点网络", "failed to pull images, check the image repository and node network"},
+	KubeadmInit:     {"kubeadm init执行失败，请查看kubelet日志和步骤输出", "kubeadm init failed, check the kubelet logs and step output"},
+	KubeadmJoin:     {"节点加入集群失败，请检查join命令、token和控制平面地址", "node failed to join the cluster, check the join command, token and control plane endpoint"},
+	ClusterNotReady: {"部署完成后节点或核心Pod在等待时间内未就绪，请查看部署输出中的诊断信息", "nodes or core pods did not become ready in time after deployment, check the diagnostics in the deployment output"},
+	Incompatible:    {"节点的发行版或containerd版本不支持所选的Kubernetes版本，请参考返回的可选方案", "the node's distribution or containerd version does not support the selected Kubernetes version, see the suggested alternatives"},
+	StepFailed:      {"部署步骤执行失败，请查看步骤输出", "deployment step failed, check the step output"},
+	Timeout:         {"操作超时", "operation timed out"},
+	Canceled:        {"操作已取消", "operation was canceled"},
+	Validation:      {"请求参数校验失败", "request validation failed"},
+	BadRequest:      {"请求格式错误", "malformed request"},
+	NotFound:        {"资源不存在", "resource not found"},
+	Locked:          {"节点或集群正在被其他任务使用", "node or cluster is locked by another job"},
+	Conflict:        {"资源冲突", "resource conflict"},
+	RateLimited:     {"请求过于频繁，请稍后重试", "too many requests, retry later"},
+	TooLarge:        {"请求体超过大小限制", "request body exceeds the size limit"},
+	Internal:        {"服务器内部错误", "internal server error"},
+}
+
+// patterns 根据错误文本识别错误码，按顺序匹配，SSH认证失败的文本同时包含连接失败的前缀，需要先匹配
+var patterns = []struct {
+	code     Code
+	keywords []string
+}{
+	{SSHAuth, []string{"unable to authenticate", "failed to parse private key", "either password or privatekey must be provided", "permission denied (publickey"}},
+	{SSHConnect, []string{"failed to create ssh client", "ssh: handshake failed"}},
+	{RepoUnreachable, []string{"could not resolve host", "temporary failure resolving", "failed to fetch", "cannot find a valid baseurl", "failed to download metadata", "failed to synchronize cache", "curl: (6)", "curl: (7)"}},
+	{ImagePull, []string{"errimagepull", "imagepullbackoff", "failed to pull image"}},
+	{Timeout, []string{"context deadline exceeded", "timed out"}},
+	{Canceled, []string{"context canceled"}},
+}
+
+// Error 带错误码的错误，Error()返回原始错误文本
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New 为err附加错误码，err已带有错误码时保留原错误码
+func New(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of 返回err的错误码：优先使用附加的错误码，否则根据上下文错误和错误文本识别，无法识别时返回空字符串
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return Canceled
+	}
+	return Classify(err.Error())
+}
+
+// Classify 根据错误文本或命令输出识别错误码，无法识别时返回空字符串
+func Classify(text string) Code {
+	text = strings.ToLower(text)
+	for _, p := range patterns {
+		for _, keyword := range p.keywords {
+			if strings.Contains(text, keyword) {
+				return p.code
+			}
+		}
+	}
+	return ""
+}
+
+// Message 返回错误码在指定语言下的说明，未知错误码返回空字符串
+func Message(code Code, lang string) string {
+	msg, ok := messages[code]
+	if !ok {
+		return ""
+	}
+	if lang == LangEN {
+		return msg[1]
+	}
+	return msg[0]
+}
+
+// Language 根据Accept-Language请求头选择错误信息语言，按出现顺序取第一个支持的语言，默认中文
+func Language(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, LangEN):
+			return LangEN
+		case strings.HasPrefix(tag, LangZH):
+			return LangZH
+		}
+	}
+	return LangZH
+}
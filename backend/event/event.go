@@ -0,0 +1,86 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	TypeDeploymentStarted   = "deployment.started"
+	TypeDeploymentSucceeded = "deployment.succeeded"
+	TypeDeploymentFailed    = "deployment.failed"
+	TypeNodeOffline         = "node.offline"
+	TypeNodeOnline          = "node.online"
+	TypeJoinCompleted       = "join.completed"
+	TypeClusterDrift        = "cluster.drift"
+)
+
+// AllTypes 所有支持的事件类型
+var AllTypes = []string{
+	TypeDeploymentStarted,
+	TypeDeploymentSucceeded,
+	TypeDeploymentFailed,
+	TypeNodeOffline,
+	TypeNodeOnline,
+	TypeJoinCompleted,
+	TypeClusterDrift,
+}
+
+// Event 事件
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Message   string                 `json:"message"`
+	NodeID    string                 `json:"nodeId,omitempty"`
+	NodeName  string                 `json:"nodeName,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// Handler 事件处理函数
+type Handler func(ev Event)
+
+// Bus 轻量级事件总线，事件异步分发给所有订阅者
+type Bus struct {
+	mutex    sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe 订阅所有事件
+func (b *Bus) Subscribe(handler Handler) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish 发布事件，不阻塞调用方
+func (b *Bus) Publish(ev Event) {
+	if ev.ID == "" {
+		ev.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+
+	b.mutex.RLock()
+	handlers := append([]Handler(nil), b.handlers...)
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		go func(h Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("事件处理失败: %v\n", r)
+				}
+			}()
+			h(ev)
+		}(handler)
+	}
+}
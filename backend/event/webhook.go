@@ -0,0 +1,302 @@
+package event
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 通知目标类型
+const (
+	WebhookTypeGeneric  = "generic"
+	WebhookTypeSlack    = "slack"
+	WebhookTypeDingTalk = "dingtalk"
+	WebhookTypeWeCom    = "wecom" // 企业微信
+)
+
+// ErrWebhookNotFound webhook不存在
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook 通知目标配置
+type Webhook struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // 为空表示订阅所有事件
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookManager webhook管理器，负责配置持久化和事件投递
+type WebhookManager struct {
+	db     *sql.DB
+	mutex  sync.RWMutex
+	client *http.Client
+}
+
+// NewWebhookManager 创建webhook管理器
+func NewWebhookManager(db *sql.DB) (*WebhookManager, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		url TEXT NOT NULL,
+		events TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create webhooks table: %v", err)
+	}
+
+	return &WebhookManager{
+		db:     db,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// validateWebhook 校验webhook配置
+func validateWebhook(w Webhook) error {
+	if strings.TrimSpace(w.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch w.Type {
+	case WebhookTypeGeneric, WebhookTypeSlack, WebhookTypeDingTalk, WebhookTypeWeCom:
+	default:
+		return fmt.Errorf("unsupported webhook type: %s", w.Type)
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid url: %s", w.URL)
+	}
+	for _, t := range w.Events {
+		valid := false
+		for _, known := range AllTypes {
+			if t == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unsupported event type: %s", t)
+		}
+	}
+	return nil
+}
+
+// scanWebhook 扫描一行webhook记录
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (Webhook, error) {
+	var w Webhook
+	var events string
+	if err := scanner.Scan(&w.ID, &w.Name, &w.Type, &w.URL, &events, &w.Enabled, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return w, err
+	}
+	w.Events = []string{}
+	if events != "" {
+		w.Events = strings.Split(events, ",")
+	}
+	return w, nil
+}
+
+// ListWebhooks 获取所有webhook
+func (m *WebhookManager) ListWebhooks() ([]Webhook, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	rows, err := m.db.Query("SELECT id, name, type, url, events, enabled, created_at, updated_at FROM webhooks ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %v", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetWebhook 获取指定webhook
+func (m *WebhookManager) GetWebhook(id string) (*Webhook, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	row := m.db.QueryRow("SELECT id, name, type, url, events, enabled, created_at, updated_at FROM webhooks WHERE id = ?", id)
+	w, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook: %v", err)
+	}
+	return &w, nil
+}
+
+// CreateWebhook 添加webhook
+func (m *WebhookManager) CreateWebhook(w Webhook) (*Webhook, error) {
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	w.ID = fmt.Sprintf("%d", now.UnixNano())
+	w.CreatedAt = now
+	w.UpdatedAt = now
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	_, err := m.db.Exec(
+		"INSERT INTO webhooks (id, name, type, url, events, enabled, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		w.ID, w.Name, w.Type, w.URL, strings.Join(w.Events, ","), w.Enabled, w.CreatedAt, w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %v", err)
+	}
+	return &w, nil
+}
+
+// UpdateWebhook 更新webhook
+func (m *WebhookManager) UpdateWebhook(id string, w Webhook) (*Webhook, error) {
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	w.ID = id
+	w.CreatedAt = existing.CreatedAt
+	w.UpdatedAt = time.Now()
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	_, err = m.db.Exec(
+		"UPDATE webhooks SET name = ?, type = ?, url = ?, events = ?, enabled = ?, updated_at = ? WHERE id = ?",
+		w.Name, w.Type, w.URL, strings.Join(w.Events, ","), w.Enabled, w.UpdatedAt, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %v", err)
+	}
+	return &w, nil
+}
+
+// DeleteWebhook 删除webhook
+func (m *WebhookManager) DeleteWebhook(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	res, err := m.db.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// subscribes 检查webhook是否订阅了指定事件
+func (w Webhook) subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleEvent 事件总线回调，将事件投递到所有订阅的webhook
+func (m *WebhookManager) HandleEvent(ev Event) {
+	webhooks, err := m.ListWebhooks()
+	if err != nil {
+		fmt.Printf("获取webhook列表失败: %v\n", err)
+		return
+	}
+
+	for _, w := range webhooks {
+		if !w.Enabled || !w.subscribes(ev.Type) {
+			continue
+		}
+		if err := m.Send(w, ev); err != nil {
+			fmt.Printf("发送webhook %s 失败: %v\n", w.Name, err)
+		}
+	}
+}
+
+// Send 按webhook类型构建消息并发送
+func (m *WebhookManager) Send(w Webhook, ev Event) error {
+	payload, err := buildPayload(w.Type, ev)
+	if err != nil {
+		return err
+	}
+
+	resp, err := m.client.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// formatText 生成聊天工具使用的文本消息
+func formatText(ev Event) string {
+	text := fmt.Sprintf("[K8s Installer] %s\n%s", ev.Type, ev.Message)
+	if ev.NodeName != "" {
+		text += fmt.Sprintf("\n节点: %s", ev.NodeName)
+	}
+	text += fmt.Sprintf("\n时间: %s", ev.CreatedAt.Format("2006-01-02 15:04:05"))
+	return text
+}
+
+// buildPayload 构建不同通知目标的消息体
+func buildPayload(webhookType string, ev Event) ([]byte, error) {
+	switch webhookType {
+	case WebhookTypeSlack:
+		return json.Marshal(map[string]interface{}{
+			"text": formatText(ev),
+		})
+	case WebhookTypeDingTalk, WebhookTypeWeCom:
+		// 钉钉和企业微信机器人的文本消息格式相同
+		return json.Marshal(map[string]interface{}{
+			"msgtype": "text",
+			"text": map[string]string{
+				"content": formatText(ev),
+			},
+		})
+	default:
+		return json.Marshal(ev)
+	}
+}
@@ -0,0 +1,186 @@
+// Package idempotency 保存带Idempotency-Key请求的响应，网络重试等重复请求在有效期内返回首次请求的响应，
+// 避免重复触发部署或重复创建节点
+package idempotency
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Header 请求中携带幂等键的请求头
+const Header = "Idempotency-Key"
+
+// ReplayedHeader 响应为重放的首次请求响应时设置的响应头
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength 幂等键的最大长度
+const MaxKeyLength = 255
+
+// DefaultWindow 默认保存响应的时间
+const DefaultWindow = 24 * time.Hour
+
+var (
+	// ErrInProgress 使用相同幂等键的请求正在处理
+	ErrInProgress = errors.New("a request with this Idempotency-Key is still in progress")
+	// ErrKeyReused 幂等键已用于内容不同的请求
+	ErrKeyReused = errors.New("Idempotency-Key was already used for a different request")
+)
+
+// Scope 幂等键的作用范围，不同项目或不同接口使用相同的幂等键互不影响
+type Scope struct {
+	ProjectID string
+	Method    string
+	Path      string
+}
+
+// String 作用范围的文本表示
+func (s Scope) String() string {
+	return s.ProjectID + " " + s.Method + " " + s.Path
+}
+
+// Response 保存的首次请求响应
+type Response struct {
+	Scope
+	Key         string
+	RequestHash string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Store 幂等键存储，响应持久化在SQLite中，处理中的请求记录在内存中
+type Store struct {
+	db       *sql.DB
+	window   time.Duration
+	mutex    sync.Mutex
+	inFlight map[string]string
+}
+
+// NewStore 创建幂等键存储，window为响应的保存时间
+func NewStore(db *sql.DB, window time.Duration) (*Store, error) {
+	if err := migrateScope(db); err != nil {
+		return nil, err
+	}
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		project_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		key TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		content_type TEXT NOT NULL,
+		body BLOB,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (project_id, method, path, key)
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency_keys table: %v", err)
+	}
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Store{db: db, window: window, inFlight: make(map[string]string)}, nil
+}
+
+// migrateScope 删除以幂等键为主键的旧表。旧记录没有项目和接口信息，且只在有效期内使用，不做迁移
+func migrateScope(db *sql.DB) error {
+	var tableExists, columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'idempotency_keys'").Scan(&tableExists); err != nil {
+		return fmt.Errorf("failed to check idempotency_keys table: %v", err)
+	}
+	if !tableExists {
+		return nil
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('idempotency_keys') WHERE name = 'project_id'").Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check project_id column of idempotency_keys: %v", err)
+	}
+	if columnExists {
+		return nil
+	}
+	if _, err := db.Exec("DROP TABLE idempotency_keys"); err != nil {
+		return fmt.Errorf("failed to drop legacy idempotency_keys table: %v", err)
+	}
+	return nil
+}
+
+// RequestHash 请求的摘要，由方法、路径和请求体计算，同一幂等键只能用于摘要相同的请求
+func RequestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin 开始处理作用范围内带幂等键的请求：有效期内已有响应时返回该响应，摘要不同时返回ErrKeyReused，
+// 相同幂等键的请求正在处理时返回ErrInProgress；都不是时返回nil，调用方处理完成后必须调用Complete或Release
+func (s *Store) Begin(scope Scope, key, requestHash string) (*Response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	flightKey := inFlightKey(scope, key)
+	if hash, ok := s.inFlight[flightKey]; ok {
+		if hash != requestHash {
+			return nil, ErrKeyReused
+		}
+		return nil, ErrInProgress
+	}
+
+	resp := Response{Scope: scope, Key: key}
+	err := s.db.QueryRow(
+		"SELECT request_hash, status, content_type, body, created_at FROM idempotency_keys WHERE project_id = ? AND method = ? AND path = ? AND key = ? AND created_at >= ?",
+		scope.ProjectID, scope.Method, scope.Path, key, time.Now().Add(-s.window),
+	).Scan(&resp.RequestHash, &resp.Status, &resp.ContentType, &resp.Body, &resp.CreatedAt)
+	switch {
+	case err == nil:
+		if resp.RequestHash != requestHash {
+			return nil, ErrKeyReused
+		}
+		return &resp, nil
+	case errors.Is(err, sql.ErrNoRows):
+		s.inFlight[flightKey] = requestHash
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to query idempotency key: %v", err)
+	}
+}
+
+// Complete 保存请求的响应并结束处理，同时清理过期的响应
+func (s *Store) Complete(resp Response) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.inFlight, inFlightKey(resp.Scope, resp.Key))
+
+	if resp.CreatedAt.IsZero() {
+		resp.CreatedAt = time.Now()
+	}
+	if _, err := s.db.Exec("DELETE FROM idempotency_keys WHERE created_at < ?", time.Now().Add(-s.window)); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %v", err)
+	}
+	if _, err := s.db.Exec(
+		"INSERT OR REPLACE INTO idempotency_keys (project_id, method, path, key, request_hash, status, content_type, body, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		resp.ProjectID, resp.Method, resp.Path, resp.Key, resp.RequestHash, resp.Status, resp.ContentType, resp.Body, resp.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to save idempotency key: %v", err)
+	}
+	return nil
+}
+
+// Release 结束处理但不保存响应，之后可以使用相同的幂等键重试
+func (s *Store) Release(scope Scope, key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.inFlight, inFlightKey(scope, key))
+}
+
+// inFlightKey 处理中请求在内存中的标识
+func inFlightKey(scope Scope, key string) string {
+	return scope.String() + " " + key
+}
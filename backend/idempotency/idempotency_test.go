@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestStore(t *testing.T) (*Store, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := NewStore(db, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, db
+}
+
+func TestStoreScope(t *testing.T) {
+	store, _ := newTestStore(t)
+	scope := Scope{ProjectID: "default", Method: "POST", Path: "/api/v1/nodes"}
+	hash := RequestHash(scope.Method, scope.Path, []byte(`{"name":"node-1"}`))
+
+	if saved, err := store.Begin(scope, "key-1", hash); err != nil || saved != nil {
+		t.Fatalf("Begin = %v, %v, want a new request", saved, err)
+	}
+	if err := store.Complete(Response{Scope: scope, Key: "key-1", RequestHash: hash, Status: 201, ContentType: "application/json", Body: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := store.Begin(scope, "key-1", hash)
+	if err != nil || saved == nil || saved.Status != 201 {
+		t.Fatalf("Begin = %+v, %v, want the saved response", saved, err)
+	}
+
+	// 其他项目和其他接口使用相同的幂等键时作为新请求处理
+	for _, other := range []Scope{
+		{ProjectID: "team-a", Method: scope.Method, Path: scope.Path},
+		{ProjectID: scope.ProjectID, Method: scope.Method, Path: "/api/v1/node-groups"},
+	} {
+		if saved, err := store.Begin(other, "key-1", RequestHash(other.Method, other.Path, nil)); err != nil || saved != nil {
+			t.Fatalf("Begin(%s) = %v, %v, want a new request", other, saved, err)
+		}
+		store.Release(other, "key-1")
+	}
+
+	if _, err := store.Begin(scope, "key-1", RequestHash(scope.Method, scope.Path, []byte(`{}`))); err != ErrKeyReused {
+		t.Fatalf("err = %v, want ErrKeyReused", err)
+	}
+}
+
+func TestStoreInProgress(t *testing.T) {
+	store, _ := newTestStore(t)
+	scope := Scope{ProjectID: "default", Method: "POST", Path: "/api/v1/kubeadm/deploy"}
+	hash := RequestHash(scope.Method, scope.Path, nil)
+
+	if _, err := store.Begin(scope, "key-1", hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Begin(scope, "key-1", hash); err != ErrInProgress {
+		t.Fatalf("err = %v, want ErrInProgress", err)
+	}
+	store.Release(scope, "key-1")
+	if saved, err := store.Begin(scope, "key-1", hash); err != nil || saved != nil {
+		t.Fatalf("Begin after Release = %v, %v", saved, err)
+	}
+}
+
+func TestMigrateLegacyTable(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE idempotency_keys (key TEXT PRIMARY KEY, request_hash TEXT NOT NULL, status INTEGER NOT NULL, content_type TEXT NOT NULL, body BLOB, created_at DATETIME NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(db, 0); err != nil {
+		t.Fatal(err)
+	}
+	var columns int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('idempotency_keys') WHERE name IN ('project_id', 'method', 'path')").Scan(&columns); err != nil {
+		t.Fatal(err)
+	}
+	if columns != 3 {
+		t.Fatalf("idempotency_keys has %d scope columns, want 3", columns)
+	}
+}
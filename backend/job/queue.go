@@ -0,0 +1,237 @@
+// Package job 长时间运行任务（如集群部署）的排队和并发控制
+package job
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConcurrent 默认同时运行的最大任务数
+const DefaultMaxConcurrent = 2
+
+// 任务状态
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+)
+
+// Job 排队的任务
+type Job struct {
+	ID        string
+	Operation string
+	// Keys 任务作用的节点和集群，键相同的任务串行执行，见lock.NodeKey和lock.ClusterKey
+	Keys []string
+	// Priority 优先级，数值大的任务先执行，相同优先级按入队顺序执行
+	Priority int
+	// Description 发起任务时填写的变更说明
+	Description string
+}
+
+// Status 任务的排队状态
+type Status struct {
+	ID          string     `json:"id"`
+	Operation   string     `json:"operation"`
+	Keys        []string   `json:"keys"`
+	Priority    int        `json:"priority"`
+	Description string     `json:"description,omitempty"`
+	State       string     `json:"state"`
+	Position    int        `json:"position,omitempty"` // 排队位置，从1开始，运行中的任务为0
+	EnqueuedAt  time.Time  `json:"enqueuedAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+}
+
+// entry 队列中的任务
+type entry struct {
+	job        Job
+	seq        uint64
+	enqueuedAt time.Time
+	startedAt  time.Time
+	running    bool
+	ready      chan struct{}
+}
+
+// Queue 任务队列：限制同时运行的任务数，作用于相同节点或集群的任务串行执行，
+// 优先级高的任务先执行，前面的任务因节点或集群冲突无法执行时，后面不冲突的任务可以先执行
+type Queue struct {
+	mutex         sync.Mutex
+	maxConcurrent int
+	seq           uint64
+	entries       []*entry
+}
+
+// NewQueue 创建任务队列，maxConcurrent小于1时使用DefaultMaxConcurrent
+func NewQueue(maxConcurrent int) *Queue {
+	if maxConcurrent < 1 {
+		maxConcurrent = DefaultMaxConcurrent
+	}
+	return &Queue{maxConcurrent: maxConcurrent}
+}
+
+// Ticket 任务的执行许可，任务结束后调用Done
+type Ticket struct {
+	queue *Queue
+	entry *entry
+	once  sync.Once
+}
+
+// Enqueue 任务入队并等待轮到执行，onQueued在任务需要排队时以排队位置调用一次，可以为nil。
+// ctx取消时任务出队并返回ctx的错误
+func (q *Queue) Enqueue(ctx context.Context, job Job, onQueued func(position int)) (*Ticket, error) {
+	q.mutex.Lock()
+	q.seq++
+	e := &entry{job: job, seq: q.seq, enqueuedAt: time.Now(), ready: make(chan struct{})}
+	q.entries = append(q.entries, e)
+	q.schedule()
+	position := q.position(e)
+	q.mutex.Unlock()
+
+	if position > 0 && onQueued != nil {
+		onQueued(position)
+	}
+
+	select {
+	case <-e.ready:
+		return &Ticket{queue: q, entry: e}, nil
+	case <-ctx.Done():
+		q.mutex.Lock()
+		defer q.mutex.Unlock()
+		// 取消与调度同时发生时任务可能已经开始运行，同样需要释放
+		q.remove(e)
+		q.schedule()
+		return nil, ctx.Err()
+	}
+}
+
+// SetID 更新任务ID，用于任务ID在入队之后才确定的场景（如继续已有的部署）
+func (t *Ticket) SetID(id string) {
+	t.queue.mutex.Lock()
+	defer t.queue.mutex.Unlock()
+	t.entry.job.ID = id
+}
+
+// Done 任务结束，释放执行许可，可以重复调用
+func (t *Ticket) Done() {
+	t.once.Do(func() {
+		t.queue.mutex.Lock()
+		defer t.queue.mutex.Unlock()
+		t.queue.remove(t.entry)
+		t.queue.schedule()
+	})
+}
+
+// List 返回运行中和排队的任务，运行中的任务在前，排队的任务按执行顺序排列
+func (q *Queue) List() []Status {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	statuses := []Status{}
+	for _, e := range q.ordered() {
+		status := Status{
+			ID:          e.job.ID,
+			Operation:   e.job.Operation,
+			Keys:        e.job.Keys,
+			Priority:    e.job.Priority,
+			Description: e.job.Description,
+			State:       StateQueued,
+			EnqueuedAt:  e.enqueuedAt,
+		}
+		if e.running {
+			startedAt := e.startedAt
+			status.State = StateRunning
+			status.StartedAt = &startedAt
+		} else {
+			status.Position = q.position(e)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// MaxConcurrent 同时运行的最大任务数
+func (q *Queue) MaxConcurrent() int {
+	return q.maxConcurrent
+}
+
+// ordered 运行中的任务按开始时间排列在前，排队的任务按优先级和入队顺序排列，调用方需持有锁
+func (q *Queue) ordered() []*entry {
+	entries := append([]*entry(nil), q.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.running != b.running {
+			return a.running
+		}
+		if a.running {
+			return a.startedAt.Before(b.startedAt)
+		}
+		if a.job.Priority != b.job.Priority {
+			return a.job.Priority > b.job.Priority
+		}
+		return a.seq < b.seq
+	})
+	return entries
+}
+
+// schedule 按优先级启动可以运行的排队任务，调用方需持有锁
+func (q *Queue) schedule() {
+	running := 0
+	busy := make(map[string]bool)
+	for _, e := range q.entries {
+		if e.running {
+			running++
+			for _, key := range e.job.Keys {
+				busy[key] = true
+			}
+		}
+	}
+
+	for _, e := range q.ordered() {
+		if running >= q.maxConcurrent {
+			return
+		}
+		if e.running {
+			continue
+		}
+		conflict := false
+		for _, key := range e.job.Keys {
+			conflict = conflict || busy[key]
+		}
+		// 冲突的任务同样占用其键，避免后入队的低优先级任务抢先执行同一集群的操作
+		for _, key := range e.job.Keys {
+			busy[key] = true
+		}
+		if conflict {
+			continue
+		}
+		e.running = true
+		e.startedAt = time.Now()
+		running++
+		close(e.ready)
+	}
+}
+
+// position 排队任务的位置，从1开始，运行中的任务返回0，调用方需持有锁
+func (q *Queue) position(target *entry) int {
+	position := 0
+	for _, e := range q.ordered() {
+		if e.running {
+			continue
+		}
+		position++
+		if e == target {
+			return position
+		}
+	}
+	return 0
+}
+
+// remove 从队列中删除任务，调用方需持有锁
+func (q *Queue) remove(target *entry) {
+	for i, e := range q.entries {
+		if e == target {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return
+		}
+	}
+}
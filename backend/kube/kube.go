@@ -0,0 +1,427 @@
+// Package kube 使用集群管理员kubeconfig直接访问Kubernetes API Server。安装器只需要读取节点、Pod、事件、
+// 组件健康状态和运行一次性测试Pod，这里用标准库实现这些REST请求，不引入client-go。
+//
+// 只支持kubeadm生成的管理员kubeconfig用到的字段：内联的CA证书（certificate-authority-data）、
+// 内联的客户端证书和密钥（client-certificate-data/client-key-data）或Bearer Token（token），
+// 以及insecure-skip-tls-verify和tls-server-name。exec插件、auth-provider、用户名密码、
+// 引用文件的证书和Token、代理和身份模拟等client-go支持的其他字段返回ErrUnsupportedKubeconfig，不会静默忽略
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 单次请求的默认超时时间
+const DefaultTimeout = 15 * time.Second
+
+// Kubeconfig kubectl config view --raw --flatten -o json输出中用到的字段，证书和密钥为base64编码的内容
+type Kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+			TLSServerName            string `json:"tls-server-name"`
+			// 以下为不支持的字段，设置时返回ErrUnsupportedKubeconfig
+			CertificateAuthority string `json:"certificate-authority"`
+			ProxyURL             string `json:"proxy-url"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			ClientCertificateData string `json:"client-certificate-data"`
+			ClientKeyData         string `json:"client-key-data"`
+			Token                 string `json:"token"`
+			// 以下为不支持的字段，设置时返回ErrUnsupportedKubeconfig
+			ClientCertificate string          `json:"client-certificate"`
+			ClientKey         string          `json:"client-key"`
+			TokenFile         string          `json:"tokenFile"`
+			Username          string          `json:"username"`
+			Password          string          `json:"password"`
+			As                string          `json:"as"`
+			Exec              json.RawMessage `json:"exec"`
+			AuthProvider      json.RawMessage `json:"auth-provider"`
+		} `json:"user"`
+	} `json:"users"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster string `json:"cluster"`
+			User    string `json:"user"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+// ErrUnsupportedKubeconfig kubeconfig使用了不支持的认证方式或字段
+var ErrUnsupportedKubeconfig = errors.New("kube: unsupported kubeconfig")
+
+// StatusError API Server返回的非2xx响应
+type StatusError struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("kube: HTTP %d", e.Code)
+	}
+	return fmt.Sprintf("kube: %s (HTTP %d)", e.Message, e.Code)
+}
+
+// IsNotFound 错误是否为资源不存在
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound
+}
+
+// IsUnauthorized 错误是否为kubeconfig中的凭据无效，集群重建或证书轮换后出现
+func IsUnauthorized(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && (statusErr.Code == http.StatusUnauthorized || statusErr.Code == http.StatusForbidden)
+}
+
+// RawGetter 按API路径读取资源的原始JSON，用于不能直接访问API Server时通过其他方式（如kubectl get --raw）读取
+type RawGetter func(ctx context.Context, path string) ([]byte, error)
+
+// Client API Server客户端
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+	// raw 不为nil时为只读客户端，GET请求通过raw读取
+	raw RawGetter
+}
+
+// NewReadOnlyClient 创建通过get读取资源的只读客户端，Server返回空字符串，创建和删除资源返回错误
+func NewReadOnlyClient(get RawGetter) *Client {
+	return &Client{raw: get}
+}
+
+// NewClient 按kubeconfig的当前上下文创建客户端，server不为空时替换kubeconfig中的API Server地址
+func NewClient(kubeconfig []byte, server string) (*Client, error) {
+	var config Kubeconfig
+	if err := json.Unmarshal(kubeconfig, &config); err != nil {
+		return nil, fmt.Errorf("kube: failed to parse kubeconfig: %v", err)
+	}
+
+	contextName := config.CurrentContext
+	if contextName == "" && len(config.Contexts) > 0 {
+		contextName = config.Contexts[0].Name
+	}
+	var clusterName, userName string
+	for _, c := range config.Contexts {
+		if c.Name == contextName {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("kube: context %q not found in kubeconfig", contextName)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	found := false
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		if c.Cluster.CertificateAuthority != "" {
+			return nil, fmt.Errorf("%w: cluster %q references certificate-authority file, use certificate-authority-data", ErrUnsupportedKubeconfig, clusterName)
+		}
+		if c.Cluster.ProxyURL != "" {
+			return nil, fmt.Errorf("%w: cluster %q uses proxy-url", ErrUnsupportedKubeconfig, clusterName)
+		}
+		if server == "" {
+			server = c.Cluster.Server
+		}
+		tlsConfig.ServerName = c.Cluster.TLSServerName
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		if c.Cluster.CertificateAuthorityData != "" {
+			pem, err := base64.StdEncoding.DecodeString(c.Cluster.CertificateAuthorityData)
+			if err != nil {
+				return nil, fmt.Errorf("kube: invalid certificate-authority-data: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("kube: no certificates found in certificate-authority-data")
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kube: cluster %q not found in kubeconfig", clusterName)
+	}
+	if !strings.HasPrefix(server, "https://") && !strings.HasPrefix(server, "http://") {
+		return nil, fmt.Errorf("kube: invalid server %q", server)
+	}
+
+	client := &Client{server: strings.TrimSuffix(server, "/")}
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		switch {
+		case len(u.User.Exec) > 0 && string(u.User.Exec) != "null":
+			return nil, fmt.Errorf("%w: user %q uses an exec credential plugin", ErrUnsupportedKubeconfig, userName)
+		case len(u.User.AuthProvider) > 0 && string(u.User.AuthProvider) != "null":
+			return nil, fmt.Errorf("%w: user %q uses an auth-provider", ErrUnsupportedKubeconfig, userName)
+		case u.User.ClientCertificate != "" || u.User.ClientKey != "":
+			return nil, fmt.Errorf("%w: user %q references client certificate files, use client-certificate-data and client-key-data", ErrUnsupportedKubeconfig, userName)
+		case u.User.TokenFile != "":
+			return nil, fmt.Errorf("%w: user %q uses tokenFile, use token", ErrUnsupportedKubeconfig, userName)
+		case u.User.Username != "" || u.User.Password != "":
+			return nil, fmt.Errorf("%w: user %q uses basic authentication", ErrUnsupportedKubeconfig, userName)
+		case u.User.As != "":
+			return nil, fmt.Errorf("%w: user %q uses impersonation", ErrUnsupportedKubeconfig, userName)
+		}
+		client.token = u.User.Token
+		if u.User.ClientCertificateData != "" {
+			cert, err := base64.StdEncoding.DecodeString(u.User.ClientCertificateData)
+			if err != nil {
+				return nil, fmt.Errorf("kube: invalid client-certificate-data: %v", err)
+			}
+			key, err := base64.StdEncoding.DecodeString(u.User.ClientKeyData)
+			if err != nil {
+				return nil, fmt.Errorf("kube: invalid client-key-data: %v", err)
+			}
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("kube: invalid client certificate: %v", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.http = &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return client, nil
+}
+
+// Server API Server地址
+func (c *Client) Server() string {
+	return c.server
+}
+
+// Do 发送请求，in不为nil时以JSON发送，out不为nil时解析JSON响应；非2xx响应返回*StatusError
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	data, err := c.do(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("kube: failed to parse response of %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// Get 读取资源
+func (c *Client) Get(ctx context.Context, path string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, path, nil, out)
+}
+
+// do 发送请求并返回响应内容
+func (c *Client) do(ctx context.Context, method, path string, in interface{}) ([]byte, error) {
+	if c.raw != nil {
+		if method != http.MethodGet {
+			return nil, fmt.Errorf("kube: %s %s is not supported by a read-only client", method, path)
+		}
+		return c.raw(ctx, path)
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kube: %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		statusErr := &StatusError{}
+		if json.Unmarshal(data, statusErr) != nil || statusErr.Message == "" {
+			statusErr.Message = strings.TrimSpace(string(data))
+		}
+		statusErr.Code = resp.StatusCode
+		return nil, statusErr
+	}
+	return data, nil
+}
+
+// ServerVersion API Server的版本，如v1.30.2
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := c.Get(ctx, "/version", &version); err != nil {
+		return "", err
+	}
+	return version.GitVersion, nil
+}
+
+// ListNodes 列出所有节点
+func (c *Client) ListNodes(ctx context.Context) ([]Node, error) {
+	var list struct {
+		Items []Node `json:"items"`
+	}
+	err := c.Get(ctx, "/api/v1/nodes", &list)
+	return list.Items, err
+}
+
+// ListPods 列出命名空间中的Pod，namespace为空时列出所有命名空间
+func (c *Client) ListPods(ctx context.Context, namespace string) ([]Pod, error) {
+	path := "/api/v1/pods"
+	if namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods"
+	}
+	var list struct {
+		Items []Pod `json:"items"`
+	}
+	err := c.Get(ctx, path, &list)
+	return list.Items, err
+}
+
+// ListDaemonSets 列出所有命名空间的DaemonSet
+func (c *Client) ListDaemonSets(ctx context.Context) ([]DaemonSet, error) {
+	var list struct {
+		Items []DaemonSet `json:"items"`
+	}
+	err := c.Get(ctx, "/apis/apps/v1/daemonsets", &list)
+	return list.Items, err
+}
+
+// ListEvents 列出命名空间中的事件，namespace为空时列出所有命名空间，fieldSelector如type=Warning
+func (c *Client) ListEvents(ctx context.Context, namespace, fieldSelector string) ([]Event, error) {
+	path := "/api/v1/events"
+	if namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/events"
+	}
+	if fieldSelector != "" {
+		path += "?fieldSelector=" + url.QueryEscape(fieldSelector)
+	}
+	var list struct {
+		Items []Event `json:"items"`
+	}
+	err := c.Get(ctx, path, &list)
+	return list.Items, err
+}
+
+// HealthCheck API Server /readyz?verbose中的单项检查，如etcd、poststarthook/rbac/bootstrap-roles
+type HealthCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// Readyz 读取API Server及其依赖组件（etcd、informer同步等）的就绪检查，检查未通过时API Server返回500，
+// 此时仍从响应中解析各项结果
+func (c *Client) Readyz(ctx context.Context) ([]HealthCheck, error) {
+	data, err := c.do(ctx, http.MethodGet, "/readyz?verbose", nil)
+	text := string(data)
+	if err != nil {
+		text = err.Error()
+	}
+	checks := parseHealthChecks(text)
+	if len(checks) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("kube: no checks found in /readyz response")
+	}
+	return checks, nil
+}
+
+// parseHealthChecks 解析"[+]etcd ok"、"[-]etcd failed: reason withheld"形式的检查结果，
+// kubectl输出的错误中换行被转义为\n
+func parseHealthChecks(text string) []HealthCheck {
+	text = strings.ReplaceAll(text, `\n`, "\n")
+	var checks []HealthCheck
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "[+]"); i >= 0 {
+			name, _, _ := strings.Cut(line[i+3:], " ")
+			checks = append(checks, HealthCheck{Name: name, OK: true})
+		} else if i := strings.Index(line, "[-]"); i >= 0 {
+			name, message, _ := strings.Cut(line[i+3:], " ")
+			checks = append(checks, HealthCheck{Name: name, Message: strings.TrimSpace(message)})
+		}
+	}
+	return checks
+}
+
+// podPath Pod的资源路径
+func podPath(namespace, name string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods/" + url.PathEscape(name)
+}
+
+// CreatePod 创建Pod，namespace取自pod.Metadata.Namespace
+func (c *Client) CreatePod(ctx context.Context, pod Pod) (*Pod, error) {
+	var created Pod
+	err := c.Do(ctx, http.MethodPost, "/api/v1/namespaces/"+url.PathEscape(pod.Metadata.Namespace)+"/pods", pod, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetPod 读取Pod
+func (c *Client) GetPod(ctx context.Context, namespace, name string) (*Pod, error) {
+	var pod Pod
+	if err := c.Get(ctx, podPath(namespace, name), &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// DeletePod 立即删除Pod，Pod不存在时不返回错误
+func (c *Client) DeletePod(ctx context.Context, namespace, name string) error {
+	err := c.Do(ctx, http.MethodDelete, podPath(namespace, name)+"?gracePeriodSeconds=0", nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// PodLogs 读取Pod第一个容器的日志
+func (c *Client) PodLogs(ctx context.Context, namespace, name string) (string, error) {
+	data, err := c.do(ctx, http.MethodGet, podPath(namespace, name)+"/log", nil)
+	return strings.TrimSpace(string(data)), err
+}
@@ -0,0 +1,100 @@
+package kube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testKubeconfig 生成kubectl config view --raw --flatten -o json格式的kubeconfig，user为用户字段
+func testKubeconfig(t *testing.T, server, caData string, user map[string]interface{}) []byte {
+	t.Helper()
+	config := map[string]interface{}{
+		"current-context": "admin@kubernetes",
+		"clusters": []map[string]interface{}{{
+			"name":    "kubernetes",
+			"cluster": map[string]interface{}{"server": server, "certificate-authority-data": caData},
+		}},
+		"users": []map[string]interface{}{{"name": "admin", "user": user}},
+		"contexts": []map[string]interface{}{{
+			"name":    "admin@kubernetes",
+			"context": map[string]interface{}{"cluster": "kubernetes", "user": "admin"},
+		}},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestNewClientToken(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"gitVersion":"v1.30.2"}`))
+	}))
+	defer server.Close()
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	client, err := NewClient(testKubeconfig(t, server.URL, ca, map[string]interface{}{"token": "test-token"}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := client.ServerVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "v1.30.2" {
+		t.Fatalf("version = %q", version)
+	}
+
+	client, err = NewClient(testKubeconfig(t, server.URL, ca, map[string]interface{}{"token": "wrong"}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ServerVersion(context.Background()); !IsUnauthorized(err) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+}
+
+func TestNewClientUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		user map[string]interface{}
+	}{
+		{"exec", map[string]interface{}{"exec": map[string]interface{}{"apiVersion": "client.authentication.k8s.io/v1", "command": "aws"}}},
+		{"auth-provider", map[string]interface{}{"auth-provider": map[string]interface{}{"name": "oidc"}}},
+		{"client certificate file", map[string]interface{}{"client-certificate": "/etc/kubernetes/pki/admin.crt", "client-key": "/etc/kubernetes/pki/admin.key"}},
+		{"token file", map[string]interface{}{"tokenFile": "/var/run/token"}},
+		{"basic auth", map[string]interface{}{"username": "admin", "password": "secret"}},
+		{"impersonation", map[string]interface{}{"token": "test-token", "as": "system:admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(testKubeconfig(t, "https://10.0.0.10:6443", "", tt.user), "")
+			if !errors.Is(err, ErrUnsupportedKubeconfig) {
+				t.Fatalf("err = %v, want ErrUnsupportedKubeconfig", err)
+			}
+		})
+	}
+}
+
+func TestNewClientCertificateAuthorityFile(t *testing.T) {
+	config := []byte(`{
+		"current-context": "admin",
+		"clusters": [{"name": "kubernetes", "cluster": {"server": "https://10.0.0.10:6443", "certificate-authority": "/etc/kubernetes/pki/ca.crt"}}],
+		"users": [{"name": "admin", "user": {"token": "test-token"}}],
+		"contexts": [{"name": "admin", "context": {"cluster": "kubernetes", "user": "admin"}}]
+	}`)
+	if _, err := NewClient(config, ""); !errors.Is(err, ErrUnsupportedKubeconfig) {
+		t.Fatalf("err = %v, want ErrUnsupportedKubeconfig", err)
+	}
+}
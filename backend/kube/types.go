@@ -0,0 +1,195 @@
+package kube
+
+import "time"
+
+// ObjectMeta 资源的元数据
+type ObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreationTimestamp time.Time         `json:"creationTimestamp,omitempty"`
+}
+
+// Condition 节点和Pod的状态条件
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// conditionTrue 条件列表中指定类型的条件是否为True
+func conditionTrue(conditions []Condition, conditionType string) bool {
+	for _, c := range conditions {
+		if c.Type == conditionType {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
+
+// Node 集群节点
+type Node struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Unschedulable bool `json:"unschedulable,omitempty"`
+		Taints        []struct {
+			Key    string `json:"key"`
+			Value  string `json:"value,omitempty"`
+			Effect string `json:"effect"`
+		} `json:"taints,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Addresses []struct {
+			Type    string `json:"type"`
+			Address string `json:"address"`
+		} `json:"addresses"`
+		Conditions []Condition `json:"conditions"`
+		NodeInfo   struct {
+			KubeletVersion          string `json:"kubeletVersion"`
+			KernelVersion           string `json:"kernelVersion"`
+			OSImage                 string `json:"osImage"`
+			Architecture            string `json:"architecture"`
+			ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
+		} `json:"nodeInfo"`
+	} `json:"status"`
+}
+
+// Ready 节点是否Ready
+func (n Node) Ready() bool {
+	return conditionTrue(n.Status.Conditions, "Ready")
+}
+
+// InternalIP 节点的第一个InternalIP地址
+func (n Node) InternalIP() string {
+	for _, address := range n.Status.Addresses {
+		if address.Type == "InternalIP" {
+			return address.Address
+		}
+	}
+	return ""
+}
+
+// ControlPlane 节点是否带有控制平面的角色标签
+func (n Node) ControlPlane() bool {
+	_, controlPlane := n.Metadata.Labels["node-role.kubernetes.io/control-plane"]
+	_, master := n.Metadata.Labels["node-role.kubernetes.io/master"]
+	return controlPlane || master
+}
+
+// Schedulable 节点可以调度普通Pod：未被cordon且没有NoSchedule污点
+func (n Node) Schedulable() bool {
+	if n.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range n.Spec.Taints {
+		if taint.Effect == "NoSchedule" {
+			return false
+		}
+	}
+	return true
+}
+
+// Container Pod中的容器
+type Container struct {
+	Name    string   `json:"name"`
+	Image   string   `json:"image"`
+	Command []string `json:"command,omitempty"`
+}
+
+// Pod 容器组
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName      string      `json:"nodeName,omitempty"`
+		RestartPolicy string      `json:"restartPolicy,omitempty"`
+		Containers    []Container `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string      `json:"phase,omitempty"`
+		Reason            string      `json:"reason,omitempty"`
+		PodIP             string      `json:"podIP,omitempty"`
+		Conditions        []Condition `json:"conditions,omitempty"`
+		ContainerStatuses []struct {
+			Name         string `json:"name"`
+			Ready        bool   `json:"ready"`
+			RestartCount int    `json:"restartCount"`
+			State        struct {
+				Waiting *struct {
+					Reason string `json:"reason"`
+				} `json:"waiting,omitempty"`
+				Terminated *struct {
+					Reason string `json:"reason"`
+				} `json:"terminated,omitempty"`
+			} `json:"state"`
+		} `json:"containerStatuses,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+// Pod的阶段
+const (
+	PodPending   = "Pending"
+	PodRunning   = "Running"
+	PodSucceeded = "Succeeded"
+	PodFailed    = "Failed"
+)
+
+// Ready Pod是否Ready
+func (p Pod) Ready() bool {
+	return conditionTrue(p.Status.Conditions, "Ready")
+}
+
+// Restarts 所有容器的重启次数之和
+func (p Pod) Restarts() int {
+	restarts := 0
+	for _, cs := range p.Status.ContainerStatuses {
+		restarts += cs.RestartCount
+	}
+	return restarts
+}
+
+// Reason Pod未就绪的原因，优先使用容器的等待或终止原因，如CrashLoopBackOff、ImagePullBackOff、OOMKilled
+func (p Pod) Reason() string {
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return cs.State.Waiting.Reason
+		}
+		if cs.State.Terminated != nil && cs.State.Terminated.Reason != "" && cs.State.Terminated.Reason != "Completed" {
+			return cs.State.Terminated.Reason
+		}
+	}
+	return p.Status.Reason
+}
+
+// Event 集群事件
+type Event struct {
+	Metadata       ObjectMeta `json:"metadata"`
+	InvolvedObject struct {
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+	} `json:"involvedObject"`
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Count          int       `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+	EventTime      time.Time `json:"eventTime"`
+}
+
+// LastSeen 事件最后一次发生的时间，新版本的事件只设置eventTime
+func (e Event) LastSeen() time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime
+	}
+	return e.Metadata.CreationTimestamp
+}
+
+// DaemonSet 守护进程集，只用到元数据
+type DaemonSet struct {
+	Metadata ObjectMeta `json:"metadata"`
+}
@@ -0,0 +1,229 @@
+package kubeadm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// 可选的集群插件
+const (
+	AddonMetricsServer    = "metrics-server"
+	AddonLocalPathStorage = "local-path-storage"
+	AddonNFSStorage       = "nfs-storage"
+	AddonIngressNginx     = "ingress-nginx"
+)
+
+// AllAddons 支持的插件，按安装顺序排列
+var AllAddons = []string{
+	AddonMetricsServer,
+	AddonLocalPathStorage,
+	AddonNFSStorage,
+	AddonIngressNginx,
+}
+
+// addonCommands 每个插件依次执行的kubectl参数
+var addonCommands = map[string][]string{
+	AddonMetricsServer: {
+		"apply -f https://github.com/kubernetes-sigs/metrics-server/releases/latest/download/components.yaml",
+		// kubeadm默认的kubelet证书未包含节点IP，metrics-server需要跳过kubelet证书校验
+		`-n kube-system patch deployment metrics-server --type=json -p '[{"op":"add","path":"/spec/template/spec/containers/0/args/-","value":"--kubelet-insecure-tls"}]'`,
+	},
+	AddonLocalPathStorage: {
+		"apply -f https://raw.githubusercontent.com/rancher/local-path-provisioner/v0.0.30/deploy/local-path-storage.yaml",
+		`patch storageclass local-path -p '{"metadata":{"annotations":{"storageclass.kubernetes.io/is-default-class":"true"}}}'`,
+	},
+	// NFS存储通过Helm安装，见installNFSStorage
+	AddonNFSStorage: nil,
+	AddonIngressNginx: {
+		"apply -f https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-v1.11.2/deploy/static/provider/baremetal/deploy.yaml",
+	},
+}
+
+// storageAddons 提供默认StorageClass的插件，安装后校验StorageClass和provisioner状态
+var storageAddons = map[string]struct {
+	storageClass string
+	namespace    string
+	deployment   string
+}{
+	AddonLocalPathStorage: {"local-path", "local-path-storage", "local-path-provisioner"},
+	AddonNFSStorage:       {nfsStorageClass, nfsNamespace, "nfs-subdir-external-provisioner"},
+}
+
+const (
+	nfsStorageClass = "nfs-client"
+	nfsNamespace    = "nfs-provisioner"
+	nfsChartRepo    = "https://kubernetes-sigs.github.io/nfs-subdir-external-provisioner/"
+)
+
+var (
+	nfsServerPattern = regexp.MustCompile(`^[A-Za-z0-9.\-:\[\]]+$`)
+	nfsPathPattern   = regexp.MustCompile(`^/[A-Za-z0-9._/\-]*$`)
+)
+
+// AddonOptions 需要额外参数的插件配置
+type AddonOptions struct {
+	// NFSServer、NFSPath nfs-storage插件使用的NFS服务器地址和导出目录
+	NFSServer string `json:"nfsServer"`
+	NFSPath   string `json:"nfsPath"`
+}
+
+// IsValidAddon 检查插件名称是否有效
+func IsValidAddon(name string) bool {
+	_, ok := addonCommands[name]
+	return ok
+}
+
+// ValidateAddons 校验插件名称以及插件所需的参数
+func ValidateAddons(addons []string, opts AddonOptions) error {
+	defaultStorage := 0
+	for _, addon := range addons {
+		if !IsValidAddon(addon) {
+			return fmt.Errorf("invalid addon: %s", addon)
+		}
+		if _, ok := storageAddons[addon]; ok {
+			defaultStorage++
+		}
+	}
+	if defaultStorage > 1 {
+		return fmt.Errorf("only one of %s and %s can be installed as default storage", AddonLocalPathStorage, AddonNFSStorage)
+	}
+	if containsStep(addons, AddonNFSStorage) {
+		if !nfsServerPattern.MatchString(opts.NFSServer) {
+			return fmt.Errorf("invalid nfs server: %s", opts.NFSServer)
+		}
+		if !nfsPathPattern.MatchString(opts.NFSPath) {
+			return fmt.Errorf("invalid nfs path: %s", opts.NFSPath)
+		}
+	}
+	return nil
+}
+
+// InstallAddons 在master节点上按AllAddons的顺序安装指定插件，nodes为集群所有节点，
+// NFS存储插件需要在每个节点上安装NFS客户端
+func InstallAddons(client ssh.Runner, nodes []node.Node, addons []string, opts AddonOptions, logf func(msg string)) error {
+	for _, addon := range AllAddons {
+		if !containsStep(addons, addon) {
+			continue
+		}
+		logf(fmt.Sprintf("=== 安装插件 %s ===", addon))
+		if addon == AddonNFSStorage {
+			if err := installNFSStorage(client, nodes, opts, logf); err != nil {
+				return fmt.Errorf("安装插件 %s 失败: %v", addon, err)
+			}
+		}
+		for _, args := range addonCommands[addon] {
+			output, err := runKubectl(client, args)
+			if output != "" {
+				logf(output)
+			}
+			if err != nil {
+				return fmt.Errorf("安装插件 %s 失败: %v", addon, err)
+			}
+		}
+		if _, ok := storageAddons[addon]; ok {
+			if err := verifyDefaultStorage(client, addon, logf); err != nil {
+				return fmt.Errorf("插件 %s 校验失败: %v", addon, err)
+			}
+		}
+		logf(fmt.Sprintf("✓ 插件 %s 安装完成", addon))
+	}
+	return nil
+}
+
+// nfsClientInstallCmd 安装挂载NFS卷所需的客户端工具
+const nfsClientInstallCmd = `if command -v mount.nfs &> /dev/null; then
+    echo "NFS客户端已安装"
+elif command -v apt-get &> /dev/null; then
+    sudo apt-get install -y nfs-common
+elif command -v zypper &> /dev/null; then
+    sudo zypper --non-interactive install -y nfs-client
+elif command -v dnf &> /dev/null; then
+    sudo dnf install -y nfs-utils
+else
+    sudo yum install -y nfs-utils
+fi`
+
+// installNFSStorage 在所有节点上安装NFS客户端，然后通过Helm安装nfs-subdir-external-provisioner并设为默认StorageClass
+func installNFSStorage(client ssh.Runner, nodes []node.Node, opts AddonOptions, logf func(msg string)) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []string
+	sem := make(chan struct{}, node.DefaultExecConcurrency)
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n node.Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			nodeClient, err := node.Connect(n)
+			if err == nil {
+				_, err = nodeClient.RunCommandSilent(nfsClientInstallCmd)
+				nodeClient.Close()
+			}
+			if err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", n.Name, err))
+				mu.Unlock()
+				return
+			}
+			logf(fmt.Sprintf("[%s] NFS客户端已就绪", n.Name))
+		}(n)
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		return fmt.Errorf("安装NFS客户端失败: %s", strings.Join(failed, "; "))
+	}
+
+	values := fmt.Sprintf(`nfs:
+  server: %s
+  path: %s
+storageClass:
+  name: %s
+  defaultClass: true
+`, yamlString(opts.NFSServer), yamlString(opts.NFSPath), nfsStorageClass)
+	_, err := installHelmChart(client, HelmChartOptions{
+		ReleaseName: "nfs-subdir-external-provisioner",
+		Namespace:   nfsNamespace,
+		RepoURL:     nfsChartRepo,
+		Chart:       "nfs-subdir-external-provisioner",
+		Values:      values,
+	}, logf)
+	return err
+}
+
+// verifyDefaultStorage 等待存储插件的provisioner就绪，并确认其StorageClass是唯一的默认StorageClass
+func verifyDefaultStorage(client ssh.Runner, addon string, logf func(msg string)) error {
+	storage := storageAddons[addon]
+	if _, err := runKubectl(client, fmt.Sprintf("-n %s rollout status deployment/%s --timeout=300s", storage.namespace, storage.deployment)); err != nil {
+		return fmt.Errorf("provisioner %s 未就绪: %v", storage.deployment, err)
+	}
+
+	output, err := runKubectl(client, `get storageclass -o jsonpath='{range .items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")]}{.metadata.name}{"\n"}{end}'`)
+	if err != nil {
+		return fmt.Errorf("获取StorageClass失败: %v", err)
+	}
+	defaults := strings.Fields(output)
+	if !containsStep(defaults, storage.storageClass) {
+		return fmt.Errorf("StorageClass %s 未被设置为默认", storage.storageClass)
+	}
+	if len(defaults) > 1 {
+		logf(fmt.Sprintf("警告: 集群中存在多个默认StorageClass: %s", strings.Join(defaults, ", ")))
+	}
+	logf(fmt.Sprintf("✓ 默认StorageClass: %s", storage.storageClass))
+	return nil
+}
+
+// AllowControlPlaneScheduling 移除控制平面节点的污点，使单节点集群可以调度普通Pod
+func AllowControlPlaneScheduling(client ssh.Runner) (string, error) {
+	// 不同版本使用control-plane或master污点，污点不存在时kubectl返回错误，逐个移除并忽略not found
+	output, err := client.RunCommandSilent(kubectlCmd + ` taint nodes --all node-role.kubernetes.io/control-plane:NoSchedule- 2>&1 | grep -v "not found" ; ` +
+		kubectlCmd + ` taint nodes --all node-role.kubernetes.io/master:NoSchedule- 2>&1 | grep -v "not found" ; ` +
+		kubectlCmd + ` get nodes -o jsonpath='{range .items[*]}{.metadata.name}{" taints="}{.spec.taints}{"\n"}{end}'`)
+	return output, err
+}
@@ -0,0 +1,195 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"k8s-installer/kube"
+	"k8s-installer/ssh"
+	"strings"
+)
+
+// k3sKubectlCmd k3s集群的kubectl，kubeconfig位于k3s的默认路径
+var k3sKubectlCmd = "sudo kubectl --kubeconfig=" + K3sKubeconfigPath
+
+// cniDaemonSets CNI插件的DaemonSet名称前缀
+var cniDaemonSets = []struct {
+	prefix string
+	cni    string
+}{
+	{"calico-node", "calico"},
+	{"canal", "canal"},
+	{"kube-flannel", "flannel"},
+	{"cilium", "cilium"},
+	{"weave-net", "weave"},
+	{"kube-router", "kube-router"},
+	{"antrea-agent", "antrea"},
+}
+
+// DiscoveredNode 已有集群中的节点
+type DiscoveredNode struct {
+	Name             string `json:"name"`
+	IP               string `json:"ip"`
+	NodeType         string `json:"nodeType"`
+	Ready            bool   `json:"ready"`
+	KubeletVersion   string `json:"kubeletVersion"`
+	OSImage          string `json:"osImage"`
+	OS               string `json:"os"`
+	Arch             string `json:"arch"`
+	ContainerRuntime string `json:"containerRuntime"`
+}
+
+// ClusterDiscovery 从master节点读取的已有集群状态
+type ClusterDiscovery struct {
+	Version              string           `json:"version"`
+	InstallerType        string           `json:"installerType"`
+	CNI                  string           `json:"cni,omitempty"`
+	ControlPlaneEndpoint string           `json:"controlPlaneEndpoint,omitempty"`
+	Nodes                []DiscoveredNode `json:"nodes"`
+}
+
+// DiscoverClusterRemote 连接master节点读取已有集群的状态
+func DiscoverClusterRemote(sshConfig SSHConfig) (*ClusterDiscovery, error) {
+	client, err := ssh.NewSSHClient(ssh.SSHConfig{
+		Host:          sshConfig.Host,
+		Port:          sshConfig.Port,
+		Username:      sshConfig.Username,
+		Password:      sshConfig.Password,
+		PrivateKey:    sshConfig.PrivateKey,
+		PasswordRef:   sshConfig.PasswordRef,
+		PrivateKeyRef: sshConfig.PrivateKeyRef,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH client: %v", err)
+	}
+	defer client.Close()
+
+	return DiscoverCluster(client)
+}
+
+// DiscoverCluster 通过master节点上的kubectl读取集群版本、节点和CNI插件，
+// 依次尝试kubeadm的admin.conf和k3s的kubeconfig
+func DiscoverCluster(client ssh.Runner) (*ClusterDiscovery, error) {
+	kubectl, err := masterKubectl(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes with kubectl, is this a master node? %v", err)
+	}
+	discovery, err := DiscoverClusterAPI(context.Background(), kubectlClient(client, kubectl))
+	if err != nil {
+		return nil, err
+	}
+
+	// 只读客户端没有API Server地址，从kubeconfig中读取
+	if output, err := client.RunCommandSilent(kubectl + ` config view --minify -o jsonpath='{.clusters[0].cluster.server}'`); err == nil {
+		discovery.ControlPlaneEndpoint = strings.TrimPrefix(strings.TrimSpace(output), "https://")
+	}
+	return discovery, nil
+}
+
+// DiscoverClusterAPI 通过API Server读取集群版本、节点和CNI插件
+func DiscoverClusterAPI(ctx context.Context, client *kube.Client) (*ClusterDiscovery, error) {
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	discovery, err := discoveryFromNodes(nodes)
+	if err != nil {
+		return nil, err
+	}
+	if version, err := client.ServerVersion(ctx); err == nil {
+		discovery.Version = version
+	}
+	if server := client.Server(); server != "" {
+		discovery.ControlPlaneEndpoint = strings.TrimPrefix(server, "https://")
+	}
+
+	var daemonSets []string
+	if items, err := client.ListDaemonSets(ctx); err == nil {
+		for _, ds := range items {
+			daemonSets = append(daemonSets, ds.Metadata.Name)
+		}
+	}
+	discovery.complete(daemonSets)
+	return discovery, nil
+}
+
+// discoveryFromNodes 根据集群节点列表识别节点角色和安装方式
+func discoveryFromNodes(nodes []kube.Node) (*ClusterDiscovery, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("cluster has no nodes")
+	}
+	discovery := &ClusterDiscovery{InstallerType: InstallerTypeKubeadm}
+	for _, item := range nodes {
+		n := DiscoveredNode{
+			Name:             item.Metadata.Name,
+			IP:               item.InternalIP(),
+			NodeType:         "worker",
+			Ready:            item.Ready(),
+			KubeletVersion:   item.Status.NodeInfo.KubeletVersion,
+			OSImage:          item.Status.NodeInfo.OSImage,
+			OS:               osFromImage(item.Status.NodeInfo.OSImage),
+			Arch:             item.Status.NodeInfo.Architecture,
+			ContainerRuntime: runtimeFromVersion(item.Status.NodeInfo.ContainerRuntimeVersion),
+		}
+		if item.ControlPlane() {
+			n.NodeType = "master"
+		}
+		if strings.Contains(n.KubeletVersion, "+k3s") {
+			discovery.InstallerType = InstallerTypeK3s
+		}
+		discovery.Nodes = append(discovery.Nodes, n)
+	}
+	return discovery, nil
+}
+
+// complete 补全无法获取的集群版本，并根据DaemonSet名称识别CNI插件
+func (d *ClusterDiscovery) complete(daemonSets []string) {
+	// 集群版本以apiserver为准，无法获取时使用master节点的kubelet版本
+	if d.Version == "" {
+		for _, n := range d.Nodes {
+			if n.NodeType == "master" {
+				d.Version = n.KubeletVersion
+				break
+			}
+		}
+	}
+	d.CNI = cniFromDaemonSets(daemonSets)
+	// k3s默认内置flannel，没有单独的DaemonSet
+	if d.CNI == "" && d.InstallerType == InstallerTypeK3s {
+		d.CNI = "flannel"
+	}
+}
+
+// cniFromDaemonSets 根据DaemonSet名称识别CNI插件
+func cniFromDaemonSets(names []string) string {
+	for _, known := range cniDaemonSets {
+		for _, name := range names {
+			if strings.HasPrefix(name, known.prefix) {
+				return known.cni
+			}
+		}
+	}
+	return ""
+}
+
+// osFromImage 根据节点的osImage（如"Ubuntu 22.04.3 LTS"）识别操作系统类型
+func osFromImage(image string) string {
+	lower := strings.ToLower(image)
+	for _, known := range []struct{ keyword, os string }{
+		{"ubuntu", "ubuntu"}, {"debian", "debian"}, {"centos", "centos"},
+		{"rocky", "rocky"}, {"alma", "alma"}, {"red hat", "rhel"},
+	} {
+		if strings.Contains(lower, known.keyword) {
+			return known.os
+		}
+	}
+	return "unknown"
+}
+
+// runtimeFromVersion 从containerRuntimeVersion（如"containerd://1.7.2"）中取出运行时类型
+func runtimeFromVersion(version string) string {
+	runtime, _, found := strings.Cut(version, "://")
+	if !found {
+		return ""
+	}
+	return runtime
+}
@@ -0,0 +1,102 @@
+package kubeadm
+
+import (
+	"fmt"
+	"time"
+
+	"k8s-installer/node"
+)
+
+// 任务产物类型
+const (
+	// ArtifactInitOutput kubeadm init的完整输出
+	ArtifactInitOutput = "kubeadm_init_output"
+	// ArtifactJoinCommand worker节点加入集群的join命令
+	ArtifactJoinCommand = "join_command"
+	// ArtifactCertificateKey kubeadm init --upload-certs输出的证书密钥，控制平面节点加入时使用
+	ArtifactCertificateKey = "certificate_key"
+	// ArtifactKubeconfig 集群管理员kubeconfig的位置，只保存引用，不保存文件内容
+	ArtifactKubeconfig = "kubeconfig"
+)
+
+// AdminKubeconfigPath kubeadm生成的管理员kubeconfig路径
+const AdminKubeconfigPath = "/etc/kubernetes/admin.conf"
+
+// Artifact 部署任务产生的产物，按任务、类型和节点保存，同一节点的同类产物只保留最新一份
+type Artifact struct {
+	JobID     string    `json:"jobId"`
+	NodeID    string    `json:"nodeId"`
+	Kind      string    `json:"kind"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// kubeconfigRef 节点上kubeconfig文件的引用，如root@192.168.1.10:22:/etc/kubernetes/admin.conf
+func kubeconfigRef(n node.Node, path string) string {
+	return fmt.Sprintf("%s@%s:%d:%s", n.Username, n.IP, n.Port, path)
+}
+
+// emitArtifact 通过OnArtifact回调输出产物，内容为空时忽略
+func emitArtifact(opts DeployOptions, nodeID, kind, content string) {
+	if opts.OnArtifact == nil || content == "" {
+		return
+	}
+	opts.OnArtifact(Artifact{NodeID: nodeID, Kind: kind, Content: content, CreatedAt: time.Now()})
+}
+
+// createArtifactsTable 创建任务产物表
+func (s *DeploymentStore) createArtifactsTable() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_artifacts (
+		job_id TEXT NOT NULL,
+		node_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (job_id, node_id, kind)
+	);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create job_artifacts table: %v", err)
+	}
+	return nil
+}
+
+// SaveArtifact 保存任务产物，替换同一任务、节点的同类产物
+func (s *DeploymentStore) SaveArtifact(jobID string, a Artifact) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	if _, err := s.db.Exec(
+		"INSERT OR REPLACE INTO job_artifacts (job_id, node_id, kind, content, created_at) VALUES (?, ?, ?, ?, ?)",
+		jobID, a.NodeID, a.Kind, a.Content, a.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to save artifact: %v", err)
+	}
+	return nil
+}
+
+// ListArtifacts 获取任务的所有产物，按产生时间排序
+func (s *DeploymentStore) ListArtifacts(jobID string) ([]Artifact, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rows, err := s.db.Query("SELECT job_id, node_id, kind, content, created_at FROM job_artifacts WHERE job_id = ? ORDER BY created_at", jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query artifacts: %v", err)
+	}
+	defer rows.Close()
+
+	artifacts := []Artifact{}
+	for rows.Next() {
+		var a Artifact
+		if err := rows.Scan(&a.JobID, &a.NodeID, &a.Kind, &a.Content, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan artifact: %v", err)
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, rows.Err()
+}
@@ -0,0 +1,187 @@
+package kubeadm
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s-installer/node"
+)
+
+// 节点cgroup版本
+const (
+	CgroupV1      = "v1"
+	CgroupV2      = "v2"
+	CgroupUnknown = "unknown"
+)
+
+// kubelet和containerd使用的cgroup驱动
+const (
+	CgroupDriverSystemd  = "systemd"
+	CgroupDriverCgroupfs = "cgroupfs"
+)
+
+// cgroup v1支持的Kubernetes版本：1.31起进入维护模式，1.35起kubelet默认拒绝在cgroup v1上启动
+const (
+	cgroupV1MaintenanceMinor = 31
+	cgroupV1UnsupportedMinor = 35
+)
+
+// containerdConfigPath containerd配置文件
+const containerdConfigPath = "/etc/containerd/config.toml"
+
+// DetectCgroupCmd 检测节点的cgroup版本、init系统，以及containerd和kubelet当前使用的cgroup驱动
+const DetectCgroupCmd = `case "$(stat -fc %T /sys/fs/cgroup/ 2>/dev/null)" in
+    cgroup2fs) echo "CGROUP v2" ;;
+    tmpfs) echo "CGROUP v1" ;;
+    *) echo "CGROUP unknown" ;;
+esac
+if [ -d /run/systemd/system ]; then
+    echo "INIT systemd"
+else
+    echo "INIT $(ps -p 1 -o comm= 2>/dev/null || echo unknown)"
+fi
+if [ -f ` + containerdConfigPath + ` ]; then
+    echo "CONTAINERD_SYSTEMD_CGROUP $(grep -E '^\s*SystemdCgroup\s*=' ` + containerdConfigPath + ` | head -n 1 | cut -d= -f2 | tr -d ' ')"
+fi
+if [ -f /var/lib/kubelet/config.yaml ]; then
+    echo "KUBELET_CGROUP_DRIVER $(awk '$1 == "cgroupDriver:" {print $2}' /var/lib/kubelet/config.yaml | tr -d '"')"
+fi`
+
+// CgroupInfo 节点的cgroup检测结果
+type CgroupInfo struct {
+	Version string `json:"version"`
+	// Systemd 节点使用systemd作为init系统
+	Systemd bool `json:"systemd"`
+	// ContainerdSystemdCgroup containerd配置中SystemdCgroup的值，未安装containerd时为空
+	ContainerdSystemdCgroup string `json:"containerdSystemdCgroup,omitempty"`
+	// KubeletCgroupDriver 已加入集群的节点上kubelet使用的cgroup驱动
+	KubeletCgroupDriver string `json:"kubeletCgroupDriver,omitempty"`
+}
+
+// ParseCgroupInfo 解析DetectCgroupCmd的输出
+func ParseCgroupInfo(output string) CgroupInfo {
+	info := CgroupInfo{Version: CgroupUnknown}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "CGROUP":
+			info.Version = fields[1]
+		case "INIT":
+			info.Systemd = fields[1] == "systemd"
+		case "CONTAINERD_SYSTEMD_CGROUP":
+			info.ContainerdSystemdCgroup = fields[1]
+		case "KUBELET_CGROUP_DRIVER":
+			info.KubeletCgroupDriver = fields[1]
+		}
+	}
+	return info
+}
+
+// Driver 节点应使用的cgroup驱动：systemd管理的节点使用systemd驱动，避免两个cgroup管理器同时工作
+func (info CgroupInfo) Driver() string {
+	if info.Systemd {
+		return CgroupDriverSystemd
+	}
+	return CgroupDriverCgroupfs
+}
+
+// Check 检查cgroup版本是否支持所选的Kubernetes版本，不支持时返回错误及处理建议，可以继续部署的问题作为警告返回
+func (info CgroupInfo) Check(kubeVersion string) (warnings []string, err error) {
+	_, minor, ok := parseMajorMinor(kubeVersion)
+	switch info.Version {
+	case CgroupV2:
+	case CgroupV1:
+		if ok && minor >= cgroupV1UnsupportedMinor {
+			return nil, fmt.Errorf("kubelet %s does not run on cgroup v1 by default: enable cgroup v2 by adding systemd.unified_cgroup_hierarchy=1 to the kernel command line and rebooting, or choose Kubernetes v1.%d or earlier", kubeVersion, cgroupV1UnsupportedMinor-1)
+		}
+		if ok && minor >= cgroupV1MaintenanceMinor {
+			warnings = append(warnings, fmt.Sprintf("cgroup v1 support is in maintenance mode since Kubernetes v1.%d and will be removed, migrate the node to cgroup v2", cgroupV1MaintenanceMinor))
+		}
+	default:
+		return nil, fmt.Errorf("cannot detect the cgroup version: /sys/fs/cgroup is not mounted as cgroup v1 or v2")
+	}
+
+	driver := info.Driver()
+	if !info.Systemd {
+		warnings = append(warnings, "node is not managed by systemd, containerd and kubelet will use the cgroupfs driver")
+	}
+	if info.KubeletCgroupDriver != "" && info.KubeletCgroupDriver != driver {
+		warnings = append(warnings, fmt.Sprintf("kubelet uses cgroupDriver %s but the node requires %s, reset and rejoin the node to change it", info.KubeletCgroupDriver, driver))
+	}
+	return warnings, nil
+}
+
+// ClusterCgroupDriver 集群使用的cgroup驱动。kubelet配置由kubeadm保存在kubelet-config ConfigMap中，所有节点使用相同的驱动
+func ClusterCgroupDriver(infos map[string]CgroupInfo) (string, error) {
+	driver := ""
+	for nodeID, info := range infos {
+		if driver == "" {
+			driver = info.Driver()
+		} else if info.Driver() != driver {
+			return "", fmt.Errorf("nodes require different cgroup drivers (%s requires %s, others require %s), use systemd on all nodes", nodeID, info.Driver(), driver)
+		}
+	}
+	return driver, nil
+}
+
+// AlignContainerdCgroupCmd 将containerd配置中的SystemdCgroup设置为与cgroup驱动一致，配置变化时重启containerd
+func AlignContainerdCgroupCmd(driver string) string {
+	want := "false"
+	if driver == CgroupDriverSystemd {
+		want = "true"
+	}
+	return fmt.Sprintf(`CONFIG=%[1]s
+WANT=%[2]s
+if [ ! -f $CONFIG ]; then
+    echo "⚠ 未找到$CONFIG，跳过containerd cgroup驱动对齐"
+    exit 0
+fi
+if grep -qE '^\s*SystemdCgroup\s*=' $CONFIG; then
+    if ! grep -E '^\s*SystemdCgroup\s*=' $CONFIG | grep -vqE "=\s*$WANT\s*$"; then
+        echo "✓ containerd已使用SystemdCgroup = $WANT"
+        exit 0
+    fi
+    sudo sed -i -E "s/^(\s*)SystemdCgroup\s*=.*/\1SystemdCgroup = $WANT/" $CONFIG
+elif grep -qE '^\s*\[plugins\..*runtimes\.runc\.options\]' $CONFIG; then
+    sudo sed -i -E "/^\s*\[plugins\..*runtimes\.runc\.options\]/a\            SystemdCgroup = $WANT" $CONFIG
+else
+    echo "✗ containerd配置中没有runc运行时的options段，无法设置SystemdCgroup，请检查$CONFIG"
+    exit 1
+fi
+sudo systemctl restart containerd
+echo "✓ containerd已设置SystemdCgroup = $WANT（cgroup驱动: %[3]s）"`, containerdConfigPath, want, driver)
+}
+
+// CgroupReport 单个节点的cgroup检查结果
+type CgroupReport struct {
+	NodeID   string     `json:"nodeId"`
+	NodeName string     `json:"nodeName"`
+	Passed   bool       `json:"passed"`
+	Info     CgroupInfo `json:"info"`
+	// CgroupDriver 节点应使用的cgroup驱动
+	CgroupDriver string   `json:"cgroupDriver,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// BuildCgroupReport 根据DetectCgroupCmd的执行结果生成节点的cgroup检查结果
+func BuildCgroupReport(n node.Node, res node.ExecResult, kubeVersion string) CgroupReport {
+	report := CgroupReport{NodeID: n.ID, NodeName: n.Name}
+	if !res.Success {
+		report.Error = res.Error
+		return report
+	}
+	report.Info = ParseCgroupInfo(res.Output)
+	warnings, err := report.Info.Check(kubeVersion)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Passed = true
+	report.CgroupDriver = report.Info.Driver()
+	report.Warnings = warnings
+	return report
+}
@@ -0,0 +1,212 @@
+package kubeadm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s-installer/errcode"
+	"k8s-installer/node"
+)
+
+// OSSupport 发行版版本支持的Kubernetes版本范围，MinKube和MaxKube为次版本号如1.24，为空时不限制
+type OSSupport struct {
+	Distro  string `json:"distro"`
+	Version string `json:"version"`
+	MinKube string `json:"minKube,omitempty"`
+	MaxKube string `json:"maxKube,omitempty"`
+}
+
+// ContainerdSupport containerd版本系列支持的Kubernetes版本范围
+type ContainerdSupport struct {
+	Version string `json:"version"`
+	MinKube string `json:"minKube,omitempty"`
+	MaxKube string `json:"maxKube,omitempty"`
+}
+
+// Compatibility Kubernetes版本与发行版、containerd版本的兼容性矩阵
+type Compatibility struct {
+	OS         []OSSupport         `json:"os"`
+	Containerd []ContainerdSupport `json:"containerd"`
+}
+
+// CompatibilityMatrix 部署前检查使用的兼容性矩阵。发行版版本按VERSION_ID匹配，8匹配8.x；
+// 不在矩阵中的发行版只给出警告，矩阵中发行版的其他版本视为不支持
+var CompatibilityMatrix = Compatibility{
+	OS: []OSSupport{
+		// 旧发行版的glibc、systemd和软件源不再满足新版本Kubernetes软件包的要求
+		{Distro: "ubuntu", Version: "18.04", MaxKube: "1.28"},
+		{Distro: "ubuntu", Version: "20.04", MinKube: "1.24"},
+		{Distro: "ubuntu", Version: "22.04", MinKube: "1.24"},
+		{Distro: "ubuntu", Version: "24.04", MinKube: "1.29"},
+		{Distro: "debian", Version: "10", MaxKube: "1.28"},
+		{Distro: "debian", Version: "11", MinKube: "1.24"},
+		{Distro: "debian", Version: "12", MinKube: "1.26"},
+		{Distro: "centos", Version: "7", MaxKube: "1.28"},
+		{Distro: "centos", Version: "8", MinKube: "1.24", MaxKube: "1.30"},
+		{Distro: "centos", Version: "9", MinKube: "1.24"},
+		{Distro: "rhel", Version: "8", MinKube: "1.24"},
+		{Distro: "rhel", Version: "9", MinKube: "1.24"},
+		{Distro: "rocky", Version: "8", MinKube: "1.24"},
+		{Distro: "rocky", Version: "9", MinKube: "1.24"},
+		{Distro: "almalinux", Version: "8", MinKube: "1.24"},
+		{Distro: "almalinux", Version: "9", MinKube: "1.24"},
+		{Distro: "amzn", Version: "2", MaxKube: "1.30"},
+		{Distro: "amzn", Version: "2023", MinKube: "1.25"},
+		{Distro: "opensuse-leap", Version: "15", MinKube: "1.24"},
+		{Distro: "sles", Version: "15", MinKube: "1.24"},
+	},
+	Containerd: []ContainerdSupport{
+		// Kubernetes 1.26起只支持CRI v1，containerd 1.5只提供v1alpha2
+		{Version: "1.5", MaxKube: "1.25"},
+		{Version: "1.6", MinKube: "1.23", MaxKube: "1.29"},
+		{Version: "1.7", MinKube: "1.24"},
+		{Version: "2", MinKube: "1.30"},
+	},
+}
+
+// CompatResult 节点的兼容性检查结果，Alternatives为可选的发行版版本或Kubernetes版本
+type CompatResult struct {
+	NodeID            string   `json:"nodeId,omitempty"`
+	NodeName          string   `json:"nodeName,omitempty"`
+	Distro            string   `json:"distro"`
+	Version           string   `json:"version,omitempty"`
+	ContainerdVersion string   `json:"containerdVersion,omitempty"`
+	Compatible        bool     `json:"compatible"`
+	Errors            []string `json:"errors,omitempty"`
+	Warnings          []string `json:"warnings,omitempty"`
+	Alternatives      []string `json:"alternatives,omitempty"`
+}
+
+// kubeInRange Kubernetes次版本号是否在[min, max]范围内，范围边界为空时不限制
+func kubeInRange(minor int, min, max string) bool {
+	if _, m, ok := parseMajorMinor(min); ok && minor < m {
+		return false
+	}
+	if _, m, ok := parseMajorMinor(max); ok && minor > m {
+		return false
+	}
+	return true
+}
+
+// kubeRange 返回版本范围的说明，如1.24+、1.24-1.28
+func kubeRange(min, max string) string {
+	switch {
+	case min == "" && max == "":
+		return "all versions"
+	case max == "":
+		return min + "+"
+	case min == "":
+		return "up to " + max
+	}
+	return min + "-" + max
+}
+
+// matchVersion 版本号是否属于矩阵中的版本，如8.9属于8，22.04属于22.04
+func matchVersion(version, entry string) bool {
+	return version == entry || strings.HasPrefix(version, entry+".")
+}
+
+// CheckCompatibility 按兼容性矩阵检查节点的发行版和containerd版本是否支持所选Kubernetes版本，
+// containerdVersion为空时不检查containerd
+func CheckCompatibility(kubeVersion string, osInfo node.OSInfo, containerdVersion string) CompatResult {
+	result := CompatResult{Distro: osInfo.Distro, Version: osInfo.Version, ContainerdVersion: containerdVersion, Compatible: true}
+	major, minor, ok := parseMajorMinor(kubeVersion)
+	if !ok || major != 1 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("cannot parse Kubernetes version %q, compatibility not checked", kubeVersion))
+		return result
+	}
+	target := fmt.Sprintf("1.%d", minor)
+
+	var distroEntries []OSSupport
+	for _, entry := range CompatibilityMatrix.OS {
+		if entry.Distro == osInfo.Distro {
+			distroEntries = append(distroEntries, entry)
+		}
+	}
+	if len(distroEntries) == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s is not in the compatibility matrix, deploying without a compatibility check", strings.TrimSpace(osInfo.Distro+" "+osInfo.Version)))
+	} else {
+		var current *OSSupport
+		for i, entry := range distroEntries {
+			if matchVersion(osInfo.Version, entry.Version) {
+				current = &distroEntries[i]
+				break
+			}
+		}
+		switch {
+		case current == nil:
+			result.Errors = append(result.Errors, fmt.Sprintf("%s is not supported", strings.TrimSpace(osInfo.Distro+" "+osInfo.Version)))
+		case !kubeInRange(minor, current.MinKube, current.MaxKube):
+			result.Errors = append(result.Errors, fmt.Sprintf("%s %s does not support Kubernetes %s (supported: %s)", osInfo.Distro, current.Version, target, kubeRange(current.MinKube, current.MaxKube)))
+			result.Alternatives = append(result.Alternatives, fmt.Sprintf("Kubernetes %s on %s %s", kubeRange(current.MinKube, current.MaxKube), osInfo.Distro, current.Version))
+		}
+		if len(result.Errors) > 0 {
+			for _, entry := range distroEntries {
+				if (current == nil || entry.Version != current.Version) && kubeInRange(minor, entry.MinKube, entry.MaxKube) {
+					result.Alternatives = append(result.Alternatives, fmt.Sprintf("%s %s with Kubernetes %s", entry.Distro, entry.Version, target))
+				}
+			}
+		}
+	}
+
+	if containerdVersion != "" {
+		checked := false
+		for _, entry := range CompatibilityMatrix.Containerd {
+			if !matchVersion(strings.TrimPrefix(containerdVersion, "v"), entry.Version) {
+				continue
+			}
+			checked = true
+			if !kubeInRange(minor, entry.MinKube, entry.MaxKube) {
+				result.Errors = append(result.Errors, fmt.Sprintf("containerd %s does not support Kubernetes %s (supported: %s)", containerdVersion, target, kubeRange(entry.MinKube, entry.MaxKube)))
+				for _, alt := range CompatibilityMatrix.Containerd {
+					if kubeInRange(minor, alt.MinKube, alt.MaxKube) {
+						result.Alternatives = append(result.Alternatives, fmt.Sprintf("containerd %s.x", alt.Version))
+					}
+				}
+			}
+			break
+		}
+		if !checked {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("containerd %s is not in the compatibility matrix", containerdVersion))
+		}
+	}
+
+	result.Compatible = len(result.Errors) == 0
+	return result
+}
+
+// Err 不兼容时返回带ERR_INCOMPATIBLE错误码的错误，错误信息包含可选方案
+func (r CompatResult) Err() error {
+	if r.Compatible {
+		return nil
+	}
+	msg := strings.Join(r.Errors, "; ")
+	if len(r.Alternatives) > 0 {
+		msg += "; supported alternatives: " + strings.Join(r.Alternatives, ", ")
+	}
+	if r.NodeName != "" {
+		msg = fmt.Sprintf("node %s: %s", r.NodeName, msg)
+	}
+	return errcode.New(errcode.Incompatible, errors.New(msg))
+}
+
+// CheckNodesCompatibility 检查节点缓存的操作系统信息和节点组指定的containerd版本，
+// 没有缓存操作系统信息的节点在部署时检测后再检查
+func CheckNodesCompatibility(nodes []node.Node, kubeVersion string, groupDefaults map[string]node.GroupDefaults) []CompatResult {
+	results := make([]CompatResult, 0, len(nodes))
+	for _, n := range nodes {
+		if n.OSInfo == nil {
+			results = append(results, CompatResult{
+				NodeID: n.ID, NodeName: n.Name, Distro: n.OS, Compatible: true,
+				Warnings: []string{"operating system has not been detected yet, compatibility is checked during deployment"},
+			})
+			continue
+		}
+		result := CheckCompatibility(kubeVersion, *n.OSInfo, groupDefaults[n.ID].ContainerdVersion)
+		result.NodeID = n.ID
+		result.NodeName = n.Name
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,303 @@
+package kubeadm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-installer/ssh"
+)
+
+// kube-proxy代理模式
+const (
+	KubeProxyModeIPTables = "iptables"
+	KubeProxyModeIPVS     = "ipvs"
+)
+
+// 默认集群参数
+const (
+	KubeadmConfigPath      = "/etc/kubernetes/kubeadm-config.yaml"
+	DefaultCRISocket       = "unix:///run/containerd/containerd.sock"
+	DefaultImageRepository = "registry.aliyuncs.com/google_containers"
+	DefaultPodSubnet       = "10.244.0.0/16"
+	DefaultServiceSubnet   = "10.96.0.0/12"
+)
+
+// KubeProxyConfiguration kube-proxy配置
+type KubeProxyConfiguration struct {
+	Mode string `json:"mode"`
+}
+
+// ValidateKubeProxyMode 校验kube-proxy模式，空字符串表示使用kube-proxy默认模式
+func ValidateKubeProxyMode(mode string) error {
+	switch mode {
+	case "", KubeProxyModeIPTables, KubeProxyModeIPVS:
+		return nil
+	}
+	return fmt.Errorf("unsupported kube-proxy mode: %s", mode)
+}
+
+// kubeadmAPIVersion 根据Kubernetes版本选择kubeadm配置API版本，1.31起使用v1beta4
+func kubeadmAPIVersion(kubeVersion string) string {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(kubeVersion), "v"), ".")
+	if len(parts) >= 2 {
+		if minor, err := strconv.Atoi(parts[1]); err == nil && parts[0] == "1" && minor >= 31 {
+			return "kubeadm.k8s.io/v1beta4"
+		}
+	}
+	return "kubeadm.k8s.io/v1beta3"
+}
+
+// yamlString 生成YAML双引号字符串
+func yamlString(s string) string {
+	return strconv.Quote(s)
+}
+
+// writeExtraArgs 写入组件额外参数，v1beta4使用name/value列表，v1beta3使用map
+func writeExtraArgs(b *strings.Builder, indent, key string, args map[string]string, apiVersion string) {
+	if len(args) == 0 {
+		return
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString(indent + key + ":\n")
+	for _, name := range names {
+		value := args[name]
+		flag := strings.TrimPrefix(name, "--")
+		if strings.HasSuffix(apiVersion, "v1beta4") {
+			b.WriteString(fmt.Sprintf("%s- name: %s\n%s  value: %s\n", indent, yamlString(flag), indent, yamlString(value)))
+		} else {
+			b.WriteString(fmt.Sprintf("%s  %s: %s\n", indent, yamlString(flag), yamlString(value)))
+		}
+	}
+}
+
+// RenderKubeadmConfig 根据配置生成kubeadm init使用的YAML配置文件
+func RenderKubeadmConfig(config KubeadmConfig) (string, error) {
+	cluster := config.ClusterConfiguration
+	if cluster.KubernetesVersion == "" {
+		return "", fmt.Errorf("kubernetesVersion is required")
+	}
+	if err := ValidateKubeProxyMode(config.KubeProxy.Mode); err != nil {
+		return "", err
+	}
+
+	apiVersion := kubeadmAPIVersion(cluster.KubernetesVersion)
+	initCfg := config.InitConfiguration
+	criSocket := initCfg.NodeRegistration.CRISocket
+	if criSocket == "" {
+		criSocket = DefaultCRISocket
+	}
+	imageRepository := cluster.ImageRepository
+	if imageRepository == "" {
+		imageRepository = DefaultImageRepository
+	}
+	podSubnet := cluster.Networking.PodSubnet
+	if podSubnet == "" {
+		podSubnet = DefaultPodSubnet
+	}
+
+	var b strings.Builder
+
+	// InitConfiguration
+	b.WriteString("apiVersion: " + apiVersion + "\n")
+	b.WriteString("kind: InitConfiguration\n")
+	if initCfg.LocalAPIEndpoint.AdvertiseAddress != "" {
+		bindPort := initCfg.LocalAPIEndpoint.BindPort
+		if bindPort == 0 {
+			bindPort = 6443
+		}
+		b.WriteString("localAPIEndpoint:\n")
+		b.WriteString("  advertiseAddress: " + yamlString(initCfg.LocalAPIEndpoint.AdvertiseAddress) + "\n")
+		b.WriteString(fmt.Sprintf("  bindPort: %d\n", bindPort))
+	}
+	b.WriteString("nodeRegistration:\n")
+	b.WriteString("  criSocket: " + yamlString(criSocket) + "\n")
+	writeExtraArgs(&b, "  ", "kubeletExtraArgs", initCfg.NodeRegistration.KubeletExtraArgs, apiVersion)
+	if len(initCfg.NodeRegistration.IgnorePreflightErrors) > 0 {
+		b.WriteString("  ignorePreflightErrors:\n")
+		for _, check := range initCfg.NodeRegistration.IgnorePreflightErrors {
+			b.WriteString("  - " + yamlString(check) + "\n")
+		}
+	}
+
+	// ClusterConfiguration
+	b.WriteString("---\n")
+	b.WriteString("apiVersion: " + apiVersion + "\n")
+	b.WriteString("kind: ClusterConfiguration\n")
+	b.WriteString("kubernetesVersion: " + yamlString(cluster.KubernetesVersion) + "\n")
+	b.WriteString("imageRepository: " + yamlString(imageRepository) + "\n")
+	if cluster.ControlPlaneEndpoint != "" {
+		b.WriteString("controlPlaneEndpoint: " + yamlString(cluster.ControlPlaneEndpoint) + "\n")
+	}
+	b.WriteString("networking:\n")
+	b.WriteString("  podSubnet: " + yamlString(podSubnet) + "\n")
+	if cluster.Networking.ServiceSubnet != "" {
+		b.WriteString("  serviceSubnet: " + yamlString(cluster.Networking.ServiceSubnet) + "\n")
+	}
+	if cluster.Networking.DNSDomain != "" {
+		b.WriteString("  dnsDomain: " + yamlString(cluster.Networking.DNSDomain) + "\n")
+	}
+	if len(cluster.APIServer.ExtraArgs) > 0 || len(cluster.APIServer.CertSANs) > 0 {
+		b.WriteString("apiServer:\n")
+		if len(cluster.APIServer.CertSANs) > 0 {
+			b.WriteString("  certSANs:\n")
+			for _, san := range cluster.APIServer.CertSANs {
+				b.WriteString("  - " + yamlString(san) + "\n")
+			}
+		}
+		writeExtraArgs(&b, "  ", "extraArgs", cluster.APIServer.ExtraArgs, apiVersion)
+	}
+	if len(cluster.ControllerManager.ExtraArgs) > 0 {
+		b.WriteString("controllerManager:\n")
+		writeExtraArgs(&b, "  ", "extraArgs", cluster.ControllerManager.ExtraArgs, apiVersion)
+	}
+	if len(cluster.Scheduler.ExtraArgs) > 0 {
+		b.WriteString("scheduler:\n")
+		writeExtraArgs(&b, "  ", "extraArgs", cluster.Scheduler.ExtraArgs, apiVersion)
+	}
+	if external := cluster.Etcd.External; external != nil {
+		b.WriteString("etcd:\n")
+		b.WriteString("  external:\n")
+		b.WriteString("    endpoints:\n")
+		for _, endpoint := range external.Endpoints {
+			b.WriteString("    - " + yamlString(endpoint) + "\n")
+		}
+		caFile, certFile, keyFile := external.files(ExternalEtcdCertDir)
+		if caFile != "" {
+			b.WriteString("    caFile: " + yamlString(caFile) + "\n")
+		}
+		if certFile != "" {
+			b.WriteString("    certFile: " + yamlString(certFile) + "\n")
+			b.WriteString("    keyFile: " + yamlString(keyFile) + "\n")
+		}
+	} else if cluster.Etcd.Local.DataDir != "" || len(cluster.Etcd.Local.ExtraArgs) > 0 {
+		b.WriteString("etcd:\n")
+		b.WriteString("  local:\n")
+		if cluster.Etcd.Local.DataDir != "" {
+			b.WriteString("    dataDir: " + yamlString(cluster.Etcd.Local.DataDir) + "\n")
+		}
+		writeExtraArgs(&b, "    ", "extraArgs", cluster.Etcd.Local.ExtraArgs, apiVersion)
+	}
+
+	// KubeProxyConfiguration
+	if config.KubeProxy.Mode != "" {
+		b.WriteString("---\n")
+		b.WriteString("apiVersion: kubeproxy.config.k8s.io/v1alpha1\n")
+		b.WriteString("kind: KubeProxyConfiguration\n")
+		b.WriteString("mode: " + yamlString(config.KubeProxy.Mode) + "\n")
+	}
+
+	// KubeletConfiguration
+	if config.Swap.Enabled || config.CgroupDriver != "" {
+		b.WriteString("---\n")
+		b.WriteString(renderKubeletConfiguration(config))
+	}
+
+	return b.String(), nil
+}
+
+// renderKubeletConfiguration 生成KubeletConfiguration，kubeadm init会将其上传到kubelet-config ConfigMap，
+// 加入集群的节点使用同一份配置。启用swap时，1.30之前NodeSwap默认关闭，需要开启特性门控
+func renderKubeletConfiguration(config KubeadmConfig) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: kubelet.config.k8s.io/v1beta1\n")
+	b.WriteString("kind: KubeletConfiguration\n")
+	if config.CgroupDriver != "" {
+		b.WriteString("cgroupDriver: " + yamlString(config.CgroupDriver) + "\n")
+	}
+	if config.Swap.Enabled {
+		b.WriteString("failSwapOn: false\n")
+		if _, minor, ok := parseMajorMinor(config.ClusterConfiguration.KubernetesVersion); ok && minor < swapDefaultOnMinor {
+			b.WriteString("featureGates:\n")
+			b.WriteString("  NodeSwap: true\n")
+		}
+		b.WriteString("memorySwap:\n")
+		b.WriteString("  swapBehavior: " + yamlString(config.Swap.behavior()) + "\n")
+	}
+	return b.String()
+}
+
+// UploadKubeadmConfig 生成kubeadm配置文件并通过SFTP上传到节点的KubeadmConfigPath，返回配置内容
+func UploadKubeadmConfig(client ssh.Runner, config KubeadmConfig) (string, error) {
+	content, err := RenderKubeadmConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	// SFTP以登录用户身份写入，先上传到临时目录再用sudo移动到目标位置
+	tmpPath := fmt.Sprintf("/tmp/kubeadm-config-%d.yaml", time.Now().UnixNano())
+	if err := client.Upload(tmpPath, []byte(content)); err != nil {
+		return "", fmt.Errorf("failed to upload kubeadm config: %v", err)
+	}
+	installCmd := fmt.Sprintf("sudo install -D -m 600 %s %s; status=$?; rm -f %s; exit $status", tmpPath, KubeadmConfigPath, tmpPath)
+	if output, err := client.RunCommand(installCmd); err != nil {
+		return "", fmt.Errorf("failed to install kubeadm config: %v, output: %s", err, output)
+	}
+	return content, nil
+}
+
+// KubeletExtraArgsCmd 生成写入kubelet额外参数环境文件的命令，
+// 用于通过join命令加入集群的节点，kubelet的systemd配置会读取该文件
+func KubeletExtraArgsCmd(args map[string]string) string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]string, 0, len(names))
+	for _, name := range names {
+		flags = append(flags, fmt.Sprintf("--%s=%s", strings.TrimPrefix(name, "--"), args[name]))
+	}
+	line := "KUBELET_EXTRA_ARGS=" + strconv.Quote(strings.Join(flags, " "))
+
+	return fmt.Sprintf(`if [ -d /etc/sysconfig ]; then
+    KUBELET_ENV_FILE=/etc/sysconfig/kubelet
+else
+    KUBELET_ENV_FILE=/etc/default/kubelet
+fi
+echo %s | sudo tee $KUBELET_ENV_FILE > /dev/null
+echo "已写入kubelet额外参数到 $KUBELET_ENV_FILE"`, shellQuote(line))
+}
+
+// shellQuote 使用单引号转义shell参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// IPVSPrepCmd kube-proxy使用ipvs模式时需要的内核模块和工具
+const IPVSPrepCmd = `echo "=== 配置IPVS内核模块 ==="
+cat <<'EOF' | sudo tee /etc/modules-load.d/ipvs.conf > /dev/null
+ip_vs
+ip_vs_rr
+ip_vs_wrr
+ip_vs_sh
+nf_conntrack
+EOF
+for mod in ip_vs ip_vs_rr ip_vs_wrr ip_vs_sh nf_conntrack; do
+    sudo modprobe $mod || { echo "✗ 加载内核模块 $mod 失败"; exit 1; }
+done
+
+echo "=== 安装ipvsadm和ipset ==="
+if command -v apt &> /dev/null; then
+    sudo apt install -y ipvsadm ipset
+elif command -v dnf &> /dev/null; then
+    sudo dnf install -y ipvsadm ipset
+elif command -v zypper &> /dev/null; then
+    sudo zypper --non-interactive install -y ipvsadm ipset
+else
+    sudo yum install -y ipvsadm ipset
+fi
+if ! command -v ipvsadm &> /dev/null; then
+    echo "✗ ipvsadm安装失败"
+    exit 1
+fi
+lsmod | grep -e ip_vs -e nf_conntrack
+echo "✓ IPVS配置完成"`
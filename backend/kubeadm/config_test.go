@@ -0,0 +1,40 @@
+package kubeadm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s-installer/ssh"
+)
+
+func TestUploadKubeadmConfig(t *testing.T) {
+	rec := ssh.NewRecorder()
+	config := KubeadmConfig{}
+	config.ClusterConfiguration.KubernetesVersion = "1.30.2"
+	content, err := UploadKubeadmConfig(rec, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands := rec.Commands()
+	if len(commands) != 1 || !strings.Contains(commands[0], "sudo install -D -m 600 ") || !strings.Contains(commands[0], KubeadmConfigPath) {
+		t.Fatalf("commands = %q, want the uploaded file installed to %s", commands, KubeadmConfigPath)
+	}
+	// 上传到临时文件，再用sudo移动到目标位置
+	tmpPath := strings.Fields(commands[0])[5]
+	uploaded, ok := rec.Uploaded(tmpPath)
+	if !ok {
+		t.Fatalf("nothing uploaded to %s", tmpPath)
+	}
+	if string(uploaded) != content || !strings.Contains(content, "1.30.2") {
+		t.Fatalf("uploaded config does not match the rendered config:\n%s", uploaded)
+	}
+}
+
+func TestUploadKubeadmConfigInstallFailure(t *testing.T) {
+	rec := ssh.NewRecorder().On("sudo install", "permission denied", errors.New("exit status 1"))
+	if _, err := UploadKubeadmConfig(rec, KubeadmConfig{}); err == nil {
+		t.Fatal("expected an error when the config cannot be installed")
+	}
+}
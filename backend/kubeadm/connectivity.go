@@ -0,0 +1,321 @@
+package kubeadm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/validate"
+)
+
+// 连通性检查结果状态
+const (
+	ConnectivityOpen       = "open"       // 连接成功
+	ConnectivityBlocked    = "blocked"    // 超时或被防火墙拒绝
+	ConnectivityUnverified = "unverified" // 目标节点无法启动临时监听，不能确认端口是否放行
+)
+
+// DefaultConnectivityTimeout 单个端口探测的超时时间
+const DefaultConnectivityTimeout = 3 * time.Second
+
+// connectivityPIDFile 临时监听进程的PID文件，检查结束后按PID结束进程
+const connectivityPIDFile = "/tmp/k8s-installer-portcheck.pid"
+
+// PortCheck 节点之间需要连通的端口
+type PortCheck struct {
+	Name     string `json:"name"`
+	Port     int    `json:"port" binding:"required"`
+	Protocol string `json:"protocol"` // tcp或udp，默认tcp
+	// MasterOnly 只检查到master节点的连通性，如apiserver
+	MasterOnly bool `json:"masterOnly,omitempty"`
+}
+
+// DefaultPortChecks 部署前检查的端口：worker到master的apiserver，所有节点之间的kubelet和VXLAN
+var DefaultPortChecks = []PortCheck{
+	{Name: "kube-apiserver", Port: 6443, Protocol: "tcp", MasterOnly: true},
+	{Name: "kubelet", Port: 10250, Protocol: "tcp"},
+	{Name: "flannel-vxlan", Port: 8472, Protocol: "udp"},
+	{Name: "vxlan", Port: 4789, Protocol: "udp"},
+}
+
+// protocol 端口协议，未指定时为tcp
+func (c PortCheck) protocol() string {
+	if c.Protocol == "" {
+		return "tcp"
+	}
+	return c.Protocol
+}
+
+// ValidatePortChecks 检查端口和协议
+func ValidatePortChecks(checks []PortCheck) error {
+	v := &validate.Validator{}
+	for i, check := range checks {
+		field := fmt.Sprintf("checks[%d]", i)
+		if check.Port < 1 || check.Port > 65535 {
+			v.Add(field+".port", "%d is out of range 1-65535", check.Port)
+		}
+		if p := check.protocol(); p != "tcp" && p != "udp" {
+			v.Add(field+".protocol", "must be tcp or udp")
+		}
+	}
+	return v.Err()
+}
+
+// PortListenerCmd 生成在节点上启动临时监听的命令：TCP端口接受连接，UDP端口回显数据，duration后自动退出。
+// 输出每个端口的监听状态，端口已被占用时为busy，节点没有python3时为none
+func PortListenerCmd(checks []PortCheck, duration time.Duration) string {
+	var ports []string
+	for _, check := range checks {
+		ports = append(ports, fmt.Sprintf("%d/%s", check.Port, check.protocol()))
+	}
+	return fmt.Sprintf(`[ -f %[1]s ] && kill $(cat %[1]s) 2>/dev/null
+if ! command -v python3 &> /dev/null; then
+    echo "LISTEN - - none"
+    exit 0
+fi
+LISTENER=$(cat <<'PY'
+import socket, sys, threading, time
+
+def serve_tcp(s):
+    while True:
+        conn, _ = s.accept()
+        conn.close()
+
+def serve_udp(s):
+    while True:
+        data, addr = s.recvfrom(64)
+        s.sendto(data, addr)
+
+def bind(port, kind):
+    # 优先使用IPv4/IPv6双栈监听，节点禁用IPv6时只监听IPv4
+    try:
+        s = socket.socket(socket.AF_INET6, kind)
+        s.setsockopt(socket.IPPROTO_IPV6, socket.IPV6_V6ONLY, 0)
+        address = ("::", port)
+    except OSError:
+        s = socket.socket(socket.AF_INET, kind)
+        address = ("0.0.0.0", port)
+    s.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+    s.bind(address)
+    return s
+
+duration = float(sys.argv[1])
+for spec in sys.argv[2:]:
+    port, proto = spec.split("/")
+    try:
+        if proto == "tcp":
+            s = bind(int(port), socket.SOCK_STREAM)
+            s.listen(64)
+            target = serve_tcp
+        else:
+            s = bind(int(port), socket.SOCK_DGRAM)
+            target = serve_udp
+        threading.Thread(target=target, args=(s,), daemon=True).start()
+        print("LISTEN", port, proto, "ok", flush=True)
+    except OSError:
+        print("LISTEN", port, proto, "busy", flush=True)
+time.sleep(duration)
+PY
+)
+STATUS_FILE=$(mktemp)
+setsid nohup python3 -c "$LISTENER" %[2]d %[3]s > "$STATUS_FILE" 2>&1 < /dev/null &
+echo $! > %[1]s
+sleep 1
+cat "$STATUS_FILE"
+rm -f "$STATUS_FILE"`, connectivityPIDFile, int(duration.Seconds()), strings.Join(ports, " "))
+}
+
+// StopPortListenerCmd 结束临时监听
+const StopPortListenerCmd = `[ -f ` + connectivityPIDFile + ` ] && kill $(cat ` + connectivityPIDFile + `) 2>/dev/null; rm -f ` + connectivityPIDFile + `; true`
+
+// ConnectivityProbeCmd 生成在节点上探测到其他节点端口的命令，所有节点使用相同的命令，跳过本机地址。
+// 输出格式为 PROBE <目标序号> <端口> <协议> <结果> <说明>，结果为open、refused、timeout或error
+func ConnectivityProbeCmd(nodes []node.Node, checks []PortCheck, timeout time.Duration) string {
+	if timeout <= 0 {
+		timeout = DefaultConnectivityTimeout
+	}
+	var specs []string
+	for i, n := range nodes {
+		for _, check := range checks {
+			if check.MasterOnly && n.NodeType != node.NodeTypeMaster {
+				continue
+			}
+			specs = append(specs, shellQuote(fmt.Sprintf("%d,%s,%d,%s", i, n.IP, check.Port, check.protocol())))
+		}
+	}
+	seconds := strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64)
+	return fmt.Sprintf(`LOCAL_IPS="$(hostname -I 2>/dev/null) $(hostname -i 2>/dev/null)"
+if command -v python3 &> /dev/null; then
+PROBE=$(cat <<'PY'
+import socket, sys
+from concurrent.futures import ThreadPoolExecutor
+
+timeout = float(sys.argv[1])
+local = set(sys.argv[2].split())
+
+def probe(spec):
+    idx, ip, port, proto = spec.split(",")
+    family = socket.AF_INET6 if ":" in ip else socket.AF_INET
+    try:
+        if proto == "tcp":
+            s = socket.create_connection((ip, int(port)), timeout)
+        else:
+            s = socket.socket(family, socket.SOCK_DGRAM)
+            s.settimeout(timeout)
+            s.connect((ip, int(port)))
+            s.send(b"k8s-installer")
+            s.recv(64)
+        s.close()
+        result, message = "open", "-"
+    except ConnectionRefusedError:
+        result, message = "refused", "connection refused"
+    except socket.timeout:
+        result, message = "timeout", "timed out"
+    except OSError as e:
+        result, message = "error", str(e)
+    return "PROBE %%s %%s %%s %%s %%s" %% (idx, port, proto, result, message)
+
+specs = [s for s in sys.argv[3:] if s.split(",")[1] not in local]
+with ThreadPoolExecutor(max_workers=32) as pool:
+    for line in pool.map(probe, specs):
+        print(line, flush=True)
+PY
+)
+python3 -c "$PROBE" %[1]s "$LOCAL_IPS" %[2]s
+else
+for spec in %[2]s; do
+    IFS=, read -r idx ip port proto <<< "$spec"
+    case " $LOCAL_IPS " in *" $ip "*) continue ;; esac
+    if [ "$proto" != "tcp" ]; then
+        echo "PROBE $idx $port $proto error python3 is required for udp checks"
+        continue
+    fi
+    timeout %[1]s bash -c "</dev/tcp/$ip/$port" 2>/dev/null
+    case $? in
+        0) echo "PROBE $idx $port $proto open -" ;;
+        124) echo "PROBE $idx $port $proto timeout timed out" ;;
+        *) echo "PROBE $idx $port $proto refused connection refused" ;;
+    esac
+done
+fi`, seconds, strings.Join(specs, " "))
+}
+
+// ConnectivityResult 一个节点到另一个节点端口的连通性
+type ConnectivityResult struct {
+	FromNodeID   string `json:"fromNodeId"`
+	FromNodeName string `json:"fromNodeName"`
+	ToNodeID     string `json:"toNodeId"`
+	ToNodeName   string `json:"toNodeName"`
+	Check        string `json:"check"`
+	Port         int    `json:"port"`
+	Protocol     string `json:"protocol"`
+	Status       string `json:"status"`
+	Message      string `json:"message,omitempty"`
+}
+
+// ConnectivityNodeError 无法在节点上执行检查
+type ConnectivityNodeError struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	Error    string `json:"error"`
+}
+
+// ConnectivityMatrix 节点之间的端口连通性矩阵
+type ConnectivityMatrix struct {
+	// Passed 没有被阻断的路径且所有节点都完成了检查
+	Passed     bool                    `json:"passed"`
+	Open       int                     `json:"open"`
+	Blocked    int                     `json:"blocked"`
+	Unverified int                     `json:"unverified"`
+	Results    []ConnectivityResult    `json:"results"`
+	Errors     []ConnectivityNodeError `json:"errors,omitempty"`
+}
+
+// parseListenerOutput 解析临时监听的状态，键为 端口/协议，值为ok、busy或none
+func parseListenerOutput(output string) map[string]string {
+	states := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "LISTEN" {
+			continue
+		}
+		states[fields[1]+"/"+fields[2]] = fields[3]
+	}
+	return states
+}
+
+// BuildConnectivityMatrix 根据临时监听和探测的执行结果生成连通性矩阵，两组结果与nodes顺序一致。
+// 目标端口上有临时监听时，拒绝连接或超时都说明路径被阻断；没有监听时拒绝连接只说明主机可达
+func BuildConnectivityMatrix(nodes []node.Node, checks []PortCheck, listeners, probes []node.ExecResult) ConnectivityMatrix {
+	matrix := ConnectivityMatrix{Results: []ConnectivityResult{}}
+	listenerStates := make([]map[string]string, len(nodes))
+	for i, res := range listeners {
+		listenerStates[i] = parseListenerOutput(res.Output)
+	}
+	checkNames := make(map[string]string)
+	for _, check := range checks {
+		checkNames[fmt.Sprintf("%d/%s", check.Port, check.protocol())] = check.Name
+	}
+
+	for i, res := range probes {
+		from := nodes[i]
+		if !res.Success {
+			matrix.Errors = append(matrix.Errors, ConnectivityNodeError{NodeID: from.ID, NodeName: from.Name, Error: res.Error})
+			continue
+		}
+		for _, line := range strings.Split(res.Output, "\n") {
+			fields := strings.SplitN(strings.TrimSpace(line), " ", 6)
+			if len(fields) < 5 || fields[0] != "PROBE" {
+				continue
+			}
+			idx, err := strconv.Atoi(fields[1])
+			if err != nil || idx < 0 || idx >= len(nodes) {
+				continue
+			}
+			port, _ := strconv.Atoi(fields[2])
+			key := fields[2] + "/" + fields[3]
+			to := nodes[idx]
+			result := ConnectivityResult{
+				FromNodeID:   from.ID,
+				FromNodeName: from.Name,
+				ToNodeID:     to.ID,
+				ToNodeName:   to.Name,
+				Check:        checkNames[key],
+				Port:         port,
+				Protocol:     fields[3],
+			}
+			if len(fields) == 6 && fields[5] != "-" {
+				result.Message = fields[5]
+			}
+
+			listening := listenerStates[idx][key] == "ok"
+			switch fields[4] {
+			case "open":
+				result.Status = ConnectivityOpen
+			case "refused", "timeout":
+				if listening || fields[3] == "tcp" && fields[4] == "timeout" {
+					result.Status = ConnectivityBlocked
+				} else {
+					result.Status = ConnectivityUnverified
+					result.Message = "no temporary listener on target: " + result.Message
+				}
+			default:
+				result.Status = ConnectivityUnverified
+			}
+
+			switch result.Status {
+			case ConnectivityOpen:
+				matrix.Open++
+			case ConnectivityBlocked:
+				matrix.Blocked++
+			default:
+				matrix.Unverified++
+			}
+			matrix.Results = append(matrix.Results, result)
+		}
+	}
+	matrix.Passed = matrix.Blocked == 0 && len(matrix.Errors) == 0
+	return matrix
+}
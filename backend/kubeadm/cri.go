@@ -0,0 +1,233 @@
+package kubeadm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// criProbeTimeout crictl和ctr连接CRI socket的超时时间
+const criProbeTimeout = "5s"
+
+// CRI状态条件，见crictl info输出的status.conditions
+const (
+	CRIConditionRuntimeReady = "RuntimeReady"
+	CRIConditionNetworkReady = "NetworkReady"
+)
+
+// CRI健康检查使用的工具
+const (
+	CRIToolCrictl = "crictl"
+	CRIToolCtr    = "ctr"
+)
+
+// CRIHealthCmd 通过CRI API探测containerd：优先使用crictl version和crictl info，
+// 未安装crictl时（如安装Kubernetes组件之前）使用containerd自带的ctr version。各段输出以"=== 名称"分隔
+const CRIHealthCmd = `echo "=== SOCKET"
+if sudo test -S ` + criSocketPath + `; then echo yes; else echo no; fi
+if command -v crictl >/dev/null 2>&1; then
+    echo "=== CRICTL_VERSION"
+    sudo crictl --runtime-endpoint unix://` + criSocketPath + ` --timeout ` + criProbeTimeout + ` version 2>&1
+    echo "=== CRICTL_INFO"
+    sudo crictl --runtime-endpoint unix://` + criSocketPath + ` --timeout ` + criProbeTimeout + ` info 2>&1
+elif command -v ctr >/dev/null 2>&1; then
+    echo "=== CTR_VERSION"
+    sudo timeout ` + criProbeTimeout + ` ctr --address ` + criSocketPath + ` version 2>&1
+fi
+true`
+
+// CRICondition CRI运行时的状态条件
+type CRICondition struct {
+	Type    string `json:"type"`
+	Status  bool   `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// CRIHealth 通过CRI API探测的容器运行时状态。Healthy表示运行时可以创建容器，
+// 安装CNI插件之前NetworkReady为false属于正常情况，不影响Healthy
+type CRIHealth struct {
+	Healthy      bool   `json:"healthy"`
+	Socket       string `json:"socket"`
+	SocketExists bool   `json:"socketExists"`
+	// Tool 探测使用的工具，crictl和ctr均未安装时为空
+	Tool              string         `json:"tool,omitempty"`
+	RuntimeName       string         `json:"runtimeName,omitempty"`
+	RuntimeVersion    string         `json:"runtimeVersion,omitempty"`
+	RuntimeAPIVersion string         `json:"runtimeApiVersion,omitempty"`
+	RuntimeReady      bool           `json:"runtimeReady"`
+	NetworkReady      bool           `json:"networkReady"`
+	Conditions        []CRICondition `json:"conditions,omitempty"`
+	Error             string         `json:"error,omitempty"`
+	CheckedAt         time.Time      `json:"checkedAt"`
+}
+
+// CRIReport 单个节点的容器运行时检查结果
+type CRIReport struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	CRIHealth
+}
+
+// splitCRISections 按"=== 名称"拆分CRIHealthCmd的输出
+func splitCRISections(output string) map[string]string {
+	sections := make(map[string]string)
+	name := ""
+	var lines []string
+	flush := func() {
+		if name != "" {
+			sections[name] = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "=== ") {
+			flush()
+			name = strings.TrimSpace(strings.TrimPrefix(line, "=== "))
+			lines = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return sections
+}
+
+// parseKeyValues 解析crictl version和ctr version的"键: 值"输出，键转为小写
+func parseKeyValues(output string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// ParseCRIHealth 解析CRIHealthCmd的输出
+func ParseCRIHealth(output string) CRIHealth {
+	health := CRIHealth{Socket: criSocketPath, CheckedAt: time.Now()}
+	sections := splitCRISections(output)
+	health.SocketExists = sections["SOCKET"] == "yes"
+
+	switch {
+	case sections["CRICTL_INFO"] != "" || sections["CRICTL_VERSION"] != "":
+		health.Tool = CRIToolCrictl
+		version := parseKeyValues(sections["CRICTL_VERSION"])
+		health.RuntimeName = version["runtimename"]
+		health.RuntimeVersion = version["runtimeversion"]
+		health.RuntimeAPIVersion = version["runtimeapiversion"]
+
+		info := sections["CRICTL_INFO"]
+		var parsed struct {
+			Status struct {
+				Conditions []CRICondition `json:"conditions"`
+			} `json:"status"`
+		}
+		// crictl可能在JSON之前输出告警
+		start := strings.Index(info, "{")
+		if start < 0 {
+			health.Error = fmt.Sprintf("crictl info failed: %s", firstLine(info))
+			break
+		}
+		if err := json.Unmarshal([]byte(info[start:]), &parsed); err != nil {
+			health.Error = fmt.Sprintf("parse crictl info: %v", err)
+			break
+		}
+		health.Conditions = parsed.Status.Conditions
+		for _, condition := range health.Conditions {
+			switch condition.Type {
+			case CRIConditionRuntimeReady:
+				health.RuntimeReady = condition.Status
+				if !condition.Status {
+					health.Error = fmt.Sprintf("runtime not ready: %s %s", condition.Reason, condition.Message)
+				}
+			case CRIConditionNetworkReady:
+				health.NetworkReady = condition.Status
+			}
+		}
+		if health.Error == "" && !health.RuntimeReady {
+			health.Error = "crictl info did not report RuntimeReady"
+		}
+	case sections["CTR_VERSION"] != "":
+		// ctr没有状态条件，containerd返回服务端版本即认为运行时就绪
+		health.Tool = CRIToolCtr
+		output := sections["CTR_VERSION"]
+		if _, server, ok := strings.Cut(output, "Server:"); ok {
+			version := parseKeyValues(server)
+			health.RuntimeName = "containerd"
+			health.RuntimeVersion = version["version"]
+			health.RuntimeReady = health.RuntimeVersion != ""
+		}
+		if !health.RuntimeReady {
+			health.Error = fmt.Sprintf("ctr version failed: %s", lastLine(output))
+		}
+	default:
+		health.Error = "neither crictl nor ctr is installed"
+	}
+
+	if !health.SocketExists && health.Error == "" {
+		health.Error = fmt.Sprintf("CRI socket %s does not exist", criSocketPath)
+	}
+	health.Healthy = health.SocketExists && health.RuntimeReady
+	return health
+}
+
+// firstLine 返回输出的第一行
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	return line
+}
+
+// lastLine 返回输出的最后一行
+func lastLine(output string) string {
+	output = strings.TrimSpace(output)
+	return output[strings.LastIndex(output, "\n")+1:]
+}
+
+// CheckCRIHealth 在节点上通过CRI API检查容器运行时状态，无法执行命令时结果的Error为失败原因
+func CheckCRIHealth(client ssh.Runner) CRIHealth {
+	output, err := client.RunCommandSilent(CRIHealthCmd)
+	if err != nil {
+		return CRIHealth{Socket: criSocketPath, Error: err.Error(), CheckedAt: time.Now()}
+	}
+	return ParseCRIHealth(output)
+}
+
+// BuildCRIReport 根据ExecOnNodes的执行结果生成节点的容器运行时检查结果
+func BuildCRIReport(n node.Node, res node.ExecResult) CRIReport {
+	report := CRIReport{NodeID: n.ID, NodeName: n.Name}
+	if !res.Success {
+		report.Socket = criSocketPath
+		report.Error = res.Error
+		report.CheckedAt = time.Now()
+		return report
+	}
+	report.CRIHealth = ParseCRIHealth(res.Output)
+	return report
+}
+
+// WaitForCRI 周期性检查容器运行时直到健康或超时，返回最后一次检查结果
+func WaitForCRI(ctx context.Context, client ssh.Runner, timeout time.Duration) (CRIHealth, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		health := CheckCRIHealth(client)
+		if health.Healthy {
+			return health, nil
+		}
+		if time.Now().After(deadline) {
+			return health, fmt.Errorf("timed out after %v waiting for container runtime: %s", timeout, health.Error)
+		}
+		select {
+		case <-ctx.Done():
+			return health, ctx.Err()
+		case <-time.After(defaultWaitInterval):
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package kubeadm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s-installer/ssh"
+)
+
+func TestCheckCRIHealth(t *testing.T) {
+	health := CheckCRIHealth(ssh.NewRecorder().On("=== SOCKET", healthyCRIOutput, nil))
+	if !health.Healthy || health.Tool != CRIToolCtr || health.RuntimeVersion != "v1.7.13" {
+		t.Fatalf("CheckCRIHealth() = %+v, want a healthy containerd v1.7.13 via ctr", health)
+	}
+
+	health = CheckCRIHealth(ssh.NewRecorder().On("=== SOCKET", "", errors.New("connection lost")))
+	if health.Healthy || health.Error != "connection lost" {
+		t.Fatalf("CheckCRIHealth() = %+v, want the command error", health)
+	}
+}
+
+func TestWaitForCRITimeout(t *testing.T) {
+	rec := ssh.NewRecorder().On("=== SOCKET", "=== SOCKET\nno\n", nil)
+	health, err := WaitForCRI(context.Background(), rec, 0)
+	if err == nil || health.Healthy {
+		t.Fatalf("WaitForCRI() = %+v, %v, want a timeout", health, err)
+	}
+	if health.Error != "neither crictl nor ctr is installed" {
+		t.Fatalf("Error = %q", health.Error)
+	}
+}
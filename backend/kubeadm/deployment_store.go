@@ -0,0 +1,531 @@
+package kubeadm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/diagnose"
+	"k8s-installer/errcode"
+)
+
+// 部署和步骤状态
+const (
+	DeploymentStatusRunning = "running"
+	DeploymentStatusSuccess = "success"
+	DeploymentStatusFailed  = "failed"
+	// DeploymentStatusTornDown 集群已被拆除
+	DeploymentStatusTornDown = "torn_down"
+	// DeploymentStatusAdopted 导入的已有集群，不是由本工具部署的
+	DeploymentStatusAdopted = "adopted"
+)
+
+// ErrDeploymentNotFound 部署记录不存在
+var ErrDeploymentNotFound = errors.New("deployment not found")
+
+// Deployment 部署记录
+type Deployment struct {
+	ID            string       `json:"id"`
+	KubeVersion   string       `json:"kubeVersion"`
+	Arch          string       `json:"arch"`
+	Distro        string       `json:"distro"`
+	InstallerType string       `json:"installerType"` // 集群安装方式：kubeadm或k3s
+	NodeIDs       []string     `json:"nodeIds"`
+	Status        string       `json:"status"`
+	Error         string       `json:"error,omitempty"`
+	ErrorCode     errcode.Code `json:"errorCode,omitempty"`
+	ErrorMessage  string       `json:"errorMessage,omitempty"` // 错误码的说明，由API按请求语言填充
+	Steps         []StepRecord `json:"steps,omitempty"`
+	CreatedAt     time.Time    `json:"createdAt"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+	// Diagnoses 根据错误和部署输出识别的故障及处理建议，由API按请求语言填充
+	Diagnoses    []diagnose.Diagnosis `json:"diagnoses,omitempty"`
+	diagnosisIDs []string
+	// Progress 运行中部署的进度和预计剩余时间，由API填充
+	Progress *Progress `json:"progress,omitempty"`
+	// PackagePinning 集群的kubelet、kubeadm和kubectl是否固定版本，升级前通过集群接口解除
+	PackagePinning bool `json:"packagePinning"`
+	// Description 发起部署时填写的变更说明，记录这次变更的原因
+	Description string `json:"description,omitempty"`
+}
+
+// StepRecord 节点步骤执行记录
+type StepRecord struct {
+	NodeID       string       `json:"nodeId"`
+	Step         string       `json:"step"`
+	Status       string       `json:"status"`
+	Error        string       `json:"error,omitempty"`
+	ErrorCode    errcode.Code `json:"errorCode,omitempty"`
+	ErrorMessage string       `json:"errorMessage,omitempty"` // 错误码的说明，由API按请求语言填充
+	UpdatedAt    time.Time    `json:"updatedAt"`
+	// Diagnoses 失败步骤识别出的故障及处理建议，由API按请求语言填充
+	Diagnoses    []diagnose.Diagnosis `json:"diagnoses,omitempty"`
+	diagnosisIDs []string
+	// DurationMs 步骤耗时（毫秒），用于预估后续部署的进度
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// StepTracker 记录每个节点每个步骤的执行结果，用于断点续部署
+type StepTracker interface {
+	// IsStepCompleted 步骤是否已在该节点上成功执行
+	IsStepCompleted(nodeID, step string) bool
+	// MarkStep 记录步骤执行结果，err为nil表示成功
+	MarkStep(nodeID, step string, err error)
+}
+
+// StepStarter StepTracker可以实现的可选接口，步骤开始时调用，用于统计步骤耗时和部署进度
+type StepStarter interface {
+	StartStep(nodeID, step string)
+}
+
+// startStep 步骤记录器实现StepStarter时记录步骤开始，nodeID为空表示步骤在所有节点上同时开始
+func startStep(tracker StepTracker, nodeID, step string) {
+	if starter, ok := tracker.(StepStarter); ok {
+		starter.StartStep(nodeID, step)
+	}
+}
+
+// DeploymentStore 部署记录存储
+type DeploymentStore struct {
+	db       *sql.DB
+	mutex    sync.RWMutex
+	progress progressTracker
+}
+
+// NewDeploymentStore 创建部署记录存储
+func NewDeploymentStore(db *sql.DB) (*DeploymentStore, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS deployments (
+		id TEXT PRIMARY KEY,
+		kube_version TEXT NOT NULL,
+		arch TEXT NOT NULL,
+		distro TEXT NOT NULL,
+		node_ids TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS deployment_steps (
+		deployment_id TEXT NOT NULL,
+		node_id TEXT NOT NULL,
+		step TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (deployment_id, node_id, step)
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create deployment tables: %v", err)
+	}
+
+	// 检查并添加installer_type、error_code和diagnoses列（如果不存在）
+	columns := []struct{ table, column, definition string }{
+		{"deployments", "installer_type", "TEXT NOT NULL DEFAULT 'kubeadm'"},
+		{"deployments", "error_code", "TEXT NOT NULL DEFAULT ''"},
+		{"deployment_steps", "error_code", "TEXT NOT NULL DEFAULT ''"},
+		{"deployments", "diagnoses", "TEXT NOT NULL DEFAULT ''"},
+		{"deployment_steps", "diagnoses", "TEXT NOT NULL DEFAULT ''"},
+		{"deployment_steps", "duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+		{"deployments", "package_pinning", "INTEGER NOT NULL DEFAULT 1"},
+		{"deployments", "description", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range columns {
+		if err := addColumnIfMissing(db, col.table, col.column, col.definition); err != nil {
+			return nil, err
+		}
+	}
+	store := &DeploymentStore{db: db, progress: progressTracker{states: make(map[string]*progressState)}}
+	if err := store.createArtifactsTable(); err != nil {
+		return nil, err
+	}
+	if err := store.createKubeconfigsTable(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// addColumnIfMissing 为旧版本数据库的表添加新列
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	var columnExists bool
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info('%s') WHERE name = ?", table), column).Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check %s column: %v", column, err)
+	}
+	if columnExists {
+		return nil
+	}
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s column: %v", column, err)
+	}
+	return nil
+}
+
+// nodeKey 将节点ID列表规范化为排序后的字符串，用于匹配同一组节点
+func nodeKey(nodeIDs []string) string {
+	ids := append([]string(nil), nodeIDs...)
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// CreateDeployment 创建部署记录
+func (s *DeploymentStore) CreateDeployment(d Deployment) (*Deployment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if d.ID == "" {
+		d.ID = fmt.Sprintf("%d", now.UnixNano())
+	}
+	d.Status = DeploymentStatusRunning
+	if d.InstallerType == "" {
+		d.InstallerType = InstallerTypeKubeadm
+	}
+	d.CreatedAt = now
+	d.UpdatedAt = now
+
+	_, err := s.db.Exec(
+		"INSERT INTO deployments (id, kube_version, arch, distro, installer_type, node_ids, status, error, package_pinning, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		d.ID, d.KubeVersion, d.Arch, d.Distro, d.InstallerType, nodeKey(d.NodeIDs), d.Status, "", d.PackagePinning, d.Description, d.CreatedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert deployment: %v", err)
+	}
+	return &d, nil
+}
+
+// RecordAdoptedCluster 为导入的已有集群创建部署记录，节点即集群成员，用于拆除等集群级操作
+func (s *DeploymentStore) RecordAdoptedCluster(d Deployment) (*Deployment, error) {
+	deployment, err := s.CreateDeployment(d)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UpdateDeploymentStatus(deployment.ID, DeploymentStatusAdopted, ""); err != nil {
+		return nil, err
+	}
+	deployment.Status = DeploymentStatusAdopted
+	return deployment, nil
+}
+
+// UpdateDeploymentStatus 更新部署状态
+func (s *DeploymentStore) UpdateDeploymentStatus(id, status, errMsg string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.db.Exec("UPDATE deployments SET status = ?, error = ?, error_code = '', updated_at = ? WHERE id = ?", status, errMsg, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update deployment: %v", err)
+	}
+	return nil
+}
+
+// FailDeployment 将部署标记为失败，记录错误码以及根据错误和部署输出识别的故障。
+// 识别出的故障同时附加到没有识别结果的失败步骤上，步骤错误通常不包含命令输出
+func (s *DeploymentStore) FailDeployment(id string, deployErr error, output string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	diagnoses := diagnose.Join(diagnose.Match(deployErr.Error() + "\n" + output))
+	_, err := s.db.Exec("UPDATE deployments SET status = ?, error = ?, error_code = ?, diagnoses = ?, updated_at = ? WHERE id = ?", DeploymentStatusFailed, deployErr.Error(), DeploymentErrorCode(deployErr), diagnoses, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update deployment: %v", err)
+	}
+	if diagnoses != "" {
+		if _, err := s.db.Exec("UPDATE deployment_steps SET diagnoses = ? WHERE deployment_id = ? AND status = ? AND diagnoses = ''", diagnoses, id, DeploymentStatusFailed); err != nil {
+			return fmt.Errorf("failed to update deployment steps: %v", err)
+		}
+	}
+	return nil
+}
+
+// scanDeployment 扫描一行部署记录
+func scanDeployment(scanner interface{ Scan(...interface{}) error }) (*Deployment, error) {
+	var d Deployment
+	var nodeIDs string
+	var errMsg sql.NullString
+	var diagnoses string
+	if err := scanner.Scan(&d.ID, &d.KubeVersion, &d.Arch, &d.Distro, &d.InstallerType, &nodeIDs, &d.Status, &errMsg, &d.ErrorCode, &diagnoses, &d.PackagePinning, &d.Description, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	d.Error = errMsg.String
+	d.diagnosisIDs = diagnose.Split(diagnoses)
+	d.NodeIDs = []string{}
+	if nodeIDs != "" {
+		d.NodeIDs = strings.Split(nodeIDs, ",")
+	}
+	return &d, nil
+}
+
+// ListDeployments 获取最近的部署记录
+func (s *DeploymentStore) ListDeployments(limit int) ([]Deployment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query("SELECT id, kube_version, arch, distro, installer_type, node_ids, status, error, error_code, diagnoses, package_pinning, description, created_at, updated_at FROM deployments ORDER BY created_at DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployments: %v", err)
+	}
+	defer rows.Close()
+
+	deployments := []Deployment{}
+	for rows.Next() {
+		d, err := scanDeployment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deployment: %v", err)
+		}
+		deployments = append(deployments, *d)
+	}
+	return deployments, rows.Err()
+}
+
+// GetDeployment 获取部署记录及其步骤
+func (s *DeploymentStore) GetDeployment(id string) (*Deployment, error) {
+	s.mutex.RLock()
+	row := s.db.QueryRow("SELECT id, kube_version, arch, distro, installer_type, node_ids, status, error, error_code, diagnoses, package_pinning, description, created_at, updated_at FROM deployments WHERE id = ?", id)
+	d, err := scanDeployment(row)
+	s.mutex.RUnlock()
+	if err == sql.ErrNoRows {
+		return nil, ErrDeploymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployment: %v", err)
+	}
+
+	steps, err := s.GetSteps(id)
+	if err != nil {
+		return nil, err
+	}
+	d.Steps = steps
+	return d, nil
+}
+
+// FindResumableDeployment 查找同一组节点、同一版本最近一次失败的部署
+func (s *DeploymentStore) FindResumableDeployment(nodeIDs []string, kubeVersion string) (*Deployment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	row := s.db.QueryRow(
+		"SELECT id, kube_version, arch, distro, installer_type, node_ids, status, error, error_code, diagnoses, package_pinning, description, created_at, updated_at FROM deployments WHERE node_ids = ? AND kube_version = ? AND status = ? ORDER BY created_at DESC LIMIT 1",
+		nodeKey(nodeIDs), kubeVersion, DeploymentStatusFailed,
+	)
+	d, err := scanDeployment(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrDeploymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployment: %v", err)
+	}
+	return d, nil
+}
+
+// FindClusterDeployment 查找包含master节点的最近一次未拆除的部署，其节点即集群成员
+func (s *DeploymentStore) FindClusterDeployment(masterID string) (*Deployment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	row := s.db.QueryRow(
+		"SELECT id, kube_version, arch, distro, installer_type, node_ids, status, error, error_code, diagnoses, package_pinning, description, created_at, updated_at FROM deployments WHERE ',' || node_ids || ',' LIKE ? AND status != ? ORDER BY created_at DESC LIMIT 1",
+		"%,"+masterID+",%", DeploymentStatusTornDown,
+	)
+	d, err := scanDeployment(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrDeploymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployment: %v", err)
+	}
+	return d, nil
+}
+
+// ListNodeDeployments 获取包含指定节点的所有部署记录，按创建时间排序
+func (s *DeploymentStore) ListNodeDeployments(nodeID string) ([]Deployment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rows, err := s.db.Query(
+		"SELECT id, kube_version, arch, distro, installer_type, node_ids, status, error, error_code, diagnoses, package_pinning, description, created_at, updated_at FROM deployments WHERE ',' || node_ids || ',' LIKE ? ORDER BY created_at",
+		"%,"+nodeID+",%",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployments: %v", err)
+	}
+	defer rows.Close()
+
+	deployments := []Deployment{}
+	for rows.Next() {
+		d, err := scanDeployment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deployment: %v", err)
+		}
+		deployments = append(deployments, *d)
+	}
+	return deployments, rows.Err()
+}
+
+// SetPackagePinning 更新集群的软件包版本固定设置，向集群添加节点时按该设置固定组件版本
+func (s *DeploymentStore) SetPackagePinning(id string, enabled bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	res, err := s.db.Exec("UPDATE deployments SET package_pinning = ?, updated_at = ? WHERE id = ?", enabled, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update deployment: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDeploymentNotFound
+	}
+	return nil
+}
+
+// AddNodes 将节点加入部署记录的节点列表，向已有集群添加节点后更新集群成员
+func (s *DeploymentStore) AddNodes(id string, nodeIDs []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var current string
+	if err := s.db.QueryRow("SELECT node_ids FROM deployments WHERE id = ?", id).Scan(&current); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrDeploymentNotFound
+		}
+		return fmt.Errorf("failed to query deployment: %v", err)
+	}
+	members := strings.Split(current, ",")
+	for _, nodeID := range nodeIDs {
+		found := false
+		for _, member := range members {
+			if member == nodeID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			members = append(members, nodeID)
+		}
+	}
+	if _, err := s.db.Exec("UPDATE deployments SET node_ids = ?, updated_at = ? WHERE id = ?", nodeKey(members), time.Now(), id); err != nil {
+		return fmt.Errorf("failed to update deployment: %v", err)
+	}
+	return nil
+}
+
+// GetSteps 获取部署的所有步骤记录
+func (s *DeploymentStore) GetSteps(deploymentID string) ([]StepRecord, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rows, err := s.db.Query("SELECT node_id, step, status, error, error_code, diagnoses, duration_ms, updated_at FROM deployment_steps WHERE deployment_id = ? ORDER BY updated_at", deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployment steps: %v", err)
+	}
+	defer rows.Close()
+
+	steps := []StepRecord{}
+	for rows.Next() {
+		var step StepRecord
+		var errMsg sql.NullString
+		var diagnoses string
+		if err := rows.Scan(&step.NodeID, &step.Step, &step.Status, &errMsg, &step.ErrorCode, &diagnoses, &step.DurationMs, &step.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deployment step: %v", err)
+		}
+		step.Error = errMsg.String
+		step.diagnosisIDs = diagnose.Split(diagnoses)
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// Tracker 返回指定部署的步骤记录器
+func (s *DeploymentStore) Tracker(deploymentID string) StepTracker {
+	return &deploymentStepTracker{store: s, deploymentID: deploymentID}
+}
+
+// deploymentStepTracker 基于数据库的步骤记录器
+type deploymentStepTracker struct {
+	store        *DeploymentStore
+	deploymentID string
+}
+
+// IsStepCompleted 步骤是否已成功执行
+func (t *deploymentStepTracker) IsStepCompleted(nodeID, step string) bool {
+	t.store.mutex.RLock()
+	defer t.store.mutex.RUnlock()
+
+	var status string
+	err := t.store.db.QueryRow(
+		"SELECT status FROM deployment_steps WHERE deployment_id = ? AND node_id = ? AND step = ?",
+		t.deploymentID, nodeID, step,
+	).Scan(&status)
+	return err == nil && status == DeploymentStatusSuccess
+}
+
+// StartStep 记录步骤开始时间
+func (t *deploymentStepTracker) StartStep(nodeID, step string) {
+	t.store.stepStarted(t.deploymentID, nodeID, step, time.Now())
+}
+
+// MarkStep 记录步骤执行结果和耗时
+func (t *deploymentStepTracker) MarkStep(nodeID, step string, err error) {
+	now := time.Now()
+	duration := t.store.stepFinished(t.deploymentID, nodeID, step, err, now)
+
+	t.store.mutex.Lock()
+	defer t.store.mutex.Unlock()
+
+	status := DeploymentStatusSuccess
+	errMsg := ""
+	var code errcode.Code
+	diagnoses := ""
+	if err != nil {
+		status = DeploymentStatusFailed
+		errMsg = err.Error()
+		code = StepErrorCode(step, err)
+		diagnoses = diagnose.Join(diagnose.Match(errMsg))
+	}
+	if _, dbErr := t.store.db.Exec(
+		"INSERT OR REPLACE INTO deployment_steps (deployment_id, node_id, step, status, error, error_code, diagnoses, duration_ms, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		t.deploymentID, nodeID, step, status, errMsg, code, diagnoses, duration.Milliseconds(), now,
+	); dbErr != nil {
+		fmt.Printf("记录部署步骤失败: %v\n", dbErr)
+	}
+}
+
+// DeploymentErrorCode 部署失败的错误码，无法识别时为ERR_STEP_FAILED
+func DeploymentErrorCode(err error) errcode.Code {
+	if code := errcode.Of(err); code != "" {
+		return code
+	}
+	return errcode.StepFailed
+}
+
+// StepErrorCode 步骤失败的错误码：优先根据错误识别，否则按步骤选择
+func StepErrorCode(step string, err error) errcode.Code {
+	if code := errcode.Of(err); code != "" {
+		return code
+	}
+	switch step {
+	case StepMasterInitialization:
+		return errcode.KubeadmInit
+	case StepWorkerJoin:
+		return errcode.KubeadmJoin
+	case StepImagePrepull:
+		return errcode.ImagePull
+	}
+	return errcode.StepFailed
+}
+
+// Localize 按语言填充部署和步骤错误码的说明以及故障处理建议
+func (d *Deployment) Localize(lang string) {
+	d.ErrorMessage = errcode.Message(d.ErrorCode, lang)
+	d.Diagnoses = diagnose.Lookup(d.diagnosisIDs, lang)
+	for i := range d.Steps {
+		d.Steps[i].ErrorMessage = errcode.Message(d.Steps[i].ErrorCode, lang)
+		d.Steps[i].Diagnoses = diagnose.Lookup(d.Steps[i].diagnosisIDs, lang)
+	}
+}
@@ -0,0 +1,59 @@
+package kubeadm
+
+import (
+	"strings"
+
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+// customScriptSteps 可以使用自定义脚本的部署步骤
+var customScriptSteps = []struct {
+	step       string
+	masterOnly bool
+}{
+	{script.StepSystemPrep, false},
+	{script.StepContainerdInstall, false},
+	{script.StepContainerdConfig, false},
+	{script.StepK8sRepo, false},
+	{script.StepK8sComponents, false},
+	{script.StepK8sInit, true},
+}
+
+// nodeDestructiveScripts 检查节点在发行版distro上使用的自定义脚本中的危险命令，
+// 按部署时的顺序解析脚本，默认模板不检查，scriptManager需已应用节点组的脚本替换
+func nodeDestructiveScripts(scriptManager interface{}, distro, kubeVersion string, master bool) []script.DestructiveFinding {
+	var findings []script.DestructiveFinding
+	for _, s := range customScriptSteps {
+		if s.masterOnly && !master {
+			continue
+		}
+		resolved, ok := script.Resolve(scriptManager, s.step, distro, "")
+		if !ok || resolved.Default {
+			continue
+		}
+		findings = append(findings, script.ScanDestructive(resolved.Name, strings.ReplaceAll(resolved.Content, "${version}", kubeVersion))...)
+	}
+	return findings
+}
+
+// DestructiveScripts 部署前检查所有节点将使用的自定义脚本中的危险命令，节点发行版未知时使用distro，
+// 同一脚本只返回一次。部署时按检测到的发行版再次检查，未设置AllowDestructiveScripts时拒绝执行
+func DestructiveScripts(scriptManager interface{}, nodes []node.Node, kubeVersion, distro string, opts DeployOptions) []script.DestructiveFinding {
+	seen := make(map[string]bool)
+	var findings []script.DestructiveFinding
+	for _, n := range nodes {
+		nodeDistro := n.OS
+		if nodeDistro == "" {
+			nodeDistro = distro
+		}
+		scripts := withScriptOverrides(scriptManager, groupDefaultsFor(opts, n.ID).ScriptOverrides)
+		for _, f := range nodeDestructiveScripts(scripts, strings.ToLower(nodeDistro), kubeVersion, n.NodeType == node.NodeTypeMaster || opts.SingleNode) {
+			if key := f.String(); !seen[key] {
+				seen[key] = true
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,29 @@
+package kubeadm
+
+import (
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// 部署循环中的node变量会遮蔽node包，发行版相关的常量和函数通过以下别名使用
+const (
+	distroFamilyDebian = node.DistroFamilyDebian
+	distroFamilyRHEL   = node.DistroFamilyRHEL
+	distroFamilySUSE   = node.DistroFamilySUSE
+	distroFamilyAmazon = node.DistroFamilyAmazon
+)
+
+// nodeOS 返回节点记录中缓存的操作系统信息，没有缓存时在节点上检测，并通过OnOSDetected保存检测结果
+func nodeOS(client ssh.Runner, n node.Node, opts DeployOptions) (node.OSInfo, error) {
+	if n.OSInfo != nil {
+		return *n.OSInfo, nil
+	}
+	info, err := node.DetectOS(client)
+	if err != nil {
+		return node.OSInfo{}, err
+	}
+	if opts.OnOSDetected != nil {
+		opts.OnOSDetected(n.ID, info)
+	}
+	return info, nil
+}
@@ -0,0 +1,248 @@
+package kubeadm
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s-installer/node"
+	"k8s-installer/validate"
+)
+
+// 节点DNS配置
+const (
+	// systemdResolvedStub systemd-resolved的本地stub解析地址，kubelet使用该地址时CoreDNS会把请求转发回自身
+	systemdResolvedStub = "127.0.0.53"
+	// SystemdResolvedResolvConf systemd-resolved维护的上游DNS服务器配置
+	SystemdResolvedResolvConf = "/run/systemd/resolve/resolv.conf"
+	// ResolvConfAuto kubelet --resolv-conf的自动模式：使用stub解析时改用SystemdResolvedResolvConf
+	ResolvConfAuto = "auto"
+	// resolvConfAutoPlaceholder 自动模式在kubelet参数中的占位符，写入节点时替换
+	resolvConfAutoPlaceholder = "RESOLV_CONF_AUTO"
+)
+
+// DNSCheckOptions 节点DNS检查选项
+type DNSCheckOptions struct {
+	// ImageRepository 镜像仓库，为空时检查默认镜像仓库的域名
+	ImageRepository string `json:"imageRepository,omitempty"`
+	// ControlPlaneEndpoint 控制平面地址，为域名时检查解析
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint,omitempty"`
+	// Hosts 额外检查的域名
+	Hosts []string `json:"hosts,omitempty"`
+	// ClusterDNS 部署后检查：从节点通过集群DNS解析kubernetes.default
+	ClusterDNS bool `json:"clusterDns,omitempty"`
+}
+
+// Validate 检查地址和域名
+func (o DNSCheckOptions) Validate() error {
+	v := &validate.Validator{}
+	if o.ImageRepository != "" {
+		v.HostPort("imageRepository", strings.SplitN(o.ImageRepository, "/", 2)[0])
+	}
+	v.HostPort("controlPlaneEndpoint", o.ControlPlaneEndpoint)
+	for _, host := range o.Hosts {
+		v.DNSSubdomain("hosts", strings.ToLower(host))
+	}
+	return v.Err()
+}
+
+// hosts 需要解析的域名：镜像仓库、控制平面地址和额外域名，IP地址不需要解析
+func (o DNSCheckOptions) hosts() []string {
+	repository := o.ImageRepository
+	if repository == "" {
+		repository = DefaultImageRepository
+	}
+	candidates := []string{strings.SplitN(repository, "/", 2)[0], o.ControlPlaneEndpoint}
+	candidates = append(candidates, o.Hosts...)
+
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, host := range candidates {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.Trim(host, "[]"))
+		if host == "" || net.ParseIP(host) != nil || seen[host] {
+			continue
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// NodeDNSCheckCmd 生成节点DNS检查命令：输出/etc/resolv.conf和systemd-resolved的DNS服务器、kubelet使用的resolv.conf、
+// 每个域名的解析结果，以及可选的集群DNS解析结果
+func NodeDNSCheckCmd(opts DNSCheckOptions) string {
+	quoted := make([]string, 0, len(opts.hosts()))
+	for _, host := range opts.hosts() {
+		quoted = append(quoted, shellQuote(host))
+	}
+	clusterDNS := "false"
+	if opts.ClusterDNS {
+		clusterDNS = "true"
+	}
+	return fmt.Sprintf(`for ns in $(awk '$1 == "nameserver" {print $2}' /etc/resolv.conf 2>/dev/null); do
+    echo "NAMESERVER $ns"
+done
+if [ -f %[1]s ]; then
+    echo "UPSTREAM %[1]s $(awk '$1 == "nameserver" {print $2}' %[1]s | tr '\n' ' ')"
+fi
+
+# kubelet使用的resolv.conf：命令行参数优先于配置文件
+KUBELET_RESOLV=$(cat %[2]s /etc/default/kubelet /etc/sysconfig/kubelet 2>/dev/null | grep -o -- '--resolv-conf=[^ "]*' | tail -n 1 | cut -d= -f2)
+if [ -z "$KUBELET_RESOLV" ] && [ -f /var/lib/kubelet/config.yaml ]; then
+    KUBELET_RESOLV=$(awk '$1 == "resolvConf:" {print $2}' /var/lib/kubelet/config.yaml | tr -d '"')
+fi
+[ -n "$KUBELET_RESOLV" ] && echo "KUBELET_RESOLV $KUBELET_RESOLV"
+
+for host in %[3]s; do
+    addrs=$(getent ahosts "$host" 2>/dev/null | awk '{print $1}' | sort -u | tr '\n' ',' | sed 's/,$//')
+    if [ -n "$addrs" ]; then
+        echo "HOST $host ok $addrs"
+    else
+        echo "HOST $host fail -"
+    fi
+done
+
+if %[4]s; then
+    CLUSTER_DNS=$(awk '/^clusterDNS:/ {getline; print $2}' /var/lib/kubelet/config.yaml 2>/dev/null)
+    CLUSTER_DOMAIN=$(awk '$1 == "clusterDomain:" {print $2}' /var/lib/kubelet/config.yaml 2>/dev/null | tr -d '"')
+    NAME=kubernetes.default.svc.${CLUSTER_DOMAIN:-cluster.local}
+    if [ -z "$CLUSTER_DNS" ]; then
+        echo "CLUSTERDNS - unverified kubelet config not found"
+    elif command -v nslookup &> /dev/null; then
+        if timeout 10 nslookup "$NAME" "$CLUSTER_DNS" > /dev/null 2>&1; then
+            echo "CLUSTERDNS $CLUSTER_DNS ok $NAME"
+        else
+            echo "CLUSTERDNS $CLUSTER_DNS fail cannot resolve $NAME"
+        fi
+    elif command -v dig &> /dev/null; then
+        if [ -n "$(timeout 10 dig +short "$NAME" @"$CLUSTER_DNS")" ]; then
+            echo "CLUSTERDNS $CLUSTER_DNS ok $NAME"
+        else
+            echo "CLUSTERDNS $CLUSTER_DNS fail cannot resolve $NAME"
+        fi
+    else
+        echo "CLUSTERDNS $CLUSTER_DNS unverified nslookup or dig is required"
+    fi
+fi`, SystemdResolvedResolvConf, KubeletDropInPath, strings.Join(quoted, " "), clusterDNS)
+}
+
+// HostResolution 域名解析结果
+type HostResolution struct {
+	Host      string   `json:"host"`
+	Resolved  bool     `json:"resolved"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// ClusterDNSResult 从节点通过集群DNS解析的结果
+type ClusterDNSResult struct {
+	Server  string `json:"server,omitempty"`
+	Status  string `json:"status"` // ok、fail或unverified
+	Message string `json:"message,omitempty"`
+}
+
+// NodeDNSReport 单个节点的DNS检查结果
+type NodeDNSReport struct {
+	NodeID      string   `json:"nodeId"`
+	NodeName    string   `json:"nodeName"`
+	Passed      bool     `json:"passed"`
+	Nameservers []string `json:"nameservers"`
+	// StubResolver /etc/resolv.conf使用systemd-resolved的stub解析地址
+	StubResolver bool `json:"stubResolver"`
+	// UpstreamNameservers systemd-resolved使用的上游DNS服务器
+	UpstreamNameservers []string `json:"upstreamNameservers,omitempty"`
+	// KubeletResolvConf kubelet当前使用的resolv.conf，节点未安装kubelet时为空
+	KubeletResolvConf string `json:"kubeletResolvConf,omitempty"`
+	// RecommendedResolvConf 建议kubelet使用的resolv.conf
+	RecommendedResolvConf string            `json:"recommendedResolvConf,omitempty"`
+	Hosts                 []HostResolution  `json:"hosts"`
+	ClusterDNS            *ClusterDNSResult `json:"clusterDns,omitempty"`
+	Problems              []string          `json:"problems,omitempty"`
+	Warnings              []string          `json:"warnings,omitempty"`
+	Error                 string            `json:"error,omitempty"`
+}
+
+// ParseNodeDNSCheck 解析NodeDNSCheckCmd的输出，检查域名解析、stub解析地址和kubelet使用的resolv.conf
+func ParseNodeDNSCheck(n node.Node, res node.ExecResult) NodeDNSReport {
+	report := NodeDNSReport{NodeID: n.ID, NodeName: n.Name, Nameservers: []string{}, Hosts: []HostResolution{}}
+	if !res.Success {
+		report.Error = res.Error
+		return report
+	}
+
+	upstream := false
+	for _, line := range strings.Split(res.Output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "NAMESERVER":
+			report.Nameservers = append(report.Nameservers, fields[1])
+		case "UPSTREAM":
+			upstream = true
+			report.UpstreamNameservers = fields[2:]
+		case "KUBELET_RESOLV":
+			report.KubeletResolvConf = fields[1]
+		case "HOST":
+			if len(fields) < 4 {
+				continue
+			}
+			host := HostResolution{Host: fields[1], Resolved: fields[2] == "ok"}
+			if host.Resolved {
+				host.Addresses = strings.Split(fields[3], ",")
+			} else {
+				report.Problems = append(report.Problems, fmt.Sprintf("cannot resolve %s", host.Host))
+			}
+			report.Hosts = append(report.Hosts, host)
+		case "CLUSTERDNS":
+			if len(fields) < 3 {
+				continue
+			}
+			result := &ClusterDNSResult{Status: fields[2], Message: strings.Join(fields[3:], " ")}
+			if fields[1] != "-" {
+				result.Server = fields[1]
+			}
+			if result.Status == "fail" {
+				report.Problems = append(report.Problems, fmt.Sprintf("cluster DNS %s: %s", result.Server, result.Message))
+			}
+			report.ClusterDNS = result
+		}
+	}
+
+	if len(report.Nameservers) == 0 {
+		report.Problems = append(report.Problems, "no nameserver configured in /etc/resolv.conf")
+	}
+	for _, ns := range report.Nameservers {
+		ip := net.ParseIP(ns)
+		if ip == nil || !ip.IsLoopback() {
+			continue
+		}
+		if ns == systemdResolvedStub {
+			report.StubResolver = true
+			continue
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf("nameserver %s is a loopback address, CoreDNS forwarding to it will loop unless kubelet uses a resolv.conf with upstream servers", ns))
+	}
+
+	if report.StubResolver {
+		if upstream {
+			report.RecommendedResolvConf = SystemdResolvedResolvConf
+		} else {
+			report.Problems = append(report.Problems, "/etc/resolv.conf points to the systemd-resolved stub but "+SystemdResolvedResolvConf+" does not exist")
+		}
+		if report.KubeletResolvConf == "/etc/resolv.conf" {
+			report.Problems = append(report.Problems, "kubelet uses /etc/resolv.conf with the systemd-resolved stub resolver, CoreDNS will loop; set kubelet resolvConf to "+SystemdResolvedResolvConf)
+		}
+	}
+	report.Passed = len(report.Problems) == 0
+	return report
+}
+
+// resolveResolvConfCmd 在节点上选择kubelet自动模式使用的resolv.conf，结果保存在RESOLV_CONF变量中
+const resolveResolvConfCmd = `RESOLV_CONF=/etc/resolv.conf
+if grep -q "^nameserver ` + systemdResolvedStub + `" /etc/resolv.conf 2>/dev/null && [ -f ` + SystemdResolvedResolvConf + ` ]; then
+    RESOLV_CONF=` + SystemdResolvedResolvConf + `
+fi`
@@ -0,0 +1,328 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/node"
+)
+
+// 漂移检查默认配置
+const (
+	DefaultDriftInterval = 10 * time.Minute
+	MinDriftInterval     = time.Minute
+)
+
+// 漂移类型
+const (
+	// DriftNotRegistered 节点在集群中但不在安装器的集群成员中
+	DriftNotRegistered = "not_registered"
+	// DriftMissingFromCluster 节点是集群成员但kubectl get nodes中没有该节点
+	DriftMissingFromCluster = "missing_from_cluster"
+	// DriftVersionMismatch kubelet版本与部署记录的版本不一致
+	DriftVersionMismatch = "version_mismatch"
+	// DriftNotReady 节点在集群中但未就绪
+	DriftNotReady = "not_ready"
+	// DriftRoleMismatch 节点在集群中的角色与节点列表中的类型不一致
+	DriftRoleMismatch = "role_mismatch"
+)
+
+// DriftItem 一处数据库记录与集群实际状态的差异
+type DriftItem struct {
+	Type     string `json:"type"`
+	NodeID   string `json:"nodeId,omitempty"`
+	NodeName string `json:"nodeName"`
+	IP       string `json:"ip,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Message  string `json:"message"`
+}
+
+// DriftReport 集群漂移检查结果，InSync为true表示没有差异
+type DriftReport struct {
+	ClusterID       string `json:"clusterId"`
+	DeploymentID    string `json:"deploymentId,omitempty"`
+	ExpectedVersion string `json:"expectedVersion,omitempty"`
+	// Access 读取集群状态使用的访问方式：api或ssh
+	Access    string           `json:"access,omitempty"`
+	InSync    bool             `json:"inSync"`
+	Items     []DriftItem      `json:"items"`
+	Nodes     []DiscoveredNode `json:"nodes"`
+	Error     string           `json:"error,omitempty"`
+	CheckedAt time.Time        `json:"checkedAt"`
+}
+
+// normalizeKubeVersion 去掉版本号的v前缀和k3s等构建后缀，如v1.30.2+k3s1为1.30.2
+func normalizeKubeVersion(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "+-"); i >= 0 {
+		version = version[:i]
+	}
+	return version
+}
+
+// CompareDrift 比较集群成员节点与kubectl get nodes的结果。节点先按IP匹配，再按名称匹配；
+// expectedVersion为部署记录的Kubernetes版本，为空时不检查版本
+func CompareDrift(members []node.Node, expectedVersion string, discovery *ClusterDiscovery) []DriftItem {
+	items := []DriftItem{}
+	matched := make(map[int]bool)
+
+	find := func(n node.Node) int {
+		for i, d := range discovery.Nodes {
+			if !matched[i] && n.IP != "" && d.IP == n.IP {
+				return i
+			}
+		}
+		for i, d := range discovery.Nodes {
+			if !matched[i] && strings.EqualFold(d.Name, n.Name) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	expected := normalizeKubeVersion(expectedVersion)
+	for _, n := range members {
+		i := find(n)
+		if i < 0 {
+			items = append(items, DriftItem{
+				Type: DriftMissingFromCluster, NodeID: n.ID, NodeName: n.Name, IP: n.IP,
+				Message: fmt.Sprintf("node %s (%s) is a cluster member but is not in kubectl get nodes", n.Name, n.IP),
+			})
+			continue
+		}
+		matched[i] = true
+		d := discovery.Nodes[i]
+		if !d.Ready {
+			items = append(items, DriftItem{
+				Type: DriftNotReady, NodeID: n.ID, NodeName: d.Name, IP: d.IP,
+				Message: fmt.Sprintf("node %s is not Ready", d.Name),
+			})
+		}
+		if actual := normalizeKubeVersion(d.KubeletVersion); expected != "" && actual != "" && actual != expected {
+			items = append(items, DriftItem{
+				Type: DriftVersionMismatch, NodeID: n.ID, NodeName: d.Name, IP: d.IP,
+				Expected: expected, Actual: actual,
+				Message: fmt.Sprintf("node %s runs kubelet %s, expected %s", d.Name, d.KubeletVersion, expected),
+			})
+		}
+		if n.NodeType != "" && d.NodeType != n.NodeType {
+			items = append(items, DriftItem{
+				Type: DriftRoleMismatch, NodeID: n.ID, NodeName: d.Name, IP: d.IP,
+				Expected: n.NodeType, Actual: d.NodeType,
+				Message: fmt.Sprintf("node %s is registered as %s but is a %s in the cluster", d.Name, n.NodeType, d.NodeType),
+			})
+		}
+	}
+
+	for i, d := range discovery.Nodes {
+		if matched[i] {
+			continue
+		}
+		items = append(items, DriftItem{
+			Type: DriftNotRegistered, NodeName: d.Name, IP: d.IP,
+			Message: fmt.Sprintf("node %s (%s) is in the cluster but is not registered in the installer", d.Name, d.IP),
+		})
+	}
+	return items
+}
+
+// DriftHandler 发现集群漂移时的回调
+type DriftHandler func(report DriftReport)
+
+// DriftReconciler 定期比较每个集群的成员节点与集群实际节点列表（通过API Server或master节点上的kubectl读取），保存最近一次检查结果
+type DriftReconciler struct {
+	nodeManager     *node.SqliteNodeManager
+	deploymentStore *DeploymentStore
+
+	mutex    sync.Mutex
+	interval time.Duration
+	enabled  bool
+	stopChan chan struct{}
+	reports  map[string]DriftReport
+	handlers []DriftHandler
+}
+
+// NewDriftReconciler 创建集群漂移检查器
+func NewDriftReconciler(nodeManager *node.SqliteNodeManager, deploymentStore *DeploymentStore, interval time.Duration) *DriftReconciler {
+	if interval < MinDriftInterval {
+		interval = DefaultDriftInterval
+	}
+	return &DriftReconciler{
+		nodeManager:     nodeManager,
+		deploymentStore: deploymentStore,
+		interval:        interval,
+		reports:         make(map[string]DriftReport),
+	}
+}
+
+// OnDrift 注册回调，定期检查发现新的差异时调用
+func (r *DriftReconciler) OnDrift(handler DriftHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handlers = append(r.handlers, handler)
+}
+
+// Start 启动定期检查
+func (r *DriftReconciler) Start() {
+	r.mutex.Lock()
+	if r.enabled {
+		r.mutex.Unlock()
+		return
+	}
+	r.enabled = true
+	r.stopChan = make(chan struct{})
+	stopChan := r.stopChan
+	interval := r.interval
+	r.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.ReconcileAll()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定期检查
+func (r *DriftReconciler) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.enabled {
+		return
+	}
+	r.enabled = false
+	close(r.stopChan)
+}
+
+// ReconcileAll 检查所有部署成功或导入的集群，部署中、失败和已拆除的集群不检查
+func (r *DriftReconciler) ReconcileAll() {
+	nodes, err := r.nodeManager.GetNodes()
+	if err != nil {
+		fmt.Printf("漂移检查获取节点列表失败: %v\n", err)
+		return
+	}
+	for _, n := range nodes {
+		if n.NodeType != node.NodeTypeMaster {
+			continue
+		}
+		deployment, err := r.deploymentStore.FindClusterDeployment(n.ID)
+		if err != nil || (deployment.Status != DeploymentStatusSuccess && deployment.Status != DeploymentStatusAdopted) {
+			continue
+		}
+
+		previous, hadPrevious := r.LastReport(n.ID)
+		report, err := r.Reconcile(n.ID)
+		if err != nil {
+			fmt.Printf("集群 %s 漂移检查失败: %v\n", n.ID, err)
+			continue
+		}
+		if report.Error != "" {
+			fmt.Printf("集群 %s 漂移检查失败: %s\n", n.ID, report.Error)
+			continue
+		}
+		if !report.InSync && (!hadPrevious || !sameDrift(previous.Items, report.Items)) {
+			r.emitDrift(report)
+		}
+	}
+}
+
+// Reconcile 立即检查集群并保存结果，clusterID为master节点ID。
+// 集群不存在时返回ErrDeploymentNotFound；无法连接master节点时结果的Error不为空
+func (r *DriftReconciler) Reconcile(clusterID string) (DriftReport, error) {
+	master, err := r.nodeManager.GetNode(clusterID)
+	if err != nil {
+		return DriftReport{}, err
+	}
+	if master.NodeType != node.NodeTypeMaster {
+		return DriftReport{}, fmt.Errorf("node %s is not a master node", clusterID)
+	}
+	deployment, err := r.deploymentStore.FindClusterDeployment(clusterID)
+	if err != nil {
+		return DriftReport{}, err
+	}
+
+	report := DriftReport{
+		ClusterID:       clusterID,
+		DeploymentID:    deployment.ID,
+		ExpectedVersion: normalizeKubeVersion(deployment.KubeVersion),
+		Items:           []DriftItem{},
+		Nodes:           []DiscoveredNode{},
+		CheckedAt:       time.Now(),
+	}
+
+	members := make([]node.Node, 0, len(deployment.NodeIDs))
+	for _, id := range deployment.NodeIDs {
+		n, err := r.nodeManager.GetNode(id)
+		if err != nil {
+			// 节点已从节点列表删除，但仍在部署记录中
+			report.Items = append(report.Items, DriftItem{
+				Type: DriftMissingFromCluster, NodeID: id,
+				Message: fmt.Sprintf("node %s is a cluster member but no longer exists in the installer", id),
+			})
+			continue
+		}
+		members = append(members, *n)
+	}
+
+	executor := NewClusterExecutor(r.deploymentStore, *master)
+	defer executor.Close()
+	discovery, access, err := executor.Discover(context.Background())
+	report.Access = access
+	if err != nil {
+		report.Error = err.Error()
+	} else {
+		report.Nodes = discovery.Nodes
+		report.Items = append(report.Items, CompareDrift(members, report.ExpectedVersion, discovery)...)
+		report.InSync = len(report.Items) == 0
+	}
+
+	r.mutex.Lock()
+	r.reports[clusterID] = report
+	r.mutex.Unlock()
+	return report, nil
+}
+
+// LastReport 返回集群最近一次检查结果
+func (r *DriftReconciler) LastReport(clusterID string) (DriftReport, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	report, ok := r.reports[clusterID]
+	return report, ok
+}
+
+// emitDrift 记录差异并通知回调
+func (r *DriftReconciler) emitDrift(report DriftReport) {
+	fmt.Printf("集群 %s 与数据库记录不一致: %d 处差异\n", report.ClusterID, len(report.Items))
+
+	r.mutex.Lock()
+	handlers := append([]DriftHandler(nil), r.handlers...)
+	r.mutex.Unlock()
+	for _, handler := range handlers {
+		handler(report)
+	}
+}
+
+// sameDrift 两次检查的差异是否相同，相同时不重复通知
+func sameDrift(a, b []DriftItem) bool {
+	key := func(items []DriftItem) string {
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			keys = append(keys, item.Type+"/"+item.NodeID+"/"+item.NodeName+"/"+item.Actual)
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ",")
+	}
+	return key(a) == key(b)
+}
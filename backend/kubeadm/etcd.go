@@ -0,0 +1,133 @@
+package kubeadm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s-installer/ssh"
+	"k8s-installer/validate"
+)
+
+// ExternalEtcdCertDir 外部etcd客户端证书在控制平面节点上的目录，kubeadm配置中的caFile等指向该目录
+const ExternalEtcdCertDir = KubernetesPKIDir + "/etcd-external"
+
+// etcdStagingDir 上传的etcd证书暂存目录，与集群CA一样在kubeadm reset之后、kubeadm init之前复制到证书目录
+const etcdStagingDir = "/tmp/k8s-installer-etcd"
+
+// ExternalEtcd 外部etcd集群：endpoints为etcd客户端地址，ca/cert/key为访问etcd的CA证书和客户端证书（PEM）。
+// 配置后kubeadm不在控制平面节点上运行etcd
+type ExternalEtcd struct {
+	Endpoints []string `json:"endpoints"`
+	CA        string   `json:"ca,omitempty"`
+	Cert      string   `json:"cert,omitempty"`
+	Key       string   `json:"key,omitempty"`
+}
+
+// Validate 检查etcd地址和证书
+func (e ExternalEtcd) Validate() error {
+	v := &validate.Validator{}
+	if len(e.Endpoints) == 0 {
+		v.Add("endpoints", "at least one endpoint is required")
+	}
+	for _, endpoint := range e.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Port() == "" {
+			v.Add("endpoints", "%q must be an http(s)://host:port URL", endpoint)
+		}
+	}
+	if e.CA != "" {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(e.CA)) {
+			v.Add("ca", "must be a PEM encoded certificate")
+		}
+	}
+	if (e.Cert == "") != (e.Key == "") {
+		v.Add("cert", "cert and key must be provided together")
+	} else if e.Cert != "" {
+		if _, err := tls.X509KeyPair([]byte(e.Cert), []byte(e.Key)); err != nil {
+			v.Add("key", "private key does not match the certificate: %v", err)
+		}
+	}
+	return v.Err()
+}
+
+// files 返回证书在目录dir中的路径，未提供的证书为空
+func (e ExternalEtcd) files(dir string) (caFile, certFile, keyFile string) {
+	if e.CA != "" {
+		caFile = dir + "/ca.crt"
+	}
+	if e.Cert != "" {
+		certFile, keyFile = dir+"/client.crt", dir+"/client.key"
+	}
+	return caFile, certFile, keyFile
+}
+
+// UploadExternalEtcdCerts 通过SFTP将etcd证书上传到节点的暂存目录
+func UploadExternalEtcdCerts(client ssh.Runner, e ExternalEtcd) error {
+	if output, err := client.RunCommand(fmt.Sprintf("rm -rf %[1]s && mkdir -m 700 %[1]s", etcdStagingDir)); err != nil {
+		return fmt.Errorf("failed to create etcd cert staging directory: %v, output: %s", err, output)
+	}
+	caFile, certFile, keyFile := e.files(etcdStagingDir)
+	for _, f := range []struct{ path, content string }{{caFile, e.CA}, {certFile, e.Cert}, {keyFile, e.Key}} {
+		if f.path == "" {
+			continue
+		}
+		if err := client.Upload(f.path, []byte(strings.TrimSpace(f.content)+"\n")); err != nil {
+			return fmt.Errorf("failed to upload %s: %v", f.path, err)
+		}
+	}
+	return nil
+}
+
+// InstallExternalEtcdCertsCmd 将暂存目录中的etcd证书复制到ExternalEtcdCertDir并删除暂存目录，未上传证书时不做任何操作
+const InstallExternalEtcdCertsCmd = `if [ -d ` + etcdStagingDir + ` ]; then
+    echo "=== 写入外部etcd证书 ==="
+    sudo mkdir -p ` + ExternalEtcdCertDir + `
+    for f in ` + etcdStagingDir + `/*; do
+        [ -f "$f" ] && sudo install -m 600 "$f" ` + ExternalEtcdCertDir + `/
+    done
+    rm -rf ` + etcdStagingDir + `
+    echo "✓ etcd证书已写入 ` + ExternalEtcdCertDir + `"
+fi`
+
+// EtcdEndpointHealth 单个etcd地址的健康检查结果
+type EtcdEndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	Healthy  bool   `json:"healthy"`
+	Output   string `json:"output,omitempty"`
+}
+
+// CheckExternalEtcd 在控制平面节点上使用暂存目录中的证书访问每个etcd地址的/health，
+// 所有地址都不可用时返回错误。需要在UploadExternalEtcdCerts之后、kubeadm init之前执行
+func CheckExternalEtcd(client ssh.Runner, e ExternalEtcd) ([]EtcdEndpointHealth, error) {
+	caFile, certFile, keyFile := e.files(etcdStagingDir)
+	args := "-sS --max-time 5"
+	if caFile != "" {
+		args += " --cacert " + caFile
+	}
+	if certFile != "" {
+		args += " --cert " + certFile + " --key " + keyFile
+	}
+
+	results := make([]EtcdEndpointHealth, 0, len(e.Endpoints))
+	healthy := 0
+	for _, endpoint := range e.Endpoints {
+		output, err := client.RunCommandSilent(fmt.Sprintf("curl %s %s/health 2>&1", args, strings.TrimSuffix(endpoint, "/")))
+		result := EtcdEndpointHealth{Endpoint: endpoint, Output: strings.TrimSpace(output)}
+		result.Healthy = err == nil && strings.Contains(strings.ReplaceAll(output, " ", ""), `"health":"true"`)
+		if result.Healthy {
+			healthy++
+		}
+		results = append(results, result)
+	}
+	if healthy == 0 {
+		var details []string
+		for _, r := range results {
+			details = append(details, fmt.Sprintf("%s: %s", r.Endpoint, r.Output))
+		}
+		return results, fmt.Errorf("none of the external etcd endpoints is healthy: %s", strings.Join(details, "; "))
+	}
+	return results, nil
+}
@@ -0,0 +1,272 @@
+package kubeadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s-installer/kube"
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// 集群的访问方式，记录在检查结果中
+const (
+	// AccessAPI 使用保存的kubeconfig直接访问API Server
+	AccessAPI = "api"
+	// AccessSSH 通过SSH在master节点上执行kubectl
+	AccessSSH = "ssh"
+)
+
+// apiProbeTimeout 检查API Server是否可达的超时时间，不可达时尽快改用SSH
+const apiProbeTimeout = 5 * time.Second
+
+// ClusterExecutor 执行集群的day-2操作：优先使用保存的管理员kubeconfig直接访问API Server，
+// 没有kubeconfig时先通过SSH从master节点读取并保存；API Server不可达以及节点级操作通过SSH执行
+type ClusterExecutor struct {
+	store  *DeploymentStore
+	master node.Node
+
+	api    *kube.Client
+	apiErr error
+	ssh    *ssh.SSHClient
+}
+
+// NewClusterExecutor 创建集群执行器，master为集群的master节点，不会立即建立连接
+func NewClusterExecutor(store *DeploymentStore, master node.Node) *ClusterExecutor {
+	return &ClusterExecutor{store: store, master: master}
+}
+
+// Close 关闭SSH连接
+func (e *ClusterExecutor) Close() error {
+	if e.ssh == nil {
+		return nil
+	}
+	err := e.ssh.Close()
+	e.ssh = nil
+	return err
+}
+
+// SSH 返回master节点的SSH连接，节点级操作使用
+func (e *ClusterExecutor) SSH() (*ssh.SSHClient, error) {
+	if e.ssh != nil {
+		return e.ssh, nil
+	}
+	client, err := ssh.NewSSHClient(ssh.SSHConfig{
+		Host:          e.master.IP,
+		Port:          e.master.Port,
+		Username:      e.master.Username,
+		Password:      e.master.Password,
+		PrivateKey:    e.master.PrivateKey,
+		PasswordRef:   e.master.PasswordRef,
+		PrivateKeyRef: e.master.PrivateKeyRef,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH client: %v", err)
+	}
+	e.ssh = client
+	return client, nil
+}
+
+// API 返回API Server客户端。保存的kubeconfig凭据失效时（集群重建或证书轮换）从master节点重新读取；
+// API Server不可达时返回错误，同一执行器不再重试
+func (e *ClusterExecutor) API(ctx context.Context) (*kube.Client, error) {
+	if e.api != nil || e.apiErr != nil {
+		return e.api, e.apiErr
+	}
+	e.api, e.apiErr = e.connectAPI(ctx)
+	return e.api, e.apiErr
+}
+
+// connectAPI 按保存的kubeconfig连接API Server
+func (e *ClusterExecutor) connectAPI(ctx context.Context) (*kube.Client, error) {
+	kubeconfig, err := e.store.GetKubeconfig(e.master.ID)
+	fetched := false
+	if errors.Is(err, ErrKubeconfigNotFound) {
+		kubeconfig, err = e.fetchKubeconfig()
+		fetched = true
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := e.probeAPI(ctx, kubeconfig)
+	if err != nil && !fetched && kube.IsUnauthorized(err) {
+		if kubeconfig, err = e.fetchKubeconfig(); err != nil {
+			return nil, err
+		}
+		fetched = true
+		client, err = e.probeAPI(ctx, kubeconfig)
+	}
+	if fetched && (err == nil || !kube.IsUnauthorized(err)) {
+		// API Server暂时不可达时也保存，避免每次都通过SSH读取
+		if saveErr := e.store.SaveKubeconfig(e.master.ID, kubeconfig); saveErr != nil {
+			fmt.Printf("保存集群 %s 的kubeconfig失败: %v\n", e.master.ID, saveErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// probeAPI 创建客户端并读取API Server版本，确认可以访问
+func (e *ClusterExecutor) probeAPI(ctx context.Context, kubeconfig []byte) (*kube.Client, error) {
+	client, err := kube.NewClient(kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+	// k3s等kubeconfig中的地址为127.0.0.1，从安装器访问时替换为master节点IP
+	if server := apiServerForMaster(client.Server(), e.master.IP); server != client.Server() {
+		if client, err = kube.NewClient(kubeconfig, server); err != nil {
+			return nil, err
+		}
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, apiProbeTimeout)
+	defer cancel()
+	if _, err := client.ServerVersion(probeCtx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// apiServerForMaster 地址指向本机时替换为master节点IP，保留端口
+func apiServerForMaster(server, masterIP string) string {
+	u, err := url.Parse(server)
+	if err != nil || masterIP == "" {
+		return server
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return server
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(masterIP, port)
+	} else {
+		u.Host = masterIP
+	}
+	return u.String()
+}
+
+// fetchKubeconfig 通过SSH从master节点读取管理员kubeconfig
+func (e *ClusterExecutor) fetchKubeconfig() ([]byte, error) {
+	client, err := e.SSH()
+	if err != nil {
+		return nil, err
+	}
+	kubectl, err := masterKubectl(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig from master node %s: %v", e.master.Name, err)
+	}
+	output, err := client.RunCommandSilent(kubectl + " config view --raw --flatten --minify -o json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig from master node %s: %v: %s", e.master.Name, err, strings.TrimSpace(output))
+	}
+	return []byte(output), nil
+}
+
+// masterKubectl 返回master节点上可用的kubectl命令，依次尝试kubeadm的admin.conf和k3s的kubeconfig
+func masterKubectl(client ssh.Runner) (string, error) {
+	var firstErr error
+	for _, kubectl := range []string{kubectlCmd, k3sKubectlCmd} {
+		output, err := client.RunCommandSilent(kubectl + " get --raw /version")
+		if err == nil {
+			return kubectl, nil
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+		}
+	}
+	return "", firstErr
+}
+
+// kubectlClient 通过master节点上的kubectl get --raw读取集群对象的只读客户端，API Server不可达时使用，
+// 结果与直接访问API Server相同
+func kubectlClient(client ssh.Runner, kubectl string) *kube.Client {
+	return kube.NewReadOnlyClient(func(ctx context.Context, path string) ([]byte, error) {
+		output, err := client.RunCommandSilent(kubectl + " get --raw " + shellQuote(path))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+		}
+		return []byte(output), nil
+	})
+}
+
+// Reader 返回读取集群对象的客户端和使用的访问方式：API Server可达时直接访问，否则通过master节点上的kubectl读取
+func (e *ClusterExecutor) Reader(ctx context.Context) (*kube.Client, string, error) {
+	if client, err := e.API(ctx); err == nil {
+		return client, AccessAPI, nil
+	}
+	client, err := e.SSH()
+	if err != nil {
+		return nil, AccessSSH, err
+	}
+	kubectl, err := masterKubectl(client)
+	if err != nil {
+		return nil, AccessSSH, fmt.Errorf("failed to run kubectl on master node %s: %v", e.master.Name, err)
+	}
+	return kubectlClient(client, kubectl), AccessSSH, nil
+}
+
+// Discover 读取集群的版本、节点和CNI插件，返回使用的访问方式
+func (e *ClusterExecutor) Discover(ctx context.Context) (*ClusterDiscovery, string, error) {
+	if client, err := e.API(ctx); err == nil {
+		if discovery, err := DiscoverClusterAPI(ctx, client); err == nil {
+			return discovery, AccessAPI, nil
+		}
+	}
+	client, err := e.SSH()
+	if err != nil {
+		return nil, AccessSSH, err
+	}
+	discovery, err := DiscoverCluster(client)
+	return discovery, AccessSSH, err
+}
+
+// Verify 执行集群验证，API Server可达时通过API创建测试Pod，否则在master节点上执行kubectl
+func (e *ClusterExecutor) Verify(ctx context.Context, opts VerifyOptions, logf func(msg string)) (*VerificationReport, error) {
+	if client, err := e.API(ctx); err == nil {
+		report := VerifyClusterAPI(ctx, client, opts, logf)
+		report.Access = AccessAPI
+		return &report, nil
+	}
+	client, err := e.SSH()
+	if err != nil {
+		return nil, err
+	}
+	report := VerifyCluster(ctx, client, opts, logf)
+	report.Access = AccessSSH
+	return &report, nil
+}
+
+// Health 读取集群健康报告
+func (e *ClusterExecutor) Health(ctx context.Context) (*ClusterHealth, error) {
+	client, access, err := e.Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	health, err := ReadClusterHealth(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	health.ClusterID, health.Access = e.master.ID, access
+	return health, nil
+}
+
+// Topology 读取集群拓扑
+func (e *ClusterExecutor) Topology(ctx context.Context) (*ClusterTopology, error) {
+	client, access, err := e.Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	topology, err := ReadClusterTopology(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	topology.ClusterID, topology.Access = e.master.ID, access
+	return topology, nil
+}
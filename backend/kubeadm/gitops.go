@@ -0,0 +1,210 @@
+package kubeadm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s-installer/ssh"
+)
+
+// 支持的GitOps工具
+const (
+	GitOpsToolArgoCD = "argocd"
+	GitOpsToolFlux   = "flux"
+)
+
+const (
+	argoCDInstallManifest = "https://raw.githubusercontent.com/argoproj/argo-cd/stable/manifests/install.yaml"
+	fluxInstallManifest   = "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml"
+	// gitOpsAppName 注册的Git仓库在Argo CD Application或Flux GitRepository/Kustomization中使用的名称
+	gitOpsAppName = "cluster-config"
+	// DefaultGitOpsBranch 未指定分支时使用的分支
+	DefaultGitOpsBranch = "main"
+	// gitOpsRolloutTimeout 等待GitOps控制器就绪的超时时间
+	gitOpsRolloutTimeout = "300s"
+)
+
+var (
+	gitRepoURLPattern = regexp.MustCompile(`^(https?://|ssh://|git@)[A-Za-z0-9._~:/?#@!$&'()*+,;=%\-]+$`)
+	gitRefPattern     = regexp.MustCompile(`^[A-Za-z0-9._/\-]+$`)
+)
+
+// GitOpsOptions 部署完成后安装的GitOps工具以及注册的Git仓库
+type GitOpsOptions struct {
+	// Tool GitOps工具：argocd或flux，为空时不安装
+	Tool string `json:"tool"`
+	// RepoURL 集群配置所在的Git仓库地址
+	RepoURL string `json:"repoUrl"`
+	// Branch 同步的分支，为空时使用DefaultGitOpsBranch
+	Branch string `json:"branch"`
+	// Path 仓库中集群配置所在的目录，为空时使用仓库根目录
+	Path string `json:"path"`
+	// Username、Password 私有仓库的HTTPS认证信息，Password也可以是访问令牌
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Enabled 是否需要安装GitOps工具
+func (o GitOpsOptions) Enabled() bool {
+	return o.Tool != ""
+}
+
+// Validate 校验GitOps工具、仓库地址、分支和目录
+func (o GitOpsOptions) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	if o.Tool != GitOpsToolArgoCD && o.Tool != GitOpsToolFlux {
+		return fmt.Errorf("invalid gitops tool: %s, must be %s or %s", o.Tool, GitOpsToolArgoCD, GitOpsToolFlux)
+	}
+	if !gitRepoURLPattern.MatchString(o.RepoURL) {
+		return fmt.Errorf("invalid gitops repo url: %s", o.RepoURL)
+	}
+	if o.Branch != "" && !gitRefPattern.MatchString(o.Branch) {
+		return fmt.Errorf("invalid gitops branch: %s", o.Branch)
+	}
+	if o.Path != "" && (!gitRefPattern.MatchString(o.Path) || strings.Contains(o.Path, "..")) {
+		return fmt.Errorf("invalid gitops path: %s", o.Path)
+	}
+	return nil
+}
+
+// gitOpsManifest 生成注册Git仓库的资源清单
+func gitOpsManifest(opts GitOpsOptions) string {
+	branch := opts.Branch
+	if branch == "" {
+		branch = DefaultGitOpsBranch
+	}
+	path := opts.Path
+	if path == "" {
+		path = "."
+	}
+	hasAuth := opts.Username != "" || opts.Password != ""
+
+	var b strings.Builder
+	if opts.Tool == GitOpsToolArgoCD {
+		if hasAuth {
+			b.WriteString(fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: %[1]s-repo
+  namespace: argocd
+  labels:
+    argocd.argoproj.io/secret-type: repository
+stringData:
+  type: git
+  url: %[2]s
+  username: %[3]s
+  password: %[4]s
+---
+`, gitOpsAppName, yamlString(opts.RepoURL), yamlString(opts.Username), yamlString(opts.Password)))
+		}
+		b.WriteString(fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: %[1]s
+  namespace: argocd
+spec:
+  project: default
+  source:
+    repoURL: %[2]s
+    targetRevision: %[3]s
+    path: %[4]s
+  destination:
+    server: https://kubernetes.default.svc
+  syncPolicy:
+    automated:
+      prune: true
+      selfHeal: true
+`, gitOpsAppName, yamlString(opts.RepoURL), yamlString(branch), yamlString(path)))
+		return b.String()
+	}
+
+	secretRef := ""
+	if hasAuth {
+		b.WriteString(fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: %[1]s-auth
+  namespace: flux-system
+stringData:
+  username: %[2]s
+  password: %[3]s
+---
+`, gitOpsAppName, yamlString(opts.Username), yamlString(opts.Password)))
+		secretRef = fmt.Sprintf("\n  secretRef:\n    name: %s-auth", gitOpsAppName)
+	}
+	b.WriteString(fmt.Sprintf(`apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: %[1]s
+  namespace: flux-system
+spec:
+  interval: 1m
+  url: %[2]s
+  ref:
+    branch: %[3]s%[4]s
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: %[1]s
+  namespace: flux-system
+spec:
+  interval: 5m
+  path: %[5]s
+  prune: true
+  sourceRef:
+    kind: GitRepository
+    name: %[1]s
+`, gitOpsAppName, yamlString(opts.RepoURL), yamlString(branch), secretRef, yamlString(path)))
+	return b.String()
+}
+
+// InstallGitOps 在master节点上安装Argo CD或Flux，等待控制器就绪后注册Git仓库
+func InstallGitOps(client ssh.Runner, opts GitOpsOptions, logf func(msg string)) error {
+	if !opts.Enabled() {
+		return nil
+	}
+	logf(fmt.Sprintf("=== 安装GitOps工具 %s ===", opts.Tool))
+
+	var steps []string
+	if opts.Tool == GitOpsToolArgoCD {
+		steps = []string{
+			"create namespace argocd --dry-run=client -o yaml | " + kubectlCmd + " apply -f -",
+			"apply -n argocd --server-side --force-conflicts -f " + argoCDInstallManifest,
+			"-n argocd rollout status deployment/argocd-server --timeout=" + gitOpsRolloutTimeout,
+			"-n argocd rollout status deployment/argocd-repo-server --timeout=" + gitOpsRolloutTimeout,
+		}
+	} else {
+		steps = []string{
+			"apply -f " + fluxInstallManifest,
+			"-n flux-system rollout status deployment/source-controller --timeout=" + gitOpsRolloutTimeout,
+			"-n flux-system rollout status deployment/kustomize-controller --timeout=" + gitOpsRolloutTimeout,
+		}
+	}
+	for _, args := range steps {
+		output, err := runKubectl(client, args)
+		if output != "" {
+			logf(output)
+		}
+		if err != nil {
+			return fmt.Errorf("安装%s失败: %v", opts.Tool, err)
+		}
+	}
+
+	// 清单中可能包含仓库密码，通过base64传递且不输出到日志
+	logf(fmt.Sprintf("注册Git仓库 %s", opts.RepoURL))
+	manifest := base64.StdEncoding.EncodeToString([]byte(gitOpsManifest(opts)))
+	output, err := client.RunCommandSilent(fmt.Sprintf("echo %s | base64 -d | %s apply -f -", manifest, kubectlCmd))
+	if strings.TrimSpace(output) != "" {
+		logf(strings.TrimSpace(output))
+	}
+	if err != nil {
+		return fmt.Errorf("注册Git仓库失败: %v", err)
+	}
+	logf(fmt.Sprintf("✓ %s安装完成，集群配置将从 %s 同步", opts.Tool, opts.RepoURL))
+	return nil
+}
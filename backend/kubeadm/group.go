@@ -0,0 +1,148 @@
+package kubeadm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s-installer/node"
+	"k8s-installer/script"
+)
+
+// proxyBlockBegin和proxyBlockEnd 代理配置在/etc/environment中的标记块
+const (
+	proxyBlockBegin = "# BEGIN k8s-installer proxy"
+	proxyBlockEnd   = "# END k8s-installer proxy"
+)
+
+// groupDefaultsFor 返回节点所属节点组的默认配置，节点不属于任何节点组时返回零值
+func groupDefaultsFor(opts DeployOptions, nodeID string) node.GroupDefaults {
+	return opts.NodeGroupDefaults[nodeID]
+}
+
+// kubeletArgsFor 返回节点的kubelet额外参数，节点组的标签合并到node-labels参数中
+func kubeletArgsFor(opts DeployOptions, nodeID string) map[string]string {
+	labels := groupDefaultsFor(opts, nodeID).Labels
+	if len(labels) == 0 {
+		return opts.KubeletExtraArgs
+	}
+
+	args := make(map[string]string, len(opts.KubeletExtraArgs)+1)
+	for name, value := range opts.KubeletExtraArgs {
+		args[strings.TrimPrefix(name, "--")] = value
+	}
+	var pairs []string
+	if existing := args["node-labels"]; existing != "" {
+		pairs = append(pairs, existing)
+	}
+	pairs = append(pairs, labelPairs(labels)...)
+	args["node-labels"] = strings.Join(pairs, ",")
+	return args
+}
+
+// labelPairs 将标签转换为按键排序的key=value列表
+func labelPairs(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return pairs
+}
+
+// scriptOverrides 按节点组的脚本替换配置查找脚本，替换脚本不存在时使用原脚本
+type scriptOverrides struct {
+	scripts interface {
+		GetScript(name string) (string, bool)
+	}
+	overrides map[string]string
+}
+
+// GetScript 获取脚本，name配置了替换时返回替换脚本的内容
+func (s scriptOverrides) GetScript(name string) (string, bool) {
+	if override, ok := s.overrides[script.CanonicalName(name)]; ok {
+		if script, found := s.scripts.GetScript(override); found {
+			return script, true
+		}
+	}
+	return s.scripts.GetScript(name)
+}
+
+// ResolveScript 解析步骤脚本并应用节点组的脚本替换：替换解析得到的脚本，使用默认模板时替换同名通用脚本
+func (s scriptOverrides) ResolveScript(step, distro, family string) (script.Resolved, bool) {
+	resolved, ok := script.Resolve(s.scripts, step, distro, family)
+	name := resolved.Name
+	if resolved.Default {
+		name = step
+	}
+	if override, found := s.overrides[name]; found {
+		if content, found := s.scripts.GetScript(override); found {
+			return script.Resolved{Name: override, Content: content}, true
+		}
+	}
+	return resolved, ok
+}
+
+// withScriptOverrides 返回应用了脚本替换的脚本管理器，没有替换配置时返回原脚本管理器；
+// 替换配置中旧格式的脚本名称按${distro}_${step}匹配
+func withScriptOverrides(scriptManager interface{}, overrides map[string]string) interface{} {
+	if len(overrides) == 0 {
+		return scriptManager
+	}
+	scripts, ok := scriptManager.(interface {
+		GetScript(name string) (string, bool)
+	})
+	if !ok {
+		return scriptManager
+	}
+	canonical := make(map[string]string, len(overrides))
+	for name, override := range overrides {
+		canonical[script.CanonicalName(name)] = override
+	}
+	return scriptOverrides{scripts: scripts, overrides: canonical}
+}
+
+// containerdVersionEnv 生成containerd安装脚本使用的CONTAINERD_VERSION变量，版本为空时返回空字符串
+func containerdVersionEnv(version string) string {
+	if version == "" {
+		return ""
+	}
+	return fmt.Sprintf("CONTAINERD_VERSION=%s\n", shellQuote(version))
+}
+
+// ProxyCmd 生成配置节点代理的命令：写入/etc/environment的标记块，
+// 并为containerd和kubelet添加systemd配置，使拉取镜像和访问外部服务时使用代理
+func ProxyCmd(proxy node.ProxySettings) string {
+	var env []string
+	if proxy.HTTPProxy != "" {
+		env = append(env, "HTTP_PROXY="+proxy.HTTPProxy, "http_proxy="+proxy.HTTPProxy)
+	}
+	if proxy.HTTPSProxy != "" {
+		env = append(env, "HTTPS_PROXY="+proxy.HTTPSProxy, "https_proxy="+proxy.HTTPSProxy)
+	}
+	if proxy.NoProxy != "" {
+		env = append(env, "NO_PROXY="+proxy.NoProxy, "no_proxy="+proxy.NoProxy)
+	}
+
+	block := proxyBlockBegin + "\n" + strings.Join(env, "\n") + "\n" + proxyBlockEnd
+	var dropIn strings.Builder
+	dropIn.WriteString("[Service]\n")
+	for _, e := range env {
+		dropIn.WriteString(fmt.Sprintf("Environment=%q\n", e))
+	}
+
+	return fmt.Sprintf(`echo "=== 配置代理 ==="
+sudo sed -i '/^%[1]s$/,/^%[2]s$/d' /etc/environment
+printf '%%s\n' %[3]s | sudo tee -a /etc/environment > /dev/null
+for service in containerd kubelet; do
+    sudo mkdir -p /etc/systemd/system/$service.service.d
+    printf '%%s' %[4]s | sudo tee /etc/systemd/system/$service.service.d/http-proxy.conf > /dev/null
+done
+sudo systemctl daemon-reload
+echo "✓ 代理配置完成"`, proxyBlockBegin, proxyBlockEnd, shellQuote(block), shellQuote(dropIn.String()))
+}
@@ -0,0 +1,230 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s-installer/kube"
+)
+
+// healthEventLimit 健康报告中保留的最近Warning事件数
+const healthEventLimit = 20
+
+// nodeProblemConditions 为True时表示节点有问题的状态条件
+var nodeProblemConditions = []string{"MemoryPressure", "DiskPressure", "PIDPressure", "NetworkUnavailable"}
+
+// NodeHealth 节点的健康状态
+type NodeHealth struct {
+	Name           string `json:"name"`
+	IP             string `json:"ip"`
+	NodeType       string `json:"nodeType"`
+	Ready          bool   `json:"ready"`
+	Schedulable    bool   `json:"schedulable"`
+	KubeletVersion string `json:"kubeletVersion"`
+	// Problems 未Ready的原因以及MemoryPressure、DiskPressure等为True的状态条件
+	Problems []string `json:"problems,omitempty"`
+}
+
+// PodSummary Pod的状态摘要
+type PodSummary struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	NodeName  string `json:"nodeName,omitempty"`
+	Phase     string `json:"phase"`
+	Ready     bool   `json:"ready"`
+	Restarts  int    `json:"restarts"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// EventSummary 事件摘要，Object为kind/name形式的关联对象
+type EventSummary struct {
+	Namespace string    `json:"namespace,omitempty"`
+	Object    string    `json:"object"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Count     int       `json:"count"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// ClusterHealth 集群健康报告。Healthy为true表示API Server的就绪检查全部通过、所有节点Ready且kube-system中的Pod均已就绪
+type ClusterHealth struct {
+	ClusterID string `json:"clusterId"`
+	// Access 读取集群状态使用的访问方式：api或ssh
+	Access        string             `json:"access"`
+	Healthy       bool               `json:"healthy"`
+	Version       string             `json:"version,omitempty"`
+	Components    []kube.HealthCheck `json:"components"`
+	Nodes         []NodeHealth       `json:"nodes"`
+	UnhealthyPods []PodSummary       `json:"unhealthyPods"`
+	WarningEvents []EventSummary     `json:"warningEvents"`
+	CheckedAt     time.Time          `json:"checkedAt"`
+}
+
+// TopologyNode 节点及其上运行的Pod
+type TopologyNode struct {
+	NodeHealth
+	Pods []PodSummary `json:"pods"`
+}
+
+// ClusterTopology 集群拓扑：每个节点上的Pod，以及尚未调度的Pod
+type ClusterTopology struct {
+	ClusterID   string         `json:"clusterId"`
+	Access      string         `json:"access"`
+	Nodes       []TopologyNode `json:"nodes"`
+	Unscheduled []PodSummary   `json:"unscheduled"`
+	CheckedAt   time.Time      `json:"checkedAt"`
+}
+
+// nodeHealth 节点的健康状态
+func nodeHealth(n kube.Node) NodeHealth {
+	h := NodeHealth{
+		Name:           n.Metadata.Name,
+		IP:             n.InternalIP(),
+		NodeType:       "worker",
+		Ready:          n.Ready(),
+		Schedulable:    n.Schedulable(),
+		KubeletVersion: n.Status.NodeInfo.KubeletVersion,
+	}
+	if n.ControlPlane() {
+		h.NodeType = "master"
+	}
+	for _, c := range n.Status.Conditions {
+		if c.Type == "Ready" && c.Status != "True" {
+			h.Problems = append(h.Problems, fmt.Sprintf("NotReady: %s %s", c.Reason, c.Message))
+		}
+		if c.Status == "True" && containsStep(nodeProblemConditions, c.Type) {
+			h.Problems = append(h.Problems, fmt.Sprintf("%s: %s", c.Type, c.Message))
+		}
+	}
+	return h
+}
+
+// podSummary Pod的状态摘要
+func podSummary(p kube.Pod) PodSummary {
+	return PodSummary{
+		Namespace: p.Metadata.Namespace,
+		Name:      p.Metadata.Name,
+		NodeName:  p.Spec.NodeName,
+		Phase:     p.Status.Phase,
+		Ready:     p.Ready(),
+		Restarts:  p.Restarts(),
+		Reason:    p.Reason(),
+	}
+}
+
+// podHealthy Pod已就绪或已成功结束
+func podHealthy(p kube.Pod) bool {
+	return p.Ready() || p.Status.Phase == kube.PodSucceeded
+}
+
+// sortPods 按命名空间和名称排序
+func sortPods(pods []PodSummary) {
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+}
+
+// ReadClusterHealth 读取API Server就绪检查、节点状态、未就绪的Pod和最近的Warning事件
+func ReadClusterHealth(ctx context.Context, client *kube.Client) (*ClusterHealth, error) {
+	health := &ClusterHealth{
+		Healthy:       true,
+		Components:    []kube.HealthCheck{},
+		Nodes:         []NodeHealth{},
+		UnhealthyPods: []PodSummary{},
+		WarningEvents: []EventSummary{},
+		CheckedAt:     time.Now(),
+	}
+
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	for _, n := range nodes {
+		h := nodeHealth(n)
+		health.Healthy = health.Healthy && h.Ready
+		health.Nodes = append(health.Nodes, h)
+	}
+
+	if version, err := client.ServerVersion(ctx); err == nil {
+		health.Version = version
+	}
+	checks, err := client.Readyz(ctx)
+	if err != nil {
+		health.Healthy = false
+		health.Components = append(health.Components, kube.HealthCheck{Name: "readyz", Message: err.Error()})
+	}
+	for _, check := range checks {
+		health.Healthy = health.Healthy && check.OK
+		health.Components = append(health.Components, check)
+	}
+
+	pods, err := client.ListPods(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	for _, p := range pods {
+		if podHealthy(p) {
+			continue
+		}
+		if p.Metadata.Namespace == "kube-system" {
+			health.Healthy = false
+		}
+		health.UnhealthyPods = append(health.UnhealthyPods, podSummary(p))
+	}
+	sortPods(health.UnhealthyPods)
+
+	// 读取事件失败不影响健康状态
+	if events, err := client.ListEvents(ctx, "", "type=Warning"); err == nil {
+		sort.Slice(events, func(i, j int) bool { return events[i].LastSeen().After(events[j].LastSeen()) })
+		if len(events) > healthEventLimit {
+			events = events[:healthEventLimit]
+		}
+		for _, e := range events {
+			health.WarningEvents = append(health.WarningEvents, EventSummary{
+				Namespace: e.Metadata.Namespace,
+				Object:    e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+				Reason:    e.Reason,
+				Message:   e.Message,
+				Count:     e.Count,
+				LastSeen:  e.LastSeen(),
+			})
+		}
+	}
+	return health, nil
+}
+
+// ReadClusterTopology 读取节点和所有Pod，按Pod所在节点分组
+func ReadClusterTopology(ctx context.Context, client *kube.Client) (*ClusterTopology, error) {
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	pods, err := client.ListPods(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	topology := &ClusterTopology{Nodes: []TopologyNode{}, Unscheduled: []PodSummary{}, CheckedAt: time.Now()}
+	index := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		index[n.Metadata.Name] = len(topology.Nodes)
+		topology.Nodes = append(topology.Nodes, TopologyNode{NodeHealth: nodeHealth(n), Pods: []PodSummary{}})
+	}
+	for _, p := range pods {
+		if i, ok := index[p.Spec.NodeName]; ok {
+			topology.Nodes[i].Pods = append(topology.Nodes[i].Pods, podSummary(p))
+		} else {
+			topology.Unscheduled = append(topology.Unscheduled, podSummary(p))
+		}
+	}
+	for i := range topology.Nodes {
+		sortPods(topology.Nodes[i].Pods)
+	}
+	sortPods(topology.Unscheduled)
+	return topology, nil
+}
@@ -0,0 +1,164 @@
+package kubeadm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s-installer/ssh"
+)
+
+const (
+	// DefaultHelmVersion master节点上未安装Helm时安装的版本
+	DefaultHelmVersion = "v3.15.4"
+	// DefaultHelmDownloadURL Helm二进制包的下载地址，国内可使用https://mirrors.huaweicloud.com/helm
+	DefaultHelmDownloadURL = "https://get.helm.sh"
+	// helmCmd 在master节点上使用集群管理员kubeconfig执行helm，部分发行版的sudo路径不包含/usr/local/bin
+	helmCmd = "sudo $(command -v helm) --kubeconfig /etc/kubernetes/admin.conf"
+)
+
+var (
+	helmNamePattern    = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	helmChartPattern   = regexp.MustCompile(`^[A-Za-z0-9._/:\-]+$`)
+	helmVersionPattern = regexp.MustCompile(`^[A-Za-z0-9.+\-^~<>=*, ]+$`)
+	helmRepoPattern    = regexp.MustCompile(`^(https?|oci)://[A-Za-z0-9._~:/?#@!$&()*+,;=%\-]+$`)
+)
+
+// HelmChartOptions 通过Helm部署的Chart
+type HelmChartOptions struct {
+	// ReleaseName Helm release名称
+	ReleaseName string `json:"releaseName" binding:"required"`
+	// Namespace 部署的命名空间，不存在时自动创建，为空时使用default
+	Namespace string `json:"namespace"`
+	// RepoURL Chart仓库地址，为空时Chart需要是oci://地址或已添加的仓库中的Chart
+	RepoURL string `json:"repoUrl"`
+	// Chart Chart名称
+	Chart string `json:"chart" binding:"required"`
+	// Version Chart版本，为空时使用最新版本
+	Version string `json:"version"`
+	// Values YAML格式的values内容
+	Values string `json:"values"`
+	// HelmVersion master节点未安装Helm时安装的版本，为空时使用DefaultHelmVersion
+	HelmVersion string `json:"helmVersion"`
+}
+
+// Validate 校验Chart参数，避免注入shell命令
+func (o HelmChartOptions) Validate() error {
+	if !helmNamePattern.MatchString(o.ReleaseName) || len(o.ReleaseName) > 53 {
+		return fmt.Errorf("invalid release name: %s", o.ReleaseName)
+	}
+	if o.Namespace != "" && !helmNamePattern.MatchString(o.Namespace) {
+		return fmt.Errorf("invalid namespace: %s", o.Namespace)
+	}
+	if o.RepoURL != "" && !helmRepoPattern.MatchString(o.RepoURL) {
+		return fmt.Errorf("invalid repo url: %s", o.RepoURL)
+	}
+	if !helmChartPattern.MatchString(o.Chart) {
+		return fmt.Errorf("invalid chart: %s", o.Chart)
+	}
+	if o.Version != "" && !helmVersionPattern.MatchString(o.Version) {
+		return fmt.Errorf("invalid chart version: %s", o.Version)
+	}
+	if o.HelmVersion != "" && !helmVersionPattern.MatchString(o.HelmVersion) {
+		return fmt.Errorf("invalid helm version: %s", o.HelmVersion)
+	}
+	return nil
+}
+
+// HelmInstallCmd 生成在节点上安装Helm的命令，已安装Helm时跳过
+func HelmInstallCmd(version string) string {
+	if version == "" {
+		version = DefaultHelmVersion
+	}
+	return fmt.Sprintf(`if command -v helm &> /dev/null; then
+    echo "Helm已安装: $(helm version --short 2>/dev/null)"
+    exit 0
+fi
+echo "=== 安装Helm %[1]s ==="
+case $(uname -m) in
+    x86_64) HELM_ARCH=amd64 ;;
+    aarch64|arm64) HELM_ARCH=arm64 ;;
+    *) echo "✗ 不支持的架构: $(uname -m)"; exit 1 ;;
+esac
+TMP=$(mktemp -d)
+trap 'rm -rf $TMP' EXIT
+curl -fsSL -o $TMP/helm.tar.gz %[2]s/helm-%[1]s-linux-$HELM_ARCH.tar.gz || exit 1
+tar -xzf $TMP/helm.tar.gz -C $TMP || exit 1
+sudo install -m 0755 $TMP/linux-$HELM_ARCH/helm /usr/local/bin/helm || exit 1
+echo "✓ Helm安装完成: $(helm version --short 2>/dev/null)"`, version, DefaultHelmDownloadURL)
+}
+
+// helmChartCmd 生成安装或升级Chart的命令，values通过base64写入临时文件
+func helmChartCmd(opts HelmChartOptions) string {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	var chart string
+	switch {
+	case opts.RepoURL == "":
+		chart = shellQuote(opts.Chart)
+	case strings.HasPrefix(opts.RepoURL, "oci://"):
+		chart = shellQuote(strings.TrimSuffix(opts.RepoURL, "/") + "/" + opts.Chart)
+	default:
+		// 使用--repo直接指定仓库，不修改master节点上已添加的仓库列表
+		chart = fmt.Sprintf("%s --repo %s", shellQuote(opts.Chart), shellQuote(opts.RepoURL))
+	}
+
+	args := []string{
+		helmCmd, "upgrade --install", shellQuote(opts.ReleaseName), chart,
+		"--namespace", shellQuote(namespace), "--create-namespace", "--wait", "--timeout 10m",
+	}
+	if opts.Version != "" {
+		args = append(args, "--version", shellQuote(opts.Version))
+	}
+	if strings.TrimSpace(opts.Values) != "" {
+		b.WriteString("VALUES=$(mktemp)\n")
+		b.WriteString("trap 'rm -f $VALUES' EXIT\n")
+		b.WriteString(fmt.Sprintf("echo %s | base64 -d > $VALUES\n", base64.StdEncoding.EncodeToString([]byte(opts.Values))))
+		args = append(args, "--values $VALUES")
+	}
+	b.WriteString(strings.Join(args, " ") + "\n")
+	b.WriteString(fmt.Sprintf("%s status %s --namespace %s", helmCmd, shellQuote(opts.ReleaseName), shellQuote(namespace)))
+	return b.String()
+}
+
+// InstallHelmChart 在master节点上确保Helm已安装，然后安装或升级Chart
+func InstallHelmChart(sshConfig SSHConfig, opts HelmChartOptions, logf func(msg string)) (string, error) {
+	client, err := ssh.NewSSHClient(ssh.SSHConfig{
+		Host:          sshConfig.Host,
+		Port:          sshConfig.Port,
+		Username:      sshConfig.Username,
+		Password:      sshConfig.Password,
+		PrivateKey:    sshConfig.PrivateKey,
+		PasswordRef:   sshConfig.PasswordRef,
+		PrivateKeyRef: sshConfig.PrivateKeyRef,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create SSH client: %v", err)
+	}
+	defer client.Close()
+
+	return installHelmChart(client, opts, logf)
+}
+
+// installHelmChart 使用已建立的SSH连接安装Helm并部署Chart
+func installHelmChart(client ssh.Runner, opts HelmChartOptions, logf func(msg string)) (string, error) {
+	var output strings.Builder
+	callback := func(line string) {
+		output.WriteString(line + "\n")
+		logf(line)
+	}
+	if _, err := client.RunCommandWithOutput(HelmInstallCmd(opts.HelmVersion), callback); err != nil {
+		return output.String(), fmt.Errorf("安装Helm失败: %v", err)
+	}
+	logf(fmt.Sprintf("=== 部署Chart %s (release %s) ===", opts.Chart, opts.ReleaseName))
+	if _, err := client.RunCommandWithOutput(helmChartCmd(opts), callback); err != nil {
+		return output.String(), fmt.Errorf("部署Chart失败: %v", err)
+	}
+	return output.String(), nil
+}
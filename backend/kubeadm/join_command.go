@@ -0,0 +1,61 @@
+package kubeadm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// caCertHashCmd 计算集群CA公钥的SHA256哈希，用于discovery-token-ca-cert-hash
+const caCertHashCmd = `openssl x509 -pubkey -in /etc/kubernetes/pki/ca.crt | openssl rsa -pubin -outform der 2>/dev/null | openssl dgst -sha256 -hex | sed 's/^.* //'`
+
+// joinCommandRetries 通过kubeadm token create --print-join-command获取join命令的尝试次数
+const joinCommandRetries = 3
+
+// fetchJoinInfo 在Master节点上创建新令牌并获取join参数：优先解析kubeadm token create --print-join-command的输出，
+// 多次失败后单独创建令牌并计算CA证书哈希，控制平面地址为endpoint。过程通过logf输出
+func fetchJoinInfo(client ssh.Runner, endpoint string, logf func(format string, args ...interface{})) (*node.JoinInfo, error) {
+	var lastErr error
+	for i := 1; i <= joinCommandRetries; i++ {
+		logf("尝试获取Join命令 (%d/%d)...", i, joinCommandRetries)
+		output, err := client.RunCommand("kubeadm token create --print-join-command")
+		if err == nil {
+			var info *node.JoinInfo
+			if info, err = node.ParseJoinCommand(output); err == nil {
+				logf("成功获取Join命令: %s", info.Command())
+				return info, nil
+			}
+		}
+		lastErr = err
+		logf("获取Join命令失败: %v", err)
+		if i < joinCommandRetries {
+			logf("等待3秒后重试...")
+			time.Sleep(3 * time.Second)
+		}
+	}
+
+	logf("=== 尝试使用另一种方法获取Join命令 ===")
+	token, err := client.RunCommand("kubeadm token create")
+	if err != nil {
+		logf("创建token失败: %v", err)
+		return nil, lastErr
+	}
+	caCertHash, err := client.RunCommand(caCertHashCmd)
+	if err != nil {
+		logf("获取ca cert hash失败: %v", err)
+		return nil, lastErr
+	}
+	info := &node.JoinInfo{
+		Endpoint:   endpoint,
+		Token:      strings.TrimSpace(token),
+		CACertHash: "sha256:" + strings.TrimSpace(caCertHash),
+	}
+	if err := info.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to build join command: %v", err)
+	}
+	logf("成功构建Join命令: %s", info.Command())
+	return info, nil
+}
@@ -0,0 +1,21 @@
+package kubeadm
+
+import (
+	"testing"
+
+	"k8s-installer/ssh"
+)
+
+func TestFetchJoinInfo(t *testing.T) {
+	rec := ssh.NewRecorder().On("kubeadm token create --print-join-command", testInitOutput, nil)
+	info, err := fetchJoinInfo(rec, "10.0.0.10:6443", t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Endpoint != "10.0.0.10:6443" || info.Token != "abcdef.0123456789abcdef" {
+		t.Fatalf("fetchJoinInfo() = %+v", info)
+	}
+	if n := len(rec.Commands()); n != 1 {
+		t.Fatalf("ran %d commands, want 1", n)
+	}
+}
@@ -0,0 +1,350 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// 集群安装方式
+const (
+	InstallerTypeKubeadm = "kubeadm"
+	InstallerTypeK3s     = "k3s"
+)
+
+// k3s默认安装参数
+const (
+	DefaultK3sInstallScriptURL = "https://rancher-mirror.rancher.cn/k3s/k3s-install.sh"
+	DefaultK3sMirror           = "cn"
+	K3sKubeconfigPath          = "/etc/rancher/k3s/k3s.yaml"
+	k3sTokenPath               = "/var/lib/rancher/k3s/server/node-token"
+)
+
+var k3sVersionPattern = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+\+k3s[0-9]+$`)
+
+// K3sOptions k3s安装选项
+type K3sOptions struct {
+	// InstallScriptURL k3s安装脚本地址，为空时使用DefaultK3sInstallScriptURL
+	InstallScriptURL string `json:"installScriptUrl"`
+	// Mirror INSTALL_K3S_MIRROR，为空时使用DefaultK3sMirror，设置为global使用官方地址
+	Mirror string `json:"mirror"`
+	// Version k3s版本，如v1.30.4+k3s1，为空时安装与kubeVersion相同次版本的最新k3s
+	Version string `json:"version"`
+	// ServerArgs和AgentArgs 传递给k3s server/agent的额外参数
+	ServerArgs []string `json:"serverArgs"`
+	AgentArgs  []string `json:"agentArgs"`
+	// ServerURL和Token 本次部署不包含master节点时，agent加入的已有k3s集群
+	ServerURL string `json:"serverUrl"`
+	Token     string `json:"token"`
+}
+
+// ValidateInstallerType 校验安装方式，空字符串表示kubeadm
+func ValidateInstallerType(installerType string) error {
+	switch installerType {
+	case "", InstallerTypeKubeadm, InstallerTypeK3s:
+		return nil
+	}
+	return fmt.Errorf("unsupported installer type: %s", installerType)
+}
+
+// Validate 校验k3s选项
+func (o K3sOptions) Validate() error {
+	if o.Version != "" && !k3sVersionPattern.MatchString(o.Version) {
+		return fmt.Errorf("invalid k3s version: %s", o.Version)
+	}
+	if (o.ServerURL == "") != (o.Token == "") {
+		return fmt.Errorf("k3s serverUrl and token must be set together")
+	}
+	return nil
+}
+
+// k3sInstallEnv 生成安装脚本的环境变量，未指定k3s版本时使用与kubeVersion相同次版本的发布通道
+func k3sInstallEnv(opts K3sOptions, kubeVersion string) []string {
+	var env []string
+	mirror := opts.Mirror
+	if mirror == "" {
+		mirror = DefaultK3sMirror
+	}
+	if mirror != "global" {
+		env = append(env, "INSTALL_K3S_MIRROR="+shellQuote(mirror))
+	}
+	if opts.Version != "" {
+		env = append(env, "INSTALL_K3S_VERSION="+shellQuote(opts.Version))
+	} else {
+		parts := strings.Split(strings.TrimPrefix(kubeVersion, "v"), ".")
+		channel := "stable"
+		if len(parts) >= 2 {
+			channel = fmt.Sprintf("v%s.%s", parts[0], parts[1])
+		}
+		env = append(env, "INSTALL_K3S_CHANNEL="+shellQuote(channel))
+	}
+	return env
+}
+
+// k3sInstallCmd 生成安装k3s server或agent的命令
+func k3sInstallCmd(opts K3sOptions, kubeVersion, role string, env, args []string) string {
+	scriptURL := opts.InstallScriptURL
+	if scriptURL == "" {
+		scriptURL = DefaultK3sInstallScriptURL
+	}
+	env = append(k3sInstallEnv(opts, kubeVersion), env...)
+	service := "k3s"
+	if role == "agent" {
+		service = "k3s-agent"
+	}
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return fmt.Sprintf(`echo "=== 安装k3s %[1]s ==="
+curl -sfL %[2]s | sudo %[3]s sh -s - %[1]s %[4]s
+if ! sudo systemctl is-active --quiet %[5]s; then
+    sudo journalctl -u %[5]s --no-pager -n 50
+    echo "✗ k3s %[1]s启动失败"
+    exit 1
+fi
+echo "✓ k3s %[1]s安装完成"`, role, shellQuote(scriptURL), strings.Join(env, " "), strings.Join(quoted, " "), service)
+}
+
+// k3sNodeArgs 节点组标签和kubelet配置转换为k3s的--node-label和--kubelet-arg参数
+func k3sNodeArgs(opts DeployOptions, nodeID string) []string {
+	var args []string
+	for _, pair := range labelPairs(groupDefaultsFor(opts, nodeID).Labels) {
+		args = append(args, "--node-label", pair)
+	}
+	for _, flag := range kubeletSettingsFor(opts, nodeID).Flags() {
+		// k3s会自动检测systemd-resolved的stub解析并设置resolv-conf
+		if strings.HasSuffix(flag, "="+resolvConfAutoPlaceholder) {
+			continue
+		}
+		args = append(args, "--kubelet-arg", strings.TrimPrefix(flag, "--"))
+	}
+	return args
+}
+
+// k3sKubectlLinkCmd 将k3s的kubeconfig链接到admin.conf，使集群验证、冒烟测试等基于kubectl的功能可以复用
+const k3sKubectlLinkCmd = `sudo mkdir -p /etc/kubernetes
+sudo ln -sf ` + K3sKubeconfigPath + ` /etc/kubernetes/admin.conf
+command -v kubectl &> /dev/null || sudo ln -sf /usr/local/bin/k3s /usr/local/bin/kubectl`
+
+// DeployK3sCluster 使用k3s部署集群：master节点安装k3s server，worker节点安装k3s agent，
+// 步骤记录、重试、集群验证和冒烟测试与kubeadm部署使用相同的机制
+func DeployK3sCluster(ctx context.Context, nodes []node.Node, kubeVersion string, skipSteps []string, opts DeployOptions, logCallback func(string, string, string)) (string, error) {
+	var result strings.Builder
+	outputLog := func(nodeID, nodeName, msg string) {
+		result.WriteString(msg + "\n")
+		if logCallback != nil {
+			logCallback(msg, nodeID, nodeName)
+		}
+		fmt.Println(msg)
+	}
+
+	var masterNodes, workerNodes []node.Node
+	for _, n := range nodes {
+		if n.NodeType == node.NodeTypeMaster {
+			masterNodes = append(masterNodes, n)
+		} else {
+			workerNodes = append(workerNodes, n)
+		}
+	}
+	if len(masterNodes) > 1 {
+		return "", fmt.Errorf("目前只支持单master节点部署")
+	}
+	if opts.SingleNode && (len(masterNodes) != 1 || len(workerNodes) > 0) {
+		return "", fmt.Errorf("单节点集群只能包含一个master节点")
+	}
+	if len(masterNodes) == 0 && opts.K3s.ServerURL == "" {
+		return "", fmt.Errorf("k3s部署不包含master节点时需要提供serverUrl和token")
+	}
+
+	isCompleted := func(nodeID, step string) bool {
+		return opts.StepTracker != nil && opts.StepTracker.IsStepCompleted(nodeID, step)
+	}
+	markStep := func(nodeID, step string, err error) {
+		if opts.StepTracker != nil {
+			opts.StepTracker.MarkStep(nodeID, step, err)
+		}
+	}
+
+	// runOnNode 在节点上执行命令，按步骤的重试策略重试，输出实时写入日志
+	runOnNode := func(client ssh.Runner, n node.Node, step, cmd string) error {
+		return retryPolicyFor(opts.RetryPolicies, step).Do(ctx, func(attempt int) error {
+			if attempt > 1 {
+				outputLog(n.ID, n.Name, fmt.Sprintf("步骤 %s 第%d次尝试", step, attempt))
+			}
+			_, err := client.RunCommandWithOutput(cmd, func(line string) {
+				outputLog(n.ID, n.Name, line)
+			})
+			return err
+		}, func(attempt int, err error, wait time.Duration) {
+			outputLog(n.ID, n.Name, fmt.Sprintf("步骤 %s 第%d次执行失败: %v，%v后重试", step, attempt, err, wait))
+		})
+	}
+
+	// prepareNode 连接节点并配置时间同步
+	prepareNode := func(n node.Node) (*ssh.SSHClient, error) {
+		outputLog(n.ID, n.Name, fmt.Sprintf("=== 部署节点: %s (%s) ===", n.Name, n.IP))
+		client, err := node.Connect(n)
+		if err != nil {
+			outputLog(n.ID, n.Name, fmt.Sprintf("创建SSH客户端失败: %v", err))
+			return nil, err
+		}
+		client.SetContext(ctx)
+		client.SetCommandTimeout(opts.CommandTimeout)
+		client.SetNodeInfo(n.ID, n.Name)
+
+		// 节点组配置了代理时先配置代理，k3s安装脚本需要下载二进制文件
+		if proxy := groupDefaultsFor(opts, n.ID).Proxy; !proxy.Empty() {
+			if err := runOnNode(client, n, StepSystemPreparation, ProxyCmd(proxy)); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("节点 %s 代理配置失败: %v", n.Name, err)
+			}
+		}
+
+		if !containsStep(skipSteps, StepSystemPreparation) && !nodeSkipsStep(opts, n.ID, StepSystemPreparation) && !isCompleted(n.ID, StepSystemPreparation) {
+			timeSync := timeSyncFor(opts, n.ID)
+			startStep(opts.StepTracker, n.ID, StepSystemPreparation)
+			err := runOnNode(client, n, StepSystemPreparation, TimeSyncCmd(timeSync.Timezone, timeSync.NTPServers, false))
+			markStep(n.ID, StepSystemPreparation, err)
+			if err != nil {
+				client.Close()
+				return nil, fmt.Errorf("节点 %s 时间同步配置失败: %v", n.Name, err)
+			}
+		}
+		return client, nil
+	}
+
+	serverURL, token := opts.K3s.ServerURL, opts.K3s.Token
+	var masterClient *ssh.SSHClient
+	var masterNode node.Node
+	if len(masterNodes) > 0 {
+		masterNode = masterNodes[0]
+		client, err := prepareNode(masterNode)
+		if err != nil {
+			return result.String(), err
+		}
+		defer client.Close()
+		masterClient = client
+
+		if !containsStep(skipSteps, StepMasterInitialization) && !nodeSkipsStep(opts, masterNode.ID, StepMasterInitialization) && !isCompleted(masterNode.ID, StepMasterInitialization) {
+			args := append([]string{"--write-kubeconfig-mode", "600", "--node-name", masterNode.Name, "--node-ip", masterNode.IP, "--tls-san", masterNode.IP}, k3sNodeArgs(opts, masterNode.ID)...)
+			for _, san := range opts.KubeadmConfig.ClusterConfiguration.APIServer.CertSANs {
+				args = append(args, "--tls-san", san)
+			}
+			args = append(args, opts.K3s.ServerArgs...)
+			startStep(opts.StepTracker, masterNode.ID, StepMasterInitialization)
+			err := runOnNode(client, masterNode, StepMasterInitialization, k3sInstallCmd(opts.K3s, kubeVersion, "server", nil, args)+"\n"+k3sKubectlLinkCmd)
+			markStep(masterNode.ID, StepMasterInitialization, err)
+			if err != nil {
+				return result.String(), fmt.Errorf("Master节点 %s 安装k3s server失败: %v", masterNode.Name, err)
+			}
+		}
+
+		output, err := client.RunCommandSilent("sudo cat " + k3sTokenPath)
+		if err != nil {
+			return result.String(), fmt.Errorf("读取k3s节点token失败: %v", err)
+		}
+		serverURL = fmt.Sprintf("https://%s:6443", masterNode.IP)
+		token = strings.TrimSpace(output)
+		outputLog(masterNode.ID, masterNode.Name, fmt.Sprintf("k3s server已就绪: %s", serverURL))
+		emitArtifact(opts, masterNode.ID, ArtifactKubeconfig, kubeconfigRef(masterNode, K3sKubeconfigPath))
+	}
+
+	if !containsStep(skipSteps, StepWorkerJoin) {
+		for _, n := range workerNodes {
+			select {
+			case <-ctx.Done():
+				outputLog("cluster", "Kubernetes Cluster", "部署已取消")
+				return result.String(), ctx.Err()
+			default:
+			}
+			if nodeSkipsStep(opts, n.ID, StepWorkerJoin) {
+				outputLog(n.ID, n.Name, fmt.Sprintf("节点配置了跳过步骤 %s", StepWorkerJoin))
+				continue
+			}
+			if isCompleted(n.ID, StepWorkerJoin) {
+				outputLog(n.ID, n.Name, fmt.Sprintf("步骤 %s 已在之前的部署中完成，跳过", StepWorkerJoin))
+				continue
+			}
+
+			client, err := prepareNode(n)
+			if err != nil {
+				return result.String(), err
+			}
+			args := append([]string{"--node-name", n.Name, "--node-ip", n.IP}, k3sNodeArgs(opts, n.ID)...)
+			args = append(args, opts.K3s.AgentArgs...)
+			env := []string{"K3S_URL=" + shellQuote(serverURL), "K3S_TOKEN=" + shellQuote(token)}
+			startStep(opts.StepTracker, n.ID, StepWorkerJoin)
+			err = runOnNode(client, n, StepWorkerJoin, k3sInstallCmd(opts.K3s, kubeVersion, "agent", env, args))
+			client.Close()
+			markStep(n.ID, StepWorkerJoin, err)
+			if err != nil {
+				return result.String(), fmt.Errorf("Worker节点 %s 安装k3s agent失败: %v", n.Name, err)
+			}
+			outputLog(n.ID, n.Name, fmt.Sprintf("Worker节点 %s 已加入k3s集群", n.Name))
+		}
+	}
+
+	// k3s server默认可以调度普通Pod，并已内置metrics-server和local-path存储
+	if len(opts.Addons) > 0 && masterClient != nil {
+		var addons []string
+		for _, addon := range opts.Addons {
+			if addon == AddonMetricsServer || addon == AddonLocalPathStorage {
+				outputLog(masterNode.ID, masterNode.Name, fmt.Sprintf("k3s已内置插件 %s，跳过安装", addon))
+				continue
+			}
+			addons = append(addons, addon)
+		}
+		if err := InstallAddons(masterClient, append(masterNodes, workerNodes...), addons, opts.AddonOptions, func(msg string) {
+			outputLog(masterNode.ID, masterNode.Name, msg)
+		}); err != nil {
+			return result.String(), err
+		}
+	}
+	if opts.GitOps.Enabled() && masterClient != nil {
+		if err := InstallGitOps(masterClient, opts.GitOps, func(msg string) {
+			outputLog(masterNode.ID, masterNode.Name, msg)
+		}); err != nil {
+			return result.String(), err
+		}
+	}
+
+	if masterClient != nil && !containsStep(skipSteps, StepClusterVerification) {
+		report, err := verifyDeployment(ctx, masterClient, opts, func(msg string) {
+			outputLog(masterNode.ID, masterNode.Name, msg)
+		})
+		if err != nil {
+			return result.String(), err
+		}
+		if !report.Passed {
+			// 就绪门控以外的检查项失败不影响部署流程，只输出警告
+			outputLog(masterNode.ID, masterNode.Name, "警告: 集群验证未通过，请检查上述失败项")
+		}
+	}
+
+	if opts.SmokeTest.Enabled && masterClient != nil {
+		probeClient, probeName := masterClient, masterNode.Name
+		if len(workerNodes) > 0 {
+			if workerClient, err := node.Connect(workerNodes[0]); err == nil {
+				defer workerClient.Close()
+				workerClient.SetContext(ctx)
+				probeClient, probeName = workerClient, workerNodes[0].Name
+			}
+		}
+		smokeResult := RunSmokeTest(ctx, masterClient, probeClient, probeName, opts.SmokeTest, func(msg string) {
+			outputLog(masterNode.ID, masterNode.Name, msg)
+		})
+		if opts.OnSmokeTested != nil {
+			opts.OnSmokeTested(smokeResult)
+		}
+	}
+
+	outputLog("cluster", "Kubernetes Cluster", "=== k3s集群部署完成 ===")
+	outputLog("cluster", "Kubernetes Cluster", fmt.Sprintf("Server地址: %s，Worker节点数量: %d", serverURL, len(workerNodes)))
+	return result.String(), nil
+}
@@ -0,0 +1,67 @@
+package kubeadm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrKubeconfigNotFound 没有保存集群的kubeconfig
+var ErrKubeconfigNotFound = errors.New("kubeconfig not found")
+
+// createKubeconfigsTable 创建集群kubeconfig表，集群ID为master节点ID
+func (s *DeploymentStore) createKubeconfigsTable() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS cluster_kubeconfigs (
+		cluster_id TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create cluster_kubeconfigs table: %v", err)
+	}
+	return nil
+}
+
+// SaveKubeconfig 保存集群的管理员kubeconfig，内容为kubectl config view --raw --flatten -o json的输出
+func (s *DeploymentStore) SaveKubeconfig(clusterID string, content []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.db.Exec(
+		"INSERT OR REPLACE INTO cluster_kubeconfigs (cluster_id, content, updated_at) VALUES (?, ?, ?)",
+		clusterID, string(content), time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to save kubeconfig: %v", err)
+	}
+	return nil
+}
+
+// GetKubeconfig 读取集群的管理员kubeconfig，没有保存时返回ErrKubeconfigNotFound
+func (s *DeploymentStore) GetKubeconfig(clusterID string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var content string
+	err := s.db.QueryRow("SELECT content FROM cluster_kubeconfigs WHERE cluster_id = ?", clusterID).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, ErrKubeconfigNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query kubeconfig: %v", err)
+	}
+	return []byte(content), nil
+}
+
+// DeleteKubeconfig 删除集群的kubeconfig，集群重置或拆除后调用
+func (s *DeploymentStore) DeleteKubeconfig(clusterID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM cluster_kubeconfigs WHERE cluster_id = ?", clusterID); err != nil {
+		return fmt.Errorf("failed to delete kubeconfig: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package kubeadm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-installer/validate"
+)
+
+// KubeletDropInPath 安装器写入的kubelet systemd配置文件，在kubeadm的10-kubeadm.conf之后加载
+const KubeletDropInPath = "/etc/systemd/system/kubelet.service.d/20-k8s-installer.conf"
+
+var (
+	// resourceNamePattern 预留资源名称，如 cpu、memory、ephemeral-storage、pid
+	resourceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9.\-]*$`)
+	// quantityPattern 资源数量或驱逐阈值，如 500m、1Gi、10%
+	quantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(m|k|Ki|M|Mi|G|Gi|T|Ti|%)?$`)
+	// evictionSignalPattern 驱逐信号，如 memory.available、nodefs.available
+	evictionSignalPattern = regexp.MustCompile(`^[a-z]+(\.[a-z]+)+$`)
+	// resolvConfPattern resolv.conf的绝对路径
+	resolvConfPattern = regexp.MustCompile(`^/[A-Za-z0-9._/\-]+$`)
+)
+
+// KubeletSettings kubelet的资源预留、驱逐阈值和Pod数量限制，部署时在节点加入集群之前写入kubelet的systemd配置
+type KubeletSettings struct {
+	// SystemReserved 为系统进程预留的资源，如 {"cpu": "500m", "memory": "1Gi"}
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+	// KubeReserved 为Kubernetes组件预留的资源
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+	// EvictionHard 硬驱逐阈值，如 {"memory.available": "200Mi", "nodefs.available": "10%"}
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+	// EvictionSoft 软驱逐阈值，需要同时配置EvictionSoftGracePeriod
+	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
+	// EvictionSoftGracePeriod 软驱逐的宽限时间，如 {"memory.available": "1m30s"}
+	EvictionSoftGracePeriod map[string]string `json:"evictionSoftGracePeriod,omitempty"`
+	// MaxPods 节点上的最大Pod数量，为0时使用kubelet默认值110
+	MaxPods int `json:"maxPods,omitempty"`
+	// PodPidsLimit 每个Pod的最大进程数，为0时不限制
+	PodPidsLimit int `json:"podPidsLimit,omitempty"`
+	// ResolvConf kubelet为Pod生成DNS配置使用的resolv.conf，auto表示节点使用systemd-resolved的stub解析时
+	// 改用/run/systemd/resolve/resolv.conf，避免CoreDNS把请求转发回自身
+	ResolvConf string `json:"resolvConf,omitempty"`
+}
+
+// Empty 是否没有任何配置
+func (s KubeletSettings) Empty() bool {
+	return len(s.Args()) == 0
+}
+
+// Validate 检查资源名称、数量和驱逐阈值，避免注入shell命令
+func (s KubeletSettings) Validate() error {
+	v := &validate.Validator{}
+	validateResources(v, "systemReserved", s.SystemReserved)
+	validateResources(v, "kubeReserved", s.KubeReserved)
+	validateEviction(v, "evictionHard", s.EvictionHard)
+	validateEviction(v, "evictionSoft", s.EvictionSoft)
+	for signal, period := range s.EvictionSoftGracePeriod {
+		if !evictionSignalPattern.MatchString(signal) {
+			v.Add("evictionSoftGracePeriod", "%q is not a valid eviction signal", signal)
+		} else if d, err := time.ParseDuration(period); err != nil || d <= 0 {
+			v.Add("evictionSoftGracePeriod", "%q is not a valid duration", period)
+		}
+	}
+	for signal := range s.EvictionSoft {
+		if _, ok := s.EvictionSoftGracePeriod[signal]; !ok {
+			v.Add("evictionSoftGracePeriod", "grace period is required for soft eviction signal %s", signal)
+		}
+	}
+	if s.MaxPods < 0 {
+		v.Add("maxPods", "must not be negative")
+	}
+	if s.PodPidsLimit < 0 {
+		v.Add("podPidsLimit", "must not be negative")
+	}
+	if s.ResolvConf != "" && s.ResolvConf != ResolvConfAuto && !resolvConfPattern.MatchString(s.ResolvConf) {
+		v.Add("resolvConf", "must be %q or an absolute path", ResolvConfAuto)
+	}
+	return v.Err()
+}
+
+// validateResources 检查预留资源的名称和数量，预留资源不支持百分比
+func validateResources(v *validate.Validator, field string, resources map[string]string) {
+	for name, quantity := range resources {
+		if !resourceNamePattern.MatchString(name) {
+			v.Add(field, "%q is not a valid resource name", name)
+		} else if !quantityPattern.MatchString(quantity) || strings.HasSuffix(quantity, "%") {
+			v.Add(field, "%q is not a valid quantity for %s", quantity, name)
+		}
+	}
+}
+
+// validateEviction 检查驱逐信号和阈值
+func validateEviction(v *validate.Validator, field string, thresholds map[string]string) {
+	for signal, threshold := range thresholds {
+		if !evictionSignalPattern.MatchString(signal) {
+			v.Add(field, "%q is not a valid eviction signal", signal)
+		} else if !quantityPattern.MatchString(threshold) {
+			v.Add(field, "%q is not a valid threshold for %s", threshold, signal)
+		}
+	}
+}
+
+// Args 转换为kubelet命令行参数，键为不带--的参数名
+func (s KubeletSettings) Args() map[string]string {
+	args := make(map[string]string)
+	if len(s.SystemReserved) > 0 {
+		args["system-reserved"] = joinPairs(s.SystemReserved, "=")
+	}
+	if len(s.KubeReserved) > 0 {
+		args["kube-reserved"] = joinPairs(s.KubeReserved, "=")
+	}
+	if len(s.EvictionHard) > 0 {
+		args["eviction-hard"] = joinPairs(s.EvictionHard, "<")
+	}
+	if len(s.EvictionSoft) > 0 {
+		args["eviction-soft"] = joinPairs(s.EvictionSoft, "<")
+	}
+	if len(s.EvictionSoftGracePeriod) > 0 {
+		args["eviction-soft-grace-period"] = joinPairs(s.EvictionSoftGracePeriod, "=")
+	}
+	if s.MaxPods > 0 {
+		args["max-pods"] = strconv.Itoa(s.MaxPods)
+	}
+	if s.PodPidsLimit > 0 {
+		args["pod-max-pids"] = strconv.Itoa(s.PodPidsLimit)
+	}
+	if s.ResolvConf == ResolvConfAuto {
+		// 写入节点时替换为节点上实际使用的resolv.conf
+		args["resolv-conf"] = resolvConfAutoPlaceholder
+	} else if s.ResolvConf != "" {
+		args["resolv-conf"] = s.ResolvConf
+	}
+	return args
+}
+
+// Flags 按参数名排序的kubelet命令行参数
+func (s KubeletSettings) Flags() []string {
+	args := s.Args()
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]string, 0, len(names))
+	for _, name := range names {
+		flags = append(flags, fmt.Sprintf("--%s=%s", name, args[name]))
+	}
+	return flags
+}
+
+// joinPairs 按键排序后拼接为 key<sep>value,... 格式
+func joinPairs(values map[string]string, sep string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+sep+values[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// kubeletSettingsFor 返回节点使用的kubelet配置，NodeKubelet中的节点配置优先于集群配置
+func kubeletSettingsFor(opts DeployOptions, nodeID string) KubeletSettings {
+	if nodeSettings, ok := opts.NodeKubelet[nodeID]; ok {
+		return nodeSettings
+	}
+	return opts.Kubelet
+}
+
+// KubeletDropInCmd 生成写入kubelet systemd配置的命令。参数通过KUBELET_INSTALLER_ARGS变量追加到kubelet的启动参数，
+// 不影响kubeadm写入的10-kubeadm.conf和KUBELET_EXTRA_ARGS；kubelet正在运行时重启使配置生效
+func KubeletDropInCmd(settings KubeletSettings) string {
+	// systemd配置中的%需要转义为%%
+	flags := strings.ReplaceAll(strings.Join(settings.Flags(), " "), "%", "%%")
+	unit := strings.Join([]string{
+		"# generated by k8s-installer",
+		"[Service]",
+		fmt.Sprintf("Environment=\"KUBELET_INSTALLER_ARGS=%s\"", flags),
+		"ExecStart=",
+		"ExecStart=KUBELET_BIN $KUBELET_KUBECONFIG_ARGS $KUBELET_CONFIG_ARGS $KUBELET_KUBEADM_ARGS $KUBELET_EXTRA_ARGS $KUBELET_INSTALLER_ARGS",
+	}, "\n")
+
+	return fmt.Sprintf(`echo "=== 写入kubelet配置 ==="
+KUBELET_BIN=$(command -v kubelet || echo /usr/bin/kubelet)
+%[4]s
+sudo mkdir -p %[1]s
+printf '%%s\n' %[2]s | sed -e "s|=KUBELET_BIN |=$KUBELET_BIN |" -e "s|=%[5]s|=$RESOLV_CONF|" | sudo tee %[3]s > /dev/null
+sudo systemctl daemon-reload
+if sudo systemctl is-active --quiet kubelet; then
+    sudo systemctl restart kubelet
+fi
+echo "✓ kubelet配置已写入 %[3]s"`, shellQuote(KubeletDropInPath[:strings.LastIndex(KubeletDropInPath, "/")]), shellQuote(unit), KubeletDropInPath, resolveResolvConfCmd, resolvConfAutoPlaceholder)
+}
+
+// RemoveKubeletDropInCmd 删除安装器写入的kubelet配置
+const RemoveKubeletDropInCmd = `sudo rm -f ` + KubeletDropInPath + `
+sudo systemctl daemon-reload
+if sudo systemctl is-active --quiet kubelet; then
+    sudo systemctl restart kubelet
+fi
+echo "✓ kubelet配置已删除"`
@@ -0,0 +1,185 @@
+package kubeadm
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+	"k8s-installer/validate"
+)
+
+// DefaultKubeVIPImage 默认的kube-vip镜像
+const DefaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.8.9"
+
+// KubeVIPManifestPath kube-vip静态Pod清单路径，kubelet启动后即运行kube-vip，早于apiserver持有VIP
+const KubeVIPManifestPath = "/etc/kubernetes/manifests/kube-vip.yaml"
+
+// kubeVIPStagingPath 上传的kube-vip清单暂存路径。kubeadm init之前会执行kubeadm reset清空清单目录，
+// 因此清单先上传到暂存路径，在init之前再复制到KubeVIPManifestPath
+const kubeVIPStagingPath = "/tmp/k8s-installer-kube-vip.yaml"
+
+// kubeVIPSuperAdminMinor 从1.29起kubeadm init期间admin.conf还没有RBAC权限，kube-vip需要使用super-admin.conf
+const kubeVIPSuperAdminMinor = 29
+
+// interfaceNamePattern Linux网卡名称
+var interfaceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.:@-]{1,15}$`)
+
+// KubeVIPOptions kube-vip配置。启用后在控制平面节点上以静态Pod运行kube-vip，通过ARP宣告VIP，
+// 并将VIP作为kubeadm配置的controlPlaneEndpoint，节点通过VIP访问apiserver
+type KubeVIPOptions struct {
+	Enabled bool   `json:"enabled"`
+	VIP     string `json:"vip"`
+	// Interface 宣告VIP的网卡，为空时使用节点上访问VIP的路由所在的网卡
+	Interface string `json:"interface,omitempty"`
+	// Image kube-vip镜像，为空时使用DefaultKubeVIPImage
+	Image string `json:"image,omitempty"`
+}
+
+// Validate 检查VIP、网卡名称和镜像
+func (o KubeVIPOptions) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	v := &validate.Validator{}
+	if v.Required("vip", o.VIP) {
+		v.IP("vip", o.VIP)
+	}
+	if o.Interface != "" && !interfaceNamePattern.MatchString(o.Interface) {
+		v.Add("interface", "%q is not a valid network interface name", o.Interface)
+	}
+	if o.Image != "" {
+		if err := ValidateImageRef(o.Image); err != nil {
+			v.Add("image", "%q is not a valid image reference", o.Image)
+		}
+	}
+	return v.Err()
+}
+
+// Endpoint 返回VIP对应的控制平面地址
+func (o KubeVIPOptions) Endpoint() string {
+	return net.JoinHostPort(o.VIP, "6443")
+}
+
+// image 返回kube-vip镜像，未设置时为DefaultKubeVIPImage
+func (o KubeVIPOptions) image() string {
+	if o.Image == "" {
+		return DefaultKubeVIPImage
+	}
+	return o.Image
+}
+
+// applyKubeVIP 使用VIP作为控制平面地址，并将VIP加入apiserver证书的SAN。
+// 用户已配置controlPlaneEndpoint时保留（如指向VIP的域名）
+func applyKubeVIP(config *KubeadmConfig, o KubeVIPOptions) {
+	if !o.Enabled {
+		return
+	}
+	cluster := &config.ClusterConfiguration
+	if cluster.ControlPlaneEndpoint == "" {
+		cluster.ControlPlaneEndpoint = o.Endpoint()
+	}
+	for _, san := range cluster.APIServer.CertSANs {
+		if san == o.VIP {
+			return
+		}
+	}
+	cluster.APIServer.CertSANs = append(cluster.APIServer.CertSANs, o.VIP)
+}
+
+// controlPlaneEndpoint 节点加入集群使用的控制平面地址：优先使用kubeadm配置中的controlPlaneEndpoint，
+// 其次为kube-vip的VIP，否则为Master节点地址
+func controlPlaneEndpoint(opts DeployOptions, master node.Node) string {
+	if endpoint := opts.KubeadmConfig.ClusterConfiguration.ControlPlaneEndpoint; endpoint != "" {
+		return endpoint
+	}
+	if opts.KubeVIP.Enabled {
+		return opts.KubeVIP.Endpoint()
+	}
+	return fmt.Sprintf("%s:6443", master.IP)
+}
+
+// kubeVIPKubeconfig kube-vip使用的kubeconfig，1.29起第一个控制平面节点在init期间使用super-admin.conf
+func kubeVIPKubeconfig(kubeVersion string, firstControlPlane bool) string {
+	if major, minor, ok := parseMajorMinor(kubeVersion); firstControlPlane && ok && major == 1 && minor >= kubeVIPSuperAdminMinor {
+		return "/etc/kubernetes/super-admin.conf"
+	}
+	return "/etc/kubernetes/admin.conf"
+}
+
+// RenderKubeVIPManifest 生成kube-vip静态Pod清单，使用ARP模式和leader选举，同一时间只有一个控制平面节点持有VIP
+func RenderKubeVIPManifest(o KubeVIPOptions, iface, kubeconfig string) string {
+	vipCIDR := "32"
+	if ip := net.ParseIP(o.VIP); ip != nil && ip.To4() == nil {
+		vipCIDR = "128"
+	}
+	env := [][2]string{
+		{"vip_arp", "true"},
+		{"port", "6443"},
+		{"vip_interface", iface},
+		{"vip_cidr", vipCIDR},
+		{"cp_enable", "true"},
+		{"cp_namespace", "kube-system"},
+		{"vip_leaderelection", "true"},
+		{"vip_leasename", "plndr-cp-lock"},
+		{"vip_leaseduration", "5"},
+		{"vip_renewdeadline", "3"},
+		{"vip_retryperiod", "1"},
+		{"address", o.VIP},
+	}
+
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: kube-vip\n  namespace: kube-system\nspec:\n")
+	b.WriteString("  containers:\n  - name: kube-vip\n    image: " + yamlString(o.image()) + "\n")
+	b.WriteString("    imagePullPolicy: IfNotPresent\n    args:\n    - manager\n    env:\n")
+	for _, e := range env {
+		b.WriteString("    - name: " + e[0] + "\n      value: " + yamlString(e[1]) + "\n")
+	}
+	b.WriteString("    securityContext:\n      capabilities:\n        add:\n        - NET_ADMIN\n        - NET_RAW\n")
+	b.WriteString("    volumeMounts:\n    - name: kubeconfig\n      mountPath: /etc/kubernetes/admin.conf\n")
+	b.WriteString("  hostAliases:\n  - hostnames:\n    - kubernetes\n    ip: 127.0.0.1\n")
+	b.WriteString("  hostNetwork: true\n")
+	b.WriteString("  volumes:\n  - name: kubeconfig\n    hostPath:\n      path: " + yamlString(kubeconfig) + "\n")
+	return b.String()
+}
+
+// detectVIPInterface 返回节点上访问VIP的路由所在的网卡
+func detectVIPInterface(client ssh.Runner, vip string) (string, error) {
+	output, err := client.RunCommandSilent(fmt.Sprintf(`ip -o route get %s | awk '{for (i = 1; i < NF; i++) if ($i == "dev") { print $(i+1); exit }}'`, vip))
+	iface := strings.TrimSpace(output)
+	if err != nil || !interfaceNamePattern.MatchString(iface) {
+		return "", fmt.Errorf("failed to detect network interface for VIP %s, set kubeVip.interface explicitly: %v %s", vip, err, iface)
+	}
+	return iface, nil
+}
+
+// UploadKubeVIPManifest 生成kube-vip清单并通过SFTP上传到节点的暂存路径，返回宣告VIP的网卡
+func UploadKubeVIPManifest(client ssh.Runner, o KubeVIPOptions, kubeVersion string, firstControlPlane bool) (string, error) {
+	iface := o.Interface
+	if iface == "" {
+		var err error
+		if iface, err = detectVIPInterface(client, o.VIP); err != nil {
+			return "", err
+		}
+	}
+	manifest := RenderKubeVIPManifest(o, iface, kubeVIPKubeconfig(kubeVersion, firstControlPlane))
+	if err := client.Upload(kubeVIPStagingPath, []byte(manifest)); err != nil {
+		return "", fmt.Errorf("failed to upload kube-vip manifest: %v", err)
+	}
+	return iface, nil
+}
+
+// InstallKubeVIPCmd 将暂存的kube-vip清单复制到静态Pod目录，未上传清单时不做任何操作
+const InstallKubeVIPCmd = `if [ -f ` + kubeVIPStagingPath + ` ]; then
+    echo "=== 安装kube-vip静态Pod ==="
+    sudo install -D -m 600 ` + kubeVIPStagingPath + ` ` + KubeVIPManifestPath + `
+    rm -f ` + kubeVIPStagingPath + `
+    echo "✓ kube-vip清单已写入 ` + KubeVIPManifestPath + `"
+fi`
+
+// KubeVIPPostInitCmd kubeadm init完成后kube-vip改用admin.conf，super-admin.conf仅用于初始化
+const KubeVIPPostInitCmd = `if sudo grep -q super-admin.conf ` + KubeVIPManifestPath + ` 2>/dev/null; then
+    sudo sed -i 's#path: "/etc/kubernetes/super-admin.conf"#path: "/etc/kubernetes/admin.conf"#' ` + KubeVIPManifestPath + `
+fi`
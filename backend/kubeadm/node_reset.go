@@ -0,0 +1,119 @@
+package kubeadm
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s-installer/node"
+)
+
+// workerResetScript 重置已加入集群的worker节点，部署流程在重复部署worker节点前执行
+const workerResetScript = `# Worker节点重置脚本
+echo "=== 开始worker节点重置流程 ==="
+
+# 检查kubeadm是否安装
+if command -v kubeadm &> /dev/null; then
+	echo "1. 检查节点是否已加入集群..."
+	# 检查kubelet服务是否运行
+	if command -v systemctl &> /dev/null; then
+		systemctl_status=$(sudo systemctl is-active kubelet 2>/dev/null || echo "inactive")
+		if [ "$systemctl_status" = "active" ] || [ -f /etc/kubernetes/kubelet.conf ]; then
+			echo "2. 节点已加入集群，执行kubeadm reset..."
+			# 执行kubeadm reset，添加--force参数确保重置成功
+			sudo kubeadm reset --force --cri-socket=unix:///run/containerd/containerd.sock
+
+			# 清理残留文件
+			echo "3. 清理kubernetes残留文件..."
+			sudo rm -rf /etc/kubernetes /var/lib/kubelet /var/lib/dockershim /var/run/kubernetes /var/lib/cni
+
+			# 清理网络配置
+			echo "4. 清理网络配置..."
+			sudo rm -rf /etc/cni/net.d
+
+			# 重启containerd服务
+			echo "5. 重启containerd服务..."
+			sudo systemctl restart containerd || true
+			sleep 5
+
+			echo "✓ Worker节点重置完成"
+		else
+			echo "节点未加入集群，跳过重置步骤"
+		fi
+	else
+		echo "系统没有systemctl，跳过服务状态检查"
+	fi
+else
+	echo "kubeadm未安装，跳过重置步骤"
+fi
+
+echo "=== Worker节点重置流程完成 ==="`
+
+// k3sAgentUninstallScript 卸载k3s agent
+const k3sAgentUninstallScript = `if [ -x /usr/local/bin/k3s-agent-uninstall.sh ]; then
+    echo "=== 卸载k3s agent ==="
+    sudo /usr/local/bin/k3s-agent-uninstall.sh
+fi`
+
+// removeContainerdScript 停止containerd并清理容器数据和配置
+const removeContainerdScript = `echo "=== 清理containerd ==="
+sudo systemctl stop containerd || true
+sudo systemctl disable containerd || true
+sudo rm -rf /var/lib/containerd /run/containerd /etc/containerd`
+
+// removeK8sPackagesScript 卸载Kubernetes组件，removeContainerd为true时同时卸载containerd
+const removeK8sPackagesScript = `echo "=== 卸载软件包: %[1]s ==="
+sudo systemctl stop kubelet || true
+if command -v apt-get &> /dev/null; then
+    sudo apt-mark unhold %[1]s > /dev/null 2>&1 || true
+    sudo apt-get purge -y %[1]s || true
+    sudo apt-get autoremove -y || true
+elif command -v zypper &> /dev/null; then
+    sudo zypper --non-interactive removelock %[1]s > /dev/null 2>&1 || true
+    sudo zypper --non-interactive remove -y %[1]s || true
+elif command -v dnf &> /dev/null; then
+    sudo dnf remove -y %[1]s || true
+else
+    sudo yum remove -y %[1]s || true
+fi
+sudo rm -rf /etc/systemd/system/kubelet.service.d
+sudo systemctl daemon-reload`
+
+// NodeResetOptions 单节点重置选项
+type NodeResetOptions struct {
+	// KeepContainerd 保留containerd及其镜像缓存，节点回收后可以更快地重新加入集群
+	KeepContainerd bool `json:"keepContainerd"`
+	// KeepPackages 保留kubeadm、kubelet、kubectl软件包
+	KeepPackages bool `json:"keepPackages"`
+	// Description 变更说明，记录在审计日志中
+	Description string `json:"description"`
+}
+
+// NodeResetCmd 生成单节点重置命令：执行worker节点重置流程，再按选项清理containerd和软件包
+func NodeResetCmd(opts NodeResetOptions) string {
+	parts := []string{k3sAgentUninstallScript, workerResetScript}
+	if !opts.KeepContainerd {
+		parts = append(parts, removeContainerdScript)
+	}
+	if !opts.KeepPackages {
+		packages := "kubelet kubeadm kubectl"
+		if !opts.KeepContainerd {
+			packages += " containerd containerd.io"
+		}
+		parts = append(parts, fmt.Sprintf(removeK8sPackagesScript, packages))
+	}
+	parts = append(parts, `echo "✓ 节点重置完成"`)
+	return strings.Join(parts, "\n")
+}
+
+// ResetNode 重置单个worker节点，使其可以重新加入集群或用于其他用途
+func ResetNode(n node.Node, opts NodeResetOptions, logf func(msg string)) (string, error) {
+	client, err := node.Connect(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect node: %v", err)
+	}
+	defer client.Close()
+
+	return client.RunCommandWithOutput(NodeResetCmd(opts), func(line string) {
+		logf(line)
+	})
+}
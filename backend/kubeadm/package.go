@@ -10,9 +10,12 @@ import (
 
 // PackageSource 包源配置
 type PackageSource struct {
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	Default bool   `json:"default"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Default   bool      `json:"default"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // PackageInfo 包信息
@@ -26,8 +29,8 @@ type PackageInfo struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-// 包源列表
-var PackageSources = []PackageSource{
+// defaultPackageSources 默认包源，数据库为空时作为初始数据写入
+var defaultPackageSources = []PackageSource{
 	{
 		Name:    "官方源",
 		URL:     "https://dl.k8s.io",
@@ -50,16 +53,6 @@ var PackageSources = []PackageSource{
 	},
 }
 
-// GetDefaultSource 获取默认包源
-func GetDefaultSource() PackageSource {
-	for _, source := range PackageSources {
-		if source.Default {
-			return source
-		}
-	}
-	return PackageSources[0]
-}
-
 // GetPackagePath 获取包的本地存储路径
 func GetPackagePath(packageName, version, arch, distro string) string {
 	// 创建packages目录（如果不存在）
@@ -141,26 +134,3 @@ func DeletePackage(packageName, version, arch, distro string) error {
 	path := GetPackagePath(packageName, version, arch, distro)
 	return os.Remove(path)
 }
-
-// UpdatePackageSource 更新包源
-func UpdatePackageSource(index int, source PackageSource) error {
-	if index < 0 || index >= len(PackageSources) {
-		return fmt.Errorf("invalid source index: %d", index)
-	}
-	PackageSources[index] = source
-	return nil
-}
-
-// AddPackageSource 添加新包源
-func AddPackageSource(source PackageSource) {
-	PackageSources = append(PackageSources, source)
-}
-
-// DeletePackageSource 删除包源
-func DeletePackageSource(index int) error {
-	if index < 0 || index >= len(PackageSources) {
-		return fmt.Errorf("invalid source index: %d", index)
-	}
-	PackageSources = append(PackageSources[:index], PackageSources[index+1:]...)
-	return nil
-}
@@ -0,0 +1,272 @@
+package kubeadm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPackageSourceNotFound 包源不存在
+var ErrPackageSourceNotFound = errors.New("package source not found")
+
+// PackageSourceTestResult 包源可达性测试结果
+type PackageSourceTestResult struct {
+	SourceID   string `json:"sourceId"`
+	URL        string `json:"url"`
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	LatencyMs  int64  `json:"latencyMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PackageSourceManager 包源管理器，包源持久化在SQLite中
+type PackageSourceManager struct {
+	db    *sql.DB
+	mutex sync.RWMutex
+}
+
+// NewPackageSourceManager 创建包源管理器，数据库中没有包源时写入默认包源
+func NewPackageSourceManager(db *sql.DB) (*PackageSourceManager, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS package_sources (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		is_default INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create package_sources table: %v", err)
+	}
+
+	m := &PackageSourceManager{db: db}
+	if err := m.seedDefaults(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// seedDefaults 写入默认包源
+func (m *PackageSourceManager) seedDefaults() error {
+	var count int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM package_sources").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count package sources: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i, source := range defaultPackageSources {
+		_, err := m.db.Exec(
+			"INSERT INTO package_sources (id, name, url, is_default, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+			fmt.Sprintf("%d", now.UnixNano()+int64(i)), source.Name, source.URL, source.Default, now, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to seed package source: %v", err)
+		}
+	}
+	return nil
+}
+
+// ValidatePackageSource 校验包源配置
+func ValidatePackageSource(source PackageSource) error {
+	if strings.TrimSpace(source.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	u, err := url.Parse(source.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid url: %s", source.URL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https: %s", source.URL)
+	}
+	return nil
+}
+
+// ListSources 获取所有包源
+func (m *PackageSourceManager) ListSources() ([]PackageSource, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	rows, err := m.db.Query("SELECT id, name, url, is_default, created_at, updated_at FROM package_sources ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query package sources: %v", err)
+	}
+	defer rows.Close()
+
+	sources := []PackageSource{}
+	for rows.Next() {
+		var source PackageSource
+		if err := rows.Scan(&source.ID, &source.Name, &source.URL, &source.Default, &source.CreatedAt, &source.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan package source: %v", err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+// GetSource 获取指定包源
+func (m *PackageSourceManager) GetSource(id string) (*PackageSource, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var source PackageSource
+	err := m.db.QueryRow(
+		"SELECT id, name, url, is_default, created_at, updated_at FROM package_sources WHERE id = ?", id,
+	).Scan(&source.ID, &source.Name, &source.URL, &source.Default, &source.CreatedAt, &source.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPackageSourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query package source: %v", err)
+	}
+	return &source, nil
+}
+
+// GetDefaultSource 获取默认包源，没有标记默认时返回第一个包源
+func (m *PackageSourceManager) GetDefaultSource() (*PackageSource, error) {
+	sources, err := m.ListSources()
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, ErrPackageSourceNotFound
+	}
+	for _, source := range sources {
+		if source.Default {
+			return &source, nil
+		}
+	}
+	return &sources[0], nil
+}
+
+// CreateSource 添加新包源
+func (m *PackageSourceManager) CreateSource(source PackageSource) (*PackageSource, error) {
+	if err := ValidatePackageSource(source); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	source.ID = fmt.Sprintf("%d", now.UnixNano())
+	source.CreatedAt = now
+	source.UpdatedAt = now
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 只允许一个默认包源
+	if source.Default {
+		if _, err := tx.Exec("UPDATE package_sources SET is_default = 0"); err != nil {
+			return nil, fmt.Errorf("failed to reset default source: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO package_sources (id, name, url, is_default, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		source.ID, source.Name, source.URL, source.Default, source.CreatedAt, source.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to insert package source: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// UpdateSource 更新包源
+func (m *PackageSourceManager) UpdateSource(id string, source PackageSource) (*PackageSource, error) {
+	if err := ValidatePackageSource(source); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.GetSource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	source.ID = id
+	source.CreatedAt = existing.CreatedAt
+	source.UpdatedAt = time.Now()
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if source.Default {
+		if _, err := tx.Exec("UPDATE package_sources SET is_default = 0"); err != nil {
+			return nil, fmt.Errorf("failed to reset default source: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE package_sources SET name = ?, url = ?, is_default = ?, updated_at = ? WHERE id = ?",
+		source.Name, source.URL, source.Default, source.UpdatedAt, id,
+	); err != nil {
+		return nil, fmt.Errorf("failed to update package source: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// DeleteSource 删除包源
+func (m *PackageSourceManager) DeleteSource(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	res, err := m.db.Exec("DELETE FROM package_sources WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete package source: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPackageSourceNotFound
+	}
+	return nil
+}
+
+// TestSource 测试包源的可达性
+func (m *PackageSourceManager) TestSource(id string) (*PackageSourceTestResult, error) {
+	source, err := m.GetSource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PackageSourceTestResult{SourceID: source.ID, URL: source.URL}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	start := time.Now()
+	resp, err := client.Head(source.URL)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	// 部分镜像站不支持HEAD或根路径返回4xx，只要服务端有响应且不是5xx即认为可达
+	result.Reachable = resp.StatusCode < http.StatusInternalServerError
+	return result, nil
+}
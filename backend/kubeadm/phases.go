@@ -0,0 +1,988 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/script"
+	"k8s-installer/ssh"
+)
+
+// ClusterState 部署阶段之间传递的集群状态，Master节点初始化阶段写入join参数，Worker节点加入阶段读取
+type ClusterState struct {
+	JoinCmd  string
+	JoinInfo *node.JoinInfo
+}
+
+// phaseStep 内置部署阶段，After为空，由DeployK8sCluster按注册顺序执行
+type phaseStep struct {
+	name  string
+	title string
+	run   func(ctx context.Context, client ssh.Runner, params StepParams) error
+}
+
+// Name 阶段名称，与AllSteps中的步骤名称一致
+func (s phaseStep) Name() string { return s.name }
+
+// Title 阶段标题
+func (s phaseStep) Title() string { return s.title }
+
+// After 内置阶段不依附其他步骤
+func (s phaseStep) After() string { return "" }
+
+// Skip 内置阶段只通过skipSteps、节点配置和断点续部署跳过
+func (s phaseStep) Skip(StepParams) bool { return false }
+
+// Run 在节点上执行阶段
+func (s phaseStep) Run(ctx context.Context, client ssh.Runner, params StepParams) error {
+	return s.run(ctx, client, params)
+}
+
+// clusterPhases 不在逐节点流程中执行的内置阶段
+var clusterPhases = map[string]bool{StepMasterInitialization: true, StepWorkerJoin: true}
+
+// Phase 返回注册的内置阶段，未注册时返回nil
+func Phase(name string) Step {
+	pipelineMu.RLock()
+	defer pipelineMu.RUnlock()
+
+	for _, s := range phaseSteps {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// NodePhases 返回在每个节点上依次执行的内置阶段，不包括Master节点初始化和Worker节点加入
+func NodePhases() []Step {
+	pipelineMu.RLock()
+	defer pipelineMu.RUnlock()
+
+	var phases []Step
+	for _, s := range phaseSteps {
+		if !clusterPhases[s.Name()] {
+			phases = append(phases, s)
+		}
+	}
+	return phases
+}
+
+// log 输出一行部署日志，未设置Log时忽略
+func (p StepParams) log(line string) {
+	if p.Log != nil {
+		p.Log(line)
+	}
+}
+
+// logf 按格式输出一行部署日志
+func (p StepParams) logf(format string, args ...interface{}) {
+	p.log(fmt.Sprintf(format, args...))
+}
+
+// runPhaseScript 按步骤的重试策略执行脚本，脚本输出逐行写入部署日志
+func runPhaseScript(ctx context.Context, client ssh.Runner, params StepParams, step, cmd string) (string, error) {
+	var output string
+	err := retryPolicyFor(params.Options.RetryPolicies, step).Do(ctx, func(attempt int) error {
+		if attempt > 1 {
+			params.logf("步骤 %s 第%d次尝试", step, attempt)
+		}
+		var runErr error
+		output, runErr = client.RunCommandWithOutput(cmd, func(line string) {
+			params.log("[脚本输出] " + line)
+		})
+		return runErr
+	}, func(attempt int, err error, wait time.Duration) {
+		params.logf("步骤 %s 第%d次执行失败: %v，%v后重试", step, attempt, err, wait)
+	})
+	return output, err
+}
+
+// resolvePhaseScript 解析阶段使用的脚本并替换版本号，返回脚本名称和内容
+func resolvePhaseScript(params StepParams, step, kind string) (string, string) {
+	resolved, _ := script.Resolve(params.Scripts, step, params.Distro, params.Family)
+	if resolved.Default {
+		params.logf("使用默认%s脚本", kind)
+	} else {
+		params.logf("使用自定义%s脚本: %s", kind, resolved.Name)
+	}
+	return resolved.Name, strings.ReplaceAll(resolved.Content, "${version}", params.KubeVersion)
+}
+
+// runSystemPreparation 执行系统准备脚本，默认脚本之前禁用swap。脚本失败时只输出警告，继续执行IP转发配置
+func runSystemPreparation(ctx context.Context, client ssh.Runner, params StepParams) error {
+	resolved, _ := script.Resolve(params.Scripts, script.StepSystemPrep, params.Distro, params.Family)
+	cmd := strings.ReplaceAll(resolved.Content, "${version}", params.KubeVersion)
+	if resolved.Default {
+		// 启用swap时保留节点的swap，cgroup版本在系统准备之后单独检查
+		if !params.Options.Swap.Enabled {
+			cmd = DisableSwapCmd + "\n" + cmd
+		}
+		params.log("使用默认系统准备脚本")
+	} else {
+		params.logf("使用自定义系统准备脚本: %s", resolved.Name)
+	}
+
+	startTime := time.Now()
+	params.logf("开始执行系统准备脚本: %s，开始时间: %s", resolved.Name, startTime.Format("2006-01-02 15:04:05"))
+	_, err := runPhaseScript(ctx, client, params, StepSystemPreparation, cmd)
+	params.logf("脚本执行持续时间: %v", time.Since(startTime))
+	if err != nil {
+		params.logf("系统准备脚本执行失败: %v", err)
+		params.log("警告: 系统准备脚本执行失败，但将继续尝试IP转发配置")
+		return nil
+	}
+	params.log("系统准备脚本执行成功")
+	return nil
+}
+
+// runIPForwardConfiguration 单独配置IP转发和桥接内核参数，即使系统准备脚本中已有配置。失败时只输出警告，init之前会再次检查
+func runIPForwardConfiguration(ctx context.Context, client ssh.Runner, params StepParams) error {
+	params.log("脚本名称: ip_forward_config")
+	output, err := runPhaseScript(ctx, client, params, StepIpForwardConfiguration, ipForwardCmd)
+	if err != nil {
+		params.logf("IP转发配置脚本执行出现错误: %v", err)
+	} else {
+		params.log("IP转发配置脚本执行成功")
+		if !strings.Contains(output, "✓ 配置文件已生成") {
+			params.log("警告: 配置文件可能未成功生成，请检查目标服务器")
+		}
+	}
+
+	params.log("=== 最终验证IP转发状态 ===")
+	if _, err := client.RunCommandWithOutput(ipForwardCheckCmd, params.log); err != nil {
+		params.logf("最终IP转发验证失败: %v", err)
+		return nil
+	}
+	params.log("最终IP转发验证完成")
+	return nil
+}
+
+// runContainerRuntimeInstallation 安装并配置containerd，通过CRI API确认运行时就绪
+func runContainerRuntimeInstallation(ctx context.Context, client ssh.Runner, params StepParams) error {
+	_, installCmd := resolvePhaseScript(params, script.StepContainerdInstall, "容器运行时安装")
+	// 节点组指定了containerd版本时通过CONTAINERD_VERSION变量传给安装脚本
+	if version := groupDefaultsFor(params.Options, params.Node.ID).ContainerdVersion; version != "" {
+		installCmd = containerdVersionEnv(version) + installCmd
+		params.logf("containerd版本: %s", version)
+	}
+	if _, err := runPhaseScript(ctx, client, params, StepContainerRuntimeInstallation, installCmd); err != nil {
+		params.logf("容器运行时安装失败: %v", err)
+		return err
+	}
+	params.log("容器运行时安装成功")
+
+	// 自定义配置脚本缺少启动containerd的必要命令时使用默认脚本
+	resolved, _ := script.Resolve(params.Scripts, script.StepContainerdConfig, params.Distro, params.Family)
+	if !resolved.Default && len(script.MissingEssentialCommands(script.StepContainerdConfig, resolved.Content)) > 0 {
+		params.logf("警告: 自定义脚本 %s 不完整，缺少必要的启动命令，将使用默认脚本", resolved.Name)
+		resolved, _ = script.ResolveDefault(script.StepContainerdConfig, params.Family)
+	}
+	if resolved.Default {
+		params.log("使用默认容器运行时配置脚本")
+	} else {
+		params.logf("使用自定义容器运行时配置脚本: %s (已验证完整性)", resolved.Name)
+	}
+	configCmd := strings.ReplaceAll(resolved.Content, "${version}", params.KubeVersion)
+	if _, err := client.RunCommandWithOutput(configCmd, func(line string) {
+		params.log("[脚本输出] " + line)
+	}); err != nil {
+		params.logf("容器运行时配置失败: %v", err)
+		return err
+	}
+	params.log("容器运行时配置成功")
+
+	// 配置脚本结束时containerd可能仍在启动，通过CRI API确认运行时就绪后再继续
+	health, err := WaitForCRI(ctx, client, ContainerdReadyTimeout)
+	if err != nil {
+		params.logf("容器运行时未就绪: %v", err)
+		return err
+	}
+	params.logf("容器运行时就绪: %s %s (%s)", health.RuntimeName, health.RuntimeVersion, health.Tool)
+	return nil
+}
+
+// runKubernetesRepositoryConfiguration 添加目标次版本号的Kubernetes软件仓库
+func runKubernetesRepositoryConfiguration(ctx context.Context, client ssh.Runner, params StepParams) error {
+	_, cmd := resolvePhaseScript(params, script.StepK8sRepo, "添加Kubernetes仓库")
+	// 自定义脚本和默认脚本中的仓库占位符替换为目标次版本号的仓库地址
+	cmd = params.KubeRepo.Expand(cmd)
+	if _, err := runPhaseScript(ctx, client, params, StepKubernetesRepositoryConfiguration, cmd); err != nil {
+		params.logf("添加Kubernetes仓库失败: %v", err)
+		return err
+	}
+	params.log("添加Kubernetes仓库成功")
+	return nil
+}
+
+// runKubernetesComponentsInstallation 安装kubeadm、kubelet和kubectl
+func runKubernetesComponentsInstallation(ctx context.Context, client ssh.Runner, params StepParams) error {
+	_, cmd := resolvePhaseScript(params, script.StepK8sComponents, "Kubernetes组件安装")
+	cmd = params.KubeRepo.Expand(cmd)
+	if _, err := runPhaseScript(ctx, client, params, StepKubernetesComponentsInstallation, cmd); err != nil {
+		params.logf("Kubernetes组件安装失败: %v", err)
+		return err
+	}
+	params.log("Kubernetes组件安装成功")
+	return nil
+}
+
+// runMasterInitialization 初始化Master节点：再次应用IP转发配置，上传CA、kube-vip清单和etcd证书，
+// 执行自定义初始化脚本或使用生成的kubeadm配置执行kubeadm init，join参数写入params.Cluster
+func runMasterInitialization(ctx context.Context, client ssh.Runner, params StepParams) error {
+	opts, master := params.Options, params.Node
+
+	// 在执行init命令前再次验证和应用IP转发配置
+	params.log("=== 最后验证和应用IP转发配置 ===")
+	output, err := client.RunCommandWithOutput(finalIpForwardCmd, func(line string) {
+		params.log("[脚本输出] " + line)
+	})
+	switch {
+	case err != nil:
+		// kubeadm init会再次检查IP转发，这里只输出警告
+		params.logf("最后验证和应用IP转发配置失败: %v", err)
+		params.log("警告: IP转发配置验证失败，但将继续执行Master节点初始化，因为kubeadm init会再次检查")
+	case !strings.Contains(output, "最终IP转发值: 1") || !strings.Contains(output, "直接写入文件后，内容为: 1"):
+		params.log("警告: IP转发值可能未正确设置为1，建议检查")
+	default:
+		params.log("✓ IP转发值已正确设置为1")
+	}
+
+	// 初始化之前写入kubelet的systemd配置，自定义初始化脚本同样生效
+	if kubelet := kubeletSettingsFor(opts, master.ID); !kubelet.Empty() {
+		if output, err := client.RunCommandWithOutput(KubeletDropInCmd(kubelet), params.log); err != nil {
+			params.logf("写入kubelet配置失败: %v\n输出: %s", err, output)
+			return fmt.Errorf("Master节点 %s 写入kubelet配置失败: %v", master.Name, err)
+		}
+	}
+
+	// 上传自定义集群CA和kube-vip清单，初始化脚本在kubeadm reset之后、kubeadm init之前将其复制到目标目录
+	var preInitCmds []string
+	if !opts.CA.Empty() {
+		if err := UploadClusterCA(client, opts.CA); err != nil {
+			params.logf("上传集群CA失败: %v", err)
+			return fmt.Errorf("Master节点 %s 上传集群CA失败: %v", master.Name, err)
+		}
+		preInitCmds = append(preInitCmds, InstallClusterCACmd)
+		params.log("自定义集群CA已上传")
+	}
+	if opts.KubeVIP.Enabled {
+		iface, err := UploadKubeVIPManifest(client, opts.KubeVIP, params.KubeVersion, true)
+		if err != nil {
+			params.logf("上传kube-vip清单失败: %v", err)
+			return fmt.Errorf("Master节点 %s 上传kube-vip清单失败: %v", master.Name, err)
+		}
+		preInitCmds = append(preInitCmds, InstallKubeVIPCmd)
+		params.logf("kube-vip清单已上传，VIP: %s，网卡: %s", opts.KubeVIP.VIP, iface)
+	}
+	// 外部etcd：上传客户端证书并在init之前检查etcd是否可以访问
+	if external := opts.KubeadmConfig.ClusterConfiguration.Etcd.External; external != nil {
+		if err := UploadExternalEtcdCerts(client, *external); err != nil {
+			params.logf("上传etcd证书失败: %v", err)
+			return fmt.Errorf("Master节点 %s 上传etcd证书失败: %v", master.Name, err)
+		}
+		health, err := CheckExternalEtcd(client, *external)
+		for _, h := range health {
+			status := "✓"
+			if !h.Healthy {
+				status = "✗"
+			}
+			params.logf("%s 外部etcd %s: %s", status, h.Endpoint, h.Output)
+		}
+		if err != nil {
+			params.logf("外部etcd检查失败: %v", err)
+			return fmt.Errorf("Master节点 %s 无法访问外部etcd: %v", master.Name, err)
+		}
+		preInitCmds = append(preInitCmds, InstallExternalEtcdCertsCmd)
+	}
+	preInitCmd := strings.Join(preInitCmds, "\n")
+
+	// 没有自定义初始化脚本时使用生成的kubeadm配置初始化，不使用k8s_init默认模板
+	var initCmd string
+	if resolved, ok := script.Resolve(params.Scripts, script.StepK8sInit, params.Distro, ""); ok && !resolved.Default {
+		initCmd = strings.ReplaceAll(resolved.Content, "${version}", params.KubeVersion)
+		if preInitCmd != "" {
+			initCmd = preInitCmd + "\n" + initCmd
+		}
+		params.logf("使用自定义Kubernetes初始化脚本: %s", resolved.Name)
+	} else {
+		// 生成kubeadm配置文件并通过SFTP上传，kubeadm init使用--config执行
+		kubeadmConfig := opts.KubeadmConfig
+		kubeadmConfig.ClusterConfiguration.KubernetesVersion = params.KubeVersion
+		if opts.KubeProxyMode != "" {
+			kubeadmConfig.KubeProxy.Mode = opts.KubeProxyMode
+		}
+		if kubeletArgs := kubeletArgsFor(opts, master.ID); len(kubeletArgs) > 0 {
+			kubeadmConfig.InitConfiguration.NodeRegistration.KubeletExtraArgs = kubeletArgs
+		}
+		kubeadmConfig.Swap = opts.Swap
+		kubeadmConfig.CgroupDriver = params.CgroupDriver
+		kubeadmConfig.InitConfiguration.NodeRegistration.IgnorePreflightErrors = append(kubeadmConfig.InitConfiguration.NodeRegistration.IgnorePreflightErrors, opts.IgnorePreflightErrors...)
+		applyKubeVIP(&kubeadmConfig, opts.KubeVIP)
+		configContent, err := UploadKubeadmConfig(client, kubeadmConfig)
+		if err != nil {
+			params.logf("上传kubeadm配置失败: %v", err)
+			return fmt.Errorf("上传kubeadm配置失败: %v", err)
+		}
+		params.logf("kubeadm配置已上传到 %s:\n%s", KubeadmConfigPath, configContent)
+		initCmd = defaultInitCmd(opts.Swap.Enabled, preInitCmd)
+		params.log("使用默认Kubernetes初始化脚本")
+	}
+
+	initOutput, err := client.RunCommandWithOutput(initCmd, params.log)
+	// 初始化失败时同样保存输出，用于排查
+	emitArtifact(opts, master.ID, ArtifactInitOutput, initOutput)
+	if err != nil {
+		params.logf("Master节点初始化失败: %v", err)
+		return err
+	}
+	params.log("Master节点初始化成功")
+	if opts.KubeVIP.Enabled {
+		if output, err := client.RunCommand(KubeVIPPostInitCmd); err != nil {
+			params.logf("警告: 切换kube-vip的kubeconfig失败: %v %s", err, output)
+		}
+	}
+
+	// 从init输出中解析join参数，包括以反斜杠续行的多行命令和--upload-certs的证书密钥；
+	// 没有从输出中捕获到join命令时直接获取
+	info, err := node.ParseJoinCommand(initOutput)
+	if err == nil {
+		emitArtifact(opts, master.ID, ArtifactCertificateKey, info.CertificateKey)
+		params.log("=== 已获取Join命令，开始部署Worker节点 ===")
+	} else {
+		params.log("=== 从输出中未捕获到Join命令，尝试直接获取 ===")
+		info, err = fetchJoinInfo(client, controlPlaneEndpoint(opts, master), params.logf)
+		if err != nil {
+			return err
+		}
+	}
+	if params.Cluster != nil {
+		params.Cluster.JoinInfo = info
+		params.Cluster.JoinCmd = info.Command()
+	}
+	return nil
+}
+
+// runWorkerJoin 准备Calico依赖、写入kubelet配置后执行params.Cluster中的join命令，失败时先kubeadm reset再按重试策略重试
+func runWorkerJoin(ctx context.Context, client ssh.Runner, params StepParams) error {
+	opts, worker := params.Options, params.Node
+	if params.Cluster == nil || params.Cluster.JoinCmd == "" {
+		return fmt.Errorf("Worker节点 %s 没有可用的join命令", worker.Name)
+	}
+
+	// 执行Calico初始化依赖步骤，依赖步骤失败不一定导致join失败
+	if output, err := client.RunCommandWithOutput(calicoPrepCmd, params.log); err != nil {
+		params.logf("Worker节点 %s Calico初始化依赖步骤执行失败: %v\n输出: %s", worker.Name, err, output)
+	} else {
+		params.logf("Worker节点 %s Calico初始化依赖步骤执行成功", worker.Name)
+	}
+
+	// kubelet额外参数和节点组标签写入环境文件，join时由kubelet读取
+	if kubeletArgs := kubeletArgsFor(opts, worker.ID); len(kubeletArgs) > 0 {
+		if output, err := client.RunCommand(KubeletExtraArgsCmd(kubeletArgs)); err != nil {
+			params.logf("Worker节点 %s 写入kubelet额外参数失败: %v\n输出: %s", worker.Name, err, output)
+			return err
+		}
+	}
+	// 加入集群之前写入kubelet的systemd配置
+	if kubelet := kubeletSettingsFor(opts, worker.ID); !kubelet.Empty() {
+		if output, err := client.RunCommand(KubeletDropInCmd(kubelet)); err != nil {
+			params.logf("Worker节点 %s 写入kubelet配置失败: %v\n输出: %s", worker.Name, err, output)
+			return err
+		}
+	}
+
+	var joinOutput string
+	err := retryPolicyFor(opts.RetryPolicies, StepWorkerJoin).Do(ctx, func(attempt int) error {
+		if attempt > 1 {
+			// 重试前清理上一次失败的join残留状态
+			params.logf("第%d次尝试加入集群，先执行kubeadm reset", attempt)
+			client.RunCommand("sudo kubeadm reset -f")
+		}
+		var joinErr error
+		joinOutput, joinErr = client.RunCommandWithOutput(params.Cluster.JoinCmd, params.log)
+		return joinErr
+	}, func(attempt int, err error, wait time.Duration) {
+		params.logf("第%d次加入集群失败: %v，%v后重试", attempt, err, wait)
+	})
+	if err != nil {
+		params.logf("Worker节点 %s 加入集群失败: %v\n输出: %s", worker.Name, err, joinOutput)
+		return err
+	}
+	params.logf("Worker节点 %s 加入集群成功", worker.Name)
+	return nil
+}
+
+func init() {
+	// 内置阶段按部署顺序注册
+	for _, phase := range []phaseStep{
+		{name: StepSystemPreparation, title: "执行系统准备", run: runSystemPreparation},
+		{name: StepIpForwardConfiguration, title: "执行IP转发配置", run: runIPForwardConfiguration},
+		{name: StepContainerRuntimeInstallation, title: "安装容器运行时", run: runContainerRuntimeInstallation},
+		{name: StepKubernetesRepositoryConfiguration, title: "添加Kubernetes仓库", run: runKubernetesRepositoryConfiguration},
+		{name: StepKubernetesComponentsInstallation, title: "安装Kubernetes组件", run: runKubernetesComponentsInstallation},
+		{name: StepMasterInitialization, title: "初始化Master节点", run: runMasterInitialization},
+		{name: StepWorkerJoin, title: "Worker节点加入集群", run: runWorkerJoin},
+	} {
+		RegisterStep(phase)
+	}
+}
+
+// ipForwardCmd 写入IP转发和桥接内核参数配置并立即生效
+const ipForwardCmd = `
+# 1. 确保/etc/sysctl.d目录存在
+echo "=== 确保配置目录存在 ==="
+sudo mkdir -p /etc/sysctl.d
+
+# 2. 写入IP转发配置文件，使用bash -c确保权限
+echo "1. 正在配置IP转发..."
+sudo bash -c 'cat <<EOF > /etc/sysctl.d/99-kubernetes-ipforward.conf
+net.ipv4.ip_forward = 1
+EOF'
+
+# 3. 验证IP转发配置文件是否生成，失败则重试
+echo "2. 验证IP转发配置文件是否生成..."
+for i in {1..3}; do
+    if [ -f /etc/sysctl.d/99-kubernetes-ipforward.conf ]; then
+        echo "✓ 配置文件已生成，内容为:"
+        sudo cat /etc/sysctl.d/99-kubernetes-ipforward.conf
+        break
+    else
+        echo "✗ 配置文件未生成，正在重试 ($i/3)..."
+        sudo bash -c 'cat <<EOF > /etc/sysctl.d/99-kubernetes-ipforward.conf
+net.ipv4.ip_forward = 1
+EOF'
+        sleep 1
+    fi
+done
+
+# 4. 写入其他Kubernetes所需内核参数配置文件
+echo "3. 正在配置其他Kubernetes内核参数..."
+sudo bash -c 'cat <<EOF > /etc/sysctl.d/k8s.conf
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+EOF'
+
+# 5. 验证其他内核参数配置文件是否生成，失败则重试
+echo "4. 验证其他内核参数配置文件是否生成..."
+for i in {1..3}; do
+    if [ -f /etc/sysctl.d/k8s.conf ]; then
+        echo "✓ 配置文件已生成，内容为:"
+        sudo cat /etc/sysctl.d/k8s.conf
+        break
+    else
+        echo "✗ 配置文件未生成，正在重试 ($i/3)..."
+        sudo bash -c 'cat <<EOF > /etc/sysctl.d/k8s.conf
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+EOF'
+        sleep 1
+    fi
+done
+
+# 6. 设置配置文件权限，确保系统可以读取
+echo "5. 设置配置文件权限..."
+sudo chmod 644 /etc/sysctl.d/99-kubernetes-ipforward.conf
+sudo chmod 644 /etc/sysctl.d/k8s.conf
+
+# 7. 加载必要的内核模块
+echo "6. 正在加载内核模块..."
+sudo modprobe br_netfilter || echo "br_netfilter模块已加载或加载失败"
+sudo modprobe overlay || echo "overlay模块已加载或加载失败"
+
+# 8. 直接写入/proc/sys/net/ipv4/ip_forward文件确保立即生效，添加重试机制
+echo "7. 直接写入/proc/sys/net/ipv4/ip_forward文件确保立即生效..."
+for i in {1..5}; do
+    if sudo bash -c 'echo 1 > /proc/sys/net/ipv4/ip_forward'; then
+        echo "✓ 直接写入/proc/sys/net/ipv4/ip_forward文件成功"
+        break
+    else
+        echo "✗ 直接写入/proc/sys/net/ipv4/ip_forward文件失败，正在重试 ($i/5)..."
+        sleep 1
+    fi
+done
+
+# 9. 验证直接写入结果
+echo "8. 验证直接写入结果..."
+direct_value=$(cat /proc/sys/net/ipv4/ip_forward)
+echo "直接写入文件后，内容为: $direct_value"
+
+# 10. 应用所有内核参数
+echo "9. 正在应用内核参数..."
+sudo sysctl --system
+
+# 11. 立即设置IP转发值，确保即时生效
+echo "10. 确保IP转发即时生效..."
+sudo sysctl -w net.ipv4.ip_forward=1
+sudo sysctl -w net.bridge.bridge-nf-call-iptables=1
+sudo sysctl -w net.bridge.bridge-nf-call-ip6tables=1
+
+# 12. 等待2秒，确保设置生效
+sleep 2
+
+# 13. 验证内核参数设置
+echo "11. 最终验证内核参数..."
+sudo sysctl net.bridge.bridge-nf-call-iptables net.bridge.bridge-nf-call-ip6tables net.ipv4.ip_forward
+
+# 14. 再次验证sysctl值
+echo "12. 再次验证sysctl值..."
+sysctl_value=$(sudo sysctl -n net.ipv4.ip_forward)
+echo "sysctl获取的IP转发值: $sysctl_value"
+
+# 15. 再次检查/proc/sys/net/ipv4/ip_forward文件内容
+echo "13. 再次检查/proc/sys/net/ipv4/ip_forward文件内容..."
+proc_value=$(cat /proc/sys/net/ipv4/ip_forward)
+echo "/proc/sys/net/ipv4/ip_forward文件内容: $proc_value"
+
+# 16. 验证文件权限
+echo "14. 验证配置文件权限..."
+sudo ls -la /etc/sysctl.d/99-kubernetes-ipforward.conf /etc/sysctl.d/k8s.conf 2>/dev/null || echo "配置文件可能未生成"
+
+# 17. 列出/etc/sysctl.d目录下的所有配置文件，确认文件已生成
+echo "15. 列出/etc/sysctl.d目录下的所有配置文件..."
+sudo ls -la /etc/sysctl.d/
+
+# 18. 最终确认IP转发状态
+echo "16. 最终确认IP转发状态..."
+if [ "$proc_value" = "1" ] && [ "$sysctl_value" = "1" ]; then
+    echo "✓ IP转发已成功设置为1"
+else
+    echo "✗ IP转发设置失败，当前值: proc=$proc_value, sysctl=$sysctl_value"
+    # 最后一次尝试
+echo "进行最后一次修复尝试..."
+sudo bash -c 'echo 1 > /proc/sys/net/ipv4/ip_forward'
+sudo sysctl -w net.ipv4.ip_forward=1
+final_value=$(cat /proc/sys/net/ipv4/ip_forward)
+echo "最后尝试后的值: $final_value"
+fi
+`
+
+// ipForwardCheckCmd 输出最终的IP转发状态
+const ipForwardCheckCmd = `
+# 最终验证IP转发状态
+final_ip_forward=$(sudo sysctl -n net.ipv4.ip_forward)
+echo "最终IP转发值: $final_ip_forward"
+
+# 检查/proc/sys/net/ipv4/ip_forward文件内容
+echo "=== 检查/proc/sys/net/ipv4/ip_forward文件内容 ==="
+cat /proc/sys/net/ipv4/ip_forward
+`
+
+// finalIpForwardCmd kubeadm init之前再次应用IP转发配置，输出中包含最终IP转发值
+const finalIpForwardCmd = `
+# 1. 确保IP转发配置文件存在并包含正确的配置，设置适当的权限
+ echo "=== 再次配置IP转发 ==="
+sudo bash -c 'cat <<EOF > /etc/sysctl.d/99-kubernetes-ipforward.conf
+net.ipv4.ip_forward = 1
+EOF'
+
+# 2. 设置配置文件权限，确保系统可以读取
+echo "=== 设置配置文件权限 ==="
+sudo chmod 644 /etc/sysctl.d/99-kubernetes-ipforward.conf
+
+# 3. 确保其他Kubernetes所需内核参数配置正确
+echo "=== 确保其他Kubernetes内核参数配置正确 ==="
+sudo bash -c 'cat <<EOF > /etc/sysctl.d/k8s.conf
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+EOF'
+sudo chmod 644 /etc/sysctl.d/k8s.conf
+
+# 4. 加载必要的内核模块，确保模块已加载
+echo "=== 加载必要的内核模块 ==="
+sudo modprobe br_netfilter || echo "br_netfilter模块已加载或加载失败"
+sudo modprobe overlay || echo "overlay模块已加载或加载失败"
+
+# 5. 应用所有内核参数，使用sudo确保权限
+echo "=== 再次应用内核参数 ==="
+sudo sysctl --system
+
+# 6. 立即直接设置IP转发值，确保即时生效，使用bash -c确保权限
+echo "=== 立即直接设置IP转发值 ==="
+sudo bash -c 'sysctl -w net.ipv4.ip_forward=1'
+sudo bash -c 'sysctl -w net.bridge.bridge-nf-call-iptables=1'
+sudo bash -c 'sysctl -w net.bridge.bridge-nf-call-ip6tables=1'
+
+# 7. 等待1秒，确保设置生效
+sleep 1
+
+# 8. 再次验证IP转发状态，使用bash -c确保权限
+echo "=== 最终验证IP转发状态 ==="
+final_ip_forward=$(sudo bash -c 'sysctl -n net.ipv4.ip_forward')
+echo "最终IP转发值: $final_ip_forward"
+
+# 9. 检查/proc/sys/net/ipv4/ip_forward文件内容，确保文件存在且内容正确，添加重试机制
+        echo "=== 再次检查/proc/sys/net/ipv4/ip_forward文件内容 ==="
+        # 重试写入/proc/sys/net/ipv4/ip_forward文件，最多5次
+        for i in {1..5}; do
+            if [ -f /proc/sys/net/ipv4/ip_forward ]; then
+                echo "文件存在，当前内容为: $(cat /proc/sys/net/ipv4/ip_forward)"
+                # 直接写入文件，确保内容正确
+                if sudo bash -c 'echo 1 > /proc/sys/net/ipv4/ip_forward'; then
+                    current_value=$(cat /proc/sys/net/ipv4/ip_forward)
+                    echo "直接写入文件后，内容为: $current_value"
+                    # 如果写入后值为1，退出循环
+                    if [ "$current_value" = "1" ]; then
+                        echo "✓ IP转发值已成功设置为1"
+                        break
+                    fi
+                fi
+            else
+                echo "文件不存在，尝试创建并写入"
+                sudo bash -c 'mkdir -p /proc/sys/net/ipv4'
+                sudo bash -c 'echo 1 > /proc/sys/net/ipv4/ip_forward'
+                echo "创建并写入后，内容为: $(cat /proc/sys/net/ipv4/ip_forward)"
+            fi
+            echo "✗ IP转发值设置失败，正在重试 ($i/5)..."
+            sleep 1
+        done
+        
+        # 验证最终结果
+        echo "=== 验证最终IP转发设置 ==="
+        final_value=$(cat /proc/sys/net/ipv4/ip_forward)
+        if [ "$final_value" = "1" ]; then
+            echo "✓ IP转发已成功设置，最终值为: $final_value"
+        else
+            echo "✗ IP转发设置失败，最终值为: $final_value"
+            # 作为最后的手段，尝试使用echo命令直接写入
+            echo "=== 作为最后的手段，尝试使用echo命令直接写入 ==="
+            sudo sh -c "echo 1 > /proc/sys/net/ipv4/ip_forward"
+            echo "最终尝试后，内容为: $(cat /proc/sys/net/ipv4/ip_forward)"
+        fi
+
+# 10. 最后再次应用所有内核参数，确保所有设置都生效
+echo "=== 最后再次应用内核参数 ==="
+sudo sysctl --system
+
+# 11. 最终验证所有关键内核参数
+echo "=== 最终验证所有关键内核参数 ==="
+sudo bash -c 'sysctl net.bridge.bridge-nf-call-iptables net.bridge.bridge-nf-call-ip6tables net.ipv4.ip_forward'
+`
+
+// defaultInitCmd 没有自定义初始化脚本时的Master节点初始化脚本：清理旧状态、确认containerd运行后
+// 使用KubeadmConfigPath执行kubeadm init，配置kubectl并安装Flannel。preInitCmd在kubeadm init之前执行
+func defaultInitCmd(swapEnabled bool, preInitCmd string) string {
+	return fmt.Sprintf(script.WaitForFunc+`# 重置集群，清理旧配置
+										echo "=== 重置集群，清理旧配置 ==="
+										sudo kubeadm reset --force
+										
+										# 清理CNI配置
+										echo "=== 清理CNI配置 ==="
+										sudo rm -rf /etc/cni/net.d
+										
+										# 重置iptables规则
+										echo "=== 重置iptables规则 ==="
+										sudo iptables -F
+										sudo iptables -t nat -F
+										sudo iptables -t mangle -F
+										sudo iptables -X
+										
+										# 重置ip6tables规则
+										echo "=== 重置ip6tables规则 ==="
+										sudo ip6tables -F
+										sudo ip6tables -t nat -F
+										sudo ip6tables -t mangle -F
+										sudo ip6tables -X
+										
+										# 如果使用IPVS，重置IPVS表
+										echo "=== 重置IPVS表 ==="
+										if command -v ipvsadm &> /dev/null; then
+										    sudo ipvsadm --clear
+										fi
+										
+										# 清理kubeconfig文件
+										echo "=== 清理kubeconfig文件 ==="
+										sudo rm -rf ~/.kube
+										rm -rf $HOME/.kube
+				
+				# 清理集群配置文件
+				echo "=== 清理集群配置文件 ==="
+				sudo rm -f /etc/kubernetes/admin.conf
+				sudo rm -f /etc/kubernetes/kubelet.conf
+				sudo rm -f /etc/kubernetes/controller-manager.conf
+				sudo rm -f /etc/kubernetes/scheduler.conf
+				sudo rm -rf /etc/kubernetes/manifests
+				
+				# 清理旧的etcd数据
+				echo "=== 清理旧的etcd数据 ==="
+				sudo rm -rf /var/lib/etcd
+				
+				# 清理旧的kubelet数据
+				echo "=== 清理旧的kubelet数据 ==="
+				sudo rm -rf /var/lib/kubelet
+
+# 在执行kubeadm init前检查并确保containerd正常运行
+echo "=== 检查并确保containerd正常运行 ==="
+
+# 1. 检查containerd服务状态
+echo "1. 检查containerd服务状态..."
+containerd_status=$(sudo systemctl is-active containerd 2>/dev/null || echo "inactive")
+echo "containerd服务状态: $containerd_status"
+
+# 2. 如果containerd没有运行，尝试启动它
+if [ "$containerd_status" != "active" ]; then
+    echo "2. containerd未运行，尝试启动..."
+    sudo systemctl daemon-reload
+    sudo systemctl start containerd
+    # 等待containerd启动
+    `+ContainerdReady().Shell()+` || true
+    # 再次检查状态
+    containerd_status=$(sudo systemctl is-active containerd 2>/dev/null || echo "inactive")
+    echo "启动后containerd服务状态: $containerd_status"
+fi
+
+# 3. 检查containerd socket是否存在
+echo "3. 检查containerd socket是否存在..."
+cri_socket="/run/containerd/containerd.sock"
+if [ ! -S "$cri_socket" ]; then
+    echo "4. containerd socket不存在，尝试手动启动containerd..."
+    # 停止可能存在的containerd进程
+    sudo pkill -f containerd || true
+    sleep 2
+    # 清理旧的socket和状态文件
+    sudo rm -rf /run/containerd /var/run/containerd
+    sudo mkdir -p /var/run/containerd
+    # 手动启动containerd
+    containerd --version
+    containerd &
+    wait_for 30 "containerd socket创建" sudo test -S "$cri_socket" || true
+    # 再次检查socket
+    if [ -S "$cri_socket" ]; then
+        echo "5. 手动启动成功，containerd socket已创建"
+    else
+        echo "6. 手动启动失败，containerd socket仍不存在"
+        echo "=== 显示containerd日志 ==="
+        sudo journalctl -u containerd --no-pager -n 50
+        echo "=== 尝试使用systemd状态检查 ==="
+        sudo systemctl status containerd --no-pager
+        echo "✗ 无法启动containerd，kubeadm init将失败"
+        exit 1
+    fi
+else
+    echo "4. containerd socket已存在"
+fi
+
+# 5. 测试containerd连接
+echo "5. 测试containerd连接..."
+if command -v ctr &> /dev/null; then
+    ctr_version=$(ctr version 2>&1 || echo "连接失败")
+    echo "containerd版本信息: $ctr_version"
+fi
+
+# 6. 最终确认containerd状态
+echo "6. 最终确认containerd状态..."
+final_status=$(sudo systemctl is-active containerd 2>/dev/null || echo "inactive")
+final_socket=$(if [ -S "$cri_socket" ]; then echo "存在"; else echo "不存在"; fi)
+echo "最终containerd服务状态: $final_status"
+echo "最终containerd socket状态: $final_socket"
+
+# 验证防火墙和swap状态
+					echo "=== 验证防火墙和swap状态 ==="
+					
+					# 检查firewalld状态
+					if command -v firewall-cmd &> /dev/null; then
+					    firewall_status=$(sudo systemctl is-active firewalld 2>/dev/null || echo "inactive")
+					    echo "当前firewalld状态: $firewall_status"
+					    if [ "$firewall_status" = "active" ]; then
+					        echo "警告: firewalld仍在运行，正在尝试停止并禁用..."
+					        sudo systemctl stop firewalld || true
+					        sudo systemctl disable firewalld || true
+					        firewall_status=$(sudo systemctl is-active firewalld 2>/dev/null || echo "inactive")
+					        echo "停止后firewalld状态: $firewall_status"
+					    fi
+					fi
+					
+					# 检查swap状态
+					swap_status=$(sudo swapon --show | wc -l)
+					echo "当前swap使用情况: $swap_status 个设备"
+					if [ $swap_status -gt 0 ] && [ "%t" != "true" ]; then
+					    echo "警告: swap仍在使用，正在尝试禁用..."
+					    sudo swapoff -a
+					    swap_status=$(sudo swapon --show | wc -l)
+					    echo "禁用后swap使用情况: $swap_status 个设备"
+					fi
+					
+					# 检查/proc/sys/net/ipv4/ip_forward状态
+					ip_forward_status=$(cat /proc/sys/net/ipv4/ip_forward)
+					echo "当前IP转发状态: $ip_forward_status"
+					if [ "$ip_forward_status" != "1" ]; then
+					    echo "警告: IP转发未启用，正在尝试启用..."
+					    sudo sysctl -w net.ipv4.ip_forward=1
+					    ip_forward_status=$(cat /proc/sys/net/ipv4/ip_forward)
+					    echo "启用后IP转发状态: $ip_forward_status"
+					fi
+					
+					%s
+
+					# 初始化Master节点，使用阿里云镜像源
+					echo "=== 执行kubeadm init ==="
+					echo "kubeadm配置文件内容:"
+					sudo cat %s
+					sudo kubeadm init --config %s --upload-certs
+
+# 检查kubeadm init是否成功
+					if [ $? -eq 0 ]; then
+					    echo "=== kubeadm init 成功 ==="
+					    
+					    # 立即生成join命令并输出，供Worker节点使用
+					    echo "=== 生成Join命令 ==="
+					    sudo kubeadm token create --print-join-command
+					    
+					    # 配置kubectl
+					echo "=== 配置kubectl ==="
+					mkdir -p $HOME/.kube
+					    
+					    # 检查admin.conf是否存在
+					    if [ -f /etc/kubernetes/admin.conf ]; then
+					        echo "✓ 找到admin.conf文件，正在配置kubectl..."
+					        sudo cp -i /etc/kubernetes/admin.conf $HOME/.kube/config
+					        sudo chown $(id -u):$(id -g) $HOME/.kube/config
+					        echo "✓ kubectl配置成功"
+					    else
+					        echo "✗ 未找到admin.conf文件，可能初始化过程中出现问题"
+					    fi
+					    
+					    # 安装CNI网络插件（使用Flannel）
+					    if [ -f $HOME/.kube/config ]; then
+					        echo "=== 安装Flannel网络插件 ==="
+					        # 增加重试机制，确保Flannel安装成功
+					        for i in {1..3}; do
+					            echo "尝试安装Flannel ($i/3)..."
+					            if kubectl apply -f https://github.com/flannel-io/flannel/releases/latest/download/kube-flannel.yml; then
+					                echo "✓ Flannel网络插件安装成功"
+					                # 等待Flannel部署完成
+					                echo "等待Flannel部署完成..."
+					                `+PodsReady("kubectl", "kube-flannel").Shell()+` || true
+					                # 检查Flannel pods状态
+					                kubectl get pods -n kube-flannel
+					                break
+					            else
+					                echo "✗ Flannel安装失败，正在重试..."
+					                sleep 5
+					            fi
+					        done
+					        
+					        # 验证CNI配置是否生成
+					        echo "=== 验证CNI配置 ==="
+					        if [ -d /etc/cni/net.d ]; then
+					            echo "CNI配置目录存在"
+					            ls -la /etc/cni/net.d/
+					            if ls /etc/cni/net.d/*.conf 1> /dev/null 2>&1; then
+					                echo "✓ CNI配置文件已生成"
+					            else
+					                echo "✗ CNI配置文件未生成，尝试手动创建Flannel配置"
+					                # 手动创建Flannel CNI配置
+					                sudo mkdir -p /etc/cni/net.d
+					                sudo bash -c 'cat <<EOF > /etc/cni/net.d/10-flannel.conf
+{
+  "name": "cbr0",
+  "type": "flannel",
+  "delegate": {
+    "isDefaultGateway": true
+  }
+}
+EOF'
+					                echo "✓ 手动创建Flannel CNI配置成功"
+					                ls -la /etc/cni/net.d/
+					            fi
+					        else
+					            echo "✗ CNI配置目录不存在，创建目录并手动配置"
+					            sudo mkdir -p /etc/cni/net.d
+					            sudo bash -c 'cat <<EOF > /etc/cni/net.d/10-flannel.conf
+{
+  "name": "cbr0",
+  "type": "flannel",
+  "delegate": {
+    "isDefaultGateway": true
+  }
+}
+EOF'
+					            echo "✓ 手动创建Flannel CNI配置成功"
+					        fi
+					        
+					        # 重启containerd和kubelet服务，确保CNI插件生效
+					        echo "=== 重启containerd和kubelet服务，确保CNI插件生效 ==="
+					        sudo systemctl restart containerd
+					        sudo systemctl restart kubelet
+					        echo "✓ 服务重启完成"
+					        
+					        # 等待服务恢复后再次检查Flannel pods和节点状态
+					        echo "=== 再次检查Flannel pods状态 ==="
+					        `+ContainerdReady().Shell()+` || true
+					        `+PodsReady("kubectl", "kube-flannel").Shell()+` || true
+					        `+NodesReady("kubectl").Shell()+` || true
+					        kubectl get pods -n kube-flannel
+					        
+					        # 检查节点状态
+					        echo "=== 检查节点状态 ==="
+					        kubectl get nodes
+					    else
+					        echo "✗ 无法安装CNI插件，kubectl配置失败"
+					        # 即使kubectl配置失败，也要尝试创建CNI配置目录
+					        sudo mkdir -p /etc/cni/net.d
+					        echo "✓ 创建CNI配置目录成功"
+					        
+					        # 重启containerd和kubelet服务
+					        echo "=== 重启containerd和kubelet服务 ==="
+					        sudo systemctl restart containerd
+					        sudo systemctl restart kubelet
+					    fi
+					else
+					        echo "✗ kubeadm init 失败"
+					        # 显示更多错误信息
+					        echo "=== 显示kubeadm日志 ==="
+					        sudo journalctl -u kubelet --no-pager -n 50
+					    fi`, swapEnabled, preInitCmd, KubeadmConfigPath, KubeadmConfigPath)
+}
+
+// calicoPrepCmd Worker节点加入集群之前准备Calico初始化依赖的内核模块、sysctl、BPF挂载点和CNI目录
+var calicoPrepCmd = script.WaitForFunc + `# 1. 必须的内核模块 - Calico初始化依赖
+			echo "=== 加载必须的内核模块（Calico初始化依赖） ==="
+		sudo modprobe br_netfilter || echo "br_netfilter模块已加载或加载失败"
+		sudo modprobe overlay || echo "overlay模块已加载或加载失败"
+		
+		# 2. 持久化内核模块配置
+		echo "=== 持久化内核模块配置 ==="
+		sudo cat <<EOF > /etc/modules-load.d/k8s.conf
+		br_netfilter
+	overlay
+		EOF
+		
+		# 3. 必须的 sysctl - Calico初始化依赖，此文件必须写入
+		echo "=== 配置必须的sysctl（Calico初始化依赖） ==="
+		sudo cat <<EOF > /etc/sysctl.d/k8s.conf
+		net.bridge.bridge-nf-call-iptables = 1
+		net.bridge.bridge-nf-call-ip6tables = 1
+		net.ipv4.ip_forward = 1
+		EOF
+		sudo sysctl --system
+		
+		# 4. Rocky 10 必装，否则 calico-node Init 直接失败
+		echo "=== 安装iproute-tc（Calico初始化依赖） ==="
+		if command -v dnf &> /dev/null; then
+		    sudo dnf install -y iproute-tc || true
+		elif command -v yum &> /dev/null; then
+		    sudo yum install -y iproute-tc || true
+		fi
+		
+		# 5. BPF 挂载点（init 容器 mount-bpffs 需要）
+		echo "=== 配置BPF挂载点 ==="
+		sudo mkdir -p /sys/fs/bpf
+		sudo mount bpffs /sys/fs/bpf || true
+		
+		# 6. CNI 目录
+		echo "=== 创建CNI目录 ==="
+		sudo mkdir -p /opt/cni/bin
+		sudo mkdir -p /etc/cni/net.d
+		
+		# 7. 重启关键服务
+		echo "=== 重启关键服务 ==="
+		sudo systemctl restart containerd || true
+		sudo systemctl restart kubelet || true
+		
+		# 8. 等待containerd重启完成，kubelet在join之前没有配置，不等待其启动
+		echo "=== 等待服务重启完成 ==="
+		` + ContainerdReady().Shell() + ` || true`
@@ -0,0 +1,317 @@
+package kubeadm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s-installer/node"
+	"k8s-installer/script"
+	"k8s-installer/ssh"
+)
+
+// fakeScripts 按步骤名称返回自定义脚本，未配置的步骤使用默认模板
+type fakeScripts map[string]string
+
+func (f fakeScripts) ResolveScript(step, distro, family string) (script.Resolved, bool) {
+	if content, ok := f[step]; ok {
+		return script.Resolved{Name: step + "_custom", Content: content}, true
+	}
+	return script.ResolveDefault(step, family)
+}
+
+// healthyCRIOutput 只安装了ctr时containerd正常运行的CRIHealthCmd输出
+const healthyCRIOutput = `=== SOCKET
+yes
+=== CTR_VERSION
+Client:
+  Version:  v1.7.13
+Server:
+  Version:  v1.7.13
+`
+
+// testInitOutput kubeadm init成功时输出的join命令
+const testInitOutput = `Your Kubernetes control-plane has initialized successfully!
+kubeadm join 10.0.0.10:6443 --token abcdef.0123456789abcdef \
+    --discovery-token-ca-cert-hash sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+`
+
+func testStepParams(t *testing.T) StepParams {
+	t.Helper()
+	repo, err := node.NewKubeRepo("1.30.2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := node.Node{ID: "n1", Name: "node-1", IP: "10.0.0.10", Port: 22, Username: "root", NodeType: node.NodeTypeMaster}
+	return StepParams{
+		Node:         n,
+		MasterNode:   n,
+		KubeVersion:  "1.30.2",
+		Distro:       "ubuntu",
+		Family:       script.FamilyDebian,
+		CgroupDriver: "systemd",
+		KubeRepo:     repo,
+		Options: DeployOptions{
+			// 测试中失败的命令不重试
+			RetryPolicies: map[string]RetryPolicy{
+				StepSystemPreparation:                 {Attempts: 1},
+				StepContainerRuntimeInstallation:      {Attempts: 1},
+				StepKubernetesRepositoryConfiguration: {Attempts: 1},
+				StepKubernetesComponentsInstallation:  {Attempts: 1},
+				StepWorkerJoin:                        {Attempts: 1},
+			},
+		},
+	}
+}
+
+func runPhase(t *testing.T, name string, rec *ssh.Recorder, params StepParams) error {
+	t.Helper()
+	phase := Phase(name)
+	if phase == nil {
+		t.Fatalf("phase %s is not registered", name)
+	}
+	return phase.Run(context.Background(), rec, params)
+}
+
+func countCommands(commands []string, substr string) int {
+	count := 0
+	for _, cmd := range commands {
+		if strings.Contains(cmd, substr) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestNodePhasesOrder(t *testing.T) {
+	var names []string
+	for _, phase := range NodePhases() {
+		names = append(names, phase.Name())
+	}
+	want := []string{
+		StepSystemPreparation,
+		StepIpForwardConfiguration,
+		StepContainerRuntimeInstallation,
+		StepKubernetesRepositoryConfiguration,
+		StepKubernetesComponentsInstallation,
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("NodePhases() = %v, want %v", names, want)
+	}
+	for _, name := range []string{StepMasterInitialization, StepWorkerJoin} {
+		if Phase(name) == nil {
+			t.Errorf("phase %s is not registered", name)
+		}
+	}
+}
+
+func TestRegisterStepRejectsDuplicatePhase(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a duplicate phase did not panic")
+		}
+	}()
+	RegisterStep(phaseStep{name: StepSystemPreparation})
+}
+
+func TestSystemPreparationDisablesSwap(t *testing.T) {
+	rec := ssh.NewRecorder()
+	if err := runPhase(t, StepSystemPreparation, rec, testStepParams(t)); err != nil {
+		t.Fatal(err)
+	}
+	commands := rec.Commands()
+	if len(commands) != 1 {
+		t.Fatalf("ran %d commands, want 1", len(commands))
+	}
+	if !strings.HasPrefix(commands[0], DisableSwapCmd+"\n") {
+		t.Error("default system preparation script does not disable swap first")
+	}
+}
+
+func TestSystemPreparationKeepsSwap(t *testing.T) {
+	params := testStepParams(t)
+	params.Options.Swap.Enabled = true
+	rec := ssh.NewRecorder()
+	if err := runPhase(t, StepSystemPreparation, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rec.Commands()[0], DisableSwapCmd) {
+		t.Error("swap is disabled although swap is enabled for the cluster")
+	}
+}
+
+func TestSystemPreparationToleratesFailure(t *testing.T) {
+	params := testStepParams(t)
+	params.Scripts = fakeScripts{script.StepSystemPrep: "prepare-node ${version}"}
+	rec := ssh.NewRecorder().On("prepare-node", "", errors.New("exit status 1"))
+	if err := runPhase(t, StepSystemPreparation, rec, params); err != nil {
+		t.Fatalf("system preparation failure should only warn, got %v", err)
+	}
+	commands := rec.Commands()
+	if len(commands) != 1 || commands[0] != "prepare-node 1.30.2" {
+		t.Fatalf("commands = %q, want the custom script with the version substituted", commands)
+	}
+}
+
+func TestContainerRuntimeInstallation(t *testing.T) {
+	params := testStepParams(t)
+	params.Scripts = fakeScripts{script.StepContainerdInstall: "install-containerd"}
+	rec := ssh.NewRecorder().On("=== SOCKET", healthyCRIOutput, nil)
+	if err := runPhase(t, StepContainerRuntimeInstallation, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	commands := rec.Commands()
+	if len(commands) != 3 {
+		t.Fatalf("ran %d commands, want install, config and CRI check", len(commands))
+	}
+	if commands[0] != "install-containerd" {
+		t.Errorf("first command = %q, want the custom install script", commands[0])
+	}
+	if commands[2] != CRIHealthCmd {
+		t.Error("container runtime readiness is not checked through the CRI API")
+	}
+}
+
+func TestContainerRuntimeInstallationFailure(t *testing.T) {
+	params := testStepParams(t)
+	params.Scripts = fakeScripts{script.StepContainerdInstall: "install-containerd"}
+	rec := ssh.NewRecorder().On("install-containerd", "", errors.New("exit status 1"))
+	if err := runPhase(t, StepContainerRuntimeInstallation, rec, params); err == nil {
+		t.Fatal("expected the install failure to fail the phase")
+	}
+	if n := len(rec.Commands()); n != 1 {
+		t.Fatalf("ran %d commands after the install failed, want only the install script", n)
+	}
+}
+
+func TestContainerRuntimeInstallationRejectsIncompleteConfig(t *testing.T) {
+	params := testStepParams(t)
+	params.Scripts = fakeScripts{script.StepContainerdConfig: "echo incomplete"}
+	rec := ssh.NewRecorder().On("=== SOCKET", healthyCRIOutput, nil)
+	if err := runPhase(t, StepContainerRuntimeInstallation, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	if countCommands(rec.Commands(), "echo incomplete") != 0 {
+		t.Error("incomplete custom containerd config script was executed")
+	}
+}
+
+func TestKubernetesRepositoryConfigurationExpandsRepo(t *testing.T) {
+	params := testStepParams(t)
+	params.Scripts = fakeScripts{script.StepK8sRepo: "add-repo " + node.KubeRepoDebPlaceholder}
+	rec := ssh.NewRecorder()
+	if err := runPhase(t, StepKubernetesRepositoryConfiguration, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Commands()[0], "add-repo "+params.KubeRepo.DebURL; got != want {
+		t.Fatalf("command = %q, want %q", got, want)
+	}
+}
+
+func TestKubernetesComponentsInstallationRetries(t *testing.T) {
+	params := testStepParams(t)
+	params.Scripts = fakeScripts{script.StepK8sComponents: "install-components ${version}"}
+	params.Options.RetryPolicies[StepKubernetesComponentsInstallation] = RetryPolicy{Attempts: 2}
+	rec := ssh.NewRecorder().On("install-components", "", errors.New("exit status 1"))
+	if err := runPhase(t, StepKubernetesComponentsInstallation, rec, params); err == nil {
+		t.Fatal("expected the components installation to fail")
+	}
+	if n := countCommands(rec.Commands(), "install-components 1.30.2"); n != 2 {
+		t.Fatalf("install script ran %d times, want 2", n)
+	}
+}
+
+func TestMasterInitializationUsesGeneratedConfig(t *testing.T) {
+	params := testStepParams(t)
+	params.Cluster = &ClusterState{}
+	rec := ssh.NewRecorder().On("kubeadm init --config", testInitOutput, nil)
+	if err := runPhase(t, StepMasterInitialization, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	commands := rec.Commands()
+	if countCommands(commands, "sudo install -D -m 600") != 1 || countCommands(commands, KubeadmConfigPath) < 2 {
+		t.Error("kubeadm config was not installed before kubeadm init")
+	}
+	want := "kubeadm join 10.0.0.10:6443 --token abcdef.0123456789abcdef --discovery-token-ca-cert-hash sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	if params.Cluster.JoinCmd != want {
+		t.Fatalf("JoinCmd = %q, want %q", params.Cluster.JoinCmd, want)
+	}
+}
+
+func TestMasterInitializationUsesCustomScript(t *testing.T) {
+	params := testStepParams(t)
+	params.Cluster = &ClusterState{}
+	params.Scripts = fakeScripts{script.StepK8sInit: "custom-init ${version}"}
+	rec := ssh.NewRecorder().On("custom-init", testInitOutput, nil)
+	if err := runPhase(t, StepMasterInitialization, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	commands := rec.Commands()
+	if countCommands(commands, "custom-init 1.30.2") != 1 {
+		t.Fatalf("custom init script was not executed: %q", commands)
+	}
+	if countCommands(commands, "kubeadm init --config") != 0 {
+		t.Error("default init script ran although a custom script is configured")
+	}
+	if params.Cluster.JoinInfo == nil || params.Cluster.JoinInfo.Token != "abcdef.0123456789abcdef" {
+		t.Fatalf("join info was not parsed from the init output: %+v", params.Cluster.JoinInfo)
+	}
+}
+
+func TestMasterInitializationFailure(t *testing.T) {
+	params := testStepParams(t)
+	params.Cluster = &ClusterState{}
+	rec := ssh.NewRecorder().On("kubeadm init --config", "preflight failed", errors.New("exit status 1"))
+	if err := runPhase(t, StepMasterInitialization, rec, params); err == nil {
+		t.Fatal("expected kubeadm init failure to fail the phase")
+	}
+	if params.Cluster.JoinCmd != "" {
+		t.Error("join command set after a failed init")
+	}
+}
+
+func TestWorkerJoin(t *testing.T) {
+	params := testStepParams(t)
+	params.Node = node.Node{ID: "w1", Name: "worker-1", IP: "10.0.0.11", NodeType: node.NodeTypeWorker}
+	params.Cluster = &ClusterState{JoinCmd: "sudo kubeadm join 10.0.0.10:6443 --token t"}
+	rec := ssh.NewRecorder()
+	if err := runPhase(t, StepWorkerJoin, rec, params); err != nil {
+		t.Fatal(err)
+	}
+	commands := rec.Commands()
+	if len(commands) != 2 || commands[0] != calicoPrepCmd || commands[1] != params.Cluster.JoinCmd {
+		t.Fatalf("commands = %q, want calico preparation then join", commands)
+	}
+}
+
+func TestWorkerJoinResetsBeforeRetry(t *testing.T) {
+	params := testStepParams(t)
+	params.Node = node.Node{ID: "w1", Name: "worker-1", IP: "10.0.0.11", NodeType: node.NodeTypeWorker}
+	params.Cluster = &ClusterState{JoinCmd: "sudo kubeadm join 10.0.0.10:6443 --token t"}
+	params.Options.RetryPolicies[StepWorkerJoin] = RetryPolicy{Attempts: 2}
+	rec := ssh.NewRecorder().On("kubeadm join", "", errors.New("exit status 1"))
+	if err := runPhase(t, StepWorkerJoin, rec, params); err == nil {
+		t.Fatal("expected join to fail")
+	}
+	commands := rec.Commands()
+	if n := countCommands(commands, "kubeadm join"); n != 2 {
+		t.Fatalf("join ran %d times, want 2", n)
+	}
+	if n := countCommands(commands, "sudo kubeadm reset -f"); n != 1 {
+		t.Fatalf("kubeadm reset ran %d times before the retry, want 1", n)
+	}
+}
+
+func TestWorkerJoinWithoutJoinCommand(t *testing.T) {
+	params := testStepParams(t)
+	params.Cluster = &ClusterState{}
+	rec := ssh.NewRecorder()
+	if err := runPhase(t, StepWorkerJoin, rec, params); err == nil {
+		t.Fatal("expected an error without a join command")
+	}
+	if len(rec.Commands()) != 0 {
+		t.Error("commands ran without a join command")
+	}
+}
@@ -0,0 +1,221 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+)
+
+// StepParams 流程步骤在节点上的执行参数
+type StepParams struct {
+	Node node.Node
+	// MasterNode 集群的master节点，只部署worker节点时为空
+	MasterNode  node.Node
+	KubeVersion string
+	// Distro 节点的os-release ID，Family 节点的发行版系列
+	Distro string
+	Family string
+	// OS 节点的操作系统信息：发行版、版本、架构和包管理器
+	OS     node.OSInfo
+	Cgroup CgroupInfo
+	// CgroupDriver 节点应使用的cgroup驱动
+	CgroupDriver string
+	Options      DeployOptions
+	// AfterSkipped After指定的内置步骤在该节点上被跳过
+	AfterSkipped bool
+	// Scripts 应用节点组脚本替换后的脚本管理器，为空时使用默认脚本
+	Scripts interface{}
+	// KubeRepo 目标次版本号的Kubernetes软件仓库
+	KubeRepo node.KubeRepo
+	// Cluster Master节点初始化阶段和Worker节点加入阶段之间传递的join参数
+	Cluster *ClusterState
+	// Log 输出一行部署日志
+	Log func(line string)
+}
+
+// Step 部署流程中在节点上执行的步骤。After为空的步骤是内置阶段，按注册顺序组成部署流程；
+// 其他步骤在After指定的内置阶段之后执行，属于该内置阶段的记录和超时范围，步骤名称可以在skipSteps中单独跳过
+type Step interface {
+	// Name 步骤名称，用于skipSteps
+	Name() string
+	// Title 部署日志中显示的步骤标题
+	Title() string
+	// After 步骤在哪个内置步骤之后执行，见PipelineHooks；内置阶段为空
+	After() string
+	// Skip 返回true时在该节点上跳过步骤
+	Skip(params StepParams) bool
+	// Run 在节点上执行步骤
+	Run(ctx context.Context, client ssh.Runner, params StepParams) error
+}
+
+// PipelineHooks 可以在其后执行流程步骤的内置步骤
+var PipelineHooks = []string{StepSystemPreparation, StepContainerRuntimeInstallation, StepKubernetesComponentsInstallation}
+
+var (
+	pipelineMu    sync.RWMutex
+	pipelineSteps []Step
+	// phaseSteps 内置阶段，见Phase和NodePhases
+	phaseSteps []Step
+)
+
+// RegisterStep 注册流程步骤，同一内置步骤之后的流程步骤按注册顺序执行；After为空时注册为内置阶段。
+// 名称重复或After不是PipelineHooks中的步骤时panic
+func RegisterStep(step Step) {
+	pipelineMu.Lock()
+	defer pipelineMu.Unlock()
+
+	for _, s := range append(append([]Step(nil), phaseSteps...), pipelineSteps...) {
+		if s.Name() == step.Name() {
+			panic(fmt.Sprintf("kubeadm: step %s registered twice", step.Name()))
+		}
+	}
+	if step.After() == "" {
+		phaseSteps = append(phaseSteps, step)
+		return
+	}
+	hook := false
+	for _, after := range PipelineHooks {
+		hook = hook || after == step.After()
+	}
+	if !hook {
+		panic(fmt.Sprintf("kubeadm: step %s runs after %q, which is not a pipeline hook", step.Name(), step.After()))
+	}
+	pipelineSteps = append(pipelineSteps, step)
+}
+
+// StepsAfter 返回在内置步骤after之后执行的流程步骤
+func StepsAfter(after string) []Step {
+	pipelineMu.RLock()
+	defer pipelineMu.RUnlock()
+
+	var steps []Step
+	for _, s := range pipelineSteps {
+		if s.After() == after {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// isPipelineStep 是否为注册的流程步骤
+func isPipelineStep(name string) bool {
+	pipelineMu.RLock()
+	defer pipelineMu.RUnlock()
+
+	for _, s := range pipelineSteps {
+		if s.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ScriptStep 执行一段命令的流程步骤，命令失败时步骤失败
+type ScriptStep struct {
+	StepName  string
+	StepTitle string
+	AfterStep string
+	// SkipFunc 为空时内置步骤被跳过则跳过该步骤
+	SkipFunc func(params StepParams) bool
+	Command  func(params StepParams) string
+}
+
+// Name 步骤名称
+func (s ScriptStep) Name() string { return s.StepName }
+
+// Title 步骤标题
+func (s ScriptStep) Title() string { return s.StepTitle }
+
+// After 步骤在哪个内置步骤之后执行
+func (s ScriptStep) After() string { return s.AfterStep }
+
+// Skip 是否跳过步骤
+func (s ScriptStep) Skip(params StepParams) bool {
+	if s.SkipFunc == nil {
+		return params.AfterSkipped
+	}
+	return s.SkipFunc(params)
+}
+
+// Run 执行命令并逐行输出日志，失败时错误包含命令输出
+func (s ScriptStep) Run(ctx context.Context, client ssh.Runner, params StepParams) error {
+	output, err := client.RunCommandWithOutput(s.Command(params), params.Log)
+	if err != nil {
+		return fmt.Errorf("%v\n输出: %s", err, output)
+	}
+	return nil
+}
+
+// 内置流程步骤
+const (
+	StepTimeSync                  = "time_sync"
+	StepSwapCheck                 = "swap_check"
+	StepIPVSConfiguration         = "ipvs_configuration"
+	StepContainerdCgroupAlignment = "containerd_cgroup_alignment"
+	StepPackagePinning            = "package_pinning"
+)
+
+func init() {
+	// 配置时区和NTP服务器，Master作为NTP服务器时Worker节点从Master同步
+	RegisterStep(ScriptStep{
+		StepName:  StepTimeSync,
+		StepTitle: "配置时间同步",
+		AfterStep: StepSystemPreparation,
+		Command: func(params StepParams) string {
+			timeSync := timeSyncFor(params.Options, params.Node.ID)
+			ntpServers := timeSync.NTPServers
+			if timeSync.MasterAsServer && params.Node.NodeType != "master" && params.MasterNode.IP != "" {
+				ntpServers = []string{params.MasterNode.IP}
+			}
+			serveTime := timeSync.MasterAsServer && params.Node.NodeType == "master"
+			return TimeSyncCmd(timeSync.Timezone, ntpServers, serveTime)
+		},
+	})
+	// 启用swap时检查节点使用cgroup v2，不满足时部署失败
+	RegisterStep(ScriptStep{
+		StepName:  StepSwapCheck,
+		StepTitle: "检查swap和cgroup版本",
+		AfterStep: StepSystemPreparation,
+		SkipFunc: func(params StepParams) bool {
+			return params.AfterSkipped || !params.Options.Swap.Enabled
+		},
+		Command: func(StepParams) string { return KeepSwapCmd },
+	})
+	// kube-proxy使用ipvs模式时加载内核模块并安装ipvsadm
+	RegisterStep(ScriptStep{
+		StepName:  StepIPVSConfiguration,
+		StepTitle: "配置IPVS",
+		AfterStep: StepSystemPreparation,
+		SkipFunc: func(params StepParams) bool {
+			opts := params.Options
+			ipvs := opts.KubeProxyMode == KubeProxyModeIPVS || (opts.KubeProxyMode == "" && opts.KubeadmConfig.KubeProxy.Mode == KubeProxyModeIPVS)
+			return params.AfterSkipped || !ipvs
+		},
+		Command: func(StepParams) string { return IPVSPrepCmd },
+	})
+	// containerd的SystemdCgroup与节点的cgroup驱动保持一致，跳过容器运行时安装的节点同样需要对齐
+	RegisterStep(ScriptStep{
+		StepName:  StepContainerdCgroupAlignment,
+		StepTitle: "对齐containerd cgroup驱动",
+		AfterStep: StepContainerRuntimeInstallation,
+		SkipFunc:  func(StepParams) bool { return false },
+		Command: func(params StepParams) string {
+			return AlignContainerdCgroupCmd(params.CgroupDriver)
+		},
+	})
+	// 固定Kubernetes组件版本，自定义安装脚本和默认脚本安装的组件同样固定
+	RegisterStep(ScriptStep{
+		StepName:  StepPackagePinning,
+		StepTitle: "固定Kubernetes组件版本",
+		AfterStep: StepKubernetesComponentsInstallation,
+		SkipFunc: func(params StepParams) bool {
+			return params.AfterSkipped || !params.Options.PackagePinning
+		},
+		Command: func(params StepParams) string {
+			return node.PackagePinCmd(params.KubeVersion)
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package kubeadm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"k8s-installer/ssh"
+	"k8s-installer/validate"
+)
+
+// KubernetesPKIDir kubeadm生成和读取集群证书的目录
+const KubernetesPKIDir = "/etc/kubernetes/pki"
+
+// caStagingDir 上传的CA证书暂存目录。kubeadm init之前会执行kubeadm reset清空证书目录，
+// 因此CA先上传到暂存目录，在init之前再复制到KubernetesPKIDir
+const caStagingDir = "/tmp/k8s-installer-ca"
+
+// ClusterCA 用户提供的集群CA证书和私钥（PEM），kubeadm使用该CA签发apiserver等组件的证书
+type ClusterCA struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+// Empty 是否未提供CA
+func (ca ClusterCA) Empty() bool {
+	return strings.TrimSpace(ca.Cert) == "" && strings.TrimSpace(ca.Key) == ""
+}
+
+// Validate 检查证书是否为CA证书，私钥与证书是否匹配
+func (ca ClusterCA) Validate() error {
+	if ca.Empty() {
+		return nil
+	}
+	v := &validate.Validator{}
+	if !v.Required("cert", ca.Cert) || !v.Required("key", ca.Key) {
+		return v.Err()
+	}
+	block, _ := pem.Decode([]byte(ca.Cert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		v.Add("cert", "must be a PEM encoded certificate")
+		return v.Err()
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		v.Add("cert", "invalid certificate: %v", err)
+		return v.Err()
+	}
+	if !cert.IsCA {
+		v.Add("cert", "certificate is not a CA certificate")
+	}
+	if _, err := tls.X509KeyPair([]byte(ca.Cert), []byte(ca.Key)); err != nil {
+		v.Add("key", "private key does not match the certificate: %v", err)
+	}
+	return v.Err()
+}
+
+// UploadClusterCA 通过SFTP将CA证书和私钥上传到节点的暂存目录
+func UploadClusterCA(client ssh.Runner, ca ClusterCA) error {
+	if output, err := client.RunCommand(fmt.Sprintf("rm -rf %[1]s && mkdir -m 700 %[1]s", caStagingDir)); err != nil {
+		return fmt.Errorf("failed to create CA staging directory: %v, output: %s", err, output)
+	}
+	if err := client.Upload(caStagingDir+"/ca.crt", []byte(strings.TrimSpace(ca.Cert)+"\n")); err != nil {
+		return fmt.Errorf("failed to upload CA certificate: %v", err)
+	}
+	if err := client.Upload(caStagingDir+"/ca.key", []byte(strings.TrimSpace(ca.Key)+"\n")); err != nil {
+		return fmt.Errorf("failed to upload CA key: %v", err)
+	}
+	return nil
+}
+
+// InstallClusterCACmd 将暂存目录中的CA复制到证书目录并删除暂存目录，未上传CA时不做任何操作
+const InstallClusterCACmd = `if [ -f ` + caStagingDir + `/ca.crt ]; then
+    echo "=== 使用自定义集群CA ==="
+    sudo install -D -m 644 ` + caStagingDir + `/ca.crt ` + KubernetesPKIDir + `/ca.crt
+    sudo install -D -m 600 ` + caStagingDir + `/ca.key ` + KubernetesPKIDir + `/ca.key
+    rm -rf ` + caStagingDir + `
+    echo "✓ CA证书已写入 ` + KubernetesPKIDir + `"
+fi`
@@ -0,0 +1,367 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/node"
+	"k8s-installer/ssh"
+	"k8s-installer/validate"
+)
+
+// 镜像预拉取的并发限制
+const (
+	DefaultPrepullConcurrency = 5
+	MaxPrepullConcurrency     = 50
+)
+
+// 镜像预拉取方式
+const (
+	PrepullModePull = "pull" // 每个节点各自从仓库拉取
+	PrepullModeSync = "sync" // 只在一个节点拉取，导出后通过SFTP分发到其他节点导入
+)
+
+// flannelManifestURL 部署时安装的Flannel清单，预拉取时从中解析CNI镜像
+const flannelManifestURL = "https://github.com/flannel-io/flannel/releases/latest/download/kube-flannel.yml"
+
+// PrepullOptions 镜像预拉取选项，启用后在kubeadm init之前并行地在所有节点上拉取控制平面、pause和CNI镜像，
+// 避免网络较慢时init和join因拉取镜像超时而失败
+type PrepullOptions struct {
+	Enabled bool `json:"enabled"`
+	// ExtraImages 额外拉取的镜像，如插件和业务使用的镜像
+	ExtraImages []string `json:"extraImages,omitempty"`
+	// Concurrency 同时拉取镜像的节点数，为0时使用DefaultPrepullConcurrency
+	Concurrency int `json:"concurrency,omitempty"`
+	// Mode 预拉取方式：pull（默认）每个节点各自拉取；sync 只在一个节点拉取，导出后分发到其他节点导入
+	Mode string `json:"mode,omitempty"`
+}
+
+// Validate 检查额外镜像和并发数
+func (o PrepullOptions) Validate() error {
+	v := &validate.Validator{}
+	for _, image := range o.ExtraImages {
+		if err := ValidateImageRef(image); err != nil {
+			v.Add("extraImages", "%q is not a valid image reference", image)
+		}
+	}
+	if o.Mode != "" && o.Mode != PrepullModePull && o.Mode != PrepullModeSync {
+		v.Add("mode", "must be %s or %s", PrepullModePull, PrepullModeSync)
+	}
+	if o.Concurrency < 0 || o.Concurrency > MaxPrepullConcurrency {
+		v.Add("concurrency", "must be between 0 and %d", MaxPrepullConcurrency)
+	}
+	return v.Err()
+}
+
+// PrepullImageListPath 预拉取的镜像列表，镜像同步时按列表导出
+const PrepullImageListPath = "/tmp/k8s-installer-images.list"
+
+// PrepullImagesCmd 生成预拉取镜像的命令：通过kubeadm拉取控制平面和pause镜像，
+// 拉取containerd配置的sandbox镜像、Flannel清单中的CNI镜像和额外镜像，拉取的镜像记录到PrepullImageListPath
+func PrepullImagesCmd(kubeVersion, imageRepository string, extraImages []string) string {
+	if imageRepository == "" {
+		imageRepository = DefaultImageRepository
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`echo "=== 预拉取Kubernetes镜像 ==="
+if ! command -v kubeadm &> /dev/null; then
+    echo "✗ kubeadm未安装"
+    exit 1
+fi
+IMAGE_LIST=%[5]s
+: > "$IMAGE_LIST"
+sudo kubeadm config images pull --kubernetes-version %[1]s --image-repository %[2]s --cri-socket %[3]s || exit 1
+sudo kubeadm config images list --kubernetes-version %[1]s --image-repository %[2]s >> "$IMAGE_LIST" 2>/dev/null
+echo "✓ 控制平面和pause镜像拉取完成"
+
+pull_image() {
+    if command -v crictl &> /dev/null; then
+        sudo crictl --runtime-endpoint %[4]s pull "$1" || return 1
+    else
+        sudo ctr -n k8s.io images pull "$1" || return 1
+    fi
+    echo "$1" >> "$IMAGE_LIST"
+}
+
+# containerd配置的sandbox镜像可能与kubeadm的pause镜像不同
+SANDBOX_IMAGE=$(sudo containerd config dump 2>/dev/null | sed -n -E 's/^\s*sandbox(_image)?\s*=\s*"([^"]+)".*/\2/p' | head -n 1)
+if [ -n "$SANDBOX_IMAGE" ]; then
+    echo "=== 拉取sandbox镜像 $SANDBOX_IMAGE ==="
+    pull_image "$SANDBOX_IMAGE" || exit 1
+fi
+
+echo "=== 拉取CNI镜像 ==="
+CNI_IMAGES=$(curl -fsSL --connect-timeout 10 --max-time 60 %[6]s 2>/dev/null | sed -n -E 's/^\s*image:\s*"?([^" ]+)"?.*/\1/p' | sort -u)
+if [ -z "$CNI_IMAGES" ]; then
+    echo "⚠ 无法获取Flannel清单，跳过CNI镜像预拉取"
+fi
+for image in $CNI_IMAGES; do
+    pull_image "$image" || exit 1
+done
+`, shellQuote(kubeVersion), shellQuote(imageRepository), shellQuote(DefaultCRISocket), DefaultCRISocket, PrepullImageListPath, shellQuote(flannelManifestURL)))
+
+	if len(extraImages) > 0 {
+		b.WriteString("echo \"=== 拉取额外镜像 ===\"\n")
+		for _, image := range extraImages {
+			b.WriteString(fmt.Sprintf("pull_image %s || exit 1\n", shellQuote(normalizeImageRef(image))))
+		}
+	}
+	b.WriteString("echo \"✓ 镜像预拉取完成\"\n")
+	return b.String()
+}
+
+// normalizeImageRef 补全镜像的仓库和标签，如 nginx 补全为 docker.io/library/nginx:latest，
+// 与containerd中记录的镜像名称一致，导出镜像时按名称查找
+func normalizeImageRef(image string) string {
+	slash := strings.Index(image, "/")
+	switch {
+	case slash < 0:
+		image = "docker.io/library/" + image
+	case !strings.ContainsAny(image[:slash], ".:") && image[:slash] != "localhost":
+		image = "docker.io/" + image
+	}
+	if name := image[strings.LastIndex(image, "/")+1:]; !strings.ContainsAny(name, ":@") {
+		image += ":latest"
+	}
+	return image
+}
+
+// ExportImagesCmd 按PrepullImageListPath导出当前节点架构的镜像到archive
+func ExportImagesCmd(archive string) string {
+	return fmt.Sprintf(`echo "=== 导出镜像 ==="
+PLATFORM=linux/$(case "$(uname -m)" in x86_64) echo amd64 ;; aarch64) echo arm64 ;; *) uname -m ;; esac)
+IMAGES=$(sort -u %[2]s)
+if [ -z "$IMAGES" ]; then
+    echo "✗ 没有需要导出的镜像"
+    exit 1
+fi
+sudo ctr -n k8s.io images export --platform "$PLATFORM" %[1]s $IMAGES || exit 1
+sudo chmod 644 %[1]s
+echo "✓ 镜像已导出到 %[1]s ($(du -h %[1]s | cut -f1))"`, shellQuote(archive), PrepullImageListPath)
+}
+
+// ImportImagesCmd 导入镜像归档，节点架构与导出节点不同时以退出码2结束
+func ImportImagesCmd(archive, arch string) string {
+	return fmt.Sprintf(`echo "=== 导入镜像 ==="
+if [ "$(uname -m)" != %[2]s ]; then
+    echo "✗ 节点架构 $(uname -m) 与镜像归档的架构 %[3]s 不同"
+    rm -f %[1]s
+    exit 2
+fi
+PLATFORM=linux/$(case "$(uname -m)" in x86_64) echo amd64 ;; aarch64) echo arm64 ;; *) uname -m ;; esac)
+sudo ctr -n k8s.io images import --platform "$PLATFORM" %[1]s
+status=$?
+rm -f %[1]s
+[ $status -eq 0 ] || exit 1
+echo "✓ 镜像导入完成"`, shellQuote(archive), shellQuote(arch), arch)
+}
+
+// PrepullResult 单个节点的镜像预拉取结果
+type PrepullResult struct {
+	NodeID   string
+	NodeName string
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// prepullRunner 预拉取和同步镜像共用的节点连接、重试和日志
+type prepullRunner struct {
+	concurrency    int
+	commandTimeout time.Duration
+	policy         RetryPolicy
+	logMutex       sync.Mutex
+	logFn          func(nodeID, nodeName, line string)
+}
+
+func newPrepullRunner(concurrency int, commandTimeout time.Duration, policy RetryPolicy, logFn func(nodeID, nodeName, line string)) *prepullRunner {
+	if concurrency <= 0 {
+		concurrency = DefaultPrepullConcurrency
+	}
+	return &prepullRunner{concurrency: concurrency, commandTimeout: commandTimeout, policy: policy, logFn: logFn}
+}
+
+// log 输出节点日志，多个节点并行时串行化调用
+func (r *prepullRunner) log(n node.Node, line string) {
+	if r.logFn == nil {
+		return
+	}
+	r.logMutex.Lock()
+	defer r.logMutex.Unlock()
+	r.logFn(n.ID, n.Name, line)
+}
+
+// connect 使用节点IP建立SSH连接
+func (r *prepullRunner) connect(ctx context.Context, n node.Node) (*ssh.SSHClient, error) {
+	client, err := ssh.NewSSHClient(ssh.SSHConfig{
+		Host:          n.IP,
+		Port:          n.Port,
+		Username:      n.Username,
+		Password:      n.Password,
+		PrivateKey:    n.PrivateKey,
+		PasswordRef:   n.PasswordRef,
+		PrivateKeyRef: n.PrivateKeyRef,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH客户端失败: %v", err)
+	}
+	client.SetContext(ctx)
+	client.SetCommandTimeout(r.commandTimeout)
+	return client, nil
+}
+
+// run 按重试策略执行命令，输出按行加上prefix写入日志
+func (r *prepullRunner) run(ctx context.Context, client ssh.Runner, n node.Node, cmd, prefix string) (string, error) {
+	var output string
+	err := r.policy.Do(ctx, func(attempt int) error {
+		if attempt > 1 {
+			r.log(n, fmt.Sprintf("%s 第%d次尝试", prefix, attempt))
+		}
+		var runErr error
+		output, runErr = client.RunCommandWithOutput(cmd, func(line string) {
+			r.log(n, prefix+" "+line)
+		})
+		return runErr
+	}, func(attempt int, err error, wait time.Duration) {
+		r.log(n, fmt.Sprintf("%s 第%d次执行失败: %v，%v后重试", prefix, attempt, err, wait))
+	})
+	return output, err
+}
+
+// pull 在节点上执行预拉取命令
+func (r *prepullRunner) pull(ctx context.Context, n node.Node, cmd string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	client, err := r.connect(ctx, n)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	return r.run(ctx, client, n, cmd, "[镜像预拉取]")
+}
+
+// parallel 以限定的并发数在节点上执行fn，结果与nodes顺序一致
+func (r *prepullRunner) parallel(nodes []node.Node, fn func(n node.Node) (string, error)) []PrepullResult {
+	results := make([]PrepullResult, len(nodes))
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n node.Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			output, err := fn(n)
+			results[i] = PrepullResult{NodeID: n.ID, NodeName: n.Name, Output: output, Err: err, Duration: time.Since(start)}
+		}(i, n)
+	}
+	wg.Wait()
+	return results
+}
+
+// PrepullImages 并行地在节点上执行预拉取命令，每个节点按重试策略重试；
+// logFn按行输出拉取日志，调用已串行化
+func PrepullImages(ctx context.Context, nodes []node.Node, cmd string, concurrency int, commandTimeout time.Duration, policy RetryPolicy, logFn func(nodeID, nodeName, line string)) []PrepullResult {
+	r := newPrepullRunner(concurrency, commandTimeout, policy, logFn)
+	return r.parallel(nodes, func(n node.Node) (string, error) {
+		return r.pull(ctx, n, cmd)
+	})
+}
+
+// SyncImages 只在source节点上拉取镜像，导出为归档后经由后端通过SFTP分发到targets节点导入，
+// 节省慢速外网链路的带宽。source节点拉取或导出失败时所有节点回退为各自拉取，
+// 单个节点传输、导入失败或架构不同时该节点回退为自行拉取。返回结果中第一个为source节点
+func SyncImages(ctx context.Context, source node.Node, targets []node.Node, cmd string, concurrency int, commandTimeout time.Duration, policy RetryPolicy, logFn func(nodeID, nodeName, line string)) []PrepullResult {
+	r := newPrepullRunner(concurrency, commandTimeout, policy, logFn)
+	start := time.Now()
+	sourceResult := PrepullResult{NodeID: source.ID, NodeName: source.Name}
+	archive, arch, err := r.exportFrom(ctx, source, cmd)
+	sourceResult.Err = err
+	sourceResult.Duration = time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			return []PrepullResult{sourceResult}
+		}
+		r.log(source, fmt.Sprintf("镜像导出失败: %v，所有节点改为各自拉取镜像", err))
+		return PrepullImages(ctx, append([]node.Node{source}, targets...), cmd, concurrency, commandTimeout, policy, logFn)
+	}
+	defer os.Remove(archive)
+
+	remoteArchive := "/tmp/" + filepath.Base(archive)
+	results := r.parallel(targets, func(n node.Node) (string, error) {
+		output, err := r.importTo(ctx, n, archive, remoteArchive, arch)
+		if err == nil || ctx.Err() != nil {
+			return output, err
+		}
+		r.log(n, fmt.Sprintf("镜像同步失败: %v，改为在节点上拉取镜像", err))
+		return r.pull(ctx, n, cmd)
+	})
+	return append([]PrepullResult{sourceResult}, results...)
+}
+
+// exportFrom 在source节点上拉取并导出镜像，下载到后端的临时文件，返回本地归档路径和节点架构
+func (r *prepullRunner) exportFrom(ctx context.Context, source node.Node, cmd string) (string, string, error) {
+	client, err := r.connect(ctx, source)
+	if err != nil {
+		return "", "", err
+	}
+	defer client.Close()
+
+	if _, err := r.run(ctx, client, source, cmd, "[镜像预拉取]"); err != nil {
+		return "", "", err
+	}
+	arch, err := client.RunCommand("uname -m")
+	if err != nil {
+		return "", "", fmt.Errorf("获取节点架构失败: %v", err)
+	}
+	arch = strings.TrimSpace(arch)
+
+	localFile, err := os.CreateTemp("", "k8s-installer-images-*.tar")
+	if err != nil {
+		return "", "", fmt.Errorf("创建本地临时文件失败: %v", err)
+	}
+	localFile.Close()
+	archive := localFile.Name()
+	remoteArchive := "/tmp/" + filepath.Base(archive)
+
+	if _, err := r.run(ctx, client, source, ExportImagesCmd(remoteArchive), "[镜像导出]"); err != nil {
+		os.Remove(archive)
+		return "", "", err
+	}
+	defer client.RunCommand("sudo rm -f " + shellQuote(remoteArchive))
+
+	r.log(source, "下载镜像归档到后端")
+	if err := client.DownloadFile(remoteArchive, archive); err != nil {
+		os.Remove(archive)
+		return "", "", fmt.Errorf("下载镜像归档失败: %v", err)
+	}
+	return archive, arch, nil
+}
+
+// importTo 通过SFTP上传镜像归档到节点并导入
+func (r *prepullRunner) importTo(ctx context.Context, n node.Node, archive, remoteArchive, arch string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	client, err := r.connect(ctx, n)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	r.log(n, "上传镜像归档")
+	if err := client.UploadFile(archive, remoteArchive); err != nil {
+		return "", fmt.Errorf("上传镜像归档失败: %v", err)
+	}
+	return client.RunCommandWithOutput(ImportImagesCmd(remoteArchive, arch), func(line string) {
+		r.log(n, "[镜像导入] "+line)
+	})
+}
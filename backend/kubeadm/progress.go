@@ -0,0 +1,270 @@
+package kubeadm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s-installer/node"
+)
+
+// defaultStepDurations 没有历史记录时步骤的预估耗时
+var defaultStepDurations = map[string]time.Duration{
+	StepSystemPreparation:                 time.Minute,
+	StepIpForwardConfiguration:            10 * time.Second,
+	StepContainerRuntimeInstallation:      3 * time.Minute,
+	StepKubernetesRepositoryConfiguration: 30 * time.Second,
+	StepKubernetesComponentsInstallation:  2 * time.Minute,
+	StepImagePrepull:                      3 * time.Minute,
+	StepMasterInitialization:              4 * time.Minute,
+	StepWorkerJoin:                        2 * time.Minute,
+}
+
+// parallelSteps 在所有节点上并行执行的步骤，预估剩余时间时取节点中的最大值
+var parallelSteps = map[string]bool{StepImagePrepull: true, StepWorkerJoin: true}
+
+// PlannedStep 部署中将在节点上执行的步骤
+type PlannedStep struct {
+	NodeID string `json:"nodeId"`
+	Step   string `json:"step"`
+}
+
+// PlanSteps 根据节点角色、安装方式和跳过的步骤列出部署中每个节点将执行的步骤
+func PlanSteps(nodes []node.Node, installerType string, skipSteps []string, opts DeployOptions) []PlannedStep {
+	var plan []PlannedStep
+	add := func(nodeID, step string) {
+		if !containsStep(skipSteps, step) && !nodeSkipsStep(opts, nodeID, step) {
+			plan = append(plan, PlannedStep{NodeID: nodeID, Step: step})
+		}
+	}
+
+	for _, n := range nodes {
+		add(n.ID, StepSystemPreparation)
+		if installerType == InstallerTypeK3s {
+			continue
+		}
+		add(n.ID, StepIpForwardConfiguration)
+		add(n.ID, StepContainerRuntimeInstallation)
+		add(n.ID, StepKubernetesRepositoryConfiguration)
+		add(n.ID, StepKubernetesComponentsInstallation)
+		if opts.Prepull.Enabled {
+			add(n.ID, StepImagePrepull)
+		}
+	}
+	for _, n := range nodes {
+		if n.NodeType == node.NodeTypeMaster {
+			add(n.ID, StepMasterInitialization)
+		}
+	}
+	for _, n := range nodes {
+		if n.NodeType != node.NodeTypeMaster {
+			add(n.ID, StepWorkerJoin)
+		}
+	}
+	return plan
+}
+
+// Progress 部署进度，根据历史部署中每个节点每个步骤的平均耗时估算
+type Progress struct {
+	Percent        int    `json:"percent"`
+	ETASeconds     int    `json:"etaSeconds"`
+	CompletedSteps int    `json:"completedSteps"`
+	TotalSteps     int    `json:"totalSteps"`
+	CurrentStep    string `json:"currentStep,omitempty"`
+	CurrentNodeID  string `json:"currentNodeId,omitempty"`
+}
+
+// progressState 运行中部署的步骤计划和执行情况
+type progressState struct {
+	plan      []PlannedStep
+	estimates map[PlannedStep]time.Duration
+	done      map[PlannedStep]bool
+	// started 步骤开始时间，键为"节点ID/步骤"，集群级开始的步骤节点ID为空
+	started map[string]time.Time
+}
+
+// startedAt 步骤在节点上的开始时间，节点没有单独的开始时间时使用集群级步骤的开始时间
+func (p *progressState) startedAt(s PlannedStep) (time.Time, bool) {
+	if t, ok := p.started[s.NodeID+"/"+s.Step]; ok {
+		return t, true
+	}
+	t, ok := p.started["/"+s.Step]
+	return t, ok
+}
+
+// snapshot 计算当前进度：已完成步骤计入全部预估耗时，运行中的步骤最多计入预估耗时的90%
+func (p *progressState) snapshot(now time.Time) Progress {
+	progress := Progress{TotalSteps: len(p.plan)}
+	var total, elapsed, sequential time.Duration
+	parallel := make(map[string]time.Duration)
+	var latest time.Time
+	for _, s := range p.plan {
+		estimate := p.estimates[s]
+		total += estimate
+		if p.done[s] {
+			progress.CompletedSteps++
+			elapsed += estimate
+			continue
+		}
+
+		remaining := estimate
+		if start, ok := p.startedAt(s); ok {
+			running := now.Sub(start)
+			if limit := estimate * 9 / 10; running > limit {
+				running = limit
+			}
+			elapsed += running
+			remaining -= running
+			if !start.Before(latest) {
+				latest = start
+				progress.CurrentStep, progress.CurrentNodeID = s.Step, s.NodeID
+			}
+		}
+		if parallelSteps[s.Step] {
+			if remaining > parallel[s.Step] {
+				parallel[s.Step] = remaining
+			}
+		} else {
+			sequential += remaining
+		}
+	}
+
+	remaining := sequential
+	for _, d := range parallel {
+		remaining += d
+	}
+	progress.ETASeconds = int(remaining.Seconds())
+	if total > 0 {
+		progress.Percent = int(elapsed * 100 / total)
+	}
+	if progress.CompletedSteps < progress.TotalSteps && progress.Percent > 99 {
+		progress.Percent = 99
+	}
+	if progress.TotalSteps == 0 || progress.CompletedSteps == progress.TotalSteps {
+		progress.Percent = 100
+	}
+	return progress
+}
+
+// progressTracker 运行中部署的进度
+type progressTracker struct {
+	mutex  sync.Mutex
+	states map[string]*progressState
+}
+
+// StartProgress 开始统计部署进度，按历史记录预估每个步骤的耗时，继续部署时已成功的步骤计为完成
+func (s *DeploymentStore) StartProgress(deploymentID string, plan []PlannedStep) error {
+	byNode, byStep, err := s.stepDurationHistory()
+	if err != nil {
+		return err
+	}
+	steps, err := s.GetSteps(deploymentID)
+	if err != nil {
+		return err
+	}
+
+	state := &progressState{
+		plan:      plan,
+		estimates: make(map[PlannedStep]time.Duration, len(plan)),
+		done:      make(map[PlannedStep]bool),
+		started:   make(map[string]time.Time),
+	}
+	for _, step := range plan {
+		estimate, ok := byNode[step]
+		if !ok {
+			estimate, ok = byStep[step.Step]
+		}
+		if !ok {
+			estimate = defaultStepDurations[step.Step]
+		}
+		state.estimates[step] = estimate
+	}
+	for _, record := range steps {
+		if record.Status == DeploymentStatusSuccess {
+			state.done[PlannedStep{NodeID: record.NodeID, Step: record.Step}] = true
+		}
+	}
+
+	s.progress.mutex.Lock()
+	defer s.progress.mutex.Unlock()
+	s.progress.states[deploymentID] = state
+	return nil
+}
+
+// Progress 返回运行中部署的进度，部署未在运行时返回false
+func (s *DeploymentStore) Progress(deploymentID string) (Progress, bool) {
+	s.progress.mutex.Lock()
+	defer s.progress.mutex.Unlock()
+
+	state, ok := s.progress.states[deploymentID]
+	if !ok {
+		return Progress{}, false
+	}
+	return state.snapshot(time.Now()), true
+}
+
+// FinishProgress 部署结束，停止统计进度
+func (s *DeploymentStore) FinishProgress(deploymentID string) {
+	s.progress.mutex.Lock()
+	defer s.progress.mutex.Unlock()
+	delete(s.progress.states, deploymentID)
+}
+
+// stepStarted 记录步骤开始时间
+func (s *DeploymentStore) stepStarted(deploymentID, nodeID, step string, at time.Time) {
+	s.progress.mutex.Lock()
+	defer s.progress.mutex.Unlock()
+	if state, ok := s.progress.states[deploymentID]; ok {
+		state.started[nodeID+"/"+step] = at
+	}
+}
+
+// stepFinished 步骤结束，成功时计为完成，返回步骤的耗时，没有开始时间时返回0
+func (s *DeploymentStore) stepFinished(deploymentID, nodeID, step string, err error, at time.Time) time.Duration {
+	s.progress.mutex.Lock()
+	defer s.progress.mutex.Unlock()
+	state, ok := s.progress.states[deploymentID]
+	if !ok {
+		return 0
+	}
+	planned := PlannedStep{NodeID: nodeID, Step: step}
+	if err == nil {
+		state.done[planned] = true
+	}
+	if start, ok := state.startedAt(planned); ok {
+		return at.Sub(start)
+	}
+	return 0
+}
+
+// stepDurationHistory 历史部署中成功步骤的平均耗时，分别按节点和步骤、按步骤统计
+func (s *DeploymentStore) stepDurationHistory() (map[PlannedStep]time.Duration, map[string]time.Duration, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rows, err := s.db.Query("SELECT node_id, step, AVG(duration_ms), COUNT(*) FROM deployment_steps WHERE status = ? AND duration_ms > 0 GROUP BY node_id, step", DeploymentStatusSuccess)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query step durations: %v", err)
+	}
+	defer rows.Close()
+
+	byNode := make(map[PlannedStep]time.Duration)
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for rows.Next() {
+		var nodeID, step string
+		var avg float64
+		var count int
+		if err := rows.Scan(&nodeID, &step, &avg, &count); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan step duration: %v", err)
+		}
+		byNode[PlannedStep{NodeID: nodeID, Step: step}] = time.Duration(avg) * time.Millisecond
+		sums[step] += avg * float64(count)
+		counts[step] += count
+	}
+	byStep := make(map[string]time.Duration, len(sums))
+	for step, sum := range sums {
+		byStep[step] = time.Duration(sum/float64(counts[step])) * time.Millisecond
+	}
+	return byNode, byStep, rows.Err()
+}
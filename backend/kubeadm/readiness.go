@@ -0,0 +1,105 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/errcode"
+	"k8s-installer/ssh"
+)
+
+// DefaultReadinessTimeout 就绪门控默认等待时间
+const DefaultReadinessTimeout = 10 * time.Minute
+
+// readinessEventLines 就绪门控失败时诊断信息中保留的Warning事件行数
+const readinessEventLines = 30
+
+// readinessChecks 就绪门控要求通过的检查项
+var readinessChecks = []string{CheckNodesReady, CheckCorePodsReady}
+
+// ReadinessGateOptions 部署就绪门控：集群验证时等待所有节点Ready和kube-system下的Pod就绪，
+// 超时后部署标记为失败。默认启用，Disabled为true时未就绪只输出警告
+type ReadinessGateOptions struct {
+	Disabled bool `json:"disabled"`
+	// TimeoutMinutes 等待节点和核心Pod就绪的时间，为0时使用DefaultReadinessTimeout
+	TimeoutMinutes int `json:"timeoutMinutes"`
+}
+
+// Validate 检查等待时间
+func (o ReadinessGateOptions) Validate() error {
+	if o.TimeoutMinutes < 0 {
+		return fmt.Errorf("readinessGate.timeoutMinutes must not be negative")
+	}
+	return nil
+}
+
+// timeout 返回等待时间
+func (o ReadinessGateOptions) timeout() time.Duration {
+	if o.TimeoutMinutes <= 0 {
+		return DefaultReadinessTimeout
+	}
+	return time.Duration(o.TimeoutMinutes) * time.Minute
+}
+
+// verifyDeployment 部署完成后执行集群验证。启用就绪门控时节点和核心Pod最多等待门控的时间，
+// 未就绪时收集诊断信息并返回ClusterNotReady错误，其他检查项失败不影响部署结果
+func verifyDeployment(ctx context.Context, client ssh.Runner, opts DeployOptions, logf func(msg string)) (VerificationReport, error) {
+	verify := opts.Verify
+	if !opts.ReadinessGate.Disabled {
+		verify.readyTimeout = opts.ReadinessGate.timeout()
+	}
+	report := VerifyCluster(ctx, client, verify, logf)
+	if opts.OnVerified != nil {
+		opts.OnVerified(report)
+	}
+	if opts.ReadinessGate.Disabled {
+		return report, nil
+	}
+
+	var failed []string
+	for _, check := range report.Checks {
+		if !check.Passed && !check.Skipped && containsStep(readinessChecks, check.Name) {
+			failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+	if len(failed) == 0 {
+		return report, nil
+	}
+
+	logf("=== 集群未就绪，收集诊断信息 ===")
+	logf(readinessDiagnostics(client))
+	return report, errcode.New(errcode.ClusterNotReady, fmt.Errorf("cluster not ready after %v: %s", opts.ReadinessGate.timeout(), strings.Join(failed, "; ")))
+}
+
+// readinessDiagnostics 收集节点状态、未运行的Pod、最近的Warning事件和kubelet日志，用于定位未就绪的原因
+func readinessDiagnostics(client ssh.Runner) string {
+	sections := []struct {
+		title   string
+		command string
+		tail    int
+	}{
+		{"节点状态", kubectlCmd + " get nodes -o wide", 0},
+		{"未运行的Pod", kubectlCmd + " get pods -A -o wide --field-selector=status.phase!=Running,status.phase!=Succeeded", 0},
+		{"最近的Warning事件", kubectlCmd + " get events -A --field-selector=type=Warning --sort-by=.lastTimestamp", readinessEventLines},
+		{"kubelet日志", "sudo journalctl -u kubelet --no-pager -n 30", 0},
+	}
+
+	var b strings.Builder
+	for _, section := range sections {
+		output, err := client.RunCommandSilent(section.command + " 2>&1")
+		output = strings.TrimSpace(output)
+		if section.tail > 0 {
+			// 保留表头和最后的若干行
+			if lines := strings.Split(output, "\n"); len(lines) > section.tail+1 {
+				output = strings.Join(append(lines[:1], lines[len(lines)-section.tail:]...), "\n")
+			}
+		}
+		if err != nil && output == "" {
+			output = err.Error()
+		}
+		b.WriteString(fmt.Sprintf("--- %s ---\n%s\n", section.title, output))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
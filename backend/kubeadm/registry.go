@@ -0,0 +1,182 @@
+package kubeadm
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"k8s-installer/validate"
+)
+
+// ContainerdCertsDir containerd读取镜像仓库hosts.toml配置的目录
+const ContainerdCertsDir = "/etc/containerd/certs.d"
+
+// imageRefPattern 镜像引用，如 nginx:1.27、registry.local:5000/library/busybox@sha256:...
+var imageRefPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._\-/:@]*$`)
+
+// RegistryConfig 单个镜像仓库的配置，渲染为containerd的hosts.toml
+type RegistryConfig struct {
+	// Host 仓库地址，如 docker.io、registry.local:5000
+	Host string `json:"host" binding:"required"`
+	// Mirrors 镜像加速地址，按顺序尝试，都失败时回退到仓库本身
+	Mirrors []string `json:"mirrors,omitempty"`
+	// PlainHTTP 仓库和未指定协议的加速地址使用HTTP访问
+	PlainHTTP bool `json:"plainHttp,omitempty"`
+	// Insecure 跳过TLS证书校验，用于自签名证书的私有仓库
+	Insecure bool `json:"insecure,omitempty"`
+	// CA 仓库的CA证书（PEM），写入仓库配置目录的ca.crt
+	CA string `json:"ca,omitempty"`
+}
+
+// Validate 检查仓库地址、加速地址和CA证书
+func (r RegistryConfig) Validate() error {
+	v := &validate.Validator{}
+	if v.Required("host", r.Host) {
+		v.HostPort("host", r.Host)
+	}
+	for _, mirror := range r.Mirrors {
+		if _, err := r.endpoint(mirror); err != nil {
+			v.Add("mirrors", "%q is not a valid mirror URL", mirror)
+		}
+	}
+	if r.CA != "" && !strings.HasPrefix(strings.TrimSpace(r.CA), "-----BEGIN CERTIFICATE-----") {
+		v.Add("ca", "must be a PEM encoded certificate")
+	}
+	return v.Err()
+}
+
+// scheme 仓库和未指定协议的加速地址使用的协议
+func (r RegistryConfig) scheme() string {
+	if r.PlainHTTP {
+		return "http"
+	}
+	return "https"
+}
+
+// server 仓库的上游地址，docker.io的实际地址为registry-1.docker.io
+func (r RegistryConfig) server() string {
+	if r.Host == "docker.io" {
+		return "https://registry-1.docker.io"
+	}
+	return r.scheme() + "://" + r.Host
+}
+
+// endpoint 规范化加速地址，未指定协议时使用仓库的协议
+func (r RegistryConfig) endpoint(mirror string) (string, error) {
+	if !strings.Contains(mirror, "://") {
+		mirror = r.scheme() + "://" + mirror
+	}
+	u, err := url.Parse(mirror)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || strings.ContainsAny(mirror, " \t\n'\"`$\\") {
+		return "", fmt.Errorf("invalid mirror: %s", mirror)
+	}
+	return strings.TrimSuffix(mirror, "/"), nil
+}
+
+// HostsTOML 渲染containerd的hosts.toml
+func (r RegistryConfig) HostsTOML() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("server = %q\n", r.server()))
+
+	hostBlock := func(endpoint string, capabilities string) {
+		b.WriteString(fmt.Sprintf("\n[host.%q]\n", endpoint))
+		b.WriteString(fmt.Sprintf("  capabilities = %s\n", capabilities))
+		if r.Insecure {
+			b.WriteString("  skip_verify = true\n")
+		}
+		if r.CA != "" {
+			b.WriteString(fmt.Sprintf("  ca = %q\n", r.caPath()))
+		}
+	}
+	for _, mirror := range r.Mirrors {
+		endpoint, err := r.endpoint(mirror)
+		if err != nil {
+			continue
+		}
+		hostBlock(endpoint, `["pull", "resolve"]`)
+	}
+	// 使用自签名证书或HTTP的仓库本身也需要单独的host配置
+	if r.Insecure || r.PlainHTTP || r.CA != "" {
+		hostBlock(r.server(), `["pull", "resolve", "push"]`)
+	}
+	return b.String()
+}
+
+// dir 仓库配置目录
+func (r RegistryConfig) dir() string {
+	return ContainerdCertsDir + "/" + r.Host
+}
+
+// caPath CA证书路径
+func (r RegistryConfig) caPath() string {
+	return r.dir() + "/ca.crt"
+}
+
+// ValidateImageRef 检查镜像引用，避免注入shell命令
+func ValidateImageRef(image string) error {
+	if !imageRefPattern.MatchString(image) {
+		return fmt.Errorf("invalid image reference: %s", image)
+	}
+	return nil
+}
+
+// RegistriesCmd 生成写入镜像仓库配置的命令：启用containerd的config_path，为每个仓库写入hosts.toml和CA证书，
+// 重启containerd；verifyImage不为空时拉取该镜像验证配置
+func RegistriesCmd(registries []RegistryConfig, verifyImage string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`echo "=== 配置containerd镜像仓库 ==="
+if ! command -v containerd &> /dev/null; then
+    echo "✗ containerd未安装"
+    exit 1
+fi
+sudo mkdir -p /etc/containerd %[1]s
+if [ ! -s /etc/containerd/config.toml ]; then
+    containerd config default | sudo tee /etc/containerd/config.toml > /dev/null
+fi
+# 启用hosts.toml配置目录：containerd 1.x默认config_path为空，2.x默认已包含certs.d
+if grep -Eq "^\s*config_path\s*=\s*[\"']{2}\s*$" /etc/containerd/config.toml; then
+    sudo sed -i -E "s|^(\s*config_path\s*=\s*)[\"']{2}\s*$|\1\"%[1]s\"|" /etc/containerd/config.toml
+    echo "已启用 config_path = %[1]s"
+elif ! grep -q "config_path" /etc/containerd/config.toml; then
+    echo "⚠ config.toml中没有config_path配置，请确认containerd版本支持hosts.toml"
+fi
+`, ContainerdCertsDir))
+
+	for _, registry := range registries {
+		b.WriteString(fmt.Sprintf("sudo mkdir -p %s\n", shellQuote(registry.dir())))
+		if registry.CA != "" {
+			b.WriteString(fmt.Sprintf("printf '%%s\\n' %s | sudo tee %s > /dev/null\n", shellQuote(strings.TrimSpace(registry.CA)), shellQuote(registry.caPath())))
+		}
+		b.WriteString(fmt.Sprintf("printf '%%s' %s | sudo tee %s > /dev/null\n", shellQuote(registry.HostsTOML()), shellQuote(registry.dir()+"/hosts.toml")))
+		b.WriteString(fmt.Sprintf("echo \"✓ 已写入 %s/hosts.toml\"\n", registry.dir()))
+	}
+
+	b.WriteString(`sudo systemctl restart containerd
+for i in $(seq 1 15); do
+    sudo systemctl is-active --quiet containerd && break
+    sleep 1
+done
+if ! sudo systemctl is-active --quiet containerd; then
+    sudo journalctl -u containerd --no-pager -n 30
+    echo "✗ containerd重启失败"
+    exit 1
+fi
+echo "✓ containerd已重启"
+`)
+	if verifyImage != "" {
+		b.WriteString(fmt.Sprintf(`echo "=== 验证拉取镜像 %[3]s ==="
+if command -v crictl &> /dev/null; then
+    sudo crictl --runtime-endpoint unix:///run/containerd/containerd.sock pull %[1]s
+else
+    sudo ctr -n k8s.io images pull --hosts-dir %[2]s %[1]s
+fi
+if [ $? -ne 0 ]; then
+    echo "✗ 镜像拉取失败"
+    exit 1
+fi
+echo "✓ 镜像拉取成功"
+`, shellQuote(verifyImage), ContainerdCertsDir, verifyImage))
+	}
+	return b.String()
+}
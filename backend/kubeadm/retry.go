@@ -0,0 +1,96 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 重试策略限制
+const (
+	MaxRetryAttempts       = 10
+	MaxRetryBackoffSeconds = 600
+)
+
+// RetryPolicy 部署步骤的重试策略
+type RetryPolicy struct {
+	Attempts       int     `json:"attempts"`       // 最大执行次数，包含首次执行，默认1次
+	BackoffSeconds int     `json:"backoffSeconds"` // 首次重试前的等待秒数
+	Multiplier     float64 `json:"multiplier"`     // 每次重试等待时间的倍数，默认1
+}
+
+// DefaultRetryPolicies 未在部署请求中配置时使用的默认重试策略，
+// 替代原先写在脚本中的 for i in {1..3} 重试循环
+var DefaultRetryPolicies = map[string]RetryPolicy{
+	StepSystemPreparation:                 {Attempts: 2, BackoffSeconds: 5},
+	StepContainerRuntimeInstallation:      {Attempts: 2, BackoffSeconds: 5},
+	StepKubernetesRepositoryConfiguration: {Attempts: 3, BackoffSeconds: 5},
+	StepKubernetesComponentsInstallation:  {Attempts: 3, BackoffSeconds: 3, Multiplier: 2},
+	StepImagePrepull:                      {Attempts: 3, BackoffSeconds: 10},
+	StepWorkerJoin:                        {Attempts: 3, BackoffSeconds: 10},
+}
+
+// Validate 校验重试策略
+func (p RetryPolicy) Validate() error {
+	if p.Attempts < 0 || p.Attempts > MaxRetryAttempts {
+		return fmt.Errorf("attempts must be between 0 and %d", MaxRetryAttempts)
+	}
+	if p.BackoffSeconds < 0 || p.BackoffSeconds > MaxRetryBackoffSeconds {
+		return fmt.Errorf("backoffSeconds must be between 0 and %d", MaxRetryBackoffSeconds)
+	}
+	if p.Multiplier < 0 {
+		return fmt.Errorf("multiplier must not be negative")
+	}
+	return nil
+}
+
+// backoff 返回第attempt次执行失败后的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := time.Duration(p.BackoffSeconds) * time.Second
+	if p.Multiplier > 1 {
+		for i := 1; i < attempt; i++ {
+			wait = time.Duration(float64(wait) * p.Multiplier)
+		}
+	}
+	if max := time.Duration(MaxRetryBackoffSeconds) * time.Second; wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// Do 按重试策略执行fn，attempt从1开始；ctx被取消时停止重试
+func (p RetryPolicy) Do(ctx context.Context, fn func(attempt int) error, onRetry func(attempt int, err error, wait time.Duration)) error {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		if attempt == attempts || ctx.Err() != nil {
+			break
+		}
+
+		wait := p.backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+	return err
+}
+
+// retryPolicyFor 获取步骤的重试策略，请求中的配置优先于默认策略
+func retryPolicyFor(policies map[string]RetryPolicy, step string) RetryPolicy {
+	if p, ok := policies[step]; ok {
+		return p
+	}
+	return DefaultRetryPolicies[step]
+}
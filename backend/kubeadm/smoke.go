@@ -0,0 +1,129 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/ssh"
+)
+
+// 冒烟测试默认参数
+const (
+	DefaultSmokeTestImage   = "nginx:1.25-alpine"
+	DefaultSmokeTestTimeout = 5 * time.Minute
+	smokeTestProbeAttempts  = 10
+)
+
+// SmokeTestOptions 部署后冒烟测试选项
+type SmokeTestOptions struct {
+	Enabled        bool   `json:"enabled"`
+	Image          string `json:"image"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+}
+
+// SmokeTestResult 冒烟测试结果
+type SmokeTestResult struct {
+	Passed     bool          `json:"passed"`
+	Message    string        `json:"message"`
+	Steps      []CheckResult `json:"steps"`
+	ProbeNode  string        `json:"probeNode"`
+	DurationMs int64         `json:"durationMs"`
+}
+
+// RunSmokeTest 部署nginx Deployment和Service，等待就绪后从probe节点访问Service，最后清理测试资源
+// probe为发起访问的节点连接，probeName为其名称
+func RunSmokeTest(ctx context.Context, master ssh.Runner, probe ssh.Runner, probeName string, opts SmokeTestOptions, logf func(msg string)) (result SmokeTestResult) {
+	if opts.Image == "" {
+		opts.Image = DefaultSmokeTestImage
+	}
+	timeout := time.Duration(opts.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultSmokeTestTimeout
+	}
+	if logf == nil {
+		logf = func(string) {}
+	}
+
+	start := time.Now()
+	result = SmokeTestResult{ProbeNode: probeName}
+	namespace := fmt.Sprintf("k8s-installer-smoke-%d", start.Unix())
+
+	// step 执行一个测试步骤并记录结果，失败时返回false
+	step := func(name string, fn func() (string, string, error)) bool {
+		stepStart := time.Now()
+		message, details, err := fn()
+		check := CheckResult{Name: name, Passed: err == nil, Message: message, Details: details, DurationMs: time.Since(stepStart).Milliseconds()}
+		if err != nil {
+			check.Message = fmt.Sprintf("%s: %v", message, err)
+			logf(fmt.Sprintf("✗ 冒烟测试 %s: %s", name, check.Message))
+		} else {
+			logf(fmt.Sprintf("✓ 冒烟测试 %s: %s", name, message))
+		}
+		result.Steps = append(result.Steps, check)
+		return err == nil
+	}
+
+	defer func() {
+		// 清理测试资源
+		if out, err := runKubectl(master, fmt.Sprintf("delete namespace %s --wait=false --ignore-not-found", namespace)); err != nil {
+			logf(fmt.Sprintf("清理冒烟测试命名空间失败: %v %s", err, out))
+		}
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	logf(fmt.Sprintf("=== 冒烟测试：在命名空间 %s 中部署 %s ===", namespace, opts.Image))
+	ok := step("create", func() (string, string, error) {
+		out, err := runKubectl(master, fmt.Sprintf("create namespace %s && %s -n %s create deployment nginx --image=%s --replicas=2 && %s -n %s expose deployment nginx --port=80",
+			namespace, kubectlCmd, namespace, opts.Image, kubectlCmd, namespace))
+		return "创建Deployment和Service", out, err
+	}) && step("rollout", func() (string, string, error) {
+		out, err := runKubectl(master, fmt.Sprintf("-n %s rollout status deployment/nginx --timeout=%ds", namespace, int(timeout/time.Second)))
+		if err != nil {
+			pods, _ := runKubectl(master, fmt.Sprintf("-n %s get pods -o wide", namespace))
+			out += "\n" + pods
+		}
+		return "等待Deployment就绪", out, err
+	})
+
+	var clusterIP string
+	ok = ok && step("service", func() (string, string, error) {
+		out, err := runKubectl(master, fmt.Sprintf("-n %s get svc nginx -o jsonpath='{.spec.clusterIP}'", namespace))
+		clusterIP = out
+		if err == nil && clusterIP == "" {
+			err = fmt.Errorf("empty cluster IP")
+		}
+		return fmt.Sprintf("Service ClusterIP: %s", clusterIP), out, err
+	})
+
+	ok = ok && step("probe", func() (string, string, error) {
+		cmd := fmt.Sprintf("curl -s -o /dev/null -w '%%{http_code}' --max-time 5 http://%s/", clusterIP)
+		var out string
+		var err error
+		for attempt := 1; attempt <= smokeTestProbeAttempts; attempt++ {
+			out, err = probe.RunCommandSilent(cmd)
+			out = strings.TrimSpace(out)
+			if err == nil && out == "200" {
+				return fmt.Sprintf("从节点 %s 访问 %s 返回200", probeName, clusterIP), out, nil
+			}
+			select {
+			case <-ctx.Done():
+				return "访问Service", out, ctx.Err()
+			case <-time.After(3 * time.Second):
+			}
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected status code %q", out)
+		}
+		return fmt.Sprintf("从节点 %s 访问 %s", probeName, clusterIP), out, err
+	})
+
+	result.Passed = ok
+	if ok {
+		result.Message = "冒烟测试通过"
+	} else {
+		result.Message = "冒烟测试失败"
+	}
+	return result
+}
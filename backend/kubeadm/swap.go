@@ -0,0 +1,75 @@
+package kubeadm
+
+import (
+	"k8s-installer/script"
+	"k8s-installer/validate"
+)
+
+// kubelet使用swap的方式
+const (
+	// SwapBehaviorLimited Burstable Pod可以按内存请求的比例使用swap
+	SwapBehaviorLimited = "LimitedSwap"
+	// SwapBehaviorNoSwap 节点保留swap，但Pod不使用swap，Kubernetes 1.30起支持
+	SwapBehaviorNoSwap = "NoSwap"
+)
+
+// 使用swap的最低Kubernetes版本：1.28起NodeSwap支持cgroup v2，1.30起NodeSwap默认开启并支持NoSwap
+const (
+	minSwapMinor       = 28
+	swapDefaultOnMinor = 30
+	swapNoSwapMinMinor = 30
+)
+
+// SwapOptions 节点swap配置。默认部署时禁用swap；启用后保留节点的swap，
+// kubelet设置failSwapOn=false和memorySwap.swapBehavior，要求节点使用cgroup v2
+type SwapOptions struct {
+	Enabled bool `json:"enabled"`
+	// SwapBehavior LimitedSwap（默认）或NoSwap
+	SwapBehavior string `json:"swapBehavior,omitempty"`
+}
+
+// Validate 检查Kubernetes版本是否支持NodeSwap以及swapBehavior
+func (o SwapOptions) Validate(kubeVersion string) error {
+	if !o.Enabled {
+		return nil
+	}
+	v := &validate.Validator{}
+	major, minor, ok := parseMajorMinor(kubeVersion)
+	if !ok || major != 1 || minor < minSwapMinor {
+		v.Add("enabled", "swap requires Kubernetes v1.%d or later, got %q", minSwapMinor, kubeVersion)
+	}
+	switch o.SwapBehavior {
+	case "", SwapBehaviorLimited:
+	case SwapBehaviorNoSwap:
+		if ok && minor < swapNoSwapMinMinor {
+			v.Add("swapBehavior", "%s requires Kubernetes v1.%d or later", SwapBehaviorNoSwap, swapNoSwapMinMinor)
+		}
+	default:
+		v.Add("swapBehavior", "must be %s or %s", SwapBehaviorLimited, SwapBehaviorNoSwap)
+	}
+	return v.Err()
+}
+
+// behavior 返回swapBehavior，未设置时为LimitedSwap
+func (o SwapOptions) behavior() string {
+	if o.SwapBehavior == "" {
+		return SwapBehaviorLimited
+	}
+	return o.SwapBehavior
+}
+
+// DisableSwapCmd 禁用swap并在重启后保持禁用，与默认系统准备脚本共用
+const DisableSwapCmd = script.DisableSwapCmd
+
+// KeepSwapCmd 启用swap时检查节点使用cgroup v2，cgroup v1不支持NodeSwap时失败；节点没有swap设备时只给出提示
+const KeepSwapCmd = `# 保留swap，kubelet使用NodeSwap
+echo "=== 检查swap和cgroup版本 ==="
+if [ "$(stat -fc %T /sys/fs/cgroup/ 2>/dev/null)" != "cgroup2fs" ]; then
+    echo "✗ 节点未使用cgroup v2，kubelet只在cgroup v2上支持swap，请启用cgroup v2或在部署参数中关闭swap"
+    exit 1
+fi
+if [ "$(sudo swapon --show --noheadings | wc -l)" -eq 0 ]; then
+    echo "⚠ 节点没有启用swap设备，kubelet将以无swap方式运行"
+else
+    echo "✓ 保留swap: $(sudo swapon --show --noheadings | awk '{print $1, $3}' | tr '\n' ' ')"
+fi`
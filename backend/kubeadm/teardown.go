@@ -0,0 +1,93 @@
+package kubeadm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s-installer/node"
+)
+
+// teardownNodeScript 拆除节点：k3s节点执行官方卸载脚本，kubeadm节点执行完整的重置清理
+const teardownNodeScript = `if [ -x /usr/local/bin/k3s-uninstall.sh ]; then
+    echo "=== 卸载k3s server ==="
+    sudo /usr/local/bin/k3s-uninstall.sh
+elif [ -x /usr/local/bin/k3s-agent-uninstall.sh ]; then
+    echo "=== 卸载k3s agent ==="
+    sudo /usr/local/bin/k3s-agent-uninstall.sh
+fi
+if command -v kubeadm &> /dev/null; then
+    echo "=== 重置kubeadm节点 ==="
+` + resetNodeScript + `
+fi
+echo "✓ 节点拆除完成"`
+
+// TeardownResult 单个节点的拆除结果
+type TeardownResult struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	NodeType string `json:"nodeType"`
+	Success  bool   `json:"success"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// TeardownCluster 拆除集群的所有成员节点：先并发重置Worker节点，再重置控制平面节点，
+// 最后删除各节点/etc/hosts中由安装器管理的解析记录。结果顺序与执行顺序一致
+func TeardownCluster(nodes []node.Node, logf func(msg string)) []TeardownResult {
+	var workers, masters []node.Node
+	for _, n := range nodes {
+		if n.NodeType == node.NodeTypeMaster {
+			masters = append(masters, n)
+		} else {
+			workers = append(workers, n)
+		}
+	}
+
+	results := make([]TeardownResult, 0, len(nodes))
+	for _, group := range [][]node.Node{workers, masters} {
+		results = append(results, teardownNodes(group, logf)...)
+	}
+	return results
+}
+
+// teardownNodes 并发拆除一组节点
+func teardownNodes(nodes []node.Node, logf func(msg string)) []TeardownResult {
+	cmd := teardownNodeScript + "\n" + node.HostsUpdateCmd("")
+
+	results := make([]TeardownResult, len(nodes))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, node.DefaultExecConcurrency)
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n node.Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := TeardownResult{NodeID: n.ID, NodeName: n.Name, NodeType: n.NodeType}
+			logf(fmt.Sprintf("[%s] 开始拆除节点", n.Name))
+			client, err := node.Connect(n)
+			if err != nil {
+				result.Error = fmt.Sprintf("failed to connect node: %v", err)
+				logf(fmt.Sprintf("[%s] ✗ %s", n.Name, result.Error))
+				results[i] = result
+				return
+			}
+			defer client.Close()
+
+			output, err := client.RunCommandSilent(cmd)
+			result.Output = strings.TrimSpace(output)
+			result.Success = err == nil
+			if err != nil {
+				result.Error = err.Error()
+				logf(fmt.Sprintf("[%s] ✗ 拆除失败: %v", n.Name, err))
+			} else {
+				logf(fmt.Sprintf("[%s] ✓ 拆除完成", n.Name))
+			}
+			results[i] = result
+		}(i, n)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,186 @@
+package kubeadm
+
+import (
+	"fmt"
+
+	"k8s-installer/node"
+	"k8s-installer/validate"
+)
+
+// 集群规模模板
+const (
+	TemplateSingleNodeDev = "single-node-dev"
+	TemplateSmall         = "small"
+	TemplateProduction    = "production"
+)
+
+// 模板中节点的角色
+const (
+	TemplateRoleControlPlane = "control-plane"
+	TemplateRoleWorker       = "worker"
+)
+
+// ClusterTemplate 常见集群规模的预定义模板，展开为节点角色分配和推荐的部署参数
+type ClusterTemplate struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// ControlPlanes 控制平面节点数
+	ControlPlanes int `json:"controlPlanes"`
+	// Workers 推荐的worker节点数，MinWorkers和MaxWorkers为允许的范围，MaxWorkers为0时不限制
+	Workers    int `json:"workers"`
+	MinWorkers int `json:"minWorkers"`
+	MaxWorkers int `json:"maxWorkers,omitempty"`
+	// SingleNode 唯一的节点作为Master并移除控制平面污点
+	SingleNode bool                    `json:"singleNode,omitempty"`
+	Settings   ClusterTemplateSettings `json:"settings"`
+}
+
+// ClusterTemplateSettings 模板推荐的部署参数
+type ClusterTemplateSettings struct {
+	PodSubnet     string          `json:"podSubnet"`
+	ServiceSubnet string          `json:"serviceSubnet"`
+	KubeProxyMode string          `json:"kubeProxyMode,omitempty"`
+	Kubelet       KubeletSettings `json:"kubelet"`
+	Addons        []string        `json:"addons,omitempty"`
+	// ReadinessTimeoutMinutes 就绪门控的等待时间
+	ReadinessTimeoutMinutes int `json:"readinessTimeoutMinutes,omitempty"`
+}
+
+// ClusterTemplates 预定义的集群规模模板
+var ClusterTemplates = []ClusterTemplate{
+	{
+		ID:            TemplateSingleNodeDev,
+		Name:          "单节点开发环境",
+		Description:   "一个节点同时作为控制平面和工作节点，适合开发和测试，预留资源较少",
+		ControlPlanes: 1,
+		SingleNode:    true,
+		Settings: ClusterTemplateSettings{
+			PodSubnet:     DefaultPodSubnet,
+			ServiceSubnet: DefaultServiceSubnet,
+			KubeProxyMode: KubeProxyModeIPTables,
+			Kubelet: KubeletSettings{
+				SystemReserved: map[string]string{"cpu": "250m", "memory": "512Mi"},
+				EvictionHard:   map[string]string{"memory.available": "200Mi", "nodefs.available": "10%"},
+			},
+			Addons: []string{AddonMetricsServer, AddonLocalPathStorage},
+		},
+	},
+	{
+		ID:            TemplateSmall,
+		Name:          "小型集群（1+2）",
+		Description:   "一个控制平面节点和两个worker节点，适合测试环境和小规模业务",
+		ControlPlanes: 1,
+		Workers:       2,
+		MinWorkers:    2,
+		MaxWorkers:    2,
+		Settings: ClusterTemplateSettings{
+			PodSubnet:     DefaultPodSubnet,
+			ServiceSubnet: DefaultServiceSubnet,
+			KubeProxyMode: KubeProxyModeIPTables,
+			Kubelet: KubeletSettings{
+				SystemReserved: map[string]string{"cpu": "500m", "memory": "1Gi"},
+				KubeReserved:   map[string]string{"cpu": "250m", "memory": "512Mi"},
+				EvictionHard:   map[string]string{"memory.available": "500Mi", "nodefs.available": "10%"},
+			},
+			Addons: []string{AddonMetricsServer, AddonLocalPathStorage},
+		},
+	},
+	{
+		ID:            TemplateProduction,
+		Name:          "生产集群（1+N）",
+		Description:   "一个控制平面节点，worker节点按业务规模扩展，使用ipvs模式和更多的资源预留。部署只支持单个控制平面节点，该模板不提供控制平面高可用",
+		ControlPlanes: 1,
+		Workers:       3,
+		MinWorkers:    2,
+		Settings: ClusterTemplateSettings{
+			PodSubnet:     DefaultPodSubnet,
+			ServiceSubnet: DefaultServiceSubnet,
+			KubeProxyMode: KubeProxyModeIPVS,
+			Kubelet: KubeletSettings{
+				SystemReserved: map[string]string{"cpu": "1", "memory": "2Gi"},
+				KubeReserved:   map[string]string{"cpu": "500m", "memory": "1Gi"},
+				EvictionHard:   map[string]string{"memory.available": "1Gi", "nodefs.available": "10%", "imagefs.available": "15%"},
+				MaxPods:        110,
+				PodPidsLimit:   4096,
+			},
+			Addons:                  []string{AddonMetricsServer, AddonIngressNginx},
+			ReadinessTimeoutMinutes: 20,
+		},
+	},
+}
+
+// FindClusterTemplate 按ID查找模板
+func FindClusterTemplate(id string) (ClusterTemplate, bool) {
+	for _, t := range ClusterTemplates {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return ClusterTemplate{}, false
+}
+
+// TemplateNodeRole 模板分配给节点的角色
+type TemplateNodeRole struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	Role     string `json:"role"`
+	// NodeType 节点当前的类型，部署按节点类型区分master和worker，与角色不一致时需要先修改节点类型
+	NodeType string `json:"nodeType"`
+}
+
+// AssignRoles 按模板为节点分配角色：已是master类型的节点优先作为控制平面，其余按给定顺序补足，剩下的节点作为worker。
+// 节点数不符合模板时返回字段校验错误
+func (t ClusterTemplate) AssignRoles(nodes []node.Node) ([]TemplateNodeRole, error) {
+	v := &validate.Validator{}
+	workers := len(nodes) - t.ControlPlanes
+	switch {
+	case t.SingleNode && len(nodes) != 1:
+		v.Add("nodeIds", "template %s requires exactly 1 node, got %d", t.ID, len(nodes))
+	case workers < t.MinWorkers:
+		v.Add("nodeIds", "template %s requires at least %d nodes, got %d", t.ID, t.ControlPlanes+t.MinWorkers, len(nodes))
+	case t.MaxWorkers > 0 && workers > t.MaxWorkers:
+		v.Add("nodeIds", "template %s allows at most %d nodes, got %d", t.ID, t.ControlPlanes+t.MaxWorkers, len(nodes))
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	controlPlane := make([]bool, len(nodes))
+	assigned := 0
+	for i, n := range nodes {
+		if assigned < t.ControlPlanes && n.NodeType == node.NodeTypeMaster {
+			controlPlane[i] = true
+			assigned++
+		}
+	}
+	for i := range nodes {
+		if assigned < t.ControlPlanes && !controlPlane[i] {
+			controlPlane[i] = true
+			assigned++
+		}
+	}
+
+	roles := make([]TemplateNodeRole, 0, len(nodes))
+	for i, n := range nodes {
+		role := TemplateRoleWorker
+		if controlPlane[i] {
+			role = TemplateRoleControlPlane
+		}
+		roles = append(roles, TemplateNodeRole{NodeID: n.ID, NodeName: n.Name, Role: role, NodeType: n.NodeType})
+	}
+	return roles, nil
+}
+
+// RoleMismatch 节点类型与分配的角色不一致时返回说明
+func (r TemplateNodeRole) RoleMismatch() string {
+	wantMaster := r.Role == TemplateRoleControlPlane
+	if wantMaster == (r.NodeType == node.NodeTypeMaster) {
+		return ""
+	}
+	want := node.NodeTypeWorker
+	if wantMaster {
+		want = node.NodeTypeMaster
+	}
+	return fmt.Sprintf("node %s is a %s node, change its type to %s before deploying", r.NodeName, r.NodeType, want)
+}
@@ -0,0 +1,112 @@
+package kubeadm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTimezone 未配置时区时使用的默认时区
+const DefaultTimezone = "Asia/Shanghai"
+
+var (
+	timezonePattern  = regexp.MustCompile(`^[A-Za-z0-9_+\-/]+$`)
+	ntpServerPattern = regexp.MustCompile(`^[A-Za-z0-9.\-:]+$`)
+)
+
+// TimeSyncOptions 时间同步配置
+type TimeSyncOptions struct {
+	// Timezone 节点时区，为空时使用DefaultTimezone
+	Timezone string `json:"timezone"`
+	// NTPServers chrony使用的NTP服务器，为空时保留发行版默认的公共时间池；
+	// 离线环境下填写内网NTP服务器地址
+	NTPServers []string `json:"ntpServers"`
+	// MasterAsServer 离线环境下Master节点作为NTP服务器（本地时钟stratum 10），Worker节点从Master同步
+	MasterAsServer bool `json:"masterAsServer"`
+}
+
+// Validate 校验时区和NTP服务器地址，避免注入shell命令
+func (o TimeSyncOptions) Validate() error {
+	if o.Timezone != "" && !timezonePattern.MatchString(o.Timezone) {
+		return fmt.Errorf("invalid timezone: %s", o.Timezone)
+	}
+	for _, server := range o.NTPServers {
+		if !ntpServerPattern.MatchString(server) {
+			return fmt.Errorf("invalid ntp server: %s", server)
+		}
+	}
+	return nil
+}
+
+// timeSyncFor 返回节点使用的时间同步配置，NodeTimeSync中的节点配置优先于集群配置
+func timeSyncFor(opts DeployOptions, nodeID string) TimeSyncOptions {
+	if nodeOpts, ok := opts.NodeTimeSync[nodeID]; ok {
+		return nodeOpts
+	}
+	return opts.TimeSync
+}
+
+// TimeSyncCmd 生成安装chrony、设置时区和NTP服务器的命令。
+// servers为空时保留发行版默认的时间池，serve为true时节点允许其他节点从本机同步时间
+func TimeSyncCmd(timezone string, servers []string, serve bool) string {
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+
+	var b strings.Builder
+	b.WriteString(`echo "=== 安装并配置时间同步 ==="
+if command -v apt-get &> /dev/null; then
+    command -v chronyc &> /dev/null || { sudo apt-get update -y; sudo apt-get install -y chrony; }
+    CHRONY_CONF=/etc/chrony/chrony.conf
+    CHRONY_SERVICE=chrony
+elif command -v zypper &> /dev/null; then
+    command -v chronyc &> /dev/null || sudo zypper --non-interactive install -y chrony
+    CHRONY_CONF=/etc/chrony.conf
+    CHRONY_SERVICE=chronyd
+elif command -v dnf &> /dev/null; then
+    command -v chronyc &> /dev/null || sudo dnf install -y chrony
+    CHRONY_CONF=/etc/chrony.conf
+    CHRONY_SERVICE=chronyd
+else
+    command -v chronyc &> /dev/null || sudo yum install -y chrony
+    CHRONY_CONF=/etc/chrony.conf
+    CHRONY_SERVICE=chronyd
+fi
+if ! command -v chronyc &> /dev/null; then
+    echo "✗ chrony安装失败，请检查软件源或离线安装chrony"
+    exit 1
+fi
+`)
+	b.WriteString(fmt.Sprintf("sudo timedatectl set-timezone %s\n", shellQuote(timezone)))
+	b.WriteString(`echo "时区: $(timedatectl show -p Timezone --value 2>/dev/null || date +%Z)"
+`)
+
+	if len(servers) > 0 || serve {
+		// 删除上次写入的配置块，注释掉发行版默认的时间池后写入新的配置块
+		var block strings.Builder
+		block.WriteString("# BEGIN k8s-installer ntp\n")
+		for _, server := range servers {
+			block.WriteString(fmt.Sprintf("server %s iburst\n", server))
+		}
+		if serve {
+			block.WriteString("allow all\nlocal stratum 10\n")
+		}
+		block.WriteString("# END k8s-installer ntp")
+
+		b.WriteString(`sudo sed -i '/^# BEGIN k8s-installer ntp$/,/^# END k8s-installer ntp$/d' $CHRONY_CONF
+`)
+		if len(servers) > 0 {
+			b.WriteString(`sudo sed -i -E 's/^(pool|server) /# &/' $CHRONY_CONF
+`)
+		}
+		b.WriteString(fmt.Sprintf("printf '%%s\\n' %s | sudo tee -a $CHRONY_CONF > /dev/null\n", shellQuote(block.String())))
+	}
+
+	b.WriteString(`sudo systemctl enable --now $CHRONY_SERVICE
+sudo systemctl restart $CHRONY_SERVICE
+sleep 2
+sudo chronyc -a makestep > /dev/null 2>&1 || true
+chronyc sources || true
+echo "✓ 时间同步配置完成"`)
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package kubeadm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s-installer/node"
+)
+
+// JoinToken kubeadm引导令牌信息
+type JoinToken struct {
+	Token       string     `json:"token"`
+	Description string     `json:"description,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty"`
+	Usages      []string   `json:"usages,omitempty"`
+	Groups      []string   `json:"groups,omitempty"`
+	Expired     bool       `json:"expired"`
+}
+
+// tokenPattern 引导令牌格式，支持完整令牌或仅令牌ID
+var tokenPattern = regexp.MustCompile(`^[a-z0-9]{6}(\.[a-z0-9]{16})?$`)
+
+// ValidateToken 校验令牌格式，防止命令注入
+func ValidateToken(token string) error {
+	if !tokenPattern.MatchString(token) {
+		return fmt.Errorf("invalid token format: %s", token)
+	}
+	return nil
+}
+
+// ParseJoinToken 从join命令中提取令牌
+func ParseJoinToken(joinCmd string) string {
+	fields := strings.Fields(joinCmd)
+	for i, f := range fields {
+		if f == "--token" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+		if strings.HasPrefix(f, "--token=") {
+			return strings.TrimPrefix(f, "--token=")
+		}
+	}
+	return ""
+}
+
+// ListJoinTokens 列出master节点上的所有引导令牌
+func ListJoinTokens(sshConfig SSHConfig) ([]JoinToken, error) {
+	output, err := RunCommandOnRemote(sshConfig, "kubeadm token list -o json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %v", err)
+	}
+
+	// kubeadm对每个令牌输出一个独立的JSON对象
+	var tokens []JoinToken
+	decoder := json.NewDecoder(strings.NewReader(output))
+	now := time.Now()
+	for {
+		var token JoinToken
+		if err := decoder.Decode(&token); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse token list: %v", err)
+		}
+		if token.Expires != nil && token.Expires.Before(now) {
+			token.Expired = true
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// CreateJoinCommand 创建新的引导令牌并返回join命令，ttl为空时使用kubeadm默认的24h
+func CreateJoinCommand(sshConfig SSHConfig, ttl string) (string, error) {
+	cmd := "kubeadm token create --print-join-command"
+	if ttl != "" {
+		if _, err := time.ParseDuration(ttl); err != nil {
+			return "", fmt.Errorf("invalid ttl: %v", err)
+		}
+		cmd += " --ttl " + ttl
+	}
+
+	output, err := RunCommandOnRemote(sshConfig, cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token: %v", err)
+	}
+
+	// 解析join参数后重新生成命令，过滤掉警告等输出
+	info, err := node.ParseJoinCommand(output)
+	if err != nil {
+		return "", fmt.Errorf("%v in output: %s", err, output)
+	}
+	return info.Command(), nil
+}
+
+// RevokeJoinToken 吊销指定的引导令牌
+func RevokeJoinToken(sshConfig SSHConfig, token string) error {
+	if err := ValidateToken(token); err != nil {
+		return err
+	}
+	if _, err := RunCommandOnRemote(sshConfig, "kubeadm token delete "+token); err != nil {
+		return fmt.Errorf("failed to revoke token: %v", err)
+	}
+	return nil
+}
+
+// IsJoinCommandValid 检查join命令中的令牌是否仍然存在且未过期
+func IsJoinCommandValid(sshConfig SSHConfig, joinCmd string) (bool, error) {
+	token := ParseJoinToken(joinCmd)
+	if token == "" {
+		return false, nil
+	}
+
+	tokens, err := ListJoinTokens(sshConfig)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range tokens {
+		if t.Token == token {
+			return !t.Expired, nil
+		}
+	}
+	return false, nil
+}
+
+// EnsureJoinCommand 确保join命令可用，令牌过期或不存在时自动重新生成
+// 返回可用的join命令，以及是否重新生成
+func EnsureJoinCommand(sshConfig SSHConfig, storedCmd string) (string, bool, error) {
+	if storedCmd != "" {
+		valid, err := IsJoinCommandValid(sshConfig, storedCmd)
+		if err == nil && valid {
+			return storedCmd, false, nil
+		}
+		if err != nil {
+			fmt.Printf("检查join令牌失败，将重新生成: %v\n", err)
+		}
+	}
+
+	cmd, err := CreateJoinCommand(sshConfig, "")
+	if err != nil {
+		return "", false, err
+	}
+	return cmd, true, nil
+}
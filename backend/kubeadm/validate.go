@@ -0,0 +1,27 @@
+package kubeadm
+
+import "k8s-installer/validate"
+
+// Validate 检查kubeadm配置中的地址、端口、网段和版本号
+func (c KubeadmConfig) Validate() error {
+	v := &validate.Validator{}
+	v.IP("initConfiguration.localAPIEndpoint.advertiseAddress", c.InitConfiguration.LocalAPIEndpoint.AdvertiseAddress)
+	v.Port("initConfiguration.localAPIEndpoint.bindPort", c.InitConfiguration.LocalAPIEndpoint.BindPort)
+
+	cluster := c.ClusterConfiguration
+	v.Version("clusterConfiguration.kubernetesVersion", cluster.KubernetesVersion)
+	v.HostPort("clusterConfiguration.controlPlaneEndpoint", cluster.ControlPlaneEndpoint)
+	v.CIDR("clusterConfiguration.networking.podSubnet", cluster.Networking.PodSubnet)
+	v.CIDR("clusterConfiguration.networking.serviceSubnet", cluster.Networking.ServiceSubnet)
+	v.DNSSubdomain("clusterConfiguration.networking.dnsDomain", cluster.Networking.DNSDomain)
+	for _, san := range cluster.APIServer.CertSANs {
+		v.SAN("clusterConfiguration.apiServer.certSANs", san)
+	}
+	if cluster.Etcd.External != nil {
+		v.Merge("clusterConfiguration.etcd.external", cluster.Etcd.External.Validate())
+		if cluster.Etcd.Local.DataDir != "" || len(cluster.Etcd.Local.ExtraArgs) > 0 {
+			v.Add("clusterConfiguration.etcd", "local and external etcd cannot both be configured")
+		}
+	}
+	return v.Err()
+}
@@ -0,0 +1,267 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/kube"
+	"k8s-installer/ssh"
+)
+
+// 集群验证检查项
+const (
+	CheckNodesReady    = "nodes_ready"
+	CheckCorePodsReady = "core_pods_ready"
+	CheckDNSResolution = "dns_resolution"
+	CheckPodNetwork    = "pod_network"
+)
+
+// AllChecks 所有验证检查项，按执行顺序排列
+var AllChecks = []string{
+	CheckNodesReady,
+	CheckCorePodsReady,
+	CheckDNSResolution,
+	CheckPodNetwork,
+}
+
+// 验证默认参数
+const (
+	DefaultVerifyTimeout   = 5 * time.Minute
+	DefaultVerifyTestImage = "busybox:1.36"
+	verifyPollInterval     = 5 * time.Second
+	// kubectlCmd 使用admin.conf执行kubectl，不依赖登录用户的kubeconfig
+	kubectlCmd = "sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf"
+)
+
+// VerifyOptions 集群验证选项
+type VerifyOptions struct {
+	// TimeoutSeconds 等待节点和Pod就绪的超时时间，为0时使用DefaultVerifyTimeout
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// TestImage DNS和网络测试Pod使用的镜像，需要包含nslookup和ping
+	TestImage string `json:"testImage"`
+	// SkipChecks 跳过的检查项
+	SkipChecks []string `json:"skipChecks"`
+	// readyTimeout 节点和核心Pod就绪检查的等待时间，为0时使用TimeoutSeconds，由部署的就绪门控设置
+	readyTimeout time.Duration
+}
+
+// CheckResult 单个检查项结果
+type CheckResult struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Message    string `json:"message"`
+	Details    string `json:"details,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// VerificationReport 集群验证报告
+type VerificationReport struct {
+	Passed bool `json:"passed"`
+	// Access 检查使用的访问方式：api或ssh，由ClusterExecutor设置
+	Access     string        `json:"access,omitempty"`
+	Checks     []CheckResult `json:"checks"`
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+}
+
+// IsValidCheck 检查是否为有效的检查项
+func IsValidCheck(name string) bool {
+	for _, c := range AllChecks {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterVerifier 执行集群检查，通过kube读取节点和Pod；client不为nil时测试Pod在master节点上通过kubectl运行，
+// 否则通过API Server创建
+type clusterVerifier struct {
+	ctx          context.Context
+	client       ssh.Runner
+	kube         *kube.Client
+	opts         VerifyOptions
+	timeout      time.Duration
+	readyTimeout time.Duration
+	logf         func(msg string)
+	suffix       string
+}
+
+// VerifyCluster 在master节点上依次执行节点就绪、核心Pod就绪、DNS解析和跨节点Pod网络检查，
+// 节点和Pod通过kubectl get --raw读取，测试Pod通过kubectl run运行
+func VerifyCluster(ctx context.Context, client ssh.Runner, opts VerifyOptions, logf func(msg string)) VerificationReport {
+	v := newClusterVerifier(ctx, opts, logf)
+	v.client = client
+	v.kube = kubectlClient(client, kubectlCmd)
+	return v.run(map[string]func() CheckResult{
+		CheckNodesReady:    v.checkNodesReady,
+		CheckCorePodsReady: v.checkCorePodsReady,
+		CheckDNSResolution: v.checkDNS,
+		CheckPodNetwork:    v.checkPodNetwork,
+	})
+}
+
+// VerifyClusterAPI 通过API Server执行与VerifyCluster相同的检查，测试Pod通过API创建并读取日志
+func VerifyClusterAPI(ctx context.Context, client *kube.Client, opts VerifyOptions, logf func(msg string)) VerificationReport {
+	v := newClusterVerifier(ctx, opts, logf)
+	v.kube = client
+	return v.run(map[string]func() CheckResult{
+		CheckNodesReady:    v.checkNodesReady,
+		CheckCorePodsReady: v.checkCorePodsReady,
+		CheckDNSResolution: v.checkDNSAPI,
+		CheckPodNetwork:    v.checkPodNetworkAPI,
+	})
+}
+
+// newClusterVerifier 按验证选项设置超时时间和测试镜像
+func newClusterVerifier(ctx context.Context, opts VerifyOptions, logf func(msg string)) *clusterVerifier {
+	timeout := time.Duration(opts.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	if opts.TestImage == "" {
+		opts.TestImage = DefaultVerifyTestImage
+	}
+	if logf == nil {
+		logf = func(string) {}
+	}
+
+	readyTimeout := opts.readyTimeout
+	if readyTimeout <= 0 {
+		readyTimeout = timeout
+	}
+
+	return &clusterVerifier{
+		ctx:          ctx,
+		opts:         opts,
+		timeout:      timeout,
+		readyTimeout: readyTimeout,
+		logf:         logf,
+		suffix:       fmt.Sprintf("%d", time.Now().Unix()),
+	}
+}
+
+// run 按AllChecks的顺序执行检查，跳过SkipChecks中的检查项
+func (v *clusterVerifier) run(checks map[string]func() CheckResult) VerificationReport {
+	ctx, opts, logf := v.ctx, v.opts, v.logf
+	report := VerificationReport{Passed: true, StartedAt: time.Now()}
+	for _, name := range AllChecks {
+		var result CheckResult
+		if containsStep(opts.SkipChecks, name) {
+			result = CheckResult{Passed: true, Skipped: true, Message: "已跳过"}
+		} else if ctx.Err() != nil {
+			result = CheckResult{Message: fmt.Sprintf("验证已取消: %v", ctx.Err())}
+		} else {
+			logf(fmt.Sprintf("=== 检查 %s ===", name))
+			start := time.Now()
+			result = checks[name]()
+			result.DurationMs = time.Since(start).Milliseconds()
+		}
+		result.Name = name
+
+		status := "✓"
+		if !result.Passed {
+			status = "✗"
+			report.Passed = false
+		}
+		logf(fmt.Sprintf("%s %s: %s", status, name, result.Message))
+		report.Checks = append(report.Checks, result)
+	}
+	report.FinishedAt = time.Now()
+	return report
+}
+
+// containsStep 检查列表中是否包含指定项
+func containsStep(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// runKubectl 在master节点上执行kubectl命令
+func runKubectl(client ssh.Runner, args string) (string, error) {
+	output, err := client.RunCommandSilent(kubectlCmd + " " + args)
+	return strings.TrimSpace(output), err
+}
+
+// kubectl 在master节点上执行kubectl命令
+func (v *clusterVerifier) kubectl(args string) (string, error) {
+	return runKubectl(v.client, args)
+}
+
+// poll 周期性执行check直到返回true或超过timeout，返回最后一次的详情
+func (v *clusterVerifier) poll(timeout time.Duration, check func() (bool, string)) (bool, string) {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, details := check()
+		if ok || time.Now().After(deadline) {
+			return ok, details
+		}
+		select {
+		case <-v.ctx.Done():
+			return false, details
+		case <-time.After(verifyPollInterval):
+		}
+	}
+}
+
+// runTestPod 运行一次性测试Pod并返回输出，Pod结束后自动删除
+func (v *clusterVerifier) runTestPod(name, overrides, command string) (string, error) {
+	args := fmt.Sprintf("run %s --image=%s --restart=Never --rm -i --quiet --pod-running-timeout=%ds", name, v.opts.TestImage, int(v.timeout/time.Second))
+	if overrides != "" {
+		args += fmt.Sprintf(" --overrides='%s'", overrides)
+	}
+	output, err := v.kubectl(args + " --command -- " + command)
+	// 确保异常退出时Pod也被清理
+	v.kubectl(fmt.Sprintf("delete pod %s --ignore-not-found --wait=false", name))
+	return output, err
+}
+
+// checkDNS 通过测试Pod解析集群内服务名
+func (v *clusterVerifier) checkDNS() CheckResult {
+	name := "k8s-installer-dns-" + v.suffix
+	output, err := v.runTestPod(name, "", "nslookup kubernetes.default")
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("DNS解析失败: %v", err), Details: output}
+	}
+	return CheckResult{Passed: true, Message: "kubernetes.default 解析成功", Details: output}
+}
+
+// checkPodNetwork 在两个不同节点上的Pod之间测试网络连通性
+func (v *clusterVerifier) checkPodNetwork() CheckResult {
+	nodes, err := v.schedulableNodes()
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("获取节点列表失败: %v", err)}
+	}
+	if len(nodes) < 2 {
+		return CheckResult{Passed: true, Skipped: true, Message: fmt.Sprintf("可调度节点数为 %d，跳过跨节点网络测试", len(nodes))}
+	}
+
+	// 在第一个节点上启动服务端Pod
+	serverName := "k8s-installer-net-server-" + v.suffix
+	defer v.kubectl(fmt.Sprintf("delete pod %s --ignore-not-found --wait=false", serverName))
+	if out, err := v.kubectl(fmt.Sprintf(`run %s --image=%s --restart=Never --overrides='{"spec":{"nodeName":"%s"}}' --command -- sleep 600`, serverName, v.opts.TestImage, nodes[0])); err != nil {
+		return CheckResult{Message: fmt.Sprintf("创建测试Pod失败: %v", err), Details: out}
+	}
+	if out, err := v.kubectl(fmt.Sprintf("wait --for=condition=Ready pod/%s --timeout=%ds", serverName, int(v.timeout/time.Second))); err != nil {
+		return CheckResult{Message: fmt.Sprintf("测试Pod未就绪: %v", err), Details: out}
+	}
+	podIP, err := v.kubectl(fmt.Sprintf("get pod %s -o jsonpath='{.status.podIP}'", serverName))
+	if err != nil || podIP == "" {
+		return CheckResult{Message: fmt.Sprintf("获取测试Pod IP失败: %v", err), Details: podIP}
+	}
+
+	// 从第二个节点上的Pod访问服务端Pod
+	clientName := "k8s-installer-net-client-" + v.suffix
+	out, err := v.runTestPod(clientName, fmt.Sprintf(`{"spec":{"nodeName":"%s"}}`, nodes[1]), "ping -c 3 -W 2 "+podIP)
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("%s -> %s (%s) 网络不通: %v", nodes[1], nodes[0], podIP, err), Details: out}
+	}
+	return CheckResult{Passed: true, Message: fmt.Sprintf("%s -> %s (%s) 网络连通", nodes[1], nodes[0], podIP), Details: out}
+}
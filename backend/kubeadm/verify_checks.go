@@ -0,0 +1,173 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/kube"
+)
+
+// verifyTestNamespace 测试Pod所在的命名空间
+const verifyTestNamespace = "default"
+
+// checkNodesReady 检查所有节点均为Ready
+func (v *clusterVerifier) checkNodesReady() CheckResult {
+	var total, notReady int
+	ok, details := v.poll(v.readyTimeout, func() (bool, string) {
+		nodes, err := v.kube.ListNodes(v.ctx)
+		if err != nil {
+			return false, err.Error()
+		}
+		total, notReady = len(nodes), 0
+		var lines []string
+		for _, n := range nodes {
+			if !n.Ready() {
+				notReady++
+			}
+			lines = append(lines, fmt.Sprintf("%s %v", n.Metadata.Name, n.Ready()))
+		}
+		return total > 0 && notReady == 0, strings.Join(lines, "\n")
+	})
+	if !ok {
+		return CheckResult{Message: fmt.Sprintf("%d/%d 个节点未Ready", notReady, total), Details: details}
+	}
+	return CheckResult{Passed: true, Message: fmt.Sprintf("%d 个节点均已Ready", total), Details: details}
+}
+
+// checkCorePodsReady 检查kube-system命名空间下的Pod均已就绪
+func (v *clusterVerifier) checkCorePodsReady() CheckResult {
+	var total int
+	var pending []string
+	ok, details := v.poll(v.readyTimeout, func() (bool, string) {
+		pods, err := v.kube.ListPods(v.ctx, "kube-system")
+		if err != nil {
+			return false, err.Error()
+		}
+		total, pending = len(pods), nil
+		var lines []string
+		for _, p := range pods {
+			if !p.Ready() && p.Status.Phase != kube.PodSucceeded {
+				pending = append(pending, p.Metadata.Name)
+			}
+			lines = append(lines, fmt.Sprintf("%s %s %v", p.Metadata.Name, p.Status.Phase, p.Ready()))
+		}
+		return total > 0 && len(pending) == 0, strings.Join(lines, "\n")
+	})
+	if !ok {
+		return CheckResult{Message: fmt.Sprintf("%d 个核心Pod未就绪: %s", len(pending), strings.Join(pending, ", ")), Details: details}
+	}
+	return CheckResult{Passed: true, Message: fmt.Sprintf("%d 个核心Pod均已就绪", total), Details: details}
+}
+
+// schedulableNodes 可调度且没有NoSchedule污点的节点名称，跨节点网络测试只使用这些节点
+func (v *clusterVerifier) schedulableNodes() ([]string, error) {
+	items, err := v.kube.ListNodes(v.ctx)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []string
+	for _, n := range items {
+		if n.Schedulable() {
+			nodes = append(nodes, n.Metadata.Name)
+		}
+	}
+	return nodes, nil
+}
+
+// testPod 在指定节点上运行测试镜像的Pod，nodeName为空时由调度器选择节点
+func (v *clusterVerifier) testPod(name, nodeName string, command []string) kube.Pod {
+	var pod kube.Pod
+	pod.Metadata = kube.ObjectMeta{Name: name, Namespace: verifyTestNamespace, Labels: map[string]string{"app": "k8s-installer-verify"}}
+	pod.Spec.NodeName = nodeName
+	pod.Spec.RestartPolicy = "Never"
+	pod.Spec.Containers = []kube.Container{{Name: "test", Image: v.opts.TestImage, Command: command}}
+	return pod
+}
+
+// deletePod 删除测试Pod，验证被取消时也要清理
+func (v *clusterVerifier) deletePod(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), kube.DefaultTimeout)
+	defer cancel()
+	v.kube.DeletePod(ctx, verifyTestNamespace, name)
+}
+
+// waitPod 等待Pod满足条件，超时或Pod失败时返回错误
+func (v *clusterVerifier) waitPod(name string, done func(p *kube.Pod) bool) (*kube.Pod, error) {
+	deadline := time.Now().Add(v.timeout)
+	for {
+		pod, err := v.kube.GetPod(v.ctx, verifyTestNamespace, name)
+		if err == nil && done(pod) {
+			return pod, nil
+		}
+		if err == nil && pod.Status.Phase == kube.PodFailed {
+			return pod, fmt.Errorf("pod %s failed", name)
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = fmt.Errorf("timed out waiting for pod %s, phase %s", name, pod.Status.Phase)
+			}
+			return pod, err
+		}
+		select {
+		case <-v.ctx.Done():
+			return pod, v.ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// runTestPodAPI 运行一次性测试Pod并返回日志，Pod结束后删除
+func (v *clusterVerifier) runTestPodAPI(name, nodeName string, command []string) (string, error) {
+	if _, err := v.kube.CreatePod(v.ctx, v.testPod(name, nodeName, command)); err != nil {
+		return "", err
+	}
+	defer v.deletePod(name)
+
+	_, err := v.waitPod(name, func(p *kube.Pod) bool { return p.Status.Phase == kube.PodSucceeded })
+	logs, logErr := v.kube.PodLogs(v.ctx, verifyTestNamespace, name)
+	if err == nil && logErr != nil {
+		err = logErr
+	}
+	return logs, err
+}
+
+// checkDNSAPI 通过测试Pod解析集群内服务名
+func (v *clusterVerifier) checkDNSAPI() CheckResult {
+	output, err := v.runTestPodAPI("k8s-installer-dns-"+v.suffix, "", []string{"nslookup", "kubernetes.default"})
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("DNS解析失败: %v", err), Details: output}
+	}
+	return CheckResult{Passed: true, Message: "kubernetes.default 解析成功", Details: output}
+}
+
+// checkPodNetworkAPI 在两个不同节点上的Pod之间测试网络连通性
+func (v *clusterVerifier) checkPodNetworkAPI() CheckResult {
+	nodes, err := v.schedulableNodes()
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("获取节点列表失败: %v", err)}
+	}
+	if len(nodes) < 2 {
+		return CheckResult{Passed: true, Skipped: true, Message: fmt.Sprintf("可调度节点数为 %d，跳过跨节点网络测试", len(nodes))}
+	}
+
+	// 在第一个节点上启动服务端Pod
+	serverName := "k8s-installer-net-server-" + v.suffix
+	if _, err := v.kube.CreatePod(v.ctx, v.testPod(serverName, nodes[0], []string{"sleep", "600"})); err != nil {
+		return CheckResult{Message: fmt.Sprintf("创建测试Pod失败: %v", err)}
+	}
+	defer v.deletePod(serverName)
+	server, err := v.waitPod(serverName, func(p *kube.Pod) bool { return p.Ready() && p.Status.PodIP != "" })
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("测试Pod未就绪: %v", err)}
+	}
+	podIP := server.Status.PodIP
+
+	// 从第二个节点上的Pod访问服务端Pod
+	out, err := v.runTestPodAPI("k8s-installer-net-client-"+v.suffix, nodes[1], []string{"ping", "-c", "3", "-W", "2", podIP})
+	if err != nil {
+		return CheckResult{Message: fmt.Sprintf("%s -> %s (%s) 网络不通: %v", nodes[1], nodes[0], podIP, err), Details: out}
+	}
+	return CheckResult{Passed: true, Message: fmt.Sprintf("%s -> %s (%s) 网络连通", nodes[1], nodes[0], podIP), Details: out}
+}
@@ -1,6 +1,7 @@
 package kubeadm
 
 import (
+	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -15,10 +16,79 @@ import (
 
 type VersionManager struct {
 	mu                sync.RWMutex
+	syncMu            sync.Mutex // 防止定时同步和手动刷新并发执行
 	availableVersions []string
 	syncInterval      time.Duration
 	running           bool
 	stopChan          chan struct{}
+	db                *sql.DB
+	lastSyncTime      time.Time
+	lastSyncSource    string
+	lastSyncError     string
+}
+
+// 版本来源
+const (
+	VersionSourceAliyun   = "aliyun"
+	VersionSourceOfficial = "official"
+	VersionSourceCache    = "cache"
+	VersionSourceBuiltin  = "builtin"
+)
+
+// supportedMinorCount Kubernetes社区同时维护的次版本数量，更早的次版本视为EOL
+const supportedMinorCount = 3
+
+// officialMinorLookback 从官方源回溯查询的次版本数量
+const officialMinorLookback = 6
+
+// versionHTTPClient 查询上游版本使用的HTTP客户端，避免离线时长时间阻塞
+var versionHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// VersionInfo 版本详细信息
+type VersionInfo struct {
+	Version string `json:"version"`
+	Minor   string `json:"minor"`
+	EOL     bool   `json:"eol"`
+}
+
+// VersionSyncStatus 版本同步状态
+type VersionSyncStatus struct {
+	LastSyncTime time.Time `json:"lastSyncTime"`
+	Source       string    `json:"source"`
+	Count        int       `json:"count"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// builtinVersions 内置版本列表，上游和缓存都不可用时使用
+var builtinVersions = []string{
+	"v1.30.0",
+	"v1.29.4",
+	"v1.29.3",
+	"v1.29.2",
+	"v1.29.1",
+	"v1.29.0",
+	"v1.28.8",
+	"v1.28.7",
+	"v1.28.6",
+	"v1.28.5",
+	"v1.28.4",
+	"v1.28.3",
+	"v1.28.2",
+	"v1.28.1",
+	"v1.28.0",
+	"v1.27.12",
+	"v1.27.11",
+	"v1.27.10",
+	"v1.27.9",
+	"v1.27.8",
+	"v1.27.7",
+	"v1.27.6",
+	"v1.27.5",
+	"v1.27.4",
+	"v1.27.3",
+	"v1.27.2",
+	"v1.27.1",
+	"v1.27.0",
 }
 
 // NewVersionManager 创建新的版本管理器
@@ -73,63 +143,197 @@ func (vm *VersionManager) GetAvailableVersions() []string {
 
 	// 如果没有同步到版本，返回默认版本列表
 	if len(vm.availableVersions) == 0 {
-		return []string{
-			"v1.30.0",
-			"v1.29.4",
-			"v1.29.3",
-			"v1.29.2",
-			"v1.29.1",
-			"v1.29.0",
-			"v1.28.8",
-			"v1.28.7",
-			"v1.28.6",
-			"v1.28.5",
-			"v1.28.4",
-			"v1.28.3",
-			"v1.28.2",
-			"v1.28.1",
-			"v1.28.0",
-			"v1.27.12",
-			"v1.27.11",
-			"v1.27.10",
-			"v1.27.9",
-			"v1.27.8",
-			"v1.27.7",
-			"v1.27.6",
-			"v1.27.5",
-			"v1.27.4",
-			"v1.27.3",
-			"v1.27.2",
-			"v1.27.1",
-			"v1.27.0",
-		}
+		return builtinVersions
 	}
 
 	return vm.availableVersions
 }
 
+// SetDB 设置数据库连接，用于缓存同步到的版本列表
+func (vm *VersionManager) SetDB(db *sql.DB) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS kubernetes_versions (
+		version TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		synced_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create kubernetes_versions table: %v", err)
+	}
+
+	vm.mu.Lock()
+	vm.db = db
+	vm.mu.Unlock()
+	return nil
+}
+
 // SyncVersions 同步Kubernetes版本列表
-func (vm *VersionManager) SyncVersions() {
+// 依次尝试阿里云、官方源，都失败时使用数据库缓存，最后使用内置列表
+func (vm *VersionManager) SyncVersions() VersionSyncStatus {
+	vm.syncMu.Lock()
+	defer vm.syncMu.Unlock()
+
 	fmt.Println("开始同步Kubernetes版本列表...")
 
 	// 从阿里云镜像源获取可用版本
+	source := VersionSourceAliyun
 	versions := vm.fetchVersionsFromAliyun()
 
 	// 从官方源获取可用版本作为备份
 	if len(versions) == 0 {
+		source = VersionSourceOfficial
 		versions = vm.fetchVersionsFromOfficial()
 	}
 
+	syncErr := ""
+	if len(versions) == 0 {
+		syncErr = "failed to fetch versions from upstream"
+		// 离线时使用数据库中缓存的版本列表
+		source = VersionSourceCache
+		versions = vm.loadCachedVersions()
+	} else {
+		vm.saveCachedVersions(versions, source)
+	}
+
+	if len(versions) == 0 {
+		source = VersionSourceBuiltin
+		versions = builtinVersions
+	}
+
 	// 处理版本列表，去重、排序
 	processedVersions := vm.processVersions(versions)
 
 	// 更新可用版本
 	vm.mu.Lock()
 	vm.availableVersions = processedVersions
+	vm.lastSyncTime = time.Now()
+	vm.lastSyncSource = source
+	vm.lastSyncError = syncErr
 	vm.mu.Unlock()
 
-	fmt.Printf("版本同步完成，共获取到 %d 个可用版本\n", len(processedVersions))
-	fmt.Printf("最新可用版本: %s\n", processedVersions[0])
+	fmt.Printf("版本同步完成，来源: %s，共获取到 %d 个可用版本\n", source, len(processedVersions))
+	if len(processedVersions) > 0 {
+		fmt.Printf("最新可用版本: %s\n", processedVersions[0])
+	}
+
+	return vm.GetSyncStatus()
+}
+
+// GetSyncStatus 获取最近一次同步的状态
+func (vm *VersionManager) GetSyncStatus() VersionSyncStatus {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+
+	return VersionSyncStatus{
+		LastSyncTime: vm.lastSyncTime,
+		Source:       vm.lastSyncSource,
+		Count:        len(vm.availableVersions),
+		Error:        vm.lastSyncError,
+	}
+}
+
+// loadCachedVersions 从数据库加载缓存的版本列表
+func (vm *VersionManager) loadCachedVersions() []string {
+	vm.mu.RLock()
+	db := vm.db
+	vm.mu.RUnlock()
+	if db == nil {
+		return nil
+	}
+
+	rows, err := db.Query("SELECT version FROM kubernetes_versions")
+	if err != nil {
+		fmt.Printf("读取版本缓存失败: %v\n", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// saveCachedVersions 将同步到的版本列表写入数据库缓存
+func (vm *VersionManager) saveCachedVersions(versions []string, source string) {
+	vm.mu.RLock()
+	db := vm.db
+	vm.mu.RUnlock()
+	if db == nil {
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		fmt.Printf("写入版本缓存失败: %v\n", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM kubernetes_versions"); err != nil {
+		fmt.Printf("写入版本缓存失败: %v\n", err)
+		return
+	}
+	now := time.Now()
+	for _, v := range versions {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO kubernetes_versions (version, source, synced_at) VALUES (?, ?, ?)", v, source, now); err != nil {
+			fmt.Printf("写入版本缓存失败: %v\n", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("写入版本缓存失败: %v\n", err)
+	}
+}
+
+// GetVersionInfos 获取带次版本和EOL信息的版本列表
+// minor不为空时只返回该次版本（如"1.29"），includeEOL为false时过滤掉已EOL的版本
+func (vm *VersionManager) GetVersionInfos(minor string, includeEOL bool) []VersionInfo {
+	versions := vm.GetAvailableVersions()
+	minor = strings.TrimPrefix(minor, "v")
+
+	// 找出最新的次版本号，用于计算EOL
+	latestMinor := 0
+	for _, v := range versions {
+		if _, m, ok := parseMajorMinor(v); ok && m > latestMinor {
+			latestMinor = m
+		}
+	}
+
+	infos := []VersionInfo{}
+	for _, v := range versions {
+		major, m, ok := parseMajorMinor(v)
+		if !ok {
+			continue
+		}
+		info := VersionInfo{
+			Version: v,
+			Minor:   fmt.Sprintf("%d.%d", major, m),
+			EOL:     m <= latestMinor-supportedMinorCount,
+		}
+		if minor != "" && info.Minor != minor {
+			continue
+		}
+		if !includeEOL && info.EOL {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// parseMajorMinor 解析版本号中的主版本号和次版本号
+func parseMajorMinor(version string) (int, int, bool) {
+	var major, minor int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(version, "v"), "%d.%d", &major, &minor); err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
 }
 
 // fetchVersionsFromAliyun 从阿里云镜像源获取可用版本
@@ -155,7 +359,7 @@ func (vm *VersionManager) fetchVersionsFromOfficial() []string {
 
 	// 官方稳定版列表
 	officialURL := "https://dl.k8s.io/release/stable.txt"
-	resp, err := http.Get(officialURL)
+	resp, err := versionHTTPClient.Get(officialURL)
 	if err != nil {
 		fmt.Printf("获取官方稳定版失败: %v\n", err)
 		return versions
@@ -169,18 +373,49 @@ func (vm *VersionManager) fetchVersionsFromOfficial() []string {
 	}
 
 	stableVersion := strings.TrimSpace(string(body))
-	if stableVersion != "" {
-		versions = append(versions, stableVersion)
+	if stableVersion == "" {
+		return versions
+	}
+	versions = append(versions, stableVersion)
+
+	// 查询最近几个次版本的最新补丁版本
+	major, minor, ok := parseMajorMinor(stableVersion)
+	if !ok {
+		return versions
+	}
+	for m := minor - 1; m >= 0 && m > minor-officialMinorLookback; m-- {
+		v := vm.fetchTextVersion(fmt.Sprintf("https://dl.k8s.io/release/stable-%d.%d.txt", major, m))
+		if v != "" {
+			versions = append(versions, v)
+		}
 	}
 
 	return versions
 }
 
+// fetchTextVersion 获取只包含版本号的文本文件内容
+func (vm *VersionManager) fetchTextVersion(url string) string {
+	resp, err := versionHTTPClient.Get(url)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(body))
+}
+
 // parseVersionsFromPackagesURL 从Packages文件中解析版本
 func (vm *VersionManager) parseVersionsFromPackagesURL(url string) []string {
 	versions := []string{}
 
-	resp, err := http.Get(url)
+	resp, err := versionHTTPClient.Get(url)
 	if err != nil {
 		fmt.Printf("获取Packages文件失败: %v\n", err)
 		return versions
@@ -253,8 +488,12 @@ func (vm *VersionManager) compareVersions(v1, v2 string) int {
 	// 比较每个部分
 	for i := 0; i < 3; i++ {
 		var num1, num2 int
-		fmt.Sscanf(parts1[i], "%d", &num1)
-		fmt.Sscanf(parts2[i], "%d", &num2)
+		if i < len(parts1) {
+			fmt.Sscanf(parts1[i], "%d", &num1)
+		}
+		if i < len(parts2) {
+			fmt.Sscanf(parts2[i], "%d", &num2)
+		}
 
 		if num1 > num2 {
 			return 1
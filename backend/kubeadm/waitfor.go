@@ -0,0 +1,99 @@
+package kubeadm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s-installer/ssh"
+)
+
+// 条件等待的默认时间
+const (
+	defaultWaitInterval    = 2 * time.Second
+	ContainerdReadyTimeout = time.Minute
+	KubeletActiveTimeout   = time.Minute
+	NodesReadyTimeout      = 5 * time.Minute
+	PodsReadyTimeout       = 5 * time.Minute
+)
+
+// criSocketPath containerd的CRI socket
+const criSocketPath = "/run/containerd/containerd.sock"
+
+// WaitCondition 等待的条件：周期性执行Command直到满足条件或超时。
+// Predicate为空时命令成功即满足条件，否则由Predicate判断命令输出
+type WaitCondition struct {
+	Description string
+	Command     string
+	Predicate   func(output string) bool
+	Timeout     time.Duration
+	Interval    time.Duration
+}
+
+// ContainerdReady containerd已创建CRI socket，不依赖systemd，手动启动的containerd同样适用
+func ContainerdReady() WaitCondition {
+	return WaitCondition{
+		Description: "containerd就绪",
+		Command:     "sudo test -S " + criSocketPath,
+		Timeout:     ContainerdReadyTimeout,
+	}
+}
+
+// KubeletActive kubelet服务处于active状态
+func KubeletActive() WaitCondition {
+	return WaitCondition{
+		Description: "kubelet启动",
+		Command:     "sudo systemctl is-active --quiet kubelet",
+		Timeout:     KubeletActiveTimeout,
+	}
+}
+
+// NodesReady 集群中的所有节点均为Ready，kubectl为执行kubectl的命令
+func NodesReady(kubectl string) WaitCondition {
+	return WaitCondition{
+		Description: "节点Ready",
+		Command:     kubectl + " wait --for=condition=Ready nodes --all --timeout=1s",
+		Timeout:     NodesReadyTimeout,
+	}
+}
+
+// PodsReady 命名空间中已有Pod且所有Pod均已就绪，用于等待CNI插件等组件
+func PodsReady(kubectl, namespace string) WaitCondition {
+	return WaitCondition{
+		Description: fmt.Sprintf("%s中的Pod就绪", namespace),
+		Command:     fmt.Sprintf("%s wait --for=condition=Ready pods --all -n %s --timeout=1s", kubectl, namespace),
+		Timeout:     PodsReadyTimeout,
+	}
+}
+
+// Shell 返回脚本中等待该条件的wait_for调用，脚本需要先定义script.WaitForFunc。
+// 脚本中只判断命令是否成功，不使用Predicate
+func (c WaitCondition) Shell() string {
+	return fmt.Sprintf("wait_for %d %s bash -c %s", int(c.Timeout/time.Second), shellQuote(c.Description), shellQuote(c.Command))
+}
+
+// WaitFor 在节点上周期性执行条件命令，满足条件时返回最后一次的输出，超时或ctx结束时返回错误
+func WaitFor(ctx context.Context, client ssh.Runner, c WaitCondition) (string, error) {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+	deadline := time.Now().Add(c.Timeout)
+	for {
+		output, err := client.RunCommandSilent(c.Command)
+		if err == nil && (c.Predicate == nil || c.Predicate(output)) {
+			return output, nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return output, fmt.Errorf("timed out after %v waiting for %s: %v", c.Timeout, c.Description, err)
+			}
+			return output, fmt.Errorf("timed out after %v waiting for %s", c.Timeout, c.Description)
+		}
+		select {
+		case <-ctx.Done():
+			return output, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
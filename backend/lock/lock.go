@@ -0,0 +1,120 @@
+package lock
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Holder 锁的持有者
+type Holder struct {
+	JobID      string    `json:"jobId"`
+	Operation  string    `json:"operation"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+}
+
+// LockedError 资源已被其他任务锁定
+type LockedError struct {
+	Key    string
+	Holder Holder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is locked by %s job %s", e.Key, e.Holder.Operation, e.Holder.JobID)
+}
+
+// NodeKey 节点锁的键
+func NodeKey(nodeID string) string {
+	return "node:" + nodeID
+}
+
+// ClusterKey 集群锁的键，集群ID即master节点ID
+func ClusterKey(clusterID string) string {
+	return "cluster:" + clusterID
+}
+
+// Manager 节点和集群锁管理器，同一节点或集群同时只允许一个变更操作
+type Manager struct {
+	mutex sync.Mutex
+	locks map[string]*Holder
+}
+
+// NewManager 创建锁管理器
+func NewManager() *Manager {
+	return &Manager{locks: make(map[string]*Holder)}
+}
+
+// Lease 一次成功获取的一组锁
+type Lease struct {
+	manager *Manager
+	keys    []string
+	holder  *Holder
+	once    sync.Once
+}
+
+// Acquire 一次性获取所有键的锁，任意一个键已被锁定时不获取任何锁并返回*LockedError
+func (m *Manager) Acquire(jobID, operation string, keys ...string) (*Lease, error) {
+	keys = uniqueKeys(keys)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, key := range keys {
+		if holder, ok := m.locks[key]; ok {
+			return nil, &LockedError{Key: key, Holder: *holder}
+		}
+	}
+	holder := &Holder{JobID: jobID, Operation: operation, AcquiredAt: time.Now()}
+	for _, key := range keys {
+		m.locks[key] = holder
+	}
+	return &Lease{manager: m, keys: keys, holder: holder}, nil
+}
+
+// List 返回当前所有锁，键为锁的键
+func (m *Manager) List() map[string]Holder {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	locks := make(map[string]Holder, len(m.locks))
+	for key, holder := range m.locks {
+		locks[key] = *holder
+	}
+	return locks
+}
+
+// SetJobID 更新持有者的任务ID，用于任务ID在获取锁之后才确定的场景（如继续已有的部署）
+func (l *Lease) SetJobID(jobID string) {
+	l.manager.mutex.Lock()
+	defer l.manager.mutex.Unlock()
+	l.holder.JobID = jobID
+}
+
+// Release 释放所有锁，可以重复调用
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		l.manager.mutex.Lock()
+		defer l.manager.mutex.Unlock()
+		for _, key := range l.keys {
+			if l.manager.locks[key] == l.holder {
+				delete(l.manager.locks, key)
+			}
+		}
+	})
+}
+
+// uniqueKeys 去重并排序
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,72 @@
+package log
+
+import "fmt"
+
+// 日志级别，按严重程度递增
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// 日志类型
+const (
+	// TypeScriptOutput 在节点上执行的脚本和命令的输出
+	TypeScriptOutput = "script-output"
+	// TypeStep 部署等任务的步骤开始和结束
+	TypeStep = "step"
+	// TypeSystem 节点状态变化、调试信息等系统日志
+	TypeSystem = "system"
+)
+
+// levels 日志级别的严重程度
+var levels = map[string]int{LevelDebug: 0, LevelInfo: 1, LevelWarn: 2, LevelError: 3}
+
+// ValidateLevel 检查日志级别，空字符串表示不限制级别
+func ValidateLevel(level string) error {
+	if _, ok := levels[level]; ok || level == "" {
+		return nil
+	}
+	return fmt.Errorf("must be one of %s, %s, %s, %s", LevelDebug, LevelInfo, LevelWarn, LevelError)
+}
+
+// ValidateType 检查日志类型，空字符串表示不限制类型
+func ValidateType(typ string) error {
+	switch typ {
+	case "", TypeScriptOutput, TypeStep, TypeSystem:
+		return nil
+	}
+	return fmt.Errorf("must be one of %s, %s, %s", TypeScriptOutput, TypeStep, TypeSystem)
+}
+
+// EntryLevel 日志条目的级别，未指定级别时失败的操作为error，其他为info
+func EntryLevel(entry LogEntry) string {
+	if _, ok := levels[entry.Level]; ok {
+		return entry.Level
+	}
+	if entry.Status == "failed" {
+		return LevelError
+	}
+	return LevelInfo
+}
+
+// Match 日志条目是否满足过滤条件
+func (f LogFilter) Match(entry LogEntry) bool {
+	if f.NodeID != "" && entry.NodeID != f.NodeID {
+		return false
+	}
+	if f.Operation != "" && entry.Operation != f.Operation {
+		return false
+	}
+	if f.JobID != "" && entry.JobID != f.JobID {
+		return false
+	}
+	if f.Type != "" && entry.Type != f.Type {
+		return false
+	}
+	if f.MinLevel != "" && levels[EntryLevel(entry)] < levels[f.MinLevel] {
+		return false
+	}
+	return f.Allow == nil || f.Allow(entry)
+}
@@ -21,6 +21,14 @@ type LogEntry struct {
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// Progress 部署日志附带的部署进度，只通过SSE推送，不保存
+	Progress interface{} `json:"progress,omitempty"`
+	// JobID 产生日志的任务ID，如部署ID
+	JobID string `json:"jobId,omitempty"`
+	// Level 日志级别，见LevelDebug等，为空时按状态推断
+	Level string `json:"level"`
+	// Type 日志类型，见TypeScriptOutput等，为空时为TypeSystem
+	Type string `json:"type"`
 }
 
 // LogSubscription 日志订阅结构体
@@ -29,6 +37,19 @@ type LogSubscription struct {
 	Id string
 }
 
+// LogFilter 日志查询条件，空字段表示不过滤
+type LogFilter struct {
+	NodeID    string
+	Operation string
+	JobID     string
+	// MinLevel 最低日志级别，见LevelDebug等
+	MinLevel string
+	// Type 日志类型，见TypeScriptOutput等
+	Type string
+	// Allow 其他条件都满足后的额外过滤，为nil时不过滤，如只保留某个项目的日志；订阅时在广播协程中调用
+	Allow func(entry LogEntry) bool
+}
+
 // LogManager 日志管理器接口
 type LogManager interface {
 	// CreateLog 创建新日志
@@ -37,19 +58,27 @@ type LogManager interface {
 	GetLogs() ([]LogEntry, error)
 	// GetLogsByNode 获取指定节点的日志
 	GetLogsByNode(nodeID string) ([]LogEntry, error)
+	// ExportLogs 按条件逐条遍历日志，用于导出
+	ExportLogs(filter LogFilter, fn func(LogEntry) error) error
 	// ClearLogs 清除所有日志
 	ClearLogs() error
-	// SubscribeLogs 订阅日志事件
-	SubscribeLogs() LogSubscription
+	// DeleteLogs 删除指定ID的日志
+	DeleteLogs(ids []string) error
+	// SubscribeLogs 订阅满足过滤条件的日志事件
+	SubscribeLogs(filter LogFilter) LogSubscription
 	// UnsubscribeLogs 取消订阅日志事件
 	UnsubscribeLogs(sub LogSubscription)
+	// Flush 等待已创建的日志全部写入数据库
+	Flush() error
+	// Close 写入剩余的日志，之后创建的日志同步写入
+	Close() error
 }
 
 // SqliteLogManager SQLite日志管理器
 type SqliteLogManager struct {
 	DB                  *sql.DB
 	broadcastChan       chan LogEntry
-	subscribers         map[string]chan LogEntry
+	subscribers         map[string]*subscriber
 	mutex               sync.RWMutex
 	broadcastChanClosed bool
 	// 日志缓冲相关字段
@@ -57,6 +86,14 @@ type SqliteLogManager struct {
 	bufferMutex         sync.Mutex          // 缓冲锁
 	bufferFlushInterval time.Duration       // 缓冲刷新间隔
 	flushTicker         *time.Ticker        // 缓冲刷新定时器
+	// writer 日志异步批量写入
+	writer *logWriter
+}
+
+// subscriber 日志订阅者，只接收满足过滤条件的日志
+type subscriber struct {
+	ch     chan LogEntry
+	filter LogFilter
 }
 
 // NewSqliteLogManager 创建新的SQLite日志管理器
@@ -101,6 +138,37 @@ func NewSqliteLogManager(db *sql.DB) (*SqliteLogManager, error) {
 		}
 	}
 
+	// 检查并添加job_id列（如果不存在）
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('logs') WHERE name = 'job_id'").Scan(&columnExists); err != nil {
+		return nil, fmt.Errorf("failed to check job_id column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE logs ADD COLUMN job_id TEXT NOT NULL DEFAULT ''"); err != nil {
+			return nil, fmt.Errorf("failed to add job_id column: %v", err)
+		}
+	}
+
+	// 检查并添加level和type列（如果不存在），已有的日志按状态推断级别
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('logs') WHERE name = 'level'").Scan(&columnExists); err != nil {
+		return nil, fmt.Errorf("failed to check level column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE logs ADD COLUMN level TEXT NOT NULL DEFAULT 'info'"); err != nil {
+			return nil, fmt.Errorf("failed to add level column: %v", err)
+		}
+		if _, err := db.Exec("UPDATE logs SET level = ? WHERE status = 'failed'", LevelError); err != nil {
+			return nil, fmt.Errorf("failed to backfill level column: %v", err)
+		}
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('logs') WHERE name = 'type'").Scan(&columnExists); err != nil {
+		return nil, fmt.Errorf("failed to check type column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE logs ADD COLUMN type TEXT NOT NULL DEFAULT 'system'"); err != nil {
+			return nil, fmt.Errorf("failed to add type column: %v", err)
+		}
+	}
+
 	// 初始化广播通道和订阅者映射
 	broadcastChan := make(chan LogEntry, 100)
 
@@ -108,11 +176,12 @@ func NewSqliteLogManager(db *sql.DB) (*SqliteLogManager, error) {
 	manager := &SqliteLogManager{
 		DB:                  db,
 		broadcastChan:       broadcastChan,
-		subscribers:         make(map[string]chan LogEntry),
+		subscribers:         make(map[string]*subscriber),
 		broadcastChanClosed: false,
 		// 初始化日志缓冲
 		logBuffer:           make(map[string]LogEntry),
 		bufferFlushInterval: 1 * time.Second, // 每秒刷新一次缓冲
+		writer:              newLogWriter(db),
 	}
 
 	// 启动广播协程
@@ -165,25 +234,28 @@ func (m *SqliteLogManager) broadcastLogs() {
 	for logEntry := range m.broadcastChan {
 		m.mutex.RLock()
 		// 创建订阅者列表的副本，避免在遍历过程中修改
-		subscribers := make([]chan LogEntry, 0, len(m.subscribers))
-		for _, ch := range m.subscribers {
-			subscribers = append(subscribers, ch)
+		subscribers := make([]*subscriber, 0, len(m.subscribers))
+		for _, sub := range m.subscribers {
+			subscribers = append(subscribers, sub)
 		}
 		m.mutex.RUnlock()
 
-		// 发送日志到所有订阅者
-		for _, ch := range subscribers {
+		// 发送日志到过滤条件匹配的订阅者
+		for _, sub := range subscribers {
+			if !sub.filter.Match(logEntry) {
+				continue
+			}
 			select {
-			case ch <- logEntry:
+			case sub.ch <- logEntry:
 				// 日志发送成功
 			default:
 				// 通道已满，跳过此日志以避免阻塞
 				// 可以考虑关闭这个通道，因为订阅者可能已经断开连接
 				m.mutex.Lock()
 				// 遍历所有订阅者，寻找对应的通道并删除
-				for id, subCh := range m.subscribers {
-					if subCh == ch {
-						close(subCh)
+				for id, s := range m.subscribers {
+					if s == sub {
+						close(s.ch)
 						delete(m.subscribers, id)
 						break
 					}
@@ -195,15 +267,15 @@ func (m *SqliteLogManager) broadcastLogs() {
 
 	// 广播通道关闭，关闭所有订阅者通道
 	m.mutex.Lock()
-	for id, ch := range m.subscribers {
-		close(ch)
+	for id, sub := range m.subscribers {
+		close(sub.ch)
 		delete(m.subscribers, id)
 	}
 	m.mutex.Unlock()
 }
 
-// SubscribeLogs 订阅日志事件
-func (m *SqliteLogManager) SubscribeLogs() LogSubscription {
+// SubscribeLogs 订阅满足过滤条件的日志事件，在广播时过滤，不满足条件的日志不会进入订阅者的通道
+func (m *SqliteLogManager) SubscribeLogs(filter LogFilter) LogSubscription {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -212,7 +284,7 @@ func (m *SqliteLogManager) SubscribeLogs() LogSubscription {
 	// 生成唯一ID
 	id := fmt.Sprintf("sub_%d", time.Now().UnixNano())
 	// 将通道存储到订阅者映射中
-	m.subscribers[id] = ch
+	m.subscribers[id] = &subscriber{ch: ch, filter: filter}
 	// 返回订阅结构体
 	return LogSubscription{
 		Ch: ch,
@@ -226,48 +298,39 @@ func (m *SqliteLogManager) UnsubscribeLogs(sub LogSubscription) {
 	defer m.mutex.Unlock()
 
 	// 检查订阅ID是否存在
-	if ch, exists := m.subscribers[sub.Id]; exists {
+	if s, exists := m.subscribers[sub.Id]; exists {
 		// 关闭通道
-		close(ch)
+		close(s.ch)
 		// 从订阅者列表中移除
 		delete(m.subscribers, sub.Id)
 	}
 }
 
-// CreateLog 创建新日志
+// CreateLog 创建新日志，日志异步写入数据库，读取日志前会等待写入完成
 func (m *SqliteLogManager) CreateLog(log LogEntry) error {
+	// 写入和广播前统一脱敏
+	log.Command = Redact(log.Command)
+	log.Output = Redact(log.Output)
+
 	// 确保UpdatedAt有值
 	if log.UpdatedAt.IsZero() {
 		log.UpdatedAt = log.CreatedAt
 	}
-
-	// 检查日志是否已存在，如果存在则更新，否则插入
-	var count int
-	err := m.DB.QueryRow("SELECT COUNT(*) FROM logs WHERE id = ?", log.ID).Scan(&count)
-	if err != nil {
-		return err
+	// 未指定级别和类型时使用默认值
+	log.Level = EntryLevel(log)
+	if log.Type == "" {
+		log.Type = TypeSystem
 	}
 
-	if count > 0 {
-		// 更新现有日志
-		_, err = m.DB.Exec(
-			"UPDATE logs SET node_id = ?, node_name = ?, operation = ?, command = ?, output = ?, status = ?, created_at = ?, updated_at = ? WHERE id = ?",
-			log.NodeID, log.NodeName, log.Operation, log.Command, log.Output, log.Status, log.CreatedAt, log.UpdatedAt, log.ID,
-		)
-	} else {
-		// 插入新日志
-		_, err = m.DB.Exec(
-			"INSERT INTO logs (id, node_id, node_name, operation, command, output, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-			log.ID, log.NodeID, log.NodeName, log.Operation, log.Command, log.Output, log.Status, log.CreatedAt, log.UpdatedAt,
-		)
-	}
+	// 异步批量写入数据库，写入失败时只记录错误
+	err := m.writer.write(log)
 
 	// 日志缓冲逻辑：将日志添加到缓冲中，按节点+操作+命令分组
 	m.bufferMutex.Lock()
 	defer m.bufferMutex.Unlock()
 
 	// 生成缓冲键：节点ID + 操作 + 命令
-	bufferKey := fmt.Sprintf("%s_%s_%s", log.NodeID, log.Operation, log.Command)
+	bufferKey := fmt.Sprintf("%s_%s_%s_%s", log.JobID, log.NodeID, log.Operation, log.Command)
 
 	// 检查是否已存在该分组的日志
 	if existingLog, exists := m.logBuffer[bufferKey]; exists {
@@ -279,6 +342,13 @@ func (m *SqliteLogManager) CreateLog(log LogEntry) error {
 		}
 		// 更新时间
 		existingLog.UpdatedAt = log.UpdatedAt
+		if log.Progress != nil {
+			existingLog.Progress = log.Progress
+		}
+		// 合并后的级别取较严重的一个
+		if levels[log.Level] > levels[existingLog.Level] {
+			existingLog.Level = log.Level
+		}
 		// 更新回缓冲
 		m.logBuffer[bufferKey] = existingLog
 	} else {
@@ -291,7 +361,8 @@ func (m *SqliteLogManager) CreateLog(log LogEntry) error {
 
 // GetLogs 获取所有日志
 func (m *SqliteLogManager) GetLogs() ([]LogEntry, error) {
-	rows, err := m.DB.Query("SELECT id, node_id, node_name, operation, command, output, status, created_at, updated_at FROM logs ORDER BY created_at DESC")
+	m.writer.flush()
+	rows, err := m.DB.Query("SELECT id, node_id, node_name, operation, command, output, status, job_id, level, type, created_at, updated_at FROM logs ORDER BY created_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +373,7 @@ func (m *SqliteLogManager) GetLogs() ([]LogEntry, error) {
 		var log LogEntry
 		var updatedAt sql.NullTime
 		if err := rows.Scan(
-			&log.ID, &log.NodeID, &log.NodeName, &log.Operation, &log.Command, &log.Output, &log.Status, &log.CreatedAt, &updatedAt,
+			&log.ID, &log.NodeID, &log.NodeName, &log.Operation, &log.Command, &log.Output, &log.Status, &log.JobID, &log.Level, &log.Type, &log.CreatedAt, &updatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -319,8 +390,9 @@ func (m *SqliteLogManager) GetLogs() ([]LogEntry, error) {
 
 // GetLogsByNode 获取指定节点的日志
 func (m *SqliteLogManager) GetLogsByNode(nodeID string) ([]LogEntry, error) {
+	m.writer.flush()
 	rows, err := m.DB.Query(
-		"SELECT id, node_id, node_name, operation, command, output, status, created_at, updated_at FROM logs WHERE node_id = ? ORDER BY created_at DESC",
+		"SELECT id, node_id, node_name, operation, command, output, status, job_id, level, type, created_at, updated_at FROM logs WHERE node_id = ? ORDER BY created_at DESC",
 		nodeID,
 	)
 	if err != nil {
@@ -333,7 +405,7 @@ func (m *SqliteLogManager) GetLogsByNode(nodeID string) ([]LogEntry, error) {
 		var log LogEntry
 		var updatedAt sql.NullTime
 		if err := rows.Scan(
-			&log.ID, &log.NodeID, &log.NodeName, &log.Operation, &log.Command, &log.Output, &log.Status, &log.CreatedAt, &updatedAt,
+			&log.ID, &log.NodeID, &log.NodeName, &log.Operation, &log.Command, &log.Output, &log.Status, &log.JobID, &log.Level, &log.Type, &log.CreatedAt, &updatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -348,8 +420,96 @@ func (m *SqliteLogManager) GetLogsByNode(nodeID string) ([]LogEntry, error) {
 	return logs, nil
 }
 
+// ExportLogs 按条件逐条遍历日志，按创建时间正序，fn返回错误时停止遍历
+func (m *SqliteLogManager) ExportLogs(filter LogFilter, fn func(LogEntry) error) error {
+	m.writer.flush()
+	query := "SELECT id, node_id, node_name, operation, command, output, status, job_id, level, type, created_at, updated_at FROM logs WHERE 1 = 1"
+	var args []interface{}
+	if filter.NodeID != "" {
+		query += " AND node_id = ?"
+		args = append(args, filter.NodeID)
+	}
+	if filter.Operation != "" {
+		query += " AND operation = ?"
+		args = append(args, filter.Operation)
+	}
+	if filter.JobID != "" {
+		query += " AND job_id = ?"
+		args = append(args, filter.JobID)
+	}
+	if filter.Type != "" {
+		query += " AND type = ?"
+		args = append(args, filter.Type)
+	}
+	query += " ORDER BY created_at"
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log LogEntry
+		var updatedAt sql.NullTime
+		if err := rows.Scan(
+			&log.ID, &log.NodeID, &log.NodeName, &log.Operation, &log.Command, &log.Output, &log.Status, &log.JobID, &log.Level, &log.Type, &log.CreatedAt, &updatedAt,
+		); err != nil {
+			return err
+		}
+		if updatedAt.Valid {
+			log.UpdatedAt = updatedAt.Time
+		} else {
+			log.UpdatedAt = log.CreatedAt
+		}
+		if !filter.Match(log) {
+			continue
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // ClearLogs 清除所有日志
 func (m *SqliteLogManager) ClearLogs() error {
+	m.writer.flush()
 	_, err := m.DB.Exec("DELETE FROM logs")
 	return err
 }
+
+// DeleteLogs 删除指定ID的日志
+func (m *SqliteLogManager) DeleteLogs(ids []string) error {
+	m.writer.flush()
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare("DELETE FROM logs WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, id := range ids {
+		if _, err := stmt.Exec(id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Flush 等待已创建的日志全部写入数据库，部署步骤结束时调用，保证步骤的日志已经持久化
+func (m *SqliteLogManager) Flush() error {
+	m.writer.flush()
+	return nil
+}
+
+// Close 写入剩余的日志并推送缓冲中的日志，服务退出时调用
+func (m *SqliteLogManager) Close() error {
+	m.writer.close()
+	m.flushLogBuffer()
+	return nil
+}
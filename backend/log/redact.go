@@ -0,0 +1,66 @@
+package log
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedText 替换敏感信息的文本
+const RedactedText = "******"
+
+// minSecretLength 注册的敏感值的最小长度，过短的值容易误替换正常输出
+const minSecretLength = 4
+
+// secretPatterns 常见的敏感参数，第一个分组保留，其余部分替换为RedactedText
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(--token[= ]+)\S+`),
+	regexp.MustCompile(`(--certificate-key[= ]+)\S+`),
+	regexp.MustCompile(`(?i)(\b(?:password|passwd|secret|k3s_token|token)\s*[=:]\s*["']?)[^\s"',;]+`),
+	regexp.MustCompile(`(?i)(Authorization:\s*(?:Bearer|Basic)\s+)\S+`),
+	regexp.MustCompile(`(sshpass\s+-p\s*)(?:'[^']*'|"[^"]*"|\S+)`),
+}
+
+// privateKeyPattern PEM格式私钥
+var privateKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+
+var (
+	secretsMutex sync.RWMutex
+	secrets      = make(map[string]bool)
+	// secretList 按长度从长到短排序，避免较短的值先替换掉较长值的一部分
+	secretList []string
+)
+
+// RegisterSecret 登记需要脱敏的值，如节点密码，之后写入日志的输出中出现该值时会被替换
+func RegisterSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	if secrets[secret] {
+		return
+	}
+	secrets[secret] = true
+	secretList = append(secretList, secret)
+	sort.Slice(secretList, func(i, j int) bool { return len(secretList[i]) > len(secretList[j]) })
+}
+
+// Redact 替换文本中的私钥、token、密码参数和登记过的敏感值
+func Redact(text string) string {
+	if text == "" {
+		return text
+	}
+	text = privateKeyPattern.ReplaceAllString(text, "[REDACTED PRIVATE KEY]")
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllString(text, "${1}"+RedactedText)
+	}
+
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+	for _, secret := range secretList {
+		text = strings.ReplaceAll(text, secret, RedactedText)
+	}
+	return text
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(tb.TempDir(), "test.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testEntry(i int) LogEntry {
+	now := time.Now()
+	return LogEntry{
+		ID:        fmt.Sprintf("log-%d", i),
+		NodeID:    "node-1",
+		NodeName:  "node-1",
+		Operation: "deploy",
+		Command:   fmt.Sprintf("step %d", i),
+		Output:    "ok",
+		Status:    "success",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func countLogs(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM logs").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// TestCloseWritesQueuedLogs Close之前入队但尚未写入的日志在Close返回时已经持久化
+func TestCloseWritesQueuedLogs(t *testing.T) {
+	db := newTestDB(t)
+	manager, err := NewSqliteLogManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 少于一批且未到刷新间隔，日志仍在队列中
+	const queued = writerBatchSize - 1
+	for i := 0; i < queued; i++ {
+		if err := manager.CreateLog(testEntry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := manager.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if count := countLogs(t, db); count != queued {
+		t.Fatalf("%d logs persisted after Close, want %d", count, queued)
+	}
+
+	// 关闭后的日志同步写入
+	if err := manager.CreateLog(testEntry(queued)); err != nil {
+		t.Fatal(err)
+	}
+	if count := countLogs(t, db); count != queued+1 {
+		t.Fatalf("%d logs persisted after writing to a closed writer, want %d", count, queued+1)
+	}
+	// 重复关闭不阻塞
+	manager.Close()
+}
+
+func TestFlushWritesQueuedLogs(t *testing.T) {
+	db := newTestDB(t)
+	manager, err := NewSqliteLogManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Close()
+
+	for i := 0; i < writerBatchSize+10; i++ {
+		if err := manager.CreateLog(testEntry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manager.Flush()
+	if count := countLogs(t, db); count != writerBatchSize+10 {
+		t.Fatalf("%d logs persisted after Flush, want %d", count, writerBatchSize+10)
+	}
+}
+
+// BenchmarkWriter 异步批量写入日志
+func BenchmarkWriter(b *testing.B) {
+	db := newTestDB(b)
+	if _, err := NewSqliteLogManager(db); err != nil {
+		b.Fatal(err)
+	}
+	w := newLogWriter(db)
+	defer w.close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := w.write(testEntry(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	w.flush()
+}
+
+// BenchmarkWriterSync 每条日志单独一个事务同步写入，作为BenchmarkWriter的对照
+func BenchmarkWriterSync(b *testing.B) {
+	db := newTestDB(b)
+	if _, err := NewSqliteLogManager(db); err != nil {
+		b.Fatal(err)
+	}
+	w := &logWriter{db: db}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := w.writeBatch([]LogEntry{testEntry(i)}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default 安装器默认的指标注册表
+var Default = NewRegistry()
+
+// 安装器自身的监控指标
+var (
+	// APIRequestsTotal API请求总数
+	APIRequestsTotal = Default.NewCounterVec("k8s_installer_api_requests_total", "Total number of API requests.", "method", "path", "status")
+	// APIRequestDuration API请求耗时
+	APIRequestDuration = Default.NewHistogramVec("k8s_installer_api_request_duration_seconds", "API request latency in seconds.", DefaultBuckets, "method", "path")
+	// SSHCommandDuration SSH命令执行耗时
+	SSHCommandDuration = Default.NewHistogramVec("k8s_installer_ssh_command_duration_seconds", "SSH command execution duration in seconds.", LongBuckets, "status")
+	// SSHActiveConnections 当前活跃的SSH连接数
+	SSHActiveConnections = Default.NewGauge("k8s_installer_ssh_active_connections", "Number of currently open SSH connections.")
+	// DeployStepDuration 部署步骤耗时
+	DeployStepDuration = Default.NewHistogramVec("k8s_installer_deploy_step_duration_seconds", "Deployment step duration in seconds.", LongBuckets, "step")
+	// DeploymentsTotal 按结果（success、failed）统计的部署总数
+	DeploymentsTotal = Default.NewCounterVec("k8s_installer_deployments_total", "Total number of cluster deployments by result.", "result")
+)
+
+// GinMiddleware 记录API请求数量和耗时的中间件
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// 使用路由模板作为path标签，避免节点ID等参数导致标签爆炸
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		APIRequestsTotal.Inc(c.Request.Method, path, strconv.Itoa(c.Writer.Status()))
+		APIRequestDuration.ObserveDuration(time.Since(start), c.Request.Method, path)
+	}
+}
+
+// Handler 输出Prometheus文本格式指标的处理函数
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var buf bytes.Buffer
+		Default.WriteText(&buf)
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+	}
+}
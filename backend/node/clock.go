@@ -0,0 +1,133 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxClockSkew 部署前允许的节点间最大时钟偏差
+const DefaultMaxClockSkew = 500 * time.Millisecond
+
+// NodeClock 单个节点的时钟信息
+type NodeClock struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	// OffsetMs 节点时间相对安装器时间的偏差，正数表示节点时间较快
+	OffsetMs int64 `json:"offsetMs"`
+	// UncertaintyMs 测量误差，为SSH命令往返时间的一半
+	UncertaintyMs   int64  `json:"uncertaintyMs"`
+	Timezone        string `json:"timezone"`
+	NTPSynchronized bool   `json:"ntpSynchronized"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ClockSkewReport 节点间时钟偏差检查结果
+type ClockSkewReport struct {
+	Passed bool        `json:"passed"`
+	Nodes  []NodeClock `json:"nodes"`
+	// MaxSkewMs 所有节点中最快和最慢节点的时间差
+	MaxSkewMs       int64    `json:"maxSkewMs"`
+	ThresholdMs     int64    `json:"thresholdMs"`
+	Message         string   `json:"message"`
+	MixedTimezones  bool     `json:"mixedTimezones"`
+	UnsyncedNodeIDs []string `json:"unsyncedNodeIds,omitempty"`
+}
+
+// clockCmd 输出纳秒时间戳、时区和NTP同步状态
+const clockCmd = `date +%s%N; timedatectl show -p Timezone --value 2>/dev/null || date +%Z; timedatectl show -p NTPSynchronized --value 2>/dev/null || echo unknown`
+
+// CheckClockSkew 并发读取所有节点的时间，计算节点间的最大时钟偏差，maxSkew为0时使用DefaultMaxClockSkew
+func CheckClockSkew(nodes []Node, maxSkew time.Duration) ClockSkewReport {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxClockSkew
+	}
+
+	clocks := make([]NodeClock, len(nodes))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, DefaultExecConcurrency)
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			clocks[i] = readNodeClock(n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	report := ClockSkewReport{Nodes: clocks, ThresholdMs: maxSkew.Milliseconds()}
+	var minOffset, maxOffset int64
+	measured := 0
+	timezones := make(map[string]bool)
+	var failed []string
+	for _, clock := range clocks {
+		if clock.Error != "" {
+			failed = append(failed, clock.NodeName)
+			continue
+		}
+		if measured == 0 || clock.OffsetMs < minOffset {
+			minOffset = clock.OffsetMs
+		}
+		if measured == 0 || clock.OffsetMs > maxOffset {
+			maxOffset = clock.OffsetMs
+		}
+		measured++
+		timezones[clock.Timezone] = true
+		if !clock.NTPSynchronized {
+			report.UnsyncedNodeIDs = append(report.UnsyncedNodeIDs, clock.NodeID)
+		}
+	}
+	report.MaxSkewMs = maxOffset - minOffset
+	report.MixedTimezones = len(timezones) > 1
+
+	switch {
+	case len(failed) > 0:
+		report.Message = fmt.Sprintf("无法读取节点时间: %s", strings.Join(failed, ", "))
+	case report.MaxSkewMs > report.ThresholdMs:
+		report.Message = fmt.Sprintf("节点间时钟偏差 %dms 超过阈值 %dms，请检查NTP配置", report.MaxSkewMs, report.ThresholdMs)
+	default:
+		report.Passed = true
+		report.Message = fmt.Sprintf("节点间时钟偏差 %dms，在阈值 %dms 以内", report.MaxSkewMs, report.ThresholdMs)
+	}
+	return report
+}
+
+// readNodeClock 读取节点时间，以命令往返时间的中点作为安装器的参考时间
+func readNodeClock(n Node) NodeClock {
+	clock := NodeClock{NodeID: n.ID, NodeName: n.Name}
+	client, err := Connect(n)
+	if err != nil {
+		clock.Error = fmt.Sprintf("failed to connect node: %v", err)
+		return clock
+	}
+	defer client.Close()
+
+	start := time.Now()
+	output, err := client.RunCommandSilent(clockCmd)
+	end := time.Now()
+	if err != nil {
+		clock.Error = fmt.Sprintf("failed to read clock: %v", err)
+		return clock
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	remoteNanos, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		clock.Error = fmt.Sprintf("invalid date output: %s", lines[0])
+		return clock
+	}
+	reference := start.Add(end.Sub(start) / 2)
+	clock.OffsetMs = time.Unix(0, remoteNanos).Sub(reference).Milliseconds()
+	clock.UncertaintyMs = end.Sub(start).Milliseconds() / 2
+	if len(lines) > 1 {
+		clock.Timezone = strings.TrimSpace(lines[1])
+	}
+	if len(lines) > 2 {
+		clock.NTPSynchronized = strings.TrimSpace(lines[2]) == "yes"
+	}
+	return clock
+}
@@ -0,0 +1,219 @@
+package node
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/ssh"
+)
+
+// 发行版家族，同一家族使用相同的包管理器和默认脚本
+const (
+	DistroFamilyDebian = "debian"
+	DistroFamilyRHEL   = "rhel"
+	DistroFamilySUSE   = "suse"
+	DistroFamilyAmazon = "amazon"
+)
+
+// 节点使用的包管理器
+const (
+	PackageManagerApt    = "apt"
+	PackageManagerDnf    = "dnf"
+	PackageManagerYum    = "yum"
+	PackageManagerZypper = "zypper"
+)
+
+// DetectOSCmd 检测节点的发行版、版本、架构和包管理器，每行输出一个KEY=VALUE。
+// 没有/etc/os-release的旧系统从发行版的release文件读取
+const DetectOSCmd = `if [ -f /etc/os-release ]; then
+	. /etc/os-release
+	echo "ID=$ID"
+	echo "VERSION_ID=$VERSION_ID"
+	echo "ID_LIKE=$ID_LIKE"
+	echo "PRETTY_NAME=$PRETTY_NAME"
+elif [ -f /etc/SuSE-release ]; then
+	echo "ID=sles"
+	echo "VERSION_ID=$(awk -F' = ' '$1 == "VERSION" {print $2}' /etc/SuSE-release)"
+elif [ -f /etc/system-release ] && grep -q "Amazon Linux" /etc/system-release; then
+	echo "ID=amzn"
+	echo "VERSION_ID=$(grep -o '[0-9]\+' /etc/system-release | head -n 1)"
+elif [ -f /etc/centos-release ]; then
+	echo "ID=centos"
+	echo "VERSION_ID=$(grep -o '[0-9]\+\(\.[0-9]\+\)\?' /etc/centos-release | head -n 1)"
+elif [ -f /etc/redhat-release ]; then
+	echo "ID=rhel"
+	echo "VERSION_ID=$(grep -o '[0-9]\+\(\.[0-9]\+\)\?' /etc/redhat-release | head -n 1)"
+elif [ -f /etc/debian_version ]; then
+	echo "ID=debian"
+	echo "VERSION_ID=$(cut -d. -f1 /etc/debian_version)"
+else
+	echo "ID=unknown"
+fi
+echo "ARCH=$(uname -m)"
+for pm in apt-get dnf yum zypper; do
+	if command -v "$pm" >/dev/null 2>&1; then
+		echo "PACKAGE_MANAGER=${pm%-get}"
+		break
+	fi
+done`
+
+// OSInfo 节点的操作系统信息，由DetectOS检测并缓存在节点记录中，部署步骤使用缓存的结果
+type OSInfo struct {
+	// Distro os-release的ID，如ubuntu、rocky，无法识别时为unknown
+	Distro string `json:"distro"`
+	// Version os-release的VERSION_ID，如22.04、9.3
+	Version string `json:"version,omitempty"`
+	IDLike  string `json:"idLike,omitempty"`
+	// Family 发行版家族，见DistroFamily，无法识别时为空
+	Family     string `json:"family,omitempty"`
+	PrettyName string `json:"prettyName,omitempty"`
+	// Arch uname -m的输出，如x86_64、aarch64
+	Arch string `json:"arch,omitempty"`
+	// PackageManager 节点上可用的包管理器：apt、dnf、yum或zypper
+	PackageManager string    `json:"packageManager,omitempty"`
+	DetectedAt     time.Time `json:"detectedAt"`
+}
+
+// ParseOSInfo 解析DetectOSCmd的输出
+func ParseOSInfo(output string) OSInfo {
+	info := OSInfo{DetectedAt: time.Now()}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch key {
+		case "ID":
+			info.Distro = value
+		case "VERSION_ID":
+			info.Version = value
+		case "ID_LIKE":
+			info.IDLike = value
+		case "PRETTY_NAME":
+			info.PrettyName = value
+		case "ARCH":
+			info.Arch = value
+		case "PACKAGE_MANAGER":
+			info.PackageManager = value
+		}
+	}
+	if info.Distro == "" {
+		info.Distro = "unknown"
+	}
+	info.Family = DistroFamily(info.Distro, info.IDLike)
+	return info
+}
+
+// DetectOS 在节点上检测操作系统信息
+func DetectOS(client ssh.Runner) (OSInfo, error) {
+	output, err := client.RunCommandSilent(DetectOSCmd)
+	if err != nil {
+		return OSInfo{}, fmt.Errorf("detect os: %v", err)
+	}
+	return ParseOSInfo(output), nil
+}
+
+// KubeArch 返回Kubernetes使用的架构名称，如x86_64为amd64，aarch64为arm64
+func (i OSInfo) KubeArch() string {
+	switch i.Arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	}
+	return i.Arch
+}
+
+// DistroFamily 根据os-release的ID和ID_LIKE判断发行版家族，无法识别时返回空字符串
+func DistroFamily(id, idLike string) string {
+	switch id {
+	case "ubuntu", "debian":
+		return DistroFamilyDebian
+	case "centos", "rhel", "rocky", "almalinux", "alma":
+		return DistroFamilyRHEL
+	case "opensuse", "opensuse-leap", "opensuse-tumbleweed", "sles", "sles_sap", "sled":
+		return DistroFamilySUSE
+	case "amzn":
+		return DistroFamilyAmazon
+	}
+	for _, like := range strings.Fields(idLike) {
+		switch like {
+		case "debian", "ubuntu":
+			return DistroFamilyDebian
+		case "rhel", "centos", "fedora":
+			return DistroFamilyRHEL
+		case "suse", "opensuse":
+			return DistroFamilySUSE
+		}
+	}
+	return ""
+}
+
+// migrateNodeOSInfo 添加缓存操作系统信息的列
+func migrateNodeOSInfo(db *sql.DB) error {
+	var columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('nodes') WHERE name = 'os_info'").Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check os_info column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN os_info TEXT"); err != nil {
+			return fmt.Errorf("failed to add os_info column: %v", err)
+		}
+	}
+	return nil
+}
+
+// encodeOSInfo 将操作系统信息编码为JSON列的值，nil时为空字符串
+func encodeOSInfo(info *OSInfo) string {
+	if info == nil {
+		return ""
+	}
+	data, _ := json.Marshal(info)
+	return string(data)
+}
+
+// decodeOSInfo 解析JSON列中的操作系统信息，为空或无效时返回nil
+func decodeOSInfo(value string) *OSInfo {
+	if value == "" {
+		return nil
+	}
+	var info OSInfo
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		return nil
+	}
+	return &info
+}
+
+// SetNodeOS 缓存节点的操作系统信息，同时更新os列
+func (m *SqliteNodeManager) SetNodeOS(id string, info OSInfo) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result, err := m.db.Exec("UPDATE nodes SET os = ?, os_info = ?, updated_at = ? WHERE id = ?", info.Distro, encodeOSInfo(&info), time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update node: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("node not found")
+	}
+	return nil
+}
+
+// NodeOS 返回节点缓存的操作系统信息，没有缓存时通过client检测并缓存
+func (m *SqliteNodeManager) NodeOS(client ssh.Runner, n Node) (OSInfo, error) {
+	if n.OSInfo != nil {
+		return *n.OSInfo, nil
+	}
+	info, err := DetectOS(client)
+	if err != nil {
+		return OSInfo{}, err
+	}
+	if err := m.SetNodeOS(n.ID, info); err != nil {
+		fmt.Printf("缓存节点 %s 的操作系统信息失败: %v\n", n.Name, err)
+	}
+	return info, nil
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"k8s-installer/ssh"
+)
+
+// rockyOSOutput Rocky Linux 9节点上DetectOSCmd的输出
+const rockyOSOutput = `ID="rocky"
+VERSION_ID="9.3"
+ID_LIKE="rhel centos fedora"
+PRETTY_NAME="Rocky Linux 9.3 (Blue Onyx)"
+ARCH=x86_64
+PACKAGE_MANAGER=dnf
+`
+
+func TestDetectOS(t *testing.T) {
+	rec := ssh.NewRecorder().On("os-release", rockyOSOutput, nil)
+	info, err := DetectOS(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Distro != "rocky" || info.Version != "9.3" || info.Family != DistroFamilyRHEL {
+		t.Fatalf("DetectOS() = %+v, want rocky 9.3 in the rhel family", info)
+	}
+	if info.PackageManager != "dnf" || info.KubeArch() != "amd64" {
+		t.Fatalf("DetectOS() = %+v, want dnf on amd64", info)
+	}
+	if commands := rec.Commands(); len(commands) != 1 || commands[0] != DetectOSCmd {
+		t.Fatalf("commands = %q, want only DetectOSCmd", commands)
+	}
+}
+
+func TestDetectOSFailure(t *testing.T) {
+	rec := ssh.NewRecorder().On("os-release", "", errors.New("connection reset"))
+	if _, err := DetectOS(rec); err == nil {
+		t.Fatal("expected an error when the command fails")
+	}
+}
+
+func TestNodeOSCachesDetection(t *testing.T) {
+	m, err := NewSqliteNodeManager(filepath.Join(t.TempDir(), "nodes.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := m.CreateNode(Node{Name: "node-1", IP: "10.0.0.10", Port: 22, Username: "root", Password: "secret", NodeType: NodeTypeWorker})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := ssh.NewRecorder().On("os-release", rockyOSOutput, nil)
+	if _, err := m.NodeOS(rec, *n); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := m.GetNode(n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.OSInfo == nil || cached.OSInfo.Distro != "rocky" {
+		t.Fatalf("detected OS was not cached: %+v", cached.OSInfo)
+	}
+
+	// 已缓存时不再在节点上执行命令
+	if _, err := m.NodeOS(rec, *cached); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rec.Commands()); n != 1 {
+		t.Fatalf("ran %d detection commands, want 1", n)
+	}
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DuplicateNodeError 节点的IP+端口或名称与已有节点重复
+type DuplicateNodeError struct {
+	// Field 重复的字段：ip或name
+	Field        string
+	Value        string
+	ExistingID   string
+	ExistingName string
+}
+
+func (e *DuplicateNodeError) Error() string {
+	if e.ExistingID == "" {
+		return fmt.Sprintf("node %s %s already exists", e.Field, e.Value)
+	}
+	return fmt.Sprintf("node %s %s already used by node %s (%s)", e.Field, e.Value, e.ExistingName, e.ExistingID)
+}
+
+// WriteOptions 创建和更新节点的选项
+type WriteOptions struct {
+	// AllowDuplicate 允许与已有节点的IP+端口或名称重复，用于有意重复的场景（如同一主机上的多个SSH端口映射）
+	AllowDuplicate bool
+}
+
+// migrateNodeUniqueness 添加allow_duplicate列并创建IP+端口和名称的唯一索引，有意重复的节点不受唯一约束
+func migrateNodeUniqueness(db *sql.DB) error {
+	var columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('nodes') WHERE name = 'allow_duplicate'").Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check allow_duplicate column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN allow_duplicate INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to add allow_duplicate column: %v", err)
+		}
+	}
+
+	indexes := []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_ip_port ON nodes(ip, port) WHERE allow_duplicate = 0",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_name ON nodes(name) WHERE allow_duplicate = 0",
+	}
+	for _, index := range indexes {
+		if _, err := db.Exec(index); err != nil {
+			// 已有数据中存在重复节点时无法创建索引，仍由API层检查新的重复
+			fmt.Printf("Warning: failed to create unique node index, existing nodes contain duplicates: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// findDuplicate 查找与节点IP+端口或名称重复的其他节点，excludeID为更新时节点自身的ID
+func (m *SqliteNodeManager) findDuplicate(node Node, excludeID string) error {
+	var id, name string
+	err := m.db.QueryRow("SELECT id, name FROM nodes WHERE ip = ? AND port = ? AND id != ? AND allow_duplicate = 0 LIMIT 1",
+		node.IP, node.Port, excludeID).Scan(&id, &name)
+	if err == nil {
+		return &DuplicateNodeError{Field: "ip", Value: fmt.Sprintf("%s:%d", node.IP, node.Port), ExistingID: id, ExistingName: name}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check duplicate node: %v", err)
+	}
+
+	err = m.db.QueryRow("SELECT id, name FROM nodes WHERE name = ? AND id != ? AND allow_duplicate = 0 LIMIT 1",
+		node.Name, excludeID).Scan(&id, &name)
+	if err == nil {
+		return &DuplicateNodeError{Field: "name", Value: node.Name, ExistingID: id, ExistingName: name}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check duplicate node: %v", err)
+	}
+	return nil
+}
+
+// constraintDuplicate 将IP+端口和名称唯一索引的冲突转换为*DuplicateNodeError，其他错误（包括主键冲突）返回nil
+func constraintDuplicate(err error, node Node) error {
+	if err == nil || !strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return nil
+	}
+	switch {
+	case strings.Contains(err.Error(), "nodes.ip, nodes.port"):
+		return &DuplicateNodeError{Field: "ip", Value: fmt.Sprintf("%s:%d", node.IP, node.Port)}
+	case strings.Contains(err.Error(), "nodes.name"):
+		return &DuplicateNodeError{Field: "name", Value: node.Name}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/log"
+)
+
+// 批量执行命令的默认配置
+const (
+	DefaultExecConcurrency = 5
+	MaxExecConcurrency     = 20
+	DefaultExecTimeout     = 10 * time.Minute
+	// execAuditOutputLimit 审计日志中保存的输出长度上限
+	execAuditOutputLimit = 64 * 1024
+)
+
+// ExecOptions 命令执行选项
+type ExecOptions struct {
+	Timeout     time.Duration
+	Concurrency int
+	// Source 命令来源，记录在审计日志中
+	Source string
+}
+
+// ExecResult 单个节点的命令执行结果
+type ExecResult struct {
+	NodeID     string `json:"nodeId"`
+	NodeName   string `json:"nodeName"`
+	Success    bool   `json:"success"`
+	Output     string `json:"output"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// ExecOutputHandler 实时输出回调
+type ExecOutputHandler func(n Node, line string)
+
+// ExecOnNodes 在多个节点上并发执行命令，每个节点的执行结果都会写入审计日志
+func (m *SqliteNodeManager) ExecOnNodes(ctx context.Context, nodes []Node, command string, opts ExecOptions, onOutput ExecOutputHandler) []ExecResult {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultExecTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultExecConcurrency
+	}
+	if opts.Concurrency > MaxExecConcurrency {
+		opts.Concurrency = MaxExecConcurrency
+	}
+
+	results := make([]ExecResult, len(nodes))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n Node) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = ExecResult{NodeID: n.ID, NodeName: n.Name, Error: ctx.Err().Error()}
+				return
+			}
+			results[i] = m.execOnNode(ctx, n, command, opts, onOutput)
+		}(i, n)
+	}
+	wg.Wait()
+	return results
+}
+
+// execOnNode 在单个节点上执行命令并记录审计日志
+func (m *SqliteNodeManager) execOnNode(ctx context.Context, n Node, command string, opts ExecOptions, onOutput ExecOutputHandler) ExecResult {
+	result := ExecResult{NodeID: n.ID, NodeName: n.Name}
+	start := time.Now()
+
+	var output strings.Builder
+	err := func() error {
+		client, err := Connect(n)
+		if err != nil {
+			return fmt.Errorf("failed to connect node: %v", err)
+		}
+		defer client.Close()
+
+		client.SetContext(ctx)
+		client.SetCommandTimeout(opts.Timeout)
+		_, err = client.RunCommandWithOutput(command, func(line string) {
+			output.WriteString(line + "\n")
+			if onOutput != nil {
+				onOutput(n, line)
+			}
+		})
+		return err
+	}()
+
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.Output = output.String()
+	result.Success = err == nil
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	m.auditExec(n, command, opts.Source, result)
+	return result
+}
+
+// auditExec 写入命令执行审计日志
+func (m *SqliteNodeManager) auditExec(n Node, command, source string, result ExecResult) {
+	if m.logManager == nil {
+		return
+	}
+
+	auditOutput := result.Output
+	if len(auditOutput) > execAuditOutputLimit {
+		auditOutput = auditOutput[len(auditOutput)-execAuditOutputLimit:]
+	}
+	if source != "" {
+		auditOutput = fmt.Sprintf("来源: %s\n%s", source, auditOutput)
+	}
+	status := "success"
+	if !result.Success {
+		status = "failed"
+		auditOutput += "\n错误: " + result.Error
+	}
+
+	now := time.Now()
+	m.logManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Operation: "Exec",
+		Command:   command,
+		Output:    auditOutput,
+		Status:    status,
+		Type:      log.TypeScriptOutput,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
@@ -114,7 +114,7 @@ func (m *FileNodeManager) CreateNode(node Node) (*Node, error) {
 
 	// 生成ID
 	if node.ID == "" {
-		node.ID = time.Now().Format("20060102150405")
+		node.ID = fmt.Sprintf("%d", time.Now().UnixNano())
 	}
 
 	// 设置默认值
@@ -132,6 +132,7 @@ func (m *FileNodeManager) CreateNode(node Node) (*Node, error) {
 
 	node.CreatedAt = time.Now()
 	node.UpdatedAt = time.Now()
+	syncJoinInfo(&node)
 
 	// 保存节点
 	m.nodes[node.ID] = node
@@ -158,6 +159,7 @@ func (m *FileNodeManager) UpdateNode(id string, node Node) (*Node, error) {
 	// 更新节点信息
 	node.ID = id
 	node.UpdatedAt = time.Now()
+	syncJoinInfo(&node)
 	m.nodes[id] = node
 
 	// 保存到文件
@@ -201,11 +203,13 @@ func (m *FileNodeManager) TestConnection(id string) (bool, error) {
 
 	// 测试SSH连接
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -253,11 +257,13 @@ func (m *FileNodeManager) ConfigureSSHSettings(id string) error {
 
 	// 创建SSH客户端
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -324,19 +330,19 @@ func (m *FileNodeManager) ConfigureSSHPasswdless() error {
 
 	// 1. 收集所有节点的公钥
 	nodePublicKeys := make(map[string]string)
-	nodeIPMap := make(map[string]string) // 节点名称到IP的映射
 
 	for _, node := range allNodes {
 		fmt.Printf("获取节点 %s (%s) 的公钥...\n", node.Name, node.IP)
-		nodeIPMap[node.Name] = node.IP
 
 		// 创建SSH客户端
 		sshConfig := ssh.SSHConfig{
-			Host:       node.IP,
-			Port:       node.Port,
-			Username:   node.Username,
-			Password:   node.Password,
-			PrivateKey: node.PrivateKey,
+			Host:          node.IP,
+			Port:          node.Port,
+			Username:      node.Username,
+			Password:      node.Password,
+			PrivateKey:    node.PrivateKey,
+			PasswordRef:   node.PasswordRef,
+			PrivateKeyRef: node.PrivateKeyRef,
 		}
 
 		client, err := ssh.NewSSHClient(sshConfig)
@@ -377,11 +383,13 @@ func (m *FileNodeManager) ConfigureSSHPasswdless() error {
 		fmt.Printf("\n配置节点: %s (%s)\n", targetNode.Name, targetNode.IP)
 		// 创建SSH客户端
 		sshConfig := ssh.SSHConfig{
-			Host:       targetNode.IP,
-			Port:       targetNode.Port,
-			Username:   targetNode.Username,
-			Password:   targetNode.Password,
-			PrivateKey: targetNode.PrivateKey,
+			Host:          targetNode.IP,
+			Port:          targetNode.Port,
+			Username:      targetNode.Username,
+			Password:      targetNode.Password,
+			PrivateKey:    targetNode.PrivateKey,
+			PasswordRef:   targetNode.PasswordRef,
+			PrivateKeyRef: targetNode.PrivateKeyRef,
 		}
 
 		client, err := ssh.NewSSHClient(sshConfig)
@@ -400,101 +408,7 @@ func (m *FileNodeManager) ConfigureSSHPasswdless() error {
 
 		// 2. 更新hosts文件，添加所有节点的名称和IP
 		fmt.Printf("  2. 更新hosts文件，添加所有节点的名称和IP...\n")
-		// 构建hosts文件内容，包含所有节点的IP和名称对应关系
-		hostsContent := "# Kubernetes集群节点解析\n"
-		for nodeName, nodeIP := range nodeIPMap {
-			hostsContent += fmt.Sprintf("%s %s\n", nodeIP, nodeName)
-		}
-
-		// 显示构建的hosts文件内容，用于调试
-		fmt.Printf("  构建的hosts文件内容: %s\n", hostsContent)
-
-		// 更新hosts文件
-		updateCmd := fmt.Sprintf(`
-# 备份原有的hosts文件
-if [ -f /etc/hosts ]; then
-    sudo cp /etc/hosts /etc/hosts.bak
-    echo "已备份原有的hosts文件到/etc/hosts.bak"
-fi
-
-# 保留原有的hosts文件内容（除了已存在的Kubernetes集群节点解析）
-echo "正在处理hosts文件，保留默认条目..."
-if [ -f /etc/hosts ]; then
-    # 移除已存在的Kubernetes集群节点解析
-    if grep -q "Kubernetes集群节点解析" /etc/hosts; then
-        echo "发现已存在的Kubernetes集群节点解析，正在移除..."
-        sudo sed -i '/Kubernetes集群节点解析/,$d' /etc/hosts
-    fi
-    # 将新的hosts内容追加到文件末尾
-    echo "正在将新的Kubernetes集群节点解析追加到hosts文件..."
-    sudo bash -c "cat >> /etc/hosts << 'EOF'
-%s
-EOF"
-else
-    # 如果hosts文件不存在，直接使用新文件
-    echo "hosts文件不存在，直接使用新文件..."
-    sudo bash -c "cat > /etc/hosts << 'EOF'
-%s
-EOF"
-fi
-
-# 设置正确的权限
-sudo chmod 644 /etc/hosts
-echo "设置/etc/hosts文件权限为644"
-
-# 刷新DNS缓存，使hosts文件生效
-if command -v systemctl &> /dev/null; then
-    # 对于使用systemd的系统，重启nscd服务（如果存在）
-    if systemctl list-units --type=service | grep -q nscd; then
-        echo "重启nscd服务，刷新DNS缓存..."
-        sudo systemctl restart nscd
-    fi
-    # 重启systemd-resolved服务（如果存在）
-    if systemctl list-units --type=service | grep -q systemd-resolved; then
-        echo "重启systemd-resolved服务，刷新DNS缓存..."
-        sudo systemctl restart systemd-resolved
-    fi
-else
-    # 对于其他系统，使用nscd命令（如果存在）
-    if command -v nscd &> /dev/null; then
-        echo "刷新nscd缓存..."
-        sudo nscd -i hosts
-    fi
-fi
-
-# 等待2秒，确保DNS缓存刷新完成
-sleep 2
-
-# 验证主机名解析是否生效
-echo "验证主机名解析..."
-resolv_success=true
-for host in $(cat /etc/hosts | grep -v '^#' | grep -v '^$' | awk '{print $2}'); do
-    if [ "$host" != "localhost" ] && [ "$host" != "localhost.localdomain" ]; then
-        echo "测试解析主机名: $host"
-        ping -c 1 $host > /dev/null 2>&1
-        if [ $? -eq 0 ]; then
-            echo "✓ 主机名 $host 解析成功"
-        else
-            echo "✗ 主机名 $host 解析失败"
-            resolv_success=false
-        fi
-    fi
-done
-
-# 显示更新后的hosts文件内容
-echo "=== 更新后的hosts文件内容 ==="
-sudo tail -20 /etc/hosts
-echo "=== 内容结束 ==="
-
-# 最终验证
-if grep -q "Kubernetes集群节点解析" /etc/hosts; then
-    echo "✓ hosts文件更新成功，包含Kubernetes集群节点解析"
-else
-    echo "✗ hosts文件更新失败，未找到Kubernetes集群节点解析"
-fi
-`, hostsContent, hostsContent)
-
-		_, err = client.RunCommand(updateCmd)
+		_, err = client.RunCommand(HostsUpdateCmd(GenerateHostsBlock(allNodes)))
 		if err != nil {
 			client.Close()
 			return fmt.Errorf("failed to update hosts file for node %s: %v", targetNode.Name, err)
@@ -559,11 +473,13 @@ fi
 
 			// 创建SSH客户端
 			sshConfig := ssh.SSHConfig{
-				Host:       sourceNode.IP,
-				Port:       sourceNode.Port,
-				Username:   sourceNode.Username,
-				Password:   sourceNode.Password,
-				PrivateKey: sourceNode.PrivateKey,
+				Host:          sourceNode.IP,
+				Port:          sourceNode.Port,
+				Username:      sourceNode.Username,
+				Password:      sourceNode.Password,
+				PrivateKey:    sourceNode.PrivateKey,
+				PasswordRef:   sourceNode.PasswordRef,
+				PrivateKeyRef: sourceNode.PrivateKeyRef,
 			}
 
 			client, err := ssh.NewSSHClient(sshConfig)
@@ -612,56 +528,31 @@ fi
 }
 
 // deployMasterNode 部署主节点
-func (m *FileNodeManager) deployMasterNode(client *ssh.SSHClient) error {
+func (m *FileNodeManager) deployMasterNode(client ssh.Runner) error {
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
-	// 2. 设置容器运行时（默认使用containerd，生产环境推荐）
-	containerRuntime := "containerd"
-	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
+	// 2. 系统准备（禁用swap、内核参数等），通过脚本管理器解析
+	systemPrepCmd, err := systemPrepScript(m.scriptManager, distro)
+	if err != nil {
 		return err
 	}
-
-	// 3. 安装kubeadm, kubelet和kubectl
-	if err := m.installKubernetesComponents(client, distro); err != nil {
+	if _, err := client.RunCommand(systemPrepCmd); err != nil {
 		return err
 	}
 
-	// 4. 禁用swap
-	swapCmd := `
-swapoff -a
-sed -i '/ swap / s/^\(.*\)$/#\1/g' /etc/fstab
-`
-	if _, err := client.RunCommand(swapCmd); err != nil {
+	// 3. 设置容器运行时（默认使用containerd，生产环境推荐）
+	containerRuntime := "containerd"
+	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
 		return err
 	}
 
-	// 5. 设置内核参数（生产环境推荐配置）
-	kernelCmd := `
-cat <<EOF | sudo tee /etc/sysctl.d/k8s.conf
-net.bridge.bridge-nf-call-iptables  = 1
-net.bridge.bridge-nf-call-ip6tables = 1
-net.ipv4.ip_forward                 = 1
-vm.swappiness                       = 0
-vm.overcommit_memory                = 1
-kernel.panic                        = 10
-kernel.panic_on_oops                = 1
-EOF
-sysctl --system
-modprobe br_netfilter
-modprobe overlay
-`
-	if _, err := client.RunCommand(kernelCmd); err != nil {
+	// 4. 安装kubeadm, kubelet和kubectl
+	if err := m.installKubernetesComponents(client, distro, ""); err != nil {
 		return err
 	}
 
@@ -669,56 +560,31 @@ modprobe overlay
 }
 
 // deployWorkerNode 部署工作节点
-func (m *FileNodeManager) deployWorkerNode(client *ssh.SSHClient) error {
+func (m *FileNodeManager) deployWorkerNode(client ssh.Runner) error {
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
-	// 2. 设置容器运行时
-	containerRuntime := "containerd"
-	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
+	// 2. 系统准备（禁用swap、内核参数等），通过脚本管理器解析
+	systemPrepCmd, err := systemPrepScript(m.scriptManager, distro)
+	if err != nil {
 		return err
 	}
-
-	// 3. 安装kubeadm和kubelet
-	if err := m.installKubernetesComponents(client, distro); err != nil {
+	if _, err := client.RunCommand(systemPrepCmd); err != nil {
 		return err
 	}
 
-	// 4. 禁用swap
-	swapCmd := `
-swapoff -a
-sed -i '/ swap / s/^\(.*\)$/#\1/g' /etc/fstab
-`
-	if _, err := client.RunCommand(swapCmd); err != nil {
+	// 3. 设置容器运行时
+	containerRuntime := "containerd"
+	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
 		return err
 	}
 
-	// 5. 设置内核参数
-	kernelCmd := `
-cat <<EOF | sudo tee /etc/sysctl.d/k8s.conf
-net.bridge.bridge-nf-call-iptables  = 1
-net.bridge.bridge-nf-call-ip6tables = 1
-net.ipv4.ip_forward                 = 1
-vm.swappiness                       = 0
-vm.overcommit_memory                = 1
-kernel.panic                        = 10
-kernel.panic_on_oops                = 1
-EOF
-sysctl --system
-modprobe br_netfilter
-modprobe overlay
-`
-	if _, err := client.RunCommand(kernelCmd); err != nil {
+	// 4. 安装kubeadm, kubelet和kubectl
+	if err := m.installKubernetesComponents(client, distro, ""); err != nil {
 		return err
 	}
 
@@ -726,49 +592,17 @@ modprobe overlay
 }
 
 // installContainerRuntime 安装容器运行时
-func (m *FileNodeManager) installContainerRuntime(client *ssh.SSHClient, distro, runtime, version string) error {
-	var cmd string
-	// 只支持containerd
-	switch distro {
-	case "ubuntu", "debian":
-		cmd = `
-		apt-get update && apt-get install -y containerd
-		mkdir -p /etc/containerd
-		containerd config default | tee /etc/containerd/config.toml
-		# 生产环境优化：设置SystemdCgroup、 sandbox_image、默认运行时等
-		sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
-		sed -i 's/sandbox_image = "registry.k8s.io\/pause:3.6"/sandbox_image = "registry.k8s.io\/pause:3.9"/g' /etc/containerd/config.toml
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri\.containerd.runtimes.runc.options"\]/a\          SystemdCgroup = true' /etc/containerd/config.toml
-		# 配置镜像加速
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  sandbox_image = "registry.k8s.io\/pause:3.9"' /etc/containerd/config.toml
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  systemd_cgroup = true' /etc/containerd/config.toml
-		systemctl restart containerd
-		systemctl enable containerd
-		`
-	case "centos", "rhel", "rocky", "alma":
-		cmd = `
-	if command -v dnf &> /dev/null; then
-		dnf install -y containerd
-	else
-		yum install -y containerd
-	fi
-	mkdir -p /etc/containerd
-	containerd config default | tee /etc/containerd/config.toml
-	# 生产环境优化：设置SystemdCgroup、 sandbox_image、默认运行时等
-	sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
-	sed -i 's/sandbox_image = "registry.k8s.io\/pause:3.6"/sandbox_image = "registry.k8s.io\/pause:3.9"/g' /etc/containerd/config.toml
-	sed -i '/\[plugins."io.containerd.grpc.v1.cri\.containerd.runtimes.runc.options"\]/a\          SystemdCgroup = true' /etc/containerd/config.toml
-	# 配置镜像加速
-	sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  sandbox_image = "registry.k8s.io\/pause:3.9"' /etc/containerd/config.toml
-	sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  systemd_cgroup = true' /etc/containerd/config.toml
-	systemctl restart containerd
-	systemctl enable containerd
-		`
-	default:
-		return fmt.Errorf("unsupported distribution: %s", distro)
-	}
-
-	_, err := client.RunCommand(cmd)
+func (m *FileNodeManager) installContainerRuntime(client ssh.Runner, distro, runtime, version string) error {
+	// 只支持containerd，安装和配置脚本通过脚本管理器解析
+	if runtime != "" && runtime != "containerd" {
+		return fmt.Errorf("unsupported container runtime: %s", runtime)
+	}
+	cmd, err := containerdScript(m.scriptManager, distro)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RunCommand(cmd)
 	return err
 }
 
@@ -788,11 +622,13 @@ func (m *FileNodeManager) InstallKubernetesComponents(id string, kubeadmVersion
 
 	// 执行安装逻辑
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -802,110 +638,24 @@ func (m *FileNodeManager) InstallKubernetesComponents(id string, kubeadmVersion
 	defer client.Close()
 
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
 	// 调用私有的安装方法
-	return m.installKubernetesComponents(client, distro)
+	return m.installKubernetesComponents(client, distro, kubeadmVersion)
 }
 
-// installKubernetesComponents 安装Kubernetes组件（私有辅助方法）
-func (m *FileNodeManager) installKubernetesComponents(client *ssh.SSHClient, distro string) error {
-	var cmd string
-	var found bool
-
-	// 从脚本管理器获取Kubernetes组件安装脚本
-	if m.scriptManager != nil {
-		if scriptGetter, ok := m.scriptManager.(interface {
-			GetScript(name string) (string, bool)
-		}); ok {
-			// 尝试获取特定发行版的脚本，使用与前端一致的命名格式: ${system}_${step.name.toLowerCase().replace(/\s+/g, '_')}
-			// 前端步骤名为 "安装Kubernetes组件"
-			componentScriptName := fmt.Sprintf("%s_安装kubernetes组件", distro)
-			if script, scriptFound := scriptGetter.GetScript(componentScriptName); scriptFound {
-				cmd = script
-				found = true
-				fmt.Printf("Using custom script for Kubernetes components installation on %s\n", distro)
-			} else {
-				// 尝试获取旧格式的脚本，保持向后兼容
-				oldComponentScriptName := fmt.Sprintf("k8s_components_%s", distro)
-				if script, scriptFound := scriptGetter.GetScript(oldComponentScriptName); scriptFound {
-					cmd = script
-					found = true
-					fmt.Printf("Using old format custom script for Kubernetes components installation on %s\n", distro)
-				} else {
-					// 尝试获取通用脚本
-					if script, scriptFound := scriptGetter.GetScript("k8s_components"); scriptFound {
-						cmd = script
-						found = true
-						fmt.Printf("Using custom script for Kubernetes components installation\n")
-					}
-				}
-			}
-		}
-	}
-
-	// 如果没有找到自定义脚本，使用默认命令
-	if !found {
-		switch distro {
-		case "ubuntu", "debian":
-			cmd = `
-apt-get update
-apt-get install -y apt-transport-https ca-certificates curl
-curl -fsSLo /usr/share/keyrings/kubernetes-archive-keyring.gpg https://packages.cloud.google.com/apt/doc/apt-key.gpg
-echo "deb [signed-by=/usr/share/keyrings/kubernetes-archive-keyring.gpg] https://apt.kubernetes.io/ kubernetes-xenial main" | tee /etc/apt/sources.list.d/kubernetes.list
-apt-get update
-# 锁定版本到最新稳定版，生产环境推荐
-K8S_VERSION=$(apt-cache madison kubeadm | grep -E "^kubeadm\s+\|\s+\d+\.\d+\.\d+" | head -1 | awk '{print $3}' | sed 's/-00//')
-apt-get install -y kubeadm=$K8S_VERSION kubelet=$K8S_VERSION kubectl=$K8S_VERSION
-apt-mark hold kubelet kubeadm kubectl
-
-# 生产环境优化：配置kubelet使用systemd cgroup驱动
-cat <<EOF | tee /etc/default/kubelet
-KUBELET_EXTRA_ARGS="--cgroup-driver=systemd --runtime-cgroups=/system.slice/containerd.service --kubelet-cgroups=/system.slice/kubelet.service"
-EOF
-
-systemctl daemon-reload
-systemctl restart kubelet
-systemctl enable kubelet
-		`
-		case "centos", "rhel", "rocky", "alma":
-			cmd = `
-yum install -y yum-utils
-yum-config-manager --add-repo https://packages.cloud.google.com/yum/repos/kubernetes-el7-x86_64
-rpm --import https://packages.cloud.google.com/yum/doc/yum-key.gpg
-rpm --import https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
-# 禁用SELINUX（生产环境推荐）
-setenforce 0
-sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
-# 锁定版本到最新稳定版
-K8S_VERSION=$(yum list --showduplicates kubeadm --disableexcludes=kubernetes | sort -r | grep -E "^kubeadm\.x86_64" | head -1 | awk '{print $2}')
-yum install -y kubelet-$K8S_VERSION kubeadm-$K8S_VERSION kubectl-$K8S_VERSION
-yum versionlock add kubelet kubeadm kubectl
-
-# 生产环境优化：配置kubelet使用systemd cgroup驱动
-cat <<EOF | tee /etc/sysconfig/kubelet
-KUBELET_EXTRA_ARGS="--cgroup-driver=systemd --runtime-cgroups=/system.slice/containerd.service --kubelet-cgroups=/system.slice/kubelet.service"
-EOF
-
-systemctl daemon-reload
-systemctl restart kubelet
-systemctl enable kubelet
-		`
-		default:
-			return fmt.Errorf("unsupported distribution: %s", distro)
-		}
+// installKubernetesComponents 安装Kubernetes组件并固定版本（私有辅助方法），kubeVersion为空时使用DefaultKubeRepoVersion的仓库
+func (m *FileNodeManager) installKubernetesComponents(client ssh.Runner, distro, kubeVersion string) error {
+	// 添加仓库和安装组件的脚本通过脚本管理器解析，没有自定义脚本时使用发行版家族的默认模板
+	cmd, err := kubeComponentsScript(m.scriptManager, distro, kubeVersion)
+	if err != nil {
+		return err
 	}
 
-	_, err := client.RunCommand(cmd)
+	_, err = client.RunCommand(cmd)
 	return err
 }
@@ -0,0 +1,473 @@
+package node
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/validate"
+)
+
+// 错误定义
+var (
+	ErrGroupNotFound = errors.New("node group not found")
+	ErrGroupExists   = errors.New("node group already exists")
+)
+
+var (
+	// noProxyPattern NO_PROXY中的主机、域名后缀、IP和网段
+	noProxyPattern = regexp.MustCompile(`^[A-Za-z0-9.\-_:/*]+$`)
+	// packageVersionPattern 软件包版本号，如 1.7.24 或 1.7.24-1
+	packageVersionPattern = regexp.MustCompile(`^[0-9A-Za-z.\-+~:]+$`)
+)
+
+// ProxySettings 节点访问外网使用的代理
+type ProxySettings struct {
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// NoProxy 逗号分隔的不使用代理的主机、域名后缀和网段
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// Empty 是否没有配置代理
+func (p ProxySettings) Empty() bool {
+	return p.HTTPProxy == "" && p.HTTPSProxy == ""
+}
+
+// GroupDefaults 节点组的默认配置，部署时应用到组内的每个节点
+type GroupDefaults struct {
+	// Proxy 节点的HTTP代理，写入系统环境变量以及containerd和kubelet的服务配置
+	Proxy ProxySettings `json:"proxy"`
+	// Labels 节点加入集群时设置的Kubernetes标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// ContainerdVersion 安装的containerd版本，为空时安装软件源中的最新版本
+	ContainerdVersion string `json:"containerdVersion,omitempty"`
+	// ScriptOverrides 脚本替换，键为部署使用的脚本名称，值为替换使用的脚本名称
+	ScriptOverrides map[string]string `json:"scriptOverrides,omitempty"`
+}
+
+// Validate 检查代理地址、标签和版本号，避免注入shell命令
+func (d GroupDefaults) Validate() error {
+	v := &validate.Validator{}
+	v.ProxyURL("proxy.httpProxy", d.Proxy.HTTPProxy)
+	v.ProxyURL("proxy.httpsProxy", d.Proxy.HTTPSProxy)
+	if d.Proxy.NoProxy != "" {
+		for _, entry := range strings.Split(d.Proxy.NoProxy, ",") {
+			if !noProxyPattern.MatchString(strings.TrimSpace(entry)) {
+				v.Add("proxy.noProxy", "%q is not a valid host, domain or CIDR", entry)
+				break
+			}
+		}
+	}
+	for key, value := range d.Labels {
+		v.Label("labels", key, value)
+	}
+	if d.ContainerdVersion != "" && !packageVersionPattern.MatchString(d.ContainerdVersion) {
+		v.Add("containerdVersion", "%q is not a valid package version", d.ContainerdVersion)
+	}
+	for name, override := range d.ScriptOverrides {
+		if strings.TrimSpace(name) == "" || strings.TrimSpace(override) == "" {
+			v.Add("scriptOverrides", "script names must not be empty")
+			break
+		}
+	}
+	return v.Err()
+}
+
+// Group 节点组，如infra、gpu、edge，组内节点共享默认配置，部署时可以按组选择节点
+type Group struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Defaults    GroupDefaults `json:"defaults"`
+	// ProjectID 所属项目，组成员必须是同一项目的节点
+	ProjectID string `json:"projectId,omitempty"`
+	// NodeIDs 组内的节点，一个节点最多属于一个节点组
+	NodeIDs   []string  `json:"nodeIds"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate 检查组名称和默认配置
+func (g Group) Validate() error {
+	v := &validate.Validator{}
+	if v.Required("name", g.Name) {
+		v.DNSSubdomain("name", g.Name)
+	}
+	v.Merge("defaults", g.Defaults.Validate())
+	return v.Err()
+}
+
+// UnknownNodesError 节点组成员中包含不存在的节点
+type UnknownNodesError struct {
+	NodeIDs []string
+}
+
+func (e *UnknownNodesError) Error() string {
+	return fmt.Sprintf("nodes not found: %s", strings.Join(e.NodeIDs, ", "))
+}
+
+// GroupManager 节点组管理器，节点组保存在node_groups表中，成员关系保存在nodes表的group_id列
+type GroupManager struct {
+	db    *sql.DB
+	mutex sync.RWMutex
+}
+
+// NewGroupManager 创建节点组管理器
+func NewGroupManager(db *sql.DB) (*GroupManager, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS node_groups (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		defaults TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create node_groups table: %v", err)
+	}
+	if err := migrateGroupProject(db); err != nil {
+		return nil, err
+	}
+	return &GroupManager{db: db}, nil
+}
+
+// migrateNodeGroup 添加nodes表的group_id列，记录节点所属的节点组
+func migrateNodeGroup(db *sql.DB) error {
+	var columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('nodes') WHERE name = 'group_id'").Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check group_id column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN group_id TEXT"); err != nil {
+			return fmt.Errorf("failed to add group_id column: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListGroups 获取所有节点组
+func (m *GroupManager) ListGroups() ([]Group, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	rows, err := m.db.Query("SELECT id, name, description, defaults, project_id, created_at, updated_at FROM node_groups ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node groups: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	members, err := m.members()
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].NodeIDs = append([]string{}, members[groups[i].ID]...)
+	}
+	return groups, nil
+}
+
+// GetGroup 获取指定节点组
+func (m *GroupManager) GetGroup(id string) (*Group, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.getGroup(id)
+}
+
+func (m *GroupManager) getGroup(id string) (*Group, error) {
+	group, err := scanGroup(m.db.QueryRow("SELECT id, name, description, defaults, project_id, created_at, updated_at FROM node_groups WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := m.members()
+	if err != nil {
+		return nil, err
+	}
+	group.NodeIDs = append([]string{}, members[group.ID]...)
+	return group, nil
+}
+
+// CreateGroup 创建节点组，NodeIDs不为空时同时设置组成员
+func (m *GroupManager) CreateGroup(group Group) (*Group, error) {
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	defaults, err := json.Marshal(group.Defaults)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	group.ID = fmt.Sprintf("%d", now.UnixNano())
+	if group.ProjectID == "" {
+		group.ProjectID = DefaultProjectID
+	}
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO node_groups (id, name, description, defaults, project_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		group.ID, group.Name, group.Description, string(defaults), group.ProjectID, group.CreatedAt, group.UpdatedAt,
+	); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s", ErrGroupExists, group.Name)
+		}
+		return nil, fmt.Errorf("failed to insert node group: %v", err)
+	}
+	if err := setMembers(tx, group.ID, group.NodeIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return m.getGroup(group.ID)
+}
+
+// UpdateGroup 更新节点组的名称、说明和默认配置，NodeIDs不为nil时同时替换组成员
+func (m *GroupManager) UpdateGroup(id string, group Group) (*Group, error) {
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	defaults, err := json.Marshal(group.Defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE node_groups SET name = ?, description = ?, defaults = ?, updated_at = ? WHERE id = ?",
+		group.Name, group.Description, string(defaults), time.Now(), id,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s", ErrGroupExists, group.Name)
+		}
+		return nil, fmt.Errorf("failed to update node group: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrGroupNotFound
+	}
+	if group.NodeIDs != nil {
+		if err := setMembers(tx, id, group.NodeIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return m.getGroup(id)
+}
+
+// DeleteGroup 删除节点组，组内节点不再属于任何节点组
+func (m *GroupManager) DeleteGroup(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM node_groups WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete node group: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrGroupNotFound
+	}
+	if _, err := tx.Exec("UPDATE nodes SET group_id = NULL WHERE group_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear node group members: %v", err)
+	}
+	return tx.Commit()
+}
+
+// SetMembers 替换节点组的成员，节点原来所属的节点组会被替换
+func (m *GroupManager) SetMembers(id string, nodeIDs []string) (*Group, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM node_groups WHERE id = ?", id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get node group: %v", err)
+	}
+	if exists == 0 {
+		return nil, ErrGroupNotFound
+	}
+	if err := setMembers(tx, id, nodeIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return m.getGroup(id)
+}
+
+// NodeIDs 返回项目内多个节点组的所有成员，按节点组顺序去重；节点组属于其他项目时按不存在处理
+func (m *GroupManager) NodeIDs(projectID string, groupIDs []string) ([]string, error) {
+	var nodeIDs []string
+	seen := make(map[string]bool)
+	for _, id := range groupIDs {
+		group, err := m.GetGroup(id)
+		if err == nil && group.ProjectID != projectID {
+			err = ErrGroupNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, id)
+		}
+		for _, nodeID := range group.NodeIDs {
+			if !seen[nodeID] {
+				seen[nodeID] = true
+				nodeIDs = append(nodeIDs, nodeID)
+			}
+		}
+	}
+	return nodeIDs, nil
+}
+
+// DefaultsFor 返回节点所属节点组的默认配置，键为节点ID，不属于任何节点组的节点不包含在结果中
+func (m *GroupManager) DefaultsFor(nodes []Node) (map[string]GroupDefaults, error) {
+	defaults := make(map[string]GroupDefaults)
+	groups := make(map[string]*Group)
+	for _, n := range nodes {
+		if n.GroupID == "" {
+			continue
+		}
+		group, ok := groups[n.GroupID]
+		if !ok {
+			var err error
+			group, err = m.GetGroup(n.GroupID)
+			if errors.Is(err, ErrGroupNotFound) {
+				groups[n.GroupID] = nil
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			groups[n.GroupID] = group
+		}
+		if group != nil {
+			defaults[n.ID] = group.Defaults
+		}
+	}
+	return defaults, nil
+}
+
+// members 读取所有节点组的成员，键为节点组ID
+func (m *GroupManager) members() (map[string][]string, error) {
+	rows, err := m.db.Query("SELECT id, group_id FROM nodes WHERE group_id IS NOT NULL AND group_id != '' ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node group members: %v", err)
+	}
+	defer rows.Close()
+
+	members := make(map[string][]string)
+	for rows.Next() {
+		var nodeID, groupID string
+		if err := rows.Scan(&nodeID, &groupID); err != nil {
+			return nil, err
+		}
+		members[groupID] = append(members[groupID], nodeID)
+	}
+	return members, rows.Err()
+}
+
+// setMembers 在事务中替换节点组的成员，节点不存在或不属于节点组所在的项目时返回*UnknownNodesError
+func setMembers(tx *sql.Tx, groupID string, nodeIDs []string) error {
+	var unknown []string
+	for _, nodeID := range nodeIDs {
+		var exists int
+		if err := tx.QueryRow(
+			"SELECT COUNT(*) FROM nodes WHERE id = ? AND project_id = (SELECT project_id FROM node_groups WHERE id = ?)",
+			nodeID, groupID,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to get node: %v", err)
+		}
+		if exists == 0 {
+			unknown = append(unknown, nodeID)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &UnknownNodesError{NodeIDs: unknown}
+	}
+
+	if _, err := tx.Exec("UPDATE nodes SET group_id = NULL WHERE group_id = ?", groupID); err != nil {
+		return fmt.Errorf("failed to clear node group members: %v", err)
+	}
+	for _, nodeID := range nodeIDs {
+		if _, err := tx.Exec("UPDATE nodes SET group_id = ? WHERE id = ?", groupID, nodeID); err != nil {
+			return fmt.Errorf("failed to add node %s to group: %v", nodeID, err)
+		}
+	}
+	return nil
+}
+
+// rowScanner *sql.Row和*sql.Rows的公共接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGroup 读取一行节点组记录
+func scanGroup(row rowScanner) (*Group, error) {
+	var group Group
+	var defaults string
+	if err := row.Scan(&group.ID, &group.Name, &group.Description, &defaults, &group.ProjectID, &group.CreatedAt, &group.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan node group: %v", err)
+	}
+	if err := json.Unmarshal([]byte(defaults), &group.Defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse node group defaults: %v", err)
+	}
+	return &group, nil
+}
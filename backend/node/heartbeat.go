@@ -0,0 +1,320 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s-installer/log"
+)
+
+// 心跳默认配置
+const (
+	DefaultHeartbeatInterval = 60 * time.Second
+	MinHeartbeatInterval     = 10 * time.Second
+	heartbeatRetention       = 7 * 24 * time.Hour
+	heartbeatConcurrency     = 10
+)
+
+// HeartbeatRecord 节点可达性记录
+type HeartbeatRecord struct {
+	NodeID    string    `json:"nodeId"`
+	Reachable bool      `json:"reachable"`
+	LatencyMs int64     `json:"latencyMs"`
+	OS        string    `json:"os,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	// osInfo 探测时检测的操作系统信息，用于更新节点的缓存
+	osInfo *OSInfo
+}
+
+// HeartbeatConfig 心跳轮询配置
+type HeartbeatConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"intervalSeconds"`
+}
+
+// StatusChangeHandler 节点状态变化回调
+type StatusChangeHandler func(n Node, oldStatus, newStatus string)
+
+// HeartbeatPoller 节点心跳轮询器，定期通过SSH探测所有节点并自动更新状态
+type HeartbeatPoller struct {
+	manager  *SqliteNodeManager
+	mutex    sync.Mutex
+	interval time.Duration
+	enabled  bool
+	stopChan chan struct{}
+	resetCh  chan struct{}
+	handlers []StatusChangeHandler
+}
+
+// NewHeartbeatPoller 创建节点心跳轮询器
+func NewHeartbeatPoller(manager *SqliteNodeManager, interval time.Duration) (*HeartbeatPoller, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS node_heartbeats (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		node_id TEXT NOT NULL,
+		reachable INTEGER NOT NULL,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		os TEXT,
+		error TEXT,
+		checked_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_node_heartbeats_node ON node_heartbeats(node_id, checked_at);
+	`
+	if _, err := manager.db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create node_heartbeats table: %v", err)
+	}
+
+	if interval < MinHeartbeatInterval {
+		interval = DefaultHeartbeatInterval
+	}
+
+	return &HeartbeatPoller{
+		manager:  manager,
+		interval: interval,
+		resetCh:  make(chan struct{}, 1),
+	}, nil
+}
+
+// OnStatusChange 注册节点状态变化回调
+func (p *HeartbeatPoller) OnStatusChange(handler StatusChangeHandler) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// Start 启动心跳轮询
+func (p *HeartbeatPoller) Start() {
+	p.mutex.Lock()
+	if p.enabled {
+		p.mutex.Unlock()
+		return
+	}
+	p.enabled = true
+	p.stopChan = make(chan struct{})
+	stopChan := p.stopChan
+	p.mutex.Unlock()
+
+	go func() {
+		// 启动后立即执行一次
+		p.PollOnce()
+
+		ticker := time.NewTicker(p.GetConfig().interval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.PollOnce()
+			case <-p.resetCh:
+				ticker.Reset(p.GetConfig().interval())
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止心跳轮询
+func (p *HeartbeatPoller) Stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.enabled {
+		return
+	}
+	p.enabled = false
+	close(p.stopChan)
+}
+
+// GetConfig 获取心跳配置
+func (p *HeartbeatPoller) GetConfig() HeartbeatConfig {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return HeartbeatConfig{
+		Enabled:         p.enabled,
+		IntervalSeconds: int(p.interval / time.Second),
+	}
+}
+
+// interval 将配置转换为轮询间隔
+func (c HeartbeatConfig) interval() time.Duration {
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// UpdateConfig 更新心跳配置，修改间隔后立即生效
+func (p *HeartbeatPoller) UpdateConfig(config HeartbeatConfig) error {
+	interval := config.interval()
+	if interval < MinHeartbeatInterval {
+		return fmt.Errorf("interval must be at least %d seconds", int(MinHeartbeatInterval/time.Second))
+	}
+
+	p.mutex.Lock()
+	p.interval = interval
+	p.mutex.Unlock()
+
+	if config.Enabled {
+		p.Start()
+		select {
+		case p.resetCh <- struct{}{}:
+		default:
+		}
+	} else {
+		p.Stop()
+	}
+	return nil
+}
+
+// PollOnce 对所有节点执行一次心跳探测
+func (p *HeartbeatPoller) PollOnce() {
+	nodes, err := p.manager.GetNodes()
+	if err != nil {
+		fmt.Printf("心跳轮询获取节点列表失败: %v\n", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, heartbeatConcurrency)
+	for _, n := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n Node) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.checkNode(n)
+		}(n)
+	}
+	wg.Wait()
+
+	// 清理过期的心跳记录
+	if _, err := p.manager.db.Exec("DELETE FROM node_heartbeats WHERE checked_at < ?", time.Now().Add(-heartbeatRetention)); err != nil {
+		fmt.Printf("清理心跳记录失败: %v\n", err)
+	}
+}
+
+// checkNode 探测单个节点并更新状态
+func (p *HeartbeatPoller) checkNode(n Node) {
+	record := Probe(n)
+
+	if _, err := p.manager.db.Exec(
+		"INSERT INTO node_heartbeats (node_id, reachable, latency_ms, os, error, checked_at) VALUES (?, ?, ?, ?, ?, ?)",
+		record.NodeID, record.Reachable, record.LatencyMs, record.OS, record.Error, record.CheckedAt,
+	); err != nil {
+		fmt.Printf("写入心跳记录失败: %v\n", err)
+	}
+
+	// 部署中的节点状态由部署流程维护
+	if n.Status == NodeStatusDeploying {
+		return
+	}
+
+	newStatus := n.Status
+	if !record.Reachable {
+		newStatus = NodeStatusOffline
+	} else if n.Status == NodeStatusOffline || n.Status == NodeStatusError || n.Status == "" {
+		// 已是online或ready的节点保持原状态
+		newStatus = NodeStatusOnline
+	}
+
+	p.manager.mutex.Lock()
+	if newStatus != n.Status {
+		p.manager.updateNodeStatus(n.ID, newStatus, time.Now())
+	}
+	p.manager.mutex.Unlock()
+	// 节点没有缓存操作系统信息或发行版变化时更新缓存
+	if record.osInfo != nil && (n.OSInfo == nil || record.OS != n.OS) {
+		if err := p.manager.SetNodeOS(n.ID, *record.osInfo); err != nil {
+			fmt.Printf("更新节点操作系统信息失败: %v\n", err)
+		}
+	}
+
+	if newStatus != n.Status {
+		p.emitStatusChange(n, n.Status, newStatus, record)
+	}
+}
+
+// emitStatusChange 记录状态变化日志并通知回调
+func (p *HeartbeatPoller) emitStatusChange(n Node, oldStatus, newStatus string, record HeartbeatRecord) {
+	output := fmt.Sprintf("节点 %s (%s) 状态变化: %s -> %s", n.Name, n.IP, oldStatus, newStatus)
+	if record.Error != "" {
+		output += fmt.Sprintf("\n错误: %s", record.Error)
+	}
+	fmt.Println(output)
+
+	status := "success"
+	if newStatus == NodeStatusOffline {
+		status = "failed"
+	}
+	if p.manager.logManager != nil {
+		p.manager.logManager.CreateLog(log.LogEntry{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			NodeID:    n.ID,
+			NodeName:  n.Name,
+			Operation: "NodeStatusChange",
+			Command:   "heartbeat",
+			Output:    output,
+			Status:    status,
+			Type:      log.TypeSystem,
+			CreatedAt: record.CheckedAt,
+			UpdatedAt: record.CheckedAt,
+		})
+	}
+
+	p.mutex.Lock()
+	handlers := append([]StatusChangeHandler(nil), p.handlers...)
+	p.mutex.Unlock()
+	for _, handler := range handlers {
+		handler(n, oldStatus, newStatus)
+	}
+}
+
+// Probe 通过SSH探测节点可达性并获取操作系统类型
+func Probe(n Node) HeartbeatRecord {
+	record := HeartbeatRecord{NodeID: n.ID, CheckedAt: time.Now()}
+
+	start := time.Now()
+	client, err := Connect(n)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+	defer client.Close()
+
+	info, err := DetectOS(client)
+	record.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+
+	record.Reachable = true
+	record.OS = info.Distro
+	record.osInfo = &info
+	return record
+}
+
+// GetHistory 获取节点的可达性历史记录，按时间倒序
+func (p *HeartbeatPoller) GetHistory(nodeID string, limit int) ([]HeartbeatRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := p.manager.db.Query(
+		"SELECT node_id, reachable, latency_ms, COALESCE(os, ''), COALESCE(error, ''), checked_at FROM node_heartbeats WHERE node_id = ? ORDER BY checked_at DESC LIMIT ?",
+		nodeID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query heartbeats: %v", err)
+	}
+	defer rows.Close()
+
+	records := []HeartbeatRecord{}
+	for rows.Next() {
+		var record HeartbeatRecord
+		if err := rows.Scan(&record.NodeID, &record.Reachable, &record.LatencyMs, &record.OS, &record.Error, &record.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan heartbeat: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
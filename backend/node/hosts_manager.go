@@ -10,13 +10,13 @@ import (
 // 避免依赖本地hosts文件，提高SSH连接的可靠性
 
 type HostsManager struct {
-	nodeManager NodeManager
+	nodeManager NodeLister
 	cache       map[string]string // 缓存：节点名称 -> IP地址
 	mutex       sync.RWMutex
 }
 
 // NewHostsManager 创建新的主机名映射管理器
-func NewHostsManager(nodeManager NodeManager) *HostsManager {
+func NewHostsManager(nodeManager NodeLister) *HostsManager {
 	return &HostsManager{
 		nodeManager: nodeManager,
 		cache:       make(map[string]string),
@@ -0,0 +1,155 @@
+package node
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// /etc/hosts中由安装器管理的标记块，块内内容每次同步时整体替换
+const (
+	HostsBeginMarker = "# BEGIN k8s-installer managed hosts"
+	HostsEndMarker   = "# END k8s-installer managed hosts"
+	// legacyHostsMarker 旧版本免密配置写入的标记，其后的内容一直到文件结尾都由旧版本追加
+	legacyHostsMarker = "# Kubernetes集群节点解析"
+)
+
+// NodeLister 提供节点列表，HostsManager只依赖该方法
+type NodeLister interface {
+	GetNodes() ([]Node, error)
+}
+
+// HostsSyncResult 单个节点的hosts同步结果
+type HostsSyncResult struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	Success  bool   `json:"success"`
+	Removed  bool   `json:"removed"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GenerateHostsBlock 生成包含所有节点"IP 名称"映射的标记块，按节点名称排序保证内容稳定
+func GenerateHostsBlock(nodes []Node) string {
+	sorted := make([]Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	b.WriteString(HostsBeginMarker + "\n")
+	for _, n := range sorted {
+		if n.IP == "" || n.Name == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", n.IP, n.Name))
+	}
+	b.WriteString(HostsEndMarker + "\n")
+	return b.String()
+}
+
+// hostsUpdateScript 删除已有的标记块（以及旧版本标记）后追加新的块，block为空时只删除。
+// 先在临时文件中完成修改，内容不变时不写回，保证重复执行结果一致
+const hostsUpdateScript = `set -e
+TMP=$(mktemp)
+trap 'rm -f $TMP' EXIT
+sudo cat /etc/hosts > $TMP
+sed -i '/^%[1]s$/,/^%[2]s$/d' $TMP
+sed -i '/^%[3]s$/,$d' $TMP
+BLOCK=$(echo %[4]s | base64 -d)
+if [ -n "$BLOCK" ]; then
+    printf '%%s\n' "$BLOCK" >> $TMP
+fi
+if sudo cmp -s $TMP /etc/hosts; then
+    echo "hosts文件无变化"
+else
+    sudo cp /etc/hosts /etc/hosts.bak
+    sudo cp $TMP /etc/hosts
+    sudo chmod 644 /etc/hosts
+    echo "hosts文件已更新"
+    if command -v nscd &> /dev/null; then
+        sudo nscd -i hosts || true
+    fi
+fi
+sed -n '/^%[1]s$/,/^%[2]s$/p' /etc/hosts`
+
+// HostsUpdateCmd 生成在节点上幂等更新/etc/hosts标记块的命令，block为空时移除标记块
+func HostsUpdateCmd(block string) string {
+	return fmt.Sprintf(hostsUpdateScript, HostsBeginMarker, HostsEndMarker, legacyHostsMarker,
+		shellQuote(base64.StdEncoding.EncodeToString([]byte(strings.TrimRight(block, "\n")))))
+}
+
+// shellQuote 使用单引号转义shell参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// SyncHosts 将所有节点的主机名映射同步到指定节点的/etc/hosts，nodeIDs为空时同步到所有节点；
+// remove为true时从节点上移除标记块
+func (hm *HostsManager) SyncHosts(nodeIDs []string, remove bool) ([]HostsSyncResult, error) {
+	return hm.SyncHostsAmong(hm.nodeManager, nodeIDs, remove)
+}
+
+// SyncHostsAmong 与SyncHosts相同，但只在nodes列出的节点范围内同步，如同一项目的节点
+func (hm *HostsManager) SyncHostsAmong(nodes NodeLister, nodeIDs []string, remove bool) ([]HostsSyncResult, error) {
+	allNodes, err := nodes.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := allNodes
+	if len(nodeIDs) > 0 {
+		byID := make(map[string]Node, len(allNodes))
+		for _, n := range allNodes {
+			byID[n.ID] = n
+		}
+		targets = make([]Node, 0, len(nodeIDs))
+		for _, id := range nodeIDs {
+			n, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("node not found: %s", id)
+			}
+			targets = append(targets, n)
+		}
+	}
+
+	block := ""
+	if !remove {
+		block = GenerateHostsBlock(allNodes)
+	}
+	cmd := HostsUpdateCmd(block)
+
+	results := make([]HostsSyncResult, len(targets))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, DefaultExecConcurrency)
+	for i, n := range targets {
+		wg.Add(1)
+		go func(i int, n Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := HostsSyncResult{NodeID: n.ID, NodeName: n.Name, Removed: remove}
+			client, err := Connect(n)
+			if err != nil {
+				result.Error = fmt.Sprintf("failed to connect node: %v", err)
+				results[i] = result
+				return
+			}
+			defer client.Close()
+
+			output, err := client.RunCommandSilent(cmd)
+			result.Output = strings.TrimSpace(output)
+			result.Success = err == nil
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, n)
+	}
+	wg.Wait()
+
+	hm.RefreshCache()
+	return results, nil
+}
@@ -0,0 +1,237 @@
+package node
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/log"
+	"k8s-installer/secretbox"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// InstallerKeyScheme 安装器密钥的凭据引用前缀，节点的PrivateKeyRef为InstallerKeyRef时使用安装器密钥连接
+const InstallerKeyScheme = "installer"
+
+// installerKeyName 引用中的密钥名称，也是installer_keys表中当前密钥的名称
+const installerKeyName = "key"
+
+// InstallerKeyRef 使用安装器密钥的凭据引用
+const InstallerKeyRef = InstallerKeyScheme + ":" + installerKeyName
+
+// installerKeyComment 公钥注释，用于在authorized_keys中识别安装器添加的公钥
+const installerKeyComment = "k8s-installer"
+
+// 错误定义
+var (
+	ErrInstallerKeyNotFound = errors.New("installer SSH key not found")
+	ErrInstallerKeyExists   = errors.New("installer SSH key already exists, rotate it instead")
+)
+
+// InstallerKey 安装器的SSH密钥对，私钥不返回给API调用方
+type InstallerKey struct {
+	PublicKey   string    `json:"publicKey"`
+	Fingerprint string    `json:"fingerprint"`
+	PrivateKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// InstallerKeyManager 管理安装器的SSH密钥对，保存在installer_keys表中，私钥使用secretbox加密。
+// 注册为InstallerKeyScheme的凭据提供方，节点通过InstallerKeyRef引用私钥
+type InstallerKeyManager struct {
+	db    *sql.DB
+	box   *secretbox.Box
+	mutex sync.Mutex
+}
+
+// NewInstallerKeyManager 创建安装器密钥管理器，升级前以明文保存的私钥在创建时加密
+func NewInstallerKeyManager(db *sql.DB, box *secretbox.Box) (*InstallerKeyManager, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS installer_keys (
+		name TEXT PRIMARY KEY,
+		public_key TEXT NOT NULL,
+		private_key TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create installer_keys table: %v", err)
+	}
+	m := &InstallerKeyManager{db: db, box: box}
+	if err := m.sealPlaintextKeys(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// sealPlaintextKeys 加密以明文保存的私钥
+func (m *InstallerKeyManager) sealPlaintextKeys() error {
+	rows, err := m.db.Query("SELECT name, private_key FROM installer_keys")
+	if err != nil {
+		return fmt.Errorf("failed to read installer SSH keys: %v", err)
+	}
+	plaintext := make(map[string]string)
+	for rows.Next() {
+		var name, privateKey string
+		if err := rows.Scan(&name, &privateKey); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read installer SSH keys: %v", err)
+		}
+		if !secretbox.Sealed(privateKey) {
+			plaintext[name] = privateKey
+		}
+	}
+	rows.Close()
+	for name, privateKey := range plaintext {
+		sealed, err := m.box.Seal(privateKey)
+		if err != nil {
+			return err
+		}
+		if _, err := m.db.Exec("UPDATE installer_keys SET private_key = ? WHERE name = ?", sealed, name); err != nil {
+			return fmt.Errorf("failed to encrypt installer SSH key: %v", err)
+		}
+	}
+	return nil
+}
+
+// Get 返回安装器密钥，未生成时返回ErrInstallerKeyNotFound
+func (m *InstallerKeyManager) Get() (*InstallerKey, error) {
+	var key InstallerKey
+	var sealed string
+	err := m.db.QueryRow("SELECT public_key, private_key, fingerprint, created_at FROM installer_keys WHERE name = ?", installerKeyName).
+		Scan(&key.PublicKey, &sealed, &key.Fingerprint, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInstallerKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installer SSH key: %v", err)
+	}
+	if key.PrivateKey, err = m.box.Open(sealed); err != nil {
+		return nil, fmt.Errorf("failed to decrypt installer SSH key: %v", err)
+	}
+	log.RegisterSecret(key.PrivateKey)
+	return &key, nil
+}
+
+// Ensure 返回安装器密钥，未生成时生成ed25519密钥对
+func (m *InstallerKeyManager) Ensure() (*InstallerKey, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key, err := m.Get()
+	if !errors.Is(err, ErrInstallerKeyNotFound) {
+		return key, err
+	}
+	if key, err = generateInstallerKey(); err != nil {
+		return nil, err
+	}
+	return key, m.save(key)
+}
+
+// Create 生成或导入安装器密钥，privateKey为空时生成ed25519密钥对。已有密钥时返回ErrInstallerKeyExists，更换密钥使用轮换
+func (m *InstallerKeyManager) Create(privateKey string) (*InstallerKey, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, err := m.Get(); !errors.Is(err, ErrInstallerKeyNotFound) {
+		if err == nil {
+			err = ErrInstallerKeyExists
+		}
+		return nil, err
+	}
+	key, err := NewInstallerKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return key, m.save(key)
+}
+
+// replace 轮换时用新密钥替换当前密钥
+func (m *InstallerKeyManager) replace(key *InstallerKey) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.save(key)
+}
+
+// save 加密私钥后保存为当前密钥
+func (m *InstallerKeyManager) save(key *InstallerKey) error {
+	sealed, err := m.box.Seal(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	if _, err := m.db.Exec(
+		"INSERT OR REPLACE INTO installer_keys (name, public_key, private_key, fingerprint, created_at) VALUES (?, ?, ?, ?, ?)",
+		installerKeyName, key.PublicKey, sealed, key.Fingerprint, key.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to save installer SSH key: %v", err)
+	}
+	log.RegisterSecret(key.PrivateKey)
+	return nil
+}
+
+// NewInstallerKey 解析导入的私钥，privateKey为空时生成ed25519密钥对。不支持带密码的私钥
+func NewInstallerKey(privateKey string) (*InstallerKey, error) {
+	if strings.TrimSpace(privateKey) == "" {
+		return generateInstallerKey()
+	}
+	signer, err := gossh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return newInstallerKey(signer.PublicKey(), strings.TrimSpace(privateKey)+"\n"), nil
+}
+
+// generateInstallerKey 生成ed25519密钥对，私钥为OpenSSH格式
+func generateInstallerKey() (*InstallerKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SSH key: %v", err)
+	}
+	block, err := gossh.MarshalPrivateKey(private, installerKeyComment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH private key: %v", err)
+	}
+	sshPublic, err := gossh.NewPublicKey(public)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH public key: %v", err)
+	}
+	return newInstallerKey(sshPublic, string(pem.EncodeToMemory(block))), nil
+}
+
+// newInstallerKey 公钥使用authorized_keys格式并带上installerKeyComment注释
+func newInstallerKey(public gossh.PublicKey, privateKey string) *InstallerKey {
+	return &InstallerKey{
+		PublicKey:   strings.TrimSpace(string(gossh.MarshalAuthorizedKey(public))) + " " + installerKeyComment,
+		Fingerprint: gossh.FingerprintSHA256(public),
+		PrivateKey:  privateKey,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// ValidateRef 实现ssh.SecretProvider，只支持InstallerKeyRef
+func (m *InstallerKeyManager) ValidateRef(ref string) error {
+	if ref != installerKeyName {
+		return fmt.Errorf("installer key reference must be %s", InstallerKeyRef)
+	}
+	return nil
+}
+
+// Resolve 实现ssh.SecretProvider，返回安装器私钥
+func (m *InstallerKeyManager) Resolve(ref string) (string, error) {
+	if err := m.ValidateRef(ref); err != nil {
+		return "", err
+	}
+	key, err := m.Get()
+	if err != nil {
+		return "", err
+	}
+	return key.PrivateKey, nil
+}
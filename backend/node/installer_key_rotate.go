@@ -0,0 +1,145 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/log"
+)
+
+// KeyRotationNode 单个节点的轮换结果
+type KeyRotationNode struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	// Installed 新公钥已加入authorized_keys并验证可以登录
+	Installed bool `json:"installed"`
+	// OldKeyRemoved 切换后已从authorized_keys中删除旧公钥
+	OldKeyRemoved bool   `json:"oldKeyRemoved"`
+	Error         string `json:"error,omitempty"`
+}
+
+// KeyRotationResult 安装器密钥轮换结果。Rotated为false时当前密钥未变化，已安装的新公钥已删除
+type KeyRotationResult struct {
+	Rotated        bool              `json:"rotated"`
+	OldFingerprint string            `json:"oldFingerprint"`
+	NewFingerprint string            `json:"newFingerprint"`
+	Nodes          []KeyRotationNode `json:"nodes"`
+}
+
+// SetInstallerKeys 设置安装器密钥管理器，配置SSH免密互通时同时分发安装器公钥
+func (m *SqliteNodeManager) SetInstallerKeys(keys *InstallerKeyManager) {
+	m.installerKeys = keys
+}
+
+// RotateInstallerKey 轮换安装器密钥，privateKey为空时生成新密钥。分三步安全切换：
+// 用当前密钥把新公钥加入所有使用安装器密钥的节点并用新私钥验证登录；全部成功后替换当前密钥；
+// 再用新密钥从各节点删除旧公钥。任一节点安装或验证失败时不替换密钥，并从已安装的节点上删除新公钥
+func (m *SqliteNodeManager) RotateInstallerKey(keys *InstallerKeyManager, privateKey string) (*KeyRotationResult, error) {
+	current, err := keys.Get()
+	if err != nil {
+		return nil, err
+	}
+	next, err := NewInstallerKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if next.Fingerprint == current.Fingerprint {
+		return nil, errors.New("the new installer SSH key is the same as the current key")
+	}
+
+	allNodes, err := m.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []Node
+	for _, n := range allNodes {
+		if n.PrivateKeyRef == InstallerKeyRef {
+			nodes = append(nodes, n)
+		}
+	}
+
+	result := &KeyRotationResult{OldFingerprint: current.Fingerprint, NewFingerprint: next.Fingerprint, Nodes: []KeyRotationNode{}}
+	failed := false
+	for _, n := range nodes {
+		status := KeyRotationNode{NodeID: n.ID, NodeName: n.Name}
+		if err := m.installInstallerKey(n, next); err != nil {
+			status.Error = err.Error()
+			failed = true
+		} else {
+			status.Installed = true
+		}
+		result.Nodes = append(result.Nodes, status)
+	}
+
+	if failed {
+		for i, n := range nodes {
+			if result.Nodes[i].Installed {
+				if err := m.runOnNode(n, removeKeyCommand(next.PublicKey)); err != nil {
+					result.Nodes[i].Error = fmt.Sprintf("failed to remove the new key after aborting rotation: %v", err)
+				}
+			}
+			m.recordKeyRotation(n, result, result.Nodes[i])
+		}
+		return result, nil
+	}
+
+	if err := keys.replace(next); err != nil {
+		return nil, err
+	}
+	result.Rotated = true
+	// 节点通过InstallerKeyRef引用私钥，替换后立即使用新密钥连接
+	for i, n := range nodes {
+		if err := m.runOnNode(n, removeKeyCommand(current.PublicKey)); err != nil {
+			result.Nodes[i].Error = fmt.Sprintf("failed to remove the old key: %v", err)
+		} else {
+			result.Nodes[i].OldKeyRemoved = true
+		}
+		m.recordKeyRotation(n, result, result.Nodes[i])
+	}
+	return result, nil
+}
+
+// runOnNode 使用节点的凭据连接并执行命令
+func (m *SqliteNodeManager) runOnNode(n Node, cmd string) error {
+	client, err := Connect(n)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if output, err := client.RunCommandSilent(cmd); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// recordKeyRotation 记录节点的密钥轮换结果
+func (m *SqliteNodeManager) recordKeyRotation(n Node, result *KeyRotationResult, status KeyRotationNode) {
+	if m.logManager == nil {
+		return
+	}
+	logStatus, output := "success", fmt.Sprintf("安装器密钥已从 %s 轮换为 %s", result.OldFingerprint, result.NewFingerprint)
+	switch {
+	case !result.Rotated:
+		logStatus, output = "failed", "安装器密钥轮换已中止，节点继续使用当前密钥"
+	case !status.OldKeyRemoved:
+		logStatus, output = "failed", output+"，但旧公钥未能删除"
+	}
+	if status.Error != "" {
+		output += ": " + status.Error
+	}
+	now := time.Now()
+	m.logManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Operation: "SSHKeyRotation",
+		Command:   fmt.Sprintf("rotate %s -> %s", result.OldFingerprint, result.NewFingerprint),
+		Output:    output,
+		Status:    logStatus,
+		Type:      log.TypeSystem,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
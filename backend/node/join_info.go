@@ -0,0 +1,201 @@
+package node
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// JoinInfo 从join命令解析出的集群加入参数，保存在Master节点上。
+// 新节点加入集群时由这些字段生成join命令，而不是重放保存的命令字符串
+type JoinInfo struct {
+	Endpoint   string `json:"endpoint"`
+	Token      string `json:"token"`
+	CACertHash string `json:"caCertHash"`
+	// CertificateKey kubeadm init --upload-certs输出的证书密钥，控制平面节点加入时使用，2小时后失效
+	CertificateKey string `json:"certificateKey,omitempty"`
+}
+
+// join命令参数的格式
+var (
+	joinTokenPattern      = regexp.MustCompile(`^[a-z0-9]{6}\.[a-z0-9]{16}$`)
+	joinCACertHashPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	certificateKeyPattern = regexp.MustCompile(`^[a-f0-9]{64}$`)
+)
+
+// ErrNoJoinCommand 输出中没有kubeadm join命令
+var ErrNoJoinCommand = errors.New("no kubeadm join command found")
+
+// ParseJoinCommand 解析kubeadm init或kubeadm token create --print-join-command的输出。
+// 支持以反斜杠续行的多行命令和--flag=value形式的参数；kubeadm init输出的控制平面和worker两条命令合并为一份参数
+func ParseJoinCommand(output string) (*JoinInfo, error) {
+	output = strings.NewReplacer("\\\r\n", " ", "\\\n", " ").Replace(output)
+
+	var info *JoinInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		start := -1
+		for i := 0; i+1 < len(fields); i++ {
+			if fields[i] == "kubeadm" && fields[i+1] == "join" {
+				start = i + 2
+				break
+			}
+		}
+		if start < 0 {
+			continue
+		}
+
+		var parsed JoinInfo
+		args := fields[start:]
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			if !strings.HasPrefix(arg, "--") {
+				if parsed.Endpoint == "" {
+					parsed.Endpoint = arg
+				}
+				continue
+			}
+			name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+			if name == "control-plane" {
+				continue
+			}
+			if !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			switch name {
+			case "token":
+				parsed.Token = value
+			case "discovery-token-ca-cert-hash":
+				// 可以指定多个CA哈希，使用第一个
+				if parsed.CACertHash == "" {
+					parsed.CACertHash = value
+				}
+			case "certificate-key":
+				parsed.CertificateKey = value
+			}
+		}
+
+		if info == nil {
+			info = &parsed
+		} else if info.CertificateKey == "" {
+			info.CertificateKey = parsed.CertificateKey
+		}
+	}
+	if info == nil {
+		return nil, ErrNoJoinCommand
+	}
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Validate 检查控制平面地址、令牌、CA哈希和证书密钥的格式，防止生成的命令被注入
+func (j JoinInfo) Validate() error {
+	if host, port, err := net.SplitHostPort(j.Endpoint); err != nil || host == "" || port == "" {
+		return fmt.Errorf("invalid join endpoint %q, expected host:port", j.Endpoint)
+	}
+	if !joinTokenPattern.MatchString(j.Token) {
+		return fmt.Errorf("invalid join token %q", j.Token)
+	}
+	if !joinCACertHashPattern.MatchString(j.CACertHash) {
+		return fmt.Errorf("invalid discovery-token-ca-cert-hash %q, expected sha256:<hex>", j.CACertHash)
+	}
+	if j.CertificateKey != "" && !certificateKeyPattern.MatchString(j.CertificateKey) {
+		return errors.New("invalid certificate key, expected 64 hex characters")
+	}
+	return nil
+}
+
+// Command 生成worker节点的join命令
+func (j JoinInfo) Command() string {
+	return fmt.Sprintf("kubeadm join %s --token %s --discovery-token-ca-cert-hash %s", j.Endpoint, j.Token, j.CACertHash)
+}
+
+// ControlPlaneCommand 生成控制平面节点的join命令，没有证书密钥时返回空字符串
+func (j JoinInfo) ControlPlaneCommand() string {
+	if j.CertificateKey == "" {
+		return ""
+	}
+	return j.Command() + " --control-plane --certificate-key " + j.CertificateKey
+}
+
+// migrateNodeJoinInfo 添加join参数列，并解析已保存的join命令
+func migrateNodeJoinInfo(db *sql.DB) error {
+	var columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('nodes') WHERE name = 'join_info'").Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check join_info column: %v", err)
+	}
+	if columnExists {
+		return nil
+	}
+	if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN join_info TEXT"); err != nil {
+		return fmt.Errorf("failed to add join_info column: %v", err)
+	}
+
+	rows, err := db.Query("SELECT id, join_command FROM nodes WHERE COALESCE(join_command, '') != ''")
+	if err != nil {
+		return fmt.Errorf("failed to query join commands: %v", err)
+	}
+	joinInfos := make(map[string]string)
+	for rows.Next() {
+		var id, joinCommand string
+		if err := rows.Scan(&id, &joinCommand); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan join command: %v", err)
+		}
+		if info, err := ParseJoinCommand(joinCommand); err == nil {
+			joinInfos[id] = encodeJoinInfo(info)
+		}
+	}
+	rows.Close()
+	for id, info := range joinInfos {
+		if _, err := db.Exec("UPDATE nodes SET join_info = ? WHERE id = ?", info, id); err != nil {
+			return fmt.Errorf("failed to update join_info: %v", err)
+		}
+	}
+	return nil
+}
+
+// syncJoinInfo 保存节点前根据join命令更新join参数：join命令为空时清除，
+// 能解析时使用解析结果，命令中没有证书密钥时保留已有的证书密钥
+func syncJoinInfo(n *Node) {
+	if strings.TrimSpace(n.JoinCommand) == "" {
+		n.JoinInfo = nil
+		return
+	}
+	parsed, err := ParseJoinCommand(n.JoinCommand)
+	if err != nil {
+		return
+	}
+	if parsed.CertificateKey == "" && n.JoinInfo != nil && n.JoinInfo.Endpoint == parsed.Endpoint {
+		parsed.CertificateKey = n.JoinInfo.CertificateKey
+	}
+	n.JoinInfo = parsed
+}
+
+// encodeJoinInfo 将join参数编码为JSON列的值
+func encodeJoinInfo(info *JoinInfo) string {
+	if info == nil {
+		return ""
+	}
+	data, _ := json.Marshal(info)
+	return string(data)
+}
+
+// decodeJoinInfo 解析JSON列中的join参数，为空或无效时返回nil
+func decodeJoinInfo(value string) *JoinInfo {
+	if value == "" {
+		return nil
+	}
+	var info JoinInfo
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		return nil
+	}
+	return &info
+}
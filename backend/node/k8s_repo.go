@@ -0,0 +1,58 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultKubeRepoMirror Kubernetes社区软件源pkgs.k8s.io，每个次版本号一个仓库，如v1.30/deb/、v1.30/rpm/。
+// 镜像源需要保持相同的目录结构，如https://mirrors.aliyun.com/kubernetes-new/core/stable/
+const DefaultKubeRepoMirror = "https://pkgs.k8s.io/core:/stable:/"
+
+// DefaultKubeRepoVersion 未指定Kubernetes版本时（如单独部署节点）使用的仓库次版本号
+const DefaultKubeRepoVersion = "1.30"
+
+// 脚本中可使用的Kubernetes仓库占位符，执行前由KubeRepo.Expand替换
+const (
+	KubeRepoChannelPlaceholder = "${k8s_repo_channel}"
+	KubeRepoDebPlaceholder     = "${k8s_repo_deb}"
+	KubeRepoRpmPlaceholder     = "${k8s_repo_rpm}"
+)
+
+// KubeRepo Kubernetes次版本号对应的软件仓库
+type KubeRepo struct {
+	// Channel 仓库对应的次版本号，如v1.30
+	Channel string `json:"channel"`
+	DebURL  string `json:"debUrl"`
+	RpmURL  string `json:"rpmUrl"`
+}
+
+// NewKubeRepo 按Kubernetes版本生成仓库地址，mirror为空时使用pkgs.k8s.io
+func NewKubeRepo(kubeVersion, mirror string) (KubeRepo, error) {
+	var major, minor int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(strings.TrimSpace(kubeVersion), "v"), "%d.%d", &major, &minor); err != nil {
+		return KubeRepo{}, fmt.Errorf("invalid Kubernetes version %q: %v", kubeVersion, err)
+	}
+	mirror = strings.TrimSpace(mirror)
+	if mirror == "" {
+		mirror = DefaultKubeRepoMirror
+	}
+	if !strings.HasSuffix(mirror, "/") {
+		mirror += "/"
+	}
+	channel := fmt.Sprintf("v%d.%d", major, minor)
+	return KubeRepo{
+		Channel: channel,
+		DebURL:  mirror + channel + "/deb/",
+		RpmURL:  mirror + channel + "/rpm/",
+	}, nil
+}
+
+// Expand 替换脚本中的仓库占位符
+func (r KubeRepo) Expand(script string) string {
+	return strings.NewReplacer(
+		KubeRepoChannelPlaceholder, r.Channel,
+		KubeRepoDebPlaceholder, r.DebURL,
+		KubeRepoRpmPlaceholder, r.RpmURL,
+	).Replace(script)
+}
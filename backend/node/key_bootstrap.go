@@ -0,0 +1,104 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-installer/log"
+)
+
+// KeyBootstrapResult 节点切换到安装器密钥认证的结果
+type KeyBootstrapResult struct {
+	NodeID      string `json:"nodeId"`
+	NodeName    string `json:"nodeName"`
+	Fingerprint string `json:"fingerprint"`
+	// AlreadyBootstrapped 节点已经使用安装器密钥，没有做任何修改
+	AlreadyBootstrapped bool `json:"alreadyBootstrapped"`
+}
+
+// BootstrapKeyAuth 使用节点现有的凭据（通常是密码）连接一次，把安装器公钥加入authorized_keys，
+// 确认可以用安装器私钥登录后把节点的凭据改为InstallerKeyRef，并删除保存的密码和私钥，之后的操作只使用密钥认证。
+// 密钥登录验证失败时不修改节点记录
+func (m *SqliteNodeManager) BootstrapKeyAuth(id string, keys *InstallerKeyManager) (*KeyBootstrapResult, error) {
+	n, err := m.GetNode(id)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keys.Ensure()
+	if err != nil {
+		return nil, err
+	}
+	result := &KeyBootstrapResult{NodeID: n.ID, NodeName: n.Name, Fingerprint: key.Fingerprint}
+	if n.PrivateKeyRef == InstallerKeyRef && n.Password == "" && n.PasswordRef == "" && n.PrivateKey == "" {
+		result.AlreadyBootstrapped = true
+		return result, nil
+	}
+	if !n.HasCredentials() {
+		return nil, fmt.Errorf("node %s has no credentials to bootstrap key authentication with", n.Name)
+	}
+
+	err = m.installInstallerKey(*n, key)
+	m.recordKeyBootstrap(*n, key, err)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, err := m.db.Exec(
+		"UPDATE nodes SET password = '', private_key = '', password_ref = '', private_key_ref = ?, updated_at = ? WHERE id = ?",
+		InstallerKeyRef, time.Now(), n.ID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to update node credentials: %v", err)
+	}
+	return result, nil
+}
+
+// installInstallerKey 使用节点现有凭据安装公钥，再只用安装器私钥连接验证
+func (m *SqliteNodeManager) installInstallerKey(n Node, key *InstallerKey) error {
+	client, err := Connect(n)
+	if err != nil {
+		return err
+	}
+	output, err := client.RunCommandSilent(authorizeKeysCommand(key.PublicKey))
+	client.Close()
+	if err != nil {
+		return fmt.Errorf("failed to install installer SSH key on node %s: %v: %s", n.Name, err, strings.TrimSpace(output))
+	}
+
+	keyOnly := Node{IP: n.IP, Port: n.Port, Username: n.Username, PrivateKey: key.PrivateKey}
+	client, err = Connect(keyOnly)
+	if err != nil {
+		return fmt.Errorf("installer SSH key was installed on node %s but key login failed, check PubkeyAuthentication in sshd_config: %v", n.Name, err)
+	}
+	defer client.Close()
+	if output, err := client.RunCommandSilent("true"); err != nil {
+		return fmt.Errorf("installer SSH key login on node %s cannot run commands: %v: %s", n.Name, err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// recordKeyBootstrap 记录切换到密钥认证的结果
+func (m *SqliteNodeManager) recordKeyBootstrap(n Node, key *InstallerKey, err error) {
+	if m.logManager == nil {
+		return
+	}
+	status, output := "success", fmt.Sprintf("已安装安装器公钥 %s，节点改为使用密钥认证，已删除保存的密码", key.Fingerprint)
+	if err != nil {
+		status, output = "failed", fmt.Sprintf("切换到密钥认证失败，节点凭据未修改: %v", err)
+	}
+	now := time.Now()
+	m.logManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Operation: "SSHKeyBootstrap",
+		Command:   fmt.Sprintf("install %s for %s@%s:%d", key.Fingerprint, n.Username, n.IP, n.Port),
+		Output:    output,
+		Status:    status,
+		Type:      log.TypeSystem,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
@@ -0,0 +1,36 @@
+package node
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrateNodeManaged 添加节点管理方式列
+func migrateNodeManaged(db *sql.DB) error {
+	var columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('nodes') WHERE name = 'managed'").Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check managed column: %v", err)
+	}
+	if !columnExists {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN managed TEXT"); err != nil {
+			return fmt.Errorf("failed to add managed column: %v", err)
+		}
+	}
+	return nil
+}
+
+// SetNodeManaged 设置节点的管理方式，见NodeManagedAdopted
+func (m *SqliteNodeManager) SetNodeManaged(id, managed string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result, err := m.db.Exec("UPDATE nodes SET managed = ?, updated_at = ? WHERE id = ?", managed, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update node: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("node not found")
+	}
+	return nil
+}
@@ -58,7 +58,7 @@ func (m *MemoryNodeManager) CreateNode(node Node) (*Node, error) {
 
 	// 生成ID
 	if node.ID == "" {
-		node.ID = time.Now().Format("20060102150405")
+		node.ID = fmt.Sprintf("%d", time.Now().UnixNano())
 	}
 
 	// 设置默认值
@@ -76,6 +76,7 @@ func (m *MemoryNodeManager) CreateNode(node Node) (*Node, error) {
 
 	node.CreatedAt = time.Now()
 	node.UpdatedAt = time.Now()
+	syncJoinInfo(&node)
 
 	// 保存节点
 	m.nodes[node.ID] = node
@@ -97,6 +98,7 @@ func (m *MemoryNodeManager) UpdateNode(id string, node Node) (*Node, error) {
 	// 更新节点信息
 	node.ID = id
 	node.UpdatedAt = time.Now()
+	syncJoinInfo(&node)
 	m.nodes[id] = node
 
 	return &node, nil
@@ -139,11 +141,13 @@ func (m *MemoryNodeManager) TestConnection(id string) (bool, error) {
 
 	// 测试SSH连接
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -160,25 +164,11 @@ func (m *MemoryNodeManager) TestConnection(id string) (bool, error) {
 	defer client.Close()
 
 	// 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo "$ID"
-else
-	# 尝试获取其他发行版信息
-	if [ -f /etc/centos-release ]; then
-		echo "centos"
-	elif [ -f /etc/redhat-release ]; then
-		echo "rhel"
-	else
-		echo "unknown"
-	fi
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
 	osType := "unknown"
+	osInfo, err := DetectOS(client)
 	if err == nil {
-		osType = strings.TrimSpace(distroOutput)
+		osType = osInfo.Distro
+		node.OSInfo = &osInfo
 	}
 
 	// 更新节点状态为在线并保存操作系统类型
@@ -208,11 +198,13 @@ func (m *MemoryNodeManager) InstallKubernetesComponents(id string, kubeadmVersio
 
 	// 执行安装逻辑
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -221,152 +213,17 @@ func (m *MemoryNodeManager) InstallKubernetesComponents(id string, kubeadmVersio
 	}
 	defer client.Close()
 
-	// 1. 检测操作系统类型和版本
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo "$ID $VERSION_ID"
-else
-	# 尝试获取其他发行版信息
-	if [ -f /etc/centos-release ]; then
-		echo "centos $(grep -o '[0-9]\+\.[0-9]\+' /etc/centos-release)"
-	elif [ -f /etc/redhat-release ]; then
-		echo "rhel $(grep -o '[0-9]\+\.[0-9]\+' /etc/redhat-release)"
-	else
-		echo "unknown 0.0"
-	fi
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	// 1. 检测操作系统类型
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distroInfo := strings.TrimSpace(distroOutput)
-	distroParts := strings.Split(distroInfo, " ")
-	distro := distroParts[0]
-
-	// 2. 安装Kubernetes组件
-	var cmd string
-	switch distro {
-	case "ubuntu", "debian":
-		cmd = `
-	apt-get update && apt-get install -y apt-transport-https ca-certificates curl gpg
-	
-	# 创建keyring目录
-	mkdir -p -m 755 /etc/apt/keyrings
-	
-	# 下载并安装GPG密钥
-	curl -fsSL https://pkgs.k8s.io/core:/stable:/v1.30/deb/Release.key | gpg --dearmor -o /etc/apt/keyrings/kubernetes-apt-keyring.gpg
-	chmod 644 /etc/apt/keyrings/kubernetes-apt-keyring.gpg
-	
-	# 添加Kubernetes repo
-	echo "deb [signed-by=/etc/apt/keyrings/kubernetes-apt-keyring.gpg] https://pkgs.k8s.io/core:/stable:/v1.30/deb/ /" | tee /etc/apt/sources.list.d/kubernetes.list
-	chmod 644 /etc/apt/sources.list.d/kubernetes.list
-	
-	apt-get update
-	apt-get install -y kubelet kubeadm kubectl
-	apt-mark hold kubelet kubeadm kubectl
-	
-	# 生产环境优化：配置kubelet使用systemd cgroup驱动
-	cat <<EOF | tee /etc/default/kubelet
-	KUBELET_EXTRA_ARGS="--cgroup-driver=systemd --runtime-cgroups=/system.slice/containerd.service --kubelet-cgroups=/system.slice/kubelet.service"
-	EOF
-	
-	systemctl daemon-reload
-	systemctl restart kubelet
-	systemctl enable kubelet
-	`
-	case "centos", "rhel", "rocky", "almalinux":
-		cmd = `
-	# 检查包管理器类型
-	if command -v dnf &> /dev/null; then
-		PKG_MGR="dnf"
-		PKG_MGR_UTILS="dnf-utils"
-	else
-		PKG_MGR="yum"
-		PKG_MGR_UTILS="yum-utils"
-	fi
-	
-	# 更彻底地移除旧的Kubernetes repo配置
-	rm -f /etc/yum.repos.d/kubernetes.repo
-	rm -f /etc/yum.repos.d/packages.cloud.google.com_yum_repos_kubernetes*.repo
-	
-	# 移除所有可能的旧repo
-	for repo in $(sudo $PKG_MGR config-manager --list-repos 2>/dev/null | grep -i kubernetes | awk '{print $1}'); do
-		sudo $PKG_MGR config-manager --remove-repo "$repo" 2>/dev/null || true
-	done
-	
-	# 清理所有包含google或kubernetes的repo文件
-	rm -f /etc/yum.repos.d/*google*.repo
-	rm -f /etc/yum.repos.d/*kubernetes*.repo
-	
-	# 使用正确的Kubernetes repo URL，使用固定版本v1.30确保兼容性
-	cat <<'EOF' | tee /etc/yum.repos.d/kubernetes.repo
-[kubernetes]
-name=Kubernetes
-baseurl=https://pkgs.k8s.io/core:/stable:/v1.30/rpm/
-enabled=1
-gpgcheck=1
-gpgkey=https://pkgs.k8s.io/core:/stable:/v1.30/rpm/repodata/repomd.xml.key
-EOF
-	
-	# 清理并更新repo缓存
-	sudo $PKG_MGR clean all
-	sudo $PKG_MGR makecache
-	
-	# 安装必要的工具
-	sudo $PKG_MGR install -y $PKG_MGR_UTILS
-	
-	# 安装Kubernetes组件
-	sudo $PKG_MGR install -y kubelet kubeadm kubectl
-	
-	# 只在kubeadm安装成功后继续
-	if ! command -v kubeadm &> /dev/null; then
-		echo "ERROR: kubeadm command not found, installation failed!"
-		exit 1
-	fi
-	
-	# 安装dnf versionlock插件（如果使用dnf）并锁定版本
-	if [ "$PKG_MGR" = "dnf" ]; then
-		sudo $PKG_MGR install -y dnf-plugin-versionlock 2>/dev/null || true
-		sudo dnf versionlock add kubelet kubeadm kubectl 2>/dev/null || true
-	else
-		sudo yum versionlock add kubelet kubeadm kubectl 2>/dev/null || true
-	fi
-	
-	# 禁用SELINUX（生产环境推荐）
-	sudo setenforce 0 2>/dev/null || true
-	sudo sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config 2>/dev/null || true
-	
-	# 生产环境优化：配置kubelet使用systemd cgroup驱动
-	sudo mkdir -p /etc/sysconfig
-	cat <<EOF | sudo tee /etc/sysconfig/kubelet
-KUBELET_EXTRA_ARGS="--cgroup-driver=systemd --runtime-cgroups=/system.slice/containerd.service --kubelet-cgroups=/system.slice/kubelet.service"
-EOF
-	
-	# 检查并启动kubelet服务
-	sudo systemctl daemon-reload
-	if systemctl list-unit-files | grep -q kubelet.service; then
-		sudo systemctl restart kubelet
-		sudo systemctl enable kubelet
-		echo "kubelet.service enabled and started successfully"
-	else
-		# 尝试手动创建kubelet服务文件或使用其他方式启动
-		echo "Warning: kubelet.service unit file not found, trying to start kubelet directly..."
-		sudo kubelet --version
-	fi
-	
-	# 最后验证kubeadm安装是否成功
-	if ! command -v kubeadm &> /dev/null; then
-		echo "ERROR: kubeadm command not found, installation failed!"
-		exit 1
-	fi
-	
-	echo "Kubernetes components installed successfully"
-	kubeadm version
-	`
-	default:
-		return fmt.Errorf("unsupported distribution: %s", distro)
+	distro := osInfo.Distro
+
+	// 2. 安装Kubernetes组件并固定版本，仓库按kubeadmVersion的次版本号选择
+	cmd, err := kubeComponentsScript(m.scriptManager, distro, kubeadmVersion)
+	if err != nil {
+		return err
 	}
 
 	// 执行安装命令
@@ -375,51 +232,26 @@ EOF
 }
 
 // deployMasterNode 部署主节点
-func (m *MemoryNodeManager) deployMasterNode(client *ssh.SSHClient) error {
+func (m *MemoryNodeManager) deployMasterNode(client ssh.Runner) error {
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
-	// 2. 设置容器运行时（默认使用containerd，生产环境推荐）
-	containerRuntime := "containerd"
-	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
+	// 2. 系统准备（禁用swap、内核参数等），通过脚本管理器解析
+	systemPrepCmd, err := systemPrepScript(m.scriptManager, distro)
+	if err != nil {
 		return err
 	}
-
-	// 3. 禁用swap
-	swapCmd := `
-swapoff -a
-sed -i '/ swap / s/^\(.*\)$/#\1/g' /etc/fstab
-`
-	if _, err := client.RunCommand(swapCmd); err != nil {
+	if _, err := client.RunCommand(systemPrepCmd); err != nil {
 		return err
 	}
 
-	// 4. 设置内核参数（生产环境推荐配置）
-	kernelCmd := `
-cat <<EOF | sudo tee /etc/sysctl.d/k8s.conf
-net.bridge.bridge-nf-call-iptables  = 1
-net.bridge.bridge-nf-call-ip6tables = 1
-net.ipv4.ip_forward                 = 1
-vm.swappiness                       = 0
-vm.overcommit_memory                = 1
-kernel.panic                        = 10
-kernel.panic_on_oops                = 1
-EOF
-sysctl --system
-modprobe br_netfilter
-modprobe overlay
-`
-	if _, err := client.RunCommand(kernelCmd); err != nil {
+	// 3. 设置容器运行时（默认使用containerd，生产环境推荐）
+	containerRuntime := "containerd"
+	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
 		return err
 	}
 
@@ -427,52 +259,17 @@ modprobe overlay
 }
 
 // installContainerRuntime 安装容器运行时
-func (m *MemoryNodeManager) installContainerRuntime(client *ssh.SSHClient, distro, runtime, version string) error {
-	var cmd string
-	switch distro {
-	case "ubuntu", "debian":
-		// 只支持containerd
-		cmd = `
-		apt-get update && apt-get install -y apt-transport-https ca-certificates curl gnupg lsb-release
-		mkdir -p /etc/apt/keyrings
-		curl -fsSL https://download.docker.com/linux/ubuntu/gpg | gpg --dearmor -o /etc/apt/keyrings/docker.gpg
-		echo "deb [arch=$(dpkg --print-architecture) signed-by=/etc/apt/keyrings/docker.gpg] https://download.docker.com/linux/ubuntu $(lsb_release -cs) stable" | tee /etc/apt/sources.list.d/docker.list > /dev/null
-		apt-get update && apt-get install -y containerd.io
-		mkdir -p /etc/containerd
-		containerd config default | tee /etc/containerd/config.toml
-		# 生产环境优化：设置SystemdCgroup、 sandbox_image、默认运行时等
-		sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
-		sed -i 's/sandbox_image = "registry.k8s.io\/pause:3.6"/sandbox_image = "registry.k8s.io\/pause:3.9"/g' /etc/containerd/config.toml
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri\.containerd.runtimes.runc.options"\]/a\          SystemdCgroup = true' /etc/containerd/config.toml
-		# 配置镜像加速
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  sandbox_image = "registry.k8s.io\/pause:3.9"' /etc/containerd/config.toml
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  systemd_cgroup = true' /etc/containerd/config.toml
-		systemctl restart containerd
-		systemctl enable containerd
-		`
-	case "centos", "rhel", "rocky", "alma":
-		// 只支持containerd
-		cmd = `
-		yum install -y yum-utils
-		yum-config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo
-		yum install -y containerd.io
-		mkdir -p /etc/containerd
-		containerd config default | tee /etc/containerd/config.toml
-		# 生产环境优化：设置SystemdCgroup、 sandbox_image、默认运行时等
-		sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
-		sed -i 's/sandbox_image = "registry.k8s.io\/pause:3.6"/sandbox_image = "registry.k8s.io\/pause:3.9"/g' /etc/containerd/config.toml
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri\.containerd.runtimes.runc.options"\]/a\          SystemdCgroup = true' /etc/containerd/config.toml
-		# 配置镜像加速
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  sandbox_image = "registry.k8s.io\/pause:3.9"' /etc/containerd/config.toml
-		sed -i '/\[plugins."io.containerd.grpc.v1.cri"\]/a\  systemd_cgroup = true' /etc/containerd/config.toml
-		systemctl restart containerd
-		systemctl enable containerd
-		`
-	default:
-		return fmt.Errorf("unsupported distribution: %s", distro)
+func (m *MemoryNodeManager) installContainerRuntime(client ssh.Runner, distro, runtime, version string) error {
+	// 只支持containerd，安装和配置脚本通过脚本管理器解析
+	if runtime != "" && runtime != "containerd" {
+		return fmt.Errorf("unsupported container runtime: %s", runtime)
+	}
+	cmd, err := containerdScript(m.scriptManager, distro)
+	if err != nil {
+		return err
 	}
 
-	_, err := client.RunCommand(cmd)
+	_, err = client.RunCommand(cmd)
 	return err
 }
 
@@ -490,11 +287,13 @@ func (m *MemoryNodeManager) ConfigureSSHSettings(id string) error {
 
 	// 创建SSH客户端
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -565,11 +364,13 @@ func (m *MemoryNodeManager) ConfigureSSHPasswdless() error {
 	for _, node := range allNodes {
 		// 创建SSH客户端
 		sshConfig := ssh.SSHConfig{
-			Host:       node.IP,
-			Port:       node.Port,
-			Username:   node.Username,
-			Password:   node.Password,
-			PrivateKey: node.PrivateKey,
+			Host:          node.IP,
+			Port:          node.Port,
+			Username:      node.Username,
+			Password:      node.Password,
+			PrivateKey:    node.PrivateKey,
+			PasswordRef:   node.PasswordRef,
+			PrivateKeyRef: node.PrivateKeyRef,
 		}
 
 		client, err := ssh.NewSSHClient(sshConfig)
@@ -607,11 +408,13 @@ func (m *MemoryNodeManager) ConfigureSSHPasswdless() error {
 	for _, targetNode := range allNodes {
 		// 创建SSH客户端
 		sshConfig := ssh.SSHConfig{
-			Host:       targetNode.IP,
-			Port:       targetNode.Port,
-			Username:   targetNode.Username,
-			Password:   targetNode.Password,
-			PrivateKey: targetNode.PrivateKey,
+			Host:          targetNode.IP,
+			Port:          targetNode.Port,
+			Username:      targetNode.Username,
+			Password:      targetNode.Password,
+			PrivateKey:    targetNode.PrivateKey,
+			PasswordRef:   targetNode.PasswordRef,
+			PrivateKeyRef: targetNode.PrivateKeyRef,
 		}
 
 		client, err := ssh.NewSSHClient(sshConfig)
@@ -657,11 +460,13 @@ func (m *MemoryNodeManager) ConfigureSSHPasswdless() error {
 
 			// 创建SSH客户端
 			sshConfig := ssh.SSHConfig{
-				Host:       sourceNode.IP,
-				Port:       sourceNode.Port,
-				Username:   sourceNode.Username,
-				Password:   sourceNode.Password,
-				PrivateKey: sourceNode.PrivateKey,
+				Host:          sourceNode.IP,
+				Port:          sourceNode.Port,
+				Username:      sourceNode.Username,
+				Password:      sourceNode.Password,
+				PrivateKey:    sourceNode.PrivateKey,
+				PasswordRef:   sourceNode.PasswordRef,
+				PrivateKeyRef: sourceNode.PrivateKeyRef,
 			}
 
 			client, err := ssh.NewSSHClient(sshConfig)
@@ -684,51 +489,26 @@ func (m *MemoryNodeManager) ConfigureSSHPasswdless() error {
 }
 
 // deployWorkerNode 部署工作节点
-func (m *MemoryNodeManager) deployWorkerNode(client *ssh.SSHClient) error {
+func (m *MemoryNodeManager) deployWorkerNode(client ssh.Runner) error {
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
-	// 2. 设置容器运行时
-	containerRuntime := "containerd"
-	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
+	// 2. 系统准备（禁用swap、内核参数等），通过脚本管理器解析
+	systemPrepCmd, err := systemPrepScript(m.scriptManager, distro)
+	if err != nil {
 		return err
 	}
-
-	// 3. 禁用swap
-	swapCmd := `
-swapoff -a
-sed -i '/ swap / s/^\(.*\)$/#\1/g' /etc/fstab
-`
-	if _, err := client.RunCommand(swapCmd); err != nil {
+	if _, err := client.RunCommand(systemPrepCmd); err != nil {
 		return err
 	}
 
-	// 4. 设置内核参数
-	kernelCmd := `
-cat <<EOF | sudo tee /etc/sysctl.d/k8s.conf
-net.bridge.bridge-nf-call-iptables  = 1
-net.bridge.bridge-nf-call-ip6tables = 1
-net.ipv4.ip_forward                 = 1
-vm.swappiness                       = 0
-vm.overcommit_memory                = 1
-kernel.panic                        = 10
-kernel.panic_on_oops                = 1
-EOF
-sysctl --system
-modprobe br_netfilter
-modprobe overlay
-`
-	if _, err := client.RunCommand(kernelCmd); err != nil {
+	// 3. 设置容器运行时（默认使用containerd，生产环境推荐）
+	containerRuntime := "containerd"
+	if err := m.installContainerRuntime(client, distro, containerRuntime, ""); err != nil {
 		return err
 	}
 
@@ -751,11 +531,13 @@ func (m *MemoryNodeManager) DeployNode(id string) error {
 
 	// 执行部署逻辑
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -925,11 +707,13 @@ func (m *MemoryNodeManager) InstallContainerRuntime(id string, runtimeType strin
 
 	// 执行安装逻辑
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -939,17 +723,11 @@ func (m *MemoryNodeManager) InstallContainerRuntime(id string, runtimeType strin
 	defer client.Close()
 
 	// 1. 检测操作系统类型
-	distroCmd := `
-	if [ -f /etc/os-release ]; then
-		. /etc/os-release
-		echo $ID
-	fi
-	`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := DetectOS(client)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
 	// 2. 安装容器运行时
 	return m.installContainerRuntime(client, distro, runtimeType, version)
@@ -968,11 +746,13 @@ func (m *MemoryNodeManager) ConfigureContainerRuntime(id string, config Containe
 	case "containerd":
 		// 配置containerd
 		sshConfig := ssh.SSHConfig{
-			Host:       node.IP,
-			Port:       node.Port,
-			Username:   node.Username,
-			Password:   node.Password,
-			PrivateKey: node.PrivateKey,
+			Host:          node.IP,
+			Port:          node.Port,
+			Username:      node.Username,
+			Password:      node.Password,
+			PrivateKey:    node.PrivateKey,
+			PasswordRef:   node.PasswordRef,
+			PrivateKeyRef: node.PrivateKeyRef,
 		}
 		client, err := ssh.NewSSHClient(sshConfig)
 		if err != nil {
@@ -1032,11 +812,13 @@ func (m *MemoryNodeManager) StartContainerRuntime(id string, runtimeType string)
 
 	// 执行启动命令
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -1071,11 +853,13 @@ func (m *MemoryNodeManager) StopContainerRuntime(id string, runtimeType string)
 
 	// 执行停止命令
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -1113,11 +897,13 @@ func (m *MemoryNodeManager) RemoveContainerRuntime(id string, runtimeType string
 
 	// 执行移除命令
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -1179,11 +965,13 @@ func (m *MemoryNodeManager) EnableContainerRuntime(id string, runtimeType string
 
 	// 执行启用命令
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -1218,11 +1006,13 @@ func (m *MemoryNodeManager) DisableContainerRuntime(id string, runtimeType strin
 
 	// 执行禁用命令
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -1257,11 +1047,13 @@ func (m *MemoryNodeManager) CheckContainerRuntimeStatus(id string, runtimeType s
 
 	// 执行状态检查逻辑
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -19,8 +19,19 @@ type Node struct {
 	ContainerRuntime string    `json:"containerRuntime"` // 容器运行时类型：containerd, cri-o
 	OS               string    `json:"os"`               // 操作系统类型：ubuntu, centos, debian, rocky等
 	JoinCommand      string    `json:"joinCommand,omitempty"` // 集群加入命令
+	GroupID          string    `json:"groupId,omitempty"`     // 所属节点组ID，由节点组接口维护
+	ProjectID        string    `json:"projectId,omitempty"`   // 所属项目ID，创建时由请求的项目决定
 	CreatedAt        time.Time `json:"createdAt"`
 	UpdatedAt        time.Time `json:"updatedAt"`
+	// Managed 节点的管理方式，导入的已有集群的节点为NodeManagedAdopted，由本工具部署的节点为空
+	Managed string `json:"managed,omitempty"`
+	// PasswordRef PrivateKeyRef 凭据引用（env:NAME或file:/path），在建立SSH连接时读取，数据库中不保存凭据本身
+	PasswordRef   string `json:"passwordRef,omitempty"`
+	PrivateKeyRef string `json:"privateKeyRef,omitempty"`
+	// JoinInfo Master节点上保存的集群加入参数，由JoinCommand解析得到
+	JoinInfo *JoinInfo `json:"joinInfo,omitempty"`
+	// OSInfo 缓存的操作系统信息，由连接测试或部署时检测，节点地址变化时清空
+	OSInfo *OSInfo `json:"osInfo,omitempty"`
 }
 
 // ContainerRuntimeConfig 容器运行时配置结构体
@@ -81,6 +92,9 @@ type SSHConfig struct {
 	Username   string `json:"username"`
 	Password   string `json:"password,omitempty"`
 	PrivateKey string `json:"privateKey,omitempty"`
+	// PasswordRef PrivateKeyRef 凭据引用，见Node
+	PasswordRef   string `json:"passwordRef,omitempty"`
+	PrivateKeyRef string `json:"privateKeyRef,omitempty"`
 }
 
 // NodeStatus 节点状态常量
@@ -97,3 +111,6 @@ const (
 	NodeTypeMaster = "master"
 	NodeTypeWorker = "worker"
 )
+
+// NodeManagedAdopted 通过导入已有集群加入的节点
+const NodeManagedAdopted = "adopted"
@@ -0,0 +1,50 @@
+package node
+
+import "strings"
+
+// kubePackages 需要固定版本的Kubernetes软件包
+const kubePackages = "kubelet kubeadm kubectl"
+
+// PackagePinCmd 固定kubelet、kubeadm和kubectl的已安装版本，防止系统更新时被升级：
+// apt使用apt-mark hold，dnf/yum使用versionlock插件，zypper使用addlock。
+// kubeVersion不为空时检查已安装的kubeadm版本，与目标版本不一致时输出警告
+func PackagePinCmd(kubeVersion string) string {
+	return `# 固定Kubernetes组件版本
+echo "=== 固定Kubernetes组件版本 ==="
+PIN_VERSION="` + strings.TrimPrefix(kubeVersion, "v") + `"
+INSTALLED_VERSION=$(kubeadm version -o short 2>/dev/null | sed 's/^v//')
+if [ -n "$PIN_VERSION" ] && [ -n "$INSTALLED_VERSION" ] && [ "$INSTALLED_VERSION" != "$PIN_VERSION" ]; then
+    echo "警告: 已安装的kubeadm版本 $INSTALLED_VERSION 与目标版本 $PIN_VERSION 不一致，固定已安装的版本"
+fi
+if command -v apt-mark &> /dev/null; then
+    sudo apt-mark hold ` + kubePackages + `
+elif command -v dnf &> /dev/null; then
+    sudo dnf install -y 'dnf-command(versionlock)' > /dev/null 2>&1 || sudo dnf install -y python3-dnf-plugin-versionlock > /dev/null 2>&1 || true
+    sudo dnf versionlock delete ` + kubePackages + ` > /dev/null 2>&1 || true
+    sudo dnf versionlock add ` + kubePackages + `
+elif command -v yum &> /dev/null; then
+    sudo yum install -y yum-plugin-versionlock > /dev/null 2>&1 || true
+    sudo yum versionlock delete ` + kubePackages + ` > /dev/null 2>&1 || true
+    sudo yum versionlock add ` + kubePackages + `
+elif command -v zypper &> /dev/null; then
+    sudo zypper --non-interactive addlock ` + kubePackages + `
+else
+    echo "未检测到支持的包管理器，无法固定Kubernetes组件版本"
+    exit 1
+fi
+echo "✓ Kubernetes组件版本已固定 ${INSTALLED_VERSION:-$PIN_VERSION}"`
+}
+
+// PackageUnpinCmd 解除kubelet、kubeadm和kubectl的版本固定，升级Kubernetes组件之前执行
+const PackageUnpinCmd = `# 解除Kubernetes组件版本固定
+echo "=== 解除Kubernetes组件版本固定 ==="
+if command -v apt-mark &> /dev/null; then
+    sudo apt-mark unhold ` + kubePackages + `
+elif command -v dnf &> /dev/null; then
+    sudo dnf versionlock delete ` + kubePackages + ` > /dev/null 2>&1 || true
+elif command -v yum &> /dev/null; then
+    sudo yum versionlock delete ` + kubePackages + ` > /dev/null 2>&1 || true
+elif command -v zypper &> /dev/null; then
+    sudo zypper --non-interactive removelock ` + kubePackages + ` > /dev/null 2>&1 || true
+fi
+echo "✓ Kubernetes组件版本固定已解除"`
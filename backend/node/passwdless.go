@@ -0,0 +1,281 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s-installer/ssh"
+)
+
+// 免密登录路径的测试状态
+const (
+	PasswdlessLinkOK      = "ok"
+	PasswdlessLinkFailed  = "failed"
+	PasswdlessLinkSkipped = "skipped"
+)
+
+// 节点准备失败的阶段
+const (
+	PasswdlessStageKey       = "key"
+	PasswdlessStageAuthorize = "authorize"
+	PasswdlessStageTest      = "test"
+)
+
+// passwdlessTestTimeout 节点之间单次SSH登录测试的连接超时，单位为秒
+const passwdlessTestTimeout = 5
+
+// passwdlessResultPrefix 登录测试输出中结果行的前缀
+const passwdlessResultPrefix = "K8S_INSTALLER_PASSWDLESS"
+
+// PasswdlessOptions 配置SSH免密互通的选项
+type PasswdlessOptions struct {
+	// Concurrency 同时操作的节点数，默认DefaultExecConcurrency，最大MaxExecConcurrency
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// PasswdlessLink 从一个节点免密登录另一个节点的测试结果
+type PasswdlessLink struct {
+	FromNodeID   string `json:"fromNodeId"`
+	FromNodeName string `json:"fromNodeName"`
+	ToNodeID     string `json:"toNodeId"`
+	ToNodeName   string `json:"toNodeName"`
+	Status       string `json:"status"`
+	Message      string `json:"message,omitempty"`
+}
+
+// PasswdlessNodeError 节点在某个阶段失败，相关的登录路径标记为skipped
+type PasswdlessNodeError struct {
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	Stage    string `json:"stage"`
+	Error    string `json:"error"`
+}
+
+// PasswdlessResult SSH免密互通的配置结果和节点之间的登录矩阵
+type PasswdlessResult struct {
+	// Passed 所有节点都完成了配置且所有路径都登录成功
+	Passed  bool                  `json:"passed"`
+	OK      int                   `json:"ok"`
+	Failed  int                   `json:"failed"`
+	Skipped int                   `json:"skipped"`
+	Links   []PasswdlessLink      `json:"links"`
+	Errors  []PasswdlessNodeError `json:"errors,omitempty"`
+}
+
+// ensureKeyPairCmd 节点没有密钥对时生成，输出公钥
+const ensureKeyPairCmd = "mkdir -p ~/.ssh && chmod 700 ~/.ssh && " +
+	"{ [ -f ~/.ssh/id_rsa ] && [ -f ~/.ssh/id_rsa.pub ] || { rm -f ~/.ssh/id_rsa ~/.ssh/id_rsa.pub && ssh-keygen -t rsa -b 4096 -f ~/.ssh/id_rsa -N '' -q; }; } && " +
+	"chmod 600 ~/.ssh/id_rsa && chmod 644 ~/.ssh/id_rsa.pub && cat ~/.ssh/id_rsa.pub"
+
+// collectPublicKey 确保节点上有密钥对并返回公钥
+func collectPublicKey(client ssh.Runner) (string, error) {
+	output, err := client.RunCommandSilent(ensureKeyPairCmd)
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	publicKey := strings.TrimSpace(output)
+	if !strings.HasPrefix(publicKey, "ssh-") {
+		return "", fmt.Errorf("unexpected public key: %s", publicKey)
+	}
+	return publicKey, nil
+}
+
+// passwdlessTestCmd 在源节点上依次通过节点名称登录每个目标节点，每个目标输出一行：前缀 序号 退出码 最后一行输出。
+// BatchMode避免密钥未生效时等待输入密码
+func passwdlessTestCmd(targets []Node) string {
+	var b strings.Builder
+	for i, target := range targets {
+		fmt.Fprintf(&b, "out=$(ssh -o BatchMode=yes -o StrictHostKeyChecking=no -o ConnectTimeout=%d %s 'echo success' 2>&1 </dev/null); rc=$?; ",
+			passwdlessTestTimeout, shellQuote(target.Username+"@"+target.Name))
+		fmt.Fprintf(&b, "echo \"%s %d $rc $(printf '%%s' \"$out\" | tail -n 1)\"\n", passwdlessResultPrefix, i)
+	}
+	return b.String()
+}
+
+// parsePasswdlessTest 解析登录测试的输出，返回与targets顺序一致的状态和信息
+func parsePasswdlessTest(output string, count int) ([]string, []string) {
+	statuses := make([]string, count)
+	messages := make([]string, count)
+	for i := range statuses {
+		statuses[i] = PasswdlessLinkFailed
+		messages[i] = "no test result"
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 4)
+		if len(fields) < 3 || fields[0] != passwdlessResultPrefix {
+			continue
+		}
+		i, err := strconv.Atoi(fields[1])
+		if err != nil || i < 0 || i >= count {
+			continue
+		}
+		message := ""
+		if len(fields) == 4 {
+			message = strings.TrimSpace(fields[3])
+		}
+		if fields[2] == "0" && message == "success" {
+			statuses[i], messages[i] = PasswdlessLinkOK, ""
+		} else {
+			statuses[i], messages[i] = PasswdlessLinkFailed, fmt.Sprintf("exit %s: %s", fields[2], message)
+		}
+	}
+	return statuses, messages
+}
+
+// forEachNode 以有限的并发对每个节点执行fn
+func forEachNode(nodes []Node, concurrency int, fn func(i int, n Node)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fn(i, n)
+		}(i, n)
+	}
+	wg.Wait()
+}
+
+// configureSSHPasswdless 配置指定节点之间的SSH免密互通，分三个阶段并发执行：
+// 确保每个节点有密钥对并收集公钥；把所有公钥（以及安装器公钥）幂等地加入每个节点的authorized_keys并更新/etc/hosts；
+// 每个节点在一个会话中依次测试登录其他节点。只有前一阶段成功的节点参与后续阶段，失败的路径不影响其他节点
+func (m *SqliteNodeManager) configureSSHPasswdless(allNodes []Node, opts PasswdlessOptions) (*PasswdlessResult, error) {
+	if len(allNodes) < 2 {
+		return nil, fmt.Errorf("at least 2 nodes are required for SSH passwdless configuration")
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultExecConcurrency
+	}
+	if concurrency > MaxExecConcurrency {
+		concurrency = MaxExecConcurrency
+	}
+
+	var installerKey *InstallerKey
+	if m.installerKeys != nil {
+		key, err := m.installerKeys.Ensure()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get installer SSH key: %v", err)
+		}
+		installerKey = key
+	}
+
+	result := &PasswdlessResult{Links: []PasswdlessLink{}}
+	var mutex sync.Mutex
+	fail := func(n Node, stage string, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		result.Errors = append(result.Errors, PasswdlessNodeError{NodeID: n.ID, NodeName: n.Name, Stage: stage, Error: err.Error()})
+	}
+
+	// 1. 确保密钥对并收集公钥
+	publicKeys := make([]string, len(allNodes))
+	forEachNode(allNodes, concurrency, func(i int, n Node) {
+		client, err := Connect(n)
+		if err != nil {
+			fail(n, PasswdlessStageKey, err)
+			return
+		}
+		defer client.Close()
+		if publicKeys[i], err = collectPublicKey(client); err != nil {
+			fail(n, PasswdlessStageKey, err)
+		}
+	})
+	keys := make([]string, 0, len(allNodes)+1)
+	for _, key := range publicKeys {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	// 2. 分发公钥并更新/etc/hosts，公钥写入authorized_keys的标记块，块外的公钥保持不变。
+	// 收集到所有节点的公钥时替换标记块，清理已删除节点的公钥；否则只加入，避免删掉本次未能读取的节点公钥
+	complete := len(keys) == len(allNodes)
+	if installerKey != nil {
+		keys = append(keys, installerKey.PublicKey)
+	}
+	authorizeCmd := authorizedKeysCmd(keys, nil, complete) + " && " + HostsUpdateCmd(GenerateHostsBlock(allNodes))
+	authorized := make([]bool, len(allNodes))
+	forEachNode(allNodes, concurrency, func(i int, n Node) {
+		client, err := Connect(n)
+		if err != nil {
+			fail(n, PasswdlessStageAuthorize, err)
+			return
+		}
+		defer client.Close()
+		if output, err := client.RunCommandSilent(authorizeCmd); err != nil {
+			fail(n, PasswdlessStageAuthorize, fmt.Errorf("%v: %s", err, strings.TrimSpace(output)))
+			return
+		}
+		authorized[i] = true
+	})
+
+	// 3. 测试节点之间的免密登录
+	links := make([][]PasswdlessLink, len(allNodes))
+	forEachNode(allNodes, concurrency, func(i int, source Node) {
+		var targets []Node
+		for j, target := range allNodes {
+			if i == j {
+				continue
+			}
+			link := PasswdlessLink{FromNodeID: source.ID, FromNodeName: source.Name, ToNodeID: target.ID, ToNodeName: target.Name}
+			switch {
+			case publicKeys[i] == "":
+				link.Status, link.Message = PasswdlessLinkSkipped, "source node key pair is not available"
+			case !authorized[j]:
+				link.Status, link.Message = PasswdlessLinkSkipped, "public keys were not installed on target node"
+			default:
+				targets = append(targets, target)
+			}
+			links[i] = append(links[i], link)
+		}
+		if len(targets) == 0 {
+			return
+		}
+
+		statuses := make([]string, len(targets))
+		messages := make([]string, len(targets))
+		client, err := Connect(source)
+		if err != nil {
+			fail(source, PasswdlessStageTest, err)
+			for k := range statuses {
+				statuses[k], messages[k] = PasswdlessLinkSkipped, "failed to connect to source node"
+			}
+		} else {
+			output, err := client.RunCommandSilent(passwdlessTestCmd(targets))
+			client.Close()
+			if err != nil {
+				fail(source, PasswdlessStageTest, fmt.Errorf("%v: %s", err, strings.TrimSpace(output)))
+			}
+			statuses, messages = parsePasswdlessTest(output, len(targets))
+		}
+		next := 0
+		for k := range links[i] {
+			if links[i][k].Status == "" {
+				links[i][k].Status, links[i][k].Message = statuses[next], messages[next]
+				next++
+			}
+		}
+	})
+
+	for _, nodeLinks := range links {
+		for _, link := range nodeLinks {
+			switch link.Status {
+			case PasswdlessLinkOK:
+				result.OK++
+			case PasswdlessLinkFailed:
+				result.Failed++
+			default:
+				result.Skipped++
+			}
+			result.Links = append(result.Links, link)
+		}
+	}
+	result.Passed = result.Failed == 0 && result.Skipped == 0 && len(result.Errors) == 0
+	fmt.Printf("SSH免密互通配置完成: %d 个节点，成功 %d，失败 %d，跳过 %d\n", len(allNodes), result.OK, result.Failed, result.Skipped)
+	return result, nil
+}
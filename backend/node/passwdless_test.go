@@ -0,0 +1,28 @@
+package node
+
+import (
+	"testing"
+
+	"k8s-installer/ssh"
+)
+
+func TestCollectPublicKey(t *testing.T) {
+	rec := ssh.NewRecorder().On("ssh-keygen", "ssh-rsa AAAAB3NzaC1yc2E root@node-1\n", nil)
+	key, err := collectPublicKey(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "ssh-rsa AAAAB3NzaC1yc2E root@node-1" {
+		t.Fatalf("collectPublicKey() = %q", key)
+	}
+	if commands := rec.Commands(); len(commands) != 1 || commands[0] != ensureKeyPairCmd {
+		t.Fatalf("commands = %q, want only ensureKeyPairCmd", commands)
+	}
+}
+
+func TestCollectPublicKeyRejectsUnexpectedOutput(t *testing.T) {
+	rec := ssh.NewRecorder().On("ssh-keygen", "cat: /root/.ssh/id_rsa.pub: Permission denied\n", nil)
+	if _, err := collectPublicKey(rec); err == nil {
+		t.Fatal("expected an error for output that is not a public key")
+	}
+}
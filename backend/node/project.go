@@ -0,0 +1,239 @@
+package node
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-installer/validate"
+)
+
+// DefaultProjectID 默认项目，未指定项目的请求和升级前已有的节点、节点组属于该项目
+const DefaultProjectID = "default"
+
+// 错误定义
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectExists   = errors.New("project already exists")
+	ErrProjectNotEmpty = errors.New("project still has nodes or node groups")
+	ErrDefaultProject  = errors.New("the default project cannot be deleted")
+)
+
+// Project 项目，不同团队的节点、节点组、集群、脚本和日志按项目隔离
+type Project struct {
+	// ID 项目标识，同时作为请求头X-Project-ID的值，创建后不能修改
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Validate 检查项目标识
+func (p Project) Validate() error {
+	v := &validate.Validator{}
+	if v.Required("id", p.ID) {
+		v.DNSSubdomain("id", p.ID)
+	}
+	return v.Err()
+}
+
+// ProjectManager 项目管理器，项目保存在projects表中，节点和节点组通过project_id列归属项目
+type ProjectManager struct {
+	db    *sql.DB
+	mutex sync.RWMutex
+}
+
+// NewProjectManager 创建项目管理器，不存在默认项目时创建默认项目
+func NewProjectManager(db *sql.DB) (*ProjectManager, error) {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS projects (
+		id TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create projects table: %v", err)
+	}
+	if _, err := db.Exec(
+		"INSERT OR IGNORE INTO projects (id, description, created_at) VALUES (?, ?, ?)",
+		DefaultProjectID, "默认项目", time.Now(),
+	); err != nil {
+		return nil, fmt.Errorf("failed to create default project: %v", err)
+	}
+	if err := migrateGroupProject(db); err != nil {
+		return nil, err
+	}
+	return &ProjectManager{db: db}, nil
+}
+
+// migrateNodeProject 添加nodes表的project_id列，已有节点属于默认项目
+func migrateNodeProject(db *sql.DB) error {
+	return addProjectColumn(db, "nodes")
+}
+
+// migrateGroupProject 添加node_groups表的project_id列，已有节点组属于默认项目
+func migrateGroupProject(db *sql.DB) error {
+	return addProjectColumn(db, "node_groups")
+}
+
+// addProjectColumn 为表添加project_id列，表不存在时忽略
+func addProjectColumn(db *sql.DB, table string) error {
+	var tableExists, columnExists bool
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&tableExists); err != nil {
+		return fmt.Errorf("failed to check %s table: %v", table, err)
+	}
+	if !tableExists {
+		return nil
+	}
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info('%s') WHERE name = 'project_id'", table)).Scan(&columnExists); err != nil {
+		return fmt.Errorf("failed to check project_id column of %s: %v", table, err)
+	}
+	if !columnExists {
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN project_id TEXT NOT NULL DEFAULT '%s'", table, DefaultProjectID)); err != nil {
+			return fmt.Errorf("failed to add project_id column to %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// ListProjects 获取所有项目
+func (m *ProjectManager) ListProjects() ([]Project, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	rows, err := m.db.Query("SELECT id, description, created_at FROM projects ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %v", err)
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		var p Project
+		if err := rows.Scan(&p.ID, &p.Description, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %v", err)
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// GetProject 获取指定项目
+func (m *ProjectManager) GetProject(id string) (*Project, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var p Project
+	err := m.db.QueryRow("SELECT id, description, created_at FROM projects WHERE id = ?", id).Scan(&p.ID, &p.Description, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrProjectNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %v", err)
+	}
+	return &p, nil
+}
+
+// CreateProject 创建项目
+func (m *ProjectManager) CreateProject(p Project) (*Project, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	p.CreatedAt = time.Now()
+	if _, err := m.db.Exec("INSERT INTO projects (id, description, created_at) VALUES (?, ?, ?)", p.ID, p.Description, p.CreatedAt); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s", ErrProjectExists, p.ID)
+		}
+		return nil, fmt.Errorf("failed to insert project: %v", err)
+	}
+	return &p, nil
+}
+
+// DeleteProject 删除项目，默认项目不能删除，项目中还有节点或节点组时返回ErrProjectNotEmpty
+func (m *ProjectManager) DeleteProject(id string) error {
+	if id == DefaultProjectID {
+		return ErrDefaultProject
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var nodes, groups int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM nodes WHERE project_id = ?", id).Scan(&nodes); err != nil {
+		return fmt.Errorf("failed to count project nodes: %v", err)
+	}
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM node_groups WHERE project_id = ?", id).Scan(&groups); err != nil {
+		return fmt.Errorf("failed to count project node groups: %v", err)
+	}
+	if nodes > 0 || groups > 0 {
+		return fmt.Errorf("%w: %d nodes, %d node groups", ErrProjectNotEmpty, nodes, groups)
+	}
+
+	result, err := m.db.Exec("DELETE FROM projects WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, id)
+	}
+	return nil
+}
+
+// ProjectNodes 项目内的节点，只能读取属于该项目的节点，实现NodeLister
+type ProjectNodes struct {
+	manager   *SqliteNodeManager
+	projectID string
+}
+
+// InProject 返回指定项目内的节点
+func (m *SqliteNodeManager) InProject(projectID string) ProjectNodes {
+	if projectID == "" {
+		projectID = DefaultProjectID
+	}
+	return ProjectNodes{manager: m, projectID: projectID}
+}
+
+// ProjectID 项目ID
+func (p ProjectNodes) ProjectID() string {
+	return p.projectID
+}
+
+// GetNodes 获取项目内的所有节点
+func (p ProjectNodes) GetNodes() ([]Node, error) {
+	all, err := p.manager.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]Node, 0, len(all))
+	for _, n := range all {
+		if n.ProjectID == p.projectID {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+// GetNode 获取项目内的节点，节点属于其他项目时与节点不存在相同，不暴露其他项目的节点
+func (p ProjectNodes) GetNode(id string) (*Node, error) {
+	n, err := p.manager.GetNode(id)
+	if err != nil {
+		return nil, err
+	}
+	if n.ProjectID != p.projectID {
+		return nil, errors.New("node not found")
+	}
+	return n, nil
+}
+
+// Contains 节点是否属于该项目
+func (p ProjectNodes) Contains(id string) bool {
+	_, err := p.GetNode(id)
+	return err == nil
+}
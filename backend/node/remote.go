@@ -0,0 +1,18 @@
+package node
+
+import (
+	"k8s-installer/ssh"
+)
+
+// Connect 使用节点的SSH凭据建立连接
+func Connect(n Node) (*ssh.SSHClient, error) {
+	return ssh.NewSSHClient(ssh.SSHConfig{
+		Host:          n.IP,
+		Port:          n.Port,
+		Username:      n.Username,
+		Password:      n.Password,
+		PrivateKey:    n.PrivateKey,
+		PasswordRef:   n.PasswordRef,
+		PrivateKeyRef: n.PrivateKeyRef,
+	})
+}
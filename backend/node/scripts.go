@@ -0,0 +1,70 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s-installer/script"
+)
+
+// resolveStepScript 通过脚本管理器解析部署步骤脚本并替换${version}，没有设置脚本管理器时使用默认模板
+func resolveStepScript(scriptManager interface{}, step, distro, kubeVersion string) (script.Resolved, error) {
+	resolved, ok := script.Resolve(scriptManager, step, distro, DistroFamily(distro, ""))
+	if !ok {
+		return resolved, fmt.Errorf("no %s script for distribution %s", step, distro)
+	}
+	resolved.Content = strings.ReplaceAll(resolved.Content, "${version}", kubeVersion)
+	if resolved.Default {
+		fmt.Printf("Using default %s script\n", step)
+	} else {
+		fmt.Printf("Using custom %s script: %s\n", step, resolved.Name)
+	}
+	return resolved, nil
+}
+
+// systemPrepScript 系统准备脚本，使用默认模板时先禁用swap
+func systemPrepScript(scriptManager interface{}, distro string) (string, error) {
+	resolved, err := resolveStepScript(scriptManager, script.StepSystemPrep, distro, "")
+	if err != nil {
+		return "", err
+	}
+	if resolved.Default {
+		return script.DisableSwapCmd + "\n" + resolved.Content, nil
+	}
+	return resolved.Content, nil
+}
+
+// containerdScript containerd安装和配置脚本
+func containerdScript(scriptManager interface{}, distro string) (string, error) {
+	install, err := resolveStepScript(scriptManager, script.StepContainerdInstall, distro, "")
+	if err != nil {
+		return "", err
+	}
+	config, err := resolveStepScript(scriptManager, script.StepContainerdConfig, distro, "")
+	if err != nil {
+		return "", err
+	}
+	return install.Content + "\n" + config.Content, nil
+}
+
+// kubeComponentsScript 添加Kubernetes仓库并安装组件的脚本，替换仓库占位符并在最后固定版本，
+// kubeVersion为空时使用DefaultKubeRepoVersion的仓库
+func kubeComponentsScript(scriptManager interface{}, distro, kubeVersion string) (string, error) {
+	repoVersion := kubeVersion
+	if repoVersion == "" {
+		repoVersion = DefaultKubeRepoVersion
+	}
+	repo, err := NewKubeRepo(repoVersion, "")
+	if err != nil {
+		return "", err
+	}
+	addRepo, err := resolveStepScript(scriptManager, script.StepK8sRepo, distro, kubeVersion)
+	if err != nil {
+		return "", err
+	}
+	components, err := resolveStepScript(scriptManager, script.StepK8sComponents, distro, kubeVersion)
+	if err != nil {
+		return "", err
+	}
+	return repo.Expand(addRepo.Content+"\n"+components.Content) + "\n" + PackagePinCmd(kubeVersion), nil
+}
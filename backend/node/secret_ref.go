@@ -0,0 +1,62 @@
+package node
+
+import (
+	"database/sql"
+	"fmt"
+
+	"k8s-installer/ssh"
+	"k8s-installer/validate"
+)
+
+// migrateNodeSecretRefs 添加凭据引用列
+func migrateNodeSecretRefs(db *sql.DB) error {
+	for _, column := range []string{"password_ref", "private_key_ref"} {
+		var columnExists bool
+		if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('nodes') WHERE name = ?", column).Scan(&columnExists); err != nil {
+			return fmt.Errorf("failed to check %s column: %v", column, err)
+		}
+		if !columnExists {
+			if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN " + column + " TEXT"); err != nil {
+				return fmt.Errorf("failed to add %s column: %v", column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// HasCredentials 是否配置了密码、私钥或凭据引用
+func (n Node) HasCredentials() bool {
+	return n.Password != "" || n.PrivateKey != "" || n.PasswordRef != "" || n.PrivateKeyRef != ""
+}
+
+// validateSecretRefs 检查凭据引用的格式，同一凭据不能同时直接提供和通过引用提供
+func validateSecretRefs(v *validate.Validator, password, passwordRef, privateKey, privateKeyRef string) {
+	if passwordRef != "" {
+		if err := ssh.ValidateSecretRef(passwordRef); err != nil {
+			v.Add("passwordRef", "%v", err)
+		}
+		if password != "" {
+			v.Add("passwordRef", "cannot be used together with password")
+		}
+	}
+	if privateKeyRef != "" {
+		if err := ssh.ValidateSecretRef(privateKeyRef); err != nil {
+			v.Add("privateKeyRef", "%v", err)
+		}
+		if privateKey != "" {
+			v.Add("privateKeyRef", "cannot be used together with privateKey")
+		}
+	}
+}
+
+// HasCredentials 是否配置了密码、私钥或凭据引用
+func (c SSHConfig) HasCredentials() bool {
+	return c.Password != "" || c.PrivateKey != "" || c.PasswordRef != "" || c.PrivateKeyRef != ""
+}
+
+// ValidateSecretRefs 检查凭据引用的格式
+func (c SSHConfig) ValidateSecretRefs() error {
+	v := &validate.Validator{}
+	validateSecretRefs(v, c.Password, c.PasswordRef, c.PrivateKey, c.PrivateKeyRef)
+	return v.Err()
+}
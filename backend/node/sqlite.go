@@ -0,0 +1,66 @@
+package node
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// sqliteBusyTimeout 数据库被其他连接锁定时等待的时间，超时后才返回database is locked
+	sqliteBusyTimeout = 5 * time.Second
+	// sqliteMaxOpenConns 最大连接数，WAL模式下读连接可以并发，写入仍然串行，连接过多只会增加锁等待
+	sqliteMaxOpenConns = 8
+)
+
+// openSqlite 打开SQLite数据库：使用WAL模式使读写互不阻塞，每个连接设置busy_timeout，
+// 事务以IMMEDIATE模式开始，避免两个事务同时从读锁升级为写锁时直接失败
+func openSqlite(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate",
+		path, sqliteBusyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(sqliteMaxOpenConns)
+	db.SetMaxIdleConns(sqliteMaxOpenConns)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// DBHealth 数据库健康状态
+type DBHealth struct {
+	Status      string  `json:"status"`
+	LatencyMs   float64 `json:"latencyMs"`
+	JournalMode string  `json:"journalMode,omitempty"`
+	OpenConns   int     `json:"openConns"`
+	InUseConns  int     `json:"inUseConns"`
+	WaitCount   int64   `json:"waitCount"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// CheckDB 执行一次查询检查数据库是否可用，返回查询延迟和连接池状态
+func (m *SqliteNodeManager) CheckDB(ctx context.Context) DBHealth {
+	start := time.Now()
+	var journalMode string
+	err := m.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode)
+	stats := m.db.Stats()
+
+	health := DBHealth{
+		Status:      "ok",
+		LatencyMs:   float64(time.Since(start).Microseconds()) / 1000,
+		JournalMode: journalMode,
+		OpenConns:   stats.OpenConnections,
+		InUseConns:  stats.InUse,
+		WaitCount:   stats.WaitCount,
+	}
+	if err != nil {
+		health.Status = "error"
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"k8s-installer/log"
+	"k8s-installer/script"
 	"k8s-installer/ssh"
 
 	// 使用纯Go实现的SQLite驱动，不需要CGO
@@ -21,6 +22,8 @@ type SqliteNodeManager struct {
 	mutex         sync.RWMutex
 	scriptManager interface{}    // 脚本管理器接口
 	logManager    log.LogManager // 日志管理器
+	// installerKeys 安装器密钥，配置SSH免密互通时分发其公钥
+	installerKeys *InstallerKeyManager
 }
 
 // GetDB 获取数据库连接
@@ -36,7 +39,7 @@ func (m *SqliteNodeManager) GetLogManager() log.LogManager {
 // NewSqliteNodeManager 创建新的SQLite节点管理器
 func NewSqliteNodeManager(dbPath string) (*SqliteNodeManager, error) {
 	// 打开数据库连接，使用modernc.org/sqlite驱动，驱动名称为"sqlite"
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := openSqlite(dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
@@ -72,6 +75,32 @@ func NewSqliteNodeManager(dbPath string) (*SqliteNodeManager, error) {
 		fmt.Printf("Warning: failed to add join_command column: %v\n", err)
 	}
 
+	// IP+端口和名称唯一约束
+	if err := migrateNodeUniqueness(db); err != nil {
+		return nil, err
+	}
+	// 节点所属的节点组
+	if err := migrateNodeGroup(db); err != nil {
+		return nil, err
+	}
+	// 节点的管理方式
+	if err := migrateNodeSecretRefs(db); err != nil {
+		return nil, err
+	}
+	if err := migrateNodeManaged(db); err != nil {
+		return nil, err
+	}
+	if err := migrateNodeJoinInfo(db); err != nil {
+		return nil, err
+	}
+	if err := migrateNodeOSInfo(db); err != nil {
+		return nil, err
+	}
+	// 节点所属的项目
+	if err := migrateNodeProject(db); err != nil {
+		return nil, err
+	}
+
 	// 创建scripts表，用于存储部署流程脚本
 	createScriptsTableSQL := `
 	CREATE TABLE IF NOT EXISTS scripts (
@@ -93,10 +122,17 @@ func NewSqliteNodeManager(dbPath string) (*SqliteNodeManager, error) {
 		return nil, fmt.Errorf("failed to create log manager: %v", err)
 	}
 
-	return &SqliteNodeManager{
+	manager := &SqliteNodeManager{
 		db:         db,
 		logManager: logManager,
-	}, nil
+	}
+	// 登记已有节点的密码，日志中出现时脱敏
+	if nodes, err := manager.GetNodes(); err == nil {
+		for _, n := range nodes {
+			log.RegisterSecret(n.Password)
+		}
+	}
+	return manager, nil
 }
 
 // GetNodes 获取所有节点
@@ -104,7 +140,7 @@ func (m *SqliteNodeManager) GetNodes() ([]Node, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	rows, err := m.db.Query("SELECT id, name, ip, port, username, password, private_key, node_type, status, os, join_command, created_at, updated_at FROM nodes")
+	rows, err := m.db.Query("SELECT id, name, ip, port, username, password, private_key, node_type, status, os, join_command, COALESCE(group_id, ''), COALESCE(managed, ''), COALESCE(password_ref, ''), COALESCE(private_key_ref, ''), COALESCE(join_info, ''), COALESCE(os_info, ''), project_id, created_at, updated_at FROM nodes")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query nodes: %v", err)
 	}
@@ -113,6 +149,7 @@ func (m *SqliteNodeManager) GetNodes() ([]Node, error) {
 	var nodes []Node
 	for rows.Next() {
 		var node Node
+		var joinInfo, osInfo string
 		if err := rows.Scan(
 			&node.ID,
 			&node.Name,
@@ -125,11 +162,20 @@ func (m *SqliteNodeManager) GetNodes() ([]Node, error) {
 			&node.Status,
 			&node.OS,
 			&node.JoinCommand,
+			&node.GroupID,
+			&node.Managed,
+			&node.PasswordRef,
+			&node.PrivateKeyRef,
+			&joinInfo,
+			&osInfo,
+			&node.ProjectID,
 			&node.CreatedAt,
 			&node.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan node: %v", err)
 		}
+		node.JoinInfo = decodeJoinInfo(joinInfo)
+		node.OSInfo = decodeOSInfo(osInfo)
 		nodes = append(nodes, node)
 	}
 
@@ -146,8 +192,9 @@ func (m *SqliteNodeManager) GetNode(id string) (*Node, error) {
 	defer m.mutex.RUnlock()
 
 	var node Node
+	var joinInfo, osInfo string
 	err := m.db.QueryRow(
-		"SELECT id, name, ip, port, username, password, private_key, node_type, status, os, join_command, created_at, updated_at FROM nodes WHERE id = ?",
+		"SELECT id, name, ip, port, username, password, private_key, node_type, status, os, join_command, COALESCE(group_id, ''), COALESCE(managed, ''), COALESCE(password_ref, ''), COALESCE(private_key_ref, ''), COALESCE(join_info, ''), COALESCE(os_info, ''), project_id, created_at, updated_at FROM nodes WHERE id = ?",
 		id,
 	).Scan(
 		&node.ID,
@@ -161,6 +208,13 @@ func (m *SqliteNodeManager) GetNode(id string) (*Node, error) {
 		&node.Status,
 		&node.OS,
 		&node.JoinCommand,
+		&node.GroupID,
+		&node.Managed,
+		&node.PasswordRef,
+		&node.PrivateKeyRef,
+		&joinInfo,
+		&osInfo,
+		&node.ProjectID,
 		&node.CreatedAt,
 		&node.UpdatedAt,
 	)
@@ -171,18 +225,25 @@ func (m *SqliteNodeManager) GetNode(id string) (*Node, error) {
 		}
 		return nil, fmt.Errorf("failed to get node: %v", err)
 	}
+	node.JoinInfo = decodeJoinInfo(joinInfo)
+	node.OSInfo = decodeOSInfo(osInfo)
 
 	return &node, nil
 }
 
-// CreateNode 创建新节点
+// CreateNode 创建新节点，IP+端口或名称与已有节点重复时返回*DuplicateNodeError
 func (m *SqliteNodeManager) CreateNode(node Node) (*Node, error) {
+	return m.CreateNodeWithOptions(node, WriteOptions{})
+}
+
+// CreateNodeWithOptions 按选项创建新节点
+func (m *SqliteNodeManager) CreateNodeWithOptions(node Node, opts WriteOptions) (*Node, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// 生成ID（这里简化处理，实际应该使用UUID）
+	// 生成ID，同一秒内创建的节点也不会冲突
 	if node.ID == "" {
-		node.ID = time.Now().Format("20060102150405")
+		node.ID = fmt.Sprintf("%d", time.Now().UnixNano())
 	}
 
 	// 设置默认值
@@ -202,16 +263,32 @@ func (m *SqliteNodeManager) CreateNode(node Node) (*Node, error) {
 		node.CreatedAt = time.Now()
 	}
 
+	if node.ProjectID == "" {
+		node.ProjectID = DefaultProjectID
+	}
+
+	// 所属节点组由节点组接口维护，管理方式由导入集群接口维护，操作系统信息由检测结果维护
+	node.GroupID = ""
+	node.Managed = ""
+	node.OSInfo = nil
+
 	node.UpdatedAt = time.Now()
 
 	// 设置默认操作系统类型
 	if node.OS == "" {
 		node.OS = "unknown"
 	}
+	syncJoinInfo(&node)
+
+	if !opts.AllowDuplicate {
+		if err := m.findDuplicate(node, ""); err != nil {
+			return nil, err
+		}
+	}
 
 	// 插入数据
 	_, err := m.db.Exec(
-		"INSERT INTO nodes (id, name, ip, port, username, password, private_key, node_type, status, os, join_command, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO nodes (id, name, ip, port, username, password, private_key, password_ref, private_key_ref, node_type, status, os, join_command, join_info, project_id, created_at, updated_at, allow_duplicate) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		node.ID,
 		node.Name,
 		node.IP,
@@ -219,65 +296,107 @@ func (m *SqliteNodeManager) CreateNode(node Node) (*Node, error) {
 		node.Username,
 		node.Password,
 		node.PrivateKey,
+		node.PasswordRef,
+		node.PrivateKeyRef,
 		node.NodeType,
 		node.Status,
 		node.OS,
 		node.JoinCommand,
+		encodeJoinInfo(node.JoinInfo),
+		node.ProjectID,
 		node.CreatedAt,
 		node.UpdatedAt,
+		opts.AllowDuplicate,
 	)
 
 	if err != nil {
+		if dupErr := constraintDuplicate(err, node); dupErr != nil {
+			return nil, dupErr
+		}
 		return nil, fmt.Errorf("failed to insert node: %v", err)
 	}
 
+	log.RegisterSecret(node.Password)
 	return &node, nil
 }
 
-// UpdateNode 更新节点信息
+// UpdateNode 更新节点信息，修改后的IP+端口或名称与其他节点重复时返回*DuplicateNodeError
 func (m *SqliteNodeManager) UpdateNode(id string, node Node) (*Node, error) {
+	return m.UpdateNodeWithOptions(id, node, WriteOptions{})
+}
+
+// UpdateNodeWithOptions 按选项更新节点信息
+func (m *SqliteNodeManager) UpdateNodeWithOptions(id string, node Node, opts WriteOptions) (*Node, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	// 检查节点是否存在
-	exists, err := m.nodeExists(id)
+	var current Node
+	var allowDuplicate bool
+	var currentOSInfo string
+	err := m.db.QueryRow("SELECT name, ip, port, allow_duplicate, COALESCE(group_id, ''), COALESCE(os_info, ''), project_id FROM nodes WHERE id = ?", id).
+		Scan(&current.Name, &current.IP, &current.Port, &allowDuplicate, &current.GroupID, &currentOSInfo, &current.ProjectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("node not found")
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get node: %v", err)
 	}
 
-	if !exists {
-		return nil, errors.New("node not found")
+	// 只在名称、IP或端口变化时检查重复，已允许重复的节点不再检查
+	changed := node.Name != current.Name || node.IP != current.IP || node.Port != current.Port
+	if changed && !opts.AllowDuplicate && !allowDuplicate {
+		if err := m.findDuplicate(node, id); err != nil {
+			return nil, err
+		}
 	}
 
-	// 更新节点信息
+	// 更新节点信息，所属节点组由节点组接口维护，所属项目不能修改
 	node.ID = id
+	node.GroupID = current.GroupID
+	node.ProjectID = current.ProjectID
 	node.UpdatedAt = time.Now()
+	// 缓存的操作系统信息由检测结果维护，节点地址变化时清空，下次连接时重新检测
+	node.OSInfo = nil
+	if node.IP == current.IP && node.Port == current.Port {
+		node.OSInfo = decodeOSInfo(currentOSInfo)
+	}
 
 	// 设置默认操作系统类型
 	if node.OS == "" {
 		node.OS = "unknown"
 	}
+	syncJoinInfo(&node)
 
 	_, err = m.db.Exec(
-		"UPDATE nodes SET name = ?, ip = ?, port = ?, username = ?, password = ?, private_key = ?, node_type = ?, status = ?, os = ?, join_command = ?, updated_at = ? WHERE id = ?",
+		"UPDATE nodes SET name = ?, ip = ?, port = ?, username = ?, password = ?, private_key = ?, password_ref = ?, private_key_ref = ?, node_type = ?, status = ?, os = ?, os_info = ?, join_command = ?, join_info = ?, updated_at = ?, allow_duplicate = MAX(allow_duplicate, ?) WHERE id = ?",
 		node.Name,
 		node.IP,
 		node.Port,
 		node.Username,
 		node.Password,
 		node.PrivateKey,
+		node.PasswordRef,
+		node.PrivateKeyRef,
 		node.NodeType,
 		node.Status,
 		node.OS,
+		encodeOSInfo(node.OSInfo),
 		node.JoinCommand,
+		encodeJoinInfo(node.JoinInfo),
 		node.UpdatedAt,
+		opts.AllowDuplicate,
 		node.ID,
 	)
 
 	if err != nil {
+		if dupErr := constraintDuplicate(err, node); dupErr != nil {
+			return nil, dupErr
+		}
 		return nil, fmt.Errorf("failed to update node: %v", err)
 	}
 
+	log.RegisterSecret(node.Password)
 	return &node, nil
 }
 
@@ -313,6 +432,24 @@ func (m *SqliteNodeManager) SetScriptManager(scriptManager interface{}) error {
 	return nil
 }
 
+// scriptsFor 节点所属项目的脚本管理器，项目的脚本无法加载时使用默认项目的脚本
+func (m *SqliteNodeManager) scriptsFor(nodeID string) interface{} {
+	sm, ok := m.scriptManager.(*script.ScriptManager)
+	if !ok {
+		return m.scriptManager
+	}
+	n, err := m.GetNode(nodeID)
+	if err != nil {
+		return m.scriptManager
+	}
+	projectScripts, err := sm.ForProject(n.ProjectID)
+	if err != nil {
+		fmt.Printf("加载项目 %s 的脚本失败，使用默认脚本: %v\n", n.ProjectID, err)
+		return m.scriptManager
+	}
+	return projectScripts
+}
+
 // TestConnection 测试节点连接
 func (m *SqliteNodeManager) TestConnection(id string) (bool, error) {
 	m.mutex.RLock()
@@ -326,11 +463,13 @@ func (m *SqliteNodeManager) TestConnection(id string) (bool, error) {
 	// 直接使用节点的IP地址进行连接，避免依赖本地hosts文件
 	// 从数据库中获取的节点信息已经包含了正确的IP地址
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP, // 直接使用IP地址，不依赖本地hosts文件
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP, // 直接使用IP地址，不依赖本地hosts文件
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	fmt.Printf("=== 测试节点 %s 的SSH连接 ===\n", node.Name)
@@ -345,6 +484,7 @@ func (m *SqliteNodeManager) TestConnection(id string) (bool, error) {
 		node.UpdatedAt = time.Now()
 		m.updateNodeStatus(id, node.Status, node.UpdatedAt)
 		m.mutex.Unlock()
+		m.recordConnectionTest(*node, err, "")
 		return false, err
 	}
 	fmt.Printf("✓ SSH客户端创建成功\n")
@@ -353,7 +493,7 @@ func (m *SqliteNodeManager) TestConnection(id string) (bool, error) {
 	// 执行简单命令测试连接
 	fmt.Println("执行测试命令: echo 'hello'")
 	testOutput, err := client.RunCommandWithOutput("echo 'hello'", func(line string) {
-		fmt.Printf("输出: %s\n", line)
+		fmt.Printf("输出: %s\n", log.Redact(line))
 	})
 	if err != nil {
 		fmt.Printf("✗ 命令执行失败: %v\n", err)
@@ -363,52 +503,64 @@ func (m *SqliteNodeManager) TestConnection(id string) (bool, error) {
 		node.UpdatedAt = time.Now()
 		m.updateNodeStatus(id, node.Status, node.UpdatedAt)
 		m.mutex.Unlock()
+		m.recordConnectionTest(*node, err, "")
 		return false, err
 	}
 
-	fmt.Printf("✓ 命令执行成功，输出: %s\n", strings.TrimSpace(testOutput))
+	fmt.Printf("✓ 命令执行成功，输出: %s\n", log.Redact(strings.TrimSpace(testOutput)))
 
-	// 检测操作系统类型
+	// 检测操作系统类型，结果缓存在节点记录中
 	fmt.Println("检测操作系统类型...")
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo "$ID"
-else
-	# 尝试获取其他发行版信息
-	if [ -f /etc/centos-release ]; then
-		echo "centos"
-	elif [ -f /etc/redhat-release ]; then
-		echo "rhel"
-	else
-		echo "unknown"
-	fi
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
 	osType := "unknown"
+	osInfo, err := DetectOS(client)
 	if err == nil {
-		osType = strings.TrimSpace(distroOutput)
+		osType = osInfo.Distro
+		fmt.Printf("✓ 操作系统检测成功: %s %s (%s, %s)\n", osInfo.Distro, osInfo.Version, osInfo.Arch, osInfo.PackageManager)
+	} else {
+		fmt.Printf("✗ 操作系统检测失败: %v\n", err)
 	}
-	fmt.Printf("✓ 操作系统检测成功: %s\n", osType)
 
-	// 更新节点状态为在线并保存操作系统类型
+	// 更新节点状态为在线
 	m.mutex.Lock()
 	node.Status = NodeStatusOnline
-	node.OS = osType
 	node.UpdatedAt = time.Now()
 	m.updateNodeStatus(id, node.Status, node.UpdatedAt)
-	// 更新节点OS字段到数据库
-	_, err = m.db.Exec("UPDATE nodes SET os = ?, updated_at = ? WHERE id = ?", osType, node.UpdatedAt, id)
-	if err != nil {
-		fmt.Printf("✗ 更新节点OS信息到数据库失败: %v\n", err)
-	}
 	m.mutex.Unlock()
+	if err == nil {
+		if err := m.SetNodeOS(id, osInfo); err != nil {
+			fmt.Printf("✗ 更新节点OS信息到数据库失败: %v\n", err)
+		}
+	}
 
 	fmt.Printf("✓ 节点 %s 连接测试成功，状态更新为在线，操作系统: %s\n", node.Name, osType)
+	m.recordConnectionTest(*node, nil, osType)
 	return true, nil
 }
 
+// recordConnectionTest 记录连接测试结果，出现在节点的事件时间线中
+func (m *SqliteNodeManager) recordConnectionTest(n Node, err error, osType string) {
+	if m.logManager == nil {
+		return
+	}
+	status, output := "success", fmt.Sprintf("SSH连接成功，操作系统: %s", osType)
+	if err != nil {
+		status, output = "failed", fmt.Sprintf("SSH连接失败: %v", err)
+	}
+	now := time.Now()
+	m.logManager.CreateLog(log.LogEntry{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Operation: "TestConnection",
+		Command:   fmt.Sprintf("ssh %s@%s:%d", n.Username, n.IP, n.Port),
+		Output:    output,
+		Status:    status,
+		Type:      log.TypeSystem,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
 // DeployNode 部署节点
 func (m *SqliteNodeManager) DeployNode(id string) error {
 	m.mutex.Lock()
@@ -432,11 +584,13 @@ func (m *SqliteNodeManager) DeployNode(id string) error {
 
 	// 执行部署逻辑，使用节点名称连接
 	sshConfig := ssh.SSHConfig{
-		Host:       node.Name,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.Name,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -491,11 +645,13 @@ func (m *SqliteNodeManager) ConfigureSSHSettings(id string) error {
 
 	// 直接使用节点的IP地址进行连接，避免依赖本地hosts文件
 	sshConfig := ssh.SSHConfig{
-		Host:       node.IP, // 直接使用IP地址，不依赖本地hosts文件
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.IP, // 直接使用IP地址，不依赖本地hosts文件
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -575,334 +731,17 @@ func (m *SqliteNodeManager) ConfigureSSHPasswdless() error {
 	if err != nil {
 		return err
 	}
+	_, err = m.configureSSHPasswdless(allNodes, PasswdlessOptions{})
+	return err
+}
 
-	if len(allNodes) < 2 {
-		return fmt.Errorf("at least 2 nodes are required for SSH passwdless configuration")
-	}
-
-	// 定义输出回调函数
-	outputCallback := func(line string) {
-		fmt.Println(line) // 实时打印到控制台
-	}
-
-	fmt.Println("=== 开始配置所有节点之间的SSH免密互通 ===")
-
-	// 1. 确保所有节点都已配置SSH密钥
-	fmt.Println("\n=== 1. 确保所有节点都已配置SSH密钥 ===")
-	for _, node := range allNodes {
-		fmt.Printf("处理节点: %s (%s)\n", node.Name, node.IP)
-		// 直接使用节点的IP地址进行连接，避免依赖本地hosts文件
-		sshConfig := ssh.SSHConfig{
-			Host:       node.IP, // 直接使用IP地址，不依赖本地hosts文件
-			Port:       node.Port,
-			Username:   node.Username,
-			Password:   node.Password,
-			PrivateKey: node.PrivateKey,
-		}
-
-		client, err := ssh.NewSSHClient(sshConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create SSH client for node %s: %v", node.Name, err)
-		}
-
-		// 检查公钥是否存在，不存在则配置SSH设置
-		_, err = client.RunCommand("test -f ~/.ssh/id_rsa.pub")
-		if err != nil {
-			client.Close()
-			fmt.Printf("  节点 %s 公钥不存在，正在配置SSH设置...\n", node.Name)
-			if err := m.ConfigureSSHSettings(node.ID); err != nil {
-				return fmt.Errorf("failed to configure SSH settings for node %s: %v", node.Name, err)
-			}
-			fmt.Printf("  节点 %s SSH设置配置完成\n", node.Name)
-		} else {
-			fmt.Printf("  节点 %s 已存在SSH密钥\n", node.Name)
-		}
-		client.Close()
-	}
-
-	// 2. 收集所有节点的公钥
-	fmt.Println("\n=== 2. 收集所有节点的公钥 ===")
-	nodePublicKeys := make(map[string]string)
-	nodeIPMap := make(map[string]string) // 节点名称到IP的映射
-
-	for _, node := range allNodes {
-		fmt.Printf("获取节点 %s (%s) 的公钥...\n", node.Name, node.IP)
-		nodeIPMap[node.Name] = node.IP
-
-		// 直接使用节点的IP地址进行连接，避免依赖本地hosts文件
-		sshConfig := ssh.SSHConfig{
-			Host:       node.IP, // 直接使用IP地址，不依赖本地hosts文件
-			Port:       node.Port,
-			Username:   node.Username,
-			Password:   node.Password,
-			PrivateKey: node.PrivateKey,
-		}
-
-		client, err := ssh.NewSSHClient(sshConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create SSH client for node %s: %v", node.Name, err)
-		}
-
-		// 获取公钥
-		publicKey, err := client.RunCommand("cat ~/.ssh/id_rsa.pub")
-		client.Close()
-		if err != nil {
-			return fmt.Errorf("failed to get public key for node %s: %v", node.Name, err)
-		}
-
-		nodePublicKeys[node.Name] = strings.TrimSpace(publicKey)
-		fmt.Printf("  成功获取节点 %s 的公钥\n", node.Name)
-	}
-
-	// 3. 配置每个节点的authorized_keys文件和hosts文件
-	fmt.Println("\n=== 3. 配置每个节点的authorized_keys文件和hosts文件 ===")
-	for _, targetNode := range allNodes {
-		fmt.Printf("\n配置节点: %s (%s)\n", targetNode.Name, targetNode.IP)
-		// 直接使用节点的IP地址进行连接，避免依赖本地hosts文件
-		sshConfig := ssh.SSHConfig{
-			Host:       targetNode.IP, // 直接使用IP地址，不依赖本地hosts文件
-			Port:       targetNode.Port,
-			Username:   targetNode.Username,
-			Password:   targetNode.Password,
-			PrivateKey: targetNode.PrivateKey,
-		}
-
-		client, err := ssh.NewSSHClient(sshConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create SSH client for node %s: %v", targetNode.Name, err)
-		}
-
-		// 确保.ssh目录存在并设置正确权限
-		fmt.Printf("  1. 设置.ssh目录权限...\n")
-		permCmd := "mkdir -p ~/.ssh && chmod 755 ~ && chmod 700 ~/.ssh"
-		_, err = client.RunCommandWithOutput(permCmd, outputCallback)
-		if err != nil {
-			client.Close()
-			return fmt.Errorf("failed to set .ssh directory permissions for node %s: %v", targetNode.Name, err)
-		}
-
-		// 2. 更新hosts文件，添加所有节点的名称和IP
-		fmt.Printf("  2. 更新hosts文件，添加所有节点的名称和IP...\n")
-		// 先备份原有hosts文件
-		_, err = client.RunCommand("sudo cp /etc/hosts /etc/hosts.bak")
-		if err != nil {
-			fmt.Printf("    警告: 无法备份hosts文件: %v\n", err)
-		}
-
-		// 构建hosts文件内容，保留原有内容，只添加新的节点条目
-		hostsContent := "# Kubernetes集群节点解析\n"
-		for nodeName, nodeIP := range nodeIPMap {
-			hostsContent += fmt.Sprintf("%s %s\n", nodeIP, nodeName)
-		}
-
-		// 显示构建的hosts文件内容，用于调试
-		fmt.Printf("  构建的hosts文件内容: %s\n", hostsContent)
-
-		// 写入临时文件，然后合并到/etc/hosts
-		tmpHostsFile := "/tmp/k8s_hosts"
-		// 使用printf命令写入临时文件，避免echo命令对特殊字符的处理问题
-		tmpCmd := fmt.Sprintf("printf '%%s' '%s' > %s", hostsContent, tmpHostsFile)
-		fmt.Printf("  执行命令: %s\n", tmpCmd)
-		_, err = client.RunCommand(tmpCmd)
-		if err != nil {
-			client.Close()
-			return fmt.Errorf("failed to write temporary hosts file for node %s: %v", targetNode.Name, err)
-		}
-
-		// 验证临时文件是否成功创建并包含内容
-		verifyCmd := fmt.Sprintf("ls -la %s && cat %s", tmpHostsFile, tmpHostsFile)
-		verifyOutput, err := client.RunCommand(verifyCmd)
-		if err != nil {
-			fmt.Printf("  验证临时hosts文件失败: %v\n", err)
-		} else {
-			fmt.Printf("  临时hosts文件验证结果: %s\n", verifyOutput)
-		}
-
-		// 合并到/etc/hosts，移除已存在的节点条目，添加新的
-		mergeCmd := `
-# 移除已存在的节点条目
-if grep -q "Kubernetes集群节点解析" /etc/hosts; then
-    # 移除从标记开始到文件结束的所有内容
-    sudo sed -i '/Kubernetes集群节点解析/,$d' /etc/hosts
-    if [ $? -eq 0 ]; then
-        echo "移除已存在的节点条目成功"
-    else
-        echo "移除已存在的节点条目失败"
-    fi
-fi
-
-# 添加新的节点条目
-echo "正在添加新的节点条目..."
-sudo bash -c 'cat /tmp/k8s_hosts >> /etc/hosts'
-if [ $? -eq 0 ]; then
-    echo "添加新的节点条目成功"
-else
-    echo "添加新的节点条目失败"
-fi
-
-# 清理临时文件
-rm -f /tmp/k8s_hosts
-if [ $? -eq 0 ]; then
-    echo "清理临时文件成功"
-else
-    echo "清理临时文件失败"
-fi
-
-# 刷新DNS缓存，使hosts文件生效
-if command -v systemctl &> /dev/null; then
-    # 对于使用systemd的系统，重启nscd服务（如果存在）
-    if systemctl list-units --type=service | grep -q nscd; then
-        echo "重启nscd服务，刷新DNS缓存..."
-        sudo systemctl restart nscd
-    fi
-    # 重启systemd-resolved服务（如果存在）
-    if systemctl list-units --type=service | grep -q systemd-resolved; then
-        echo "重启systemd-resolved服务，刷新DNS缓存..."
-        sudo systemctl restart systemd-resolved
-    fi
-else
-    # 对于其他系统，使用nscd命令（如果存在）
-    if command -v nscd &> /dev/null; then
-        echo "刷新nscd缓存..."
-        sudo nscd -i hosts
-    fi
-fi
-
-# 验证主机名解析是否生效
-echo "验证主机名解析..."
-for host in $(cat /etc/hosts | grep -v '^#' | grep -v '^$' | awk '{print $2}'); do
-    if [ "$host" != "localhost" ] && [ "$host" != "localhost.localdomain" ]; then
-        echo "测试解析主机名: $host"
-        ping -c 1 $host > /dev/null 2>&1
-        if [ $? -eq 0 ]; then
-            echo "✓ 主机名 $host 解析成功"
-        else
-            echo "✗ 主机名 $host 解析失败"
-        fi
-    fi
-done
-
-# 显示更新后的hosts文件内容
-echo "=== 更新后的hosts文件内容 ==="
-sudo tail -20 /etc/hosts
-echo "=== 内容结束 ==="
-`
-		_, err = client.RunCommandWithOutput(mergeCmd, outputCallback)
-		if err != nil {
-			client.Close()
-			return fmt.Errorf("failed to update hosts file for node %s: %v", targetNode.Name, err)
-		}
-
-		// 3. 清空并重新创建authorized_keys文件
-		fmt.Printf("  3. 重新创建authorized_keys文件...\n")
-		_, err = client.RunCommandWithOutput("rm -f ~/.ssh/authorized_keys && touch ~/.ssh/authorized_keys", outputCallback)
-		if err != nil {
-			client.Close()
-			return fmt.Errorf("failed to recreate authorized_keys for node %s: %v", targetNode.Name, err)
-		}
-
-		// 4. 添加所有节点的公钥到authorized_keys文件
-		fmt.Printf("  4. 添加所有节点的公钥...\n")
-		for nodeName, publicKey := range nodePublicKeys {
-			fmt.Printf("    添加节点 %s 的公钥...\n", nodeName)
-			// 使用echo命令添加公钥，确保格式正确
-			cmd := fmt.Sprintf("echo '%s' >> ~/.ssh/authorized_keys", publicKey)
-			_, err = client.RunCommand(cmd)
-			if err != nil {
-				client.Close()
-				return fmt.Errorf("failed to add public key for node %s to %s: %v", nodeName, targetNode.Name, err)
-			}
-		}
-
-		// 5. 设置authorized_keys文件权限
-		fmt.Printf("  5. 设置authorized_keys文件权限...\n")
-		_, err = client.RunCommandWithOutput("chmod 600 ~/.ssh/authorized_keys", outputCallback)
-		if err != nil {
-			client.Close()
-			return fmt.Errorf("failed to set authorized_keys permissions for node %s: %v", targetNode.Name, err)
-		}
-
-		// 6. 验证authorized_keys文件内容
-		fmt.Printf("  6. 验证authorized_keys文件...\n")
-		verifyCmd = "echo '=== authorized_keys内容 ===' && wc -l ~/.ssh/authorized_keys && echo '=== 内容结束 ==='"
-		_, err = client.RunCommandWithOutput(verifyCmd, outputCallback)
-		if err != nil {
-			client.Close()
-			return fmt.Errorf("failed to verify authorized_keys content for node %s: %v", targetNode.Name, err)
-		}
-
-		fmt.Printf("  ✓ 节点 %s 配置完成\n", targetNode.Name)
-		client.Close()
-	}
-
-	// 4. 测试节点之间的免密连接
-	fmt.Println("\n=== 4. 测试节点之间的免密连接 ===")
-	testSuccessCount := 0
-	testTotalCount := 0
-
-	for i, sourceNode := range allNodes {
-		for j, targetNode := range allNodes {
-			// 跳过自己
-			if i == j {
-				continue
-			}
-
-			testTotalCount++
-			fmt.Printf("\n测试从 %s 到 %s 的免密连接...\n", sourceNode.Name, targetNode.Name)
-
-			// 直接使用节点的IP地址进行连接，避免依赖本地hosts文件
-			sshConfig := ssh.SSHConfig{
-				Host:       sourceNode.IP, // 直接使用IP地址，不依赖本地hosts文件
-				Port:       sourceNode.Port,
-				Username:   sourceNode.Username,
-				Password:   sourceNode.Password,
-				PrivateKey: sourceNode.PrivateKey,
-			}
-
-			client, err := ssh.NewSSHClient(sshConfig)
-			if err != nil {
-				fmt.Printf("  ✗ 创建SSH客户端失败: %v\n", err)
-				continue
-			}
-
-			// 测试免密连接，使用简单的测试命令，使用节点名称而不是IP地址
-			testCmd := fmt.Sprintf(
-				"ssh -o StrictHostKeyChecking=no -o ConnectTimeout=5 %s@%s 'echo success'",
-				targetNode.Username, targetNode.Name,
-			)
-
-			output, err := client.RunCommand(testCmd)
-			client.Close()
-
-			if err != nil {
-				fmt.Printf("  ✗ 免密连接测试失败\n")
-				fmt.Printf("    错误: %v\n", err)
-			} else {
-				if strings.TrimSpace(output) == "success" {
-					fmt.Printf("  ✓ 免密连接测试成功\n")
-					testSuccessCount++
-				} else {
-					fmt.Printf("  ✗ 免密连接测试失败，输出不符合预期: %s\n", output)
-				}
-			}
-		}
-	}
-
-	// 5. 输出测试结果
-	fmt.Println("\n=== 5. SSH免密互通配置结果 ===")
-	fmt.Printf("测试总数: %d\n", testTotalCount)
-	fmt.Printf("成功数量: %d\n", testSuccessCount)
-	fmt.Printf("失败数量: %d\n", testTotalCount-testSuccessCount)
-
-	if testSuccessCount == testTotalCount {
-		fmt.Println("\n✓ 所有节点之间的SSH免密互通配置成功！")
-	} else {
-		fmt.Printf("\n⚠️  部分节点之间的免密连接测试失败，成功率: %.2f%%\n", float64(testSuccessCount)/float64(testTotalCount)*100)
-		fmt.Println("建议检查失败节点的网络连接、SSH配置和公钥配置")
+// ConfigureProjectSSHPasswdless 配置项目内所有节点之间的SSH免密互通，不涉及其他项目的节点
+func (m *SqliteNodeManager) ConfigureProjectSSHPasswdless(projectID string, opts PasswdlessOptions) (*PasswdlessResult, error) {
+	nodes, err := m.InProject(projectID).GetNodes()
+	if err != nil {
+		return nil, err
 	}
-
-	fmt.Println("\n=== 所有节点之间的SSH免密互通配置完成 ===")
-	return nil
+	return m.configureSSHPasswdless(nodes, opts)
 }
 
 // 辅助方法：更新节点状态
@@ -946,103 +785,39 @@ func formatRegMirrors(mirrors []string) string {
 	return result
 }
 
+// cachedNodeOS 返回节点缓存的操作系统信息，见NodeOS
+func (m *SqliteNodeManager) cachedNodeOS(client ssh.Runner, id string) (OSInfo, error) {
+	n, err := m.GetNode(id)
+	if err != nil {
+		return OSInfo{}, err
+	}
+	return m.NodeOS(client, *n)
+}
+
 // deployMasterNode 部署主节点
-func (m *SqliteNodeManager) deployMasterNode(client *ssh.SSHClient, nodeID, nodeName string) error {
+func (m *SqliteNodeManager) deployMasterNode(client ssh.Runner, nodeID, nodeName string) error {
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := m.cachedNodeOS(client, nodeID)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
-
-	// 2. 从脚本管理器获取系统准备脚本
-	var systemPrepCmd string
-	var systemPrepFound bool
-	if m.scriptManager != nil {
-		if scriptGetter, ok := m.scriptManager.(interface {
-			GetScript(name string) (string, bool)
-		}); ok {
-			// 尝试获取特定发行版的系统准备脚本，使用与前端一致的命名格式
-			stepName := strings.ReplaceAll(strings.ToLower("系统准备"), " ", "_")
-			systemPrepScriptName := fmt.Sprintf("%s_%s", distro, stepName)
-			if script, scriptFound := scriptGetter.GetScript(systemPrepScriptName); scriptFound {
-				systemPrepCmd = script
-				systemPrepFound = true
-				fmt.Printf("Using custom system prep script for %s\n", distro)
-			} else {
-				// 尝试获取通用系统准备脚本
-				if script, scriptFound := scriptGetter.GetScript("system_prep"); scriptFound {
-					systemPrepCmd = script
-					systemPrepFound = true
-					fmt.Printf("Using custom system prep script\n")
-				}
-			}
-		}
-	}
+	distro := osInfo.Distro
 
-	// 执行系统准备脚本（无论是否是自定义脚本）
+	// 2. 通过脚本管理器解析系统准备脚本，没有自定义脚本时使用默认模板
+	systemPrepCmd, err := systemPrepScript(m.scriptsFor(nodeID), distro)
+	if err != nil {
+		return err
+	}
 	fmt.Println("=== 执行系统准备脚本 ===")
-	if systemPrepFound {
-		systemPrepOutput, err := client.RunCommandWithOutput(systemPrepCmd, func(line string) {
-			fmt.Println(line) // 实时打印到控制台
-		})
-		if err != nil {
-			fmt.Printf("系统准备脚本执行出现错误: %v\n输出: %s\n", err, systemPrepOutput)
-			fmt.Println("警告: 系统准备脚本执行失败，但将继续尝试IP转发配置...")
-			// 不返回错误，继续执行IP转发配置
-		} else {
-			fmt.Println("系统准备脚本执行成功")
-		}
+	systemPrepOutput, err := client.RunCommandWithOutput(systemPrepCmd, func(line string) {
+		fmt.Println(line) // 实时打印到控制台
+	})
+	if err != nil {
+		fmt.Printf("系统准备脚本执行出现错误: %v\n输出: %s\n", err, log.Redact(systemPrepOutput))
+		fmt.Println("警告: 系统准备脚本执行失败，但将继续尝试IP转发配置...")
+		// 不返回错误，继续执行IP转发配置
 	} else {
-		// 3. 禁用swap
-		fmt.Println("\n=== 执行禁用swap操作 ===")
-		swapCmd := `
-sudo swapoff -a
-sudo sed -i '/ swap / s/^\(.*\)$/#\1/g' /etc/fstab
-`
-		swapOutput, err := client.RunCommandWithOutput(swapCmd, func(line string) {
-			fmt.Println(line) // 实时打印到控制台
-		})
-		if err != nil {
-			fmt.Printf("禁用swap操作失败: %v\n输出: %s\n", err, swapOutput)
-			fmt.Println("警告: 禁用swap操作失败，但将继续执行...")
-			// 不返回错误，继续执行
-		} else {
-			fmt.Println("禁用swap操作成功")
-		}
-
-		// 4. 设置内核参数（生产环境推荐配置）
-		fmt.Println("\n=== 执行内核参数配置 ===")
-		kernelCmd := `
-cat <<EOF | sudo tee /etc/sysctl.d/k8s.conf
-net.bridge.bridge-nf-call-iptables  = 1
-net.bridge.bridge-nf-call-ip6tables = 1
-net.ipv4.ip_forward                 = 1
-vm.swappiness                       = 0
-vm.overcommit_memory                = 1
-kernel.panic                        = 10
-kernel.panic_on_oops                = 1
-EOF
-sudo sysctl --system
-sudo modprobe br_netfilter
-sudo modprobe overlay
-`
-		kernelOutput, err := client.RunCommandWithOutput(kernelCmd, func(line string) {
-			fmt.Println(line) // 实时打印到控制台
-		})
-		if err != nil {
-			fmt.Printf("内核参数配置失败: %v\n输出: %s\n", err, kernelOutput)
-			fmt.Println("警告: 内核参数配置失败，但将继续执行...")
-			// 不返回错误，继续执行
-		} else {
-			fmt.Println("内核参数配置成功")
-		}
+		fmt.Println("系统准备脚本执行成功")
 	}
 
 	// 添加延迟，确保系统准备完全执行
@@ -1172,7 +947,7 @@ cat /proc/sys/net/ipv4/ip_forward
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("IP转发配置脚本执行出现错误: %v\n输出: %s\n", err, ensureIpForwardOutput)
+		fmt.Printf("IP转发配置脚本执行出现错误: %v\n输出: %s\n", err, log.Redact(ensureIpForwardOutput))
 		fmt.Println("警告: IP转发配置脚本执行失败，但将继续执行...")
 		// 不返回错误，继续执行
 	} else {
@@ -1200,7 +975,7 @@ cat /proc/sys/net/ipv4/ip_forward
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("最终IP转发验证失败: %v\n输出: %s\n", err, finalCheckOutput)
+		fmt.Printf("最终IP转发验证失败: %v\n输出: %s\n", err, log.Redact(finalCheckOutput))
 		// 不返回错误，继续执行
 	} else {
 		fmt.Println("最终IP转发验证完成")
@@ -1208,12 +983,12 @@ cat /proc/sys/net/ipv4/ip_forward
 
 	// 5. 设置容器运行时（默认使用containerd，生产环境推荐）
 	containerRuntime := "containerd"
-	if err := m.installContainerRuntime(client, distro, containerRuntime); err != nil {
+	if err := m.installContainerRuntime(client, nodeID, distro, containerRuntime); err != nil {
 		return err
 	}
 
 	// 6. 安装kubeadm, kubelet和kubectl
-	if err := m.installKubernetesComponents(client, distro); err != nil {
+	if err := m.installKubernetesComponents(client, nodeID, distro, ""); err != nil {
 		return err
 	}
 
@@ -1221,7 +996,7 @@ cat /proc/sys/net/ipv4/ip_forward
 }
 
 // deployWorkerNode 部署工作节点
-func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, nodeName string) error {
+func (m *SqliteNodeManager) deployWorkerNode(client ssh.Runner, nodeID, nodeName string) error {
 	// 部署流程：
 	// 1. 环境检查 → 2. 操作系统检测 → 3. 系统准备 → 4. IP转发配置 → 5. 容器运行时安装 → 6. Kubernetes组件安装 → 7. 部署完成验证
 
@@ -1234,6 +1009,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "部署开始",
 			Output:    "开始执行Kubernetes工作节点部署流程",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -1254,6 +1030,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "环境检查",
 			Output:    "执行部署前环境检查",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -1307,7 +1084,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("环境检查执行出现错误: %v\n输出: %s\n", err, envCheckOutput)
+		fmt.Printf("环境检查执行出现错误: %v\n输出: %s\n", err, log.Redact(envCheckOutput))
 		fmt.Println("警告: 环境检查执行失败，但将继续执行部署...")
 	} else {
 		fmt.Println("环境检查完成")
@@ -1324,34 +1101,17 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "操作系统检测",
 			Output:    "检测操作系统类型",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 		m.logManager.CreateLog(stepLog)
 	}
-	distroCmd := `
-	if [ -f /etc/os-release ]; then
-		. /etc/os-release
-		echo $ID
-	elif [ -f /etc/centos-release ]; then
-		echo "centos"
-	elif [ -f /etc/redhat-release ]; then
-		echo "rhel"
-	elif [ -f /etc/rocky-release ]; then
-		echo "rocky"
-	elif [ -f /etc/almalinux-release ]; then
-		echo "almalinux"
-	elif [ -f /etc/debian_version ]; then
-		echo "debian"
-	else
-		echo "unknown"
-	fi
-	`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := m.cachedNodeOS(client, nodeID)
 	if err != nil {
 		return fmt.Errorf("检测操作系统类型失败: %v", err)
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
 	if distro == "unknown" {
 		return fmt.Errorf("无法识别的操作系统类型，不支持部署Kubernetes工作节点")
@@ -1370,94 +1130,26 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "系统准备",
 			Output:    "执行系统准备操作",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 		m.logManager.CreateLog(stepLog)
 	}
 
-	// 从脚本管理器获取系统准备脚本
-	var systemPrepCmd string
-	var systemPrepFound bool
-	if m.scriptManager != nil {
-		if scriptGetter, ok := m.scriptManager.(interface {
-			GetScript(name string) (string, bool)
-		}); ok {
-			// 尝试获取特定发行版的系统准备脚本，使用与前端一致的命名格式
-			stepName := strings.ReplaceAll(strings.ToLower("系统准备"), " ", "_")
-			systemPrepScriptName := fmt.Sprintf("%s_%s", distro, stepName)
-			if script, scriptFound := scriptGetter.GetScript(systemPrepScriptName); scriptFound {
-				systemPrepCmd = script
-				systemPrepFound = true
-				fmt.Printf("使用自定义系统准备脚本: %s\n", systemPrepScriptName)
-			} else {
-				// 尝试获取通用系统准备脚本
-				if script, scriptFound := scriptGetter.GetScript("system_prep"); scriptFound {
-					systemPrepCmd = script
-					systemPrepFound = true
-					fmt.Println("使用通用系统准备脚本")
-				}
-			}
-		}
+	// 通过脚本管理器解析系统准备脚本，没有自定义脚本时使用默认模板
+	systemPrepCmd, err := systemPrepScript(m.scriptsFor(nodeID), distro)
+	if err != nil {
+		return err
 	}
-
-	// 执行系统准备脚本（无论是否是自定义脚本）
-	if systemPrepFound {
-		systemPrepOutput, err := client.RunCommandWithOutput(systemPrepCmd, func(line string) {
-			fmt.Println(line) // 实时打印到控制台
-		})
-		if err != nil {
-			fmt.Printf("自定义系统准备脚本执行失败: %v\n输出: %s\n", err, systemPrepOutput)
-			return fmt.Errorf("系统准备失败: %v", err)
-		} else {
-			fmt.Println("系统准备脚本执行成功")
-		}
-	} else {
-		// 执行默认系统准备操作
-		fmt.Println("使用默认系统准备操作")
-
-		// 3.1 禁用swap
-		fmt.Println("\n3.1 执行禁用swap操作...")
-		swapCmd := `
-		sudo swapoff -a
-		sudo sed -i '/ swap / s/^\(.*\)$/#\1/g' /etc/fstab
-		`
-		swapOutput, err := client.RunCommandWithOutput(swapCmd, func(line string) {
-			fmt.Println(line) // 实时打印到控制台
-		})
-		if err != nil {
-			fmt.Printf("禁用swap操作失败: %v\n输出: %s\n", err, swapOutput)
-			return fmt.Errorf("禁用swap失败: %v", err)
-		} else {
-			fmt.Println("禁用swap操作成功")
-		}
-
-		// 3.2 设置内核参数
-		fmt.Println("\n3.2 执行内核参数配置...")
-		kernelCmd := `
-		cat <<EOF | sudo tee /etc/sysctl.d/k8s.conf
-		net.bridge.bridge-nf-call-iptables  = 1
-		net.bridge.bridge-nf-call-ip6tables = 1
-		net.ipv4.ip_forward                 = 1
-		vm.swappiness                       = 0
-		vm.overcommit_memory                = 1
-		kernel.panic                        = 10
-		kernel.panic_on_oops                = 1
-		EOF
-		sudo sysctl --system
-		sudo modprobe br_netfilter
-		sudo modprobe overlay
-		`
-		kernelOutput, err := client.RunCommandWithOutput(kernelCmd, func(line string) {
-			fmt.Println(line) // 实时打印到控制台
-		})
-		if err != nil {
-			fmt.Printf("内核参数配置失败: %v\n输出: %s\n", err, kernelOutput)
-			return fmt.Errorf("内核参数配置失败: %v", err)
-		} else {
-			fmt.Println("内核参数配置成功")
-		}
+	systemPrepOutput, err := client.RunCommandWithOutput(systemPrepCmd, func(line string) {
+		fmt.Println(line) // 实时打印到控制台
+	})
+	if err != nil {
+		fmt.Printf("系统准备脚本执行失败: %v\n输出: %s\n", err, log.Redact(systemPrepOutput))
+		return fmt.Errorf("系统准备失败: %v", err)
 	}
+	fmt.Println("系统准备脚本执行成功")
 
 	// 3.3 验证系统准备结果
 	fmt.Println("\n3.3 验证系统准备结果...")
@@ -1487,7 +1179,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("系统准备结果验证失败: %v\n输出: %s\n", err, sysPrepVerifyOutput)
+		fmt.Printf("系统准备结果验证失败: %v\n输出: %s\n", err, log.Redact(sysPrepVerifyOutput))
 		return fmt.Errorf("系统准备验证失败: %v", err)
 	} else {
 		fmt.Println("系统准备结果验证成功")
@@ -1510,6 +1202,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "IP转发配置",
 			Output:    "配置IP转发设置",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -1572,7 +1265,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("IP转发配置脚本执行失败: %v\n输出: %s\n", err, ensureIpForwardOutput)
+		fmt.Printf("IP转发配置脚本执行失败: %v\n输出: %s\n", err, log.Redact(ensureIpForwardOutput))
 		return fmt.Errorf("IP转发配置失败: %v", err)
 	} else {
 		fmt.Println("IP转发配置脚本执行成功")
@@ -1608,7 +1301,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("IP转发最终验证失败: %v\n输出: %s\n", err, finalCheckOutput)
+		fmt.Printf("IP转发最终验证失败: %v\n输出: %s\n", err, log.Redact(finalCheckOutput))
 		return fmt.Errorf("IP转发验证失败: %v", err)
 	} else {
 		fmt.Println("IP转发最终验证成功")
@@ -1625,13 +1318,14 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "容器运行时安装",
 			Output:    "安装containerd容器运行时",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 		m.logManager.CreateLog(stepLog)
 	}
 	containerRuntime := "containerd"
-	if err := m.installContainerRuntime(client, distro, containerRuntime); err != nil {
+	if err := m.installContainerRuntime(client, nodeID, distro, containerRuntime); err != nil {
 		if m.logManager != nil {
 			failLog := log.LogEntry{
 				NodeID:    nodeID,
@@ -1640,6 +1334,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 				Command:   "容器运行时安装",
 				Output:    fmt.Sprintf("容器运行时安装失败: %v", err),
 				Status:    "failed",
+				Type:      log.TypeStep,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -1655,6 +1350,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "容器运行时安装",
 			Output:    "containerd容器运行时安装成功",
 			Status:    "success",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -1672,12 +1368,13 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "Kubernetes组件安装",
 			Output:    "安装kubeadm、kubelet和kubectl",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 		m.logManager.CreateLog(stepLog)
 	}
-	if err := m.installKubernetesComponents(client, distro); err != nil {
+	if err := m.installKubernetesComponents(client, nodeID, distro, ""); err != nil {
 		if m.logManager != nil {
 			failLog := log.LogEntry{
 				NodeID:    nodeID,
@@ -1686,6 +1383,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 				Command:   "Kubernetes组件安装",
 				Output:    fmt.Sprintf("Kubernetes组件安装失败: %v", err),
 				Status:    "failed",
+				Type:      log.TypeStep,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -1701,6 +1399,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "Kubernetes组件安装",
 			Output:    "Kubernetes组件安装成功",
 			Status:    "success",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -1718,6 +1417,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 			Command:   "部署完成验证",
 			Output:    "验证部署完成情况",
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -1786,7 +1486,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("部署完成验证失败: %v\n输出: %s\n", err, finalVerifyOutput)
+		fmt.Printf("部署完成验证失败: %v\n输出: %s\n", err, log.Redact(finalVerifyOutput))
 		if m.logManager != nil {
 			failLog := log.LogEntry{
 				NodeID:    nodeID,
@@ -1795,6 +1495,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 				Command:   "部署完成验证",
 				Output:    fmt.Sprintf("部署完成验证失败: %v", err),
 				Status:    "failed",
+				Type:      log.TypeStep,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -1807,6 +1508,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 				Command:   "部署结束",
 				Output:    "Kubernetes工作节点部署失败",
 				Status:    "failed",
+				Type:      log.TypeStep,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -1825,6 +1527,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 				Command:   "部署完成验证",
 				Output:    "部署完成验证成功",
 				Status:    "success",
+				Type:      log.TypeStep,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -1837,6 +1540,7 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 				Command:   "部署结束",
 				Output:    "Kubernetes工作节点部署成功",
 				Status:    "success",
+				Type:      log.TypeStep,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -1848,62 +1552,19 @@ func (m *SqliteNodeManager) deployWorkerNode(client *ssh.SSHClient, nodeID, node
 }
 
 // installContainerRuntime 安装容器运行时
-func (m *SqliteNodeManager) installContainerRuntime(client *ssh.SSHClient, distro, runtime string) error {
+func (m *SqliteNodeManager) installContainerRuntime(client ssh.Runner, nodeID, distro, runtime string) error {
 	var cmd string
-	var found bool
-
-	// 从脚本管理器获取容器运行时安装脚本，使用与前端一致的命名格式
-	if m.scriptManager != nil {
-		if scriptGetter, ok := m.scriptManager.(interface {
-			GetScript(name string) (string, bool)
-		}); ok {
-			// 使用与前端完全一致的脚本命名格式: ${system}_${step.name.toLowerCase().replace(/\s+/g, '_')}
-			// 步骤名称："安装容器运行时"
-			stepName := strings.ReplaceAll(strings.ToLower("安装容器运行时"), " ", "_")
-			runtimeScriptName := fmt.Sprintf("%s_%s", distro, stepName)
-			if script, scriptFound := scriptGetter.GetScript(runtimeScriptName); scriptFound {
-				cmd = script
-				found = true
-				fmt.Printf("Using custom script for container runtime installation on %s: %s\n", distro, runtimeScriptName)
-			} else {
-				// 尝试旧格式的脚本名作为备选
-				runtimeScriptNameOld := fmt.Sprintf("%s_%s", runtime, distro)
-				if script, scriptFound := scriptGetter.GetScript(runtimeScriptNameOld); scriptFound {
-					cmd = script
-					found = true
-					fmt.Printf("Using old format custom script for container runtime installation: %s\n", runtimeScriptNameOld)
-				} else {
-					// 尝试获取特定运行时的通用脚本
-					if script, scriptFound := scriptGetter.GetScript(runtime); scriptFound {
-						cmd = script
-						found = true
-						fmt.Printf("Using custom script for container runtime installation: %v\n", runtime)
-					}
-				}
-			}
+	if runtime == "containerd" {
+		// containerd安装和配置脚本通过脚本管理器解析，没有自定义脚本时使用默认模板
+		containerdCmd, err := containerdScript(m.scriptsFor(nodeID), distro)
+		if err != nil {
+			return err
 		}
-	}
-
-	// 如果没有找到自定义脚本，使用默认命令
-	if !found {
-		switch distro {
-		case "ubuntu", "debian":
-			if runtime == "containerd" {
-				cmd = `
-				apt-get update && apt-get install -y apt-transport-https ca-certificates curl gnupg lsb-release
-				mkdir -p /etc/apt/keyrings
-				curl -fsSL https://download.docker.com/linux/ubuntu/gpg | gpg --dearmor -o /etc/apt/keyrings/docker.gpg
-				echo "deb [arch=$(dpkg --print-architecture) signed-by=/etc/apt/keyrings/docker.gpg] https://download.docker.com/linux/ubuntu $(lsb_release -cs) stable" | tee /etc/apt/sources.list.d/docker.list > /dev/null
-				apt-get update
-				apt-get install -y containerd.io
-				mkdir -p /etc/containerd
-				containerd config default | tee /etc/containerd/config.toml
-				sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
-				systemctl restart containerd
-				systemctl enable containerd
-				`
-			} else if runtime == "docker" {
-				cmd = `
+		cmd = containerdCmd
+	} else if runtime == "docker" {
+		switch DistroFamily(distro, "") {
+		case DistroFamilyDebian:
+			cmd = `
 				apt-get update && apt-get install -y apt-transport-https ca-certificates curl gnupg lsb-release
 				mkdir -p /etc/apt/keyrings
 				curl -fsSL https://download.docker.com/linux/ubuntu/gpg | gpg --dearmor -o /etc/apt/keyrings/docker.gpg
@@ -1913,31 +1574,31 @@ func (m *SqliteNodeManager) installContainerRuntime(client *ssh.SSHClient, distr
 				systemctl restart docker
 				systemctl enable docker
 				`
-			}
-		case "centos", "rhel", "rocky", "almalinux":
-			if runtime == "containerd" {
-				cmd = `
-				yum install -y yum-utils
-				yum-config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo
-				yum install -y containerd.io
-				mkdir -p /etc/containerd
-				containerd config default | tee /etc/containerd/config.toml
-				sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
-				systemctl restart containerd
-				systemctl enable containerd
-				`
-			} else if runtime == "docker" {
-				cmd = `
+		case DistroFamilyRHEL:
+			cmd = `
 				yum install -y yum-utils
 				yum-config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo
 				yum install -y docker-ce docker-ce-cli containerd.io
 				systemctl restart docker
 				systemctl enable docker
 				`
+		case DistroFamilyAmazon, DistroFamilySUSE:
+			// Amazon Linux和openSUSE/SLES使用发行版仓库提供的docker
+			pkgMgr := "if command -v dnf &> /dev/null; then dnf install -y $PKGS; else yum install -y $PKGS; fi"
+			if DistroFamily(distro, "") == DistroFamilySUSE {
+				pkgMgr = "zypper --non-interactive install -y $PKGS"
 			}
+			cmd = `
+				PKGS=docker
+				` + pkgMgr + `
+				systemctl restart docker
+				systemctl enable docker
+				`
 		default:
 			return fmt.Errorf("unsupported distribution: %s", distro)
 		}
+	} else {
+		return fmt.Errorf("unsupported container runtime: %s", runtime)
 	}
 
 	if _, err := client.RunCommand(cmd); err != nil {
@@ -1969,7 +1630,7 @@ func (m *SqliteNodeManager) installContainerRuntime(client *ssh.SSHClient, distr
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("容器运行时验证失败: %v\n输出: %s\n", err, verifyOutput)
+		fmt.Printf("容器运行时验证失败: %v\n输出: %s\n", err, log.Redact(verifyOutput))
 		return fmt.Errorf("容器运行时验证失败: %v", err)
 	}
 
@@ -1992,11 +1653,13 @@ func (m *SqliteNodeManager) InstallKubernetesComponents(id string, kubeadmVersio
 
 	// 执行安装逻辑，首先尝试使用节点名称连接
 	sshConfig := ssh.SSHConfig{
-		Host:       node.Name,
-		Port:       node.Port,
-		Username:   node.Username,
-		Password:   node.Password,
-		PrivateKey: node.PrivateKey,
+		Host:          node.Name,
+		Port:          node.Port,
+		Username:      node.Username,
+		Password:      node.Password,
+		PrivateKey:    node.PrivateKey,
+		PasswordRef:   node.PasswordRef,
+		PrivateKeyRef: node.PrivateKeyRef,
 	}
 
 	client, err := ssh.NewSSHClient(sshConfig)
@@ -2011,162 +1674,26 @@ func (m *SqliteNodeManager) InstallKubernetesComponents(id string, kubeadmVersio
 	defer client.Close()
 
 	// 1. 检测操作系统类型
-	distroCmd := `
-if [ -f /etc/os-release ]; then
-	. /etc/os-release
-	echo $ID
-fi
-`
-	distroOutput, err := client.RunCommand(distroCmd)
+	osInfo, err := m.cachedNodeOS(client, node.ID)
 	if err != nil {
 		return err
 	}
-	distro := strings.TrimSpace(distroOutput)
+	distro := osInfo.Distro
 
 	// 调用私有的安装方法
-	return m.installKubernetesComponents(client, distro)
+	return m.installKubernetesComponents(client, node.ID, distro, kubeadmVersion)
 }
 
-// installKubernetesComponents 安装Kubernetes组件（私有辅助方法）
-func (m *SqliteNodeManager) installKubernetesComponents(client *ssh.SSHClient, distro string) error {
-	var addRepoCmd string
-	var installComponentsCmd string
-	var found bool
-
-	// 从脚本管理器获取脚本
-	if m.scriptManager != nil {
-		if scriptGetter, ok := m.scriptManager.(interface {
-			GetScript(name string) (string, bool)
-		}); ok {
-			// 1. 先添加Kubernetes仓库
-			// 使用与前端完全一致的脚本命名格式: ${system}_${step.name.toLowerCase().replace(/\s+/g, '_')}
-			// 步骤名称："添加Kubernetes仓库"
-			addRepoStepName := "添加kubernetes仓库"
-			// 将步骤名称转换为小写并替换所有空格为下划线（与前端保持一致）
-			formattedAddRepoStepName := strings.ReplaceAll(strings.ToLower(addRepoStepName), " ", "_")
-			addRepoScriptName := fmt.Sprintf("%s_%s", distro, formattedAddRepoStepName)
-			if script, scriptFound := scriptGetter.GetScript(addRepoScriptName); scriptFound {
-				addRepoCmd = script
-				fmt.Printf("Using custom script for adding Kubernetes repository on %s: %s\n", distro, addRepoScriptName)
-			} else {
-				// 尝试旧格式的脚本名作为备选
-				oldAddRepoScriptName := fmt.Sprintf("%s_添加kubernetes仓库", distro)
-				if script, scriptFound := scriptGetter.GetScript(oldAddRepoScriptName); scriptFound {
-					addRepoCmd = script
-					fmt.Printf("Using old format custom script for adding Kubernetes repository: %s\n", oldAddRepoScriptName)
-				} else {
-					fmt.Printf("No custom script found for adding Kubernetes repository on %s\n", distro)
-				}
-			}
-
-			// 2. 然后安装Kubernetes组件
-			// 使用与前端完全一致的脚本命名格式: ${system}_${step.name.toLowerCase().replace(/\s+/g, '_')}
-			// 步骤名称："安装Kubernetes组件"
-			installComponentsStepName := "安装kubernetes组件"
-			// 将步骤名称转换为小写并替换所有空格为下划线（与前端保持一致）
-			formattedInstallComponentsStepName := strings.ReplaceAll(strings.ToLower(installComponentsStepName), " ", "_")
-			installComponentsScriptName := fmt.Sprintf("%s_%s", distro, formattedInstallComponentsStepName)
-			if script, scriptFound := scriptGetter.GetScript(installComponentsScriptName); scriptFound {
-				installComponentsCmd = script
-				found = true
-				fmt.Printf("Using custom script for Kubernetes components installation on %s: %s\n", distro, installComponentsScriptName)
-			} else {
-				// 尝试旧格式的脚本名作为备选
-				oldInstallComponentsScriptName := fmt.Sprintf("%s_安装kubernetes组件", distro)
-				if script, scriptFound := scriptGetter.GetScript(oldInstallComponentsScriptName); scriptFound {
-					installComponentsCmd = script
-					found = true
-					fmt.Printf("Using old format custom script for Kubernetes components installation: %s\n", oldInstallComponentsScriptName)
-				} else {
-					fmt.Printf("No custom script found for Kubernetes components installation on %s\n", distro)
-				}
-			}
-		}
-	}
-
-	// 合并命令
-	fullCmd := ""
-	if addRepoCmd != "" {
-		fullCmd += addRepoCmd + "\n"
-	}
-
-	// 如果没有找到自定义安装组件脚本，使用默认命令
-	if !found {
-		switch distro {
-		case "ubuntu", "debian":
-			if addRepoCmd == "" {
-				// 没有自定义添加仓库脚本，使用默认添加仓库命令
-				fullCmd += `
-				apt-get update
-				apt-get install -y apt-transport-https ca-certificates curl gpg
-				
-				// 创建keyring目录
-				mkdir -p -m 755 /etc/apt/keyrings
-				
-				// 下载并安装GPG密钥
-				curl -fsSL -L https://pkgs.k8s.io/core:/stable:/v1.30/deb/Release.key | gpg --dearmor -o /etc/apt/keyrings/kubernetes-apt-keyring.gpg
-				
-				// 添加Kubernetes仓库
-				echo "deb [signed-by=/etc/apt/keyrings/kubernetes-apt-keyring.gpg] https://pkgs.k8s.io/core:/stable:/v1.30/deb/ /" | tee /etc/apt/sources.list.d/kubernetes.list
-				
-				// 更新仓库缓存
-				apt-get update
-				`
-			}
-			// 使用默认安装组件命令
-			fullCmd += `
-			apt-get install -y kubelet kubeadm kubectl
-			systemctl enable --now kubelet
-			`
-		case "centos", "rhel", "rocky", "almalinux":
-			if addRepoCmd == "" {
-				// 没有自定义添加仓库脚本，使用默认添加仓库命令
-				fullCmd += `
-				// 清理旧的Kubernetes仓库配置
-			rm -f /etc/yum.repos.d/kubernetes.repo
-		rm -f /etc/yum.repos.d/packages.cloud.google.com_yum_repos_kubernetes-el7-x86_64.repo
-				
-				// 添加Kubernetes仓库
-				cat <<EOF > /etc/yum.repos.d/kubernetes.repo
-				[kubernetes]
-				name=Kubernetes
-				baseurl=https://pkgs.k8s.io/core:/stable:/v1.30/rpm/
-				enabled=1
-				gpgcheck=1
-				gpgkey=https://pkgs.k8s.io/core:/stable:/v1.30/rpm/repodata/repomd.xml.key
-				exclude=kubelet kubeadm kubectl
-				EOF
-				
-				// 更新仓库缓存
-				if command -v dnf &> /dev/null; then
-					dnf makecache -y
-				else
-					yum makecache -y
-				fi
-				`
-			}
-			// 使用默认安装组件命令
-			fullCmd += `
-			// 安装Kubernetes组件
-			if command -v dnf &> /dev/null; then
-				dnf install -y kubelet kubeadm kubectl --disableexcludes=kubernetes
-			else
-				yum install -y kubelet kubeadm kubectl --disableexcludes=kubernetes
-			fi
-			
-			// 启动kubelet
-			systemctl enable --now kubelet
-			`
-		default:
-			return fmt.Errorf("unsupported distribution: %s", distro)
-		}
-	} else {
-		// 使用自定义安装组件脚本
-		fullCmd += installComponentsCmd
+// installKubernetesComponents 安装Kubernetes组件并固定版本（私有辅助方法），kubeVersion为空时使用DefaultKubeRepoVersion的仓库
+func (m *SqliteNodeManager) installKubernetesComponents(client ssh.Runner, nodeID, distro, kubeVersion string) error {
+	// 添加仓库和安装组件的脚本通过节点所属项目的脚本管理器解析，没有自定义脚本时使用发行版家族的默认模板
+	cmd, err := kubeComponentsScript(m.scriptsFor(nodeID), distro, kubeVersion)
+	if err != nil {
+		return err
 	}
 
 	// 执行完整的Kubernetes组件安装命令并实时输出
-	_, err := client.RunCommandWithOutput(fullCmd, func(line string) {
+	_, err = client.RunCommandWithOutput(cmd, func(line string) {
 		// 实时打印到控制台，便于调试和监控
 		fmt.Println(line)
 	})
@@ -2219,7 +1746,7 @@ func (m *SqliteNodeManager) installKubernetesComponents(client *ssh.SSHClient, d
 		fmt.Println(line) // 实时打印到控制台
 	})
 	if err != nil {
-		fmt.Printf("Kubernetes组件验证失败: %v\n输出: %s\n", err, k8sVerifyOutput)
+		fmt.Printf("Kubernetes组件验证失败: %v\n输出: %s\n", err, log.Redact(k8sVerifyOutput))
 		return fmt.Errorf("Kubernetes组件验证失败: %v", err)
 	}
 
@@ -2236,12 +1763,32 @@ func (m *SqliteNodeManager) GetLogsByNode(nodeID string) ([]log.LogEntry, error)
 	return m.logManager.GetLogsByNode(nodeID)
 }
 
+// ExportLogs 按条件逐条遍历日志
+func (m *SqliteNodeManager) ExportLogs(filter log.LogFilter, fn func(log.LogEntry) error) error {
+	return m.logManager.ExportLogs(filter, fn)
+}
+
 // ClearLogs 清除所有日志
 func (m *SqliteNodeManager) ClearLogs() error {
 	return m.logManager.ClearLogs()
 }
 
+// DeleteLogs 删除指定ID的日志
+func (m *SqliteNodeManager) DeleteLogs(ids []string) error {
+	return m.logManager.DeleteLogs(ids)
+}
+
 // CreateLog 创建新日志
 func (m *SqliteNodeManager) CreateLog(logEntry log.LogEntry) error {
 	return m.logManager.CreateLog(logEntry)
 }
+
+// FlushLogs 等待已创建的日志全部写入数据库
+func (m *SqliteNodeManager) FlushLogs() error {
+	return m.logManager.Flush()
+}
+
+// CloseLogs 写入剩余的日志，服务退出时调用
+func (m *SqliteNodeManager) CloseLogs() error {
+	return m.logManager.Close()
+}
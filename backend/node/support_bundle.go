@@ -0,0 +1,207 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SupportBundleDir 支持包本地保存目录
+const SupportBundleDir = "support-bundles"
+
+// supportBundleMetaSuffix 支持包元数据文件的后缀，元数据与支持包保存在同一目录
+const supportBundleMetaSuffix = ".json"
+
+// ErrSupportBundleNotFound 支持包不存在
+var ErrSupportBundleNotFound = errors.New("support bundle not found")
+
+// SupportBundle 节点故障排查支持包
+type SupportBundle struct {
+	Name     string `json:"name"`
+	NodeID   string `json:"nodeId"`
+	NodeName string `json:"nodeName"`
+	// ProjectID 节点所属的项目，只能在该项目中下载和删除
+	ProjectID string    `json:"projectId"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// supportBundleScript 在节点上收集排查信息并打包，%[1]s为临时目录名
+const supportBundleScript = `DIR=/tmp/%[1]s
+rm -rf $DIR && mkdir -p $DIR
+
+# 系统信息
+{
+    echo "=== uname ==="; uname -a
+    echo "=== os-release ==="; cat /etc/os-release
+    echo "=== uptime ==="; uptime
+    echo "=== memory ==="; free -m
+    echo "=== disk ==="; df -h
+    echo "=== swap ==="; swapon --show
+    echo "=== ip addr ==="; ip addr
+    echo "=== ip route ==="; ip route
+    echo "=== lsmod ==="; lsmod
+} > $DIR/system.txt 2>&1
+
+# kubelet和containerd日志
+sudo journalctl -u kubelet --no-pager -n 3000 > $DIR/kubelet.log 2>&1
+sudo journalctl -u containerd --no-pager -n 3000 > $DIR/containerd.log 2>&1
+systemctl status kubelet containerd --no-pager > $DIR/services.txt 2>&1
+
+# kubeadm相关信息
+{
+    echo "=== kubeadm version ==="; kubeadm version
+    echo "=== kubelet version ==="; kubelet --version
+    echo "=== kubeadm config ==="; sudo cat /etc/kubernetes/kubeadm-config.yaml
+    echo "=== /etc/kubernetes ==="; sudo ls -lR /etc/kubernetes
+    echo "=== kubeadm-flags.env ==="; sudo cat /var/lib/kubelet/kubeadm-flags.env
+    echo "=== containers ==="; sudo crictl ps -a
+} > $DIR/kubeadm.txt 2>&1
+mkdir -p $DIR/pods
+for f in $(sudo ls /var/log/pods 2>/dev/null | grep kube-system); do
+    sudo sh -c "tail -n 500 /var/log/pods/$f/*/*.log" > $DIR/pods/$f.log 2>&1
+done
+
+# 系统日志节选
+if [ -f /var/log/messages ]; then
+    sudo tail -n 3000 /var/log/messages > $DIR/messages.log 2>&1
+elif [ -f /var/log/syslog ]; then
+    sudo tail -n 3000 /var/log/syslog > $DIR/messages.log 2>&1
+fi
+sudo dmesg -T 2>/dev/null | tail -n 500 > $DIR/dmesg.log
+
+# 内核参数和containerd配置
+sudo sysctl -a > $DIR/sysctl.txt 2>/dev/null
+sudo cat /etc/containerd/config.toml > $DIR/containerd-config.toml 2>&1
+
+sudo tar czf $DIR.tar.gz -C /tmp %[1]s
+sudo chown $(id -u):$(id -g) $DIR.tar.gz
+rm -rf $DIR
+ls -l $DIR.tar.gz`
+
+// CollectSupportBundle 从节点收集kubelet/containerd日志、kubeadm信息、系统日志、内核参数和containerd配置，
+// 打包后下载到SupportBundleDir
+func CollectSupportBundle(n Node) (*SupportBundle, error) {
+	client, err := Connect(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect node: %v", err)
+	}
+	defer client.Close()
+
+	now := time.Now()
+	name := fmt.Sprintf("support-%s-%s", sanitizeBundleName(n.Name), now.Format("20060102-150405"))
+	remotePath := fmt.Sprintf("/tmp/%s.tar.gz", name)
+
+	if output, err := client.RunCommandSilent(fmt.Sprintf(supportBundleScript, name)); err != nil {
+		return nil, fmt.Errorf("failed to collect support bundle: %v, output: %s", err, output)
+	}
+	defer client.RunCommandSilent("rm -f " + remotePath)
+
+	if err := os.MkdirAll(SupportBundleDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create support bundle dir: %v", err)
+	}
+	localPath := filepath.Join(SupportBundleDir, name+".tar.gz")
+	if err := client.DownloadFile(remotePath, localPath); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat support bundle: %v", err)
+	}
+	if n.ProjectID == "" {
+		n.ProjectID = DefaultProjectID
+	}
+	bundle := &SupportBundle{
+		Name:      filepath.Base(localPath),
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		ProjectID: n.ProjectID,
+		Size:      info.Size(),
+		CreatedAt: now,
+	}
+	if err := SaveSupportBundle(bundle); err != nil {
+		os.Remove(localPath)
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// SaveSupportBundle 保存支持包的元数据，支持包文件已经在SupportBundleDir中
+func SaveSupportBundle(bundle *SupportBundle) error {
+	if err := validateBundleName(bundle.Name); err != nil {
+		return err
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(SupportBundleDir, bundle.Name+supportBundleMetaSuffix), data, 0644); err != nil {
+		return fmt.Errorf("failed to save support bundle metadata: %v", err)
+	}
+	return nil
+}
+
+// GetSupportBundle 读取支持包的元数据和本地路径。没有元数据的旧支持包属于默认项目
+func GetSupportBundle(name string) (*SupportBundle, string, error) {
+	if err := validateBundleName(name); err != nil {
+		return nil, "", err
+	}
+	path := filepath.Join(SupportBundleDir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrSupportBundleNotFound, name)
+	}
+
+	bundle := &SupportBundle{Name: name, ProjectID: DefaultProjectID, Size: info.Size(), CreatedAt: info.ModTime()}
+	data, err := os.ReadFile(path + supportBundleMetaSuffix)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, bundle); err != nil {
+			return nil, "", fmt.Errorf("invalid metadata of support bundle %s: %v", name, err)
+		}
+		if bundle.ProjectID == "" {
+			bundle.ProjectID = DefaultProjectID
+		}
+	case !os.IsNotExist(err):
+		return nil, "", fmt.Errorf("failed to read metadata of support bundle %s: %v", name, err)
+	}
+	return bundle, path, nil
+}
+
+// DeleteSupportBundle 删除支持包和元数据
+func DeleteSupportBundle(name string) error {
+	_, path, err := GetSupportBundle(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete support bundle %s: %v", name, err)
+	}
+	if err := os.Remove(path + supportBundleMetaSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete metadata of support bundle %s: %v", name, err)
+	}
+	return nil
+}
+
+// validateBundleName 拒绝包含路径分隔符或不是支持包文件的名称
+func validateBundleName(name string) error {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".tar.gz") {
+		return fmt.Errorf("invalid support bundle name: %s", name)
+	}
+	return nil
+}
+
+// sanitizeBundleName 将节点名称转换为可用于文件名的字符串
+func sanitizeBundleName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+}
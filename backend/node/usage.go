@@ -0,0 +1,191 @@
+package node
+
+import (
+	"fmt"
+	"k8s-installer/ssh"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usagePaths 需要检查磁盘和inode使用率的目录，部署中镜像和etcd数据写入这些目录
+var usagePaths = []string{"/", "/var/lib/containerd", "/var/lib/etcd"}
+
+// UsageThresholds 资源使用率告警阈值，超过阈值时在Warnings中给出提示
+type UsageThresholds struct {
+	DiskWarnPercent   float64 `json:"diskWarnPercent"`
+	InodeWarnPercent  float64 `json:"inodeWarnPercent"`
+	MemoryWarnPercent float64 `json:"memoryWarnPercent"`
+	// MinFreeBytes 目录所在文件系统的最小可用空间，拉取镜像需要数GB空间
+	MinFreeBytes uint64 `json:"minFreeBytes"`
+	// LoadWarnPerCPU 1分钟平均负载除以CPU数的告警阈值
+	LoadWarnPerCPU float64 `json:"loadWarnPerCpu"`
+}
+
+// DefaultUsageThresholds 默认告警阈值
+var DefaultUsageThresholds = UsageThresholds{
+	DiskWarnPercent:   85,
+	InodeWarnPercent:  85,
+	MemoryWarnPercent: 90,
+	MinFreeBytes:      5 << 30,
+	LoadWarnPerCPU:    2,
+}
+
+// DiskUsage 目录所在文件系统的空间和inode使用情况
+type DiskUsage struct {
+	Path string `json:"path"`
+	// Mount 目录所在的挂载点，目录不存在时为最近的已存在上级目录所在的挂载点
+	Mount            string  `json:"mount"`
+	Exists           bool    `json:"exists"`
+	TotalBytes       uint64  `json:"totalBytes"`
+	UsedBytes        uint64  `json:"usedBytes"`
+	AvailableBytes   uint64  `json:"availableBytes"`
+	UsedPercent      float64 `json:"usedPercent"`
+	InodesTotal      uint64  `json:"inodesTotal"`
+	InodesUsed       uint64  `json:"inodesUsed"`
+	InodeUsedPercent float64 `json:"inodeUsedPercent"`
+}
+
+// NodeUsage 节点资源使用快照
+type NodeUsage struct {
+	NodeID               string          `json:"nodeId"`
+	NodeName             string          `json:"nodeName"`
+	CPUs                 int             `json:"cpus"`
+	Load1                float64         `json:"load1"`
+	Load5                float64         `json:"load5"`
+	Load15               float64         `json:"load15"`
+	MemoryTotalBytes     uint64          `json:"memoryTotalBytes"`
+	MemoryAvailableBytes uint64          `json:"memoryAvailableBytes"`
+	MemoryUsedPercent    float64         `json:"memoryUsedPercent"`
+	Disks                []DiskUsage     `json:"disks"`
+	Thresholds           UsageThresholds `json:"thresholds"`
+	Warnings             []string        `json:"warnings"`
+	CollectedAt          time.Time       `json:"collectedAt"`
+}
+
+// usageCmd 输出CPU数、平均负载、内存和每个目录的磁盘及inode使用情况，
+// 目录不存在时（如尚未安装containerd）统计最近的已存在上级目录
+func usageCmd() string {
+	return `echo "cpus $(nproc)"; echo "load $(cat /proc/loadavg)"; ` +
+		`awk '/^MemTotal:|^MemAvailable:/{print "mem", $1, $2}' /proc/meminfo; ` +
+		`for p in ` + strings.Join(usagePaths, " ") + `; do ` +
+		`d=$p; while [ ! -e "$d" ]; do d=$(dirname "$d"); done; e=no; [ "$d" = "$p" ] && e=yes; ` +
+		`echo "disk $p $e $(df -P -B1 "$d" | awk 'NR==2{print $2, $3, $4, $6}')"; ` +
+		`echo "inode $p $(df -P -i "$d" | awk 'NR==2{print $2, $3}')"; done`
+}
+
+// CollectNodeUsage 通过SSH读取节点的资源使用情况
+func CollectNodeUsage(n Node, thresholds UsageThresholds) (*NodeUsage, error) {
+	client, err := Connect(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH client: %v", err)
+	}
+	defer client.Close()
+
+	usage, err := CollectUsage(client, thresholds)
+	if err != nil {
+		return nil, err
+	}
+	usage.NodeID, usage.NodeName = n.ID, n.Name
+	return usage, nil
+}
+
+// CollectUsage 读取CPU负载、内存、磁盘和inode使用情况，并按阈值给出告警
+func CollectUsage(r ssh.Runner, thresholds UsageThresholds) (*NodeUsage, error) {
+	output, err := r.RunCommandSilent(usageCmd())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource usage: %v", err)
+	}
+
+	usage := &NodeUsage{Thresholds: thresholds, Disks: []DiskUsage{}, Warnings: []string{}, CollectedAt: time.Now()}
+	disks := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "cpus":
+			usage.CPUs, _ = strconv.Atoi(fields[1])
+		case "load":
+			if len(fields) >= 4 {
+				usage.Load1, _ = strconv.ParseFloat(fields[1], 64)
+				usage.Load5, _ = strconv.ParseFloat(fields[2], 64)
+				usage.Load15, _ = strconv.ParseFloat(fields[3], 64)
+			}
+		case "mem":
+			if len(fields) >= 3 {
+				kb, _ := strconv.ParseUint(fields[2], 10, 64)
+				if fields[1] == "MemTotal:" {
+					usage.MemoryTotalBytes = kb << 10
+				} else {
+					usage.MemoryAvailableBytes = kb << 10
+				}
+			}
+		case "disk":
+			if len(fields) >= 7 {
+				disk := DiskUsage{Path: fields[1], Exists: fields[2] == "yes", Mount: fields[6]}
+				disk.TotalBytes, _ = strconv.ParseUint(fields[3], 10, 64)
+				disk.UsedBytes, _ = strconv.ParseUint(fields[4], 10, 64)
+				disk.AvailableBytes, _ = strconv.ParseUint(fields[5], 10, 64)
+				disk.UsedPercent = percent(disk.UsedBytes, disk.UsedBytes+disk.AvailableBytes)
+				usage.Disks = append(usage.Disks, disk)
+				disks[disk.Path] = len(usage.Disks) - 1
+			}
+		case "inode":
+			if i, ok := disks[fields[1]]; ok && len(fields) >= 4 {
+				disk := &usage.Disks[i]
+				disk.InodesTotal, _ = strconv.ParseUint(fields[2], 10, 64)
+				disk.InodesUsed, _ = strconv.ParseUint(fields[3], 10, 64)
+				disk.InodeUsedPercent = percent(disk.InodesUsed, disk.InodesTotal)
+			}
+		}
+	}
+	if usage.CPUs == 0 && usage.MemoryTotalBytes == 0 && len(usage.Disks) == 0 {
+		return nil, fmt.Errorf("unexpected resource usage output: %s", strings.TrimSpace(output))
+	}
+	if usage.MemoryTotalBytes > 0 {
+		usage.MemoryUsedPercent = percent(usage.MemoryTotalBytes-usage.MemoryAvailableBytes, usage.MemoryTotalBytes)
+	}
+
+	usage.Warnings = usageWarnings(usage, thresholds)
+	return usage, nil
+}
+
+// usageWarnings 按阈值检查使用情况，同一挂载点只提示一次
+func usageWarnings(usage *NodeUsage, t UsageThresholds) []string {
+	warnings := []string{}
+	if usage.CPUs > 0 && t.LoadWarnPerCPU > 0 && usage.Load1/float64(usage.CPUs) > t.LoadWarnPerCPU {
+		warnings = append(warnings, fmt.Sprintf("1分钟平均负载 %.2f 超过 %d 个CPU的 %.1f 倍", usage.Load1, usage.CPUs, t.LoadWarnPerCPU))
+	}
+	if t.MemoryWarnPercent > 0 && usage.MemoryUsedPercent > t.MemoryWarnPercent {
+		warnings = append(warnings, fmt.Sprintf("内存使用率 %.1f%% 超过 %.0f%%", usage.MemoryUsedPercent, t.MemoryWarnPercent))
+	}
+
+	checked := make(map[string]bool)
+	for _, disk := range usage.Disks {
+		if checked[disk.Mount] {
+			continue
+		}
+		checked[disk.Mount] = true
+		if t.DiskWarnPercent > 0 && disk.UsedPercent > t.DiskWarnPercent {
+			warnings = append(warnings, fmt.Sprintf("%s 所在的 %s 磁盘使用率 %.1f%% 超过 %.0f%%", disk.Path, disk.Mount, disk.UsedPercent, t.DiskWarnPercent))
+		}
+		if t.MinFreeBytes > 0 && disk.AvailableBytes < t.MinFreeBytes {
+			warnings = append(warnings, fmt.Sprintf("%s 所在的 %s 可用空间 %.1fGiB 低于 %.1fGiB，部署中可能因磁盘写满失败",
+				disk.Path, disk.Mount, float64(disk.AvailableBytes)/(1<<30), float64(t.MinFreeBytes)/(1<<30)))
+		}
+		if t.InodeWarnPercent > 0 && disk.InodeUsedPercent > t.InodeWarnPercent {
+			warnings = append(warnings, fmt.Sprintf("%s 所在的 %s inode使用率 %.1f%% 超过 %.0f%%", disk.Path, disk.Mount, disk.InodeUsedPercent, t.InodeWarnPercent))
+		}
+	}
+	return warnings
+}
+
+// percent 计算百分比，保留一位小数
+func percent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used*1000/total) / 10
+}
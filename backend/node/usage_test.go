@@ -0,0 +1,51 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"k8s-installer/ssh"
+)
+
+// usageOutput 4核节点的usageCmd输出，/var/lib/etcd尚不存在，统计的是根目录
+const usageOutput = `cpus 4
+load 9.50 3.20 1.10 2/300 12345
+mem MemTotal: 8000000
+mem MemAvailable: 400000
+disk / yes 100000000000 95000000000 5000000000 /
+inode / 1000000 100000
+disk /var/lib/containerd yes 100000000000 95000000000 5000000000 /
+inode /var/lib/containerd 1000000 100000
+disk /var/lib/etcd no 100000000000 95000000000 5000000000 /
+inode /var/lib/etcd 1000000 100000
+`
+
+func TestCollectUsage(t *testing.T) {
+	rec := ssh.NewRecorder().On("/proc/loadavg", usageOutput, nil)
+	usage, err := CollectUsage(rec, DefaultUsageThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.CPUs != 4 || usage.Load1 != 9.5 {
+		t.Fatalf("CPUs = %d, Load1 = %v, want 4 and 9.5", usage.CPUs, usage.Load1)
+	}
+	if usage.MemoryUsedPercent != 95 {
+		t.Fatalf("MemoryUsedPercent = %v, want 95", usage.MemoryUsedPercent)
+	}
+	if len(usage.Disks) != 3 || usage.Disks[2].Exists {
+		t.Fatalf("Disks = %+v, want 3 paths with /var/lib/etcd missing", usage.Disks)
+	}
+	// 负载、内存、磁盘使用率和可用空间各告警一次，三个目录在同一挂载点只提示一次
+	if len(usage.Warnings) != 4 {
+		t.Fatalf("Warnings = %q, want 4 warnings", usage.Warnings)
+	}
+}
+
+func TestCollectUsageErrors(t *testing.T) {
+	if _, err := CollectUsage(ssh.NewRecorder().On("/proc/loadavg", "", errors.New("timeout")), DefaultUsageThresholds); err == nil {
+		t.Error("expected an error when the command fails")
+	}
+	if _, err := CollectUsage(ssh.NewRecorder().On("/proc/loadavg", "command not found\n", nil), DefaultUsageThresholds); err == nil {
+		t.Error("expected an error for unexpected output")
+	}
+}
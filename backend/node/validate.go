@@ -0,0 +1,21 @@
+package node
+
+import "k8s-installer/validate"
+
+// Validate 检查节点的IP、端口、名称和类型，名称需要满足RFC 1123以便作为主机名和Kubernetes节点名
+func (n Node) Validate() error {
+	v := &validate.Validator{}
+	if v.Required("name", n.Name) {
+		v.DNSSubdomain("name", n.Name)
+	}
+	if v.Required("ip", n.IP) {
+		v.IP("ip", n.IP)
+	}
+	v.Port("port", n.Port)
+	v.Required("username", n.Username)
+	validateSecretRefs(v, n.Password, n.PasswordRef, n.PrivateKey, n.PrivateKeyRef)
+	if n.NodeType != "" && n.NodeType != NodeTypeMaster && n.NodeType != NodeTypeWorker {
+		v.Add("nodeType", "must be %s or %s", NodeTypeMaster, NodeTypeWorker)
+	}
+	return v.Err()
+}
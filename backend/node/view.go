@@ -0,0 +1,63 @@
+package node
+
+import "time"
+
+// View 返回给API调用方的节点信息，不包含密码、私钥和join命令，只标记是否已配置
+type View struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	IP               string    `json:"ip"`
+	Port             int       `json:"port"`
+	Username         string    `json:"username"`
+	NodeType         string    `json:"nodeType"`
+	Status           string    `json:"status"`
+	ContainerRuntime string    `json:"containerRuntime"`
+	OS               string    `json:"os"`
+	OSInfo           *OSInfo   `json:"osInfo,omitempty"`
+	GroupID          string    `json:"groupId,omitempty"`
+	ProjectID        string    `json:"projectId,omitempty"`
+	Managed          string    `json:"managed,omitempty"`
+	HasPassword      bool      `json:"hasPassword"`
+	HasPrivateKey    bool      `json:"hasPrivateKey"`
+	HasJoinCommand   bool      `json:"hasJoinCommand"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	// PasswordRef PrivateKeyRef 凭据引用只包含环境变量名或文件路径，可以返回
+	PasswordRef   string `json:"passwordRef,omitempty"`
+	PrivateKeyRef string `json:"privateKeyRef,omitempty"`
+}
+
+// View 转换为不含凭据的节点信息
+func (n Node) View() View {
+	return View{
+		ID:               n.ID,
+		Name:             n.Name,
+		IP:               n.IP,
+		Port:             n.Port,
+		Username:         n.Username,
+		NodeType:         n.NodeType,
+		Status:           n.Status,
+		ContainerRuntime: n.ContainerRuntime,
+		OS:               n.OS,
+		OSInfo:           n.OSInfo,
+		GroupID:          n.GroupID,
+		ProjectID:        n.ProjectID,
+		Managed:          n.Managed,
+		HasPassword:      n.Password != "",
+		HasPrivateKey:    n.PrivateKey != "",
+		HasJoinCommand:   n.JoinCommand != "",
+		CreatedAt:        n.CreatedAt,
+		UpdatedAt:        n.UpdatedAt,
+		PasswordRef:      n.PasswordRef,
+		PrivateKeyRef:    n.PrivateKeyRef,
+	}
+}
+
+// Views 批量转换为不含凭据的节点信息
+func Views(nodes []Node) []View {
+	views := make([]View, 0, len(nodes))
+	for _, n := range nodes {
+		views = append(views, n.View())
+	}
+	return views
+}
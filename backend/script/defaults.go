@@ -0,0 +1,157 @@
+package script
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// DefaultsVersion 默认脚本模板的版本，模板定义在templates.go中
+const DefaultsVersion = 2
+
+// 默认脚本按发行版家族区分，与node.DistroFamily的返回值一致
+const (
+	FamilyDebian = "debian"
+	FamilyRHEL   = "rhel"
+	FamilySUSE   = "suse"
+	FamilyAmazon = "amazon"
+)
+
+// familyFallback 没有专用模板的发行版家族改用的模板
+var familyFallback = map[string]string{FamilyAmazon: FamilyRHEL}
+
+// defaultTemplates 各步骤的默认脚本模板，按发行版家族区分；家族为空的通用模板以步骤名称保存为默认脚本，
+// 在发行版未知或没有家族模板时使用
+var defaultTemplates = map[string]map[string]string{
+	StepSystemPrep:        {"": systemPrepTemplate},
+	StepContainerdInstall: {"": containerdInstallTemplate},
+	StepContainerdConfig:  {"": containerdConfigTemplate},
+	StepK8sRepo: {
+		"":           byPackageManager(debianRepoTemplate, rhelRepoTemplate, suseRepoTemplate),
+		FamilyDebian: debianRepoTemplate,
+		FamilyRHEL:   rhelRepoTemplate,
+		FamilySUSE:   suseRepoTemplate,
+	},
+	StepK8sComponents: {
+		"":           byPackageManager(debianComponentsTemplate, rhelComponentsTemplate, suseComponentsTemplate),
+		FamilyDebian: debianComponentsTemplate,
+		FamilyRHEL:   rhelComponentsTemplate,
+		FamilySUSE:   suseComponentsTemplate,
+	},
+	StepK8sInit: {"": k8sInitTemplate},
+	StepK8sJoin: {"": k8sJoinTemplate},
+}
+
+// legacyDefaultDigests 旧版本默认脚本内容的SHA-256摘要，已保存的同名脚本与其一致时视为未修改，启动时升级为当前模板
+var legacyDefaultDigests = map[string][]string{
+	StepSystemPrep: {
+		"618ce51b7e96b2b26652d04ec3f8d46089978c4772ec9e1e29164e58d6246a94",
+	},
+	StepContainerdInstall: {
+		"ca7ff1b05916f174010436d43d1a8bb961659f8dccd95258b1271e4836a2f986",
+	},
+	StepContainerdConfig: {
+		"37a2a199a1cfb3d850d213e27b6c293d00daa44fe1541aaae00d663bda2e2928",
+		"8b121e1ccf30b8200192d01d312efebd0b3d93720416acdfe192cd5047615ce5",
+		"1805145397333e43991f96a00617c9e381675905eec98d4a4c328e3b90c5ce39",
+		"2fb360a6ff17929a4cc4c8b0c9035e7503950d160c093578950c6e1a534e0bc3",
+	},
+	StepK8sComponents: {
+		"3ec93954d499321ca552058c7918e9f3aa60ba52f6b3bcf23ca7fad1c2be74ab",
+		"5fea6b8dad095318c68711a46454922ee040d797436bd884dafec34e6e156ac1",
+		"447deb585d45f5602adfaa1003ad23115ca7ece8dbaa09d34ffac238f4797066",
+		"df85edbaa28148ef5439c4c9643e3e401bbbbfeea9839e62a6806a87421b61f2",
+	},
+}
+
+// byPackageManager 将各发行版家族的模板合并为按包管理器选择的通用脚本
+func byPackageManager(debian, rhel, suse string) string {
+	return "if command -v apt-get &> /dev/null; then\n" + debian +
+		"\nelif command -v zypper &> /dev/null; then\n" + suse +
+		"\nelif command -v dnf &> /dev/null || command -v yum &> /dev/null; then\n" + rhel +
+		"\nelse\n    echo \"未检测到支持的包管理器\"\n    exit 1\nfi"
+}
+
+// DefaultScript 返回步骤在发行版家族上使用的默认脚本模板，没有家族专用模板时返回通用模板
+func DefaultScript(step, family string) (string, bool) {
+	templates := defaultTemplates[step]
+	for _, f := range []string{family, familyFallback[family]} {
+		if f == "" {
+			continue
+		}
+		if template, ok := templates[f]; ok {
+			return template, true
+		}
+	}
+	template, ok := templates[""]
+	return template, ok
+}
+
+// DefaultScripts 返回以步骤名称保存的通用默认脚本
+func DefaultScripts() map[string]string {
+	scripts := make(map[string]string, len(defaultTemplates))
+	for step, templates := range defaultTemplates {
+		if template, ok := templates[""]; ok {
+			scripts[step] = template
+		}
+	}
+	return scripts
+}
+
+// isDefaultContent 脚本内容是否为步骤当前或旧版本的通用默认模板，即用户没有修改过
+func isDefaultContent(step, content string) bool {
+	if template, ok := defaultTemplates[step][""]; ok && template == content {
+		return true
+	}
+	return isLegacyDefault(step, content)
+}
+
+// isLegacyDefault 脚本内容是否为旧版本的默认脚本
+func isLegacyDefault(step, content string) bool {
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+	return containsString(legacyDefaultDigests[step], digest)
+}
+
+// Resolved 部署步骤解析得到的脚本
+type Resolved struct {
+	Name    string
+	Content string
+	// Default 使用的是默认模板，没有用户自定义的脚本
+	Default bool
+}
+
+// ResolveDefault 返回步骤在发行版家族上使用的默认模板，名称为${步骤}_default
+func ResolveDefault(step, family string) (Resolved, bool) {
+	template, ok := DefaultScript(step, family)
+	if !ok {
+		return Resolved{}, false
+	}
+	return Resolved{Name: step + "_default", Content: template, Default: true}, true
+}
+
+// ResolveScript 解析步骤在节点上使用的脚本，依次查找：按元数据绑定到该发行版的脚本、修改过的同名通用脚本、
+// 发行版家族的默认模板；没有可用的脚本时返回false
+func (m *ScriptManager) ResolveScript(step, distro, family string) (Resolved, bool) {
+	if m == nil {
+		return ResolveDefault(step, family)
+	}
+	if name, ok := m.FindScript(step, distro); ok {
+		if content, found := m.GetScript(name); found {
+			return Resolved{Name: name, Content: content}, true
+		}
+	}
+	if content, ok := m.GetScript(step); ok && !isDefaultContent(step, content) {
+		return Resolved{Name: step, Content: content}, true
+	}
+	return ResolveDefault(step, family)
+}
+
+// Resolve 通过脚本管理器解析步骤脚本，scriptManager为nil或不支持解析时使用默认模板
+func Resolve(scriptManager interface{}, step, distro, family string) (Resolved, bool) {
+	if resolver, ok := scriptManager.(interface {
+		ResolveScript(step, distro, family string) (Resolved, bool)
+	}); ok {
+		return resolver.ResolveScript(step, distro, family)
+	}
+	return ResolveDefault(step, family)
+}
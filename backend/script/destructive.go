@@ -0,0 +1,62 @@
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AllowDestructiveMarker 行尾带有该注释的命令不作为危险命令检查，用于确实需要格式化数据盘等操作的脚本
+const AllowDestructiveMarker = "# k8s-installer: allow-destructive"
+
+// destructivePattern 危险命令的匹配规则
+type destructivePattern struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// destructivePatterns 可能破坏节点系统或数据的命令
+var destructivePatterns = []destructivePattern{
+	// 递归删除根目录、根目录下的所有文件或整个系统目录，如rm -rf /、rm -rf /*、rm -rf /etc
+	{"rm-root", regexp.MustCompile(`\brm\s+(?:-[a-zA-Z]*[rR][a-zA-Z]*\s+|--recursive\s+|--no-preserve-root\s+|-[a-zA-Z]+\s+)*(?:"|')?/(?:\*|bin|boot|dev|etc|home|lib|lib64|opt|proc|root|sbin|srv|sys|usr|var)?/?\*?(?:"|')?(?:\s|;|&|\||$)`)},
+	{"rm-no-preserve-root", regexp.MustCompile(`\brm\s+.*--no-preserve-root`)},
+	{"mkfs", regexp.MustCompile(`\bmkfs(?:\.[a-z0-9]+)?\b`)},
+	{"dd-device", regexp.MustCompile(`\bdd\b.*\bof=/dev/(?:sd[a-z]|nvme\d|vd[a-z]|xvd[a-z]|hd[a-z]|mmcblk\d|dm-\d|md\d|disk/|mapper/)`)},
+	{"wipefs", regexp.MustCompile(`\bwipefs\b`)},
+	{"partition-zap", regexp.MustCompile(`\b(?:sgdisk\s+.*--zap|sfdisk\s+.*--delete|parted\s+.*\brm\b)`)},
+	{"write-block-device", regexp.MustCompile(`>\s*/dev/(?:sd[a-z]|nvme\d|vd[a-z]|xvd[a-z]|hd[a-z]|mmcblk\d)`)},
+	{"shred-device", regexp.MustCompile(`\bshred\b.*\s/dev/`)},
+	{"recursive-chmod-root", regexp.MustCompile(`\bch(?:mod|own)\s+(?:-[a-zA-Z]*R[a-zA-Z]*|--recursive)\s+\S+\s+/(?:\s|$)`)},
+	{"fork-bomb", regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`)},
+}
+
+// DestructiveFinding 脚本中的危险命令
+type DestructiveFinding struct {
+	Script  string `json:"script"`
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Command string `json:"command"`
+}
+
+// String 返回便于阅读的描述
+func (f DestructiveFinding) String() string {
+	return fmt.Sprintf("%s line %d: %s (%s)", f.Script, f.Line, f.Command, f.Rule)
+}
+
+// ScanDestructive 检查渲染后的脚本中的危险命令，忽略注释和带有AllowDestructiveMarker的行
+func ScanDestructive(name, content string) []DestructiveFinding {
+	var findings []DestructiveFinding
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasSuffix(trimmed, AllowDestructiveMarker) {
+			continue
+		}
+		for _, p := range destructivePatterns {
+			if p.pattern.MatchString(trimmed) {
+				findings = append(findings, DestructiveFinding{Script: name, Line: i + 1, Rule: p.name, Command: trimmed})
+				break
+			}
+		}
+	}
+	return findings
+}
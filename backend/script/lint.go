@@ -0,0 +1,206 @@
+package script
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 脚本检查问题级别：error阻止保存，warning只提示
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// 脚本对应的部署步骤，与通用脚本名称一致
+const (
+	StepSystemPrep        = "system_prep"
+	StepContainerdInstall = "containerd_install"
+	StepContainerdConfig  = "containerd_config"
+	StepK8sRepo           = "k8s_repo"
+	StepK8sComponents     = "k8s_components"
+	StepK8sInit           = "k8s_init"
+	StepK8sJoin           = "k8s_join"
+)
+
+// TemplateVariables 部署时替换的模板变量
+var TemplateVariables = []string{"version", "k8s_repo_channel", "k8s_repo_deb", "k8s_repo_rpm"}
+
+// essentialCommands 每个步骤的脚本必须包含的命令，每组命令中至少包含一个
+var essentialCommands = map[string][][]string{
+	StepSystemPrep:        {{"ip_forward"}},
+	StepContainerdInstall: {{"containerd"}},
+	StepContainerdConfig:  {{"systemctl restart containerd", "systemctl start containerd"}, {"systemctl enable containerd"}, {"systemctl daemon-reload"}},
+	StepK8sComponents:     {{"kubeadm"}, {"kubelet"}},
+	StepK8sInit:           {{"kubeadm init"}},
+	StepK8sJoin:           {{"kubeadm join"}},
+}
+
+// LintIssue 脚本检查发现的问题
+type LintIssue struct {
+	Script   string `json:"script"`
+	Severity string `json:"severity"`
+	// Rule 检查项：syntax、essential-command、template-variable或destructive-command
+	Rule    string `json:"rule"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// StepOf 根据脚本名称识别对应的部署步骤，支持通用名称、_default后缀、${distro}_${step}以及旧格式的${distro}_${中文步骤名}和k8s_components_${distro}，无法识别时返回空字符串
+func StepOf(name string) string {
+	return inferMetadata(name).Step
+}
+
+// MissingEssentialCommands 返回步骤脚本缺少的必要命令，每组命令以" | "连接
+func MissingEssentialCommands(step, content string) []string {
+	var missing []string
+	for _, alternatives := range essentialCommands[step] {
+		found := false
+		for _, cmd := range alternatives {
+			if strings.Contains(content, cmd) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, strings.Join(alternatives, " | "))
+		}
+	}
+	return missing
+}
+
+// Lint 检查脚本：bash语法、步骤必要命令、未替换的模板变量和危险命令
+func Lint(name, content string) []LintIssue {
+	issues := checkSyntax(name, content)
+	if step := StepOf(name); step != "" {
+		for _, cmd := range MissingEssentialCommands(step, content) {
+			issues = append(issues, LintIssue{Script: name, Severity: SeverityWarning, Rule: "essential-command", Message: fmt.Sprintf("%s script does not contain %s", step, cmd)})
+		}
+	}
+	// 危险命令不阻止保存，部署时需要force确认
+	for _, f := range ScanDestructive(name, content) {
+		issues = append(issues, LintIssue{Script: name, Severity: SeverityWarning, Rule: "destructive-command", Line: f.Line, Message: fmt.Sprintf("destructive command (%s): %s, deploying requires force or a trailing %q", f.Rule, f.Command, AllowDestructiveMarker)})
+	}
+	return append(issues, checkTemplateVariables(name, content)...)
+}
+
+// LintAll 检查多个脚本，按脚本名称排序返回问题
+func LintAll(scripts map[string]string) []LintIssue {
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	issues := []LintIssue{}
+	for _, name := range names {
+		issues = append(issues, Lint(name, scripts[name])...)
+	}
+	return issues
+}
+
+// HasErrors 是否存在阻止保存的问题
+func HasErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// syntaxErrorLine bash -n输出中的行号，如"bash: line 3: syntax error near unexpected token"
+var syntaxErrorLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+// checkSyntax 使用bash -n检查语法，服务器上没有bash时跳过
+func checkSyntax(name, content string) []LintIssue {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		return []LintIssue{{Script: name, Severity: SeverityWarning, Rule: "syntax", Message: "bash not found on the server, syntax check skipped"}}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bash, "-n")
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		return nil
+	}
+
+	var issues []LintIssue
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		issue := LintIssue{Script: name, Severity: SeverityError, Rule: "syntax", Message: line}
+		if m := syntaxErrorLine.FindStringSubmatch(line); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+			issue.Message = m[2]
+		}
+		issues = append(issues, issue)
+	}
+	if len(issues) == 0 {
+		issues = append(issues, LintIssue{Script: name, Severity: SeverityError, Rule: "syntax", Message: "bash -n failed"})
+	}
+	return issues
+}
+
+var (
+	// goTemplateVar 脚本不支持的Go模板变量，如{{ .Version }}
+	goTemplateVar = regexp.MustCompile(`\{\{[^}]*\}\}`)
+	// bracedVar ${name}形式的变量引用，忽略${#name}、${name:-default}等运算
+	bracedVar = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	// assignedVar 脚本中赋值的变量：name=、for name in、read name、export/local/declare name
+	assignedVar = regexp.MustCompile(`(?m)(?:^|[\s;(])(?:export\s+|local\s+|declare\s+(?:-\w+\s+)*|readonly\s+)?([A-Za-z_][A-Za-z0-9_]*)(?:\[[^\]]*\])?\+?=|\bfor\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b|\bread\s+(?:-\w+\s+)*([A-Za-z_][A-Za-z0-9_ ]*)`)
+)
+
+// environmentVars 节点上通常存在的环境变量
+var environmentVars = map[string]bool{
+	"HOME": true, "PATH": true, "USER": true, "SHELL": true, "PWD": true, "HOSTNAME": true,
+	"LANG": true, "TERM": true, "SUDO_USER": true, "ID": true, "VERSION_ID": true, "ID_LIKE": true,
+	"VERSION_CODENAME": true, "UBUNTU_CODENAME": true, "NAME": true, "PRETTY_NAME": true,
+	"http_proxy": true, "https_proxy": true, "no_proxy": true, "HTTP_PROXY": true, "HTTPS_PROXY": true, "NO_PROXY": true,
+}
+
+// checkTemplateVariables 检查未替换的模板变量：Go模板语法，以及既不是模板变量、也没有在脚本中赋值的${name}
+func checkTemplateVariables(name, content string) []LintIssue {
+	var issues []LintIssue
+	for i, line := range strings.Split(content, "\n") {
+		for _, m := range goTemplateVar.FindAllString(line, -1) {
+			issues = append(issues, LintIssue{Script: name, Severity: SeverityWarning, Rule: "template-variable", Line: i + 1, Message: fmt.Sprintf("%s is not substituted, only ${%s} is supported", m, strings.Join(TemplateVariables, "}, ${"))})
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, v := range TemplateVariables {
+		known[v] = true
+	}
+	for _, m := range assignedVar.FindAllStringSubmatch(content, -1) {
+		for _, group := range m[1:] {
+			for _, v := range strings.Fields(group) {
+				known[v] = true
+			}
+		}
+	}
+
+	reported := make(map[string]bool)
+	for i, line := range strings.Split(content, "\n") {
+		for _, m := range bracedVar.FindAllStringSubmatch(line, -1) {
+			v := m[1]
+			if known[v] || environmentVars[v] || reported[v] {
+				continue
+			}
+			reported[v] = true
+			issues = append(issues, LintIssue{Script: name, Severity: SeverityWarning, Rule: "template-variable", Line: i + 1, Message: fmt.Sprintf("${%s} is neither a template variable nor assigned in the script and will expand to an empty string, template variables: ${%s}", v, strings.Join(TemplateVariables, "}, ${"))})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,237 @@
+package script
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s-installer/validate"
+)
+
+// Steps 脚本可以绑定的部署步骤
+var Steps = []string{StepSystemPrep, StepContainerdInstall, StepContainerdConfig, StepK8sRepo, StepK8sComponents, StepK8sInit, StepK8sJoin}
+
+// distroPattern 发行版名称，与节点检测到的发行版一致，如ubuntu、centos、openeuler
+var distroPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Metadata 脚本元数据，部署流程按Step和Distros选择发行版专用的脚本
+type Metadata struct {
+	Description string `json:"description,omitempty"`
+	// Step 脚本绑定的部署步骤，为空时部署流程不会自动选用该脚本
+	Step string `json:"step,omitempty"`
+	// Distros 脚本适用的发行版，为空表示通用脚本
+	Distros []string `json:"distros,omitempty"`
+	Author  string   `json:"author,omitempty"`
+}
+
+// Validate 检查步骤和发行版名称
+func (md Metadata) Validate() error {
+	v := &validate.Validator{}
+	if md.Step != "" && !containsString(Steps, md.Step) {
+		v.Add("step", "must be one of %s", strings.Join(Steps, ", "))
+	}
+	for _, distro := range md.Distros {
+		if !distroPattern.MatchString(distro) {
+			v.Add("distros", "invalid distro %q, use lowercase names such as ubuntu or centos", distro)
+		}
+	}
+	return v.Err()
+}
+
+// isZero 没有设置任何元数据
+func (md Metadata) isZero() bool {
+	return md.Description == "" && md.Step == "" && len(md.Distros) == 0 && md.Author == ""
+}
+
+// legacyStepAliases 旧版前端按发行版保存脚本时使用的步骤名（界面中文名称转小写、空格替换为下划线），
+// 只用于识别和迁移旧名称${distro}_${步骤名}，新脚本使用稳定的步骤键${distro}_${step}
+var legacyStepAliases = map[string]string{
+	"系统准备":            StepSystemPrep,
+	"安装容器运行时":         StepContainerdInstall,
+	"配置容器运行时":         StepContainerdConfig,
+	"添加kubernetes仓库":  StepK8sRepo,
+	"安装kubernetes组件":  StepK8sComponents,
+	"初始化kubernetes集群": StepK8sInit,
+	"生成worker节点加入命令":  StepK8sInit,
+	"worker节点加入集群":    StepK8sJoin,
+}
+
+// ScriptName 步骤脚本的名称：通用脚本为步骤键，发行版专用脚本为${distro}_${step}
+func ScriptName(step, distro string) string {
+	distro = strings.ToLower(strings.TrimSpace(distro))
+	if distro == "" {
+		return step
+	}
+	return distro + "_" + step
+}
+
+// CanonicalName 将旧格式的脚本名称${distro}_${中文步骤名}和k8s_components_${distro}转换为${distro}_${step}，其他名称原样返回
+func CanonicalName(name string) string {
+	if step, distro, ok := parseLegacyName(name); ok {
+		return ScriptName(step, distro)
+	}
+	return name
+}
+
+// parseLegacyName 解析旧格式的脚本名称
+func parseLegacyName(name string) (step, distro string, ok bool) {
+	for alias, step := range legacyStepAliases {
+		if distro := strings.TrimSuffix(name, "_"+alias); distro != name && distro != "" {
+			return step, distro, true
+		}
+	}
+	if distro := strings.TrimPrefix(name, StepK8sComponents+"_"); distro != name && distro != "" && distro != "default" {
+		return StepK8sComponents, distro, true
+	}
+	return "", "", false
+}
+
+// inferMetadata 根据脚本名称推断元数据：通用名称、${distro}_${step}和旧格式名称
+func inferMetadata(name string) Metadata {
+	base := strings.TrimSuffix(name, "_default")
+	if containsString(Steps, base) {
+		return Metadata{Step: base}
+	}
+	for _, step := range Steps {
+		if distro := strings.TrimSuffix(name, "_"+step); distro != name && distroPattern.MatchString(distro) {
+			return Metadata{Step: step, Distros: []string{distro}}
+		}
+	}
+	if step, distro, ok := parseLegacyName(name); ok {
+		return Metadata{Step: step, Distros: []string{distro}}
+	}
+	return Metadata{}
+}
+
+// MetadataOf 返回脚本的元数据，没有保存元数据时根据脚本名称推断
+func (m *ScriptManager) MetadataOf(name string) Metadata {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.metadataOf(name)
+}
+
+// metadataOf 调用方需持有读锁
+func (m *ScriptManager) metadataOf(name string) Metadata {
+	if md, ok := m.metadata[name]; ok {
+		return md
+	}
+	return inferMetadata(name)
+}
+
+// GetMetadata 获取所有脚本的元数据
+func (m *ScriptManager) GetMetadata() map[string]Metadata {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	metadata := make(map[string]Metadata, len(m.scripts))
+	for name := range m.scripts {
+		metadata[name] = m.metadataOf(name)
+	}
+	return metadata
+}
+
+// SetMetadata 设置脚本的元数据并保存
+func (m *ScriptManager) SetMetadata(name string, md Metadata) error {
+	if err := md.Validate(); err != nil {
+		return err
+	}
+	name = CanonicalName(name)
+	m.mutex.Lock()
+	if _, ok := m.scripts[name]; !ok {
+		m.mutex.Unlock()
+		return fmt.Errorf("script %s not found", name)
+	}
+	md.Distros = normalizeDistros(md.Distros)
+	m.metadata[name] = md
+	m.mutex.Unlock()
+
+	return m.SaveScripts()
+}
+
+// FindScript 按元数据查找绑定到步骤并适用于发行版的脚本，返回脚本名称。
+// 只匹配明确列出该发行版的脚本，通用脚本由调用方按名称查找；多个脚本匹配时使用名称排序后的第一个
+func (m *ScriptManager) FindScript(step, distro string) (string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	distro = strings.ToLower(distro)
+	var matches []string
+	for name := range m.scripts {
+		md := m.metadataOf(name)
+		if md.Step == step && containsString(md.Distros, distro) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+	sort.Strings(matches)
+	return matches[0], true
+}
+
+// migrateMetadata 为旧版本数据库的scripts表添加元数据列
+func migrateMetadata(db *sql.DB) error {
+	for _, column := range []string{"description", "step", "distros", "author"} {
+		var columnExists bool
+		if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('scripts') WHERE name = ?", column).Scan(&columnExists); err != nil {
+			return fmt.Errorf("failed to check %s column: %v", column, err)
+		}
+		if columnExists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE scripts ADD COLUMN %s TEXT NOT NULL DEFAULT ''", column)); err != nil {
+			return fmt.Errorf("failed to add %s column: %v", column, err)
+		}
+	}
+	return nil
+}
+
+// createProjectScriptsTable 创建默认项目之外的项目的脚本表，列与scripts表相同
+func createProjectScriptsTable(db *sql.DB) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS project_scripts (
+		project_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		content TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		step TEXT NOT NULL DEFAULT '',
+		distros TEXT NOT NULL DEFAULT '',
+		author TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (project_id, name)
+	);
+	`
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create project_scripts table: %v", err)
+	}
+	return nil
+}
+
+// normalizeDistros 发行版名称去重并排序
+func normalizeDistros(distros []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, distro := range distros {
+		distro = strings.ToLower(strings.TrimSpace(distro))
+		if distro == "" || seen[distro] {
+			continue
+		}
+		seen[distro] = true
+		result = append(result, distro)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
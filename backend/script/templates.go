@@ -0,0 +1,810 @@
+package script
+
+// 默认脚本模板，${version}和${k8s_repo_*}占位符在部署时替换。
+// 修改任一模板时需递增DefaultsVersion，并将修改前的通用模板摘要加入legacyDefaultDigests
+
+// DisableSwapCmd 禁用swap并在重启后保持禁用，未启用swap支持时在默认系统准备脚本之前执行
+const DisableSwapCmd = `# 禁用swap
+echo "=== 禁用swap ==="
+sudo swapoff -a
+sudo sed -i '/ swap / s/^/#/' /etc/fstab
+if [ $? -eq 0 ]; then
+    echo "✓ swap已禁用并在重启后保持禁用"
+else
+    echo "⚠ swap禁用可能未完全生效，请检查/etc/fstab文件"
+fi`
+
+// systemPrepTemplate 系统准备：内核模块、sysctl、iptables、防火墙和SELinux，swap和时间同步由部署流程按参数单独处理
+const systemPrepTemplate = `# 系统准备脚本
+# 更新软件包索引，时间同步在系统准备脚本之后按部署参数单独配置
+if command -v apt-get &> /dev/null; then
+    sudo apt update -y
+fi
+
+# 1. 必须的内核模块 - Calico初始化依赖
+	echo "=== 加载必须的内核模块（Calico初始化依赖） ==="
+	sudo modprobe br_netfilter || echo "br_netfilter模块已加载或加载失败"
+	sudo modprobe overlay || echo "overlay模块已加载或加载失败"
+	
+	# 2. 持久化内核模块配置
+	echo "=== 持久化内核模块配置 ==="
+	sudo cat <<EOF > /etc/modules-load.d/k8s.conf
+br_netfilter
+overlay
+EOF
+
+	# 3. 必须的 sysctl - Calico初始化依赖，此文件必须写入
+	echo "=== 配置必须的sysctl（Calico初始化依赖） ==="
+	sudo cat <<EOF > /etc/sysctl.d/k8s.conf
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+net.ipv4.ip_forward = 1
+EOF
+	sudo sysctl --system
+
+	# 4. 安装iptables和ip6tables以及CNI插件所需的iproute-tc工具
+	echo "=== 安装iptables、ip6tables和iproute-tc ==="
+	if command -v apt-get &> /dev/null; then
+	    sudo apt install -y iptables ip6tables iproute2
+	elif command -v dnf &> /dev/null; then
+	    # Rocky 10 必装，否则calico-node Init直接失败
+	    sudo dnf install -y iptables ip6tables-services iproute-tc
+	elif command -v yum &> /dev/null; then
+	    sudo yum install -y iptables-services iproute-tc
+	elif command -v zypper &> /dev/null; then
+	    sudo zypper --non-interactive install -y iptables iproute2
+	fi
+
+	# 5. BPF挂载点（init容器mount-bpffs需要）
+	echo "=== 创建并挂载BPF挂载点 ==="
+	sudo mkdir -p /sys/fs/bpf
+	sudo mount bpffs /sys/fs/bpf || true
+
+	# 6. 确保CNI目录存在
+	echo "=== 确保CNI目录存在 ==="
+	sudo mkdir -p /opt/cni/bin
+	sudo chmod 755 /opt/cni/bin
+	sudo mkdir -p /etc/cni/net.d
+	sudo chmod 755 /etc/cni/net.d
+
+	# 7. 重启关键服务
+	echo "=== 重启关键服务 ==="
+	sudo systemctl restart containerd || true
+	sudo systemctl restart kubelet || true
+
+# 处理iptables服务（兼容不同系统）
+echo "=== 处理iptables服务 ==="
+if command -v systemctl &> /dev/null; then
+    # 对于不同系统的iptables服务兼容处理
+    echo "检查iptables服务状态..."
+    # 尝试启动并启用iptables服务，如果不存在则忽略错误
+    if systemctl list-units --type=service | grep -q iptables; then
+        echo "iptables服务存在，正在启动和启用..."
+        sudo systemctl enable --now iptables || true
+        sudo systemctl restart iptables || true
+    else
+        echo "iptables服务不存在，确保iptables命令可用..."
+        if ! command -v iptables &> /dev/null; then
+            echo "iptables命令不可用，尝试安装..."
+            if command -v apt-get &> /dev/null; then
+                sudo apt install -y iptables || true
+            elif command -v dnf &> /dev/null; then
+                sudo dnf install -y iptables || true
+            elif command -v yum &> /dev/null; then
+                sudo yum install -y iptables || true
+            elif command -v zypper &> /dev/null; then
+                sudo zypper --non-interactive install -y iptables || true
+            fi
+        else
+            echo "✓ iptables命令已可用"
+        fi
+    fi
+    
+    # 处理ip6tables服务
+    if systemctl list-units --type=service | grep -q ip6tables; then
+        echo "ip6tables服务存在，正在启动和启用..."
+        sudo systemctl enable --now ip6tables || true
+        sudo systemctl restart ip6tables || true
+    else
+        echo "ip6tables服务不存在，确保ip6tables命令可用..."
+        if ! command -v ip6tables &> /dev/null; then
+            echo "ip6tables命令不可用，尝试安装..."
+            if command -v apt-get &> /dev/null; then
+                sudo apt install -y ip6tables || true
+            elif command -v dnf &> /dev/null; then
+                sudo dnf install -y ip6tables || true
+            elif command -v yum &> /dev/null; then
+                sudo yum install -y ip6tables || true
+            elif command -v zypper &> /dev/null; then
+                sudo zypper --non-interactive install -y iptables || true
+            fi
+        else
+            echo "✓ ip6tables命令已可用"
+        fi
+    fi
+fi
+
+# 关闭防火墙（实验环境建议关闭）并确保重启后保持关闭
+echo "=== 配置防火墙 ==="
+if command -v ufw &> /dev/null; then
+    echo "处理ufw防火墙..."
+    # 停止并禁用ufw服务
+    sudo systemctl stop ufw || true
+    sudo systemctl disable ufw || true
+    # 额外的禁用步骤，确保完全关闭
+    sudo ufw disable 2>/dev/null || true
+    # 确保ufw配置文件设置为禁用
+    if [ -f /etc/ufw/ufw.conf ]; then
+        sudo sed -i 's/^ENABLED=yes/ENABLED=no/' /etc/ufw/ufw.conf || true
+    fi
+    echo "✓ ufw防火墙已关闭并禁用，重启后保持关闭"
+elif command -v firewall-cmd &> /dev/null; then
+    echo "处理firewalld防火墙..."
+    # 停止并禁用firewalld服务
+    sudo systemctl stop firewalld || true
+    sudo systemctl disable firewalld || true
+    # 额外的禁用步骤，确保完全关闭
+    sudo firewall-cmd --state 2>/dev/null && sudo firewall-cmd --panic-on || true
+    # 确保firewalld配置文件设置为禁用
+    if [ -f /etc/firewalld/firewalld.conf ]; then
+        sudo sed -i 's/^FirewallBackend=.*/FirewallBackend=nftables/' /etc/firewalld/firewalld.conf || true
+    fi
+    echo "✓ firewalld防火墙已关闭并禁用，重启后保持关闭"
+else
+    echo "未检测到ufw或firewalld，跳过防火墙配置"
+fi
+
+# 配置SELinux为permissive模式（仅适用于RHEL/CentOS系统）并确保重启后保持配置
+echo "=== 配置SELinux ==="
+if command -v setenforce &> /dev/null; then
+    echo "临时设置SELinux为permissive模式..."
+    sudo setenforce 0 2>/dev/null || true
+    
+    echo "永久设置SELinux为permissive模式..."
+    # 尝试多种方式修改SELINUX配置，确保生效
+    if [ -f /etc/selinux/config ]; then
+        # 备份原始配置文件
+        sudo cp /etc/selinux/config /etc/selinux/config.bak
+        # 修改配置文件，将enforcing改为permissive
+        sudo sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config 2>/dev/null || true
+        # 添加fallback，将disabled也改为permissive
+        sudo sed -i 's/^SELINUX=disabled$/SELINUX=permissive/' /etc/selinux/config 2>/dev/null || true
+        # 验证SELinux配置
+        selinux_status=$(grep ^SELINUX= /etc/selinux/config | cut -d= -f2)
+        echo "SELinux配置已设置为: $selinux_status"
+        # 验证SELinux配置文件内容
+        sudo grep -E '^SELINUX=' /etc/selinux/config 2>/dev/null || true
+        # 再次确认SELinux状态
+        selinux_current=$(sudo getenforce 2>/dev/null || echo "Unknown")
+        echo "当前SELinux状态: $selinux_current"
+        if [ "$selinux_status" = "permissive" ] || [ "$selinux_current" = "Permissive" ]; then
+            echo "✓ SELinux已成功设置为permissive模式，重启后保持配置"
+        else
+            echo "⚠ SELinux配置可能未完全生效，请检查/etc/selinux/config文件"
+        fi
+    else
+        echo "未找到/etc/selinux/config文件，SELinux可能未安装或使用不同配置"
+    fi
+else
+    echo "未检测到SELinux，跳过SELinux配置"
+fi
+
+# 确保防火墙和SELinux状态在重启后保持
+echo "=== 最终确认防火墙和SELinux状态 ==="
+# 再次确认防火墙状态
+if command -v ufw &> /dev/null; then
+    ufw_status=$(sudo ufw status 2>/dev/null || echo "inactive")
+    echo "当前ufw状态: $ufw_status"
+elif command -v firewall-cmd &> /dev/null; then
+    firewalld_status=$(sudo systemctl is-active firewalld 2>/dev/null || echo "inactive")
+    echo "当前firewalld状态: $firewalld_status"
+fi
+
+# 再次确认SELinux状态
+if command -v getenforce &> /dev/null; then
+    selinux_current=$(sudo getenforce 2>/dev/null || echo "Disabled")
+    echo "当前SELinux状态: $selinux_current"
+fi
+
+# 加载K8s所需内核模块
+echo "=== 加载Kubernetes所需内核模块 ==="
+sudo cat <<EOF > /etc/modules-load.d/k8s.conf
+overlay
+br_netfilter
+EOF
+
+sudo modprobe overlay || echo "overlay模块已加载或加载失败"
+sudo modprobe br_netfilter || echo "br_netfilter模块已加载或加载失败"
+
+# 设置内核参数
+echo "=== 设置内核参数 ==="
+# 使用EOF方式写入IP转发配置文件
+sudo cat <<EOF > /etc/sysctl.d/99-kubernetes-ipforward.conf
+net.ipv4.ip_forward = 1
+EOF
+
+# 设置其他Kubernetes所需内核参数
+sudo cat <<EOF > /etc/sysctl.d/k8s.conf
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+EOF
+
+# 应用内核参数
+sudo sysctl --system
+
+# 验证内核参数设置
+echo "=== 验证内核参数 ==="
+sudo sysctl net.bridge.bridge-nf-call-iptables net.bridge.bridge-nf-call-ip6tables net.ipv4.ip_forward`
+
+// containerdInstallTemplate 安装containerd和crictl，CONTAINERD_VERSION变量指定containerd版本
+const containerdInstallTemplate = `# containerd安装脚本
+echo "=== 安装containerd ==="
+if ! command -v containerd &> /dev/null; then
+    echo "containerd未安装，正在安装..."
+    if command -v apt-get &> /dev/null; then
+        # Ubuntu/Debian系统
+        echo "=== 使用apt-get安装containerd ==="
+        sudo apt update -y
+        sudo apt install -y containerd.io${CONTAINERD_VERSION:+=${CONTAINERD_VERSION}*} crictl curl
+        # 确保containerd服务存在
+        if [ ! -f /lib/systemd/system/containerd.service ]; then
+            echo "containerd.service不存在，创建默认服务文件..."
+            sudo mkdir -p /etc/containerd
+            sudo containerd config default | sudo tee /etc/containerd/config.toml
+        fi
+    elif command -v zypper &> /dev/null; then
+        # openSUSE/SLES系统，containerd由发行版仓库提供（SLES需启用Containers模块）
+        echo "=== 使用zypper安装containerd ==="
+        sudo zypper --non-interactive install -y containerd cri-tools curl
+    elif grep -q '^ID="\?amzn' /etc/os-release 2>/dev/null; then
+        # Amazon Linux系统，containerd由Amazon仓库提供，不使用Docker的CentOS仓库
+        echo "=== 使用Amazon Linux仓库安装containerd ==="
+        if command -v dnf &> /dev/null; then
+            sudo dnf install -y containerd curl
+        else
+            sudo yum install -y containerd curl
+        fi
+    elif command -v dnf &> /dev/null || command -v yum &> /dev/null; then
+        # CentOS/RHEL系统
+        echo "=== 添加Docker仓库 ==="
+        # 安装必要的依赖
+        if command -v dnf &> /dev/null; then
+            sudo dnf install -y dnf-plugins-core curl
+            sudo dnf config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo
+            sudo dnf install -y containerd.io${CONTAINERD_VERSION:+-${CONTAINERD_VERSION}} crictl
+        else
+            sudo yum install -y yum-utils curl
+            sudo yum-config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo
+            sudo yum install -y containerd.io${CONTAINERD_VERSION:+-${CONTAINERD_VERSION}} crictl
+        fi
+    else
+        echo "=== 警告: 不支持的包管理器，尝试手动安装containerd ==="
+        # 尝试从GitHub下载并安装containerd
+        if command -v curl &> /dev/null && command -v tar &> /dev/null; then
+            CONTAINERD_VERSION="${CONTAINERD_VERSION:-1.6.28}"
+            ARCH="amd64"
+            echo "从GitHub下载containerd v${CONTAINERD_VERSION}..."
+            sudo mkdir -p /tmp/containerd
+            curl -fsSL -o /tmp/containerd/containerd.tar.gz https://github.com/containerd/containerd/releases/download/v${CONTAINERD_VERSION}/containerd-${CONTAINERD_VERSION}-linux-${ARCH}.tar.gz
+            sudo mkdir -p /usr/local/bin /usr/local/lib /etc/containerd
+            sudo tar Cxzvf /usr/local /tmp/containerd/containerd.tar.gz
+            sudo rm -rf /tmp/containerd
+            # 创建systemd服务文件
+            sudo cat > /etc/systemd/system/containerd.service <<-'EOF'
+[Unit]
+Description=containerd container runtime
+Documentation=https://containerd.io
+After=network.target local-fs.target
+
+[Service]
+ExecStartPre=-/sbin/modprobe overlay
+ExecStart=/usr/local/bin/containerd
+Restart=always
+RestartSec=5
+Delegate=yes
+KillMode=process
+OOMScoreAdjust=-999
+LimitNOFILE=1048576
+LimitNPROC=infinity
+LimitCORE=infinity
+
+[Install]
+WantedBy=multi-user.target
+EOF
+            sudo systemctl daemon-reload
+            sudo systemctl enable containerd
+        fi
+    fi
+else
+    echo "containerd已安装，跳过安装步骤"
+fi
+
+# 安装crictl（容器运行时接口客户端）
+echo "=== 安装crictl ==="
+if ! command -v crictl &> /dev/null; then
+    echo "crictl未安装，正在安装..."
+    if command -v curl &> /dev/null; then
+        CRICTL_VERSION="1.26.0"
+        ARCH="amd64"
+        echo "从GitHub下载crictl v${CRICTL_VERSION}..."
+        sudo curl -fsSL -o /usr/local/bin/crictl https://github.com/kubernetes-sigs/cri-tools/releases/download/v${CRICTL_VERSION}/crictl-v${CRICTL_VERSION}-linux-${ARCH}.tar.gz
+        sudo tar -xzf /usr/local/bin/crictl -C /usr/local/bin
+        sudo rm -f /usr/local/bin/crictl.tar.gz
+        echo "设置crictl配置文件..."
+        sudo cat > /etc/crictl.yaml <<-'EOF'
+runtime-endpoint: unix:///run/containerd/containerd.sock
+image-endpoint: unix:///run/containerd/containerd.sock
+timeout: 10
+debug: false
+EOF
+    fi
+else
+    echo "crictl已安装，跳过安装步骤"
+fi`
+
+// containerdConfigTemplate 生成containerd配置、启用systemd cgroup驱动并启动服务
+const containerdConfigTemplate = WaitForFunc + `# containerd配置脚本
+# 配置containerd
+echo "=== 配置containerd ==="
+sudo mkdir -p /etc/containerd
+
+# 生成默认配置，覆盖现有配置以确保正确性
+echo "生成containerd默认配置..."
+sudo containerd config default | sudo tee /etc/containerd/config.toml
+
+# 确保使用systemd cgroup驱动
+echo "配置systemd cgroup驱动..."
+sudo sed -i 's/SystemdCgroup = false/SystemdCgroup = true/g' /etc/containerd/config.toml
+
+# 修复cgroup配置路径
+echo "修复cgroup配置路径..."
+sudo sed -i 's#containerd.runtimes.runc.options#containerd.runtimes.runc.options.cgroup#g' /etc/containerd/config.toml || true
+
+# 配置containerd使用镜像加速
+echo "配置containerd使用镜像加速..."
+sudo sed -i '/\[plugins\."io\.containerd\.grpc\.v1\.cri"\.registry\.mirrors\]/,/\[/c\[plugins."io.containerd.grpc.v1.cri".registry.mirrors\]\n\n  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]\n    endpoint = ["https://registry.docker-cn.com", "https://docker.mirrors.ustc.edu.cn", "https://docker.io"]' /etc/containerd/config.toml
+
+# 启动前先停止可能运行的containerd进程
+echo "停止可能运行的containerd进程..."
+sudo pkill -f containerd || true
+sleep 2
+
+# 清理旧的containerd socket和状态文件
+echo "清理旧的containerd socket和状态文件..."
+sudo rm -f /run/containerd/containerd.sock || true
+sudo rm -rf /var/run/containerd || true
+sudo mkdir -p /var/run/containerd
+
+# 确保containerd服务存在
+echo "确保containerd服务存在..."
+if [ ! -f /etc/systemd/system/containerd.service ]; then
+    echo "创建containerd服务文件..."
+    sudo cat > /etc/systemd/system/containerd.service <<-'EOF'
+[Unit]
+Description=containerd container runtime
+Documentation=https://containerd.io
+After=network.target local-fs.target
+
+[Service]
+ExecStartPre=-/sbin/modprobe overlay
+ExecStart=/usr/bin/containerd
+Restart=always
+RestartSec=5
+Delegate=yes
+KillMode=process
+OOMScoreAdjust=-999
+LimitNOFILE=1048576
+LimitNPROC=infinity
+LimitCORE=infinity
+
+[Install]
+WantedBy=multi-user.target
+EOF
+fi
+
+# 启动并启用containerd服务
+echo "启动containerd服务..."
+sudo systemctl daemon-reload
+sudo systemctl start containerd || true
+sudo systemctl enable containerd
+
+# 等待containerd启动，未就绪时重启后再等待一次
+echo "等待containerd启动..."
+if ! wait_for 60 'containerd就绪' bash -c 'sudo test -S /run/containerd/containerd.sock'; then
+    sudo systemctl restart containerd || true
+    wait_for 60 'containerd就绪' bash -c 'sudo test -S /run/containerd/containerd.sock' || true
+fi
+
+# 检查containerd状态
+echo "=== 检查containerd状态 ==="
+if command -v systemctl &> /dev/null; then
+    systemctl_status=$(sudo systemctl is-active containerd 2>/dev/null || echo "unknown")
+    echo "containerd服务状态: $systemctl_status"
+    
+    # 显示containerd服务详细状态
+    echo "containerd服务详细状态:"
+    sudo systemctl status containerd --no-pager
+fi
+
+# 检查containerd socket是否存在
+echo "=== 检查containerd socket ==="
+cri_socket="/run/containerd/containerd.sock"
+if [ -S "$cri_socket" ]; then
+    echo "✓ CRI socket $cri_socket 存在"
+    # 测试socket连接
+    echo "测试containerd连接..."
+    if command -v ctr &> /dev/null; then
+        sudo ctr version
+    fi
+    if command -v crictl &> /dev/null; then
+        sudo crictl version
+    fi
+else
+    echo "✗ 警告: CRI socket $cri_socket 不存在，检查containerd日志..."
+    sudo journalctl -u containerd --no-pager -n 30
+    
+    # 尝试手动启动containerd
+echo "尝试手动启动containerd..."
+if command -v containerd &> /dev/null; then
+    containerd_version=$(containerd --version)
+    echo "containerd版本: $containerd_version"
+    
+    # 手动创建必要的目录
+    sudo mkdir -p /run/containerd /var/lib/containerd
+    
+    # 尝试手动启动containerd
+echo "使用默认配置手动启动containerd..."
+    sudo containerd --config /etc/containerd/config.toml &
+    CONTAINERD_PID=$!
+    wait_for 30 "containerd socket创建" sudo test -S "$cri_socket" || true
+    
+    # 再次检查socket
+    if [ -S "$cri_socket" ]; then
+        echo "✓ 手动启动成功，CRI socket $cri_socket 现在存在"
+        # 停止手动启动的containerd进程
+        sudo kill $CONTAINERD_PID || true
+        sleep 2
+        # 重新使用systemctl启动
+        sudo systemctl restart containerd
+    else
+        echo "✗ 手动启动失败，CRI socket $cri_socket 仍然不存在"
+        # 停止手动启动的containerd进程
+        sudo kill $CONTAINERD_PID || true
+    fi
+fi
+fi
+
+# 最终验证containerd状态
+echo "=== 最终验证containerd状态 ==="
+if command -v crictl &> /dev/null; then
+    echo "使用crictl测试containerd连接..."
+    sudo crictl info || echo "crictl测试失败，可能containerd未正常运行"
+fi`
+
+// aptRepoCmd 添加目标次版本号的apt仓库
+const aptRepoCmd = `sudo apt-get update -y
+sudo apt-get install -y apt-transport-https ca-certificates curl gpg
+sudo mkdir -p -m 755 /etc/apt/keyrings
+curl -fsSL ${k8s_repo_deb}Release.key | sudo gpg --dearmor --yes -o /etc/apt/keyrings/kubernetes-apt-keyring.gpg
+sudo chmod 644 /etc/apt/keyrings/kubernetes-apt-keyring.gpg
+echo "deb [signed-by=/etc/apt/keyrings/kubernetes-apt-keyring.gpg] ${k8s_repo_deb} /" | sudo tee /etc/apt/sources.list.d/kubernetes.list > /dev/null
+sudo apt-get update -y`
+
+// yumRepoCmd 写入yum/dnf仓库配置，kubelet、kubeadm和kubectl默认排除，安装时使用--disableexcludes=kubernetes
+const yumRepoCmd = `sudo rm -f /etc/yum.repos.d/packages.cloud.google.com_yum_repos_kubernetes*.repo
+cat <<EOF | sudo tee /etc/yum.repos.d/kubernetes.repo > /dev/null
+[kubernetes]
+name=Kubernetes ${k8s_repo_channel}
+baseurl=${k8s_repo_rpm}
+enabled=1
+gpgcheck=1
+gpgkey=${k8s_repo_rpm}repodata/repomd.xml.key
+exclude=kubelet kubeadm kubectl cri-tools kubernetes-cni
+EOF
+if command -v dnf &> /dev/null; then
+    sudo dnf clean all
+    sudo dnf makecache -y
+else
+    sudo yum clean all
+    sudo yum makecache -y
+fi`
+
+// debianRepoTemplate 添加Kubernetes仓库（Ubuntu/Debian）
+const debianRepoTemplate = `# 添加Kubernetes仓库（Ubuntu/Debian）
+echo "=== 添加Kubernetes仓库 ${k8s_repo_channel} ==="
+` + aptRepoCmd
+
+// rhelRepoTemplate 添加Kubernetes仓库（CentOS/RHEL/Rocky/AlmaLinux/Amazon Linux）
+const rhelRepoTemplate = `# 添加Kubernetes仓库（CentOS/RHEL/Rocky/AlmaLinux）
+echo "=== 添加Kubernetes仓库 ${k8s_repo_channel} ==="
+` + yumRepoCmd
+
+// suseRepoTemplate 添加Kubernetes仓库（openSUSE/SLES）
+const suseRepoTemplate = `# 添加Kubernetes仓库（openSUSE/SLES）
+echo "=== 添加Kubernetes仓库 ==="
+sudo zypper --non-interactive removerepo kubernetes > /dev/null 2>&1 || true
+sudo rpm --import ${k8s_repo_rpm}repodata/repomd.xml.key
+sudo zypper --non-interactive addrepo --refresh ${k8s_repo_rpm} kubernetes
+
+# 更新仓库缓存
+sudo zypper --non-interactive --gpg-auto-import-keys refresh kubernetes`
+
+// debianComponentsTemplate 安装kubelet、kubeadm和kubectl（Ubuntu/Debian），指定版本不可用时使用仓库中的最新版本
+const debianComponentsTemplate = `# 安装Kubernetes组件（Ubuntu/Debian）
+echo "=== 添加Kubernetes仓库 ${k8s_repo_channel} ==="
+` + aptRepoCmd + `
+
+# 检查可用的Kubernetes版本
+echo "=== 检查可用的Kubernetes版本 ==="
+AVAILABLE_VERSIONS=$(apt-cache madison kubelet | grep -oP '[0-9]+\.[0-9]+\.[0-9]+' | sort -V | uniq)
+
+echo "可用的Kubernetes版本: $AVAILABLE_VERSIONS"
+
+# 选择要安装的版本
+SELECTED_VERSION="${version}"
+echo "尝试安装指定版本: $SELECTED_VERSION"
+
+# 检查指定版本是否可用
+if ! echo "$AVAILABLE_VERSIONS" | grep -q "^$SELECTED_VERSION$"; then
+    echo "指定版本 $SELECTED_VERSION 不可用，查找可用的最新版本..."
+    # 如果指定版本不可用，使用可用的最新版本
+    LATEST_VERSION=$(echo "$AVAILABLE_VERSIONS" | tail -1)
+    if [ -n "$LATEST_VERSION" ]; then
+        echo "使用可用的最新版本: $LATEST_VERSION"
+        SELECTED_VERSION="$LATEST_VERSION"
+    else
+        echo "警告: 未找到可用的Kubernetes版本，尝试使用1.28.2版本..."
+        SELECTED_VERSION="1.28.2"
+    fi
+fi
+
+# 安装Kubernetes组件
+echo "=== 安装kubelet、kubeadm和kubectl $SELECTED_VERSION ==="
+apt-get install -y "kubelet=$SELECTED_VERSION-*" "kubeadm=$SELECTED_VERSION-*" "kubectl=$SELECTED_VERSION-*"
+
+# 启动kubelet
+echo "=== 启动kubelet服务 ==="
+sudo systemctl enable --now kubelet
+
+# 验证所有组件安装
+echo "=== 验证组件安装 ==="
+echo "检查kubeadm版本..."
+kubeadm version
+echo "检查kubelet版本..."
+kubelet --version
+echo "检查kubectl版本..."
+kubectl version --client
+echo "检查containerd版本..."
+containerd --version
+if command -v crictl &> /dev/null; then
+    echo "检查crictl版本..."
+    crictl version
+fi`
+
+// rhelComponentsTemplate 安装kubelet、kubeadm和kubectl（CentOS/RHEL/Rocky/AlmaLinux/Amazon Linux）
+const rhelComponentsTemplate = `# 安装Kubernetes组件（CentOS/RHEL/Rocky/AlmaLinux）
+echo "=== 添加Kubernetes仓库 ${k8s_repo_channel} ==="
+` + yumRepoCmd + `
+
+# 检查可用的Kubernetes版本
+echo "=== 检查可用的Kubernetes版本 ==="
+# 改进版本检测逻辑，使用更可靠的方法
+AVAILABLE_VERSIONS=$(if command -v dnf &> /dev/null; then
+    # 尝试多种方法获取可用版本
+    sudo dnf list --available kubelet --disableexcludes=kubernetes 2>/dev/null | grep -E 'kubelet' | grep -v '^\+' | awk '{print $2}' | cut -d'-' -f1 | sort -V | uniq || \
+    sudo dnf search kubelet --disableexcludes=kubernetes 2>/dev/null | grep -E '^kubelet-[0-9]' | awk '{print $1}' | cut -d'-' -f2 | sort -V | uniq || \
+    echo "1.28.2"
+else
+    # 尝试多种方法获取可用版本
+    sudo yum list --available kubelet --disableexcludes=kubernetes 2>/dev/null | grep -E 'kubelet' | grep -v '^\+' | awk '{print $2}' | cut -d'-' -f1 | sort -V | uniq || \
+    sudo yum search kubelet --disableexcludes=kubernetes 2>/dev/null | grep -E '^kubelet-[0-9]' | awk '{print $1}' | cut -d'-' -f2 | sort -V | uniq || \
+    echo "1.28.2"
+fi)
+
+# 清理版本列表，移除空值和重复项
+AVAILABLE_VERSIONS=$(echo "$AVAILABLE_VERSIONS" | grep -v '^$' | sort -V | uniq)
+
+echo "可用的Kubernetes版本: $AVAILABLE_VERSIONS"
+
+# 选择要安装的版本
+SELECTED_VERSION="${version}"
+echo "尝试安装指定版本: $SELECTED_VERSION"
+
+# 检查指定版本是否可用
+if ! echo "$AVAILABLE_VERSIONS" | grep -q "^$SELECTED_VERSION$"; then
+    echo "指定版本 $SELECTED_VERSION 不可用，查找可用的最新版本..."
+    # 如果指定版本不可用，使用可用的最新版本
+    LATEST_VERSION=$(echo "$AVAILABLE_VERSIONS" | tail -1)
+    if [ -n "$LATEST_VERSION" ]; then
+        echo "使用可用的最新版本: $LATEST_VERSION"
+        SELECTED_VERSION="$LATEST_VERSION"
+    else
+        echo "警告: 未找到可用的Kubernetes版本，尝试使用1.28.2版本..."
+        SELECTED_VERSION="1.28.2"
+    fi
+fi
+
+# 最终验证SELECTED_VERSION是否为空
+if [ -z "$SELECTED_VERSION" ]; then
+    echo "错误: SELECTED_VERSION变量为空，使用默认版本1.28.2"
+    SELECTED_VERSION="1.28.2"
+fi
+
+# 安装Kubernetes组件
+echo "=== 安装kubelet、kubeadm和kubectl $SELECTED_VERSION ==="
+# 依次尝试不同的版本格式，失败后的重试由部署流程的重试策略控制
+PKG_MGR=yum
+if command -v dnf &> /dev/null; then
+    PKG_MGR=dnf
+fi
+echo "使用$PKG_MGR安装Kubernetes组件..."
+INSTALL_SUCCESS=false
+# 尝试1: 不指定版本，使用最新版本
+if sudo $PKG_MGR install -y kubelet kubeadm kubectl --disableexcludes=kubernetes; then
+    echo "✓ 安装成功（使用最新版本）"
+    INSTALL_SUCCESS=true
+# 尝试2: 指定完整版本号
+elif sudo $PKG_MGR install -y kubelet-$SELECTED_VERSION kubeadm-$SELECTED_VERSION kubectl-$SELECTED_VERSION --disableexcludes=kubernetes; then
+    echo "✓ 安装成功（使用指定版本）"
+    INSTALL_SUCCESS=true
+# 尝试3: 使用更宽松的版本匹配
+elif sudo $PKG_MGR install -y "kubelet-$SELECTED_VERSION*" "kubeadm-$SELECTED_VERSION*" "kubectl-$SELECTED_VERSION*" --disableexcludes=kubernetes; then
+    echo "✓ 安装成功（使用版本匹配）"
+    INSTALL_SUCCESS=true
+fi
+
+# 检查安装是否成功
+if [ "$INSTALL_SUCCESS" = false ]; then
+    echo "✗ Kubernetes组件安装失败，请检查网络连接和仓库配置"
+    exit 1
+fi
+
+# 启动kubelet
+echo "=== 启动kubelet服务 ==="
+sudo systemctl enable --now kubelet
+
+# 验证所有组件安装
+echo "=== 验证组件安装 ==="
+echo "检查kubeadm版本..."
+kubeadm version 2>/dev/null || echo "kubeadm版本检查失败"
+echo "检查kubelet版本..."
+kubelet --version 2>/dev/null || echo "kubelet版本检查失败"
+echo "检查kubectl版本..."
+kubectl version --client 2>/dev/null || echo "kubectl版本检查失败"
+echo "检查containerd版本..."
+containerd --version 2>/dev/null || echo "containerd版本检查失败"
+if command -v crictl &> /dev/null; then
+    echo "检查crictl版本..."
+    crictl version 2>/dev/null || echo "crictl版本检查失败"
+fi
+
+# 最终验证
+echo "=== 最终验证Kubernetes组件安装 ==="
+if command -v kubeadm &> /dev/null && command -v kubelet &> /dev/null && command -v kubectl &> /dev/null; then
+    echo "✓ 所有Kubernetes组件已成功安装"
+else
+    echo "⚠ 部分Kubernetes组件安装失败，请检查安装日志"
+fi`
+
+// suseComponentsTemplate 安装kubelet、kubeadm和kubectl（openSUSE/SLES）
+const suseComponentsTemplate = `# 安装Kubernetes组件（openSUSE/SLES）
+echo "=== 添加Kubernetes仓库 ==="
+if ! sudo zypper --non-interactive repos kubernetes > /dev/null 2>&1; then
+    sudo rpm --import ${k8s_repo_rpm}repodata/repomd.xml.key
+    sudo zypper --non-interactive addrepo --refresh ${k8s_repo_rpm} kubernetes
+fi
+sudo zypper --non-interactive --gpg-auto-import-keys refresh kubernetes
+
+# 检查可用的Kubernetes版本
+echo "=== 检查可用的Kubernetes版本 ==="
+AVAILABLE_VERSIONS=$(sudo zypper --non-interactive search -s -r kubernetes --match-exact kubelet 2>/dev/null | awk -F'|' '/kubelet/ {gsub(/ /, "", $4); print $4}' | cut -d'-' -f1 | sort -V | uniq)
+echo "可用的Kubernetes版本: $AVAILABLE_VERSIONS"
+
+SELECTED_VERSION="${version}"
+if ! echo "$AVAILABLE_VERSIONS" | grep -q "^$SELECTED_VERSION$"; then
+    LATEST_VERSION=$(echo "$AVAILABLE_VERSIONS" | tail -1)
+    if [ -n "$LATEST_VERSION" ]; then
+        echo "指定版本 $SELECTED_VERSION 不可用，使用可用的最新版本: $LATEST_VERSION"
+        SELECTED_VERSION="$LATEST_VERSION"
+    fi
+fi
+
+# 安装Kubernetes组件，失败后的重试由部署流程的重试策略控制
+echo "=== 安装kubelet、kubeadm和kubectl $SELECTED_VERSION ==="
+if sudo zypper --non-interactive install -y kubelet-$SELECTED_VERSION kubeadm-$SELECTED_VERSION kubectl-$SELECTED_VERSION; then
+    echo "✓ 安装成功（使用指定版本）"
+elif sudo zypper --non-interactive install -y kubelet kubeadm kubectl; then
+    echo "✓ 安装成功（使用最新版本）"
+else
+    echo "✗ Kubernetes组件安装失败，请检查网络连接和仓库配置"
+    exit 1
+fi
+
+# 启动kubelet
+echo "=== 启动kubelet服务 ==="
+sudo systemctl enable --now kubelet
+
+# 验证所有组件安装
+echo "=== 验证组件安装 ==="
+kubeadm version 2>/dev/null || echo "kubeadm版本检查失败"
+kubelet --version 2>/dev/null || echo "kubelet版本检查失败"
+kubectl version --client 2>/dev/null || echo "kubectl版本检查失败"
+containerd --version 2>/dev/null || echo "containerd版本检查失败"
+if command -v kubeadm &> /dev/null && command -v kubelet &> /dev/null && command -v kubectl &> /dev/null; then
+    echo "✓ 所有Kubernetes组件已成功安装"
+else
+    echo "✗ 部分Kubernetes组件安装失败，请检查安装日志"
+    exit 1
+fi`
+
+// k8sInitTemplate 执行kubeadm init并配置kubectl和Flannel，部署流程默认使用生成的kubeadm配置，只在自定义时使用
+const k8sInitTemplate = `# 初始化Kubernetes集群
+# 执行kubeadm init
+echo "=== 执行kubeadm init ==="
+sudo kubeadm init --kubernetes-version=${version} --image-repository=registry.aliyuncs.com/google_containers --cri-socket=unix:///run/containerd/containerd.sock --pod-network-cidr=10.244.0.0/16 --upload-certs
+
+# 检查kubeadm init是否成功
+if [ $? -eq 0 ]; then
+    echo "=== kubeadm init 成功 ==="
+    
+    # 配置kubectl
+echo "=== 配置kubectl ==="
+mkdir -p $HOME/.kube
+    
+    # 检查admin.conf是否存在
+    if [ -f /etc/kubernetes/admin.conf ]; then
+        echo "✓ 找到admin.conf文件，正在配置kubectl..."
+        sudo cp -i /etc/kubernetes/admin.conf $HOME/.kube/config
+        sudo chown $(id -u):$(id -g) $HOME/.kube/config
+        echo "✓ kubectl配置成功"
+    else
+        echo "✗ 未找到admin.conf文件，可能初始化过程中出现问题"
+    fi
+    
+    # 安装CNI网络插件（使用Flannel）
+    if [ -f $HOME/.kube/config ]; then
+        echo "=== 安装Flannel网络插件 ==="
+        kubectl apply -f https://github.com/flannel-io/flannel/releases/latest/download/kube-flannel.yml
+    else
+        echo "✗ 无法安装CNI插件，kubectl配置失败"
+    fi
+else
+    echo "✗ kubeadm init 失败"
+    # 显示更多错误信息
+    echo "=== 显示kubeadm日志 ==="
+    sudo journalctl -u kubelet --no-pager -n 50
+fi`
+
+// k8sJoinTemplate Worker节点加入集群，join命令作为第一个参数传入
+const k8sJoinTemplate = `# Worker节点加入集群脚本
+# 执行kubeadm join将Worker节点加入集群
+# 注意：此脚本需要在Master节点上生成join命令后使用
+
+# 检查join命令是否提供
+if [ -z "$1" ]; then
+    echo "错误：请提供从Master节点获取的join命令作为参数"
+    echo "例如：bash k8s_join.sh 'kubeadm join 192.168.1.100:6443 --token xxx --discovery-token-ca-cert-hash xxx'"
+    exit 1
+fi
+
+JOIN_COMMAND="$1"
+echo "=== 执行kubeadm join命令 ==="
+echo "执行命令：$JOIN_COMMAND"
+
+# 执行join命令
+sudo $JOIN_COMMAND
+
+# 检查join是否成功
+if [ $? -eq 0 ]; then
+    echo "=== Worker节点加入集群成功 ==="
+    echo "✓ Worker节点已成功加入Kubernetes集群"
+    echo ""
+    echo "提示：您可以在Master节点上使用以下命令验证节点是否加入成功："
+    echo "kubectl get nodes"
+else
+    echo "✗ Worker节点加入集群失败"
+    # 显示更多错误信息
+    echo "=== 显示kubelet日志 ==="
+    sudo journalctl -u kubelet --no-pager -n 50
+    exit 1
+fi`
@@ -0,0 +1,18 @@
+package script
+
+// WaitForFunc 定义脚本中的wait_for函数：wait_for <超时秒数> <描述> <命令> [参数...]。
+// 每秒执行一次命令直到命令成功或超时，成功返回0，超时返回1，用于代替固定时长的sleep。
+// 函数中不使用%，可以拼接到fmt.Sprintf的格式字符串中
+const WaitForFunc = `wait_for() {
+    local timeout="$1" desc="$2" start=$SECONDS
+    shift 2
+    until "$@" >/dev/null 2>&1; do
+        if [ $((SECONDS - start)) -ge "$timeout" ]; then
+            echo "✗ 等待${desc}超时（${timeout}秒）"
+            return 1
+        fi
+        sleep 1
+    done
+    echo "✓ ${desc}（等待$((SECONDS - start))秒）"
+}
+`
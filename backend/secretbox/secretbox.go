@@ -0,0 +1,127 @@
+// Package secretbox 使用AES-256-GCM加密保存在数据库中的敏感数据，如安装器的SSH私钥。
+// 密钥通过环境变量提供，或保存在数据目录中单独的密钥文件里，只拿到数据库文件无法解密
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvKey 提供密钥的环境变量，值为32字节密钥的base64编码，可用openssl rand -base64 32生成
+const EnvKey = "K8S_INSTALLER_SECRET_KEY"
+
+// keySize AES-256密钥长度
+const keySize = 32
+
+// sealedPrefix 加密后的值的前缀，用于区分升级前保存的明文
+const sealedPrefix = "enc:v1:"
+
+// ErrDecrypt 密钥不匹配或数据损坏
+var ErrDecrypt = errors.New("secretbox: failed to decrypt, the secret key does not match or the data is corrupted")
+
+// Box 加密和解密字符串
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New 使用32字节密钥创建Box
+func New(key []byte) (*Box, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("secretbox: key must be %d bytes, got %d", keySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Load 读取密钥创建Box：优先使用环境变量EnvKey，否则读取path，文件不存在时生成新密钥并以0600权限写入
+func Load(path string) (*Box, error) {
+	if value, ok := os.LookupEnv(EnvKey); ok {
+		key, err := decodeKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvKey, err)
+		}
+		return New(key)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key := make([]byte, keySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, fmt.Errorf("secretbox: failed to create key directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("secretbox: failed to write key file: %v", err)
+		}
+		return New(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: failed to read key file: %v", err)
+	}
+	key, err := decodeKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key file %s: %v", path, err)
+	}
+	return New(key)
+}
+
+// decodeKey 解析base64编码的密钥
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// Sealed 值是否为Seal加密后的格式
+func Sealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Seal 加密字符串，输出格式：前缀 + base64(nonce | 密文)
+func (b *Box) Seal(plain string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plain), []byte(sealedPrefix))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密Seal生成的字符串
+func (b *Box) Open(value string) (string, error) {
+	if !Sealed(value) {
+		return "", errors.New("secretbox: value is not sealed")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, []byte(sealedPrefix))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"strings"
+	"sync"
+)
+
+// Runner 在节点上执行命令和上传文件，由*SSHClient实现，测试部署逻辑时使用Recorder代替真实节点
+type Runner interface {
+	RunCommand(cmd string) (string, error)
+	// RunCommandSilent 执行命令但不写入日志，用于探测和读取状态
+	RunCommandSilent(cmd string) (string, error)
+	RunCommandWithOutput(cmd string, callback OutputCallback) (string, error)
+	Upload(remotePath string, content []byte) error
+}
+
+var _ Runner = (*SSHClient)(nil)
+
+// recorderRule 命令包含match时返回的结果
+type recorderRule struct {
+	match  string
+	output string
+	err    error
+}
+
+// Recorder 记录执行的命令和上传的文件，按注册的规则返回命令输出，没有匹配的规则时返回空输出
+type Recorder struct {
+	mutex    sync.Mutex
+	rules    []recorderRule
+	commands []string
+	uploads  map[string][]byte
+}
+
+// NewRecorder 创建命令记录器
+func NewRecorder() *Recorder {
+	return &Recorder{uploads: make(map[string][]byte)}
+}
+
+// On 命令包含match时返回output和err，按注册顺序使用第一个匹配的规则
+func (r *Recorder) On(match, output string, err error) *Recorder {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.rules = append(r.rules, recorderRule{match: match, output: output, err: err})
+	return r
+}
+
+// RunCommand 记录命令并返回匹配规则的结果
+func (r *Recorder) RunCommand(cmd string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.commands = append(r.commands, cmd)
+	for _, rule := range r.rules {
+		if strings.Contains(cmd, rule.match) {
+			return rule.output, rule.err
+		}
+	}
+	return "", nil
+}
+
+// RunCommandSilent 与RunCommand相同，命令同样被记录
+func (r *Recorder) RunCommandSilent(cmd string) (string, error) {
+	return r.RunCommand(cmd)
+}
+
+// RunCommandWithOutput 记录命令，并将匹配规则的输出逐行传给callback
+func (r *Recorder) RunCommandWithOutput(cmd string, callback OutputCallback) (string, error) {
+	output, err := r.RunCommand(cmd)
+	if callback != nil && output != "" {
+		for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+			callback(line)
+		}
+	}
+	return output, err
+}
+
+// Upload 记录上传的文件内容
+func (r *Recorder) Upload(remotePath string, content []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.uploads[remotePath] = append([]byte(nil), content...)
+	return nil
+}
+
+// Commands 返回按顺序执行过的命令
+func (r *Recorder) Commands() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.commands...)
+}
+
+// Uploaded 返回上传到remotePath的文件内容
+func (r *Recorder) Uploaded(remotePath string) ([]byte, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	content, ok := r.uploads[remotePath]
+	return content, ok
+}
@@ -0,0 +1,151 @@
+package ssh
+
+import (
+	"fmt"
+	"k8s-installer/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 凭据引用：env:NAME 读取后端进程的环境变量，file:/path 读取后端主机上的文件，
+// 其他前缀由RegisterSecretProvider注册的凭据提供方解析，如vault:path#field
+const (
+	SecretRefEnv  = "env:"
+	SecretRefFile = "file:"
+)
+
+// SecretProvider 外部凭据提供方，ref为去掉前缀后的部分
+type SecretProvider interface {
+	// ValidateRef 检查引用格式，不访问外部服务
+	ValidateRef(ref string) error
+	// Resolve 读取引用的凭据
+	Resolve(ref string) (string, error)
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = make(map[string]SecretProvider)
+)
+
+// RegisterSecretProvider 注册凭据提供方，scheme为引用前缀（不含冒号），如vault
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[scheme] = provider
+}
+
+// secretProvider 返回引用前缀对应的凭据提供方
+func secretProvider(ref string) (SecretProvider, string, bool) {
+	scheme, rest, found := strings.Cut(ref, ":")
+	if !found {
+		return nil, "", false
+	}
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+	provider, ok := secretProviders[scheme]
+	return provider, rest, ok
+}
+
+// SecretEnvPrefix 可以被引用的环境变量前缀，避免通过引用读取后端的其他环境变量并发送到任意SSH服务器
+const SecretEnvPrefix = "K8S_INSTALLER_SECRET_"
+
+// 可以被引用的文件所在目录，默认为DefaultSecretDir，可通过环境变量修改
+const (
+	SecretDirEnv     = "K8S_INSTALLER_SECRET_DIR"
+	DefaultSecretDir = "/etc/k8s-installer/secrets"
+)
+
+// secretDir 返回可以被引用的文件所在目录
+func secretDir() string {
+	if dir := os.Getenv(SecretDirEnv); dir != "" {
+		return filepath.Clean(dir)
+	}
+	return DefaultSecretDir
+}
+
+// ValidateSecretRef 检查引用格式，环境变量需要以SecretEnvPrefix开头，文件需要位于secretDir中。
+// 只检查格式，不读取引用的值，引用的值在建立SSH连接时读取
+func ValidateSecretRef(ref string) error {
+	switch {
+	case strings.HasPrefix(ref, SecretRefEnv):
+		name := strings.TrimPrefix(ref, SecretRefEnv)
+		if !strings.HasPrefix(name, SecretEnvPrefix) || len(name) == len(SecretEnvPrefix) {
+			return fmt.Errorf("environment variable must start with %s", SecretEnvPrefix)
+		}
+	case strings.HasPrefix(ref, SecretRefFile):
+		path := strings.TrimPrefix(ref, SecretRefFile)
+		dir := secretDir()
+		if !filepath.IsAbs(path) || !strings.HasPrefix(filepath.Clean(path), dir+string(filepath.Separator)) {
+			return fmt.Errorf("file must be an absolute path under %s", dir)
+		}
+	default:
+		if provider, rest, ok := secretProvider(ref); ok {
+			return provider.ValidateRef(rest)
+		}
+		formats := []string{SecretRefEnv + "NAME", SecretRefFile + "/path"}
+		secretProvidersMu.RLock()
+		for scheme := range secretProviders {
+			formats = append(formats, scheme+":...")
+		}
+		secretProvidersMu.RUnlock()
+		return fmt.Errorf("must be one of %s", strings.Join(formats, ", "))
+	}
+	return nil
+}
+
+// ResolveSecretRef 读取引用的凭据，读取到的值登记到日志脱敏
+func ResolveSecretRef(ref string) (string, error) {
+	if err := ValidateSecretRef(ref); err != nil {
+		return "", fmt.Errorf("invalid secret reference %q: %v", ref, err)
+	}
+	var value string
+	switch {
+	case strings.HasPrefix(ref, SecretRefEnv):
+		v, ok := os.LookupEnv(strings.TrimPrefix(ref, SecretRefEnv))
+		if !ok {
+			return "", fmt.Errorf("secret reference %q: environment variable is not set", ref)
+		}
+		value = v
+	case strings.HasPrefix(ref, SecretRefFile):
+		data, err := os.ReadFile(filepath.Clean(strings.TrimPrefix(ref, SecretRefFile)))
+		if err != nil {
+			return "", fmt.Errorf("secret reference %q: %v", ref, err)
+		}
+		value = string(data)
+	default:
+		provider, rest, _ := secretProvider(ref)
+		v, err := provider.Resolve(rest)
+		if err != nil {
+			return "", fmt.Errorf("secret reference %q: %v", ref, err)
+		}
+		value = v
+	}
+	// 文件末尾的换行不属于密码，私钥解析不受影响
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("secret reference %q is empty", ref)
+	}
+	log.RegisterSecret(value)
+	return value, nil
+}
+
+// resolveCredentials 未直接提供密码和私钥时读取引用的凭据
+func resolveCredentials(config *SSHConfig) error {
+	if config.PrivateKey == "" && config.PrivateKeyRef != "" {
+		value, err := ResolveSecretRef(config.PrivateKeyRef)
+		if err != nil {
+			return err
+		}
+		config.PrivateKey = value + "\n"
+	}
+	if config.PrivateKey == "" && config.Password == "" && config.PasswordRef != "" {
+		value, err := ResolveSecretRef(config.PasswordRef)
+		if err != nil {
+			return err
+		}
+		config.Password = value
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Shell 交互式PTY会话
+type Shell struct {
+	session *ssh.Session
+	Stdin   io.WriteCloser
+	Stdout  io.Reader
+}
+
+// StartShell 在远程节点上启动带PTY的交互式shell
+func (c *SSHClient) StartShell(term string, cols, rows int) (*Shell, error) {
+	if term == "" {
+		term = "xterm-256color"
+	}
+	if cols <= 0 {
+		cols = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(term, rows, cols, modes); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to request pty: %v", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to get stdin pipe: %v", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
+	}
+
+	// PTY模式下远程进程的stderr也写入终端，通过stdout读取
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to start shell: %v", err)
+	}
+
+	return &Shell{
+		session: session,
+		Stdin:   stdin,
+		Stdout:  stdout,
+	}, nil
+}
+
+// Resize 调整终端窗口大小
+func (s *Shell) Resize(cols, rows int) error {
+	return s.session.WindowChange(rows, cols)
+}
+
+// Wait 等待shell退出
+func (s *Shell) Wait() error {
+	return s.session.Wait()
+}
+
+// Close 关闭shell会话
+func (s *Shell) Close() error {
+	return s.session.Close()
+}
@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
+	"k8s-installer/errcode"
 	"k8s-installer/log"
+	"k8s-installer/metrics"
+	"os"
 	"strings"
 	"time"
 
@@ -22,6 +24,86 @@ type SSHClient struct {
 	}
 	nodeID   string
 	nodeName string
+	// ctx 命令执行的父上下文，用于部署取消和步骤级超时
+	ctx context.Context
+	// commandTimeout 单条命令的超时时间
+	commandTimeout time.Duration
+}
+
+// DefaultCommandTimeout 默认命令超时时间（60分钟），适应Kubernetes组件安装的耗时过程
+const DefaultCommandTimeout = 60 * time.Minute
+
+// TimeoutError 命令执行超时错误
+type TimeoutError struct {
+	Cmd     string
+	Timeout time.Duration
+	// StepDeadline 为true表示超出的是步骤级的截止时间
+	StepDeadline bool
+	Stdout       string
+	Stderr       string
+}
+
+func (e *TimeoutError) Error() string {
+	msg := fmt.Sprintf("command timed out after %v: %s", e.Timeout, e.Cmd)
+	if e.StepDeadline {
+		msg = fmt.Sprintf("step deadline exceeded while running command: %s", e.Cmd)
+	}
+	if e.Stdout != "" || e.Stderr != "" {
+		msg += fmt.Sprintf("\nStdout: %s\nStderr: %s", e.Stdout, e.Stderr)
+	}
+	return msg
+}
+
+// IsTimeout 判断错误是否为命令超时
+func IsTimeout(err error) bool {
+	_, ok := err.(*TimeoutError)
+	return ok
+}
+
+// SetContext 设置命令执行的父上下文，上下文取消或到期时正在执行的命令会被终止
+func (c *SSHClient) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// SetCommandTimeout 设置单条命令的超时时间，小于等于0时使用默认值
+func (c *SSHClient) SetCommandTimeout(timeout time.Duration) {
+	c.commandTimeout = timeout
+}
+
+// wait 在超时和上下文控制下执行会话命令，超时后终止远程命令
+func (c *SSHClient) wait(session *ssh.Session, cmd string, run func() error) error {
+	parent := c.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	timeout := c.commandTimeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// 终止远程命令并关闭会话，使run返回
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+		if parent.Err() == context.Canceled {
+			return parent.Err()
+		}
+		return &TimeoutError{Cmd: cmd, Timeout: timeout, StepDeadline: parent.Err() == context.DeadlineExceeded}
+	}
 }
 
 // OutputCallback 实时输出回调函数
@@ -42,6 +124,9 @@ func (c *SSHClient) SetNodeInfo(nodeID, nodeName string) {
 
 // NewSSHClient 创建新的SSH客户端
 func NewSSHClient(config SSHConfig) (*SSHClient, error) {
+	if err := resolveCredentials(&config); err != nil {
+		return nil, errcode.New(errcode.SSHAuth, err)
+	}
 	sshConfig := &ssh.ClientConfig{
 		User:            config.Username,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该使用更安全的HostKeyCallback
@@ -53,24 +138,30 @@ func NewSSHClient(config SSHConfig) (*SSHClient, error) {
 		// 使用私钥认证
 		signer, err := ssh.ParsePrivateKey([]byte(config.PrivateKey))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse private key: %v", err)
+			return nil, errcode.New(errcode.SSHAuth, fmt.Errorf("failed to parse private key: %v", err))
 		}
 		sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(signer))
 	} else if config.Password != "" {
 		// 使用密码认证
 		sshConfig.Auth = append(sshConfig.Auth, ssh.Password(config.Password))
 	} else {
-		return nil, fmt.Errorf("either password or privateKey must be provided for SSH connection to %s:%d", config.Host, config.Port)
+		return nil, errcode.New(errcode.SSHAuth, fmt.Errorf("either password or privateKey must be provided for SSH connection to %s:%d", config.Host, config.Port))
 	}
 
 	// 连接到SSH服务器
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	client, err := ssh.Dial("tcp", addr, sshConfig)
 	if err != nil {
-		// 提供更详细的错误信息，包括主机名解析失败的情况
-		return nil, fmt.Errorf("failed to create SSH client: failed to connect to %s:%d: %v", config.Host, config.Port, err)
+		// 提供更详细的错误信息，包括主机名解析失败的情况；认证失败和连接失败使用不同的错误码
+		code := errcode.SSHConnect
+		if errcode.Classify(err.Error()) == errcode.SSHAuth {
+			code = errcode.SSHAuth
+		}
+		return nil, errcode.New(code, fmt.Errorf("failed to create SSH client: failed to connect to %s:%d: %v", config.Host, config.Port, err))
 	}
 
+	metrics.SSHActiveConnections.Inc()
+
 	return &SSHClient{client: client}, nil
 }
 
@@ -81,10 +172,14 @@ type SSHConfig struct {
 	Username   string `json:"username"`
 	Password   string `json:"password,omitempty"`
 	PrivateKey string `json:"privateKey,omitempty"`
+	// PasswordRef PrivateKeyRef 凭据引用，未直接提供密码或私钥时在连接时读取，见ResolveSecretRef
+	PasswordRef   string `json:"passwordRef,omitempty"`
+	PrivateKeyRef string `json:"privateKeyRef,omitempty"`
 }
 
 // Close 关闭SSH连接
 func (c *SSHClient) Close() error {
+	metrics.SSHActiveConnections.Dec()
 	return c.client.Close()
 }
 
@@ -97,10 +192,6 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 	}
 	defer session.Close()
 
-	// 设置命令执行超时（60分钟），适应Kubernetes组件安装的耗时过程
-	ctx, cancel := context.WithTimeout(context.Background(), 3600*time.Second)
-	defer cancel()
-
 	// 执行命令
 	var stdout, stderr bytes.Buffer
 	session.Stdout = &stdout
@@ -128,6 +219,7 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 		Command:   cmd,
 		Output:    fmt.Sprintf("开始执行命令，共 %d 个步骤\n命令: %s\n", len(filteredCmdLines), cmd),
 		Status:    "running",
+		Type:      log.TypeScriptOutput,
 		CreatedAt: executionStartTime,
 		UpdatedAt: executionStartTime,
 	}
@@ -147,6 +239,7 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 			Command:   stepCmd,
 			Output:    fmt.Sprintf("执行第 %d/%d 步: %s\n正在执行: 开始执行命令...", i+1, len(filteredCmdLines), stepCmd),
 			Status:    "running",
+			Type:      log.TypeStep,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -156,7 +249,9 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 		}
 	}
 
-	err = session.Run(cmd)
+	err = c.wait(session, cmd, func() error {
+		return session.Run(cmd)
+	})
 
 	// 记录命令执行结束的时间和耗时
 	executionEndTime := time.Now()
@@ -181,6 +276,7 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 			Command:   stepCmd,
 			Output:    fmt.Sprintf("执行第 %d/%d 步: %s\n执行成功\n", i+1, len(filteredCmdLines), stepCmd),
 			Status:    "success",
+			Type:      log.TypeStep,
 			CreatedAt: executionStartTime,
 			UpdatedAt: executionEndTime,
 		}
@@ -198,6 +294,7 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 	if err != nil {
 		status = "failed"
 	}
+	metrics.SSHCommandDuration.ObserveDuration(executionDuration, status)
 
 	endLogEntry := log.LogEntry{
 		ID:        startLogEntry.ID,
@@ -207,6 +304,7 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 		Command:   cmd,
 		Output:    logOutput,
 		Status:    status,
+		Type:      log.TypeScriptOutput,
 		CreatedAt: executionStartTime,
 		UpdatedAt: executionEndTime,
 	}
@@ -217,14 +315,15 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 	}
 
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("command timed out after 60 minutes: %s\nStdout: %s\nStderr: %s", cmd, stdout.String(), stderr.String())
+		if timeoutErr, ok := err.(*TimeoutError); ok {
+			timeoutErr.Stdout, timeoutErr.Stderr = stdout.String(), stderr.String()
+			return "", timeoutErr
 		}
 		// 区分不同类型的错误
 		if exitErr, ok := err.(*ssh.ExitError); ok {
 			// 检查是否是信号中断
 			if exitErr.Signal() == "TERM" {
-				return "", fmt.Errorf("command was terminated by signal SIGTERM after 60 minutes: %s\nStdout: %s\nStderr: %s", cmd, stdout.String(), stderr.String())
+				return "", fmt.Errorf("command was terminated by signal SIGTERM: %s\nStdout: %s\nStderr: %s", cmd, stdout.String(), stderr.String())
 			}
 			return "", fmt.Errorf("command failed with exit code %d: %s\nStdout: %s\nStderr: %s", exitErr.ExitStatus(), cmd, stdout.String(), stderr.String())
 		}
@@ -234,6 +333,23 @@ func (c *SSHClient) RunCommand(cmd string) (string, error) {
 	return stdout.String(), nil
 }
 
+// RunCommandSilent 执行SSH命令，不写入日志也不打印到控制台，用于心跳等高频的轻量探测
+func (c *SSHClient) RunCommandSilent(cmd string) (string, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := c.wait(session, cmd, func() error { return session.Run(cmd) }); err != nil {
+		return stdout.String(), fmt.Errorf("command failed: %v\nStderr: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
 // RunCommandWithOutput 执行SSH命令并实时输出结果
 func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (string, error) {
 	// 创建SSH会话
@@ -243,10 +359,6 @@ func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (s
 	}
 	defer session.Close()
 
-	// 设置命令执行超时（60分钟）
-	ctx, cancel := context.WithTimeout(context.Background(), 3600*time.Second)
-	defer cancel()
-
 	// 获取会话的标准输出和标准错误
 	stdoutPipe, err := session.StdoutPipe()
 	if err != nil {
@@ -270,6 +382,7 @@ func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (s
 		Command:   cmd,
 		Output:    "命令开始执行...",
 		Status:    "running",
+		Type:      log.TypeScriptOutput,
 		CreatedAt: executionStartTime,
 		UpdatedAt: executionStartTime,
 	}
@@ -308,7 +421,7 @@ func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (s
 	}()
 
 	// 等待命令执行完成
-	err = session.Wait()
+	err = c.wait(session, cmd, session.Wait)
 	stdout := stdoutBuf.String()
 	stderr := stderrBuf.String()
 
@@ -345,6 +458,7 @@ func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (s
 	if err != nil {
 		status = "failed"
 	}
+	metrics.SSHCommandDuration.ObserveDuration(executionDuration, status)
 
 	endLogEntry := log.LogEntry{
 		ID:        startLogEntry.ID,
@@ -354,6 +468,7 @@ func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (s
 		Command:   cmd,
 		Output:    logOutput,
 		Status:    status,
+		Type:      log.TypeScriptOutput,
 		CreatedAt: executionStartTime,
 		UpdatedAt: executionEndTime,
 	}
@@ -364,14 +479,15 @@ func (c *SSHClient) RunCommandWithOutput(cmd string, callback OutputCallback) (s
 	}
 
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return stdout, fmt.Errorf("command timed out after 60 minutes: %s\nStdout: %s\nStderr: %s", cmd, stdout, stderr)
+		if timeoutErr, ok := err.(*TimeoutError); ok {
+			timeoutErr.Stdout, timeoutErr.Stderr = stdout, stderr
+			return stdout, timeoutErr
 		}
 		// 区分不同类型的错误
 		if exitErr, ok := err.(*ssh.ExitError); ok {
 			// 检查是否是信号中断
 			if exitErr.Signal() == "TERM" {
-				return stdout, fmt.Errorf("command was terminated by signal SIGTERM after 60 minutes: %s\nStdout: %s\nStderr: %s", cmd, stdout, stderr)
+				return stdout, fmt.Errorf("command was terminated by signal SIGTERM: %s\nStdout: %s\nStderr: %s", cmd, stdout, stderr)
 			}
 			return stdout, fmt.Errorf("command failed with exit code %d: %s\nStdout: %s\nStderr: %s", exitErr.ExitStatus(), cmd, stdout, stderr)
 		}
@@ -390,11 +506,12 @@ func (c *SSHClient) UploadFile(localPath, remotePath string) error {
 	}
 	defer sftpClient.Close()
 
-	// 读取本地文件
-	localFile, err := ioutil.ReadFile(localPath)
+	// 打开本地文件，流式写入远程文件，避免大文件（如镜像归档）整个读入内存
+	localFile, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to read local file: %v", err)
 	}
+	defer localFile.Close()
 
 	// 写入远程文件
 	remoteFile, err := sftpClient.Create(remotePath)
@@ -403,14 +520,33 @@ func (c *SSHClient) UploadFile(localPath, remotePath string) error {
 	}
 	defer remoteFile.Close()
 
-	_, err = remoteFile.Write(localFile)
-	if err != nil {
+	if _, err := remoteFile.ReadFrom(localFile); err != nil {
 		return fmt.Errorf("failed to write remote file: %v", err)
 	}
 
 	return nil
 }
 
+// Upload 通过SFTP将内容写入远程文件
+func (c *SSHClient) Upload(remotePath string, content []byte) error {
+	sftpClient, err := sftp.NewClient(c.client)
+	if err != nil {
+		return fmt.Errorf("failed to create SFTP client: %v", err)
+	}
+	defer sftpClient.Close()
+
+	remoteFile, err := sftpClient.Create(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file: %v", err)
+	}
+	defer remoteFile.Close()
+
+	if _, err := remoteFile.Write(content); err != nil {
+		return fmt.Errorf("failed to write remote file: %v", err)
+	}
+	return nil
+}
+
 // DownloadFile 从远程服务器下载文件
 func (c *SSHClient) DownloadFile(remotePath, localPath string) error {
 	// 创建SFTP客户端
@@ -427,16 +563,15 @@ func (c *SSHClient) DownloadFile(remotePath, localPath string) error {
 	}
 	defer remoteFile.Close()
 
-	// 读取文件内容
-	content, err := ioutil.ReadAll(remoteFile)
+	// 流式写入本地文件
+	localFile, err := os.OpenFile(localPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to read remote file: %v", err)
+		return fmt.Errorf("failed to write local file: %v", err)
 	}
+	defer localFile.Close()
 
-	// 写入本地文件
-	err = ioutil.WriteFile(localPath, content, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write local file: %v", err)
+	if _, err := remoteFile.WriteTo(localFile); err != nil {
+		return fmt.Errorf("failed to read remote file: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,245 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors 一组字段校验错误
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// AsErrors 提取err中的字段校验错误
+func AsErrors(err error) (Errors, bool) {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+	return nil, false
+}
+
+var (
+	// dnsLabelPattern RFC 1123 标签：小写字母、数字和'-'，以字母或数字开头和结尾
+	dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// versionPattern Kubernetes版本号，如 1.30、v1.30.2、1.30.2-00
+	versionPattern = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?([-+][0-9A-Za-z.-]+)?$`)
+	// labelNamePattern Kubernetes标签名（不含前缀）和标签值
+	labelNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+)
+
+// Validator 收集字段校验错误，空值由调用方决定是否允许，各检查方法对空值直接跳过
+type Validator struct {
+	errs Errors
+}
+
+// Add 记录一个字段错误
+func (v *Validator) Add(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Merge 合并其他校验结果，字段名加上prefix前缀；err不是字段校验错误时作为prefix字段的错误记录
+func (v *Validator) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	errs, ok := AsErrors(err)
+	if !ok {
+		v.Add(prefix, "%v", err)
+		return
+	}
+	for _, fe := range errs {
+		if prefix != "" {
+			fe.Field = prefix + "." + fe.Field
+		}
+		v.errs = append(v.errs, fe)
+	}
+}
+
+// Err 返回收集到的错误，没有错误时返回nil
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Required 检查字段非空
+func (v *Validator) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// IP 检查IPv4或IPv6地址
+func (v *Validator) IP(field, value string) {
+	if value != "" && net.ParseIP(value) == nil {
+		v.Add(field, "%q is not a valid IP address", value)
+	}
+}
+
+// Port 检查端口范围，0表示使用默认端口
+func (v *Validator) Port(field string, port int) {
+	if port < 0 || port > 65535 {
+		v.Add(field, "%d is out of range 1-65535", port)
+	}
+}
+
+// DNSSubdomain 检查RFC 1123子域名，Kubernetes节点名称需要满足该格式
+func (v *Validator) DNSSubdomain(field, value string) {
+	if value == "" {
+		return
+	}
+	if len(value) > 253 {
+		v.Add(field, "must be no more than 253 characters")
+		return
+	}
+	for _, label := range strings.Split(value, ".") {
+		if len(label) > 63 || !dnsLabelPattern.MatchString(label) {
+			v.Add(field, "%q must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (RFC 1123)", value)
+			return
+		}
+	}
+}
+
+// CIDR 检查CIDR网段，如 10.244.0.0/16，多个网段（双栈）使用逗号分隔
+func (v *Validator) CIDR(field, value string) {
+	if value == "" {
+		return
+	}
+	for _, cidr := range strings.Split(value, ",") {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+			v.Add(field, "%q is not a valid CIDR", cidr)
+			return
+		}
+	}
+}
+
+// Version 检查版本号，如 1.30.2 或 v1.30.2
+func (v *Validator) Version(field, value string) {
+	if value != "" && !versionPattern.MatchString(value) {
+		v.Add(field, "%q is not a valid version, expected format like v1.30.2", value)
+	}
+}
+
+// HostPort 检查 主机[:端口] 格式的地址，主机可以是IP或域名
+func (v *Validator) HostPort(field, value string) {
+	if value == "" {
+		return
+	}
+	host := value
+	if h, p, err := net.SplitHostPort(value); err == nil {
+		host = h
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			v.Add(field, "%q has an invalid port", value)
+			return
+		}
+	} else if strings.Count(value, ":") == 1 {
+		v.Add(field, "%q is not a valid host:port address", value)
+		return
+	}
+	host = strings.Trim(host, "[]")
+	if net.ParseIP(host) != nil {
+		return
+	}
+	sub := Validator{}
+	sub.DNSSubdomain(field, strings.ToLower(host))
+	if len(sub.errs) > 0 {
+		v.Add(field, "%q is not a valid IP address or host name", value)
+	}
+}
+
+// Label 检查Kubernetes标签的键和值，键可以带有DNS子域名前缀，如 node.example.com/gpu
+func (v *Validator) Label(field, key, value string) {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		sub := Validator{}
+		sub.DNSSubdomain(field, key[:i])
+		if i == 0 || len(sub.errs) > 0 {
+			v.Add(field, "label key %q has an invalid prefix", key)
+			return
+		}
+		name = key[i+1:]
+	}
+	if len(name) > 63 || !labelNamePattern.MatchString(name) {
+		v.Add(field, "label key %q must be 63 characters or less, consist of alphanumeric characters, '-', '_' or '.'", key)
+		return
+	}
+	if value != "" && (len(value) > 63 || !labelNamePattern.MatchString(value)) {
+		v.Add(field, "label value %q must be 63 characters or less, consist of alphanumeric characters, '-', '_' or '.'", value)
+	}
+}
+
+// ProxyURL 检查代理地址，只允许http、https和socks5协议，且不能包含空白和引号
+func (v *Validator) ProxyURL(field, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || strings.ContainsAny(value, " \t\n'\"`$\\") {
+		v.Add(field, "%q is not a valid proxy URL", value)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+		v.Add(field, "proxy URL scheme must be http, https or socks5")
+	}
+}
+
+// MirrorURL 检查软件仓库镜像地址，只允许http和https协议，地址会写入节点上执行的脚本，不能包含空白和引号
+func (v *Validator) MirrorURL(field, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || strings.ContainsAny(value, " \t\n'\"`$\\") {
+		v.Add(field, "%q is not a valid mirror URL", value)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		v.Add(field, "mirror URL scheme must be http or https")
+	}
+}
+
+// SAN 检查证书的主题备用名称，可以是IP地址、域名或通配符域名，如 *.example.com
+func (v *Validator) SAN(field, value string) {
+	if value == "" {
+		v.Add(field, "must not be empty")
+		return
+	}
+	if net.ParseIP(value) != nil {
+		return
+	}
+	sub := Validator{}
+	sub.DNSSubdomain(field, strings.TrimPrefix(value, "*."))
+	if len(sub.errs) > 0 {
+		v.Add(field, "%q is not a valid IP address or DNS name", value)
+	}
+}
+
+// MaxLength 检查字段的字符数不超过max
+func (v *Validator) MaxLength(field, value string, max int) {
+	if n := utf8.RuneCountInString(value); n > max {
+		v.Add(field, "must be at most %d characters, got %d", max, n)
+	}
+}
@@ -0,0 +1,301 @@
+// Package vault 从HashiCorp Vault读取节点凭据。节点只保存vault:path#field形式的引用，
+// 建立SSH连接时通过Vault HTTP API读取，凭据不写入安装器数据库
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scheme 凭据引用前缀
+const Scheme = "vault"
+
+// Vault认证方式
+const (
+	AuthToken      = "token"
+	AuthAppRole    = "approle"
+	AuthKubernetes = "kubernetes"
+)
+
+// DefaultKubernetesJWTFile 在Kubernetes中运行时ServiceAccount令牌的默认路径
+const DefaultKubernetesJWTFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// tokenRenewMargin 登录获得的令牌在到期前提前重新登录的时间
+const tokenRenewMargin = 30 * time.Second
+
+// Config Vault连接和认证配置，Address为空时不启用Vault
+type Config struct {
+	Address   string `json:"address"`
+	Namespace string `json:"namespace,omitempty"`
+	// AuthMethod 认证方式：token（默认）、approle或kubernetes
+	AuthMethod string `json:"authMethod,omitempty"`
+	// AuthMount 认证方式的挂载路径，为空时与AuthMethod相同
+	AuthMount string `json:"authMount,omitempty"`
+	// Token token认证使用的令牌，建议通过环境变量提供
+	Token string `json:"token,omitempty"`
+	// RoleID SecretID approle认证的角色ID和SecretID，SecretIDFile不为空时从文件读取SecretID
+	RoleID       string `json:"roleId,omitempty"`
+	SecretID     string `json:"secretId,omitempty"`
+	SecretIDFile string `json:"secretIdFile,omitempty"`
+	// Role JWTFile kubernetes认证的角色和ServiceAccount令牌文件
+	Role    string `json:"role,omitempty"`
+	JWTFile string `json:"jwtFile,omitempty"`
+	// CACertFile 校验Vault服务端证书的CA，为空时使用系统CA
+	CACertFile string `json:"caCertFile,omitempty"`
+	// TimeoutSeconds 单次请求超时时间，默认10秒
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// Enabled 是否配置了Vault
+func (c Config) Enabled() bool {
+	return c.Address != ""
+}
+
+// method 返回认证方式，未设置时为token
+func (c Config) method() string {
+	if c.AuthMethod == "" {
+		return AuthToken
+	}
+	return c.AuthMethod
+}
+
+// Validate 检查地址和认证方式需要的参数
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if !strings.HasPrefix(c.Address, "http://") && !strings.HasPrefix(c.Address, "https://") {
+		return fmt.Errorf("vault: invalid address %q, expected http(s)://host:port", c.Address)
+	}
+	switch c.method() {
+	case AuthToken:
+		if c.Token == "" {
+			return errors.New("vault: token is required for token auth")
+		}
+	case AuthAppRole:
+		if c.RoleID == "" || (c.SecretID == "" && c.SecretIDFile == "") {
+			return errors.New("vault: roleId and secretId or secretIdFile are required for approle auth")
+		}
+	case AuthKubernetes:
+		if c.Role == "" {
+			return errors.New("vault: role is required for kubernetes auth")
+		}
+	default:
+		return fmt.Errorf("vault: unsupported auth method %q, expected %s, %s or %s", c.AuthMethod, AuthToken, AuthAppRole, AuthKubernetes)
+	}
+	if c.TimeoutSeconds < 0 {
+		return fmt.Errorf("vault: timeoutSeconds must not be negative")
+	}
+	return nil
+}
+
+// Client Vault客户端，实现ssh.SecretProvider。approle和kubernetes认证获得的令牌缓存到过期前
+type Client struct {
+	config Config
+	http   *http.Client
+
+	mutex       sync.Mutex
+	token       string
+	tokenExpiry time.Time // 为零值表示令牌不过期
+}
+
+// NewClient 创建Vault客户端，不会立即登录
+func NewClient(config Config) (*Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to read CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("vault: no certificates found in %s", config.CACertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	timeout := time.Duration(config.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	config.Address = strings.TrimSuffix(config.Address, "/")
+	return &Client{config: config, http: &http.Client{Transport: transport, Timeout: timeout}}, nil
+}
+
+// parseRef 拆分path#field形式的引用
+func parseRef(ref string) (path, field string, err error) {
+	path, field, found := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if !found || path == "" || field == "" {
+		return "", "", fmt.Errorf("must be vault:<path>#<field>, e.g. vault:secret/data/nodes/web1#privateKey")
+	}
+	return path, field, nil
+}
+
+// ValidateRef 检查引用格式
+func (c *Client) ValidateRef(ref string) error {
+	_, _, err := parseRef(ref)
+	return err
+}
+
+// Resolve 读取secret中的字段，同时支持KV v2（data.data）和KV v1（data）
+func (c *Client) Resolve(ref string) (string, error) {
+	path, field, err := parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	token, err := c.loginToken()
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	status, err := c.do(http.MethodGet, "/v1/"+path, token, nil, &secret)
+	if status == http.StatusForbidden {
+		// 缓存的令牌可能已被撤销，下次读取时重新登录
+		c.resetToken()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, isV2 := data["metadata"]; isV2 {
+			data = nested
+		}
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: field %q not found in %s", field, path)
+	}
+	return value, nil
+}
+
+// loginToken 返回访问Vault的令牌，approle和kubernetes认证在令牌不存在或即将过期时重新登录
+func (c *Client) loginToken() (string, error) {
+	if c.config.method() == AuthToken {
+		return c.config.Token, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token != "" && (c.tokenExpiry.IsZero() || time.Now().Before(c.tokenExpiry)) {
+		return c.token, nil
+	}
+
+	body := map[string]string{}
+	switch c.config.method() {
+	case AuthAppRole:
+		secretID := c.config.SecretID
+		if c.config.SecretIDFile != "" {
+			data, err := os.ReadFile(c.config.SecretIDFile)
+			if err != nil {
+				return "", fmt.Errorf("vault: failed to read secret id: %v", err)
+			}
+			secretID = strings.TrimSpace(string(data))
+		}
+		body["role_id"], body["secret_id"] = c.config.RoleID, secretID
+	case AuthKubernetes:
+		jwtFile := c.config.JWTFile
+		if jwtFile == "" {
+			jwtFile = DefaultKubernetesJWTFile
+		}
+		jwt, err := os.ReadFile(jwtFile)
+		if err != nil {
+			return "", fmt.Errorf("vault: failed to read service account token: %v", err)
+		}
+		body["role"], body["jwt"] = c.config.Role, strings.TrimSpace(string(jwt))
+	}
+
+	mount := c.config.AuthMount
+	if mount == "" {
+		mount = c.config.method()
+	}
+	var login struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if _, err := c.do(http.MethodPost, "/v1/auth/"+strings.Trim(mount, "/")+"/login", "", body, &login); err != nil {
+		return "", fmt.Errorf("vault: %s login failed: %v", c.config.method(), err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault: %s login returned no token", c.config.method())
+	}
+	c.token = login.Auth.ClientToken
+	c.tokenExpiry = time.Time{}
+	if login.Auth.LeaseDuration > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(login.Auth.LeaseDuration)*time.Second - tokenRenewMargin)
+	}
+	return c.token, nil
+}
+
+// resetToken 丢弃缓存的登录令牌
+func (c *Client) resetToken() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.token = ""
+}
+
+// do 发送Vault API请求并解析JSON响应，返回HTTP状态码
+func (c *Client) do(method, path, token string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.config.Address+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("vault: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("vault: failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// 错误响应只包含errors字段，不会包含凭据
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return resp.StatusCode, fmt.Errorf("vault: %s %s returned %d: %s", method, path, resp.StatusCode, strings.Join(apiErr.Errors, "; "))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("vault: invalid response: %v", err)
+	}
+	return resp.StatusCode, nil
+}
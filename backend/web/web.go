@@ -0,0 +1,60 @@
+package web
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"k8s-installer/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dist 前端构建产物，由frontend目录下的npm run build生成到web/dist
+//
+//go:embed all:dist
+var dist embed.FS
+
+// errUINotBuilt 编译时没有前端构建产物
+var errUINotBuilt = errors.New("web UI is not built, run npm run build in frontend and rebuild the backend")
+
+// assetsDir vite生成的带内容哈希的静态资源目录，可以长期缓存
+const assetsDir = "assets/"
+
+// Register 在没有匹配路由的GET请求上提供内嵌的前端页面：存在的文件直接返回，其他路径返回index.html，
+// 由前端处理路由；接口路径仍然返回JSON格式的404
+func Register(r *gin.Engine) {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	index, _ := fs.ReadFile(files, "index.html")
+	fileServer := http.FileServer(http.FS(files))
+
+	r.NoRoute(func(c *gin.Context) {
+		if (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) || strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			api.Error(c, http.StatusNotFound, errors.New("route not found"))
+			return
+		}
+		if index == nil {
+			api.Error(c, http.StatusNotFound, errUINotBuilt)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(c.Request.URL.Path), "/")
+		if info, err := fs.Stat(files, name); err == nil && !info.IsDir() && name != "index.html" {
+			if strings.HasPrefix(name, assetsDir) {
+				c.Header("Cache-Control", "public, max-age=31536000, immutable")
+			}
+			fileServer.ServeHTTP(c.Writer, c.Request)
+			return
+		}
+
+		// 单页应用回退到index.html，index.html不缓存，确保升级后加载新的静态资源
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+}
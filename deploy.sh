@@ -5,6 +5,9 @@
 
 set -e
 
+# 脚本所在目录，构建和systemd服务使用绝对路径
+SCRIPT_DIR="$(cd "$(dirname "$0")" && pwd)"
+
 # 颜色定义
 RED='\033[0;31m'
 GREEN='\033[0;32m'
@@ -241,7 +244,7 @@ EOF
 build_backend() {
     echo -e "${YELLOW}正在构建后端服务...${NC}"
     
-    cd "$(dirname "$0")/backend"
+    cd "$SCRIPT_DIR/backend"
     
     # 设置Go环境变量
     source /etc/profile.d/go.sh
@@ -249,17 +252,17 @@ build_backend() {
     # 下载依赖
     go mod tidy
     
-    # 构建
-    go build -o k8s-installer-backend main.go
+    # 构建，前端页面需要先构建，通过go:embed内嵌到程序中
+    go build -o k8s-installer-backend .
     
     echo -e "${GREEN}后端服务构建完成${NC}"
 }
 
-# 构建前端服务
+# 构建前端页面，构建产物输出到backend/web/dist，由后端内嵌
 build_frontend() {
-    echo -e "${YELLOW}正在构建前端服务...${NC}"
+    echo -e "${YELLOW}正在构建前端页面...${NC}"
     
-    cd "$(dirname "$0")/frontend"
+    cd "$SCRIPT_DIR/frontend"
     
     # 安装依赖
     npm install
@@ -267,7 +270,7 @@ build_frontend() {
     # 构建
     npm run build
     
-    echo -e "${GREEN}前端服务构建完成${NC}"
+    echo -e "${GREEN}前端页面构建完成${NC}"
 }
 
 # 创建systemd服务文件
@@ -283,8 +286,8 @@ Requires=docker.service
 
 [Service]
 Type=simple
-WorkingDirectory=$(dirname "$0")/backend
-ExecStart=$(dirname "$0")/backend/k8s-installer-backend
+WorkingDirectory=$SCRIPT_DIR/backend
+ExecStart=$SCRIPT_DIR/backend/k8s-installer-backend
 Restart=always
 RestartSec=5
 
@@ -314,12 +317,11 @@ show_completion() {
     echo -e "${GREEN}=====================================${NC}"
     echo -e "${GREEN}   部署完成!   ${NC}"
     echo -e "${GREEN}=====================================${NC}"
-    echo -e "${YELLOW}后端服务地址: http://localhost:8080${NC}"
-    echo -e "${YELLOW}前端访问地址: http://localhost:5173${NC}"
+    echo -e "${YELLOW}访问地址: http://localhost:8080${NC}"
     echo -e "${YELLOW}API文档: http://localhost:8080/api/health${NC}"
     echo -e "${GREEN}=====================================${NC}"
     echo -e "${GREEN}使用说明:${NC}"
-    echo -e "1. 打开浏览器访问 http://localhost:5173"
+    echo -e "1. 打开浏览器访问 http://localhost:8080"
     echo -e "2. 输入节点IP地址和Kubernetes版本"
     echo -e "3. 点击'初始化集群'按钮开始部署"
     echo -e "4. 部署完成后，可获取工作节点加入命令"
@@ -334,8 +336,8 @@ main() {
     install_nodejs
     install_docker
     install_kubeadm
-    build_backend
     build_frontend
+    build_backend
     create_systemd_service
     start_services
     show_completion
@@ -0,0 +1,30 @@
+// 当前项目，保存在localStorage中；不同团队的节点、集群、脚本和日志按项目隔离，
+// 接口请求通过X-Project-ID请求头指定项目，EventSource无法设置请求头，通过project查询参数指定
+const STORAGE_KEY = 'k8s-installer-project'
+
+export const DEFAULT_PROJECT = 'default'
+
+export const currentProject = () => localStorage.getItem(STORAGE_KEY) || DEFAULT_PROJECT
+
+export const setCurrentProject = (id) => {
+  if (id && id !== DEFAULT_PROJECT) {
+    localStorage.setItem(STORAGE_KEY, id)
+  } else {
+    localStorage.removeItem(STORAGE_KEY)
+  }
+}
+
+// 为axios实例的每个请求加上当前项目
+export const withProject = (client) => {
+  client.interceptors.request.use((config) => {
+    config.headers['X-Project-ID'] = currentProject()
+    return config
+  })
+  return client
+}
+
+// SSE地址加上当前项目
+export const withProjectQuery = (url) => {
+  const separator = url.includes('?') ? '&' : '?'
+  return `${url}${separator}project=${encodeURIComponent(currentProject())}`
+}
@@ -1,7 +1,30 @@
+import { writeFileSync } from 'node:fs'
+import { fileURLToPath } from 'node:url'
 import { defineConfig } from 'vite'
 import vue from '@vitejs/plugin-vue'
 
+// 构建产物输出到后端的web/dist，由后端通过go:embed内嵌，单个程序同时提供接口和界面
+const outDir = fileURLToPath(new URL('../backend/web/dist', import.meta.url))
+
+// 构建时会清空输出目录，重新写入占位文件，使未构建前端时后端也能编译
+const keepPlaceholder = {
+  name: 'keep-placeholder',
+  closeBundle() {
+    writeFileSync(`${outDir}/.gitkeep`, '')
+  }
+}
+
 // https://vite.dev/config/
 export default defineConfig({
-  plugins: [vue()],
+  plugins: [vue(), keepPlaceholder],
+  build: {
+    outDir,
+    emptyOutDir: true
+  },
+  server: {
+    // 开发服务器将接口请求转发到后端，与内嵌页面一样使用同源的/api/v1
+    proxy: {
+      '/api': 'http://localhost:8080'
+    }
+  }
 })